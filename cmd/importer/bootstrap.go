@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/infra"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/pkg/logger"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// Bootstrap runs a bulk import of historical reports, with args being the command line arguments after `import`.
+func Bootstrap(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	importId := fs.String("id", "", "import id; re-running with the same id resumes from the checkpoint")
+	input := fs.String("input", "", "path of the file to import, with one report record per line; defaults to stdin")
+	errorsPath := fs.String("errors", "import-errors.jsonl", "path of the file to write failed records to")
+	concurrency := fs.Int("concurrency", 8, "number of records processed concurrently")
+	dryRun := fs.Bool("dry-run", false, "process and verify records without queueing them")
+	_ = fs.Parse(args)
+
+	var reportService *service.Report
+	app := fx.New(
+		fx.Provide(config.Parse),
+		infra.Module(),
+		reportverifs.Module(),
+		repo.Module(),
+		service.Module(),
+		fx.Invoke(logger.Configure),
+		fx.Invoke(cache.Initialize),
+		fx.Populate(&reportService),
+		fx.NopLogger,
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize importer")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := app.Start(ctx); err != nil {
+		log.Fatal().Err(err).Msg("failed to start importer")
+	}
+	defer func() {
+		if err := app.Stop(context.Background()); err != nil {
+			log.Error().Err(err).Msg("failed to stop importer")
+		}
+	}()
+
+	r := os.Stdin
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open input file")
+		}
+		defer f.Close()
+		r = f
+	}
+
+	errW, err := os.Create(*errorsPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create errors file")
+	}
+	defer errW.Close()
+
+	summary, err := reportService.ImportReports(ctx, r, errW, &service.ReportImportOptions{
+		ImportID:    *importId,
+		Concurrency: *concurrency,
+		DryRun:      *dryRun,
+	})
+	evt := log.Info()
+	if err != nil {
+		evt = log.Error().Err(err)
+	}
+	if summary != nil {
+		evt = evt.
+			Int64("total", summary.Total).
+			Int64("succeeded", summary.Succeeded).
+			Int64("failed", summary.Failed).
+			Int64("skipped", summary.Skipped)
+	}
+	evt.Bool("dryRun", *dryRun).Msg("report import finished")
+}
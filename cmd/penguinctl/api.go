@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// adminPrefix is the path prefix of the admin API.
+const adminPrefix = "/api/admin"
+
+// apiBackend runs operations through the admin API of an instance of the backend.
+type apiBackend struct {
+	client   *http.Client
+	endpoint string
+	key      string
+}
+
+func newAPIBackend(endpoint string, key string) (*apiBackend, error) {
+	if endpoint == "" {
+		return nil, errors.New("endpoint of the backend is required, by -endpoint or PENGUINCTL_ENDPOINT")
+	}
+	if key == "" {
+		return nil, errors.New("admin key is required, by -key or PENGUINCTL_ADMIN_KEY")
+	}
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, errors.Wrap(err, "invalid endpoint")
+	}
+
+	return &apiBackend{
+		// full server refreshes run within the request, and take minutes
+		client:   &http.Client{Timeout: 30 * time.Minute},
+		endpoint: strings.TrimSuffix(endpoint, "/"),
+		key:      key,
+	}, nil
+}
+
+// do sends a request of method to path of the admin API, with body encoded in JSON if not nil, and returns the
+// response body. Error responses of the backend are returned as *pgerr.PenguinError.
+func (b *apiBackend) do(ctx context.Context, method string, path string, body any) (json.RawMessage, error) {
+	var reqBody io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reqBody = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.endpoint+adminPrefix+path, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.key)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response")
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		penguinErr := pgerr.New(resp.StatusCode, "", "")
+		if err := json.Unmarshal(respBody, penguinErr); err != nil || penguinErr.Message == "" {
+			return nil, errors.Errorf("%s %s: %s: %s", method, path, resp.Status, bytes.TrimSpace(respBody))
+		}
+		return nil, errors.Wrapf(penguinErr, "%s %s: %s", method, path, resp.Status)
+	}
+	return respBody, nil
+}
+
+func (b *apiBackend) PurgeReports(ctx context.Context, req *types.ReportPurgeRequest) (any, error) {
+	return b.do(ctx, http.MethodPost, "/report/purge", req)
+}
+
+func (b *apiBackend) GetReportPurges(ctx context.Context, limit int) (any, error) {
+	return b.do(ctx, http.MethodGet, "/report/purges?limit="+strconv.Itoa(limit), nil)
+}
+
+func (b *apiBackend) RefreshDropMatrix(ctx context.Context, server string) error {
+	_, err := b.do(ctx, http.MethodPost, "/refresh/matrix/"+url.PathEscape(server), nil)
+	return err
+}
+
+func (b *apiBackend) RefreshStageDropMatrix(ctx context.Context, req *types.MatrixRefreshRequest) (any, error) {
+	return b.do(ctx, http.MethodPost, "/matrix/refresh", req)
+}
+
+func (b *apiBackend) GetShadowBans(ctx context.Context) (any, error) {
+	return b.do(ctx, http.MethodGet, "/shadowban", nil)
+}
+
+func (b *apiBackend) CreateShadowBan(ctx context.Context, req *types.ShadowBanRequest) (any, error) {
+	return b.do(ctx, http.MethodPost, "/shadowban", req)
+}
+
+func (b *apiBackend) DeleteShadowBan(ctx context.Context, id int) error {
+	_, err := b.do(ctx, http.MethodDelete, "/shadowban/"+strconv.Itoa(id), nil)
+	return err
+}
+
+func (b *apiBackend) SyncShadowBans(ctx context.Context) error {
+	_, err := b.do(ctx, http.MethodPost, "/shadowban/sync", nil)
+	return err
+}
+
+func (b *apiBackend) GetJobs(ctx context.Context) (any, error) {
+	return b.do(ctx, http.MethodGet, "/jobs", nil)
+}
+
+func (b *apiBackend) GetJobRuns(ctx context.Context, job string, limit int) (any, error) {
+	query := url.Values{"limit": {strconv.Itoa(limit)}}
+	if job != "" {
+		query.Set("job", job)
+	}
+	return b.do(ctx, http.MethodGet, "/jobs/runs?"+query.Encode(), nil)
+}
+
+func (b *apiBackend) TriggerJob(ctx context.Context, name string) (any, error) {
+	return b.do(ctx, http.MethodPost, "/jobs/"+url.PathEscape(name)+"/trigger", nil)
+}
+
+func (b *apiBackend) GetRejectedReportTasks(ctx context.Context) (any, error) {
+	return b.do(ctx, http.MethodGet, "/report/rejected", nil)
+}
+
+func (b *apiBackend) RequeueRejectedReportTask(ctx context.Context, id int) error {
+	_, err := b.do(ctx, http.MethodPost, "/report/rejected/"+strconv.Itoa(id)+"/requeue", nil)
+	return err
+}
+
+func (b *apiBackend) DiscardRejectedReportTask(ctx context.Context, id int) error {
+	_, err := b.do(ctx, http.MethodDelete, "/report/rejected/"+strconv.Itoa(id), nil)
+	return err
+}
+
+func (b *apiBackend) Close() {
+	b.client.CloseIdleConnections()
+}
@@ -0,0 +1,397 @@
+// Command penguinctl runs common operations of the backend, such as purging reports, refreshing drop matrices,
+// managing shadow bans, triggering jobs and inspecting rejected report tasks, through the admin API. With
+// `-maintenance`, operations run directly against the database and the other infrastructure the backend is
+// configured with instead, e.g. while instances of the backend are down.
+//
+// Usage:
+//
+//	penguinctl [-endpoint URL] [-key KEY] [-maintenance] <command> [arguments]
+//
+// The endpoint and the admin key default to environment variables PENGUINCTL_ENDPOINT and PENGUINCTL_ADMIN_KEY.
+// In maintenance mode, the backend is configured by the same environment variables as the service, and operations
+// which change anything are recorded to the audit log as made by `penguinctl:<name of the user running it>`.
+//
+// Commands are:
+//
+//	purge [flags]              purge reports matching a filter, refreshing the drop matrix of the stages affected
+//	purge list [-limit N]      list the latest report purges
+//	refresh matrix <server>    refresh the drop matrix of a server, or of a stage with -stage
+//	ban list                   list active shadow bans
+//	ban create [flags]         shadow ban an account or an IP range
+//	ban delete <id>            lift a shadow ban
+//	ban sync                   republish active shadow bans to Redis
+//	jobs list                  list scheduled jobs of the instance serving the request
+//	jobs runs [-job NAME]      list the latest runs of scheduled jobs
+//	jobs trigger <name>        run a scheduled job right away
+//	dlq list                   list report tasks rejected by the report consumer
+//	dlq requeue <id>           requeue a rejected report task
+//	dlq discard <id>           discard a rejected report task
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+// backend runs operations of penguinctl, either through the admin API or directly in maintenance mode. Results are
+// values encoded in JSON as they are printed.
+type backend interface {
+	PurgeReports(ctx context.Context, req *types.ReportPurgeRequest) (any, error)
+	GetReportPurges(ctx context.Context, limit int) (any, error)
+	RefreshDropMatrix(ctx context.Context, server string) error
+	RefreshStageDropMatrix(ctx context.Context, req *types.MatrixRefreshRequest) (any, error)
+	GetShadowBans(ctx context.Context) (any, error)
+	CreateShadowBan(ctx context.Context, req *types.ShadowBanRequest) (any, error)
+	DeleteShadowBan(ctx context.Context, id int) error
+	SyncShadowBans(ctx context.Context) error
+	GetJobs(ctx context.Context) (any, error)
+	GetJobRuns(ctx context.Context, job string, limit int) (any, error)
+	TriggerJob(ctx context.Context, name string) (any, error)
+	GetRejectedReportTasks(ctx context.Context) (any, error)
+	RequeueRejectedReportTask(ctx context.Context, id int) error
+	DiscardRejectedReportTask(ctx context.Context, id int) error
+	// Close waits for operations running in background to finish, and releases resources of the backend.
+	Close()
+}
+
+// errUsage is returned when a command is invoked with invalid arguments, and usage is printed along.
+var errUsage = errors.New("invalid usage")
+
+func main() {
+	// logs go to stderr, so that results printed to stdout could be piped, e.g. into jq
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr, TimeFormat: time.RFC3339})
+
+	fs := flag.NewFlagSet("penguinctl", flag.ExitOnError)
+	fs.Usage = usage(fs)
+	endpoint := fs.String("endpoint", os.Getenv("PENGUINCTL_ENDPOINT"), "base URL of the backend, e.g. https://penguin-stats.io")
+	key := fs.String("key", os.Getenv("PENGUINCTL_ADMIN_KEY"), "admin key of the backend")
+	maintenance := fs.Bool("maintenance", false, "run operations directly against the database instead of the admin API")
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var (
+		b   backend
+		err error
+	)
+	if *maintenance {
+		b, err = newMaintenanceBackend(ctx)
+	} else {
+		b, err = newAPIBackend(*endpoint, *key)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize penguinctl")
+	}
+
+	err = run(ctx, b, fs.Arg(0), fs.Args()[1:])
+	b.Close()
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal().Err(err).Str("command", strings.Join(fs.Args(), " ")).Msg("command failed")
+	}
+}
+
+func usage(fs *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintln(fs.Output(), "Usage: penguinctl [-endpoint URL] [-key KEY] [-maintenance] <command> [arguments]")
+		fmt.Fprintln(fs.Output(), "\nCommands: purge, purge list, refresh matrix, ban list|create|delete|sync, jobs list|runs|trigger, dlq list|requeue|discard")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+}
+
+func run(ctx context.Context, b backend, command string, args []string) error {
+	switch command {
+	case "purge":
+		return runPurge(ctx, b, args)
+	case "refresh":
+		return runRefresh(ctx, b, args)
+	case "ban":
+		return runBan(ctx, b, args)
+	case "jobs":
+		return runJobs(ctx, b, args)
+	case "dlq":
+		return runDLQ(ctx, b, args)
+	default:
+		return errUsage
+	}
+}
+
+func runPurge(ctx context.Context, b backend, args []string) error {
+	if len(args) > 0 && args[0] == "list" {
+		fs := flag.NewFlagSet("purge list", flag.ExitOnError)
+		limit := fs.Int("limit", 100, "number of purges listed")
+		_ = fs.Parse(args[1:])
+		return printResult(b.GetReportPurges(ctx, *limit))
+	}
+
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	server := fs.String("server", "", "server of the reports, one of CN, US, JP and KR")
+	accountId := fs.Int("account", 0, "id of the account which submitted the reports")
+	ipRange := fs.String("ip", "", "IP range in CIDR notation, or a single IP, the reports were submitted from")
+	source := fs.String("source", "", "source name of the client which submitted the reports")
+	version := fs.String("version", "", "version of the client which submitted the reports; requires -source")
+	stageId := fs.String("stage", "", "ark stage id of the stage of the reports")
+	start := fs.String("start", "", "purges reports submitted since, in RFC 3339 or milliseconds since the epoch")
+	end := fs.String("end", "", "purges reports submitted until, in RFC 3339 or milliseconds since the epoch; defaults to now")
+	reason := fs.String("reason", "", "reason of the purge, recorded along with it")
+	dryRun := fs.Bool("dry-run", false, "only count the reports which would be purged")
+	_ = fs.Parse(args)
+
+	startTime, err := parseTime(*start)
+	if err != nil {
+		return errors.Wrap(err, "invalid -start")
+	}
+	endTime, err := parseTime(*end)
+	if err != nil {
+		return errors.Wrap(err, "invalid -end")
+	}
+	if *server == "" || startTime == 0 || *reason == "" {
+		return errors.Wrap(errUsage, "purge requires -server, -start and -reason")
+	}
+
+	return printResult(b.PurgeReports(ctx, &types.ReportPurgeRequest{
+		Filter: types.ReportPurgeFilter{
+			Server:    *server,
+			AccountID: *accountId,
+			IPRange:   *ipRange,
+			Source:    *source,
+			Version:   *version,
+			StageID:   *stageId,
+			StartTime: startTime,
+			EndTime:   endTime,
+		},
+		Reason: *reason,
+		DryRun: *dryRun,
+	}))
+}
+
+func runRefresh(ctx context.Context, b backend, args []string) error {
+	if len(args) == 0 || args[0] != "matrix" {
+		return errUsage
+	}
+
+	fs := flag.NewFlagSet("refresh matrix", flag.ExitOnError)
+	stageId := fs.String("stage", "", "ark stage id of the stage to refresh; refreshes the whole server when omitted")
+	start := fs.String("start", "", "refreshes the stage since, in RFC 3339 or milliseconds since the epoch; defaults to its earliest time range")
+	end := fs.String("end", "", "refreshes the stage until, in RFC 3339 or milliseconds since the epoch; defaults to now")
+	_ = fs.Parse(args[1:])
+	if fs.NArg() == 0 {
+		return errors.Wrap(errUsage, "refresh matrix requires a server")
+	}
+	// flags are accepted after the server as well, e.g. `refresh matrix CN -stage main_01-07`
+	server := fs.Arg(0)
+	_ = fs.Parse(fs.Args()[1:])
+	if fs.NArg() != 0 {
+		return errors.Wrap(errUsage, "refresh matrix requires exactly one server")
+	}
+
+	if *stageId == "" {
+		if err := b.RefreshDropMatrix(ctx, server); err != nil {
+			return err
+		}
+		log.Info().Str("server", server).Msg("drop matrix refreshed")
+		return nil
+	}
+
+	startTime, err := parseTime(*start)
+	if err != nil {
+		return errors.Wrap(err, "invalid -start")
+	}
+	endTime, err := parseTime(*end)
+	if err != nil {
+		return errors.Wrap(err, "invalid -end")
+	}
+	return printResult(b.RefreshStageDropMatrix(ctx, &types.MatrixRefreshRequest{
+		Server:    server,
+		StageID:   *stageId,
+		StartTime: startTime,
+		EndTime:   endTime,
+	}))
+}
+
+func runBan(ctx context.Context, b backend, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return printResult(b.GetShadowBans(ctx))
+	case "create":
+		fs := flag.NewFlagSet("ban create", flag.ExitOnError)
+		accountId := fs.Int("account", 0, "id of the account to ban")
+		ipRange := fs.String("ip", "", "IP range in CIDR notation, or a single IP, to ban")
+		reason := fs.String("reason", "", "reason of the ban")
+		expiresIn := fs.Duration("expires", 0, "lifts the ban after, e.g. 72h; the ban never expires when omitted")
+		_ = fs.Parse(args[1:])
+		if (*accountId == 0) == (*ipRange == "") || *reason == "" {
+			return errors.Wrap(errUsage, "ban create requires either -account or -ip, and -reason")
+		}
+
+		req := &types.ShadowBanRequest{
+			AccountID: *accountId,
+			IPRange:   *ipRange,
+			Reason:    *reason,
+		}
+		if *expiresIn > 0 {
+			req.ExpiresAt = time.Now().Add(*expiresIn).UnixMilli()
+		}
+		return printResult(b.CreateShadowBan(ctx, req))
+	case "delete":
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		if err := b.DeleteShadowBan(ctx, id); err != nil {
+			return err
+		}
+		log.Info().Int("id", id).Msg("shadow ban lifted")
+		return nil
+	case "sync":
+		if err := b.SyncShadowBans(ctx); err != nil {
+			return err
+		}
+		log.Info().Msg("shadow bans synced")
+		return nil
+	default:
+		return errUsage
+	}
+}
+
+func runJobs(ctx context.Context, b backend, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return printResult(b.GetJobs(ctx))
+	case "runs":
+		fs := flag.NewFlagSet("jobs runs", flag.ExitOnError)
+		job := fs.String("job", "", "name of the job; lists runs of all jobs when omitted")
+		limit := fs.Int("limit", 100, "number of runs listed")
+		_ = fs.Parse(args[1:])
+		return printResult(b.GetJobRuns(ctx, *job, *limit))
+	case "trigger":
+		if len(args) != 2 {
+			return errors.Wrap(errUsage, "jobs trigger requires the name of a job")
+		}
+		return printResult(b.TriggerJob(ctx, args[1]))
+	default:
+		return errUsage
+	}
+}
+
+func runDLQ(ctx context.Context, b backend, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	switch args[0] {
+	case "list":
+		return printResult(b.GetRejectedReportTasks(ctx))
+	case "requeue":
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		if err := b.RequeueRejectedReportTask(ctx, id); err != nil {
+			return err
+		}
+		log.Info().Int("id", id).Msg("rejected report task requeued")
+		return nil
+	case "discard":
+		id, err := idArg(args)
+		if err != nil {
+			return err
+		}
+		if err := b.DiscardRejectedReportTask(ctx, id); err != nil {
+			return err
+		}
+		log.Info().Int("id", id).Msg("rejected report task discarded")
+		return nil
+	default:
+		return errUsage
+	}
+}
+
+// idArg returns the id following the subcommand in args.
+func idArg(args []string) (int, error) {
+	if len(args) != 2 {
+		return 0, errors.Wrapf(errUsage, "%s requires an id", args[0])
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, errors.Errorf("invalid id %q", args[1])
+	}
+	return id, nil
+}
+
+// parseTime parses s, in RFC 3339 or milliseconds since the epoch, into milliseconds since the epoch. Empty s is
+// parsed as 0.
+func parseTime(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixMilli(), nil
+}
+
+// printResult prints result to stdout in indented JSON, or returns err if it is not nil.
+func printResult(result any, err error) error {
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if raw, ok := result.(json.RawMessage); ok {
+		if len(raw) == 0 {
+			return nil
+		}
+		if err := json.Indent(&buf, raw, "", "    "); err != nil {
+			// printed as is, as the response is not JSON
+			buf.Reset()
+			buf.Write(raw)
+		}
+		buf.WriteByte('\n')
+	} else {
+		encoder := json.NewEncoder(&buf)
+		encoder.SetIndent("", "    ")
+		if err := encoder.Encode(result); err != nil {
+			return err
+		}
+	}
+	_, err = buf.WriteTo(os.Stdout)
+	return err
+}
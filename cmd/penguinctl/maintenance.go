@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os/user"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/infra"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// maintenanceIP is recorded as the IP of the admin requesting purges in maintenance mode, as they are requested on
+// the host penguinctl runs on.
+const maintenanceIP = "127.0.0.1"
+
+// maintenanceActorPrefix prefixes the name of the user running penguinctl in maintenance mode, as the actor of
+// operations recorded to the audit log.
+const maintenanceActorPrefix = "penguinctl:"
+
+// errNotInMaintenance is returned for operations which need a running instance of the backend.
+var errNotInMaintenance = errors.New("scheduled jobs run on instances of the backend only, and are unavailable in maintenance mode; use the admin API instead")
+
+// maintenanceBackend runs operations directly against the database and the other infrastructure the backend is
+// configured with, through the same services as the backend does.
+type maintenanceBackend struct {
+	app   *fx.App
+	actor string
+
+	ReportService        *service.Report
+	ReportPurgeService   *service.ReportPurge
+	DropMatrixService    *service.DropMatrix
+	MatrixRefreshService *service.MatrixRefresh
+	ShadowBanService     *service.ShadowBan
+	Scheduler            *service.Scheduler
+	AuditService         *service.Audit
+}
+
+func newMaintenanceBackend(ctx context.Context) (*maintenanceBackend, error) {
+	b := maintenanceBackend{
+		actor: constant.AuditDefaultActor,
+	}
+	if u, err := user.Current(); err == nil {
+		b.actor = maintenanceActorPrefix + u.Username
+	}
+	b.app = fx.New(
+		fx.Provide(config.Parse),
+		infra.Module(),
+		reportverifs.Module(),
+		repo.Module(),
+		service.Module(),
+		fx.Invoke(cache.Initialize),
+		fx.Populate(
+			&b.ReportService,
+			&b.ReportPurgeService,
+			&b.DropMatrixService,
+			&b.MatrixRefreshService,
+			&b.ShadowBanService,
+			&b.Scheduler,
+			&b.AuditService,
+		),
+		fx.NopLogger,
+	)
+	if err := b.app.Err(); err != nil {
+		return nil, err
+	}
+	if err := b.app.Start(ctx); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// audited runs op, and records it to the audit log the same way as the admin API request of method to path would be,
+// which is routed by route.
+func (b *maintenanceBackend) audited(ctx context.Context, method, route, path string, req any, op func() error) error {
+	err := op()
+	b.AuditService.RecordOperation(ctx, &model.AuditLog{
+		Actor:  b.actor,
+		Action: method + " " + adminPrefix + route,
+		Path:   adminPrefix + path,
+		IP:     maintenanceIP,
+	}, req, err)
+	return err
+}
+
+func (b *maintenanceBackend) PurgeReports(ctx context.Context, req *types.ReportPurgeRequest) (resp any, err error) {
+	if err := rekuest.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	err = b.audited(ctx, http.MethodPost, "/report/purge", "/report/purge", req, func() (err error) {
+		resp, err = b.ReportPurgeService.PurgeReports(ctx, req, maintenanceIP)
+		return err
+	})
+	return resp, err
+}
+
+func (b *maintenanceBackend) GetReportPurges(ctx context.Context, limit int) (any, error) {
+	return b.ReportPurgeService.GetReportPurges(ctx, limit)
+}
+
+func (b *maintenanceBackend) RefreshDropMatrix(ctx context.Context, server string) error {
+	return b.audited(ctx, http.MethodPost, "/refresh/matrix/:server", "/refresh/matrix/"+url.PathEscape(server), nil, func() error {
+		return b.DropMatrixService.RefreshAllDropMatrixElements(ctx, server, b.DropMatrixService.SourceCategories)
+	})
+}
+
+func (b *maintenanceBackend) RefreshStageDropMatrix(ctx context.Context, req *types.MatrixRefreshRequest) (resp any, err error) {
+	if err := rekuest.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	err = b.audited(ctx, http.MethodPost, "/matrix/refresh", "/matrix/refresh", req, func() error {
+		refreshId, err := b.MatrixRefreshService.RefreshStageDropMatrix(ctx, req)
+		if err != nil {
+			return err
+		}
+		resp = &types.MatrixRefreshResponse{
+			RefreshID: refreshId,
+			Subject:   constant.MatrixRefreshSubjectPrefix + refreshId,
+		}
+		return nil
+	})
+	return resp, err
+}
+
+func (b *maintenanceBackend) GetShadowBans(ctx context.Context) (any, error) {
+	return b.ShadowBanService.GetActiveShadowBans(ctx)
+}
+
+func (b *maintenanceBackend) CreateShadowBan(ctx context.Context, req *types.ShadowBanRequest) (resp any, err error) {
+	if err := rekuest.Validate.Struct(req); err != nil {
+		return nil, err
+	}
+	err = b.audited(ctx, http.MethodPost, "/shadowban", "/shadowban", req, func() (err error) {
+		resp, err = b.ShadowBanService.CreateShadowBan(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+func (b *maintenanceBackend) DeleteShadowBan(ctx context.Context, id int) error {
+	return b.audited(ctx, http.MethodDelete, "/shadowban/:id", "/shadowban/"+strconv.Itoa(id), nil, func() error {
+		return b.ShadowBanService.DeleteShadowBan(ctx, id)
+	})
+}
+
+func (b *maintenanceBackend) SyncShadowBans(ctx context.Context) error {
+	return b.audited(ctx, http.MethodPost, "/shadowban/sync", "/shadowban/sync", nil, func() error {
+		return b.ShadowBanService.SyncShadowBans(ctx)
+	})
+}
+
+func (b *maintenanceBackend) GetJobs(context.Context) (any, error) {
+	return nil, errNotInMaintenance
+}
+
+func (b *maintenanceBackend) GetJobRuns(ctx context.Context, job string, limit int) (any, error) {
+	return b.Scheduler.GetJobRuns(ctx, job, limit)
+}
+
+func (b *maintenanceBackend) TriggerJob(context.Context, string) (any, error) {
+	return nil, errNotInMaintenance
+}
+
+func (b *maintenanceBackend) GetRejectedReportTasks(ctx context.Context) (any, error) {
+	return b.ReportService.GetRejectedReportTasks(ctx)
+}
+
+func (b *maintenanceBackend) RequeueRejectedReportTask(ctx context.Context, id int) error {
+	return b.audited(ctx, http.MethodPost, "/report/rejected/:id/requeue", "/report/rejected/"+strconv.Itoa(id)+"/requeue", nil, func() error {
+		return b.ReportService.RequeueRejectedReportTask(ctx, id)
+	})
+}
+
+func (b *maintenanceBackend) DiscardRejectedReportTask(ctx context.Context, id int) error {
+	return b.audited(ctx, http.MethodDelete, "/report/rejected/:id", "/report/rejected/"+strconv.Itoa(id), nil, func() error {
+		return b.ReportService.DiscardRejectedReportTask(ctx, id)
+	})
+}
+
+// Close waits for drop matrix refreshes started by purges and stage refreshes to finish, as they run in background
+// and would be abandoned once penguinctl exits.
+func (b *maintenanceBackend) Close() {
+	b.MatrixRefreshService.Wait()
+	if err := b.app.Stop(context.Background()); err != nil {
+		log.Error().Err(err).Msg("failed to stop penguinctl")
+	}
+}
@@ -0,0 +1,93 @@
+package replay
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/infra"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/pkg/logger"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// Bootstrap replays archived report tasks through the current report pipeline, with args being the command line
+// arguments after `replay`.
+func Bootstrap(args []string) {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	input := fs.String("input", "", "path of the file to replay, with one report task, dead-lettered or rejected report task per line; defaults to stdin")
+	resultsPath := fs.String("results", "replay-results.jsonl", "path of the file to write the result of every task to")
+	concurrency := fs.Int("concurrency", 8, "number of tasks replayed concurrently")
+	dryRun := fs.Bool("dry-run", false, "verify tasks without persisting their reports")
+	_ = fs.Parse(args)
+
+	var reportService *service.Report
+	app := fx.New(
+		fx.Provide(config.Parse),
+		infra.Module(),
+		reportverifs.Module(),
+		repo.Module(),
+		service.Module(),
+		fx.Invoke(logger.Configure),
+		fx.Invoke(cache.Initialize),
+		fx.Invoke(service.InjectReportStages),
+		fx.Populate(&reportService),
+		fx.NopLogger,
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize replay")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := app.Start(ctx); err != nil {
+		log.Fatal().Err(err).Msg("failed to start replay")
+	}
+	defer func() {
+		if err := app.Stop(context.Background()); err != nil {
+			log.Error().Err(err).Msg("failed to stop replay")
+		}
+	}()
+
+	r := os.Stdin
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to open input file")
+		}
+		defer f.Close()
+		r = f
+	}
+
+	w, err := os.Create(*resultsPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to create results file")
+	}
+	defer w.Close()
+
+	summary, err := reportService.ReplayReportTasks(ctx, r, w, &service.ReportReplayOptions{
+		Concurrency: *concurrency,
+		DryRun:      *dryRun,
+	})
+	evt := log.Info()
+	if err != nil {
+		evt = log.Error().Err(err)
+	}
+	if summary != nil {
+		evt = evt.
+			Int64("total", summary.Total).
+			Int64("replayed", summary.Replayed).
+			Int64("failed", summary.Failed).
+			Int64("skipped", summary.Skipped).
+			Int64("rejected", summary.Rejected)
+	}
+	evt.Bool("dryRun", *dryRun).Msg("report replay finished")
+}
@@ -0,0 +1,45 @@
+package sdk
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	controllermeta "github.com/penguin-statistics/backend-next/internal/controller/meta"
+)
+
+// Bootstrap writes client SDK artifacts of the API into a directory of the version of the binary, with args being
+// the command line arguments after `sdk`. It is the build step of publishing SDKs, e.g. to npm, and serves the same
+// artifacts as the `/sdk` endpoints.
+func Bootstrap(args []string) {
+	fs := flag.NewFlagSet("sdk", flag.ExitOnError)
+	out := fs.String("out", "dist/sdk", "directory to write artifacts into, under a subdirectory of the version")
+	_ = fs.Parse(args)
+
+	sdk, err := controllermeta.GenerateSDK()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to generate client SDK artifacts")
+	}
+
+	dir := filepath.Join(*out, sdk.Version)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Fatal().Err(err).Str("dir", dir).Msg("failed to create SDK directory")
+	}
+	for _, artifact := range sdk.Artifacts {
+		if err := os.WriteFile(filepath.Join(dir, artifact.Name), artifact.Body, 0o644); err != nil {
+			log.Fatal().Err(err).Str("artifact", artifact.Name).Msg("failed to write SDK artifact")
+		}
+	}
+	manifest, err := json.MarshalIndent(sdk, "", "    ")
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to encode SDK manifest")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), manifest, 0o644); err != nil {
+		log.Fatal().Err(err).Msg("failed to write SDK manifest")
+	}
+
+	log.Info().Str("dir", dir).Int("artifacts", len(sdk.Artifacts)).Msg("client SDK artifacts generated")
+}
@@ -1,12 +1,49 @@
 package service
 
 import (
+	"flag"
+
+	"github.com/rs/zerolog/log"
 	"go.uber.org/fx"
 
 	"github.com/penguin-statistics/backend-next/internal/appentry"
 )
 
-func Bootstrap() {
+// Bootstrap starts the service, with args being the command line arguments. With `--migrate`, `--rollback` or
+// `--unlock`, migrations of the database schema are run instead, and the process exits once they finish; along with
+// `--migrate`, `--partition-drop-reports` also converts drop_reports into a table partitioned by month. With
+// `--dev-seed`, a local development environment is bootstrapped instead.
+func Bootstrap(args []string) {
+	fs := flag.NewFlagSet("service", flag.ExitOnError)
+	migrateMode := fs.Bool("migrate", false, "apply pending migrations of the database schema and exit")
+	rollbackMode := fs.Bool("rollback", false, "roll back the latest run of migrations of the database schema and exit")
+	unlockMode := fs.Bool("unlock", false, "release the lock of migration runs left by a crashed run and exit")
+	devSeedMode := fs.Bool("dev-seed", false, "apply migrations, load development fixtures into servers not seeded yet, queue fake reports of them on those servers and exit; requires development mode")
+	confirm := fs.Bool("confirm", false, "confirm --rollback; otherwise the migrations which would be rolled back are only listed")
+	partitionDropReports := fs.Bool("partition-drop-reports", false, "with --migrate, also convert drop_reports into a table partitioned by month of created_at once migrations are applied, locking drop_reports until the conversion completes")
+	_ = fs.Parse(args)
+
+	modes := 0
+	for _, mode := range []bool{*migrateMode, *rollbackMode, *unlockMode, *devSeedMode} {
+		if mode {
+			modes++
+		}
+	}
+	if modes > 1 {
+		log.Fatal().Msg("only one of --migrate, --rollback, --unlock and --dev-seed could be given")
+	}
+	if *partitionDropReports && !*migrateMode {
+		log.Fatal().Msg("--partition-drop-reports could only be given along with --migrate")
+	}
+	if *devSeedMode {
+		runDevSeed()
+		return
+	}
+	if modes == 1 {
+		runMigrations(*migrateMode, *rollbackMode, *unlockMode, *confirm, *partitionDropReports)
+		return
+	}
+
 	opts := []fx.Option{}
 	opts = append(opts, appentry.ProvideOptions(true)...)
 	opts = append(opts, fx.Invoke(run))
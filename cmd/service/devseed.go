@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/devseed"
+	"github.com/penguin-statistics/backend-next/internal/infra"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/pkg/logger"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// runDevSeed bootstraps a local development environment without starting the service: migrations are applied, and
+// the fixture set of package devseed is loaded into servers it has not been loaded into, with its reports queued on
+// those servers for the report worker of the service to consume once started. Reports are not queued again on
+// servers seeded already, so that running it twice does not double the reports. It refuses to run unless in
+// development mode, as fixtures and reports are written to the database and queue configured.
+func runDevSeed() {
+	var (
+		conf             *config.Config
+		migrationService *service.Migration
+		devSeedService   *service.DevSeed
+	)
+	app := fx.New(
+		fx.Provide(config.Parse),
+		infra.Module(),
+		reportverifs.Module(),
+		repo.Module(),
+		service.Module(),
+		fx.Provide(service.NewDevSeed),
+		fx.Invoke(logger.Configure),
+		fx.Invoke(cache.Initialize),
+		fx.Populate(&conf, &migrationService, &devSeedService),
+		fx.NopLogger,
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize dev seed")
+	}
+	if !conf.DevMode {
+		log.Fatal().Msg("--dev-seed writes fixtures into the database configured, and is only allowed with PENGUIN_V3_DEV_MODE=true")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := app.Start(ctx); err != nil {
+		log.Fatal().Err(err).Msg("failed to start dev seed")
+	}
+	defer func() {
+		if err := app.Stop(context.Background()); err != nil {
+			log.Error().Err(err).Msg("failed to stop dev seed")
+		}
+	}()
+
+	group, err := migrationService.Migrate(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to apply migrations")
+	}
+	if !group.IsZero() {
+		logGroup(group).Msg("migrations applied")
+	}
+
+	fixtures, err := devseed.Load()
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load dev seed fixtures")
+	}
+	servers, err := devSeedService.LoadFixtures(ctx, fixtures)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to load dev seed fixtures")
+	}
+	if len(servers) == 0 {
+		log.Info().Msg("dev seed fixtures have already been loaded into every server; no reports are queued")
+		return
+	}
+
+	taskIds, err := devSeedService.QueueReports(ctx, fixtures, servers)
+	if err != nil {
+		log.Fatal().Err(err).Int("queued", len(taskIds)).Msg("failed to queue dev seed reports")
+	}
+	log.Info().
+		Strs("servers", servers).
+		Int("reports", len(taskIds)).
+		Msg("dev seed finished; start the service to consume the reports queued")
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/signal"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun/migrate"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/infra"
+	"github.com/penguin-statistics/backend-next/internal/pkg/logger"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// runMigrations runs migrations of the database schema in the mode selected, without starting the service. With
+// partitionDropReports, drop_reports is also partitioned once migrations are applied.
+func runMigrations(migrateMode bool, rollbackMode bool, unlockMode bool, confirm bool, partitionDropReports bool) {
+	var (
+		conf             *config.Config
+		migrationService *service.Migration
+		partitionService *service.DropReportPartition
+	)
+	app := fx.New(
+		fx.Provide(config.Parse),
+		infra.Module(),
+		fx.Provide(service.NewMigration, repo.NewDropReportPartition, service.NewDropReportPartition),
+		fx.Invoke(logger.Configure),
+		fx.Populate(&conf, &migrationService, &partitionService),
+		fx.NopLogger,
+	)
+	if err := app.Err(); err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize migrations")
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if err := app.Start(ctx); err != nil {
+		log.Fatal().Err(err).Msg("failed to start migrations")
+	}
+	defer func() {
+		if err := app.Stop(context.Background()); err != nil {
+			log.Error().Err(err).Msg("failed to stop migrations")
+		}
+	}()
+
+	switch {
+	case unlockMode:
+		if err := migrationService.Unlock(ctx); err != nil {
+			log.Fatal().Err(err).Msg("failed to release the lock of migration runs")
+		}
+		log.Info().Msg("lock of migration runs released")
+	case rollbackMode:
+		group, err := migrationService.GetLastGroup(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to get the latest run of migrations")
+		}
+		if group.IsZero() {
+			log.Info().Msg("no migration to roll back")
+			return
+		}
+		if !confirm {
+			logGroup(group).Msg("the latest run of migrations would be rolled back; rerun with --confirm to roll it back")
+			return
+		}
+
+		group, err = migrationService.Rollback(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to roll back migrations")
+		}
+		logGroup(group).Msg("migrations rolled back")
+	case migrateMode:
+		group, err := migrationService.Migrate(ctx)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to apply migrations")
+		}
+		if group.IsZero() {
+			log.Info().Msg("no pending migration; the database schema is up to date")
+		} else {
+			logGroup(group).Msg("migrations applied")
+		}
+		if partitionDropReports {
+			partitionDropReportsTable(ctx, conf, partitionService)
+		}
+	}
+}
+
+// partitionDropReportsTable converts drop_reports into a table partitioned by month of created_at, keeping the
+// original table as drop_reports_legacy for verification.
+func partitionDropReportsTable(ctx context.Context, conf *config.Config, partitionService *service.DropReportPartition) {
+	if err := partitionService.MigrateToPartitioned(ctx, conf.DropReportPartitionsAhead); err != nil {
+		log.Fatal().Err(err).Msg("failed to partition drop_reports")
+	}
+
+	partitions, err := partitionService.GetPartitionNames(ctx)
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to get drop_reports partitions")
+	}
+	log.Info().
+		Strs("partitions", partitions).
+		Msg("drop_reports partitioned; drop_reports_legacy could be dropped once the migrated data has been verified")
+}
+
+func logGroup(group *migrate.MigrationGroup) *zerolog.Event {
+	return log.Info().Int64("run", group.ID).Stringer("migrations", group.Migrations)
+}
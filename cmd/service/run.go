@@ -10,12 +10,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"go.uber.org/fx"
+	"google.golang.org/grpc"
 
 	"github.com/penguin-statistics/backend-next/internal/config"
 	"github.com/penguin-statistics/backend-next/internal/pkg/async"
 )
 
-func run(app *fiber.App, conf *config.Config, lc fx.Lifecycle) {
+func run(app *fiber.App, grpcServer *grpc.Server, conf *config.Config, lc fx.Lifecycle) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			ln, err := net.Listen("tcp", conf.Address)
@@ -29,6 +30,19 @@ func run(app *fiber.App, conf *config.Config, lc fx.Lifecycle) {
 				}
 			}()
 
+			if conf.GRPCAddress != "" {
+				grpcLn, err := net.Listen("tcp", conf.GRPCAddress)
+				if err != nil {
+					return err
+				}
+
+				go func() {
+					if err := grpcServer.Serve(grpcLn); err != nil {
+						log.Error().Err(err).Msg("gRPC server terminated unexpectedly")
+					}
+				}()
+			}
+
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
@@ -36,8 +50,26 @@ func run(app *fiber.App, conf *config.Config, lc fx.Lifecycle) {
 				return nil
 			}
 
+			// listeners are closed right away, while in-flight requests are given until the deadline to finish
 			return async.WaitAll(
-				async.Errable(app.Shutdown),
+				async.Errable(func() error {
+					if err := <-async.Deadline(ctx, conf.HTTPServerShutdownTimeout, app.Shutdown); err != nil {
+						return errors.Wrap(err, "failed to drain in-flight HTTP requests")
+					}
+					return nil
+				}),
+				async.Errable(func() error {
+					err := <-async.Deadline(ctx, conf.HTTPServerShutdownTimeout, func() error {
+						grpcServer.GracefulStop()
+						return nil
+					})
+					if err != nil {
+						// cancel the RPCs still in-flight, rather than leaving them to be cut off by the exit
+						grpcServer.Stop()
+						return errors.Wrap(err, "failed to drain in-flight gRPC calls")
+					}
+					return nil
+				}),
 				async.Errable(func() error {
 					flushed := sentry.Flush(time.Second * 30)
 					if !flushed {
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
+	"github.com/penguin-statistics/backend-next/internal/pkg/testentry"
+)
+
+func TestDistributedLock(t *testing.T) {
+	var client *redis.Client
+	testentry.Populate(t, &client)
+	locker := dlock.New(client, time.Second*3)
+	ctx := context.Background()
+
+	t.Run("ExcludesOtherHolders", func(t *testing.T) {
+		lock, err := locker.TryLock(ctx, "test:exclusive")
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = locker.TryLock(ctx, "test:exclusive")
+		assert.ErrorIs(t, err, dlock.ErrNotAcquired, "expect a held lock not to be acquired again")
+
+		lock.Unlock()
+		assert.Error(t, lock.Context().Err(), "expect the context of an unlocked lock to be cancelled")
+
+		again, err := locker.TryLock(ctx, "test:exclusive")
+		if assert.NoError(t, err, "expect an unlocked lock to be acquired again") {
+			again.Unlock()
+		}
+	})
+
+	t.Run("IssuesIncreasingFencingTokens", func(t *testing.T) {
+		var last int64
+		for i := 0; i < 3; i++ {
+			lock, err := locker.TryLock(ctx, "test:fencing")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Greater(t, lock.Token(), last, "expect every acquisition to be issued a greater token")
+			assert.Same(t, lock, dlock.FromContext(lock.Context()), "expect the context to carry its lock")
+			last = lock.Token()
+			lock.Unlock()
+		}
+	})
+
+	t.Run("RenewsHeldLocks", func(t *testing.T) {
+		lock, err := locker.TryLock(ctx, "test:renew")
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer lock.Unlock()
+
+		// the ttl passes twice, so the lock would have expired if it had not been renewed
+		time.Sleep(time.Second * 6)
+		assert.NoError(t, lock.Context().Err(), "expect a renewed lock to be kept")
+		_, err = locker.TryLock(ctx, "test:renew")
+		assert.ErrorIs(t, err, dlock.ErrNotAcquired, "expect a renewed lock not to be acquired by others")
+	})
+
+	t.Run("DoReleasesAfterwards", func(t *testing.T) {
+		errDone := errors.New("done")
+		tests := []struct {
+			fn  func(ctx context.Context) error
+			err error
+		}{
+			{func(ctx context.Context) error { return nil }, nil},
+			{func(ctx context.Context) error { return errDone }, errDone},
+		}
+		for _, test := range tests {
+			err := locker.Do(ctx, "test:do", test.fn)
+			assert.Equal(t, test.err, err, "expect Do to return the error of fn")
+
+			lock, err := locker.TryLock(ctx, "test:do")
+			if assert.NoError(t, err, "expect Do to release the lock") {
+				lock.Unlock()
+			}
+		}
+	})
+}
@@ -148,6 +148,17 @@ const docTemplate = `{
                         "name": "server",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "description": "Length of each interval of trends; default to day",
+                        "name": "granularity",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -157,6 +168,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/v2.TrendQueryResult"
                         }
                     },
+                    "400": {
+                        "description": "Invalid granularity",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
                     "500": {
                         "description": "An unexpected error occurred",
                         "schema": {
@@ -260,6 +277,46 @@ const docTemplate = `{
                 }
             }
         },
+        "/PenguinStats/api/v2/graphql": {
+            "post": {
+                "description": "Read-only GraphQL endpoint covering items, stages, zones, the drop matrix, drop patterns and trends, so that only the fields needed are fetched, in one round trip. Query errors are reported in ` + "`" + `errors` + "`" + ` of the response as per the GraphQL specification, with a status of 200. The schema could be introspected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Query Result Data with GraphQL",
+                "parameters": [
+                    {
+                        "description": "GraphQL request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.GraphQLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "GraphQL response, with ` + "`" + `data` + "`" + ` and ` + "`" + `errors` + "`" + `",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
         "/PenguinStats/api/v2/items": {
             "get": {
                 "produces": [
@@ -269,6 +326,31 @@ const docTemplate = `{
                     "Item"
                 ],
                 "summary": "Get All Items",
+                "parameters": [
+                    {
+                        "enum": [
+                            "zh",
+                            "en",
+                            "ja",
+                            "ko",
+                            "auto"
+                        ],
+                        "type": "string",
+                        "description": "Only include names in this language, or negotiate it by Accept-Language with ` + "`" + `auto` + "`" + `",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of fields to limit each record to",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -319,6 +401,29 @@ const docTemplate = `{
                         "name": "itemId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "zh",
+                            "en",
+                            "ja",
+                            "ko",
+                            "auto"
+                        ],
+                        "type": "string",
+                        "description": "Only include names in this language, or negotiate it by Accept-Language with ` + "`" + `auto` + "`" + `",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of fields to limit each record to",
+                        "name": "fields",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -395,6 +500,21 @@ const docTemplate = `{
                     "EventPeriod"
                 ],
                 "summary": "Get All Event Periods",
+                "parameters": [
+                    {
+                        "enum": [
+                            "zh",
+                            "en",
+                            "ja",
+                            "ko",
+                            "auto"
+                        ],
+                        "type": "string",
+                        "description": "Only include names in this language, or negotiate it by Accept-Language with ` + "`" + `auto` + "`" + `",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -429,14 +549,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/report": {
+        "/PenguinStats/api/v2/plan": {
             "post": {
-                "security": [
-                    {
-                        "PenguinIDAuth": []
-                    }
-                ],
-                "description": "Submit a Drop Report. You can use the ` + "`" + `reportHash` + "`" + ` in the response to recall the report in 24 hours after it has been submitted.",
+                "description": "Plan which stages to farm, and how many runs each, to obtain the items required with the least sanity expected, according to drop rates of the current drop matrix. Only stages currently open, and sampled enough times, are farmed.",
                 "consumes": [
                     "application/json"
                 ],
@@ -444,29 +559,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Report"
+                    "Planner"
                 ],
-                "summary": "Submit a Drop Report",
+                "summary": "Plan Farming",
                 "parameters": [
                     {
-                        "description": "Report request",
-                        "name": "report",
+                        "description": "Items required",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/types.SingleReportRequest"
+                            "$ref": "#/definitions/types.PlanRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Report has been successfully submitted",
+                    "200": {
+                        "description": "Farming plan",
                         "schema": {
-                            "$ref": "#/definitions/v2.ReportResponse"
+                            "$ref": "#/definitions/v2.Plan"
                         }
                     },
                     "400": {
-                        "description": "Invalid request",
+                        "description": "Invalid request, or some items are not dropped by any stage available",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
                         "schema": {
                             "$ref": "#/definitions/pgerr.PenguinError"
                         }
@@ -480,38 +601,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/report/recall": {
-            "post": {
-                "description": "Recall a Drop Report by its ` + "`" + `reportHash` + "`" + `. The farest report you can recall is limited to 24 hours. Recalling a report after it has been already recalled will result in an error.",
-                "consumes": [
-                    "application/json"
-                ],
+        "/PenguinStats/api/v2/public/aggregated": {
+            "get": {
+                "description": "Get the drop matrix of a server, with closed zones, along with its stages and all items, in one document. The document is rendered every few minutes rather than on request, and is compressed ahead in Brotli, Zstandard and gzip, responded as is to clients accepting any of them, so third-party tools are encouraged to use it instead of the dynamic endpoints. Responses carry an ETag to be revalidated with.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Report"
+                    "Public"
                 ],
-                "summary": "Recall a Drop Report",
+                "summary": "Get Public Aggregate",
                 "parameters": [
                     {
-                        "description": "Report Recall request",
-                        "name": "report",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.SingleReportRecallRequest"
-                        }
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Report has been successfully recalled"
-                    },
-                    "400": {
-                        "description": "` + "`" + `reportHash` + "`" + ` is missing, invalid, or already been recalled.",
+                    "200": {
+                        "description": "Public aggregate of the server",
                         "schema": {
-                            "$ref": "#/definitions/pgerr.PenguinError"
+                            "$ref": "#/definitions/v2.PublicAggregate"
                         }
                     },
                     "500": {
@@ -523,41 +642,34 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/report/recognition": {
-            "post": {
-                "security": [
-                    {
-                        "PenguinIDAuth": []
-                    }
-                ],
-                "description": "Submit an Item Drop Report with Frontend Recognition. Notice that this is a **private API** and is not designed for external use.",
+        "/PenguinStats/api/v2/recognition/bundles/{hash}": {
+            "get": {
+                "description": "Download a bundle of item recognition definitions by its hash. The content of a hash never changes, hence responses could be cached forever.",
                 "produces": [
-                    "application/json"
+                    "application/octet-stream"
                 ],
                 "tags": [
-                    "Report"
+                    "Recognition"
                 ],
-                "summary": "Bulk Submit with Frontend Recognition",
+                "summary": "Get Recognition Bundle",
                 "parameters": [
                     {
-                        "description": "Recognition Report Request",
-                        "name": "report",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "Hex-encoded SHA-256 hash of the bundle",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Report has been successfully submitted for queue processing",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v2.RecognitionReportResponse"
+                            "type": "file"
                         }
                     },
-                    "400": {
-                        "description": "Invalid request",
+                    "404": {
+                        "description": "Recognition bundle not found",
                         "schema": {
                             "$ref": "#/definitions/pgerr.PenguinError"
                         }
@@ -571,20 +683,16 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/result/matrix": {
+        "/PenguinStats/api/v2/recognition/{server}/latest": {
             "get": {
-                "security": [
-                    {
-                        "PenguinIDAuth": []
-                    }
-                ],
+                "description": "Get the latest bundle of item recognition definitions of a server. Recognizers shall check it for updates every now and then, and download the bundle from its URL only when the hash has changed.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Result"
+                    "Recognition"
                 ],
-                "summary": "Get Drop Matrix",
+                "summary": "Get Latest Recognition Bundle",
                 "parameters": [
                     {
                         "enum": [
@@ -594,49 +702,23 @@ const docTemplate = `{
                             "KR"
                         ],
                         "type": "string",
-                        "description": "Server; default to CN",
+                        "description": "Server",
                         "name": "server",
-                        "in": "query",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Whether to query for personal drop matrix or not. If ` + "`" + `is_personal` + "`" + ` equals to ` + "`" + `true` + "`" + `, a valid PenguinID would be required to be provided (PenguinIDAuth)",
-                        "name": "is_personal",
-                        "in": "query"
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Whether to show closed stages or not",
-                        "name": "show_closed_zones",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Comma separated list of stage IDs to filter",
-                        "name": "stageFilter",
-                        "in": "query"
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "description": "Comma separated list of item IDs to filter",
-                        "name": "itemFilter",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Drop Matrix response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v2.DropMatrixQueryResult"
+                            "$ref": "#/definitions/v2.RecognitionRelease"
+                        }
+                    },
+                    "404": {
+                        "description": "No recognition bundle has been released for the server",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
                         }
                     },
                     "500": {
@@ -648,86 +730,58 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/result/pattern": {
-            "get": {
+        "/PenguinStats/api/v2/report": {
+            "post": {
                 "security": [
                     {
                         "PenguinIDAuth": []
                     }
                 ],
+                "description": "Submit a Drop Report. You can use the ` + "`" + `reportHash` + "`" + ` in the response to recall the report in 24 hours after it has been submitted.\nWhen the report is rejected for a fixable reason, the error response carries a ` + "`" + `suggestion` + "`" + ` object (see ` + "`" + `types.ReportCorrectionSuggestion` + "`" + `) which clients could apply to correct the report and retry.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Result"
+                    "Report"
                 ],
-                "summary": "Get Pattern Matrix",
+                "summary": "Submit a Drop Report",
                 "parameters": [
                     {
-                        "enum": [
-                            "CN",
-                            "US",
-                            "JP",
-                            "KR"
-                        ],
-                        "type": "string",
-                        "description": "Server; default to CN",
-                        "name": "server",
-                        "in": "query",
-                        "required": true
+                        "description": "Report request",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.SingleReportRequest"
+                        }
                     },
                     {
                         "type": "boolean",
-                        "description": "Whether to query for personal drop matrix or not. If ` + "`" + `is_personal` + "`" + ` equals to ` + "`" + `true` + "`" + `, a valid PenguinID would be required to be provided (PenguinIDAuth)",
-                        "name": "is_personal",
+                        "description": "When true, the report is verified and persisted before responding, and the final verdict is returned in ` + "`" + `verdict` + "`" + `, unless synchronous mode is not available on the server of the report, in which case the report is queued as usual",
+                        "name": "sync",
                         "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Report has been successfully submitted",
                         "schema": {
-                            "$ref": "#/definitions/v2.PatternMatrixQueryResult"
+                            "$ref": "#/definitions/v2.ReportResponse"
                         }
                     },
-                    "500": {
-                        "description": "An unexpected error occurred",
+                    "400": {
+                        "description": "Invalid request",
                         "schema": {
                             "$ref": "#/definitions/pgerr.PenguinError"
                         }
-                    }
-                }
-            }
-        },
-        "/PenguinStats/api/v2/result/trends": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Result"
-                ],
-                "summary": "Get Trends",
-                "parameters": [
-                    {
-                        "enum": [
-                            "CN",
-                            "US",
-                            "JP",
-                            "KR"
-                        ],
-                        "type": "string",
-                        "description": "Server; default to CN",
-                        "name": "server",
-                        "in": "query",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "429": {
+                        "description": "Report quota of the stage exceeded; retry after ` + "`" + `Retry-After` + "`" + ` seconds",
                         "schema": {
-                            "$ref": "#/definitions/v2.TrendQueryResult"
+                            "$ref": "#/definitions/pgerr.PenguinError"
                         }
                     },
                     "500": {
@@ -735,42 +789,71 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/pgerr.PenguinError"
                         }
+                    },
+                    "503": {
+                        "description": "The report could not be queued as the message queue is unavailable; retry later",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "504": {
+                        "description": "The report could not be processed in time in synchronous mode",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
                     }
                 }
             }
         },
-        "/PenguinStats/api/v2/stages": {
+        "/PenguinStats/api/v2/report/mitigation/preview": {
             "get": {
+                "description": "Preview how the report pipeline would rewrite the ` + "`" + `stageId` + "`" + ` of a report, reported by ` + "`" + `source` + "`" + ` at ` + "`" + `timestamp` + "`" + `, to compensate known client-side defects. Nothing is submitted.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Stage"
+                    "Report"
+                ],
+                "summary": "Preview Report Mitigations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source of the report, same as ` + "`" + `source` + "`" + ` in the report request",
+                        "name": "source",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Stage ID, same as ` + "`" + `stageId` + "`" + ` in the report request",
+                        "name": "stageId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Version of the source, same as ` + "`" + `version` + "`" + ` in the report request",
+                        "name": "version",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Time the report would be submitted at, in milliseconds since the epoch; default to now",
+                        "name": "timestamp",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Get All Stages",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Mitigation preview",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "allOf": [
-                                    {
-                                        "$ref": "#/definitions/v2.Stage"
-                                    },
-                                    {
-                                        "type": "object",
-                                        "properties": {
-                                            "code_i18n": {
-                                                "$ref": "#/definitions/model.I18nString"
-                                            },
-                                            "existence": {
-                                                "$ref": "#/definitions/model.Existence"
-                                            }
-                                        }
-                                    }
-                                ]
-                            }
+                            "$ref": "#/definitions/v2.MitigationPreviewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
                         }
                     },
                     "500": {
@@ -782,48 +865,36 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/stages/{stageId}": {
-            "get": {
+        "/PenguinStats/api/v2/report/recall": {
+            "post": {
+                "description": "Recall a Drop Report by its ` + "`" + `reportHash` + "`" + `. The farest report you can recall is limited to 24 hours by default. Recalling a report after it has been already recalled will result in an error.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Stage"
+                    "Report"
                 ],
-                "summary": "Get an Stage with ID",
+                "summary": "Recall a Drop Report",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Stage ID",
-                        "name": "stageId",
-                        "in": "path",
-                        "required": true
+                        "description": "Report Recall request",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.SingleReportRecallRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/v2.Stage"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "code_i18n": {
-                                            "$ref": "#/definitions/model.I18nString"
-                                        },
-                                        "existence": {
-                                            "$ref": "#/definitions/model.Existence"
-                                        }
-                                    }
-                                }
-                            ]
-                        }
+                    "204": {
+                        "description": "Report has been successfully recalled"
                     },
                     "400": {
-                        "description": "Invalid or missing stageId. Notice that this shall be the **string ID** of the stage, instead of the internally used numerical ID of the stage.",
+                        "description": "` + "`" + `reportHash` + "`" + ` is missing, invalid, already been recalled, or the recall window has passed.",
                         "schema": {
                             "$ref": "#/definitions/pgerr.PenguinError"
                         }
@@ -837,38 +908,41 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/stats": {
-            "get": {
+        "/PenguinStats/api/v2/report/recall/batch": {
+            "post": {
+                "description": "Recall multiple Drop Reports by their ` + "`" + `reportHash` + "`" + `es in a single transaction. Each ` + "`" + `reportHash` + "`" + ` is subject to the same limitations as recalling a single report; those could not be recalled are reported in ` + "`" + `results` + "`" + ` without preventing others from being recalled.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "SiteStats"
+                    "Report"
                 ],
-                "summary": "Get Site Stats",
+                "summary": "Recall Drop Reports in Batch",
                 "parameters": [
                     {
-                        "enum": [
-                            "CN",
-                            "US",
-                            "JP",
-                            "KR"
-                        ],
-                        "type": "string",
-                        "description": "Server; default to CN",
-                        "name": "server",
-                        "in": "query",
-                        "required": true
+                        "description": "Batch Report Recall request",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.BatchReportRecallRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Recall result of each report",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/v2.SiteStats"
-                            }
+                            "$ref": "#/definitions/v2.BatchRecallResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "` + "`" + `reportHashes` + "`" + ` is missing or invalid",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
                         }
                     },
                     "500": {
@@ -880,39 +954,55 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/users": {
+        "/PenguinStats/api/v2/report/recognition": {
             "post": {
                 "security": [
                     {
                         "PenguinIDAuth": []
                     }
                 ],
-                "consumes": [
-                    "text/plain"
-                ],
+                "description": "Submit an Item Drop Report with Frontend Recognition. Notice that this is a **private API** and is not designed for external use.",
                 "produces": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "tags": [
-                    "Account"
+                    "Report"
                 ],
-                "summary": "Login with PenguinID",
+                "summary": "Bulk Submit with Frontend Recognition",
                 "parameters": [
                     {
-                        "description": "User ID",
-                        "name": "userId",
+                        "description": "Recognition Report Request",
+                        "name": "report",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "integer"
+                            "type": "string"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "When true, the reports are verified and persisted before responding, and the final verdicts are returned in ` + "`" + `verdicts` + "`" + `, unless synchronous mode is not available on the server of the reports, in which case the reports are queued as usual",
+                        "name": "sync",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User ID. In the deprecated backend this is, for some reason, been implemented to return a JSON in the response body but with a ` + "`" + `Content-Type: text/plain` + "`" + ` in the response header instead of the correct ` + "`" + `Content-Type: application/json` + "`" + `. So the v2 API has replicated this behavior to ensure compatibility.",
+                        "description": "Report has been successfully submitted for queue processing",
                         "schema": {
-                            "$ref": "#/definitions/v2.LoginResponse"
+                            "$ref": "#/definitions/v2.RecognitionReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "429": {
+                        "description": "Report quota of a stage exceeded; retry after ` + "`" + `Retry-After` + "`" + ` seconds",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
                         }
                     },
                     "500": {
@@ -920,42 +1010,46 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/pgerr.PenguinError"
                         }
+                    },
+                    "503": {
+                        "description": "The reports could not be queued as the message queue is unavailable; retry later",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
                     }
                 }
             }
         },
-        "/PenguinStats/api/v2/zones": {
+        "/PenguinStats/api/v2/report/task/{taskId}": {
             "get": {
+                "description": "Get the processing state of a report task by its ` + "`" + `taskId` + "`" + `, which is the ` + "`" + `reportHash` + "`" + ` (or ` + "`" + `taskId` + "`" + ` for recognition reports) returned when the report has been submitted. Status is kept as long as the report could be recalled.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Zone"
+                    "Report"
+                ],
+                "summary": "Get Report Task Status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "taskId",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get All Zones",
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Report task status",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "allOf": [
-                                    {
-                                        "$ref": "#/definitions/v2.Zone"
-                                    },
-                                    {
-                                        "type": "object",
-                                        "properties": {
-                                            "existence": {
-                                                "$ref": "#/definitions/model.Existence"
-                                            },
-                                            "zoneName_i18n": {
-                                                "$ref": "#/definitions/model.I18nString"
-                                            }
-                                        }
-                                    }
-                                ]
-                            }
+                            "$ref": "#/definitions/v2.ReportTaskStatus"
+                        }
+                    },
+                    "400": {
+                        "description": "Task not found or already expired",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
                         }
                     },
                     "500": {
@@ -967,48 +1061,316 @@ const docTemplate = `{
                 }
             }
         },
-        "/PenguinStats/api/v2/zones/{zoneId}": {
+        "/PenguinStats/api/v2/report/task/{taskId}/events": {
             "get": {
+                "description": "Watch the processing state of a report task by its ` + "`" + `taskId` + "`" + ` as a stream of Server-Sent Events. A ` + "`" + `status` + "`" + ` event, with the same payload as Get Report Task Status, is sent with the current status and every update afterwards, and the stream ends once the task has been persisted, rejected or failed. Streams also end after 15 seconds, in which case clients (e.g. ` + "`" + `EventSource` + "`" + `) shall reconnect.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "Zone"
+                    "Report"
                 ],
-                "summary": "Get a Zone with ID",
+                "summary": "Watch Report Task Status",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Zone ID",
-                        "name": "zoneId",
+                        "type": "string",
+                        "description": "Task ID",
+                        "name": "taskId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Stream of report task status",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/v2.Zone"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "existence": {
-                                            "$ref": "#/definitions/model.Existence"
-                                        },
-                                        "zoneName_i18n": {
-                                            "$ref": "#/definitions/model.I18nString"
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/v2.ReportTaskStatus"
                         }
                     },
                     "400": {
-                        "description": "Invalid or missing zoneId. Notice that this shall be the **string ID** of the zone, instead of the v3 API internally used numerical ID of the zone.",
+                        "description": "Task not found or already expired",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/report/{hash}": {
+            "patch": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "description": "Correct the drops of a Drop Report by its ` + "`" + `reportHash` + "`" + `, e.g. when a quantity has been recognized wrongly. The corrected report keeps the stage, server and source of the report, and is queued just like a new report; once persisted, it supersedes the report, and both are linked for auditing. A report could be corrected within the same window it could be recalled in, and only once; use the ` + "`" + `reportHash` + "`" + ` in the response to recall or correct the corrected report.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Report"
+                ],
+                "summary": "Correct a Drop Report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Report hash of the report to correct",
+                        "name": "hash",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Report Correction request",
+                        "name": "report",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ReportCorrectionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Corrected report has been successfully submitted",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ReportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "` + "`" + `reportHash` + "`" + ` is invalid, already been recalled or corrected, the recall window has passed, or the corrected drops are invalid",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "503": {
+                        "description": "The corrected report could not be queued as the message queue is unavailable; retry later",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/compare": {
+            "get": {
+                "description": "Get the drop matrix element of a stage and an item on every server side by side, with the drop rate and its 95% confidence interval. Servers the item has never been reported to drop from the stage on are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Compare Drop Rates across Servers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "main_01-07",
+                        "description": "Stage ID",
+                        "name": "stageId",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "30012",
+                        "description": "Item ID",
+                        "name": "itemId",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Drop matrix element on each server",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ServerComparison"
+                        }
+                    },
+                    "400": {
+                        "description": "` + "`" + `stageId` + "`" + ` or ` + "`" + `itemId` + "`" + ` is missing",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "404": {
+                        "description": "Stage or item not found",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/efficiency/{itemId}": {
+            "get": {
+                "description": "Rank the stages an item drops from on a server by the expected sanity spent per item dropped, from the most efficient one. Drop rates are of the drop matrix, or of reports within the time range when either ` + "`" + `start` + "`" + ` or ` + "`" + `end` + "`" + ` is given. Stages of unknown sanity costs, or sampled fewer times than ` + "`" + `min_times` + "`" + `, are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Rank Stages by Efficiency for an Item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "30012",
+                        "description": "Item ID",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Start of the time range, in milliseconds since the epoch; default to the start of the server",
+                        "name": "start",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "End of the time range, in milliseconds since the epoch; default to now",
+                        "name": "end",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum number of times stages shall be sampled; default to the low sample threshold",
+                        "name": "min_times",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of stages ranked; default to 10, at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to rank closed stages or not. Ignored when a time range is given",
+                        "name": "show_closed_zones",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stages ranked by efficiency",
+                        "schema": {
+                            "$ref": "#/definitions/v2.StageEfficiencyRanking"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/item/{itemId}": {
+            "get": {
+                "description": "Get all stages an item drops from on a server, with the drop rate and its 95% confidence interval, the number of runs sampled, and the expected sanity spent per item, as they are in the drop matrix.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Drop Stages of an Item",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "30012",
+                        "description": "Item ID",
+                        "name": "itemId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to show closed stages or not",
+                        "name": "show_closed_zones",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "flag",
+                            "exclude"
+                        ],
+                        "type": "string",
+                        "description": "How elements sampled too few times to be accurate are treated; default to the server configuration",
+                        "name": "low_sample",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stages the item drops from",
+                        "schema": {
+                            "$ref": "#/definitions/v2.ItemDropStages"
+                        }
+                    },
+                    "404": {
+                        "description": "Item not found",
                         "schema": {
                             "$ref": "#/definitions/pgerr.PenguinError"
                         }
@@ -1021,485 +1383,2882 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "model.Existence": {
+        },
+        "/PenguinStats/api/v2/result/matrix": {
+            "get": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "description": "Responds in CSV or TSV, with a row per drop matrix element, when requested with the ` + "`" + `format` + "`" + ` query or the ` + "`" + `Accept` + "`" + ` header. The global drop matrix in JSON, without filters or fields, is compressed ahead and responded in Brotli, Zstandard or gzip according to the ` + "`" + `Accept-Encoding` + "`" + ` header.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/tab-separated-values"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Drop Matrix",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to query for personal drop matrix or not. If ` + "`" + `is_personal` + "`" + ` equals to ` + "`" + `true` + "`" + `, a valid PenguinID would be required to be provided (PenguinIDAuth)",
+                        "name": "is_personal",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to show closed stages or not",
+                        "name": "show_closed_zones",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of stage IDs to filter",
+                        "name": "stageFilter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of item IDs to filter",
+                        "name": "itemFilter",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "tsv"
+                        ],
+                        "type": "string",
+                        "description": "Response format; default to json, or negotiated by the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of fields to limit each drop matrix element to, in JSON responses",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "flag",
+                            "exclude"
+                        ],
+                        "type": "string",
+                        "description": "How elements sampled too few times to be accurate are treated; default to the server configuration",
+                        "name": "low_sample",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "2",
+                            "3"
+                        ],
+                        "type": "string",
+                        "description": "Schema version of the payload; default to 2, which is deprecated in favor of 3 (modelv3.DropMatrix). Version 3 could also be opted in to by accepting application/vnd.penguin.v3+json",
+                        "name": "X-Penguin-Schema",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "recent"
+                        ],
+                        "type": "string",
+                        "description": "` + "`" + `recent` + "`" + ` weights recent reports more heavily, halving the weight of reports every few days, for stages currently open only; ` + "`" + `times` + "`" + ` and ` + "`" + `quantity` + "`" + ` are then the weighted counts. Not available for personal drop matrix",
+                        "name": "mode",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Drop Matrix response",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DropMatrixQueryResult"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/matrix/history": {
+            "get": {
+                "description": "Get the drop matrix of a server, including closed zones, as it has been on a past date. Drop matrices are snapshotted daily at 00:00 UTC, and the latest snapshot taken on or before the date is responded.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Historical Drop Matrix",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2022-05-01",
+                        "description": "UTC date in the format of YYYY-MM-DD",
+                        "name": "date",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Drop matrix of the snapshot",
+                        "schema": {
+                            "$ref": "#/definitions/v2.DropMatrixHistoryResult"
+                        }
+                    },
+                    "400": {
+                        "description": "` + "`" + `date` + "`" + ` is missing or invalid, or in the future",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "404": {
+                        "description": "No snapshot has been taken on or before the date",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/pattern": {
+            "get": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "description": "Responds in CSV or TSV, with a row per pattern matrix element, when requested with the ` + "`" + `format` + "`" + ` query or the ` + "`" + `Accept` + "`" + ` header.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/tab-separated-values"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Pattern Matrix",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to query for personal drop matrix or not. If ` + "`" + `is_personal` + "`" + ` equals to ` + "`" + `true` + "`" + `, a valid PenguinID would be required to be provided (PenguinIDAuth)",
+                        "name": "is_personal",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "tsv"
+                        ],
+                        "type": "string",
+                        "description": "Response format; default to json, or negotiated by the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of fields to limit each pattern matrix element to, in JSON responses",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.PatternMatrixQueryResult"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/personal/history": {
+            "get": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Personal Drop History",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "description": "Bucket interval; default to day. ` + "`" + `day` + "`" + ` returns the last 30 game days, while ` + "`" + `week` + "`" + ` returns the last 12 weeks. Buckets without any report are omitted.",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.PersonalHistoryQueryResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid interval",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/personal/reports": {
+            "get": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Personal Drop Reports",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Opaque cursor of the page, as ` + "`" + `nextCursor` + "`" + ` of the previous page; default to the first page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of reports in the page; default to 50, and at most 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/model.DropReportPage"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid cursor or limit",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/snapshots": {
+            "get": {
+                "description": "List anonymized snapshots of the whole dataset of a server, newest first. Each snapshot is a gzip'd ND-JSON file, with a line per stage, item and game day of the server, holding the total times of the stage and the total quantity of the item on that day. Download URLs are signed and expire after a while, so they shall not be stored.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Dataset Snapshots",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v2.DatasetSnapshot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Dataset snapshots are not available",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/result/trends": {
+            "get": {
+                "description": "Responds in CSV or TSV, with a row per interval of each item of each stage, when requested with the ` + "`" + `format` + "`" + ` query or the ` + "`" + `Accept` + "`" + ` header.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/tab-separated-values"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Trends",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week"
+                        ],
+                        "type": "string",
+                        "description": "Length of each interval of trends; default to day. ` + "`" + `hour` + "`" + ` returns the last 72 hours, ` + "`" + `day` + "`" + ` the last 60 game days, and ` + "`" + `week` + "`" + ` the last 26 weeks.",
+                        "name": "granularity",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "tsv"
+                        ],
+                        "type": "string",
+                        "description": "Response format; default to json, or negotiated by the Accept header",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.TrendQueryResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid granularity",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/stages": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stage"
+                ],
+                "summary": "Get All Stages",
+                "parameters": [
+                    {
+                        "enum": [
+                            "zh",
+                            "en",
+                            "ja",
+                            "ko",
+                            "auto"
+                        ],
+                        "type": "string",
+                        "description": "Only include names in this language, or negotiate it by Accept-Language with ` + "`" + `auto` + "`" + `",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of fields to limit each record to",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "allOf": [
+                                    {
+                                        "$ref": "#/definitions/v2.Stage"
+                                    },
+                                    {
+                                        "type": "object",
+                                        "properties": {
+                                            "code_i18n": {
+                                                "$ref": "#/definitions/model.I18nString"
+                                            },
+                                            "existence": {
+                                                "$ref": "#/definitions/model.Existence"
+                                            }
+                                        }
+                                    }
+                                ]
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/stages/{stageId}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Stage"
+                ],
+                "summary": "Get a Stage with ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Stage ID",
+                        "name": "stageId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "zh",
+                            "en",
+                            "ja",
+                            "ko",
+                            "auto"
+                        ],
+                        "type": "string",
+                        "description": "Only include names in this language, or negotiate it by Accept-Language with ` + "`" + `auto` + "`" + `",
+                        "name": "lang",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "description": "Comma separated list of fields to limit each record to",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/v2.Stage"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "code_i18n": {
+                                            "$ref": "#/definitions/model.I18nString"
+                                        },
+                                        "existence": {
+                                            "$ref": "#/definitions/model.Existence"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or missing stageId. Notice that this shall be the **string ID** of the stage, instead of the internally used numerical ID of the stage.",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/stats": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SiteStats"
+                ],
+                "summary": "Get Site Stats",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/v2.SiteStats"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/users": {
+            "post": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "consumes": [
+                    "text/plain"
+                ],
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Login with PenguinID",
+                "parameters": [
+                    {
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "integer"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User ID. In the deprecated backend this is, for some reason, been implemented to return a JSON in the response body but with a ` + "`" + `Content-Type: text/plain` + "`" + ` in the response header instead of the correct ` + "`" + `Content-Type: application/json` + "`" + `. So the v2 API has replicated this behavior to ensure compatibility.",
+                        "schema": {
+                            "$ref": "#/definitions/v2.LoginResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/users/apikeys": {
+            "get": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Get API Keys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.APIKey"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Authenticated with an API key, which could not manage the account",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Create API Key",
+                "parameters": [
+                    {
+                        "description": "API key to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.APIKeyCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "API key created. The key itself is only returned here, and shall be sent as ` + "`" + `Authorization: Bearer \u003ckey\u003e` + "`" + ` by automated reporters.",
+                        "schema": {
+                            "$ref": "#/definitions/model.CreatedAPIKey"
+                        }
+                    },
+                    "400": {
+                        "description": "Maximum number of API keys reached",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "401": {
+                        "description": "Authenticated with an API key, which could not manage the account",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/users/apikeys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Revoke API Key",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "API key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "API key not found",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "401": {
+                        "description": "Authenticated with an API key, which could not manage the account",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/users/identities": {
+            "get": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Get Attached Identities",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/model.AccountIdentity"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Authenticated with an API key, which could not manage the account",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/users/identities/{provider}": {
+            "delete": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Detach Identity",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github",
+                            "google"
+                        ],
+                        "type": "string",
+                        "description": "Identity provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": ""
+                    },
+                    "401": {
+                        "description": "Authenticated with an API key, which could not manage the account",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "404": {
+                        "description": "No identity of the provider is attached",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/users/oauth/callback": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Complete OAuth Login",
+                "parameters": [
+                    {
+                        "description": "Code and state the identity provider redirected back with",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PenguinID the identity is attached to. The PenguinID is also set in the cookie and in the ` + "`" + `X-Penguin-Set-PenguinID` + "`" + ` header, as with the login API.",
+                        "schema": {
+                            "$ref": "#/definitions/v2.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "State is invalid or expired, or not bound to this browser by the authorize API; or the identity has been attached to another PenguinID",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "401": {
+                        "description": "Authenticated with an API key, which could not manage the account",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/users/oauth/{provider}/authorize": {
+            "get": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Account"
+                ],
+                "summary": "Get OAuth Authorize URL",
+                "parameters": [
+                    {
+                        "enum": [
+                            "github",
+                            "google"
+                        ],
+                        "type": "string",
+                        "description": "Identity provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URL of the identity provider to redirect to. When a valid PenguinID is provided, the identity authorized would be attached to it; otherwise the identity is logged in with. The state of the authorization is bound to the browser with a cookie, which the callback has to be completed with.",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthAuthorizeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Identity provider not supported",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "401": {
+                        "description": "Authenticated with an API key, which could not manage the account",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/zones": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "summary": "Get All Zones",
+                "parameters": [
+                    {
+                        "enum": [
+                            "zh",
+                            "en",
+                            "ja",
+                            "ko",
+                            "auto"
+                        ],
+                        "type": "string",
+                        "description": "Only include names in this language, or negotiate it by Accept-Language with ` + "`" + `auto` + "`" + `",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "allOf": [
+                                    {
+                                        "$ref": "#/definitions/v2.Zone"
+                                    },
+                                    {
+                                        "type": "object",
+                                        "properties": {
+                                            "existence": {
+                                                "$ref": "#/definitions/model.Existence"
+                                            },
+                                            "zoneName_i18n": {
+                                                "$ref": "#/definitions/model.I18nString"
+                                            }
+                                        }
+                                    }
+                                ]
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/PenguinStats/api/v2/zones/{zoneId}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Zone"
+                ],
+                "summary": "Get a Zone with ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Zone ID",
+                        "name": "zoneId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "zh",
+                            "en",
+                            "ja",
+                            "ko",
+                            "auto"
+                        ],
+                        "type": "string",
+                        "description": "Only include names in this language, or negotiate it by Accept-Language with ` + "`" + `auto` + "`" + `",
+                        "name": "lang",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/v2.Zone"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "existence": {
+                                            "$ref": "#/definitions/model.Existence"
+                                        },
+                                        "zoneName_i18n": {
+                                            "$ref": "#/definitions/model.I18nString"
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid or missing zoneId. Notice that this shall be the **string ID** of the zone, instead of the v3 API internally used numerical ID of the zone.",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v3-alpha/result/advanced": {
+            "post": {
+                "security": [
+                    {
+                        "PenguinIDAuth": []
+                    }
+                ],
+                "description": "Execute a batch of advanced queries in one request. Each query covers a set of stages, optionally limited to a set of items, on one or more servers, within an optional time range; with ` + "`" + `interval` + "`" + `, the results are bucketed into a trend of intervals of that length instead of a drop matrix. Queries on the same server, time range, interval and source are calculated together, hence batching queries is much cheaper than requesting them one by one. A result is returned per query per server, in the order of the queries and of their ` + "`" + `servers` + "`" + `. As with every v3 endpoint, the request has to accept ` + "`" + `application/vnd.penguin.v3+json` + "`" + ` to opt in to the alpha version of the API.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/vnd.penguin.v3+json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Execute Advanced Queries in Batch",
+                "parameters": [
+                    {
+                        "description": "Queries",
+                        "name": "query",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.AdvancedQueryV3Request"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Results of the queries",
+                        "schema": {
+                            "$ref": "#/definitions/v3.AdvancedQueryResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or the request does not accept application/vnd.penguin.v3+json",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "404": {
+                        "description": "Stage or item not found, or the advanced query is not available on a server queried",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many requests",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v3-alpha/result/matrix": {
+            "get": {
+                "description": "Get the global drop matrix of a server in the v3 schema of result payloads, which is the same as requesting the v2 endpoint with the ` + "`" + `X-Penguin-Schema: 3` + "`" + ` header. As with every v3 endpoint, the request has to accept ` + "`" + `application/vnd.penguin.v3+json` + "`" + ` to opt in to the alpha version of the API.",
+                "produces": [
+                    "application/vnd.penguin.v3+json"
+                ],
+                "tags": [
+                    "Result"
+                ],
+                "summary": "Get Drop Matrix",
+                "parameters": [
+                    {
+                        "enum": [
+                            "CN",
+                            "US",
+                            "JP",
+                            "KR"
+                        ],
+                        "type": "string",
+                        "description": "Server; default to CN",
+                        "name": "server",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Whether to show closed stages or not",
+                        "name": "show_closed_zones",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "flag",
+                            "exclude"
+                        ],
+                        "type": "string",
+                        "description": "How elements sampled too few times to be accurate are treated; default to the server configuration",
+                        "name": "low_sample",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Drop matrix of the server",
+                        "schema": {
+                            "$ref": "#/definitions/v3.DropMatrix"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, or the request does not accept application/vnd.penguin.v3+json",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/pgerr.PenguinError"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "model.APIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the beginning of the key, for users to tell keys apart.",
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "description": "RevokedAt is when the key has been revoked. Null when the key is active.",
+                    "type": "string"
+                }
+            }
+        },
+        "model.AccountIdentity": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "provider": {
+                    "description": "Provider is the name of the identity provider, e.g. \"github\". Provider and Subject are unique together.",
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.CreatedAPIKey": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string",
+                    "example": "pgk_xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"
+                },
+                "lastUsedAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "description": "Prefix is the beginning of the key, for users to tell keys apart.",
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "description": "RevokedAt is when the key has been revoked. Null when the key is active.",
+                    "type": "string"
+                }
+            }
+        },
+        "model.DropReportPage": {
+            "type": "object",
+            "properties": {
+                "nextCursor": {
+                    "description": "NextCursor is the cursor of the next page, which is omitted on the last page.",
+                    "type": "string"
+                },
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ListedDropReport"
+                    }
+                }
+            }
+        },
+        "model.Existence": {
+            "type": "object",
+            "required": [
+                "CN",
+                "JP",
+                "KR",
+                "US"
+            ],
+            "properties": {
+                "CN": {
+                    "description": "CN: 国服 Mainland China Server (maintained by Hypergryph Network Technology Co., Ltd.)",
+                    "$ref": "#/definitions/model.ServerExistence"
+                },
+                "JP": {
+                    "description": "JP: 日服 Japan Server (maintained by Yostar Inc,.)",
+                    "$ref": "#/definitions/model.ServerExistence"
+                },
+                "KR": {
+                    "description": "KR: 韩服 Korea Server (maintained by Yostar Limited)",
+                    "$ref": "#/definitions/model.ServerExistence"
+                },
+                "US": {
+                    "description": "US: 美服/国际服 Global Server (maintained by Yostar Limited)",
+                    "$ref": "#/definitions/model.ServerExistence"
+                }
+            }
+        },
+        "model.I18nString": {
+            "type": "object",
+            "required": [
+                "en",
+                "ja",
+                "ko",
+                "zh"
+            ],
+            "properties": {
+                "en": {
+                    "description": "EN: English (en)",
+                    "type": "string"
+                },
+                "ja": {
+                    "description": "JP: 日本語 (ja)",
+                    "type": "string"
+                },
+                "ko": {
+                    "description": "KR: 한국어 (ko)",
+                    "type": "string"
+                },
+                "zh": {
+                    "description": "ZH: 中文 (zh-CN)",
+                    "type": "string"
+                }
+            }
+        },
+        "model.ListedDrop": {
+            "type": "object",
+            "properties": {
+                "itemId": {
+                    "type": "string"
+                },
+                "quantity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ListedDropReport": {
+            "type": "object",
+            "properties": {
+                "accountId": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "type": "string"
+                },
+                "drops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/model.ListedDrop"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reliability": {
+                    "description": "Reliability, AccountID and DeletedAt are omitted in listings of an account's own reports.",
+                    "type": "integer"
+                },
+                "server": {
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "stageId": {
+                    "type": "string"
+                },
+                "times": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                }
+            }
+        },
+        "model.Notice": {
+            "type": "object",
+            "properties": {
+                "content_i18n": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "existence": {
+                    "type": "object"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "severity": {
+                    "type": "integer"
+                }
+            }
+        },
+        "model.ServerExistence": {
+            "type": "object",
+            "required": [
+                "exist"
+            ],
+            "properties": {
+                "closeTime": {
+                    "type": "integer",
+                    "example": 1635966000000
+                },
+                "exist": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "openTime": {
+                    "type": "integer",
+                    "example": 1634799600000
+                }
+            }
+        },
+        "pgerr.PenguinError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "INVALID_REQUEST"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "invalid request: some or all request parameters are invalid"
+                }
+            }
+        },
+        "pgerr.Reason": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "REJECTED_BY_DROP"
+                },
+                "docsUrl": {
+                    "type": "string",
+                    "example": "https://developer.penguin-stats.io/docs/report-rejections#rejected_by_drop"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "verifier": {
+                    "description": "Verifier is the name of the report verifier which rejected the report. Omitted when not rejected by a verifier.",
+                    "type": "string",
+                    "example": "drop"
+                }
+            }
+        },
+        "types.APIKeyCreateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "name": {
+                    "description": "Name is a free-form description of what the API key is used for.",
+                    "type": "string",
+                    "maxLength": 64,
+                    "example": "MAA on my phone"
+                }
+            }
+        },
+        "types.AdvancedQuery": {
+            "type": "object",
+            "required": [
+                "server",
+                "stageId"
+            ],
+            "properties": {
+                "end": {
+                    "type": "integer"
+                },
+                "interval": {
+                    "type": "integer"
+                },
+                "isPersonal": {
+                    "type": "boolean"
+                },
+                "itemIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "server": {
+                    "type": "string"
+                },
+                "stageId": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.AdvancedQueryRequest": {
+            "type": "object",
+            "required": [
+                "queries"
+            ],
+            "properties": {
+                "queries": {
+                    "type": "array",
+                    "maxItems": 5,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/types.AdvancedQuery"
+                    }
+                }
+            }
+        },
+        "types.AdvancedQueryV3": {
+            "type": "object",
+            "required": [
+                "itemIds",
+                "servers",
+                "stageIds"
+            ],
+            "properties": {
+                "end": {
+                    "type": "integer"
+                },
+                "id": {
+                    "description": "ID is an optional id of the query, echoed in its results so that clients could tell them apart.",
+                    "type": "string",
+                    "maxLength": 64
+                },
+                "interval": {
+                    "description": "Interval buckets the results into a trend of intervals of this length, in milliseconds, when specified.",
+                    "type": "integer"
+                },
+                "isPersonal": {
+                    "type": "boolean"
+                },
+                "itemIds": {
+                    "description": "ItemIDs limits the results to these items. All items are included when empty.",
+                    "type": "array",
+                    "maxItems": 100,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "servers": {
+                    "type": "array",
+                    "maxItems": 4,
+                    "minItems": 1,
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "stageIds": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.AdvancedQueryV3Request": {
+            "type": "object",
+            "required": [
+                "queries"
+            ],
+            "properties": {
+                "queries": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/types.AdvancedQueryV3"
+                    }
+                }
+            }
+        },
+        "types.ArkDrop": {
+            "type": "object",
+            "required": [
+                "dropType",
+                "itemId",
+                "quantity"
+            ],
+            "properties": {
+                "dropType": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "NORMAL_DROP"
+                },
+                "itemId": {
+                    "type": "string",
+                    "example": "30013"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 1000
+                }
+            }
+        },
+        "types.BatchReportRecallRequest": {
+            "type": "object",
+            "required": [
+                "reportHashes"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Reason is an optional free-form description of why the reports are recalled, kept for moderation.",
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "reportHashes": {
+                    "type": "array",
+                    "maxItems": 100,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
+                    ]
+                }
+            }
+        },
+        "types.GraphQLRequest": {
+            "type": "object",
+            "required": [
+                "query"
+            ],
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object"
+                }
+            }
+        },
+        "types.OAuthAuthorizeResponse": {
+            "type": "object",
+            "properties": {
+                "url": {
+                    "description": "URL is the URL of the identity provider to redirect the user to.",
+                    "type": "string"
+                }
+            }
+        },
+        "types.OAuthCallbackRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "state"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.PlanRequest": {
+            "type": "object",
+            "required": [
+                "requirements",
+                "server"
+            ],
+            "properties": {
+                "excludeStages": {
+                    "description": "ExcludeStages are ark stage ids of stages not to be farmed.",
+                    "type": "array",
+                    "maxItems": 200,
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "main_01-07"
+                    ]
+                },
+                "requirements": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/types.PlanRequirement"
+                    }
+                },
+                "server": {
+                    "type": "string",
+                    "enum": [
+                        "CN",
+                        "US",
+                        "JP",
+                        "KR"
+                    ],
+                    "example": "CN"
+                }
+            }
+        },
+        "types.PlanRequirement": {
+            "type": "object",
+            "required": [
+                "itemId",
+                "quantity"
+            ],
+            "properties": {
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 1000000,
+                    "example": 100
+                }
+            }
+        },
+        "types.RecognitionBox": {
+            "type": "object",
+            "properties": {
+                "height": {
+                    "type": "integer"
+                },
+                "width": {
+                    "type": "integer"
+                },
+                "x": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "y": {
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "types.RecognitionOutput": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "description": "Items are items recognized from the screenshot, one per item slot.",
+                    "type": "array",
+                    "maxItems": 64,
+                    "items": {
+                        "$ref": "#/definitions/types.RecognizedItem"
+                    }
+                }
+            }
+        },
+        "types.RecognizedItem": {
+            "type": "object",
+            "required": [
+                "dropType",
+                "itemId",
+                "quantity"
+            ],
+            "properties": {
+                "box": {
+                    "description": "Box is where the item has been recognized in the screenshot.",
+                    "$ref": "#/definitions/types.RecognitionBox"
+                },
+                "confidence": {
+                    "description": "Confidence is how confident the recognizer is of the item, from 0 to 1.",
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.98
+                },
+                "dropType": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "example": "NORMAL_DROP"
+                },
+                "itemId": {
+                    "type": "string",
+                    "example": "30013"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "maximum": 1000
+                },
+                "quantityConfidence": {
+                    "description": "QuantityConfidence is how confident the recognizer is of the quantity, from 0 to 1.",
+                    "type": "number",
+                    "maximum": 1,
+                    "minimum": 0,
+                    "example": 0.95
+                }
+            }
+        },
+        "types.ReportCorrectionRequest": {
+            "type": "object",
+            "properties": {
+                "drops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArkDrop"
+                    }
+                },
+                "reason": {
+                    "description": "Reason is an optional free-form description of what has been corrected, kept for moderation.",
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "times": {
+                    "description": "Times is the number of runs the drops are aggregated from. Defaults to 1 when omitted.",
+                    "type": "integer",
+                    "maximum": 6,
+                    "minimum": 1,
+                    "example": 1
+                }
+            }
+        },
+        "types.ReportRequestMetadata": {
+            "type": "object",
+            "properties": {
+                "fileName": {
+                    "type": "string",
+                    "maxLength": 512
+                },
+                "fingerprint": {
+                    "type": "string",
+                    "maxLength": 128
+                },
+                "itemCount": {
+                    "description": "ItemCount is the total quantity of items recognized from the screenshot, which shall match the drops.",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "lastModified": {
+                    "type": "integer"
+                },
+                "md5": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "recognition": {
+                    "description": "Recognition is the raw output of the recognizer, which is cross-checked against the drops on submission.\nReports with recognition output of low confidence are refused or down-weighted.",
+                    "$ref": "#/definitions/types.RecognitionOutput"
+                },
+                "recognizerAssetsVersion": {
+                    "type": "string",
+                    "maxLength": 32
+                },
+                "recognizerVersion": {
+                    "type": "string",
+                    "maxLength": 32
+                }
+            }
+        },
+        "types.SingleReportRecallRequest": {
+            "type": "object",
+            "required": [
+                "reportHash"
+            ],
+            "properties": {
+                "reason": {
+                    "description": "Reason is an optional free-form description of why the report is recalled, kept for moderation.",
+                    "type": "string",
+                    "maxLength": 256
+                },
+                "reportHash": {
+                    "type": "string",
+                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
+                }
+            }
+        },
+        "types.SingleReportRequest": {
+            "type": "object",
+            "required": [
+                "server",
+                "source",
+                "stageId",
+                "version"
+            ],
+            "properties": {
+                "drops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.ArkDrop"
+                    }
+                },
+                "metadata": {
+                    "$ref": "#/definitions/types.ReportRequestMetadata"
+                },
+                "server": {
+                    "type": "string",
+                    "example": "CN"
+                },
+                "source": {
+                    "description": "Source describes a source of the report. Third-party API consumers should change this to their own name.",
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "your-app-name"
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
+                },
+                "times": {
+                    "description": "Times is the number of runs the drops are aggregated from, for clients farming the same stage repeatedly.\nDefaults to 1 when omitted.",
+                    "type": "integer",
+                    "maximum": 6,
+                    "minimum": 1,
+                    "example": 1
+                },
+                "version": {
+                    "description": "Version describes the version of the source app used to submit this report. Third-party API consumers should change this to their own app version.",
+                    "type": "string",
+                    "maxLength": 128,
+                    "example": "v0.0.0+0000000"
+                }
+            }
+        },
+        "v2.Activity": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "integer"
+                },
+                "existence": {
+                    "type": "object"
+                },
+                "label_i18n": {
+                    "type": "object"
+                },
+                "start": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v2.AdvancedQueryResult": {
+            "type": "object",
+            "properties": {
+                "advanced_results": {
+                    "type": "array",
+                    "items": {
+                        "type": "any"
+                    }
+                }
+            }
+        },
+        "v2.BatchRecallResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.BatchRecallResult"
+                    }
+                }
+            }
+        },
+        "v2.BatchRecallResult": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "Reason describes why the report could not be recalled. Omitted when the report has been recalled.",
+                    "type": "string"
+                },
+                "reasonDetail": {
+                    "description": "ReasonDetail is the structured reason of why the report could not be recalled. Omitted when the report has\nbeen recalled.",
+                    "$ref": "#/definitions/pgerr.Reason"
+                },
+                "recalled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "reportHash": {
+                    "type": "string",
+                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
+                }
+            }
+        },
+        "v2.DatasetSnapshot": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "CreatedAt is the time the snapshot has been generated at, in milliseconds since the epoch",
+                    "type": "integer",
+                    "example": 1651377600000
+                },
+                "name": {
+                    "description": "Name is the file name of the snapshot",
+                    "type": "string",
+                    "example": "drop-reports-daily-20220501T040000Z.ndjson.gz"
+                },
+                "server": {
+                    "type": "string",
+                    "example": "CN"
+                },
+                "size": {
+                    "description": "Size is the size of the snapshot in bytes",
+                    "type": "integer",
+                    "example": 1048576
+                },
+                "url": {
+                    "description": "URL is a signed URL to download the snapshot, which expires after a while",
+                    "type": "string"
+                }
+            }
+        },
+        "v2.DropInfo": {
+            "type": "object",
+            "properties": {
+                "bounds": {
+                    "type": "object"
+                },
+                "dropType": {
+                    "type": "string"
+                },
+                "itemId": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.DropMatrixHistoryResult": {
             "type": "object",
-            "required": [
-                "CN",
-                "JP",
-                "KR",
-                "US"
-            ],
             "properties": {
-                "CN": {
-                    "description": "CN: 国服 Mainland China Server (maintained by Hypergryph Network Technology Co., Ltd.)",
-                    "$ref": "#/definitions/model.ServerExistence"
+                "date": {
+                    "description": "Date is the UTC date the snapshot has been taken on, which is the latest one on or before the date requested.",
+                    "type": "string",
+                    "example": "2022-05-01"
                 },
-                "JP": {
-                    "description": "JP: 日服 Japan Server (maintained by Yostar Inc,.)",
-                    "$ref": "#/definitions/model.ServerExistence"
+                "matrix": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.OneDropMatrixElement"
+                    }
                 },
-                "KR": {
-                    "description": "KR: 韩服 Korea Server (maintained by Yostar Limited)",
-                    "$ref": "#/definitions/model.ServerExistence"
+                "server": {
+                    "type": "string",
+                    "example": "CN"
                 },
-                "US": {
-                    "description": "US: 美服/国际服 Global Server (maintained by Yostar Limited)",
-                    "$ref": "#/definitions/model.ServerExistence"
+                "snapshotAt": {
+                    "description": "SnapshotAt is when the snapshot has been taken, in milliseconds since the epoch.",
+                    "type": "integer",
+                    "example": 1651363200000
                 }
             }
         },
-        "model.I18nString": {
+        "v2.DropMatrixQueryResult": {
             "type": "object",
-            "required": [
-                "en",
-                "ja",
-                "ko",
-                "zh"
-            ],
             "properties": {
-                "en": {
-                    "description": "EN: English (en)",
+                "matrix": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.OneDropMatrixElement"
+                    }
+                }
+            }
+        },
+        "v2.Item": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "existence": {
+                    "type": "object"
+                },
+                "groupID": {
                     "type": "string"
                 },
-                "ja": {
-                    "description": "JP: 日本語 (ja)",
+                "itemId": {
                     "type": "string"
                 },
-                "ko": {
-                    "description": "KR: 한국어 (ko)",
+                "itemType": {
                     "type": "string"
                 },
-                "zh": {
-                    "description": "ZH: 中文 (zh-CN)",
+                "name": {
                     "type": "string"
+                },
+                "name_i18n": {
+                    "type": "object"
+                },
+                "pron": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rarity": {
+                    "type": "integer"
+                },
+                "sortId": {
+                    "type": "integer"
+                },
+                "spriteCoord": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 }
             }
         },
-        "model.Notice": {
+        "v2.ItemDropStage": {
             "type": "object",
             "properties": {
-                "content_i18n": {
+                "apCost": {
+                    "description": "ApCost is the sanity cost of a run of the stage. Null when the cost of the stage is unknown.",
+                    "type": "integer",
+                    "example": 6
+                },
+                "apPerItem": {
+                    "description": "ApPerItem is the expected sanity spent per item dropped, i.e. ApCost divided by Rate. Null when the cost of the\nstage is unknown, or the item has never been reported to drop from the stage.",
+                    "type": "number",
+                    "example": 4.817
+                },
+                "end": {
+                    "type": "integer"
+                },
+                "low_sample": {
+                    "type": "boolean"
+                },
+                "lower": {
+                    "description": "Lower and Upper bound the confidence interval of Rate at the 95% confidence level.",
+                    "type": "number",
+                    "example": 1.2454
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 1322056
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.2456
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
+                },
+                "start": {
+                    "type": "integer",
+                    "example": 1556676000000
+                },
+                "times": {
+                    "type": "integer",
+                    "example": 1061347
+                },
+                "upper": {
+                    "type": "number",
+                    "example": 1.2458
+                }
+            }
+        },
+        "v2.ItemDropStages": {
+            "type": "object",
+            "properties": {
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
+                },
+                "server": {
+                    "type": "string",
+                    "example": "CN"
+                },
+                "stages": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/v2.ItemDropStage"
                     }
+                }
+            }
+        },
+        "v2.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "userID": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.MitigationPreviewResponse": {
+            "type": "object",
+            "properties": {
+                "matchedRule": {
+                    "description": "MatchedRule is the name of the mitigation rule that rewrote the stageId; null when no rule matched.",
+                    "type": "string",
+                    "example": "maa-act18d3-perm-as-rep"
                 },
-                "existence": {
-                    "type": "object"
+                "rewritten": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "id": {
-                    "type": "integer"
+                "rewrittenStageId": {
+                    "type": "string",
+                    "example": "act18d3_01_rep"
                 },
-                "severity": {
+                "stageId": {
+                    "type": "string",
+                    "example": "act18d3_01_perm"
+                }
+            }
+        },
+        "v2.OneDrop": {
+            "type": "object",
+            "properties": {
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 1
+                }
+            }
+        },
+        "v2.OneDropMatrixElement": {
+            "type": "object",
+            "properties": {
+                "end": {
                     "type": "integer"
+                },
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
+                },
+                "low_sample": {
+                    "description": "LowSample is true when the element has been sampled fewer times than the low sample threshold, and its rate\nis likely to be inaccurate.",
+                    "type": "boolean"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 1322056
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
+                },
+                "start": {
+                    "type": "integer",
+                    "example": 1556676000000
+                },
+                "stdDev": {
+                    "type": "number",
+                    "example": 0.114514
+                },
+                "times": {
+                    "type": "integer",
+                    "example": 1061347
                 }
             }
         },
-        "model.ServerExistence": {
+        "v2.OneItemTrend": {
             "type": "object",
-            "required": [
-                "exist"
-            ],
             "properties": {
-                "closeTime": {
+                "quantity": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "times": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "v2.OnePatternMatrixElement": {
+            "type": "object",
+            "properties": {
+                "end": {
                     "type": "integer",
-                    "example": 1635966000000
+                    "x-nullable": true
                 },
-                "exist": {
-                    "type": "boolean",
-                    "example": true
+                "lower": {
+                    "type": "number",
+                    "example": 0.2476
+                },
+                "pattern": {
+                    "$ref": "#/definitions/v2.Pattern"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 159486
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
+                },
+                "start": {
+                    "type": "integer",
+                    "example": 1633032000000
                 },
-                "openTime": {
+                "times": {
                     "type": "integer",
-                    "example": 1634799600000
+                    "example": 641734
+                },
+                "upper": {
+                    "type": "number",
+                    "example": 0.2495
                 }
             }
         },
-        "pgerr.PenguinError": {
+        "v2.Pattern": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "string",
-                    "example": "INVALID_REQUEST"
-                },
-                "message": {
-                    "type": "string",
-                    "example": "invalid request: some or all request parameters are invalid"
+                "drops": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.OneDrop"
+                    }
                 }
             }
         },
-        "types.AdvancedQuery": {
+        "v2.PatternMatrixQueryResult": {
             "type": "object",
-            "required": [
-                "server",
-                "stageId"
-            ],
             "properties": {
-                "end": {
-                    "type": "integer"
-                },
-                "interval": {
-                    "type": "integer"
-                },
-                "isPersonal": {
-                    "type": "boolean"
-                },
-                "itemIds": {
+                "pattern_matrix": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/v2.OnePatternMatrixElement"
                     }
-                },
-                "server": {
-                    "type": "string"
-                },
-                "stageId": {
-                    "type": "string"
-                },
-                "start": {
-                    "type": "integer"
                 }
             }
         },
-        "types.AdvancedQueryRequest": {
+        "v2.PersonalHistoryBucket": {
             "type": "object",
-            "required": [
-                "queries"
-            ],
             "properties": {
-                "queries": {
+                "end": {
+                    "type": "integer",
+                    "example": 1633118400000
+                },
+                "stages": {
                     "type": "array",
-                    "maxItems": 5,
-                    "minItems": 1,
                     "items": {
-                        "$ref": "#/definitions/types.AdvancedQuery"
+                        "$ref": "#/definitions/v2.PersonalHistoryStage"
                     }
+                },
+                "start": {
+                    "type": "integer",
+                    "example": 1633032000000
                 }
             }
         },
-        "types.ArkDrop": {
+        "v2.PersonalHistoryItem": {
             "type": "object",
-            "required": [
-                "dropType",
-                "itemId",
-                "quantity"
-            ],
             "properties": {
-                "dropType": {
-                    "type": "string",
-                    "enum": [
-                        "REGULAR_DROP",
-                        "NORMAL_DROP",
-                        "SPECIAL_DROP",
-                        "EXTRA_DROP",
-                        "FURNITURE"
-                    ]
-                },
                 "itemId": {
                     "type": "string",
-                    "example": "30013"
+                    "example": "30012"
                 },
                 "quantity": {
                     "type": "integer",
-                    "maximum": 1000
+                    "example": 15
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.25
                 }
             }
         },
-        "types.SingleReportRecallRequest": {
+        "v2.PersonalHistoryQueryResult": {
             "type": "object",
-            "required": [
-                "reportHash"
-            ],
             "properties": {
-                "reportHash": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.PersonalHistoryBucket"
+                    }
+                },
+                "interval": {
                     "type": "string",
-                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
+                    "example": "day"
                 }
             }
         },
-        "types.SingleReportRequest": {
+        "v2.PersonalHistoryStage": {
             "type": "object",
-            "required": [
-                "server",
-                "source",
-                "stageId",
-                "version"
-            ],
             "properties": {
-                "drops": {
+                "items": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/types.ArkDrop"
+                        "$ref": "#/definitions/v2.PersonalHistoryItem"
                     }
                 },
-                "server": {
-                    "type": "string",
-                    "example": "CN"
-                },
-                "source": {
-                    "description": "Source describes a source of the report. Third-party API consumers should change this to their own name.",
-                    "type": "string",
-                    "maxLength": 32,
-                    "example": "your-app-name"
-                },
                 "stageId": {
                     "type": "string",
                     "example": "main_01-07"
                 },
-                "version": {
-                    "description": "Version describes the version of the source app used to submit this report. Third-party API consumers should change this to their own app version.",
-                    "type": "string",
-                    "maxLength": 32,
-                    "example": "v0.0.0+0000000"
+                "times": {
+                    "type": "integer",
+                    "example": 12
                 }
             }
         },
-        "v2.Activity": {
+        "v2.Plan": {
             "type": "object",
             "properties": {
-                "end": {
-                    "type": "integer"
-                },
-                "existence": {
-                    "type": "object"
+                "server": {
+                    "type": "string",
+                    "example": "CN"
                 },
-                "label_i18n": {
-                    "type": "object"
+                "stages": {
+                    "description": "Stages are the stages to farm, from the one to farm the most sanity on.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.PlanStage"
+                    }
                 },
-                "start": {
-                    "type": "integer"
+                "totalAp": {
+                    "description": "TotalAp is the sanity expected to be spent by the plan.",
+                    "type": "number",
+                    "example": 1280.5
                 }
             }
         },
-        "v2.AdvancedQueryResult": {
+        "v2.PlanItem": {
             "type": "object",
             "properties": {
-                "advanced_results": {
-                    "type": "array",
-                    "items": {
-                        "type": "any"
-                    }
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
+                },
+                "quantity": {
+                    "type": "number",
+                    "example": 265.8
                 }
             }
         },
-        "v2.DropInfo": {
+        "v2.PlanStage": {
             "type": "object",
             "properties": {
-                "bounds": {
-                    "type": "object"
+                "apCost": {
+                    "type": "integer",
+                    "example": 6
                 },
-                "dropType": {
-                    "type": "string"
+                "items": {
+                    "description": "Items are the expected drops of the runs, of the items required only.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.PlanItem"
+                    }
                 },
-                "itemId": {
-                    "type": "string"
+                "runs": {
+                    "description": "Runs is the expected number of runs, which is fractional as it is of expectations.",
+                    "type": "number",
+                    "example": 213.4
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
                 }
             }
         },
-        "v2.DropMatrixQueryResult": {
+        "v2.PublicAggregate": {
             "type": "object",
             "properties": {
+                "generatedAt": {
+                    "description": "GeneratedAt is when the aggregate has been rendered, in milliseconds since the epoch.",
+                    "type": "integer",
+                    "example": 1651363200000
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.Item"
+                    }
+                },
                 "matrix": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/v2.OneDropMatrixElement"
                     }
+                },
+                "server": {
+                    "type": "string",
+                    "example": "CN"
+                },
+                "stages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.Stage"
+                    }
                 }
             }
         },
-        "v2.Item": {
+        "v2.RecognitionRelease": {
             "type": "object",
             "properties": {
-                "alias": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
-                },
-                "existence": {
-                    "type": "object"
+                "contentType": {
+                    "type": "string",
+                    "example": "application/zip"
                 },
-                "groupID": {
-                    "type": "string"
+                "hash": {
+                    "description": "Hash is the hex-encoded SHA-256 hash of the bundle, which addresses it",
+                    "type": "string",
+                    "example": "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
                 },
-                "itemId": {
-                    "type": "string"
+                "releasedAt": {
+                    "description": "ReleasedAt is the time the bundle has become the latest one of the server, in milliseconds since the epoch",
+                    "type": "integer",
+                    "example": 1651377600000
                 },
-                "itemType": {
-                    "type": "string"
+                "server": {
+                    "type": "string",
+                    "example": "CN"
                 },
-                "name": {
-                    "type": "string"
+                "size": {
+                    "description": "Size is the size of the bundle in bytes",
+                    "type": "integer",
+                    "example": 1048576
                 },
-                "name_i18n": {
-                    "type": "object"
+                "url": {
+                    "description": "URL is the path to download the bundle from, which never changes and could be cached forever",
+                    "type": "string",
+                    "example": "/PenguinStats/api/v2/recognition/bundles/9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
                 },
-                "pron": {
+                "version": {
+                    "type": "string",
+                    "example": "4.2.0"
+                }
+            }
+        },
+        "v2.RecognitionReportResponse": {
+            "type": "object",
+            "properties": {
+                "errors": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
-                "rarity": {
-                    "type": "integer"
-                },
-                "sortId": {
-                    "type": "integer"
+                "taskId": {
+                    "type": "string",
+                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
                 },
-                "spriteCoord": {
+                "verdicts": {
+                    "description": "Verdicts are the final verdicts of each report. Only present when the reports are submitted in synchronous mode.",
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/v2.ReportVerdict"
                     }
                 }
             }
         },
-        "v2.LoginResponse": {
+        "v2.ReportResponse": {
             "type": "object",
             "properties": {
-                "userID": {
+                "reportHash": {
+                    "type": "string",
+                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
+                },
+                "verdict": {
+                    "description": "Verdict is the final verdict of the report. Only present when the report is submitted in synchronous mode.",
+                    "$ref": "#/definitions/v2.ReportVerdict"
+                }
+            }
+        },
+        "v2.ReportTaskRejection": {
+            "type": "object",
+            "properties": {
+                "index": {
+                    "description": "Index is the index of the rejected report in the task.",
+                    "type": "integer"
+                },
+                "message": {
                     "type": "string"
+                },
+                "reason": {
+                    "description": "Reason is the structured reason of why the report is rejected. Omitted when the verifier is not known.",
+                    "$ref": "#/definitions/pgerr.Reason"
+                },
+                "reliability": {
+                    "type": "integer"
+                },
+                "verifier": {
+                    "type": "string",
+                    "example": "drop"
                 }
             }
         },
-        "v2.OneDrop": {
+        "v2.ReportTaskStatus": {
             "type": "object",
             "properties": {
-                "itemId": {
+                "error": {
+                    "description": "Error describes why the task could not be processed. Only present when State is \"failed\".",
+                    "type": "string"
+                },
+                "rejections": {
+                    "description": "Rejections lists why reports of the task are rejected. Only present when State is \"rejected\".",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.ReportTaskRejection"
+                    }
+                },
+                "reportHash": {
+                    "description": "ReportHash is the hash to recall reports of the task with. Only present once reports have been persisted,\ni.e. when State is \"persisted\" or \"rejected\".",
+                    "type": "string",
+                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
+                },
+                "state": {
+                    "description": "State is one of \"queued\", \"verified\", \"persisted\", \"rejected\" and \"failed\". Reports of a \"rejected\" task are\nstill persisted, with a reliability excluding them from statistics.",
                     "type": "string",
-                    "example": "30012"
+                    "example": "persisted"
                 },
-                "quantity": {
+                "taskId": {
+                    "type": "string",
+                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
+                },
+                "updatedAt": {
                     "type": "integer",
-                    "example": 1
+                    "example": 1654718400000
                 }
             }
         },
-        "v2.OneDropMatrixElement": {
+        "v2.ReportVerdict": {
             "type": "object",
             "properties": {
-                "end": {
-                    "type": "integer"
-                },
-                "itemId": {
-                    "type": "string",
-                    "example": "30012"
+                "accepted": {
+                    "type": "boolean",
+                    "example": true
                 },
-                "quantity": {
-                    "type": "integer",
-                    "example": 1322056
+                "message": {
+                    "description": "Message describes why the report is rejected. Omitted when the report is accepted.",
+                    "type": "string"
                 },
-                "stageId": {
-                    "type": "string",
-                    "example": "main_01-07"
+                "reason": {
+                    "description": "Reason is the structured reason of why the report is rejected. Omitted when the report is accepted.",
+                    "$ref": "#/definitions/pgerr.Reason"
                 },
-                "start": {
+                "reliability": {
+                    "description": "Reliability is the reliability the report has been persisted with; 0 means the report passed all verifications.",
                     "type": "integer",
-                    "example": 1556676000000
+                    "example": 0
                 },
-                "times": {
-                    "type": "integer",
-                    "example": 1061347
+                "verifier": {
+                    "description": "Verifier is the name of the verifier rejected the report. Omitted when the report is accepted.",
+                    "type": "string",
+                    "example": "drop"
                 }
             }
         },
-        "v2.OneItemTrend": {
+        "v2.ServerComparison": {
             "type": "object",
             "properties": {
-                "quantity": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
                 },
-                "times": {
+                "servers": {
+                    "description": "Servers lists the element on each server the item has been reported to drop from the stage on.",
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/v2.ServerComparisonElement"
                     }
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
                 }
             }
         },
-        "v2.OnePatternMatrixElement": {
+        "v2.ServerComparisonElement": {
             "type": "object",
             "properties": {
                 "end": {
-                    "type": "integer",
-                    "x-nullable": true
+                    "type": "integer"
                 },
-                "pattern": {
-                    "$ref": "#/definitions/v2.Pattern"
+                "lower": {
+                    "description": "Lower and Upper bound the confidence interval of Rate at the 95% confidence level.",
+                    "type": "number",
+                    "example": 1.2454
                 },
                 "quantity": {
                     "type": "integer",
-                    "example": 159486
+                    "example": 1322056
                 },
-                "stageId": {
+                "rate": {
+                    "type": "number",
+                    "example": 1.2456
+                },
+                "server": {
                     "type": "string",
-                    "example": "main_01-07"
+                    "example": "CN"
                 },
                 "start": {
                     "type": "integer",
-                    "example": 1633032000000
+                    "example": 1556676000000
                 },
                 "times": {
                     "type": "integer",
-                    "example": 641734
-                }
-            }
-        },
-        "v2.Pattern": {
-            "type": "object",
-            "properties": {
-                "drops": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/v2.OneDrop"
-                    }
+                    "example": 1061347
+                },
+                "upper": {
+                    "type": "number",
+                    "example": 1.2458
                 }
             }
         },
-        "v2.PatternMatrixQueryResult": {
+        "v2.SiteStats": {
             "type": "object",
             "properties": {
-                "pattern_matrix": {
+                "sourceBreakdown_24h": {
+                    "description": "SourceBreakdown24H and SourceBreakdown7D break down submissions in the last 24 hours and 7 days by source",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/v2.OnePatternMatrixElement"
+                        "$ref": "#/definitions/v2.SourceSubmissions"
                     }
-                }
-            }
-        },
-        "v2.RecognitionReportResponse": {
-            "type": "object",
-            "properties": {
-                "errors": {
+                },
+                "sourceBreakdown_7d": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/v2.SourceSubmissions"
                     }
                 },
-                "taskId": {
-                    "type": "string",
-                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
-                }
-            }
-        },
-        "v2.ReportResponse": {
-            "type": "object",
-            "properties": {
-                "reportHash": {
-                    "type": "string",
-                    "example": "0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"
-                }
-            }
-        },
-        "v2.SiteStats": {
-            "type": "object",
-            "properties": {
                 "totalApCost": {
                     "type": "integer"
                 },
@@ -1520,6 +4279,33 @@ const docTemplate = `{
                     "items": {
                         "$ref": "#/definitions/v2.TotalStageTime"
                     }
+                },
+                "uniqueReporters_24h": {
+                    "description": "UniqueReporters24H and UniqueReporters7D are the numbers of distinct accounts submitting reports in the last\n24 hours and 7 days",
+                    "type": "integer",
+                    "example": 1024
+                },
+                "uniqueReporters_7d": {
+                    "type": "integer",
+                    "example": 4096
+                }
+            }
+        },
+        "v2.SourceSubmissions": {
+            "type": "object",
+            "properties": {
+                "reports": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "source": {
+                    "description": "Source is one of ` + "`" + `maa` + "`" + `, ` + "`" + `frontend` + "`" + ` and ` + "`" + `others` + "`" + `",
+                    "type": "string",
+                    "example": "maa"
+                },
+                "times": {
+                    "type": "integer",
+                    "example": 120
                 }
             }
         },
@@ -1577,6 +4363,93 @@ const docTemplate = `{
                 }
             }
         },
+        "v2.StageEfficiency": {
+            "type": "object",
+            "properties": {
+                "apCost": {
+                    "description": "ApCost is the sanity cost of a run of the stage. Null when the cost of the stage is unknown.",
+                    "type": "integer",
+                    "example": 6
+                },
+                "apPerItem": {
+                    "description": "ApPerItem is the expected sanity spent per item dropped, i.e. ApCost divided by Rate. Null when the cost of the\nstage is unknown, or the item has never been reported to drop from the stage.",
+                    "type": "number",
+                    "example": 4.817
+                },
+                "end": {
+                    "type": "integer"
+                },
+                "itemsPerAp": {
+                    "description": "ItemsPerAp is the expected number of items dropped per sanity spent.",
+                    "type": "number",
+                    "example": 0.2076
+                },
+                "low_sample": {
+                    "type": "boolean"
+                },
+                "lower": {
+                    "description": "Lower and Upper bound the confidence interval of Rate at the 95% confidence level.",
+                    "type": "number",
+                    "example": 1.2454
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 1322056
+                },
+                "rank": {
+                    "description": "Rank starts from 1.",
+                    "type": "integer",
+                    "example": 1
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.2456
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
+                },
+                "start": {
+                    "type": "integer",
+                    "example": 1556676000000
+                },
+                "times": {
+                    "type": "integer",
+                    "example": 1061347
+                },
+                "upper": {
+                    "type": "number",
+                    "example": 1.2458
+                }
+            }
+        },
+        "v2.StageEfficiencyRanking": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "integer"
+                },
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
+                },
+                "server": {
+                    "type": "string",
+                    "example": "CN"
+                },
+                "stages": {
+                    "description": "Stages are ranked from the most efficient one.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.StageEfficiency"
+                    }
+                },
+                "start": {
+                    "description": "StartTime and EndTime bound the reports drop rates are of. Omitted when drop rates are of the drop matrix.",
+                    "type": "integer"
+                }
+            }
+        },
         "v2.StageTrend": {
             "type": "object",
             "properties": {
@@ -1658,6 +4531,100 @@ const docTemplate = `{
                     "type": "object"
                 }
             }
+        },
+        "v3.AdvancedQueryResult": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v3.AdvancedQueryResultEntry"
+                    }
+                }
+            }
+        },
+        "v3.AdvancedQueryResultEntry": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID is the id of the query given by the client, if any.",
+                    "type": "string"
+                },
+                "matrix": {
+                    "description": "Matrix is the drop matrix of the query, when the query has no ` + "`" + `interval` + "`" + `.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.OneDropMatrixElement"
+                    }
+                },
+                "server": {
+                    "type": "string",
+                    "example": "CN"
+                },
+                "trend": {
+                    "description": "Trend is the trend of each stage of the query, when the query has an ` + "`" + `interval` + "`" + `.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/v2.StageTrend"
+                    }
+                }
+            }
+        },
+        "v3.DropMatrix": {
+            "type": "object",
+            "properties": {
+                "elements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v3.DropMatrixElement"
+                    }
+                },
+                "server": {
+                    "type": "string",
+                    "example": "CN"
+                }
+            }
+        },
+        "v3.DropMatrixElement": {
+            "type": "object",
+            "properties": {
+                "endTime": {
+                    "type": "integer"
+                },
+                "itemId": {
+                    "type": "string",
+                    "example": "30012"
+                },
+                "lowSample": {
+                    "description": "LowSample is true when the element has been sampled fewer times than the low sample threshold, and its rate\nis likely to be inaccurate.",
+                    "type": "boolean"
+                },
+                "quantity": {
+                    "type": "integer",
+                    "example": 1322056
+                },
+                "rate": {
+                    "type": "number",
+                    "example": 1.2456
+                },
+                "stageId": {
+                    "type": "string",
+                    "example": "main_01-07"
+                },
+                "startTime": {
+                    "description": "StartTime and EndTime are the time range the element has been accumulated in, in milliseconds since the\nepoch. EndTime is omitted if the range has not ended yet.",
+                    "type": "integer",
+                    "example": 1556676000000
+                },
+                "stdDev": {
+                    "type": "number",
+                    "example": 0.114514
+                },
+                "times": {
+                    "type": "integer",
+                    "example": 1061347
+                }
+            }
         }
     },
     "securityDefinitions": {
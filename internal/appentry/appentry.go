@@ -8,17 +8,29 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/config"
 	controllermeta "github.com/penguin-statistics/backend-next/internal/controller/meta"
 	controllerv2 "github.com/penguin-statistics/backend-next/internal/controller/v2"
+	controllerv3 "github.com/penguin-statistics/backend-next/internal/controller/v3"
 	"github.com/penguin-statistics/backend-next/internal/infra"
 	"github.com/penguin-statistics/backend-next/internal/model/cache"
 	"github.com/penguin-statistics/backend-next/internal/pkg/crypto"
 	"github.com/penguin-statistics/backend-next/internal/pkg/logger"
 	"github.com/penguin-statistics/backend-next/internal/repo"
+	grpcserver "github.com/penguin-statistics/backend-next/internal/server/grpc"
 	"github.com/penguin-statistics/backend-next/internal/server/httpserver"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
 	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+	"github.com/penguin-statistics/backend-next/internal/workers/alertwkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/anomalywkr"
 	"github.com/penguin-statistics/backend-next/internal/workers/calcwkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/gamedatawkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/historywkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/partitionwkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/patternwkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/publicwkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/recentwkr"
 	"github.com/penguin-statistics/backend-next/internal/workers/reportwkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/snapshotwkr"
+	"github.com/penguin-statistics/backend-next/internal/workers/webhookwkr"
 )
 
 func ProvideOptions(includeSwagger bool) []fx.Option {
@@ -26,6 +38,7 @@ func ProvideOptions(includeSwagger bool) []fx.Option {
 		// Misc
 		fx.Provide(config.Parse),
 		fx.Provide(httpserver.Create),
+		fx.Provide(grpcserver.Create),
 		fx.Provide(svr.CreateEndpointGroups),
 		fx.Provide(crypto.NewCrypto),
 
@@ -46,31 +59,52 @@ func ProvideOptions(includeSwagger bool) []fx.Option {
 		// are called in the order of their registration.
 		fx.Invoke(logger.Configure),
 		fx.Invoke(infra.SentryInit),
+		fx.Invoke(service.CheckMigrations),
 		fx.Invoke(cache.Initialize),
+		fx.Invoke(service.RunConfigReload),
+		fx.Invoke(service.ListenCacheInvalidations),
+		fx.Invoke(service.InjectReportStages),
+		fx.Invoke(service.ReplayReportSpool),
+		fx.Invoke(service.RunScheduler),
 
 		// Controllers (v2)
 		controllerv2.Module(),
 
 		// Controllers (v3)
 		// controllerv3.Module(),
+		// only the advanced query API of v3 is served for now
+		fx.Invoke(controllerv3.RegisterResult),
 
 		// Controllers (meta)
 		controllermeta.Module(),
 
+		// gRPC Services
+		fx.Invoke(grpcserver.RegisterReport),
+
 		// Workers
 		fx.Invoke(calcwkr.Start),
 		fx.Invoke(reportwkr.Start),
+		fx.Invoke(partitionwkr.Start),
+		fx.Invoke(anomalywkr.Start),
+		fx.Invoke(recentwkr.Start),
+		fx.Invoke(snapshotwkr.Start),
+		fx.Invoke(gamedatawkr.Start),
+		fx.Invoke(webhookwkr.Start),
+		fx.Invoke(alertwkr.Start),
+		fx.Invoke(patternwkr.Start),
+		fx.Invoke(historywkr.Start),
+		fx.Invoke(publicwkr.Start),
 
 		// fx Extra Options
 		fx.StartTimeout(1 * time.Second),
-		// StopTimeout is not typically needed, since we're using fiber's Shutdown(),
-		// in which fiber has its own IdleTimeout for controlling the shutdown timeout.
-		// It acts as a countermeasure in case the fiber app is not properly shutting down.
+		// StopTimeout is not typically reached, since every stop hook has its own deadline: the HTTP server
+		// drains in-flight requests first, then workers settle in-flight tasks, and infrastructures are closed
+		// last. It acts as a countermeasure in case any of them is not properly shutting down.
 		fx.StopTimeout(5 * time.Minute),
 	}
 
 	if includeSwagger {
-		opts = append(opts, fx.Invoke(controllermeta.RegisterSwagger))
+		opts = append(opts, fx.Invoke(controllermeta.RegisterSwagger), fx.Invoke(controllermeta.RegisterSDK))
 	}
 
 	return opts
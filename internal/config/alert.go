@@ -0,0 +1,96 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Kinds of alert channels.
+const (
+	AlertChannelDiscord  = "discord"
+	AlertChannelTelegram = "telegram"
+)
+
+// AlertChannel is a Discord or Telegram channel alerts are pushed to, along with rules of which alerts are routed
+// to it.
+type AlertChannel struct {
+	Name string `json:"name"`
+	// Kind is either `discord` or `telegram`.
+	Kind string `json:"kind"`
+	// URL is the Discord webhook URL of the channel. Only for Discord channels.
+	URL string `json:"url,omitempty"`
+	// BotToken and ChatID are the token of the Telegram bot alerts are sent by, and the chat they are sent to. Only
+	// for Telegram channels.
+	BotToken string `json:"botToken,omitempty"`
+	ChatID   string `json:"chatId,omitempty"`
+
+	// Alerts are kinds of alerts routed to the channel. All kinds are routed when empty.
+	Alerts []string `json:"alerts,omitempty"`
+	// Servers are servers of alerts routed to the channel. Alerts of all servers, and those not of any server, are
+	// routed when empty.
+	Servers []string `json:"servers,omitempty"`
+	// MinSeverity is the least severity of alerts routed to the channel, one of `info`, `warning` and `critical`.
+	// All severities are routed when empty.
+	MinSeverity string `json:"minSeverity,omitempty"`
+}
+
+// AlertChannels are channels alerts are pushed to, in the form of a JSON array of AlertChannel, e.g.
+// `[{"name":"ops","kind":"discord","url":"https://discord.com/api/webhooks/...","alerts":["report.backlog"]}]`.
+// An empty value disables alerts.
+type AlertChannels []AlertChannel
+
+// Decode implements envconfig.Decoder.
+func (c *AlertChannels) Decode(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		*c = nil
+		return nil
+	}
+
+	var channels AlertChannels
+	if err := json.Unmarshal([]byte(value), &channels); err != nil {
+		return fmt.Errorf("alert channels are not a JSON array of channels: %w", err)
+	}
+	names := make(map[string]struct{}, len(channels))
+	for i, channel := range channels {
+		if channel.Name == "" {
+			return fmt.Errorf("alert channel #%d has no name", i)
+		}
+		if _, ok := names[channel.Name]; ok {
+			return fmt.Errorf("alert channel %q is given more than once", channel.Name)
+		}
+		names[channel.Name] = struct{}{}
+
+		switch channel.Kind {
+		case AlertChannelDiscord:
+			if !strings.HasPrefix(channel.URL, "https://") {
+				return fmt.Errorf("discord alert channel %q has no valid url", channel.Name)
+			}
+		case AlertChannelTelegram:
+			if channel.BotToken == "" || channel.ChatID == "" {
+				return fmt.Errorf("telegram alert channel %q has no botToken or chatId", channel.Name)
+			}
+		default:
+			return fmt.Errorf("alert channel %q has unknown kind %q", channel.Name, channel.Kind)
+		}
+
+		switch channel.MinSeverity {
+		case "", "info", "warning", "critical":
+		default:
+			return fmt.Errorf("alert channel %q has unknown minSeverity %q", channel.Name, channel.MinSeverity)
+		}
+	}
+
+	*c = channels
+	return nil
+}
+
+// String returns names and kinds of the channels, leaving out their URLs and tokens, which are secrets.
+func (c AlertChannels) String() string {
+	names := make([]string, 0, len(c))
+	for _, channel := range c {
+		names = append(names, channel.Name+"("+channel.Kind+")")
+	}
+	return strings.Join(names, ",")
+}
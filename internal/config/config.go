@@ -12,6 +12,13 @@ type Config struct {
 	// Address is the listen address would listen on.
 	Address string
 
+	// GRPCAddress is the listen address the gRPC server would listen on. The gRPC server is disabled when empty.
+	GRPCAddress string `split_words:"true"`
+
+	// TrustedProxies are IPs or CIDRs of reverse proxies in front of the HTTP and gRPC servers. X-Forwarded-For
+	// is only honored when the peer is one of them, so that clients could not spoof their IP otherwise.
+	TrustedProxies []string `split_words:"true" default:"::1,127.0.0.1"`
+
 	// DevMode to indicate development mode. When true, the program would spin up utilities for debugging and
 	// provide a more contextual message when encountered a panic. See internal/server/httpserver/http.go for the
 	// actual implementation details.
@@ -26,8 +33,28 @@ type Config struct {
 	// https://bun.uptrace.dev/postgres/#pgdriver for more details on how to construct a PostgreSQL DSN.
 	PostgresDSN string `required:"true" split_words:"true"`
 
+	// PostgresReplicaDSN is the data source name for a read-only replica of the PostgreSQL database. Read-only
+	// queries made on demand, such as advanced queries and site stats, are routed to the replica while it is
+	// available, and to the primary otherwise. Duplicate checks, refills of caches dropped after writes and
+	// refreshes of the calculator worker always read from the primary. All queries are routed to the primary when
+	// left empty.
+	PostgresReplicaDSN string `split_words:"true"`
+
 	BunDebugVerbose bool `split_words:"true"`
 
+	// PostgresQueryTimeout is the timeout of a single query, on top of the deadline of its context. Workers
+	// recalculating matrices are only bound by WorkerTimeout. Set to 0 to disable.
+	PostgresQueryTimeout time.Duration `split_words:"true" default:"30s"`
+
+	// PostgresSlowQueryThreshold is the duration after which a query is logged and counted as slow. Set to 0 to
+	// disable.
+	PostgresSlowQueryThreshold time.Duration `split_words:"true" default:"1s"`
+
+	// MigrationsPendingAllowed allows the service to start while migrations of the database schema are pending.
+	// Otherwise the service refuses to start until they have been applied with `--migrate`, as queries of the
+	// binary may rely on them.
+	MigrationsPendingAllowed bool `split_words:"true"`
+
 	// NatsURL is the URL of the NATS server. See https://pkg.go.dev/github.com/nats-io/nats.go#Connect
 	// for more information on how to construct a NATS URL.
 	NatsURL string `required:"true" split_words:"true" default:"nats://127.0.0.1:4222"`
@@ -48,14 +75,14 @@ type Config struct {
 	// Normal contributors should not need to change this: when left empty, recognition report is simply disabled.
 	RecognitionEncryptionIV []int `split_words:"true"`
 
-	// HTTPServerShutdownTimeout is the timeout for the HTTP server to shut down gracefully.
+	// HTTPServerShutdownTimeout is the timeout for the HTTP and gRPC servers to drain in-flight requests on shutdown.
 	HTTPServerShutdownTimeout time.Duration `required:"true" split_words:"true" default:"60s"`
 
 	// GeoIPDBPath is the path to the GeoIP2 database.
 	GeoIPDBPath string `required:"true" split_words:"true" default:"vendors/maxmind/assets/geolite2/GeoLite2-Country.mmdb"`
 
 	// WorkerInterval describes the interval in-between different batches
-	WorkerInterval time.Duration `required:"true" split_words:"true" default:"10m"`
+	WorkerInterval time.Duration `required:"true" split_words:"true" default:"10m" reload:"true"`
 
 	// WorkerSeparation describes the separation time in-between different microtasks
 	WorkerSeparation time.Duration `required:"true" split_words:"true" default:"3s"`
@@ -68,12 +95,328 @@ type Config struct {
 	// WorkerEnabled is a flag to indicate whether to enable the worker.
 	WorkerEnabled bool `split_words:"true"`
 
-	// AdminKey is the key used to authenticate the admin API.
+	// DistributedLockTTL is the duration distributed locks, which keep recalculations from running concurrently across
+	// instances, are held for without being renewed. Locks are renewed while their holders are alive, so it only
+	// bounds how long a lock outlives a holder which has gone away.
+	DistributedLockTTL time.Duration `split_words:"true" default:"30s"`
+
+	// SchedulerJobSpecs overrides cron specs of scheduled jobs, as semicolon separated `job=spec` pairs, such as
+	// `snapshot=0 4 * * *;anomaly=@every 30m`. Jobs not listed run on their default specs, which follow the intervals
+	// configured for them.
+	SchedulerJobSpecs string `split_words:"true" reload:"true"`
+
+	// SchedulerDisabledJobs is a list of scheduled jobs which are not run on schedule, but could still be triggered
+	// manually.
+	SchedulerDisabledJobs []string `split_words:"true"`
+
+	// WorkerLeaderElection is a flag to indicate whether instances with WorkerEnabled elect a leader among
+	// themselves, so that only the leader runs the calculator and recent matrix workers.
+	WorkerLeaderElection bool `split_words:"true" default:"true"`
+
+	// WorkerLeaderLeaseTTL is the duration the leader holds its lease for without renewing it. Another instance
+	// takes over within it once the leader is gone.
+	WorkerLeaderLeaseTTL time.Duration `split_words:"true" default:"30s"`
+
+	// ReportRecallWindow is the duration after a report has been submitted, within which the report could be recalled.
+	ReportRecallWindow time.Duration `required:"true" split_words:"true" default:"24h"`
+
+	// ReportScreenshotDedupWindow is the duration within which reports with the same screenshot md5 are merged
+	// into the report first submitted. Set to 0 to disable deduplication.
+	ReportScreenshotDedupWindow time.Duration `split_words:"true" default:"1h" reload:"true"`
+
+	// ReportQuotaTrusted is the maximum number of reports an account could submit per stage per hour, when
+	// authenticated with an API key. Set to 0 to disable the quota.
+	ReportQuotaTrusted int `split_words:"true" default:"300" reload:"true"`
+
+	// ReportQuotaUnknown is the maximum number of reports an account could submit per stage per hour, when not
+	// authenticated with an API key. Set to 0 to disable the quota.
+	ReportQuotaUnknown int `split_words:"true" default:"60" reload:"true"`
+
+	// RecognitionRejectConfidence is the confidence of recognized items or quantities, below which reports submitted
+	// with recognition output are refused. Set to 0 to disable.
+	RecognitionRejectConfidence float64 `split_words:"true" default:"0.5" reload:"true"`
+
+	// RecognitionFlagConfidence is the confidence of recognized items or quantities, below which reports submitted
+	// with recognition output are accepted but down-weighted. Set to 0 to disable.
+	RecognitionFlagConfidence float64 `split_words:"true" default:"0.8" reload:"true"`
+
+	// RecognitionMaxOverlap is the maximum intersection over union of the bounding boxes of two recognized items,
+	// beyond which the report is refused, as an item is likely recognized twice. Set to 0 to disable.
+	RecognitionMaxOverlap float64 `split_words:"true" default:"0.3" reload:"true"`
+
+	// RecognitionRequiredSources are sources expected to submit recognition output along with every report. Reports
+	// from them without recognition output are accepted but down-weighted, as the cross-check would be skipped
+	// otherwise by simply omitting the output.
+	RecognitionRequiredSources []string `split_words:"true" reload:"true"`
+
+	// ReportWorkerConcurrency is the number of report tasks a single instance processes concurrently.
+	// Set to 0 to use the number of CPUs.
+	ReportWorkerConcurrency int `split_words:"true" default:"0"`
+
+	// ReportWorkerMaxInFlight is the maximum number of report tasks delivered but not yet acked, shared across
+	// all instances consuming the report stream.
+	ReportWorkerMaxInFlight int `split_words:"true" default:"128"`
+
+	// ReportPublishTimeout is the timeout of a single attempt to publish a report task to NATS.
+	ReportPublishTimeout time.Duration `split_words:"true" default:"10s"`
+
+	// ReportPublishRetries is the number of retries after the first attempt to publish a report task has failed.
+	ReportPublishRetries int `split_words:"true" default:"2"`
+
+	// ReportPublishRetryBackoff is the base of the exponential, fully jittered backoff in-between retries of
+	// publishing a report task.
+	ReportPublishRetryBackoff time.Duration `split_words:"true" default:"200ms"`
+
+	// ReportPublishBreakerThreshold is the number of consecutive report tasks failed to be published, after which
+	// reports are rejected with 503 for ReportPublishBreakerCooldown without trying. Set to 0 to disable.
+	ReportPublishBreakerThreshold int `split_words:"true" default:"5"`
+
+	// ReportPublishBreakerCooldown is the duration reports are rejected for once ReportPublishBreakerThreshold is
+	// reached, after which a single report task is published to probe whether NATS has recovered.
+	ReportPublishBreakerCooldown time.Duration `split_words:"true" default:"30s"`
+
+	// ReportSpoolPath is the path to the local spool report tasks are written to when they could not be published
+	// to NATS, and replayed from once NATS recovers. Leave empty to disable the spool, rejecting such reports instead.
+	ReportSpoolPath string `split_words:"true"`
+
+	// ReportSpoolReplayInterval is the interval of replaying report tasks in the spool to NATS.
+	ReportSpoolReplayInterval time.Duration `split_words:"true" default:"15s"`
+
+	// ReportBatchMaxRows is the maximum number of reports persisted within a single transaction. A batch is
+	// persisted once it holds ReportBatchMaxRows reports or ReportBatchWindow has passed since its first report task,
+	// whichever comes first, so batches are bounded by ReportWorkerConcurrency as well. Set to 0 to persist each
+	// report task on its own.
+	ReportBatchMaxRows int `split_words:"true" default:"100"`
+
+	// ReportBatchWindow is how long the first report task of a batch waits for others to be persisted together.
+	// Set to 0 to never hold report tasks back, persisting only those verified while the previous batch is being
+	// persisted together.
+	ReportBatchWindow time.Duration `split_words:"true" default:"50ms"`
+
+	// ReportScoringURL is the URL of the external service scoring reports for how likely they are fake, which
+	// verified report tasks are POSTed to as JSON. Reports are not scored when empty. The URL could carry
+	// credentials, hence is a secret.
+	ReportScoringURL string `split_words:"true" reload:"true" secret:"true"`
+
+	// ReportScoringTimeout is the timeout of scoring a single report task, after which its reports are persisted
+	// without scores.
+	ReportScoringTimeout time.Duration `split_words:"true" default:"500ms" reload:"true"`
+
+	// ReportScoringBreakerThreshold is the number of consecutive report tasks failed to be scored, after which
+	// scoring is skipped for ReportScoringBreakerCooldown. Set to 0 to disable.
+	ReportScoringBreakerThreshold int `split_words:"true" default:"5"`
+
+	// ReportScoringBreakerCooldown is the duration scoring is skipped for once ReportScoringBreakerThreshold is
+	// reached, after which a single report task is scored to probe whether the scoring service has recovered.
+	ReportScoringBreakerCooldown time.Duration `split_words:"true" default:"30s"`
+
+	// AnomalyWorkerInterval describes the interval in-between runs of drop rate anomaly detection. Anomaly detection
+	// only runs when WorkerEnabled is true.
+	AnomalyWorkerInterval time.Duration `split_words:"true" default:"1h" reload:"true"`
+
+	// AnomalyRecentWindow is the duration of the recent window of reports, whose drop rates are compared against
+	// the baseline of AnomalyBaselineWindow right before it.
+	AnomalyRecentWindow time.Duration `split_words:"true" default:"24h"`
+
+	// AnomalyBaselineWindow is the duration of the baseline window of reports right before AnomalyRecentWindow.
+	AnomalyBaselineWindow time.Duration `split_words:"true" default:"336h"`
+
+	// AnomalyMinTimes is the minimum number of times in both windows for a drop rate to be checked for anomalies.
+	AnomalyMinTimes int `split_words:"true" default:"100" reload:"true"`
+
+	// AnomalyZThreshold is the minimum absolute z-score of the difference of drop rates to be flagged as an anomaly.
+	AnomalyZThreshold float64 `split_words:"true" default:"5" reload:"true"`
+
+	// AlertChannels are Discord and Telegram channels ops alerts, such as report backlogs, spikes of rejected reports
+	// and drop rate anomalies, are pushed to, along with rules of which alerts are routed to each of them. See
+	// AlertChannels for the format.
+	AlertChannels AlertChannels `split_words:"true" reload:"true" secret:"true"`
+
+	// AlertCooldown is how long an alert is not pushed again after being pushed, across all instances.
+	AlertCooldown time.Duration `split_words:"true" default:"30m" reload:"true"`
+
+	// AlertCheckInterval describes the interval in-between checks of ops conditions alerts are pushed for. Checks
+	// only run when WorkerEnabled is true.
+	AlertCheckInterval time.Duration `split_words:"true" default:"5m" reload:"true"`
+
+	// AlertReportBacklogThreshold is the number of report tasks pending in the stream, beyond which the report
+	// backlog is alerted. Set to 0 to disable.
+	AlertReportBacklogThreshold int `split_words:"true" default:"10000" reload:"true"`
+
+	// AlertRejectionSpikeRatio is the ratio of reports rejected by verifiers among those submitted within
+	// AlertRejectionWindow, beyond which the spike of rejections is alerted. Set to 0 to disable.
+	AlertRejectionSpikeRatio float64 `split_words:"true" default:"0.2" reload:"true"`
+
+	// AlertRejectionMinReports is the minimum number of reports within AlertRejectionWindow for spikes of
+	// rejections to be checked.
+	AlertRejectionMinReports int `split_words:"true" default:"200" reload:"true"`
+
+	// AlertRejectionWindow is the duration of reports checked for spikes of rejections.
+	AlertRejectionWindow time.Duration `split_words:"true" default:"1h" reload:"true"`
+
+	// WebhookDeliveryInterval describes the interval in-between attempts of pending webhook deliveries. Deliveries
+	// are also attempted right after events are sent, so it mostly bounds how soon failed deliveries are retried.
+	WebhookDeliveryInterval time.Duration `split_words:"true" default:"15s" reload:"true"`
+
+	// WebhookMaxAttempts is the maximum number of attempts of a webhook delivery, after which it is given up.
+	WebhookMaxAttempts int `split_words:"true" default:"8" reload:"true"`
+
+	// WebhookTimeout is the timeout of a single attempt of a webhook delivery.
+	WebhookTimeout time.Duration `split_words:"true" default:"10s" reload:"true"`
+
+	// DropReportPartitionsAhead is the number of monthly partitions of drop_reports created ahead of the current
+	// month. Only takes effect once drop_reports has been partitioned with `--migrate --partition-drop-reports`.
+	DropReportPartitionsAhead int `split_words:"true" default:"2"`
+
+	// DropReportPartitionInterval describes the interval in-between checks for missing drop_reports partitions.
+	DropReportPartitionInterval time.Duration `split_words:"true" default:"6h" reload:"true"`
+
+	// SnapshotS3Endpoint, SnapshotS3Region, SnapshotS3Bucket, SnapshotS3AccessKeyID and SnapshotS3SecretAccessKey
+	// locate the S3-compatible object storage dataset snapshots are uploaded to. Dataset snapshots are disabled when
+	// SnapshotS3Endpoint or SnapshotS3Bucket is left empty.
+	SnapshotS3Endpoint        string `split_words:"true"`
+	SnapshotS3Region          string `split_words:"true" default:"us-east-1"`
+	SnapshotS3Bucket          string `split_words:"true"`
+	SnapshotS3AccessKeyID     string `split_words:"true"`
+	SnapshotS3SecretAccessKey string `split_words:"true"`
+
+	// SnapshotWorkerInterval describes the interval in-between generations of dataset snapshots. Snapshots are only
+	// generated when WorkerEnabled is true.
+	SnapshotWorkerInterval time.Duration `split_words:"true" default:"24h" reload:"true"`
+
+	// SnapshotDownloadURLTTL is how long signed download URLs of dataset snapshots are valid for.
+	SnapshotDownloadURLTTL time.Duration `split_words:"true" default:"1h" reload:"true"`
+
+	// SnapshotRetention is how long dataset snapshots are kept for in object storage. The latest snapshot of each
+	// server is always kept. Set to 0 to keep snapshots indefinitely.
+	SnapshotRetention time.Duration `split_words:"true" default:"720h" reload:"true"`
+
+	// MatrixSnapshotRetention is how long daily snapshots of drop matrices are kept for. Snapshots are taken every
+	// day at 00:00 UTC when WorkerEnabled is true. Set to 0 to keep snapshots indefinitely.
+	MatrixSnapshotRetention time.Duration `split_words:"true" default:"17520h" reload:"true"`
+
+	// PublicAggregateInterval describes the interval in-between renders of the public aggregates of all servers,
+	// which are rendered only when WorkerEnabled is true.
+	PublicAggregateInterval time.Duration `split_words:"true" default:"10m" reload:"true"`
+
+	// PrecompressEncodings lists encodings cached aggregate responses, such as the default drop matrix and the
+	// public aggregates, are compressed in ahead and stored in Redis, so that they are served without compressing
+	// them per request. Available encodings are: br, zstd, gzip.
+	PrecompressEncodings []string `split_words:"true" default:"br,zstd,gzip" reload:"true"`
+
+	// PrecompressTTL is how long precompressed copies of cached aggregate responses are kept in Redis for. Copies
+	// are keyed by the version of their aggregate, so the TTL only bounds how long outdated copies linger.
+	PrecompressTTL time.Duration `split_words:"true" default:"1h" reload:"true"`
+
+	// SchemaV2DeprecatedAt is when the v2 schema of result payloads, such as the drop matrix, has been deprecated in
+	// favor of the v3 schema, in RFC 3339. Payloads in the v2 schema are responded with a Deprecation header once set.
+	SchemaV2DeprecatedAt time.Time `split_words:"true"`
+
+	// SchemaV2SunsetAt is when the v2 schema of result payloads is to be removed, in RFC 3339. Payloads in the v2
+	// schema are responded with a Sunset header once set.
+	SchemaV2SunsetAt time.Time `split_words:"true"`
+
+	// SchemaV2DeprecationURL is the URL of the documentation on migrating off the v2 schema of result payloads,
+	// linked from payloads in the v2 schema once either SchemaV2DeprecatedAt or SchemaV2SunsetAt is set.
+	SchemaV2DeprecationURL string `split_words:"true"`
+
+	// GameDataSyncURL is the URL of the directory item_table.json and stage_table.json of the external gamedata
+	// repository are fetched from.
+	GameDataSyncURL string `split_words:"true" default:"https://raw.githubusercontent.com/Kengxxiao/ArknightsGameData/master/zh_CN/gamedata/excel"`
+
+	// GameDataSyncInterval describes the interval in-between syncs of game data from GameDataSyncURL. Changes found
+	// are staged for approval by moderators. Syncs only run when WorkerEnabled is true. Set to 0 to disable.
+	GameDataSyncInterval time.Duration `split_words:"true" default:"6h"`
+
+	// ReportVerifiersDisabled is a list of names of report verifiers to disable. Verifiers could also be disabled
+	// or reordered at runtime via the `report_verifiers` property.
+	ReportVerifiersDisabled []string `split_words:"true"`
+
+	// ReportGateAllowlistOnly is a flag to indicate whether reports from sources and versions not allowed by any
+	// report gate with the `allow` action are down-weighted.
+	ReportGateAllowlistOnly bool `split_words:"true"`
+
+	// ConfigOverridesPath is the path to a JSON file overriding fields of Config tagged with `reload`, which is
+	// watched for changes and reloaded without restarting. Overrides in the file are in turn overridden by those of
+	// the `config_overrides` property. No file is watched when left empty.
+	ConfigOverridesPath string `split_words:"true"`
+
+	// ConfigReloadInterval is the interval in-between checks for changes to config overrides. Changes made via the
+	// admin API are broadcast to all instances right away, so it only bounds how long other changes take to apply.
+	ConfigReloadInterval time.Duration `split_words:"true" default:"1m"`
+
+	// AdminKey is the key used to authenticate the admin API, shared among moderators. Requests authenticated with
+	// it are audited as constant.AuditDefaultActor.
 	AdminKey string `split_words:"true"`
 
+	// AdminActorKeys are keys of moderators to authenticate the admin API with, keyed by the moderator they identify
+	// in the audit log, e.g. `alice:<key>,bob:<key>`. Keys shorter than 64 characters are ignored.
+	AdminActorKeys map[string]string `split_words:"true"`
+
+	// OAuthRedirectURL is the frontend page identity providers redirect to after authorization, which shall post
+	// the code and the state it receives to the OAuth callback API. OAuth login is disabled when empty.
+	OAuthRedirectURL string `split_words:"true"`
+
+	// OAuthGitHubClientID and OAuthGitHubClientSecret are credentials of the GitHub OAuth app. GitHub login is
+	// disabled when left empty.
+	OAuthGitHubClientID     string `split_words:"true"`
+	OAuthGitHubClientSecret string `split_words:"true"`
+
+	// OAuthGoogleClientID and OAuthGoogleClientSecret are credentials of the Google OAuth client. Google login is
+	// disabled when left empty.
+	OAuthGoogleClientID     string `split_words:"true"`
+	OAuthGoogleClientSecret string `split_words:"true"`
+
+	// RateLimitIP, RateLimitAccount and RateLimitAPIKey are the rate limits of requests to the v2 and v3 APIs,
+	// counted per IP, per account and per API key respectively. Requests authenticated with an API key are only
+	// counted towards RateLimitAPIKey, requests authenticated with a PenguinID towards both RateLimitAccount and
+	// RateLimitIP, so that accounts created in bulk could not evade the limit of their IP, and other requests
+	// towards RateLimitIP. See RateLimitTier for the format.
+	RateLimitIP      RateLimitTier `split_words:"true" default:"600/1m" reload:"true"`
+	RateLimitAccount RateLimitTier `split_words:"true" default:"1200/1m" reload:"true"`
+	RateLimitAPIKey  RateLimitTier `split_words:"true" default:"3000/1m" reload:"true"`
+
 	// MatrixWorkerSourceCategories is a list of categories that the matrix worker will run for.
 	// Available categories are: all, automated, manual.
 	MatrixWorkerSourceCategories []string `required:"true" split_words:"true" default:"all"`
+
+	// TrendWorkerGranularities is a list of granularities that the worker calculates saved trends in.
+	// Available granularities are: hour, day, week.
+	TrendWorkerGranularities []string `split_words:"true" default:"hour,day,week"`
+
+	// MatrixWorkerIncremental is a flag to indicate whether the worker refreshes the drop matrix incrementally,
+	// recalculating only stages with reports submitted since the last run.
+	MatrixWorkerIncremental bool `split_words:"true" default:"true"`
+
+	// MatrixWorkerFullRefreshHour is the hour of day, in UTC, after which the worker fully recalculates the drop
+	// matrix once a day when MatrixWorkerIncremental is enabled. Defaults to 20, which is 04:00 in UTC+8.
+	MatrixWorkerFullRefreshHour int `split_words:"true" default:"20"`
+
+	// MatrixMaterializedViews is a flag to indicate whether the global drop matrix and pattern matrix are calculated
+	// from materialized views of aggregated reports, refreshed by the worker at the start of each batch, instead of
+	// from the reports themselves. The drop matrix is then always fully recalculated, as reading the views is cheap.
+	MatrixMaterializedViews bool `split_words:"true" default:"false"`
+
+	// MatrixLowSampleThreshold is the minimum number of times a drop matrix element shall be sampled, below which
+	// its rate is considered too inaccurate to be shown as is. Set to 0 to disable.
+	MatrixLowSampleThreshold int `split_words:"true" default:"10" reload:"true"`
+
+	// MatrixLowSampleMode is how drop matrix elements below MatrixLowSampleThreshold are treated when not requested
+	// otherwise. Available modes are: flag, which flags them with `low_sample`, and exclude, which leaves them out.
+	MatrixLowSampleMode string `split_words:"true" default:"flag" reload:"true"`
+
+	// RecentMatrixWorkerInterval describes the interval in-between recalculations of the recent drop matrix, which
+	// weights recent reports more heavily. Recalculations only run when WorkerEnabled is true, and requests are
+	// served with an empty recent drop matrix until it has been calculated once.
+	RecentMatrixWorkerInterval time.Duration `split_words:"true" default:"15m" reload:"true"`
+
+	// RecentMatrixHalfLife is the age at which reports weigh half as much as those just submitted in the recent
+	// drop matrix.
+	RecentMatrixHalfLife time.Duration `split_words:"true" default:"72h"`
+
+	// RecentMatrixWindow is the duration of reports the recent drop matrix is calculated from. Reports older than
+	// it are left out, as their weights are negligible.
+	RecentMatrixWindow time.Duration `split_words:"true" default:"336h"`
 }
 
 func Parse() (*Config, error) {
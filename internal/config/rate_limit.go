@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitTier is a token bucket rate limit in the form of `<requests>/<period>`, e.g. `600/1m`, which allows
+// bursts of up to `requests` requests, refilled evenly over `period`. An empty value or `0` disables the tier.
+type RateLimitTier struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Decode implements envconfig.Decoder.
+func (t *RateLimitTier) Decode(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		*t = RateLimitTier{}
+		return nil
+	}
+
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return fmt.Errorf("rate limit tier %q is not in the form of <requests>/<period>", value)
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return fmt.Errorf("rate limit tier %q has invalid requests", value)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("rate limit tier %q has invalid period", value)
+	}
+
+	*t = RateLimitTier{Requests: n, Period: d}
+	return nil
+}
+
+// Enabled reports whether requests are limited by the tier.
+func (t RateLimitTier) Enabled() bool {
+	return t.Requests > 0 && t.Period > 0
+}
+
+// String returns the tier in the form it is decoded from.
+func (t RateLimitTier) String() string {
+	return strconv.Itoa(t.Requests) + "/" + t.Period.String()
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitTierDecode(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected RateLimitTier
+		enabled  bool
+		wantErr  bool
+	}{
+		{"", RateLimitTier{}, false, false},
+		{"0", RateLimitTier{}, false, false},
+		{"600/1m", RateLimitTier{Requests: 600, Period: time.Minute}, true, false},
+		{" 10/1s ", RateLimitTier{Requests: 10, Period: time.Second}, true, false},
+		{"0/1m", RateLimitTier{Period: time.Minute}, false, false},
+		{"600", RateLimitTier{}, false, true},
+		{"-1/1m", RateLimitTier{}, false, true},
+		{"many/1m", RateLimitTier{}, false, true},
+		{"600/minute", RateLimitTier{}, false, true},
+		{"600/0s", RateLimitTier{}, false, true},
+	}
+	for _, test := range tests {
+		var tier RateLimitTier
+		err := tier.Decode(test.value)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Decode(%q): expected error %v, got %v", test.value, test.wantErr, err)
+			continue
+		}
+		if tier != test.expected {
+			t.Errorf("Decode(%q): expected %+v, got %+v", test.value, test.expected, tier)
+		}
+		if tier.Enabled() != test.enabled {
+			t.Errorf("Decode(%q): expected enabled %v, got %v", test.value, test.enabled, tier.Enabled())
+		}
+	}
+}
+
+func TestRateLimitTierString(t *testing.T) {
+	tier := RateLimitTier{Requests: 600, Period: time.Minute}
+	var decoded RateLimitTier
+	if err := decoded.Decode(tier.String()); err != nil || decoded != tier {
+		t.Errorf("Expected %s to decode to %+v, got %+v (%v)", tier, tier, decoded, err)
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// reloadTag marks fields of Config which could be overridden at runtime without restarting, such as thresholds,
+// rate limits, cache TTLs and worker intervals. Fields without it are only read once on start.
+const reloadTag = "reload"
+
+// secretTag marks fields of Config whose values are secrets, such as URLs and tokens carrying credentials, which are
+// never disclosed by admin APIs or audit logs.
+const secretTag = "secret"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// ReloadableFields returns names of fields of Config which could be overridden at runtime, in alphabetical order.
+func ReloadableFields() []string {
+	t := reflect.TypeOf(Config{})
+	fields := make([]string, 0)
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get(reloadTag) == "true" {
+			fields = append(fields, t.Field(i).Name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// IsSecretField returns whether the field of Config of name is a secret.
+func IsSecretField(name string) bool {
+	field, ok := reflect.TypeOf(Config{}).FieldByName(name)
+	return ok && field.Tag.Get(secretTag) == "true"
+}
+
+// Override returns a copy of c with fields overridden by overrides, keyed by field names and valued in the same
+// format as their environment variables. Only fields returned by ReloadableFields could be overridden.
+func (c *Config) Override(overrides map[string]string) (*Config, error) {
+	overridden := *c
+	v := reflect.ValueOf(&overridden).Elem()
+	for name, value := range overrides {
+		field, ok := v.Type().FieldByName(name)
+		if !ok || field.Tag.Get(reloadTag) != "true" {
+			return nil, fmt.Errorf("config %s does not exist or could not be reloaded", name)
+		}
+		if err := decodeField(v.FieldByIndex(field.Index), value); err != nil {
+			return nil, fmt.Errorf("invalid value %q of config %s: %w", value, name, err)
+		}
+	}
+	return &overridden, nil
+}
+
+// Changed returns names of fields returned by ReloadableFields which differ between c and other.
+func (c *Config) Changed(other *Config) []string {
+	a, b := reflect.ValueOf(c).Elem(), reflect.ValueOf(other).Elem()
+	changed := make([]string, 0)
+	for _, name := range ReloadableFields() {
+		if !reflect.DeepEqual(a.FieldByName(name).Interface(), b.FieldByName(name).Interface()) {
+			changed = append(changed, name)
+		}
+	}
+	return changed
+}
+
+// FieldValue returns the value of the field of name of c.
+func FieldValue(c *Config, name string) any {
+	return reflect.ValueOf(c).Elem().FieldByName(name).Interface()
+}
+
+// decodeField decodes value into field the same way envconfig decodes environment variables of the field's type.
+func decodeField(field reflect.Value, value string) error {
+	if decoder, ok := field.Addr().Interface().(envconfig.Decoder); ok {
+		return decoder.Decode(value)
+	}
+
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", field.Type())
+		}
+		values := make([]string, 0)
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+		field.Set(reflect.ValueOf(values))
+	default:
+		return fmt.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
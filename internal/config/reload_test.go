@@ -0,0 +1,92 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOverride(t *testing.T) {
+	base := &Config{
+		PostgresDSN:       "postgres://localhost",
+		AnomalyMinTimes:   100,
+		AnomalyZThreshold: 5,
+		RateLimitIP:       RateLimitTier{Requests: 600, Period: time.Minute},
+	}
+	tests := []struct {
+		overrides map[string]string
+		changed   []string
+		wantErr   bool
+	}{
+		{map[string]string{}, []string{}, false},
+		{map[string]string{"AnomalyMinTimes": "100"}, []string{}, false},
+		{map[string]string{"AnomalyMinTimes": "50"}, []string{"AnomalyMinTimes"}, false},
+		{map[string]string{"AnomalyZThreshold": "3.5", "WebhookTimeout": "5s"}, []string{"AnomalyZThreshold", "WebhookTimeout"}, false},
+		{map[string]string{"RateLimitIP": "60/1s"}, []string{"RateLimitIP"}, false},
+		{map[string]string{"RecognitionRequiredSources": "MeoAssistant, ,penguin-stats.io"}, []string{"RecognitionRequiredSources"}, false},
+		{map[string]string{"PostgresDSN": "postgres://elsewhere"}, nil, true},
+		{map[string]string{"NoSuchField": "1"}, nil, true},
+		{map[string]string{"AnomalyMinTimes": "many"}, nil, true},
+		{map[string]string{"WebhookTimeout": "10"}, nil, true},
+		{map[string]string{"RateLimitIP": "600"}, nil, true},
+	}
+	for _, test := range tests {
+		overridden, err := base.Override(test.overrides)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Override(%v): expected error %v, got %v", test.overrides, test.wantErr, err)
+			continue
+		}
+		if test.wantErr {
+			continue
+		}
+		if changed := base.Changed(overridden); !reflect.DeepEqual(changed, test.changed) {
+			t.Errorf("Override(%v): expected changed %v, got %v", test.overrides, test.changed, changed)
+		}
+	}
+	if base.AnomalyMinTimes != 100 {
+		t.Errorf("Expected Override to leave the original config as is, got AnomalyMinTimes %d", base.AnomalyMinTimes)
+	}
+}
+
+func TestOverrideDecodes(t *testing.T) {
+	overridden, err := (&Config{}).Override(map[string]string{
+		"AnomalyZThreshold":          "3.5",
+		"WebhookTimeout":             "5s",
+		"RateLimitIP":                "60/1s",
+		"RecognitionRequiredSources": "MeoAssistant, ,penguin-stats.io",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name  string
+		value any
+	}{
+		{"AnomalyZThreshold", 3.5},
+		{"WebhookTimeout", time.Second * 5},
+		{"RateLimitIP", RateLimitTier{Requests: 60, Period: time.Second}},
+		{"RecognitionRequiredSources", []string{"MeoAssistant", "penguin-stats.io"}},
+	}
+	for _, e := range expected {
+		if value := FieldValue(overridden, e.name); !reflect.DeepEqual(value, e.value) {
+			t.Errorf("Expected %s to be %v, got %v", e.name, e.value, value)
+		}
+	}
+}
+
+func TestIsSecretField(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{"ReportScoringURL", true},
+		{"AlertChannels", true},
+		{"AnomalyMinTimes", false},
+		{"NoSuchField", false},
+	}
+	for _, test := range tests {
+		if secret := IsSecretField(test.name); secret != test.expected {
+			t.Errorf("IsSecretField(%s): expected %v, got %v", test.name, test.expected, secret)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package constant
+
+// Kinds of alerts pushed to alert channels, by which alerts are routed to channels.
+const (
+	// AlertReportBacklog is pushed when report tasks pending in the stream exceed config.Config
+	// AlertReportBacklogThreshold.
+	AlertReportBacklog = "report.backlog"
+	// AlertRejectionSpike is pushed when the ratio of reports rejected by verifiers of a server exceeds
+	// config.Config AlertRejectionSpikeRatio.
+	AlertRejectionSpike = "report.rejection_spike"
+	// AlertAnomaly is pushed when drop rate anomalies are flagged by the anomaly worker.
+	AlertAnomaly = "anomaly.detected"
+	// AlertTest is only pushed on request, to test whether channels are reachable.
+	AlertTest = "test"
+)
+
+// Severities of alerts, in ascending order.
+const (
+	AlertSeverityInfo     = "info"
+	AlertSeverityWarning  = "warning"
+	AlertSeverityCritical = "critical"
+)
+
+// AlertSeverityRanks ranks severities, by which alerts are compared against the least severity of channels.
+var AlertSeverityRanks = map[string]int{
+	AlertSeverityInfo:     0,
+	AlertSeverityWarning:  1,
+	AlertSeverityCritical: 2,
+}
+
+// AlertCooldownKeyPrefix prefixes the Redis key marking an alert as recently pushed, followed by the dedup key of
+// the alert.
+const AlertCooldownKeyPrefix = "alert-cooldown:"
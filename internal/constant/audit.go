@@ -0,0 +1,9 @@
+package constant
+
+const (
+	// AuditDefaultActor is the actor of admin requests authenticated with the shared admin key.
+	AuditDefaultActor = "admin"
+
+	// AuditRedactedValue replaces secret values in request bodies recorded to the audit log.
+	AuditRedactedValue = "<redacted>"
+)
@@ -12,4 +12,18 @@ const (
 	// PenguinIDAuthorizationRealm is the authorization realm (prefix of value
 	// in the `Authorization` header)
 	PenguinIDAuthorizationRealm = "PenguinID"
+
+	// APIKeyAuthorizationRealm is the authorization realm of API keys, which are prefixed with APIKeyPrefix.
+	APIKeyAuthorizationRealm = "Bearer"
+	APIKeyPrefix             = "pgk_"
+	// APIKeyMaxPerAccount is the maximum number of active API keys an account could have.
+	APIKeyMaxPerAccount = 10
+
+	// OAuthStateKeyPrefix prefixes the Redis key of a pending OAuth authorization, followed by its state.
+	OAuthStateKeyPrefix = "oauth-state:"
+	// OAuthStateCookieKey is the cookie holding the state of the pending OAuth authorization started by the browser,
+	// so that callbacks with states of authorizations started elsewhere are refused, which prevents login CSRF.
+	OAuthStateCookieKey = "oauthState"
+	// OAuthStateTTLSec bounds how long a user could take to authorize at the identity provider, in seconds
+	OAuthStateTTLSec = 600
 )
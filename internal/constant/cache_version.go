@@ -0,0 +1,33 @@
+package constant
+
+const (
+	// CacheVersionKeyPrefix prefixes the Redis key of the version of a cached aggregate, followed by the name and
+	// the key of the aggregate, separated by a colon.
+	CacheVersionKeyPrefix = "cache-version:"
+
+	// CacheInvalidationSubject is the NATS subject version bumps of cached aggregates are published to, so that
+	// every instance drops its own copy of the aggregate.
+	CacheInvalidationSubject = "CACHE.INVALIDATE"
+
+	// CacheVersionDropMatrix versions the max accumulable drop matrix of a server, keyed by the server.
+	CacheVersionDropMatrix = "dropMatrix"
+	// CacheVersionRecentDropMatrix versions the recent drop matrix of a server, keyed by the server.
+	CacheVersionRecentDropMatrix = "recentDropMatrix"
+	// CacheVersionPatternMatrix versions the latest pattern matrix of a server, keyed by the server.
+	CacheVersionPatternMatrix = "patternMatrix"
+	// CacheVersionTrend versions saved trends of a server in a granularity, keyed by the server and the granularity
+	// joined with CacheSep.
+	CacheVersionTrend = "trend"
+	// CacheVersionSiteStats versions site stats of a server, keyed by the server.
+	CacheVersionSiteStats = "siteStats"
+	// CacheVersionPersonal versions personal drop matrices and personal histories of an account, keyed by the
+	// account id.
+	CacheVersionPersonal = "personal"
+	// CacheVersionGameData versions game data of hot lookups cached in-process, such as items, stages and drop
+	// infos, keyed by an empty key.
+	CacheVersionGameData = "gameData"
+	// CacheVersionRecognitionRelease versions the latest recognition bundle of a server, keyed by the server.
+	CacheVersionRecognitionRelease = "recognitionRelease"
+	// CacheVersionDropPattern versions elements and the hash of a drop pattern, keyed by the pattern id.
+	CacheVersionDropPattern = "dropPattern"
+)
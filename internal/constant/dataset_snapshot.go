@@ -0,0 +1,9 @@
+package constant
+
+const (
+	// DatasetSnapshotKeyPrefix prefixes the object keys of dataset snapshots, followed by the server and the
+	// file name of the snapshot.
+	DatasetSnapshotKeyPrefix = "snapshots/"
+
+	DatasetSnapshotContentType = "application/x-ndjson"
+)
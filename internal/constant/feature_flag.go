@@ -0,0 +1,15 @@
+package constant
+
+// Keys of feature flags gating behaviors, which are toggled via the admin API. Behaviors gated by flags not created
+// yet keep their default, as noted on each key.
+const (
+	// FeatureFlagV3AdvancedQuery gates the v3 advanced query API, per server of each query. Enabled by default.
+	FeatureFlagV3AdvancedQuery = "v3_advanced_query"
+	// FeatureFlagSyncReport gates the synchronous mode of report submissions, per server of the report. Reports
+	// requested to be processed synchronously are queued as usual when disabled. Enabled by default.
+	FeatureFlagSyncReport = "sync_report"
+	// FeatureFlagVerifierPrefix prefixes flags gating report verifiers, followed by the name of the verifier, per
+	// server and account of the report, so that new verifiers could be rolled out gradually. Verifiers enabled in
+	// the pipeline run by default.
+	FeatureFlagVerifierPrefix = "verifier:"
+)
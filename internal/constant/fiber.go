@@ -1,3 +1,13 @@
 package constant
 
-const ContextKeyRequestID = "requestid"
+const (
+	ContextKeyRequestID = "requestid"
+
+	// ContextKeyPenguinID and ContextKeyAPIKeyID hold the PenguinID and the id of the API key a request has
+	// authenticated with, when it has authenticated with an API key.
+	ContextKeyPenguinID = "penguinid"
+	ContextKeyAPIKeyID  = "apikeyid"
+
+	// ContextKeyAdminActor holds who an admin request has authenticated as.
+	ContextKeyAdminActor = "adminactor"
+)
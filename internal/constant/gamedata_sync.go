@@ -0,0 +1,23 @@
+package constant
+
+const (
+	// GameDataChangeKindItem and GameDataChangeKindStage are kinds of records game data changes are of.
+	GameDataChangeKindItem  = "item"
+	GameDataChangeKindStage = "stage"
+
+	// GameDataChangeActionCreate and GameDataChangeActionUpdate are actions game data changes take when applied.
+	GameDataChangeActionCreate = "create"
+	GameDataChangeActionUpdate = "update"
+
+	// GameDataChangeStatusPending changes wait for a moderator to approve or reject them. Pending changes are
+	// superseded once a later sync stages a different change to the same record, or finds no change to it at all.
+	GameDataChangeStatusPending    = "pending"
+	GameDataChangeStatusApproved   = "approved"
+	GameDataChangeStatusRejected   = "rejected"
+	GameDataChangeStatusSuperseded = "superseded"
+
+	// GameDataSyncServer is the server of the game data synced from, as the gamedata repository is of CN.
+	GameDataSyncServer = "CN"
+	// GameDataSyncLanguage is the language of names and codes in the game data synced from.
+	GameDataSyncLanguage = "zh"
+)
@@ -0,0 +1,14 @@
+package constant
+
+// Names of distributed locks guarding operations that must not run concurrently across instances. Locks of
+// operations on a server are suffixed with the server, and locks of jobs with the name of the job.
+const (
+	LockDropMatrix       = "drop-matrix:"
+	LockRecentDropMatrix = "recent-drop-matrix:"
+	LockPatternMatrix    = "pattern-matrix:"
+	LockTrend            = "trend:"
+	LockSiteStats        = "site-stats:"
+	LockAggregateViews   = "aggregate-views"
+	LockGameDataSync     = "gamedata-sync"
+	LockJob              = "job:"
+)
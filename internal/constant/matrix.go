@@ -0,0 +1,19 @@
+package constant
+
+const (
+	// MatrixRefreshSubjectPrefix prefixes the NATS subject progress of an on-demand matrix refresh is published
+	// to, followed by the refresh id.
+	MatrixRefreshSubjectPrefix = "MATRIX.REFRESH."
+
+	MatrixRefreshStateStarted  = "started"
+	MatrixRefreshStateProgress = "progress"
+	MatrixRefreshStateFinished = "finished"
+	MatrixRefreshStateFailed   = "failed"
+
+	// RecentDropMatrixKeyPrefix prefixes the Redis key of the recent drop matrix of a server, followed by the server.
+	// The recent drop matrix is calculated by the recent matrix worker alone, and requests only read it.
+	RecentDropMatrixKeyPrefix = "recent-drop-matrix-results:"
+
+	PersonalHistoryIntervalDay  = "day"
+	PersonalHistoryIntervalWeek = "week"
+)
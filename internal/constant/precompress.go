@@ -0,0 +1,5 @@
+package constant
+
+// PrecompressedKeyPrefix prefixes the Redis key of a precompressed response, followed by the key of the response.
+// The key is a hash of the body of the response in each encoding it has been compressed in, keyed by the encoding.
+const PrecompressedKeyPrefix = "precompressed:"
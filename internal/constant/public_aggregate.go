@@ -0,0 +1,20 @@
+package constant
+
+// PublicAggregateKeyPrefix prefixes the Redis key of the pre-rendered public aggregate of a server, followed by
+// the server. The key is a hash of the body of the aggregate in each encoding it has been compressed in, keyed by
+// the encoding.
+const PublicAggregateKeyPrefix = "public-aggregated:"
+
+// PublicAggregateMetaKeyPrefix prefixes the Redis key describing the pre-rendered public aggregate of a server,
+// followed by the server. The key is a hash of fields PublicAggregateField*, kept apart from the bodies so that
+// requests revalidated are responded without reading any body.
+const PublicAggregateMetaKeyPrefix = "public-aggregated-meta:"
+
+const (
+	// PublicAggregateFieldGeneratedAt is when the aggregate has been rendered, in milliseconds since the epoch.
+	PublicAggregateFieldGeneratedAt = "generatedAt"
+	// PublicAggregateFieldETag is the hash of the body, as an entity tag.
+	PublicAggregateFieldETag = "etag"
+	// PublicAggregateFieldEncodings are the encodings the body is held in, separated by commas.
+	PublicAggregateFieldEncodings = "encodings"
+)
@@ -6,7 +6,29 @@ const (
 
 	StdDevDigits = 4
 
+	// ConfidenceZ is the z-score of the confidence level of intervals in query results, which is 95%
+	ConfidenceZ           = 1.959964
+	ConfidenceBoundDigits = 4
+
 	SourceCategoryManual    = "manual"
 	SourceCategoryAutomated = "automated"
 	SourceCategoryAll       = "all"
+
+	TrendGranularityHour = "hour"
+	TrendGranularityDay  = "day"
+	TrendGranularityWeek = "week"
+
+	// LowSampleModeFlag and LowSampleModeExclude are how drop matrix elements sampled fewer times than the low
+	// sample threshold are treated: either flagged with `low_sample`, or excluded from the result.
+	LowSampleModeFlag    = "flag"
+	LowSampleModeExclude = "exclude"
+
+	// DropMatrixModeRecent is the mode of the drop matrix weighting recent reports more heavily.
+	DropMatrixModeRecent = "recent"
+
+	// DateLayout is the layout of dates in queries, such as the date of drop matrix history.
+	DateLayout = "2006-01-02"
 )
+
+// TrendGranularities lists all granularities saved trends could be calculated in.
+var TrendGranularities = []string{TrendGranularityHour, TrendGranularityDay, TrendGranularityWeek}
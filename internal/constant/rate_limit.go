@@ -0,0 +1,13 @@
+package constant
+
+const (
+	// RateLimitKeyPrefix prefixes the Redis key of the token bucket of a rate limited subject, followed by
+	// the kind of the subject and its id, e.g. `rate-limit:ip:127.0.0.1`.
+	RateLimitKeyPrefix = "rate-limit:"
+
+	// RateLimitLimitHeader, RateLimitRemainingHeader and RateLimitResetHeader are the standard rate limit headers.
+	// See https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
@@ -0,0 +1,14 @@
+package constant
+
+import "time"
+
+const (
+	// RecognitionBundleURLPrefix prefixes the URL of recognition bundles, followed by the hash of the bundle.
+	RecognitionBundleURLPrefix = "/PenguinStats/api/v2/recognition/bundles/"
+
+	RecognitionBundleDefaultContentType = "application/octet-stream"
+
+	// RecognitionReleaseMaxAge is how long responses of the latest recognition bundle of a server could be cached
+	// for. It is short, so that clients pick up new releases soon.
+	RecognitionReleaseMaxAge = time.Minute * 5
+)
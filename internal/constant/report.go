@@ -5,40 +5,100 @@ const (
 
 	ExtraProcessTypeGachaBox = "GACHABOX"
 
+	// ReportSubjectDeadLetter is the NATS subject report tasks are dead-lettered to, after the report worker
+	// failed to consume them for ReportTaskMaxDeliveries times.
+	ReportSubjectDeadLetter = "REPORT.DLQ"
+	ReportTaskMaxDeliveries = 3
+
+	// ReportTaskStatusKeyPrefix prefixes the Redis key of the processing status of a report task.
+	ReportTaskStatusKeyPrefix = "report-task-status:"
+	// ReportTaskStatusChannelPrefix prefixes the Redis channel updates of the processing status of a report task
+	// are published to.
+	ReportTaskStatusChannelPrefix = "report-task-status-updates:"
+
+	ReportTaskStateQueued    = "queued"
+	ReportTaskStateVerified  = "verified"
+	ReportTaskStatePersisted = "persisted"
+	ReportTaskStateRejected  = "rejected"
+	ReportTaskStateFailed    = "failed"
+
+	// ReportImportCheckpointKeyPrefix prefixes the Redis key of the bitmap recording which records of
+	// a bulk report import have been processed.
+	ReportImportCheckpointKeyPrefix = "report-import-checkpoint:"
+
+	// ReportReplayKeyPrefix prefixes the Redis key claiming a report task for a replay persisting its reports, so
+	// that the task could not be replayed, hence persisted, twice.
+	ReportReplayKeyPrefix = "report-replay:"
+
+	// ReportVelocityIPKeyPrefix and ReportVelocityAccountKeyPrefix prefix the Redis keys of the sorted sets
+	// recording report tasks submitted recently by an IP and by an account respectively.
+	ReportVelocityIPKeyPrefix      = "report-velocity:ip:"
+	ReportVelocityAccountKeyPrefix = "report-velocity:account:"
+
+	// AccountTrustDirtyKey is the Redis key of the set of accounts whose trust score shall be recalculated.
+	AccountTrustDirtyKey = "account-trust-dirty"
+
+	// ReportScreenshotKeyPrefix prefixes the Redis key recording which report task has first submitted
+	// a screenshot md5, within the screenshot deduplication window. The claim is released when the report is recalled.
+	ReportScreenshotKeyPrefix = "report-screenshot:"
+
+	// ReportQuotaKeyPrefix prefixes the Redis key counting reports submitted by an account for a stage
+	// within an hour.
+	ReportQuotaKeyPrefix = "report-quota:"
+
+	// ShadowBansKey is the Redis key of the JSON list of active shadow bans.
+	ShadowBansKey = "shadow-bans"
+
+	// ReportGatesKey is the Redis key of the JSON list of report gates.
+	ReportGatesKey = "report-gates"
+
+	// StageRewriteRulesKey is the Redis key of the JSON list of stage rewrite rules.
+	StageRewriteRulesKey = "stage-rewrite-rules"
+
+	// ReportGateActionAllow, ReportGateActionReject and ReportGateActionDownweight are actions of report gates.
+	// Reports matching a rejecting gate are refused on submission, while those matching a down-weighting gate are
+	// accepted but persisted with ViolationReliabilityReportGate.
+	ReportGateActionAllow      = "allow"
+	ReportGateActionReject     = "reject"
+	ReportGateActionDownweight = "downweight"
+
+	// DropTypeRegular, DropTypeSpecial, DropTypeExtra, DropTypeRecognitionOnly and DropTypeFurniture are names of
+	// builtin drop types in the drop type registry. Other drop types could be registered at runtime.
 	DropTypeRegular         = "REGULAR"
 	DropTypeSpecial         = "SPECIAL"
 	DropTypeExtra           = "EXTRA"
 	DropTypeRecognitionOnly = "RECOGNITION_ONLY"
 	DropTypeFurniture       = "FURNITURE"
 
+	// ReliabilityRecalled is the reliability of reports recalled by their submitters.
+	ReliabilityRecalled = -1
+	// ReliabilityReplaced is the reliability of reports superseded by a correction from their submitters.
+	ReliabilityReplaced = -2
+
 	ViolationReliabilityUser                 = 1 << 2
 	ViolationReliabilityMD5                  = 1<<2 + 1
 	ViolationReliabilityDrop                 = 1<<2 + 2
 	ViolationReliabilityRejectRuleUnexpected = 1<<2 + 3
+	ViolationReliabilityGameModeCompat       = 1<<2 + 4
+	ViolationReliabilityQuantitySanity       = 1<<2 + 5
+	ViolationReliabilityVelocity             = 1<<2 + 6
+	ViolationReliabilityShadowBan            = 1<<2 + 7
+	ViolationReliabilityRecognition          = 1<<2 + 8
+	ViolationReliabilityPurged               = 1<<2 + 9 // purged in bulk by an admin after being persisted
+	ViolationReliabilityReportGate           = 1<<2 + 10
+	ViolationReliabilityActivityWindow       = 1<<2 + 11
 
 	ViolationReliabilityRejectRuleRangeLeast = 1 << 8
 	ViolationReliabilityRejectRuleRangeMost  = 1 << 10
 )
 
-// DropTypeMap maps an API drop type to a database drop type.
+// DropTypeAliasMap maps commonly mistaken API drop types to the API drop type or alias they are meant to be, which
+// is resolved against the drop type registry to suggest corrections for rejected reports. Keys are upper-cased.
 // The map must not be modified.
-var DropTypeMap = map[string]string{
-	"REGULAR_DROP": "REGULAR",
-	"NORMAL_DROP":  "REGULAR",
-	"SPECIAL_DROP": "SPECIAL",
-	"EXTRA_DROP":   "EXTRA",
-	"FURNITURE":    "FURNITURE",
-}
-
-var DropTypeReversedMap = map[string]string{
-	"REGULAR":   "NORMAL_DROP",
-	"SPECIAL":   "SPECIAL_DROP",
-	"EXTRA":     "EXTRA_DROP",
-	"FURNITURE": "FURNITURE",
-}
-
-var DropTypeMapKeys = []string{
-	"NORMAL_DROP",
-	"SPECIAL_DROP",
-	"EXTRA_DROP",
+var DropTypeAliasMap = map[string]string{
+	"REGULAR":        "REGULAR_DROP",
+	"NORMAL":         "NORMAL_DROP",
+	"SPECIAL":        "SPECIAL_DROP",
+	"EXTRA":          "EXTRA_DROP",
+	"FURNITURE_DROP": "FURNITURE",
 }
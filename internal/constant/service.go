@@ -3,6 +3,28 @@ package constant
 const (
 	FormulaPropertyKey = "formula"
 
+	// ItemGameModesPropertyKey is the key of the property describing in which game modes items could drop.
+	// Its value is a JSON object with ark item id as key and a list of zone categories as value, e.g.
+	// `{"randomMaterial_1": ["ACTIVITY"]}`. Items not listed could drop in any game mode.
+	ItemGameModesPropertyKey = "item_game_modes"
+
+	// ReportVerifiersPropertyKey is the key of the property configuring the order of report verifiers and which
+	// of them are disabled at runtime. See reportverifs.PipelineConfig for its value.
+	ReportVerifiersPropertyKey = "report_verifiers"
+
+	// ReportVelocityPropertyKey is the key of the property configuring submission velocity thresholds per report
+	// source. See reportverifs.DefaultVelocityThresholds for its value.
+	ReportVelocityPropertyKey = "report_velocity"
+
+	// ConfigOverridesPropertyKey is the key of the property overriding tunables of config.Config at runtime. Its
+	// value is a JSON object with names of fields of config.Config as keys, e.g. `{"ReportQuotaTrusted": 500}`.
+	// See config.ReloadableFields for fields which could be overridden.
+	ConfigOverridesPropertyKey = "config_overrides"
+
+	// ConfigChangedSubject is the NATS subject published to once config overrides have been changed, so that every
+	// instance reloads them.
+	ConfigChangedSubject = "CONFIG.CHANGED"
+
 	// SlimHeaderKey is to indicate whether the current request shall be ignored by Sentry transaction tracing.
 	// This is typically used by probes to avoid useless data being sent to Sentry.
 	SlimHeaderKey = "X-Slim"
@@ -0,0 +1,8 @@
+package constant
+
+const (
+	// site stats break down submissions into the following source groups
+	SiteStatsSourceMAA      = "maa"
+	SiteStatsSourceFrontend = "frontend"
+	SiteStatsSourceOthers   = "others"
+)
@@ -4,6 +4,8 @@ const (
 	FrontendV2         = "frontend-v2"
 	FrontendV1         = "penguin-stats.io"
 	FrontendV1Internal = "penguin-stats.io(internal)"
+
+	MeoAssistant = "MeoAssistant"
 )
 
 var ManualSources = []string{
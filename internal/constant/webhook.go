@@ -0,0 +1,54 @@
+package constant
+
+import "time"
+
+// Events webhooks could subscribe to, which are sent as the `event` of each delivery.
+const (
+	// WebhookEventActivityPublished is sent once an activity is created or updated via the admin API.
+	WebhookEventActivityPublished = "activity.published"
+	// WebhookEventMatrixRefreshed is sent once elements of the drop matrix of a server have been recalculated, either
+	// by the worker or for some stages on demand.
+	WebhookEventMatrixRefreshed = "matrix.refreshed"
+	// WebhookEventAnomalyDetected is sent once drop rate anomalies have been flagged.
+	WebhookEventAnomalyDetected = "anomaly.detected"
+	// WebhookEventPing is only sent to a webhook on request, to test whether it is reachable.
+	WebhookEventPing = "ping"
+)
+
+// WebhookEvents are events webhooks could subscribe to.
+var WebhookEvents = []string{
+	WebhookEventActivityPublished,
+	WebhookEventMatrixRefreshed,
+	WebhookEventAnomalyDetected,
+}
+
+const (
+	WebhookDeliveryStatusPending   = "pending"
+	WebhookDeliveryStatusSucceeded = "succeeded"
+	WebhookDeliveryStatusFailed    = "failed"
+)
+
+const (
+	// WebhookSecretPrefix prefixes secrets of webhooks, which deliveries are signed with.
+	WebhookSecretPrefix = "whsec_"
+
+	// WebhookEventHeader, WebhookDeliveryHeader and WebhookSignatureHeader are headers of deliveries, holding the
+	// event, the id of the delivery, and the signature of the delivery. The signature is in the form of
+	// `t={unix seconds},v1={hex HMAC-SHA256 of "{t}.{body}" keyed by the secret}`, so that receivers could verify
+	// deliveries and reject those replayed long after t.
+	WebhookEventHeader     = "X-Penguin-Event"
+	WebhookDeliveryHeader  = "X-Penguin-Delivery"
+	WebhookSignatureHeader = "X-Penguin-Signature"
+
+	// WebhookRetryBaseDelay is the delay before the first retry of a failed delivery, which doubles on every
+	// retry up to WebhookRetryMaxDelay.
+	WebhookRetryBaseDelay = time.Second * 30
+	WebhookRetryMaxDelay  = time.Hour * 6
+
+	// WebhookDeliveryLease is how long a delivery claimed by an instance is not claimed by others, which shall be
+	// longer than a delivery attempt could take.
+	WebhookDeliveryLease = time.Minute * 2
+
+	// WebhookDeliveryBatchSize is the maximum number of deliveries attempted in a single run.
+	WebhookDeliveryBatchSize = 100
+)
@@ -0,0 +1,35 @@
+package constant
+
+const (
+	// WorkerLeaderElectionCalc is the election of the instance running the calculator and recent matrix workers.
+	WorkerLeaderElectionCalc = "calc"
+	// WorkerLeaderKeyPrefix prefixes the Redis key of the lease of the leader of an election, followed by the name
+	// of the election.
+	WorkerLeaderKeyPrefix = "worker-leader:"
+)
+
+const (
+	// JobTriggerSchedule is the trigger of runs started by the schedule of the job.
+	JobTriggerSchedule = "schedule"
+	// JobTriggerManual is the trigger of runs started by an admin.
+	JobTriggerManual = "manual"
+
+	JobResultRunning   = "running"
+	JobResultSucceeded = "succeeded"
+	JobResultFailed    = "failed"
+)
+
+// Names of jobs run by the scheduler, by which their specs are overridden, and they are disabled or triggered.
+const (
+	JobCalc            = "calc"
+	JobRecentMatrix    = "recentMatrix"
+	JobAnomaly         = "anomaly"
+	JobSnapshot        = "snapshot"
+	JobGameDataSync    = "gameDataSync"
+	JobPartition       = "partition"
+	JobWebhook         = "webhook"
+	JobAlert           = "alert"
+	JobPatternDedup    = "patternDedup"
+	JobMatrixHistory   = "matrixHistory"
+	JobPublicAggregate = "publicAggregate"
+)
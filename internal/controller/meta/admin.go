@@ -1,18 +1,17 @@
 package meta
 
 import (
-	"fmt"
+	"encoding/json"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/davecgh/go-spew/spew"
 	"github.com/gofiber/fiber/v2"
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
-	"github.com/zeebo/xxh3"
 	"go.uber.org/fx"
+	"gopkg.in/guregu/null.v3"
 
 	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model"
@@ -23,7 +22,9 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/repo"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util"
 	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
 )
 
 type AdminController struct {
@@ -32,204 +33,412 @@ type AdminController struct {
 	PatternRepo          *repo.DropPattern
 	PatternElementRepo   *repo.DropPatternElement
 	AdminService         *service.Admin
+	AggregateViewService *service.AggregateView
+	Scheduler            *service.Scheduler
+	RuntimeConfig        *service.RuntimeConfig
+	FeatureFlagService   *service.FeatureFlag
+	AuditService         *service.Audit
+	WebhookService       *service.Webhook
+	Notifier             *service.Notifier
+	RecognitionService   *service.Recognition
 	ItemService          *service.Item
+	DropTypeService      *service.DropType
 	DropMatrixService    *service.DropMatrix
 	PatternMatrixService *service.PatternMatrix
 	TrendService         *service.Trend
 	SiteStatsService     *service.SiteStats
+	ReportService        *service.Report
+	AccountTrustService  *service.AccountTrust
+	ShadowBanService     *service.ShadowBan
+	ReportGateService    *service.ReportGate
+	StageRewriteService  *service.StageRewrite
+	MatrixRefreshService *service.MatrixRefresh
+	ReportPurgeService   *service.ReportPurge
+	PatternDedupService  *service.PatternDedup
+	ReportBrowseService  *service.ReportBrowse
+	GameDataSyncService  *service.GameDataSync
+	MigrationService     *service.Migration
 }
 
 func RegisterAdmin(admin *svr.Admin, c AdminController) {
 	admin.Get("/bonjour", c.Bonjour)
 	admin.Post("/save", c.SaveRenderedObjects)
+	admin.Post("/gamedata/items", c.SaveItems)
+	admin.Patch("/gamedata/items/:itemId", c.PatchItem)
+	admin.Patch("/gamedata/stages/:stageId", c.PatchStage)
+	admin.Patch("/gamedata/zones/:zoneId", c.PatchZone)
+	admin.Patch("/gamedata/activities/:activityId", c.PatchActivity)
+	admin.Patch("/gamedata/dropinfos/:dropId", c.PatchDropInfo)
+	admin.Post("/gamedata/dropinfos/bounds", c.SaveDropInfoBounds)
+	admin.Post("/gamedata/timeranges/split", c.SplitTimeRange)
+	admin.Get("/gamedata/droptypes", c.GetDropTypes)
+	admin.Put("/gamedata/droptypes/:dropType", c.SaveDropType)
+	admin.Delete("/gamedata/droptypes/:dropType", c.DeleteDropType)
+	admin.Post("/gamedata/sync", c.SyncGameData)
+	admin.Get("/gamedata/changes", c.GetGameDataChanges)
+	admin.Post("/gamedata/changes/:id/approve", c.ApproveGameDataChange)
+	admin.Post("/gamedata/changes/:id/reject", c.RejectGameDataChange)
 	admin.Post("/purge", c.PurgeCache)
 
 	admin.Get("/cli/gamedata/seed", c.GetCliGameDataSeed)
-	admin.Get("/_temp/pattern/merging", c.FindPatterns)
-	admin.Get("/_temp/pattern/disambiguation", c.DisambiguatePatterns)
+	admin.Get("/patterns/duplicates", c.GetDuplicatePatterns)
 
-	admin.Get("/refresh/matrix/:server", c.RefreshAllDropMatrixElements)
-	admin.Get("/refresh/pattern/:server", c.RefreshAllPatternMatrixElements)
-	admin.Get("/refresh/trend/:server", c.RefreshAllTrendElements)
-	admin.Get("/refresh/sitestats/:server", c.RefreshAllSiteStats)
+	admin.Post("/refresh/matrix/:server", c.RefreshAllDropMatrixElements)
+	admin.Post("/refresh/pattern/:server", c.RefreshAllPatternMatrixElements)
+	admin.Post("/refresh/trend/:server", c.RefreshAllTrendElements)
+	admin.Post("/refresh/sitestats/:server", c.RefreshAllSiteStats)
+	admin.Post("/refresh/views", c.RefreshAggregateViews)
+	admin.Post("/matrix/refresh", c.RefreshStageDropMatrix)
+
+	admin.Get("/jobs", c.GetJobs)
+	admin.Get("/jobs/runs", c.GetJobRuns)
+	admin.Post("/jobs/:name/trigger", c.TriggerJob)
+
+	admin.Get("/migrations", c.GetMigrationStatus)
+
+	admin.Get("/config", c.GetRuntimeConfig)
+	admin.Put("/config/overrides", c.SetConfigOverrides)
+	admin.Post("/config/reload", c.ReloadConfig)
+
+	admin.Get("/featureflags", c.GetFeatureFlags)
+	admin.Put("/featureflags/:key", c.SetFeatureFlag)
+	admin.Delete("/featureflags/:key", c.DeleteFeatureFlag)
+
+	admin.Get("/audit", c.GetAuditLogs)
+
+	admin.Get("/webhooks", c.GetWebhooks)
+	admin.Post("/webhooks", c.CreateWebhook)
+	admin.Put("/webhooks/:id", c.UpdateWebhook)
+	admin.Delete("/webhooks/:id", c.DeleteWebhook)
+	admin.Post("/webhooks/:id/ping", c.PingWebhook)
+	admin.Get("/webhooks/:id/deliveries", c.GetWebhookDeliveries)
+	admin.Post("/webhooks/deliveries/:id/redeliver", c.RedeliverWebhookDelivery)
+
+	admin.Post("/alerts/channels/:name/test", c.TestAlertChannel)
+
+	admin.Get("/recognition/bundles", c.GetRecognitionBundles)
+	admin.Post("/recognition/bundles", c.UploadRecognitionBundle)
+	admin.Get("/recognition/releases", c.GetRecognitionReleases)
+	admin.Put("/recognition/releases/:server", c.ReleaseRecognitionBundle)
+
+	admin.Get("/report/verifiers", c.GetReportVerifiers)
+	admin.Get("/report/rejected", c.GetRejectedReportTasks)
+	admin.Post("/report/rejected/:id/requeue", c.RequeueRejectedReportTask)
+	admin.Delete("/report/rejected/:id", c.DiscardRejectedReportTask)
+	admin.Post("/report/replay", c.ReplayReportTasks)
+	admin.Get("/report/purges", c.GetReportPurges)
+	admin.Get("/report/browse", c.BrowseReports)
+	admin.Post("/report/:id/restore", c.RestoreReport)
+	admin.Post("/report/purge", c.PurgeReports)
+
+	admin.Get("/account/trust", c.GetLowestAccountTrustScores)
+	admin.Get("/account/trust/:accountId", c.GetAccountTrustScore)
+
+	admin.Get("/shadowban", c.GetShadowBans)
+	admin.Post("/shadowban", c.CreateShadowBan)
+	admin.Post("/shadowban/sync", c.SyncShadowBans)
+	admin.Delete("/shadowban/:id", c.DeleteShadowBan)
+
+	admin.Get("/reportgate", c.GetReportGates)
+	admin.Post("/reportgate", c.CreateReportGate)
+	admin.Post("/reportgate/sync", c.SyncReportGates)
+	admin.Delete("/reportgate/:id", c.DeleteReportGate)
+
+	admin.Get("/stagerewrite", c.GetStageRewriteRules)
+	admin.Post("/stagerewrite", c.CreateStageRewriteRule)
+	admin.Post("/stagerewrite/sync", c.SyncStageRewriteRules)
+	admin.Delete("/stagerewrite/:id", c.DeleteStageRewriteRule)
+}
+
+// SetConfigOverridesRequest replaces the overrides of the `config_overrides` property with Overrides, keyed by names
+// of fields of config.Config which could be reloaded. An empty object clears all overrides of the property.
+type SetConfigOverridesRequest struct {
+	Overrides map[string]json.RawMessage `json:"overrides" validate:"required"`
 }
 
 type CliGameDataSeedResponse struct {
 	Items []*model.Item `json:"items"`
 }
 
+// SaveItemsRequest creates Items, or updates them if items of the same itemIds already exist.
+type SaveItemsRequest struct {
+	Items []*model.Item `json:"items" validate:"required,min=1,max=1000,dive,required"`
+}
+
+// SaveDropInfoBoundsRequest proposes Bounds of the drop info of an item of a stage, drop type and time range, which
+// are previewed against reports created within the last LookbackDays (default 7), and committed if Commit is set.
+type SaveDropInfoBoundsRequest struct {
+	Server       string        `json:"server" validate:"required,oneof=CN US JP KR"`
+	StageID      int           `json:"stageId" validate:"required"`
+	ItemID       int           `json:"itemId" validate:"required"`
+	DropType     string        `json:"dropType" validate:"required"`
+	RangeID      int           `json:"rangeId" validate:"required"`
+	Accumulable  bool          `json:"accumulable"`
+	Bounds       *model.Bounds `json:"bounds" validate:"required"`
+	LookbackDays int           `json:"lookbackDays" validate:"min=0,max=90"`
+	Commit       bool          `json:"commit"`
+}
+
 // Bonjour is for the admin dashboard to detect authentication status
 func (c AdminController) Bonjour(ctx *fiber.Ctx) error {
 	return ctx.SendStatus(http.StatusNoContent)
 }
 
-func (c AdminController) FindPatterns(ctx *fiber.Ctx) error {
-	patterns, err := c.PatternRepo.GetDropPatterns(ctx.Context())
+// GetDuplicatePatterns previews what the pattern dedup job would merge: groups of drop patterns of the same drops,
+// and patterns whose hashes or elements are not canonical
+func (c AdminController) GetDuplicatePatterns(ctx *fiber.Ctx) error {
+	duplicates, err := c.PatternDedupService.FindDuplicates(ctx.Context())
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(duplicates)
+}
+
+func (c AdminController) GetCliGameDataSeed(ctx *fiber.Ctx) error {
+	items, err := c.ItemService.GetItems(ctx.Context())
 	if err != nil {
 		return err
 	}
 
-	var sb strings.Builder
+	return ctx.JSON(CliGameDataSeedResponse{
+		Items: items,
+	})
+}
 
-	for _, pattern := range patterns {
-		m := map[string]int{}
-		haveDup := false
-		segments := strings.Split(pattern.OriginalFingerprint, "|")
-		if len(segments) == 1 && segments[0] == "" {
-			continue
-		}
-		for _, segment := range segments {
-			a := strings.Split(segment, ":")
-			if _, ok := m[a[0]]; ok {
-				haveDup = true
-			}
+func (c *AdminController) SaveRenderedObjects(ctx *fiber.Ctx) error {
+	var request gamedata.RenderedObjects
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
 
-			i, _ := strconv.Atoi(a[1])
+	err := c.AdminService.SaveRenderedObjects(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
 
-			if _, ok := m[a[0]]; ok {
-				m[a[0]] = m[a[0]] + i
-			} else {
-				m[a[0]] = i
-			}
-		}
-		if haveDup {
-			segments := make([]string, 0)
+	return ctx.JSON(request)
+}
 
-			for i, j := range m {
-				segments = append(segments, fmt.Sprintf("%s:%d", i, j))
-			}
+// SaveItems creates or updates items, and invalidates cached game data of all instances
+func (c *AdminController) SaveItems(ctx *fiber.Ctx) error {
+	var request SaveItemsRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
 
-			fingerprint, hash := c.calculateDropPatternHash(segments)
+	if err := c.AdminService.SaveItems(ctx.Context(), request.Items); err != nil {
+		return err
+	}
 
-			correctPattern, err := c.PatternRepo.GetDropPatternByHash(ctx.Context(), hash)
-			if err != nil {
-				spew.Dump(hash, fingerprint, err)
-				sb.WriteString(fmt.Sprintf(`WITH inserted_id AS (INSERT INTO drop_patterns ("hash", "original_fingerprint") VALUES ('%s', '%s') RETURNING pattern_id)
-UPDATE drop_reports SET pattern_id = (select pattern_id from inserted_id) WHERE pattern_id = '%d';
-`, hash, fingerprint, pattern.PatternID))
-				continue
-			}
+	return ctx.JSON(request.Items)
+}
 
-			sb.WriteString(fmt.Sprintf("UPDATE drop_reports SET pattern_id = '%d' WHERE pattern_id = '%d';\n", correctPattern.PatternID, pattern.PatternID))
-		}
+// PatchItem updates fields of an item present in the body, which is a partial item. The same goes for PatchStage,
+// PatchZone, PatchActivity and PatchDropInfo.
+func (c *AdminController) PatchItem(ctx *fiber.Ctx) error {
+	item, err := c.AdminService.PatchItem(ctx.Context(), ctx.Params("itemId"), ctx.Body())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(item)
+}
+
+func (c *AdminController) PatchStage(ctx *fiber.Ctx) error {
+	stage, err := c.AdminService.PatchStage(ctx.Context(), ctx.Params("stageId"), ctx.Body())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(stage)
+}
+
+func (c *AdminController) PatchZone(ctx *fiber.Ctx) error {
+	zone, err := c.AdminService.PatchZone(ctx.Context(), ctx.Params("zoneId"), ctx.Body())
+	if err != nil {
+		return err
 	}
 
-	return ctx.SendString(sb.String())
+	return ctx.JSON(zone)
 }
 
-func must[T any](v T, err error) T {
+func (c *AdminController) PatchActivity(ctx *fiber.Ctx) error {
+	activityId, err := strconv.Atoi(ctx.Params("activityId"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid activity id")
+	}
+
+	activity, err := c.AdminService.PatchActivity(ctx.Context(), activityId, ctx.Body())
 	if err != nil {
-		panic(err)
+		return err
 	}
-	return v
+
+	return ctx.JSON(activity)
 }
 
-func (c AdminController) DisambiguatePatterns(ctx *fiber.Ctx) error {
-	patterns, err := c.PatternRepo.GetDropPatterns(ctx.Context())
+func (c *AdminController) PatchDropInfo(ctx *fiber.Ctx) error {
+	dropId, err := strconv.Atoi(ctx.Params("dropId"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid drop info id")
+	}
+
+	dropInfo, err := c.AdminService.PatchDropInfo(ctx.Context(), dropId, ctx.Body())
 	if err != nil {
 		return err
 	}
 
-	elements, err := c.PatternElementRepo.GetDropPatternElements(ctx.Context())
+	return ctx.JSON(dropInfo)
+}
+
+// SaveDropInfoBounds previews how many recent reports would be out of the proposed bounds of a drop info, and
+// commits the bounds if requested so.
+func (c *AdminController) SaveDropInfoBounds(ctx *fiber.Ctx) error {
+	var request SaveDropInfoBoundsRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+	if request.LookbackDays == 0 {
+		request.LookbackDays = 7
+	}
+
+	preview, err := c.AdminService.SaveDropInfoBounds(ctx.Context(), &model.DropInfo{
+		Server:      request.Server,
+		StageID:     request.StageID,
+		ItemID:      null.IntFrom(int64(request.ItemID)),
+		DropType:    request.DropType,
+		RangeID:     request.RangeID,
+		Accumulable: request.Accumulable,
+		Bounds:      request.Bounds,
+	}, time.Duration(request.LookbackDays)*24*time.Hour, request.Commit)
 	if err != nil {
 		return err
 	}
 
-	getElementsByPatternId := func(patternId int) []*model.DropPatternElement {
-		var filtered []*model.DropPatternElement
-		for _, element := range elements {
-			if element.DropPatternID == patternId {
-				filtered = append(filtered, element)
-			}
-		}
-		return filtered
+	return ctx.JSON(preview)
+}
+
+// SplitTimeRange splits a time range of a stage, and refreshes the drop matrix of the stage within both time ranges
+// in background. Progress of the refresh is published over NATS, to the subject in the response
+func (c *AdminController) SplitTimeRange(ctx *fiber.Ctx) error {
+	var request types.TimeRangeSplitRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
 	}
 
-	var sb strings.Builder
-	sb.WriteString("BEGIN\n")
+	split, err := c.AdminService.SplitTimeRange(ctx.Context(), request.Server, request.StageID, request.RangeID, time.UnixMilli(request.At))
+	if err != nil {
+		return err
+	}
 
-	for _, pattern := range patterns {
-		segments := strings.Split(pattern.OriginalFingerprint, "|")
-		if len(segments) == 1 && segments[0] == "" {
-			continue
-		}
-		storedPatterns := getElementsByPatternId(pattern.PatternID)
-		calculatedPatterns := make([]*model.DropPatternElement, 0)
-		for _, segment := range segments {
-			a := strings.Split(segment, ":")
-			itemId := must(strconv.Atoi(a[0]))
-			quantity := must(strconv.Atoi(a[1]))
-			calculatedPatterns = append(calculatedPatterns, &model.DropPatternElement{
-				DropPatternID: pattern.PatternID,
-				ItemID:        itemId,
-				Quantity:      quantity,
-			})
-		}
-		// // compare calculated and stored patterns
-		// if len(storedPatterns) != len(calculatedPatterns) {
-		// 	sb.WriteString(fmt.Sprintf("LENGTH: (patternId: %d) %d != %d\n", pattern.PatternID, len(storedPatterns), len(calculatedPatterns)))
-		// 	continue
-		// } else {
-
-		// }
-		storedPatternsOF, storedPatternsHash := c.calculateDropPatternHashFromElements(storedPatterns)
-		calculatedPatternsOF, calculatedPatternsHash := c.calculateDropPatternHashFromElements(calculatedPatterns)
-		if storedPatternsHash != calculatedPatternsHash {
-			sb.WriteString(fmt.Sprintf("HASH: (patternId: %d) %s (%s) != %s (%s)\n", pattern.PatternID, storedPatternsHash, storedPatternsOF, calculatedPatternsHash, calculatedPatternsOF))
-			continue
-		}
+	refreshId, err := c.MatrixRefreshService.RefreshSplitTimeRange(ctx.Context(), request.Server, request.StageID, split)
+	if err != nil {
+		return err
 	}
 
-	sb.WriteString("END\n")
+	return ctx.Status(http.StatusAccepted).JSON(fiber.Map{
+		"before":    split.Before,
+		"after":     split.After,
+		"refreshId": refreshId,
+		"subject":   constant.MatrixRefreshSubjectPrefix + refreshId,
+	})
+}
+
+func (c *AdminController) GetDropTypes(ctx *fiber.Ctx) error {
+	dropTypes, err := c.DropTypeService.GetDropTypes(ctx.Context())
+	if err != nil {
+		return err
+	}
 
-	return ctx.SendString(sb.String())
+	return ctx.JSON(dropTypes)
 }
 
-func (c AdminController) calculateDropPatternHashFromElements(elements []*model.DropPatternElement) (originalFingerprint, hexHash string) {
-	segments := make([]string, len(elements))
+// SaveDropType registers the drop type of the dropType param, or replaces the registered one
+func (c *AdminController) SaveDropType(ctx *fiber.Ctx) error {
+	name := ctx.Params("dropType")
+	if err := rekuest.ValidVar(ctx, name, "required,max=32,printascii"); err != nil {
+		return err
+	}
 
-	for i, element := range elements {
-		segments[i] = fmt.Sprintf("%d:%d", element.ItemID, element.Quantity)
+	var request types.DropTypeRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
 	}
 
-	sort.Strings(segments)
+	dropType, err := c.DropTypeService.SaveDropType(ctx.Context(), name, &request)
+	if err != nil {
+		return err
+	}
 
-	originalFingerprint = strings.Join(segments, "|")
-	hash := xxh3.HashStringSeed(originalFingerprint, 0)
-	return originalFingerprint, strconv.FormatUint(hash, 16)
+	return ctx.JSON(dropType)
 }
 
-func (c AdminController) calculateDropPatternHash(segments []string) (originalFingerprint, hexHash string) {
-	sort.Strings(segments)
+// DeleteDropType deletes the stored drop type of the dropType param. Builtin drop types are reverted to their
+// defaults
+func (c *AdminController) DeleteDropType(ctx *fiber.Ctx) error {
+	if err := c.DropTypeService.DeleteDropType(ctx.Context(), ctx.Params("dropType")); err != nil {
+		return err
+	}
 
-	originalFingerprint = strings.Join(segments, "|")
-	hash := xxh3.HashStringSeed(originalFingerprint, 0)
-	return originalFingerprint, strconv.FormatUint(hash, 16)
+	return ctx.SendStatus(http.StatusNoContent)
 }
 
-func (c AdminController) GetCliGameDataSeed(ctx *fiber.Ctx) error {
-	items, err := c.ItemService.GetItems(ctx.Context())
+// SyncGameData syncs game data from the external gamedata repository now, staging changes found for approval
+func (c *AdminController) SyncGameData(ctx *fiber.Ctx) error {
+	staged, err := c.GameDataSyncService.Sync(ctx.Context())
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(CliGameDataSeedResponse{
-		Items: items,
+	return ctx.JSON(fiber.Map{
+		"staged": staged,
 	})
 }
 
-func (c *AdminController) SaveRenderedObjects(ctx *fiber.Ctx) error {
-	var request gamedata.RenderedObjects
-	if err := rekuest.ValidBody(ctx, &request); err != nil {
+// GetGameDataChanges returns the latest game data changes of query `status` (default pending), limited by query
+// `limit` (default 100)
+func (c *AdminController) GetGameDataChanges(ctx *fiber.Ctx) error {
+	status := ctx.Query("status", constant.GameDataChangeStatusPending)
+	limit := 100
+	if ctx.Query("limit") != "" {
+		var err error
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit <= 0 {
+			return pgerr.ErrInvalidReq.Msg("invalid limit")
+		}
+	}
+
+	changes, err := c.GameDataSyncService.GetGameDataChanges(ctx.Context(), status, limit)
+	if err != nil {
 		return err
 	}
 
-	err := c.AdminService.SaveRenderedObjects(ctx.Context(), &request)
+	return ctx.JSON(changes)
+}
+
+func (c *AdminController) ApproveGameDataChange(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid game data change id")
+	}
+
+	change, err := c.GameDataSyncService.ApproveGameDataChange(ctx.Context(), id)
 	if err != nil {
 		return err
 	}
 
-	return ctx.JSON(request)
+	return ctx.JSON(change)
+}
+
+func (c *AdminController) RejectGameDataChange(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid game data change id")
+	}
+
+	if err := c.GameDataSyncService.RejectGameDataChange(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
 }
 
 func (c *AdminController) PurgeCache(ctx *fiber.Ctx) error {
@@ -260,7 +469,7 @@ func (c *AdminController) PurgeCache(ctx *fiber.Ctx) error {
 
 func (c *AdminController) RefreshAllDropMatrixElements(ctx *fiber.Ctx) error {
 	server := ctx.Params("server")
-	return c.DropMatrixService.RefreshAllDropMatrixElements(ctx.Context(), server, []string{constant.SourceCategoryAll})
+	return c.DropMatrixService.RefreshAllDropMatrixElements(ctx.Context(), server, c.DropMatrixService.SourceCategories)
 }
 
 func (c *AdminController) RefreshAllPatternMatrixElements(ctx *fiber.Ctx) error {
@@ -270,7 +479,8 @@ func (c *AdminController) RefreshAllPatternMatrixElements(ctx *fiber.Ctx) error
 
 func (c *AdminController) RefreshAllTrendElements(ctx *fiber.Ctx) error {
 	server := ctx.Params("server")
-	return c.TrendService.RefreshTrendElements(ctx.Context(), server, []string{constant.SourceCategoryAll})
+	granularity := ctx.Query("granularity", constant.TrendGranularityDay)
+	return c.TrendService.RefreshTrendElements(ctx.Context(), server, granularity, []string{constant.SourceCategoryAll})
 }
 
 func (c *AdminController) RefreshAllSiteStats(ctx *fiber.Ctx) error {
@@ -278,3 +488,639 @@ func (c *AdminController) RefreshAllSiteStats(ctx *fiber.Ctx) error {
 	_, err := c.SiteStatsService.RefreshShimSiteStats(ctx.Context(), server)
 	return err
 }
+
+// RefreshAggregateViews refreshes the materialized views the global drop matrix and pattern matrix are calculated
+// from, without waiting for the next run of the worker. The matrices themselves are left as they are.
+func (c *AdminController) RefreshAggregateViews(ctx *fiber.Ctx) error {
+	return c.AggregateViewService.RefreshViews(ctx.Context())
+}
+
+// RefreshStageDropMatrix refreshes the drop matrix of a stage in background. Progress is published over NATS, to the
+// subject in the response
+func (c *AdminController) RefreshStageDropMatrix(ctx *fiber.Ctx) error {
+	var request types.MatrixRefreshRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	refreshId, err := c.MatrixRefreshService.RefreshStageDropMatrix(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(types.MatrixRefreshResponse{
+		RefreshID: refreshId,
+		Subject:   constant.MatrixRefreshSubjectPrefix + refreshId,
+	})
+}
+
+// GetReportVerifiers returns the names of enabled report verifiers, in the order they run
+func (c *AdminController) GetReportVerifiers(ctx *fiber.Ctx) error {
+	pipeline := c.ReportService.ReportVerifier.Pipeline(ctx.Context())
+
+	return ctx.JSON(lo.Map(pipeline, func(verifier reportverifs.Verifier, _ int) string {
+		return verifier.Name()
+	}))
+}
+
+func (c *AdminController) GetRejectedReportTasks(ctx *fiber.Ctx) error {
+	tasks, err := c.ReportService.GetRejectedReportTasks(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(tasks)
+}
+
+func (c *AdminController) RequeueRejectedReportTask(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid rejected report task id")
+	}
+
+	if err := c.ReportService.RequeueRejectedReportTask(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AdminController) DiscardRejectedReportTask(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid rejected report task id")
+	}
+
+	if err := c.ReportService.DiscardRejectedReportTask(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// ReplayReportTasks replays rejected or archived report tasks through the current report pipeline, e.g. after a bug
+// of verifiers has been fixed, and returns the verdicts of their reports
+func (c *AdminController) ReplayReportTasks(ctx *fiber.Ctx) error {
+	var request types.ReportReplayRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	response, err := c.ReportService.ReplayRequestedReportTasks(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(response)
+}
+
+// PurgeReports marks persisted reports matching a filter unreliable, and refreshes the drop matrix of the stages
+// affected. With `dryRun`, only the reports which would be purged are counted
+func (c *AdminController) PurgeReports(ctx *fiber.Ctx) error {
+	var request types.ReportPurgeRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	resp, err := c.ReportPurgeService.PurgeReports(ctx.Context(), &request, util.ExtractIP(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(resp)
+}
+
+// BrowseReports searches drop reports by the filters in the query string, along with their drops, latest first.
+// Pages are selected by query `cursor` and `limit` (default 50, at most 500)
+func (c *AdminController) BrowseReports(ctx *fiber.Ctx) error {
+	var query types.ReportBrowseQuery
+	if err := ctx.QueryParser(&query); err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid query: %s", err)
+	}
+	if err := rekuest.ValidStruct(ctx, &query); err != nil {
+		return err
+	}
+
+	cur, limit, err := rekuest.Page(ctx, 50, 500)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.ReportBrowseService.BrowseReports(ctx.Context(), &query, cur, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(page)
+}
+
+// RestoreReport reverses the recall or the purge of a drop report, and refreshes the drop matrix of its stage in
+// background. Progress of the refresh is published over NATS, to the subject in the response
+func (c *AdminController) RestoreReport(ctx *fiber.Ctx) error {
+	reportId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid report id")
+	}
+
+	resp, err := c.ReportPurgeService.RestoreDropReport(ctx.Context(), reportId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(resp)
+}
+
+// GetJobs returns the status of scheduled jobs of the instance serving the request
+func (c *AdminController) GetJobs(ctx *fiber.Ctx) error {
+	return ctx.JSON(c.Scheduler.GetJobs())
+}
+
+// GetJobRuns returns the latest runs of scheduled jobs across instances, of the job in query `job` if specified,
+// limited by query `limit` (default 100)
+func (c *AdminController) GetJobRuns(ctx *fiber.Ctx) error {
+	limit := 100
+	if ctx.Query("limit") != "" {
+		var err error
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit <= 0 {
+			return pgerr.ErrInvalidReq.Msg("invalid limit")
+		}
+	}
+
+	runs, err := c.Scheduler.GetJobRuns(ctx.Context(), ctx.Query("job"), limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(runs)
+}
+
+// TriggerJob runs a scheduled job on the instance serving the request in background right away, and returns the
+// record of the run
+func (c *AdminController) TriggerJob(ctx *fiber.Ctx) error {
+	run, err := c.Scheduler.Trigger(ctx.Params("name"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(run)
+}
+
+// GetMigrationStatus returns whether each migration of the database schema known to the instance serving the
+// request is applied, along with migrations applied by newer binaries, and whether a migration run is in progress
+func (c *AdminController) GetMigrationStatus(ctx *fiber.Ctx) error {
+	status, err := c.MigrationService.GetMigrationStatus(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(status)
+}
+
+// GetRuntimeConfig returns the value in effect on the instance serving the request of each config which could be
+// reloaded, and the overrides in effect
+func (c *AdminController) GetRuntimeConfig(ctx *fiber.Ctx) error {
+	return ctx.JSON(c.RuntimeConfig.GetConfigStatus())
+}
+
+// SetConfigOverrides replaces config overrides of the `config_overrides` property, and reloads the config of all
+// instances
+func (c *AdminController) SetConfigOverrides(ctx *fiber.Ctx) error {
+	var request SetConfigOverridesRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	if err := c.RuntimeConfig.SetOverrides(ctx.Context(), request.Overrides); err != nil {
+		return err
+	}
+
+	return ctx.JSON(c.RuntimeConfig.GetConfigStatus())
+}
+
+// ReloadConfig reloads config overrides on the instance serving the request right away, e.g. after the overrides
+// file has been changed
+func (c *AdminController) ReloadConfig(ctx *fiber.Ctx) error {
+	if err := c.RuntimeConfig.Reload(ctx.Context()); err != nil {
+		return err
+	}
+
+	return ctx.JSON(c.RuntimeConfig.GetConfigStatus())
+}
+
+func (c *AdminController) GetFeatureFlags(ctx *fiber.Ctx) error {
+	flags, err := c.FeatureFlagService.GetFeatureFlags(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(flags)
+}
+
+// SetFeatureFlag creates or replaces the feature flag of key, which applies to this instance right away, and to
+// other instances within seconds
+func (c *AdminController) SetFeatureFlag(ctx *fiber.Ctx) error {
+	var request types.FeatureFlagRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	flag, err := c.FeatureFlagService.SetFeatureFlag(ctx.Context(), ctx.Params("key"), &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(flag)
+}
+
+// DeleteFeatureFlag deletes the feature flag of key, so that the behavior it gates falls back to its default
+func (c *AdminController) DeleteFeatureFlag(ctx *fiber.Ctx) error {
+	if err := c.FeatureFlagService.DeleteFeatureFlag(ctx.Context(), ctx.Params("key")); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// GetAuditLogs returns records of admin mutations matching the query, latest first. Older records are paged with
+// query `before`, the id of the last record of the previous page
+func (c *AdminController) GetAuditLogs(ctx *fiber.Ctx) error {
+	var query types.AuditLogQuery
+	if err := ctx.QueryParser(&query); err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid query: %s", err)
+	}
+	if err := rekuest.ValidStruct(ctx, &query); err != nil {
+		return err
+	}
+
+	logs, err := c.AuditService.GetAuditLogs(ctx.Context(), &query)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(logs)
+}
+
+func (c *AdminController) GetWebhooks(ctx *fiber.Ctx) error {
+	webhooks, err := c.WebhookService.GetWebhooks(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(webhooks)
+}
+
+// CreateWebhook creates a webhook, and responds with its secret, which deliveries are signed with and is only
+// returned once
+func (c *AdminController) CreateWebhook(ctx *fiber.Ctx) error {
+	var request types.WebhookRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	webhook, err := c.WebhookService.CreateWebhook(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(webhook)
+}
+
+func (c *AdminController) UpdateWebhook(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid webhook id")
+	}
+
+	var request types.WebhookRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	webhook, err := c.WebhookService.UpdateWebhook(ctx.Context(), id, &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(webhook)
+}
+
+func (c *AdminController) DeleteWebhook(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid webhook id")
+	}
+
+	if err := c.WebhookService.DeleteWebhook(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+// PingWebhook delivers a ping event to a webhook right away, and responds with the delivery once attempted
+func (c *AdminController) PingWebhook(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid webhook id")
+	}
+
+	delivery, err := c.WebhookService.Ping(ctx.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(delivery)
+}
+
+// GetWebhookDeliveries returns the latest deliveries to a webhook, limited by query `limit` (default 100)
+func (c *AdminController) GetWebhookDeliveries(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid webhook id")
+	}
+	limit := 100
+	if ctx.Query("limit") != "" {
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit <= 0 {
+			return pgerr.ErrInvalidReq.Msg("invalid limit")
+		}
+	}
+
+	deliveries, err := c.WebhookService.GetWebhookDeliveries(ctx.Context(), id, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(deliveries)
+}
+
+// RedeliverWebhookDelivery attempts a delivery again from scratch, e.g. after it has been given up
+func (c *AdminController) RedeliverWebhookDelivery(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid delivery id")
+	}
+
+	delivery, err := c.WebhookService.Redeliver(ctx.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusAccepted).JSON(delivery)
+}
+
+// TestAlertChannel pushes a test alert to an alert channel of config.Config AlertChannels, regardless of its routing
+// rules
+func (c *AdminController) TestAlertChannel(ctx *fiber.Ctx) error {
+	if err := c.Notifier.NotifyChannel(ctx.Context(), ctx.Params("name")); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AdminController) GetRecognitionBundles(ctx *fiber.Ctx) error {
+	bundles, err := c.RecognitionService.GetBundles(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(bundles)
+}
+
+// UploadRecognitionBundle stores the request body as a recognition bundle, labeled with the version query param.
+// The bundle is not served as the latest one of any server until it is released.
+func (c *AdminController) UploadRecognitionBundle(ctx *fiber.Ctx) error {
+	version := ctx.Query("version")
+	if err := rekuest.ValidVar(ctx, version, "required,max=64"); err != nil {
+		return err
+	}
+
+	bundle, err := c.RecognitionService.UploadBundle(ctx.Context(), version, ctx.Get(fiber.HeaderContentType), ctx.Body())
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(bundle)
+}
+
+func (c *AdminController) GetRecognitionReleases(ctx *fiber.Ctx) error {
+	releases, err := c.RecognitionService.GetReleases(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(releases)
+}
+
+func (c *AdminController) ReleaseRecognitionBundle(ctx *fiber.Ctx) error {
+	server := ctx.Params("server")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+
+	var request types.RecognitionReleaseRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	release, err := c.RecognitionService.Release(ctx.Context(), server, strings.ToLower(request.Hash))
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(release)
+}
+
+func (c *AdminController) GetReportPurges(ctx *fiber.Ctx) error {
+	limit := 100
+	if ctx.Query("limit") != "" {
+		var err error
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit <= 0 {
+			return pgerr.ErrInvalidReq.Msg("invalid limit")
+		}
+	}
+
+	purges, err := c.ReportPurgeService.GetReportPurges(ctx.Context(), limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(purges)
+}
+
+// GetLowestAccountTrustScores returns accounts with the lowest trust scores, limited by query `limit` (default 100)
+func (c *AdminController) GetLowestAccountTrustScores(ctx *fiber.Ctx) error {
+	limit := 100
+	if ctx.Query("limit") != "" {
+		var err error
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit <= 0 {
+			return pgerr.ErrInvalidReq.Msg("invalid limit")
+		}
+	}
+
+	scores, err := c.AccountTrustService.GetLowestAccountTrustScores(ctx.Context(), limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(scores)
+}
+
+// GetAccountTrustScore returns the trust score of an account. With query `refresh=true`, the score is
+// recalculated before returned
+func (c *AdminController) GetAccountTrustScore(ctx *fiber.Ctx) error {
+	accountId, err := strconv.Atoi(ctx.Params("accountId"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid account id")
+	}
+
+	if ctx.Query("refresh") == "true" {
+		score, err := c.AccountTrustService.RecalculateAccountTrustScore(ctx.Context(), accountId)
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(score)
+	}
+
+	score, err := c.AccountTrustService.GetAccountTrustScore(ctx.Context(), accountId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(score)
+}
+
+func (c *AdminController) GetShadowBans(ctx *fiber.Ctx) error {
+	bans, err := c.ShadowBanService.GetActiveShadowBans(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(bans)
+}
+
+func (c *AdminController) CreateShadowBan(ctx *fiber.Ctx) error {
+	var request types.ShadowBanRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	ban, err := c.ShadowBanService.CreateShadowBan(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(ban)
+}
+
+// SyncShadowBans republishes active shadow bans to Redis, e.g. after Redis has been flushed
+func (c *AdminController) SyncShadowBans(ctx *fiber.Ctx) error {
+	if err := c.ShadowBanService.SyncShadowBans(ctx.Context()); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AdminController) DeleteShadowBan(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid shadow ban id")
+	}
+
+	if err := c.ShadowBanService.DeleteShadowBan(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AdminController) GetReportGates(ctx *fiber.Ctx) error {
+	gates, err := c.ReportGateService.GetReportGates(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(gates)
+}
+
+func (c *AdminController) CreateReportGate(ctx *fiber.Ctx) error {
+	var request types.ReportGateRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	gate, err := c.ReportGateService.CreateReportGate(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(gate)
+}
+
+// SyncReportGates republishes report gates to Redis, e.g. after Redis has been flushed
+func (c *AdminController) SyncReportGates(ctx *fiber.Ctx) error {
+	if err := c.ReportGateService.SyncReportGates(ctx.Context()); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AdminController) DeleteReportGate(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid report gate id")
+	}
+
+	if err := c.ReportGateService.DeleteReportGate(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AdminController) GetStageRewriteRules(ctx *fiber.Ctx) error {
+	rules, err := c.StageRewriteService.GetStageRewriteRules(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(rules)
+}
+
+func (c *AdminController) CreateStageRewriteRule(ctx *fiber.Ctx) error {
+	var request types.StageRewriteRuleRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	rule, err := c.StageRewriteService.CreateStageRewriteRule(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(http.StatusCreated).JSON(rule)
+}
+
+// SyncStageRewriteRules republishes stage rewrite rules to Redis, e.g. after Redis has been flushed
+func (c *AdminController) SyncStageRewriteRules(ctx *fiber.Ctx) error {
+	if err := c.StageRewriteService.SyncStageRewriteRules(ctx.Context()); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
+
+func (c *AdminController) DeleteStageRewriteRule(ctx *fiber.Ctx) error {
+	id, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid stage rewrite rule id")
+	}
+
+	if err := c.StageRewriteService.DeleteStageRewriteRule(ctx.Context(), id); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(http.StatusNoContent)
+}
@@ -0,0 +1,57 @@
+package meta
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/bininfo"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/pkg/sdkgen"
+)
+
+// sdkLatestVersion is the alias of the version of the running binary in paths of SDK artifacts.
+const sdkLatestVersion = "latest"
+
+// RegisterSDK serves client SDK artifacts generated from the API of the running binary. The manifest is served at
+// `/sdk`, and artifacts at `/sdk/:version/:artifact`, where version is either the version of the binary or `latest`.
+func RegisterSDK(app *fiber.App) {
+	sdk, err := GenerateSDK()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to generate client SDK artifacts")
+	}
+
+	app.Get("/sdk", func(ctx *fiber.Ctx) error {
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(sdk)
+	})
+	app.Get("/sdk/:version/:artifact", func(ctx *fiber.Ctx) error {
+		if err != nil {
+			return err
+		}
+		if version := ctx.Params("version"); version != sdk.Version && version != sdkLatestVersion {
+			return pgerr.ErrNotFound.Msg("only artifacts of the current version `%s` are served", sdk.Version)
+		}
+		artifact := sdk.Artifact(ctx.Params("artifact"))
+		if artifact == nil {
+			return pgerr.ErrNotFound.Msg("artifact `%s` not found", ctx.Params("artifact"))
+		}
+
+		if cachectrl.NotModified(ctx, `"`+artifact.SHA256+`"`) {
+			return ctx.SendStatus(fiber.StatusNotModified)
+		}
+		ctx.Set(fiber.HeaderContentType, artifact.ContentType)
+		return ctx.Send(artifact.Body)
+	})
+}
+
+// GenerateSDK generates the client SDK artifacts of the API of the running binary.
+func GenerateSDK() (*sdkgen.SDK, error) {
+	spec, err := OpenAPISpec()
+	if err != nil {
+		return nil, err
+	}
+	return sdkgen.Generate(spec, bininfo.Version)
+}
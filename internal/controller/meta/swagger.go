@@ -3,12 +3,33 @@ package meta
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
+	"github.com/rs/zerolog/log"
 
 	"github.com/penguin-statistics/backend-next/docs"
 	"github.com/penguin-statistics/backend-next/internal/pkg/bininfo"
+	"github.com/penguin-statistics/backend-next/internal/pkg/openapi"
 )
 
 func RegisterSwagger(app *fiber.App) {
 	docs.SwaggerInfo.Version = bininfo.Version
 	app.Get("/swagger/*", swagger.HandlerDefault) // default
+
+	spec, err := OpenAPISpec()
+	if err != nil {
+		log.Error().Err(err).Msg("failed to convert the swagger document to OpenAPI 3")
+	}
+	app.Get("/swagger.json", func(ctx *fiber.Ctx) error {
+		if err != nil {
+			return err
+		}
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.Send(spec)
+	})
+}
+
+// OpenAPISpec returns the OpenAPI 3 document of the API, converted from the document swag has generated from
+// annotations of controllers.
+func OpenAPISpec() ([]byte, error) {
+	docs.SwaggerInfo.Version = bininfo.Version
+	return openapi.FromSwagger2([]byte(docs.SwaggerInfo.ReadDoc()))
 }
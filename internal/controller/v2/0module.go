@@ -18,5 +18,10 @@ func Module() fx.Option {
 		RegisterSiteStats,
 		RegisterEventPeriod,
 		RegisterShortURL,
+		RegisterDatasetSnapshot,
+		RegisterRecognition,
+		RegisterGraphQL,
+		RegisterPlanner,
+		RegisterPublicAggregate,
 	))
 }
@@ -0,0 +1,49 @@
+package v2
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
+	"github.com/penguin-statistics/backend-next/internal/server/svr"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+)
+
+var _ modelv2.Dummy
+
+type DatasetSnapshot struct {
+	fx.In
+
+	DatasetSnapshotService *service.DatasetSnapshot
+}
+
+func RegisterDatasetSnapshot(v2 *svr.V2, c DatasetSnapshot) {
+	v2.Get("/result/snapshots", c.GetDatasetSnapshots)
+}
+
+// @Summary      Get Dataset Snapshots
+// @Description  List anonymized snapshots of the whole dataset of a server, newest first. Each snapshot is a gzip'd ND-JSON file, with a line per stage, item and game day of the server, holding the total times of the stage and the total quantity of the item on that day. Download URLs are signed and expire after a while, so they shall not be stored.
+// @Tags         Result
+// @Produce      json
+// @Param        server  query     string  true  "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Success      200     {array}   modelv2.DatasetSnapshot
+// @Failure      400     {object}  pgerr.PenguinError  "Dataset snapshots are not available"
+// @Failure      500     {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/result/snapshots [GET]
+func (c *DatasetSnapshot) GetDatasetSnapshots(ctx *fiber.Ctx) error {
+	server := ctx.Query("server", "CN")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+
+	snapshots, err := c.DatasetSnapshotService.ListSnapshots(ctx.Context(), server)
+	if err != nil {
+		return err
+	}
+
+	// download URLs expire, hence the response shall never be cached
+	cachectrl.OptOut(ctx)
+	return ctx.JSON(snapshots)
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
 )
 
 type EventPeriod struct {
@@ -26,10 +27,16 @@ func RegisterEventPeriod(v2 *svr.V2, c EventPeriod) {
 // @Summary  Get All Event Periods
 // @Tags     EventPeriod
 // @Produce  json
+// @Param    lang  query     string  false  "Only include names in this language, or negotiate it by Accept-Language with `auto`"  Enums(zh, en, ja, ko, auto)
 // @Success  200  {array}   modelv2.Activity{label_i18n=model.I18nString,existence=model.Existence}
 // @Failure  500  {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router   /PenguinStats/api/v2/period [GET]
 func (c *EventPeriod) GetEventPeriods(ctx *fiber.Ctx) (err error) {
+	lang, err := rekuest.NameLanguage(ctx)
+	if err != nil {
+		return err
+	}
+
 	var activities []*modelv2.Activity
 	activities, err = c.ActivityService.GetShimActivities(ctx.Context())
 	if err != nil {
@@ -40,5 +47,8 @@ func (c *EventPeriod) GetEventPeriods(ctx *fiber.Ctx) (err error) {
 		lastModifiedTime = time.Now()
 	}
 	cachectrl.OptIn(ctx, lastModifiedTime)
+	if lang != "" {
+		return ctx.JSON(activitiesWithNames(activities, lang))
+	}
 	return ctx.JSON(activities)
 }
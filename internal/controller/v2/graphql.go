@@ -0,0 +1,58 @@
+package v2
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/server/svr"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util"
+	"github.com/penguin-statistics/backend-next/internal/util/graph"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+)
+
+type GraphQL struct {
+	fx.In
+
+	ItemService          *service.Item
+	StageService         *service.Stage
+	ZoneService          *service.Zone
+	DropMatrixService    *service.DropMatrix
+	PatternMatrixService *service.PatternMatrix
+	TrendService         *service.Trend
+}
+
+func RegisterGraphQL(v2 *svr.V2, c GraphQL) {
+	schema := util.Must(graph.NewSchema(graph.Services{
+		ItemService:          c.ItemService,
+		StageService:         c.StageService,
+		ZoneService:          c.ZoneService,
+		DropMatrixService:    c.DropMatrixService,
+		PatternMatrixService: c.PatternMatrixService,
+		TrendService:         c.TrendService,
+	}))
+
+	v2.Post("/graphql", func(ctx *fiber.Ctx) error {
+		return c.Query(ctx, schema)
+	})
+}
+
+// @Summary      Query Result Data with GraphQL
+// @Description  Read-only GraphQL endpoint covering items, stages, zones, the drop matrix, drop patterns and trends, so that only the fields needed are fetched, in one round trip. Query errors are reported in `errors` of the response as per the GraphQL specification, with a status of 200. The schema could be introspected.
+// @Tags         Result
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.GraphQLRequest  true  "GraphQL request"
+// @Success      200      {object}  object                "GraphQL response, with `data` and `errors`"
+// @Failure      400      {object}  pgerr.PenguinError    "Invalid request"
+// @Router       /PenguinStats/api/v2/graphql [POST]
+func (c *GraphQL) Query(ctx *fiber.Ctx, schema *graphql.Schema) error {
+	var req types.GraphQLRequest
+	if err := rekuest.ValidBody(ctx, &req); err != nil {
+		return err
+	}
+
+	return ctx.JSON(schema.Exec(ctx.Context(), req.Query, req.OperationName, req.Variables))
+}
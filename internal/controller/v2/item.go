@@ -11,6 +11,8 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/fieldset"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
 )
 
 var _ modelv2.Dummy
@@ -29,36 +31,60 @@ func RegisterItem(v2 *svr.V2, c Item) {
 // @Summary  Get All Items
 // @Tags     Item
 // @Produce  json
+// @Param    lang    query     string    false  "Only include names in this language, or negotiate it by Accept-Language with `auto`"  Enums(zh, en, ja, ko, auto)
+// @Param    fields  query     []string  false  "Comma separated list of fields to limit each record to"  collectionFormat(csv)
 // @Success  200  {array}   modelv2.Item{name_i18n=model.I18nString,existence=model.Existence}
 // @Failure  500  {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router   /PenguinStats/api/v2/items [GET]
 func (c *Item) GetItems(ctx *fiber.Ctx) error {
+	lang, err := rekuest.NameLanguage(ctx)
+	if err != nil {
+		return err
+	}
+	fields := fieldset.Parse(ctx)
+
 	items, err := c.ItemService.GetShimItems(ctx.Context())
 	if err != nil {
 		return err
 	}
+	cacheKey := "[shimItems]"
 	var lastModifiedTime time.Time
-	if err := cache.LastModifiedTime.Get("[shimItems]", &lastModifiedTime); err != nil {
+	if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 		lastModifiedTime = time.Now()
 	}
 	cachectrl.OptIn(ctx, lastModifiedTime)
-	return ctx.JSON(items)
+	if cachectrl.NotModified(ctx, cachectrl.ETag(cacheKey, lang, fieldset.Key(fields), lastModifiedTime.Format(time.RFC3339Nano))) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	if lang != "" {
+		items = itemsWithNames(items, lang)
+	}
+	return fieldset.Send(ctx, items, "", fields)
 }
 
 // @Summary  Get an Item with ID
 // @Tags     Item
 // @Produce  json
-// @Param    itemId  path      string  true  "Item ID"
+// @Param    itemId  path      string    true   "Item ID"
+// @Param    lang    query     string    false  "Only include names in this language, or negotiate it by Accept-Language with `auto`"  Enums(zh, en, ja, ko, auto)
+// @Param    fields  query     []string  false  "Comma separated list of fields to limit each record to"  collectionFormat(csv)
 // @Success  200     {object}  modelv2.Item{name_i18n=model.I18nString,existence=model.Existence}
 // @Failure  400     {object}  pgerr.PenguinError  "Invalid or missing itemId. Notice that this shall be the **string ID** of the item, instead of the internally used numerical ID of the item."
 // @Failure  500     {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router   /PenguinStats/api/v2/items/{itemId} [GET]
 func (c *Item) GetItemByArkId(ctx *fiber.Ctx) error {
 	itemId := ctx.Params("itemId")
+	lang, err := rekuest.NameLanguage(ctx)
+	if err != nil {
+		return err
+	}
 
 	item, err := c.ItemService.GetShimItemByArkId(ctx.Context(), itemId)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(item)
+	if lang != "" {
+		item = itemWithNames(item, lang)
+	}
+	return fieldset.Send(ctx, item, "", fieldset.Parse(ctx))
 }
@@ -0,0 +1,66 @@
+package v2
+
+import (
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/util/i18n"
+)
+
+// names of the models below are filtered on copies, as the models themselves are shared through caches
+
+func itemsWithNames(items []*modelv2.Item, lang string) []*modelv2.Item {
+	filtered := make([]*modelv2.Item, 0, len(items))
+	for _, item := range items {
+		filtered = append(filtered, itemWithNames(item, lang))
+	}
+	return filtered
+}
+
+func itemWithNames(item *modelv2.Item, lang string) *modelv2.Item {
+	filtered := *item
+	filtered.NameI18n = i18n.FilterNames(item.NameI18n, lang)
+	if item.AliasMap != nil {
+		filtered.AliasMap = i18n.FilterNames(item.AliasMap, lang)
+	}
+	if item.PronMap != nil {
+		filtered.PronMap = i18n.FilterNames(item.PronMap, lang)
+	}
+	return &filtered
+}
+
+func stagesWithNames(stages []*modelv2.Stage, lang string) []*modelv2.Stage {
+	filtered := make([]*modelv2.Stage, 0, len(stages))
+	for _, stage := range stages {
+		filtered = append(filtered, stageWithNames(stage, lang))
+	}
+	return filtered
+}
+
+func stageWithNames(stage *modelv2.Stage, lang string) *modelv2.Stage {
+	filtered := *stage
+	filtered.CodeI18n = i18n.FilterNames(stage.CodeI18n, lang)
+	return &filtered
+}
+
+func zonesWithNames(zones []*modelv2.Zone, lang string) []*modelv2.Zone {
+	filtered := make([]*modelv2.Zone, 0, len(zones))
+	for _, zone := range zones {
+		filtered = append(filtered, zoneWithNames(zone, lang))
+	}
+	return filtered
+}
+
+func zoneWithNames(zone *modelv2.Zone, lang string) *modelv2.Zone {
+	filtered := *zone
+	filtered.ZoneNameI18n = i18n.FilterNames(zone.ZoneNameI18n, lang)
+	return &filtered
+}
+
+func activitiesWithNames(activities []*modelv2.Activity, lang string) []*modelv2.Activity {
+	filtered := make([]*modelv2.Activity, 0, len(activities))
+	for _, activity := range activities {
+		activityCopy := *activity
+		activityCopy.LabelI18n = i18n.FilterNames(activity.LabelI18n, lang)
+		filtered = append(filtered, &activityCopy)
+	}
+	return filtered
+}
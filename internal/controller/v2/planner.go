@@ -0,0 +1,60 @@
+package v2
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/server/svr"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+)
+
+var _ modelv2.Dummy
+
+type Planner struct {
+	fx.In
+
+	PlannerService *service.Planner
+}
+
+func RegisterPlanner(v2 *svr.V2, c Planner) {
+	v2.Post("/plan", limiter.New(limiter.Config{
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"code":    "TOO_MANY_REQUESTS",
+				"message": "Your client is sending requests too frequently. The Penguin Stats planner API is limited to 60 requests per 5 minutes.",
+			})
+		},
+		Max:        60,
+		Expiration: time.Minute * 5,
+	}), c.Plan)
+}
+
+// @Summary      Plan Farming
+// @Description  Plan which stages to farm, and how many runs each, to obtain the items required with the least sanity expected, according to drop rates of the current drop matrix. Only stages currently open, and sampled enough times, are farmed.
+// @Tags         Planner
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.PlanRequest   true  "Items required"
+// @Success      200      {object}  modelv2.Plan        "Farming plan"
+// @Failure      400      {object}  pgerr.PenguinError  "Invalid request, or some items are not dropped by any stage available"
+// @Failure      404      {object}  pgerr.PenguinError  "Item not found"
+// @Failure      500      {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/plan [POST]
+func (c *Planner) Plan(ctx *fiber.Ctx) error {
+	var request types.PlanRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	plan, err := c.PlannerService.Plan(ctx.Context(), &request)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(plan)
+}
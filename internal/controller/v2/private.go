@@ -27,6 +27,7 @@ type Private struct {
 	AccountService       *service.Account
 	ItemService          *service.Item
 	StageService         *service.Stage
+	CacheVersionService  *service.CacheVersion
 }
 
 func RegisterPrivate(v2 *svr.V2, c Private) {
@@ -57,18 +58,24 @@ func (c *Private) GetDropMatrix(ctx *fiber.Ctx) error {
 		accountId.Valid = true
 	}
 
+	validator := cachectrl.NewValidator(c.CacheVersionService.ETagVersion(ctx.Context(), constant.CacheVersionDropMatrix, server))
 	shimResult, err := c.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx.Context(), server, true, "", "", accountId)
 	if err != nil {
 		return err
 	}
+	shimResult = c.DropMatrixService.GateLowSample(shimResult, "")
 
 	if !accountId.Valid {
 		key := server + constant.CacheSep + "true"
+		cacheKey := "[shimMaxAccumulableDropMatrixResults#server|showClosedZoned:" + key + "]"
 		var lastModifiedTime time.Time
-		if err := cache.LastModifiedTime.Get("[shimMaxAccumulableDropMatrixResults#server|showClosedZoned:"+key+"]", &lastModifiedTime); err != nil {
+		if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 			lastModifiedTime = time.Now()
 		}
 		cachectrl.OptIn(ctx, lastModifiedTime)
+		if validator.NotModified(ctx, cacheKey) {
+			return ctx.SendStatus(fiber.StatusNotModified)
+		}
 	}
 
 	return ctx.JSON(shimResult)
@@ -96,17 +103,22 @@ func (c *Private) GetPatternMatrix(ctx *fiber.Ctx) error {
 		accountId.Valid = true
 	}
 
+	validator := cachectrl.NewValidator(c.CacheVersionService.ETagVersion(ctx.Context(), constant.CacheVersionPatternMatrix, server))
 	shimResult, err := c.PatternMatrixService.GetShimLatestPatternMatrixResults(ctx.Context(), server, accountId)
 	if err != nil {
 		return err
 	}
 
 	if !accountId.Valid {
+		cacheKey := "[shimLatestPatternMatrixResults#server:" + server + "]"
 		var lastModifiedTime time.Time
-		if err := cache.LastModifiedTime.Get("[shimLatestPatternMatrixResults#server:"+server+"]", &lastModifiedTime); err != nil {
+		if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 			lastModifiedTime = time.Now()
 		}
 		cachectrl.OptIn(ctx, lastModifiedTime)
+		if validator.NotModified(ctx, cacheKey) {
+			return ctx.SendStatus(fiber.StatusNotModified)
+		}
 	}
 
 	return ctx.JSON(shimResult)
@@ -115,22 +127,30 @@ func (c *Private) GetPatternMatrix(ctx *fiber.Ctx) error {
 // @Summary  Get Trends
 // @Tags     Private
 // @Produce  json
-// @Param    server  path      string  true  "Server; default to CN"  Enums(CN, US, JP, KR)
-// @Success  200     {object}  modelv2.TrendQueryResult
-// @Failure  500     {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Param    server       path      string  true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param    granularity  query     string  false  "Length of each interval of trends; default to day"  Enums(hour, day, week)
+// @Success  200          {object}  modelv2.TrendQueryResult
+// @Failure  400          {object}  pgerr.PenguinError  "Invalid granularity"
+// @Failure  500          {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router   /PenguinStats/api/v2/_private/result/trend/{server} [GET]
 func (c *Private) GetTrends(ctx *fiber.Ctx) error {
 	server := ctx.Params("server")
-	shimResult, err := c.TrendService.GetShimSavedTrendResults(ctx.Context(), server)
+	granularity := ctx.Query("granularity", constant.TrendGranularityDay)
+	validator := cachectrl.NewValidator(c.CacheVersionService.ETagVersion(ctx.Context(), constant.CacheVersionTrend, service.ShimSavedTrendResultsKey(server, granularity)))
+	shimResult, err := c.TrendService.GetShimSavedTrendResults(ctx.Context(), server, granularity)
 	if err != nil {
 		return err
 	}
 
+	cacheKey := "[shimSavedTrendResults#server|granularity:" + service.ShimSavedTrendResultsKey(server, granularity) + "]"
 	var lastModifiedTime time.Time
-	if err := cache.LastModifiedTime.Get("[shimSavedTrendResults#server:"+server+"]", &lastModifiedTime); err != nil {
+	if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 		lastModifiedTime = time.Now()
 	}
 	cachectrl.OptIn(ctx, lastModifiedTime)
+	if validator.NotModified(ctx, cacheKey) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
 
 	return ctx.JSON(shimResult)
 }
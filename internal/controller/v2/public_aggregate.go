@@ -0,0 +1,58 @@
+package v2
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
+	"github.com/penguin-statistics/backend-next/internal/pkg/precompress"
+	"github.com/penguin-statistics/backend-next/internal/server/svr"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+)
+
+var _ modelv2.Dummy
+
+type PublicAggregate struct {
+	fx.In
+
+	PublicAggregateService *service.PublicAggregate
+}
+
+func RegisterPublicAggregate(v2 *svr.V2, c PublicAggregate) {
+	v2.Get("/public/aggregated", c.GetPublicAggregate)
+}
+
+// @Summary      Get Public Aggregate
+// @Description  Get the drop matrix of a server, with closed zones, along with its stages and all items, in one document. The document is rendered every few minutes rather than on request, and is compressed ahead in Brotli, Zstandard and gzip, responded as is to clients accepting any of them, so third-party tools are encouraged to use it instead of the dynamic endpoints. Responses carry an ETag to be revalidated with.
+// @Tags         Public
+// @Produce      json
+// @Param        server  query     string                   true  "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Success      200     {object}  modelv2.PublicAggregate  "Public aggregate of the server"
+// @Failure      500     {object}  pgerr.PenguinError       "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/public/aggregated [GET]
+func (c *PublicAggregate) GetPublicAggregate(ctx *fiber.Ctx) error {
+	server := ctx.Query("server", "CN")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+
+	meta, err := c.PublicAggregateService.GetPublicAggregateMeta(ctx.Context(), server)
+	if err != nil {
+		return err
+	}
+
+	encoding := precompress.Negotiate(ctx.Get(fiber.HeaderAcceptEncoding), meta.Encodings)
+	ctx.Vary(fiber.HeaderAcceptEncoding)
+	cachectrl.OptIn(ctx, meta.GeneratedAt)
+	if cachectrl.NotModified(ctx, cachectrl.ETag(meta.ETag, encoding)) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	entry, err := c.PublicAggregateService.GetPublicAggregateEntry(ctx.Context(), server, meta, encoding)
+	if err != nil {
+		return err
+	}
+	return precompress.Send(ctx, entry, encoding)
+}
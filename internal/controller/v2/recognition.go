@@ -0,0 +1,90 @@
+package v2
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
+	"github.com/penguin-statistics/backend-next/internal/server/svr"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+)
+
+var _ modelv2.Dummy
+
+// recognitionBundleMaxAge is how long bundles could be cached for. Bundles are addressed by the hash of their content
+// and never change.
+const recognitionBundleMaxAge = time.Hour * 24 * 365
+
+type Recognition struct {
+	fx.In
+
+	RecognitionService *service.Recognition
+}
+
+func RegisterRecognition(v2 *svr.V2, c Recognition) {
+	v2.Get("/recognition/:server/latest", c.GetLatestRecognitionRelease)
+	v2.Get("/recognition/bundles/:hash", c.GetRecognitionBundle)
+}
+
+// @Summary      Get Latest Recognition Bundle
+// @Description  Get the latest bundle of item recognition definitions of a server. Recognizers shall check it for updates every now and then, and download the bundle from its URL only when the hash has changed.
+// @Tags         Recognition
+// @Produce      json
+// @Param        server  path      string  true  "Server"  Enums(CN, US, JP, KR)
+// @Success      200     {object}  modelv2.RecognitionRelease
+// @Failure      404     {object}  pgerr.PenguinError  "No recognition bundle has been released for the server"
+// @Failure      500     {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/recognition/{server}/latest [GET]
+func (c *Recognition) GetLatestRecognitionRelease(ctx *fiber.Ctx) error {
+	server := ctx.Params("server")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+
+	release, err := c.RecognitionService.GetLatestRelease(ctx.Context(), server)
+	if err != nil {
+		return err
+	}
+
+	if cachectrl.NotModified(ctx, cachectrl.ETag(server, release.Hash)) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	cachectrl.OptInCustom(ctx, time.UnixMilli(release.ReleasedAt), constant.RecognitionReleaseMaxAge)
+	return ctx.JSON(release)
+}
+
+// @Summary      Get Recognition Bundle
+// @Description  Download a bundle of item recognition definitions by its hash. The content of a hash never changes, hence responses could be cached forever.
+// @Tags         Recognition
+// @Produce      octet-stream
+// @Param        hash  path      string  true  "Hex-encoded SHA-256 hash of the bundle"
+// @Success      200   {file}    binary
+// @Failure      404   {object}  pgerr.PenguinError  "Recognition bundle not found"
+// @Failure      500   {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/recognition/bundles/{hash} [GET]
+func (c *Recognition) GetRecognitionBundle(ctx *fiber.Ctx) error {
+	hash := ctx.Params("hash")
+	if err := rekuest.ValidVar(ctx, hash, "required,len=64,hexadecimal,lowercase"); err != nil {
+		return err
+	}
+
+	etag := `"` + hash + `"`
+	if cachectrl.NotModified(ctx, etag) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+
+	content, err := c.RecognitionService.GetBundleContent(ctx.Context(), hash)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(recognitionBundleMaxAge.Seconds()))+", immutable")
+	ctx.Set(fiber.HeaderContentType, content.ContentType)
+	return ctx.Send(content.Content)
+}
@@ -1,13 +1,20 @@
 package v2
 
 import (
+	"bufio"
+	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"go.uber.org/fx"
 
+	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model/types"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
 	"github.com/penguin-statistics/backend-next/internal/pkg/crypto"
@@ -20,31 +27,50 @@ import (
 type Report struct {
 	fx.In
 
-	Crypto        *crypto.Crypto
-	ReportService *service.Report
+	Crypto             *crypto.Crypto
+	ReportService      *service.Report
+	FeatureFlagService *service.FeatureFlag
 }
 
 func RegisterReport(v2 *svr.V2, c Report) {
 	v2.Post("/report", c.SingularReport)
 	v2.Post("/report/recall", c.RecallSingularReport)
+	v2.Post("/report/recall/batch", c.RecallBatchReports)
+	v2.Patch("/report/:hash", c.CorrectSingularReport)
 	v2.Post("/report/recognition", c.RecognitionReport)
+	v2.Get("/report/mitigation/preview", c.PreviewMitigation)
+	v2.Get("/report/task/:taskId", c.GetReportTaskStatus)
+	v2.Get("/report/task/:taskId/events", c.WatchReportTaskStatus)
 }
 
 // @Summary      Submit a Drop Report
 // @Description  Submit a Drop Report. You can use the `reportHash` in the response to recall the report in 24 hours after it has been submitted.
+// @Description  When the report is rejected for a fixable reason, the error response carries a `suggestion` object (see `types.ReportCorrectionSuggestion`) which clients could apply to correct the report and retry.
 // @Tags         Report
 // @Accept       json
 // @Produce      json
-// @Param        report  body      types.SingleReportRequest  true  "Report request"
+// @Param        report  body      types.SingleReportRequest  true   "Report request"
+// @Param        sync    query     bool                       false  "When true, the report is verified and persisted before responding, and the final verdict is returned in `verdict`, unless synchronous mode is not available on the server of the report, in which case the report is queued as usual"
 // @Success      201     {object}  modelv2.ReportResponse     "Report has been successfully submitted"
 // @Failure      400     {object}  pgerr.PenguinError         "Invalid request"
+// @Failure      429     {object}  pgerr.PenguinError         "Report quota of the stage exceeded; retry after `Retry-After` seconds"
 // @Failure      500     {object}  pgerr.PenguinError         "An unexpected error occurred"
+// @Failure      503     {object}  pgerr.PenguinError         "The report could not be queued as the message queue is unavailable; retry later"
+// @Failure      504     {object}  pgerr.PenguinError         "The report could not be processed in time in synchronous mode"
 // @Security     PenguinIDAuth
 // @Router       /PenguinStats/api/v2/report [POST]
 func (c *Report) SingularReport(ctx *fiber.Ctx) error {
 	var report types.SingleReportRequest
 	if err := rekuest.ValidBody(ctx, &report); err != nil {
-		return err
+		return c.ReportService.WithCorrectionSuggestion(ctx.Context(), &report, err)
+	}
+
+	if ctx.Query("sync") == "true" && c.FeatureFlagService.EnabledForRequest(ctx, constant.FeatureFlagSyncReport, report.Server, true) {
+		taskId, verdict, err := c.ReportService.PreprocessAndConsumeSingularReport(ctx, &report)
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(modelv2.ReportResponse{ReportHash: taskId, Verdict: verdict})
 	}
 
 	taskId, err := c.ReportService.PreprocessAndQueueSingularReport(ctx, &report)
@@ -55,13 +81,13 @@ func (c *Report) SingularReport(ctx *fiber.Ctx) error {
 }
 
 // @Summary      Recall a Drop Report
-// @Description  Recall a Drop Report by its `reportHash`. The farest report you can recall is limited to 24 hours. Recalling a report after it has been already recalled will result in an error.
+// @Description  Recall a Drop Report by its `reportHash`. The farest report you can recall is limited to 24 hours by default. Recalling a report after it has been already recalled will result in an error.
 // @Tags         Report
 // @Accept       json
 // @Produce      json
 // @Param        report  body  types.SingleReportRecallRequest  true  "Report Recall request"
 // @Success      204     "Report has been successfully recalled"
-// @Failure      400     {object}  pgerr.PenguinError  "`reportHash` is missing, invalid, or already been recalled."
+// @Failure      400     {object}  pgerr.PenguinError  "`reportHash` is missing, invalid, already been recalled, or the recall window has passed."
 // @Failure      500     {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router       /PenguinStats/api/v2/report/recall [POST]
 func (c *Report) RecallSingularReport(ctx *fiber.Ctx) error {
@@ -70,7 +96,7 @@ func (c *Report) RecallSingularReport(ctx *fiber.Ctx) error {
 		return err
 	}
 
-	err := c.ReportService.RecallSingularReport(ctx.Context(), &req)
+	err := c.ReportService.RecallSingularReport(ctx, &req)
 	if err != nil {
 		return err
 	}
@@ -78,14 +104,67 @@ func (c *Report) RecallSingularReport(ctx *fiber.Ctx) error {
 	return ctx.SendStatus(fiber.StatusOK)
 }
 
+// @Summary      Recall Drop Reports in Batch
+// @Description  Recall multiple Drop Reports by their `reportHash`es in a single transaction. Each `reportHash` is subject to the same limitations as recalling a single report; those could not be recalled are reported in `results` without preventing others from being recalled.
+// @Tags         Report
+// @Accept       json
+// @Produce      json
+// @Param        report  body      types.BatchReportRecallRequest  true  "Batch Report Recall request"
+// @Success      200     {object}  modelv2.BatchRecallResponse     "Recall result of each report"
+// @Failure      400     {object}  pgerr.PenguinError              "`reportHashes` is missing or invalid"
+// @Failure      500     {object}  pgerr.PenguinError              "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/report/recall/batch [POST]
+func (c *Report) RecallBatchReports(ctx *fiber.Ctx) error {
+	var req types.BatchReportRecallRequest
+	if err := rekuest.ValidBody(ctx, &req); err != nil {
+		return err
+	}
+
+	resp, err := c.ReportService.RecallBatchReports(ctx, &req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(resp)
+}
+
+// @Summary      Correct a Drop Report
+// @Description  Correct the drops of a Drop Report by its `reportHash`, e.g. when a quantity has been recognized wrongly. The corrected report keeps the stage, server and source of the report, and is queued just like a new report; once persisted, it supersedes the report, and both are linked for auditing. A report could be corrected within the same window it could be recalled in, and only once; use the `reportHash` in the response to recall or correct the corrected report.
+// @Tags         Report
+// @Accept       json
+// @Produce      json
+// @Param        hash    path      string                         true  "Report hash of the report to correct"
+// @Param        report  body      types.ReportCorrectionRequest  true  "Report Correction request"
+// @Success      200     {object}  modelv2.ReportResponse         "Corrected report has been successfully submitted"
+// @Failure      400     {object}  pgerr.PenguinError             "`reportHash` is invalid, already been recalled or corrected, the recall window has passed, or the corrected drops are invalid"
+// @Failure      500     {object}  pgerr.PenguinError             "An unexpected error occurred"
+// @Failure      503     {object}  pgerr.PenguinError             "The corrected report could not be queued as the message queue is unavailable; retry later"
+// @Security     PenguinIDAuth
+// @Router       /PenguinStats/api/v2/report/{hash} [PATCH]
+func (c *Report) CorrectSingularReport(ctx *fiber.Ctx) error {
+	var req types.ReportCorrectionRequest
+	if err := rekuest.ValidBody(ctx, &req); err != nil {
+		return err
+	}
+
+	taskId, err := c.ReportService.CorrectSingularReport(ctx, ctx.Params("hash"), &req)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(modelv2.ReportResponse{ReportHash: taskId})
+}
+
 // @Summary      Bulk Submit with Frontend Recognition
 // @Description  Submit an Item Drop Report with Frontend Recognition. Notice that this is a **private API** and is not designed for external use.
 // @Tags         Report
 // @Produce      json
-// @Param        report  body      string                             true  "Recognition Report Request"
+// @Param        report  body      string                             true   "Recognition Report Request"
+// @Param        sync    query     bool                               false  "When true, the reports are verified and persisted before responding, and the final verdicts are returned in `verdicts`, unless synchronous mode is not available on the server of the reports, in which case the reports are queued as usual"
 // @Success      200     {object}  modelv2.RecognitionReportResponse  "Report has been successfully submitted for queue processing"
 // @Failure      400     {object}  pgerr.PenguinError                 "Invalid request"
+// @Failure      429     {object}  pgerr.PenguinError                 "Report quota of a stage exceeded; retry after `Retry-After` seconds"
 // @Failure      500     {object}  pgerr.PenguinError                 "An unexpected error occurred"
+// @Failure      503     {object}  pgerr.PenguinError                 "The reports could not be queued as the message queue is unavailable; retry later"
 // @Security     PenguinIDAuth
 // @Router       /PenguinStats/api/v2/report/recognition [POST]
 func (c *Report) RecognitionReport(ctx *fiber.Ctx) error {
@@ -124,6 +203,18 @@ func (c *Report) RecognitionReport(ctx *fiber.Ctx) error {
 			Msg("received recognition report request")
 	}
 
+	if ctx.Query("sync") == "true" && c.FeatureFlagService.EnabledForRequest(ctx, constant.FeatureFlagSyncReport, request.Server, true) {
+		taskId, verdicts, err := c.ReportService.PreprocessAndConsumeBatchReport(ctx, &request)
+		if err != nil {
+			return err
+		}
+		return ctx.JSON(modelv2.RecognitionReportResponse{
+			TaskId:   taskId,
+			Errors:   []string{},
+			Verdicts: verdicts,
+		})
+	}
+
 	taskId, err := c.ReportService.PreprocessAndQueueBatchReport(ctx, &request)
 	if err != nil {
 		return err
@@ -134,3 +225,113 @@ func (c *Report) RecognitionReport(ctx *fiber.Ctx) error {
 		Errors: []string{},
 	})
 }
+
+// @Summary      Get Report Task Status
+// @Description  Get the processing state of a report task by its `taskId`, which is the `reportHash` (or `taskId` for recognition reports) returned when the report has been submitted. Status is kept as long as the report could be recalled.
+// @Tags         Report
+// @Produce      json
+// @Param        taskId  path      string                    true  "Task ID"
+// @Success      200     {object}  modelv2.ReportTaskStatus  "Report task status"
+// @Failure      400     {object}  pgerr.PenguinError        "Task not found or already expired"
+// @Failure      500     {object}  pgerr.PenguinError        "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/report/task/{taskId} [GET]
+func (c *Report) GetReportTaskStatus(ctx *fiber.Ctx) error {
+	taskId := ctx.Params("taskId")
+	if err := rekuest.ValidVar(ctx, taskId, "required,printascii,max=128"); err != nil {
+		return err
+	}
+
+	status, err := c.ReportService.GetReportTaskStatus(ctx.Context(), taskId)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(status)
+}
+
+// @Summary      Preview Report Mitigations
+// @Description  Preview how the report pipeline would rewrite the `stageId` of a report, reported by `source` at `timestamp`, to compensate known client-side defects. Nothing is submitted.
+// @Tags         Report
+// @Produce      json
+// @Param        source     query     string                             true   "Source of the report, same as `source` in the report request"
+// @Param        stageId    query     string                             true   "Stage ID, same as `stageId` in the report request"
+// @Param        version    query     string                             false  "Version of the source, same as `version` in the report request"
+// @Param        timestamp  query     int                                false  "Time the report would be submitted at, in milliseconds since the epoch; default to now"
+// @Success      200        {object}  modelv2.MitigationPreviewResponse  "Mitigation preview"
+// @Failure      400        {object}  pgerr.PenguinError                 "Invalid request"
+// @Failure      500        {object}  pgerr.PenguinError                 "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/report/mitigation/preview [GET]
+func (c *Report) PreviewMitigation(ctx *fiber.Ctx) error {
+	source := ctx.Query("source")
+	stageId := ctx.Query("stageId")
+	if err := rekuest.ValidVar(ctx, source, "required,printascii,max=128"); err != nil {
+		return err
+	}
+	if err := rekuest.ValidVar(ctx, stageId, "required,printascii"); err != nil {
+		return err
+	}
+
+	t := time.Now()
+	if timestamp := ctx.Query("timestamp"); timestamp != "" {
+		millis, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return pgerr.ErrInvalidReq.Msg("invalid timestamp: %s", timestamp)
+		}
+		t = time.UnixMilli(millis)
+	}
+
+	return ctx.JSON(c.ReportService.PreviewMitigation(ctx.Context(), source, ctx.Query("version"), stageId, t))
+}
+
+// reportTaskWatchTimeout is how long a report task status event stream lasts, which shall be shorter than the
+// write timeout of the HTTP server. Clients are expected to reconnect if the task has not settled by then.
+const reportTaskWatchTimeout = time.Second * 15
+
+// @Summary      Watch Report Task Status
+// @Description  Watch the processing state of a report task by its `taskId` as a stream of Server-Sent Events. A `status` event, with the same payload as Get Report Task Status, is sent with the current status and every update afterwards, and the stream ends once the task has been persisted, rejected or failed. Streams also end after 15 seconds, in which case clients (e.g. `EventSource`) shall reconnect.
+// @Tags         Report
+// @Produce      text/event-stream
+// @Param        taskId  path      string                    true  "Task ID"
+// @Success      200     {object}  modelv2.ReportTaskStatus  "Stream of report task status"
+// @Failure      400     {object}  pgerr.PenguinError        "Task not found or already expired"
+// @Failure      500     {object}  pgerr.PenguinError        "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/report/task/{taskId}/events [GET]
+func (c *Report) WatchReportTaskStatus(ctx *fiber.Ctx) error {
+	taskId := ctx.Params("taskId")
+	if err := rekuest.ValidVar(ctx, taskId, "required,printascii,max=128"); err != nil {
+		return err
+	}
+
+	// fail with a regular error response if the task does not exist, before the stream has started
+	if _, err := c.ReportService.GetReportTaskStatus(ctx.Context(), taskId); err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	// disables response buffering of nginx, which would otherwise hold events back
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// the fiber context is released once the handler returns, hence a context of its own
+		watchCtx, cancel := context.WithTimeout(context.Background(), reportTaskWatchTimeout)
+		defer cancel()
+
+		fmt.Fprintf(w, "retry: %d\n\n", time.Second.Milliseconds())
+		err := c.ReportService.WatchReportTaskStatus(watchCtx, taskId, func(status *modelv2.ReportTaskStatus) error {
+			data, err := json.Marshal(status)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "event: status\ndata: %s\n\n", data)
+			// an error on flush means the client has gone away
+			return w.Flush()
+		})
+		if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+			log.Warn().Err(err).Str("taskId", taskId).Msg("report task status stream terminated")
+		}
+	})
+
+	return nil
+}
@@ -9,16 +9,22 @@ import (
 	"go.uber.org/fx"
 	"gopkg.in/guregu/null.v3"
 
+	"github.com/penguin-statistics/backend-next/internal/config"
 	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model"
 	"github.com/penguin-statistics/backend-next/internal/model/cache"
 	"github.com/penguin-statistics/backend-next/internal/model/types"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	modelv3 "github.com/penguin-statistics/backend-next/internal/model/v3"
 	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/pkg/precompress"
+	"github.com/penguin-statistics/backend-next/internal/pkg/schemaver"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/fieldset"
 	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+	"github.com/penguin-statistics/backend-next/internal/util/tabular"
 )
 
 // ErrIntervalLengthTooSmall is returned when the interval length is invalid
@@ -27,18 +33,40 @@ var ErrIntervalLengthTooSmall = pgerr.ErrInvalidReq.Msg("interval length must be
 type Result struct {
 	fx.In
 
-	DropMatrixService    *service.DropMatrix
-	PatternMatrixService *service.PatternMatrix
-	TrendService         *service.Trend
-	AccountService       *service.Account
-	ItemService          *service.Item
-	StageService         *service.Stage
+	Config                   *config.Config
+	DropMatrixService        *service.DropMatrix
+	PrecompressedService     *service.Precompressed
+	DropMatrixHistoryService *service.DropMatrixHistory
+	EfficiencyService        *service.Efficiency
+	PatternMatrixService     *service.PatternMatrix
+	TrendService             *service.Trend
+	PersonalHistoryService   *service.PersonalHistory
+	DropReportService        *service.DropReport
+	CacheVersionService      *service.CacheVersion
+	AccountService           *service.Account
+	ItemService              *service.Item
+	StageService             *service.Stage
 }
 
 func RegisterResult(v2 *svr.V2, c Result) {
 	v2.Get("/result/matrix", c.GetDropMatrix)
+	v2.Get("/result/matrix/history", c.GetDropMatrixHistory)
+	v2.Get("/result/item/:itemId", c.GetItemDropStages)
+	v2.Get("/result/efficiency/:itemId", limiter.New(limiter.Config{
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"code":    "TOO_MANY_REQUESTS",
+				"message": "Your client is sending requests too frequently. The Penguin Stats stage efficiency API is limited to 60 requests per 5 minutes.",
+			})
+		},
+		Max:        60,
+		Expiration: time.Minute * 5,
+	}), c.GetStageEfficiency)
 	v2.Get("/result/pattern", c.GetPatternMatrix)
+	v2.Get("/result/compare", c.CompareServers)
 	v2.Get("/result/trends", c.GetTrends)
+	v2.Get("/result/personal/history", c.GetPersonalHistory)
+	v2.Get("/result/personal/reports", c.GetPersonalReports)
 	v2.Post("/result/advanced", limiter.New(limiter.Config{
 		LimitReached: func(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
@@ -51,23 +79,39 @@ func RegisterResult(v2 *svr.V2, c Result) {
 	}), c.AdvancedQuery)
 }
 
-// @Summary   Get Drop Matrix
-// @Tags      Result
-// @Produce   json
-// @Param     server             query     string                         true   "Server; default to CN"  Enums(CN, US, JP, KR)
-// @Param     is_personal        query     bool                           false  "Whether to query for personal drop matrix or not. If `is_personal` equals to `true`, a valid PenguinID would be required to be provided (PenguinIDAuth)"
-// @Param     show_closed_zones  query     bool                           false  "Whether to show closed stages or not"
-// @Param     stageFilter        query     []string                       false  "Comma separated list of stage IDs to filter"  collectionFormat(csv)
-// @Param     itemFilter         query     []string                       false  "Comma separated list of item IDs to filter"   collectionFormat(csv)
-// @Success   200                {object}  modelv2.DropMatrixQueryResult  "Drop Matrix response"
-// @Failure   500                {object}  pgerr.PenguinError             "An unexpected error occurred"
-// @Security  PenguinIDAuth
-// @Router    /PenguinStats/api/v2/result/matrix [GET]
+// @Summary      Get Drop Matrix
+// @Description  Responds in CSV or TSV, with a row per drop matrix element, when requested with the `format` query or the `Accept` header. The global drop matrix in JSON, without filters or fields, is compressed ahead and responded in Brotli, Zstandard or gzip according to the `Accept-Encoding` header.
+// @Tags         Result
+// @Produce      json
+// @Produce      text/csv
+// @Produce      text/tab-separated-values
+// @Param        server             query     string                         true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param        is_personal        query     bool                           false  "Whether to query for personal drop matrix or not. If `is_personal` equals to `true`, a valid PenguinID would be required to be provided (PenguinIDAuth)"
+// @Param        show_closed_zones  query     bool                           false  "Whether to show closed stages or not"
+// @Param        stageFilter        query     []string                       false  "Comma separated list of stage IDs to filter"  collectionFormat(csv)
+// @Param        itemFilter         query     []string                       false  "Comma separated list of item IDs to filter"   collectionFormat(csv)
+// @Param        format             query     string                         false  "Response format; default to json, or negotiated by the Accept header"  Enums(json, csv, tsv)
+// @Param        fields             query     []string                       false  "Comma separated list of fields to limit each drop matrix element to, in JSON responses"  collectionFormat(csv)
+// @Param        low_sample         query     string                         false  "How elements sampled too few times to be accurate are treated; default to the server configuration"  Enums(flag, exclude)
+// @Param        X-Penguin-Schema   header    string                         false  "Schema version of the payload; default to 2, which is deprecated in favor of 3 (modelv3.DropMatrix). Version 3 could also be opted in to by accepting application/vnd.penguin.v3+json"  Enums(2, 3)
+// @Param        mode               query     string                         false  "`recent` weights recent reports more heavily, halving the weight of reports every few days, for stages currently open only; `times` and `quantity` are then the weighted counts. Not available for personal drop matrix"  Enums(recent)
+// @Success      200                {object}  modelv2.DropMatrixQueryResult  "Drop Matrix response"
+// @Failure      500                {object}  pgerr.PenguinError             "An unexpected error occurred"
+// @Security     PenguinIDAuth
+// @Router       /PenguinStats/api/v2/result/matrix [GET]
 func (c *Result) GetDropMatrix(ctx *fiber.Ctx) error {
 	server := ctx.Query("server", "CN")
 	if err := rekuest.ValidServer(ctx, server); err != nil {
 		return err
 	}
+	format, err := tabular.Negotiate(ctx)
+	if err != nil {
+		return err
+	}
+	schema, err := schemaver.Negotiate(ctx, schemaver.V2)
+	if err != nil {
+		return err
+	}
 
 	isPersonal, err := strconv.ParseBool(ctx.Query("is_personal", "false"))
 	if err != nil {
@@ -79,6 +123,11 @@ func (c *Result) GetDropMatrix(ctx *fiber.Ctx) error {
 	}
 	stageFilterStr := ctx.Query("stageFilter")
 	itemFilterStr := ctx.Query("itemFilter")
+	fields := fieldset.Parse(ctx)
+	lowSample := ctx.Query("low_sample")
+	if lowSample != "" && lowSample != constant.LowSampleModeFlag && lowSample != constant.LowSampleModeExclude {
+		return pgerr.ErrInvalidReq.Msg("low_sample must be either `flag` or `exclude`")
+	}
 
 	accountId := null.NewInt(0, false)
 	if isPersonal {
@@ -90,43 +139,291 @@ func (c *Result) GetDropMatrix(ctx *fiber.Ctx) error {
 		accountId.Valid = true
 	}
 
-	shimQueryResult, err := c.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx.Context(), server, showClosedZones, stageFilterStr, itemFilterStr, accountId)
-	if err != nil {
-		return err
+	mode := ctx.Query("mode")
+	if mode == constant.DropMatrixModeRecent {
+		if isPersonal {
+			return pgerr.ErrInvalidReq.Msg("mode `recent` is not available for personal drop matrix")
+		}
+		return c.getRecentDropMatrix(ctx, server, format, schema, stageFilterStr, itemFilterStr, fields, lowSample)
+	} else if mode != "" {
+		return pgerr.ErrInvalidReq.Msg("mode must be `recent` if specified")
+	}
+
+	validator := cachectrl.NewValidator(c.CacheVersionService.ETagVersion(ctx.Context(), constant.CacheVersionDropMatrix, server))
+	getShimQueryResult := func() (*modelv2.DropMatrixQueryResult, error) {
+		shimQueryResult, err := c.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx.Context(), server, showClosedZones, stageFilterStr, itemFilterStr, accountId)
+		if err != nil {
+			return nil, err
+		}
+		return c.DropMatrixService.GateLowSample(shimQueryResult, lowSample), nil
 	}
 
 	useCache := !accountId.Valid && stageFilterStr == "" && itemFilterStr == ""
 	if useCache {
 		key := server + constant.CacheSep + strconv.FormatBool(showClosedZones)
+		cacheKey := "[shimMaxAccumulableDropMatrixResults#server|showClosedZoned:" + key + "]"
 		var lastModifiedTime time.Time
-		if err := cache.LastModifiedTime.Get("[shimMaxAccumulableDropMatrixResults#server|showClosedZoned:"+key+"]", &lastModifiedTime); err != nil {
+		if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 			lastModifiedTime = time.Now()
 		}
+		// the default JSON response is served precompressed, so it differs by the encoding negotiated as well
+		precompressed := format == tabular.FormatJSON && fields == nil
+		encoding := ""
+		if precompressed {
+			encoding = precompress.Negotiate(ctx.Get(fiber.HeaderAcceptEncoding), c.PrecompressedService.Encodings())
+			ctx.Vary(fiber.HeaderAcceptEncoding)
+		}
+		// elements gated depend on the low sample threshold and mode at runtime, besides the mode requested
+		lowSampleKey := c.DropMatrixService.LowSampleKey(lowSample)
 		cachectrl.OptIn(ctx, lastModifiedTime)
+		if validator.NotModified(ctx, cacheKey, format, fieldset.Key(fields), lowSampleKey, encoding, strconv.Itoa(schema)) {
+			return ctx.SendStatus(fiber.StatusNotModified)
+		}
+
+		// precompressed bodies are kept by version, so they are skipped while the version cannot be read
+		if version, versioned := validator.Version(); precompressed && versioned {
+			precompressedKey := "dropMatrix#server|showClosedZones|lowSample|schema|version:" + key + constant.CacheSep + lowSampleKey + constant.CacheSep + strconv.Itoa(schema) + constant.CacheSep + version
+			entry, err := c.PrecompressedService.Get(ctx.Context(), precompressedKey, encoding, func() (any, error) {
+				shimQueryResult, err := getShimQueryResult()
+				if err != nil {
+					return nil, err
+				}
+				payload, _ := dropMatrixPayload(schema, server, shimQueryResult)
+				return payload, nil
+			})
+			if err != nil {
+				return err
+			}
+			schemaver.Respond(ctx, schema, c.schemaDeprecation(schema))
+			return precompress.Send(ctx, entry, encoding)
+		}
+	}
+
+	shimQueryResult, err := getShimQueryResult()
+	if err != nil {
+		return err
 	}
 
-	return ctx.JSON(shimQueryResult)
+	if format != tabular.FormatJSON {
+		return tabular.SendDropMatrix(ctx, format, "matrix_"+server, shimQueryResult)
+	}
+	payload, key := dropMatrixPayload(schema, server, shimQueryResult)
+	schemaver.Respond(ctx, schema, c.schemaDeprecation(schema))
+	return fieldset.Send(ctx, payload, key, fields)
 }
 
-// @Summary   Get Pattern Matrix
-// @Tags      Result
-// @Produce   json
-// @Param     server       query     string  true   "Server; default to CN"  Enums(CN, US, JP, KR)
-// @Param     is_personal  query     bool    false  "Whether to query for personal drop matrix or not. If `is_personal` equals to `true`, a valid PenguinID would be required to be provided (PenguinIDAuth)"
-// @Success   200          {object}  modelv2.PatternMatrixQueryResult
-// @Failure   500          {object}  pgerr.PenguinError  "An unexpected error occurred"
-// @Security  PenguinIDAuth
-// @Router    /PenguinStats/api/v2/result/pattern [GET]
+// getRecentDropMatrix responds with the recent drop matrix of server, which is cached separately from, and
+// refreshed on a different schedule than, the max accumulable drop matrix.
+func (c *Result) getRecentDropMatrix(ctx *fiber.Ctx, server string, format string, schema int, stageFilterStr string, itemFilterStr string, fields []string, lowSample string) error {
+	validator := cachectrl.NewValidator(c.CacheVersionService.ETagVersion(ctx.Context(), constant.CacheVersionRecentDropMatrix, server))
+	shimQueryResult, err := c.DropMatrixService.GetShimRecentDropMatrixResults(ctx.Context(), server, stageFilterStr, itemFilterStr)
+	if err != nil {
+		return err
+	}
+	shimQueryResult = c.DropMatrixService.GateLowSample(shimQueryResult, lowSample)
+
+	if stageFilterStr == "" && itemFilterStr == "" {
+		cacheKey := "[recentDropMatrixResults#server:" + server + "]"
+		var lastModifiedTime time.Time
+		if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
+			lastModifiedTime = time.Now()
+		}
+		cachectrl.OptIn(ctx, lastModifiedTime)
+		if validator.NotModified(ctx, cacheKey, format, fieldset.Key(fields), c.DropMatrixService.LowSampleKey(lowSample), strconv.Itoa(schema)) {
+			return ctx.SendStatus(fiber.StatusNotModified)
+		}
+	}
+
+	if format != tabular.FormatJSON {
+		return tabular.SendDropMatrix(ctx, format, "matrix_recent_"+server, shimQueryResult)
+	}
+	payload, key := dropMatrixPayload(schema, server, shimQueryResult)
+	schemaver.Respond(ctx, schema, c.schemaDeprecation(schema))
+	return fieldset.Send(ctx, payload, key, fields)
+}
+
+// dropMatrixPayload returns result, being the drop matrix of server, shaped in schema, along with the member of
+// the payload holding elements of the matrix.
+func dropMatrixPayload(schema int, server string, result *modelv2.DropMatrixQueryResult) (any, string) {
+	if schema == schemaver.V3 {
+		return modelv3.NewDropMatrix(server, result), "elements"
+	}
+	return result, "matrix"
+}
+
+// schemaDeprecation returns the deprecation of schema as configured, or nil if schema is not deprecated.
+func (c *Result) schemaDeprecation(schema int) *schemaver.Deprecation {
+	if schema != schemaver.V2 {
+		return nil
+	}
+	return &schemaver.Deprecation{
+		Since:  c.Config.SchemaV2DeprecatedAt,
+		Sunset: c.Config.SchemaV2SunsetAt,
+		Link:   c.Config.SchemaV2DeprecationURL,
+	}
+}
+
+// @Summary      Get Historical Drop Matrix
+// @Description  Get the drop matrix of a server, including closed zones, as it has been on a past date. Drop matrices are snapshotted daily at 00:00 UTC, and the latest snapshot taken on or before the date is responded.
+// @Tags         Result
+// @Produce      json
+// @Param        server  query     string                           true  "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param        date    query     string                           true  "UTC date in the format of YYYY-MM-DD"  example(2022-05-01)
+// @Success      200     {object}  modelv2.DropMatrixHistoryResult  "Drop matrix of the snapshot"
+// @Failure      400     {object}  pgerr.PenguinError               "`date` is missing or invalid, or in the future"
+// @Failure      404     {object}  pgerr.PenguinError               "No snapshot has been taken on or before the date"
+// @Failure      500     {object}  pgerr.PenguinError               "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/result/matrix/history [GET]
+func (c *Result) GetDropMatrixHistory(ctx *fiber.Ctx) error {
+	server := ctx.Query("server", "CN")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+	date, err := time.Parse(constant.DateLayout, ctx.Query("date"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("`date` is required in the format of YYYY-MM-DD")
+	}
+	if date.After(time.Now().UTC()) {
+		return pgerr.ErrInvalidReq.Msg("`date` shall not be in the future")
+	}
+
+	result, err := c.DropMatrixHistoryService.GetDropMatrixHistory(ctx.Context(), server, date)
+	if err != nil {
+		return err
+	}
+	cachectrl.OptIn(ctx, time.UnixMilli(result.SnapshotAt))
+	return ctx.JSON(result)
+}
+
+// @Summary      Get Drop Stages of an Item
+// @Description  Get all stages an item drops from on a server, with the drop rate and its 95% confidence interval, the number of runs sampled, and the expected sanity spent per item, as they are in the drop matrix.
+// @Tags         Result
+// @Produce      json
+// @Param        itemId             path      string                  true   "Item ID"  example(30012)
+// @Param        server             query     string                  true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param        show_closed_zones  query     bool                    false  "Whether to show closed stages or not"
+// @Param        low_sample         query     string                  false  "How elements sampled too few times to be accurate are treated; default to the server configuration"  Enums(flag, exclude)
+// @Success      200                {object}  modelv2.ItemDropStages  "Stages the item drops from"
+// @Failure      404                {object}  pgerr.PenguinError      "Item not found"
+// @Failure      500                {object}  pgerr.PenguinError      "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/result/item/{itemId} [GET]
+func (c *Result) GetItemDropStages(ctx *fiber.Ctx) error {
+	server := ctx.Query("server", "CN")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+	showClosedZones, err := strconv.ParseBool(ctx.Query("show_closed_zones", "false"))
+	if err != nil {
+		return err
+	}
+	lowSample := ctx.Query("low_sample")
+	if lowSample != "" && lowSample != constant.LowSampleModeFlag && lowSample != constant.LowSampleModeExclude {
+		return pgerr.ErrInvalidReq.Msg("low_sample must be either `flag` or `exclude`")
+	}
+
+	result, err := c.DropMatrixService.GetItemDropStages(ctx.Context(), server, ctx.Params("itemId"), showClosedZones, lowSample)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(result)
+}
+
+// @Summary      Rank Stages by Efficiency for an Item
+// @Description  Rank the stages an item drops from on a server by the expected sanity spent per item dropped, from the most efficient one. Drop rates are of the drop matrix, or of reports within the time range when either `start` or `end` is given. Stages of unknown sanity costs, or sampled fewer times than `min_times`, are left out.
+// @Tags         Result
+// @Produce      json
+// @Param        itemId             path      string                          true   "Item ID"  example(30012)
+// @Param        server             query     string                          true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param        start              query     integer                         false  "Start of the time range, in milliseconds since the epoch; default to the start of the server"
+// @Param        end                query     integer                         false  "End of the time range, in milliseconds since the epoch; default to now"
+// @Param        min_times          query     integer                         false  "Minimum number of times stages shall be sampled; default to the low sample threshold"
+// @Param        limit              query     integer                         false  "Number of stages ranked; default to 10, at most 100"
+// @Param        show_closed_zones  query     bool                            false  "Whether to rank closed stages or not. Ignored when a time range is given"
+// @Success      200                {object}  modelv2.StageEfficiencyRanking  "Stages ranked by efficiency"
+// @Failure      400                {object}  pgerr.PenguinError              "Invalid query"
+// @Failure      404                {object}  pgerr.PenguinError              "Item not found"
+// @Failure      500                {object}  pgerr.PenguinError              "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/result/efficiency/{itemId} [GET]
+func (c *Result) GetStageEfficiency(ctx *fiber.Ctx) error {
+	var query types.StageEfficiencyQuery
+	if err := ctx.QueryParser(&query); err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid query: %s", err)
+	}
+	if query.Server == "" {
+		query.Server = "CN"
+	}
+	if err := rekuest.ValidServer(ctx, query.Server); err != nil {
+		return err
+	}
+	query.ItemID = ctx.Params("itemId")
+	if err := rekuest.ValidStruct(ctx, &query); err != nil {
+		return err
+	}
+	if query.EndTime != 0 && query.EndTime <= query.StartTime {
+		return pgerr.ErrInvalidReq.Msg("`end` must be after `start`")
+	}
+
+	ranking, err := c.EfficiencyService.RankStagesForItem(ctx.Context(), &query)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(ranking)
+}
+
+// @Summary      Compare Drop Rates across Servers
+// @Description  Get the drop matrix element of a stage and an item on every server side by side, with the drop rate and its 95% confidence interval. Servers the item has never been reported to drop from the stage on are left out.
+// @Tags         Result
+// @Produce      json
+// @Param        stageId  query     string                    true  "Stage ID"  example(main_01-07)
+// @Param        itemId   query     string                    true  "Item ID"   example(30012)
+// @Success      200      {object}  modelv2.ServerComparison  "Drop matrix element on each server"
+// @Failure      400      {object}  pgerr.PenguinError        "`stageId` or `itemId` is missing"
+// @Failure      404      {object}  pgerr.PenguinError        "Stage or item not found"
+// @Failure      500      {object}  pgerr.PenguinError        "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/result/compare [GET]
+func (c *Result) CompareServers(ctx *fiber.Ctx) error {
+	stageId := ctx.Query("stageId")
+	itemId := ctx.Query("itemId")
+	if stageId == "" || itemId == "" {
+		return pgerr.ErrInvalidReq.Msg("both `stageId` and `itemId` are required")
+	}
+
+	comparison, err := c.DropMatrixService.CompareServers(ctx.Context(), stageId, itemId)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(comparison)
+}
+
+// @Summary      Get Pattern Matrix
+// @Description  Responds in CSV or TSV, with a row per pattern matrix element, when requested with the `format` query or the `Accept` header.
+// @Tags         Result
+// @Produce      json
+// @Produce      text/csv
+// @Produce      text/tab-separated-values
+// @Param        server       query     string    true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param        is_personal  query     bool      false  "Whether to query for personal drop matrix or not. If `is_personal` equals to `true`, a valid PenguinID would be required to be provided (PenguinIDAuth)"
+// @Param        format       query     string    false  "Response format; default to json, or negotiated by the Accept header"  Enums(json, csv, tsv)
+// @Param        fields       query     []string  false  "Comma separated list of fields to limit each pattern matrix element to, in JSON responses"  collectionFormat(csv)
+// @Success      200          {object}  modelv2.PatternMatrixQueryResult
+// @Failure      500          {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Security     PenguinIDAuth
+// @Router       /PenguinStats/api/v2/result/pattern [GET]
 func (c *Result) GetPatternMatrix(ctx *fiber.Ctx) error {
 	server := ctx.Query("server", "CN")
 	if err := rekuest.ValidServer(ctx, server); err != nil {
 		return err
 	}
+	format, err := tabular.Negotiate(ctx)
+	if err != nil {
+		return err
+	}
 
 	isPersonal, err := strconv.ParseBool(ctx.Query("is_personal", "false"))
 	if err != nil {
 		return err
 	}
+	fields := fieldset.Parse(ctx)
 
 	accountId := null.NewInt(0, false)
 	if isPersonal {
@@ -138,49 +435,149 @@ func (c *Result) GetPatternMatrix(ctx *fiber.Ctx) error {
 		accountId.Valid = true
 	}
 
+	validator := cachectrl.NewValidator(c.CacheVersionService.ETagVersion(ctx.Context(), constant.CacheVersionPatternMatrix, server))
 	shimResult, err := c.PatternMatrixService.GetShimLatestPatternMatrixResults(ctx.Context(), server, accountId)
 	if err != nil {
 		return err
 	}
 
 	if !accountId.Valid {
+		cacheKey := "[shimLatestPatternMatrixResults#server:" + server + "]"
 		var lastModifiedTime time.Time
-		if err := cache.LastModifiedTime.Get("[shimLatestPatternMatrixResults#server:"+server+"]", &lastModifiedTime); err != nil {
+		if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 			lastModifiedTime = time.Now()
 		}
 		cachectrl.OptIn(ctx, lastModifiedTime)
+		if validator.NotModified(ctx, cacheKey, format, fieldset.Key(fields)) {
+			return ctx.SendStatus(fiber.StatusNotModified)
+		}
 	}
 
-	return ctx.JSON(shimResult)
+	if format != tabular.FormatJSON {
+		return tabular.SendPatternMatrix(ctx, format, "pattern_"+server, shimResult)
+	}
+	return fieldset.Send(ctx, shimResult, "pattern_matrix", fields)
 }
 
-// @Summary  Get Trends
-// @Tags     Result
-// @Produce  json
-// @Param    server  query     string  true  "Server; default to CN"  Enums(CN, US, JP, KR)
-// @Success  200     {object}  modelv2.TrendQueryResult
-// @Failure  500     {object}  pgerr.PenguinError  "An unexpected error occurred"
-// @Router   /PenguinStats/api/v2/result/trends [GET]
+// @Summary      Get Trends
+// @Description  Responds in CSV or TSV, with a row per interval of each item of each stage, when requested with the `format` query or the `Accept` header.
+// @Tags         Result
+// @Produce      json
+// @Produce      text/csv
+// @Produce      text/tab-separated-values
+// @Param        server       query     string  true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param        granularity  query     string  false  "Length of each interval of trends; default to day. `hour` returns the last 72 hours, `day` the last 60 game days, and `week` the last 26 weeks."  Enums(hour, day, week)
+// @Param        format       query     string  false  "Response format; default to json, or negotiated by the Accept header"  Enums(json, csv, tsv)
+// @Success      200          {object}  modelv2.TrendQueryResult
+// @Failure      400          {object}  pgerr.PenguinError  "Invalid granularity"
+// @Failure      500          {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Router       /PenguinStats/api/v2/result/trends [GET]
 func (c *Result) GetTrends(ctx *fiber.Ctx) error {
 	server := ctx.Query("server", "CN")
 	if err := rekuest.ValidServer(ctx, server); err != nil {
 		return err
 	}
+	granularity := ctx.Query("granularity", constant.TrendGranularityDay)
+	intervalLength, err := service.TrendGranularityLength(granularity)
+	if err != nil {
+		return err
+	}
+	format, err := tabular.Negotiate(ctx)
+	if err != nil {
+		return err
+	}
 
-	shimResult, err := c.TrendService.GetShimSavedTrendResults(ctx.Context(), server)
+	validator := cachectrl.NewValidator(c.CacheVersionService.ETagVersion(ctx.Context(), constant.CacheVersionTrend, service.ShimSavedTrendResultsKey(server, granularity)))
+	shimResult, err := c.TrendService.GetShimSavedTrendResults(ctx.Context(), server, granularity)
 	if err != nil {
 		return err
 	}
 
+	cacheKey := "[shimSavedTrendResults#server|granularity:" + service.ShimSavedTrendResultsKey(server, granularity) + "]"
 	var lastModifiedTime time.Time
-	if err := cache.LastModifiedTime.Get("[shimSavedTrendResults#server:"+server+"]", &lastModifiedTime); err != nil {
+	if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 		lastModifiedTime = time.Now()
 	}
 	cachectrl.OptIn(ctx, lastModifiedTime)
+	if validator.NotModified(ctx, cacheKey, format) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
 
+	if format != tabular.FormatJSON {
+		return tabular.SendTrend(ctx, format, "trends_"+server+"_"+granularity, shimResult, intervalLength)
+	}
 	return ctx.JSON(shimResult)
 }
 
+// @Summary   Get Personal Drop History
+// @Tags      Result
+// @Produce   json
+// @Param     server    query     string  true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param     interval  query     string  false  "Bucket interval; default to day. `day` returns the last 30 game days, while `week` returns the last 12 weeks. Buckets without any report are omitted."  Enums(day, week)
+// @Success   200       {object}  modelv2.PersonalHistoryQueryResult
+// @Failure   400       {object}  pgerr.PenguinError  "Invalid interval"
+// @Failure   500       {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/result/personal/history [GET]
+func (c *Result) GetPersonalHistory(ctx *fiber.Ctx) error {
+	server := ctx.Query("server", "CN")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+
+	interval := ctx.Query("interval", constant.PersonalHistoryIntervalDay)
+	if interval != constant.PersonalHistoryIntervalDay && interval != constant.PersonalHistoryIntervalWeek {
+		return pgerr.ErrInvalidReq.Msg("interval must be either `day` or `week`")
+	}
+
+	account, err := c.AccountService.GetAccountFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	result, err := c.PersonalHistoryService.GetPersonalHistory(ctx.Context(), server, account.AccountID, interval)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(result)
+}
+
+// @Summary   Get Personal Drop Reports
+// @Tags      Result
+// @Produce   json
+// @Param     server  query     string  true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param     cursor  query     string  false  "Opaque cursor of the page, as `nextCursor` of the previous page; default to the first page"
+// @Param     limit   query     int     false  "Maximum number of reports in the page; default to 50, and at most 100"
+// @Success   200     {object}  model.DropReportPage
+// @Failure   400     {object}  pgerr.PenguinError  "Invalid cursor or limit"
+// @Failure   500     {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/result/personal/reports [GET]
+func (c *Result) GetPersonalReports(ctx *fiber.Ctx) error {
+	server := ctx.Query("server", "CN")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+
+	cur, limit, err := rekuest.Page(ctx, 50, 100)
+	if err != nil {
+		return err
+	}
+
+	account, err := c.AccountService.GetAccountFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	page, err := c.DropReportService.ListPersonalDropReports(ctx.Context(), server, account.AccountID, cur, limit)
+	if err != nil {
+		return err
+	}
+
+	return ctx.JSON(page)
+}
+
 // @Summary  Execute Advanced Query
 // @Tags     Result
 // @Produce  json
@@ -259,7 +656,11 @@ func (c *Result) handleAdvancedQuery(ctx *fiber.Ctx, query *types.AdvancedQuery)
 			StartTime: &startTime,
 			EndTime:   &endTime,
 		}
-		return c.DropMatrixService.GetShimCustomizedDropMatrixResults(ctx.Context(), query.Server, timeRange, []int{stage.StageID}, itemIds, accountId)
+		result, err := c.DropMatrixService.GetShimCustomizedDropMatrixResults(ctx.Context(), query.Server, timeRange, []int{stage.StageID}, itemIds, accountId)
+		if err != nil {
+			return nil, err
+		}
+		return c.DropMatrixService.GateLowSample(result, ""), nil
 	} else {
 		// interval originally is in milliseconds, so we need to convert it to nanoseconds
 		intervalLength := time.Duration(query.Interval.Int64 * 1e6).Round(time.Hour)
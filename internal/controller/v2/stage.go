@@ -11,6 +11,8 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/fieldset"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
 )
 
 var _ modelv2.Stage
@@ -29,28 +31,44 @@ func RegisterStage(v2 *svr.V2, c Stage) {
 // @Summary  Get All Stages
 // @Tags     Stage
 // @Produce  json
+// @Param    lang    query     string    false  "Only include names in this language, or negotiate it by Accept-Language with `auto`"  Enums(zh, en, ja, ko, auto)
+// @Param    fields  query     []string  false  "Comma separated list of fields to limit each record to"  collectionFormat(csv)
 // @Success  200  {array}   modelv2.Stage{existence=model.Existence,code_i18n=model.I18nString}
 // @Failure  500  {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router   /PenguinStats/api/v2/stages [GET]
 func (c *Stage) GetStages(ctx *fiber.Ctx) error {
 	server := ctx.Query("server", "CN")
+	lang, err := rekuest.NameLanguage(ctx)
+	if err != nil {
+		return err
+	}
+	fields := fieldset.Parse(ctx)
 
 	stages, err := c.StageService.GetShimStages(ctx.Context(), server)
 	if err != nil {
 		return err
 	}
+	cacheKey := "[shimStages#server:" + server + "]"
 	var lastModifiedTime time.Time
-	if err := cache.LastModifiedTime.Get("[shimStages#server:"+server+"]", &lastModifiedTime); err != nil {
+	if err := cache.LastModifiedTime.Get(cacheKey, &lastModifiedTime); err != nil {
 		lastModifiedTime = time.Now()
 	}
 	cachectrl.OptIn(ctx, lastModifiedTime)
-	return ctx.JSON(stages)
+	if cachectrl.NotModified(ctx, cachectrl.ETag(cacheKey, lang, fieldset.Key(fields), lastModifiedTime.Format(time.RFC3339Nano))) {
+		return ctx.SendStatus(fiber.StatusNotModified)
+	}
+	if lang != "" {
+		stages = stagesWithNames(stages, lang)
+	}
+	return fieldset.Send(ctx, stages, "", fields)
 }
 
 // @Summary  Get a Stage with ID
 // @Tags     Stage
 // @Produce  json
-// @Param    stageId  path      int  true  "Stage ID"
+// @Param    stageId  path      int       true   "Stage ID"
+// @Param    lang     query     string    false  "Only include names in this language, or negotiate it by Accept-Language with `auto`"  Enums(zh, en, ja, ko, auto)
+// @Param    fields   query     []string  false  "Comma separated list of fields to limit each record to"  collectionFormat(csv)
 // @Success  200      {object}  modelv2.Stage{existence=model.Existence,code_i18n=model.I18nString}
 // @Failure  400      {object}  pgerr.PenguinError  "Invalid or missing stageId. Notice that this shall be the **string ID** of the stage, instead of the internally used numerical ID of the stage."
 // @Failure  500      {object}  pgerr.PenguinError  "An unexpected error occurred"
@@ -58,10 +76,17 @@ func (c *Stage) GetStages(ctx *fiber.Ctx) error {
 func (c *Stage) GetStageByArkId(ctx *fiber.Ctx) error {
 	stageId := ctx.Params("stageId")
 	server := ctx.Query("server", "CN")
+	lang, err := rekuest.NameLanguage(ctx)
+	if err != nil {
+		return err
+	}
 
 	stage, err := c.StageService.GetShimStageByArkId(ctx.Context(), stageId, server)
 	if err != nil {
 		return err
 	}
-	return ctx.JSON(stage)
+	if lang != "" {
+		stage = stageWithNames(stage, lang)
+	}
+	return fieldset.Send(ctx, stage, "", fieldset.Parse(ctx))
 }
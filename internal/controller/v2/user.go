@@ -2,25 +2,45 @@ package v2
 
 import (
 	"encoding/json"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/fx"
 
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
 	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
+	"github.com/penguin-statistics/backend-next/internal/pkg/middlewares"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgid"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
 )
 
 type Account struct {
 	fx.In
 
-	AccountService *service.Account
+	AccountService      *service.Account
+	AccountOAuthService *service.AccountOAuth
+	APIKeyService       *service.APIKey
 }
 
 func RegisterAccount(v2 *svr.V2, c Account) {
 	v2.Post("/users", c.Login)
+
+	// a leaked api key must not be escalated into managing the account it belongs to
+	refuseAPIKey := middlewares.RefuseAPIKey()
+	v2.Get("/users/oauth/:provider/authorize", refuseAPIKey, c.GetOAuthAuthorizeURL)
+	v2.Post("/users/oauth/callback", refuseAPIKey, c.HandleOAuthCallback)
+	v2.Get("/users/identities", refuseAPIKey, c.GetIdentities)
+	v2.Delete("/users/identities/:provider", refuseAPIKey, c.DetachIdentity)
+	v2.Get("/users/apikeys", refuseAPIKey, c.GetAPIKeys)
+	v2.Post("/users/apikeys", refuseAPIKey, c.CreateAPIKey)
+	v2.Delete("/users/apikeys/:id", refuseAPIKey, c.RevokeAPIKey)
 }
 
 // @Summary   Login with PenguinID
@@ -56,3 +76,211 @@ func (c *Account) Login(ctx *fiber.Ctx) error {
 
 	return ctx.Send(resp)
 }
+
+// @Summary   Get OAuth Authorize URL
+// @Tags      Account
+// @Produce   json
+// @Param     provider  path      string                        true  "Identity provider"  Enums(github, google)
+// @Success   200       {object}  types.OAuthAuthorizeResponse  "URL of the identity provider to redirect to. When a valid PenguinID is provided, the identity authorized would be attached to it; otherwise the identity is logged in with. The state of the authorization is bound to the browser with a cookie, which the callback has to be completed with."
+// @Failure   400       {object}  pgerr.PenguinError            "Identity provider not supported"
+// @Failure   401       {object}  pgerr.PenguinError            "Authenticated with an API key, which could not manage the account"
+// @Failure   500       {object}  pgerr.PenguinError            "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/users/oauth/{provider}/authorize [GET]
+func (c *Account) GetOAuthAuthorizeURL(ctx *fiber.Ctx) error {
+	// PenguinID is optional here: without it the identity is logged in with instead of attached
+	var account *model.Account
+	if pgid.Extract(ctx) != "" {
+		var err error
+		if account, err = c.AccountService.GetAccountFromRequest(ctx); err != nil {
+			return err
+		}
+	}
+
+	url, state, err := c.AccountOAuthService.GetAuthorizeURL(ctx.Context(), ctx.Params("provider"), account)
+	if err != nil {
+		return err
+	}
+
+	setOAuthStateCookie(ctx, state, constant.OAuthStateTTLSec)
+	cachectrl.OptOut(ctx)
+
+	return ctx.JSON(&types.OAuthAuthorizeResponse{URL: url})
+}
+
+// @Summary   Complete OAuth Login
+// @Tags      Account
+// @Accept    json
+// @Produce   json
+// @Param     request  body      types.OAuthCallbackRequest  true  "Code and state the identity provider redirected back with"
+// @Success   200      {object}  modelv2.LoginResponse       "PenguinID the identity is attached to. The PenguinID is also set in the cookie and in the `X-Penguin-Set-PenguinID` header, as with the login API."
+// @Failure   400      {object}  pgerr.PenguinError          "State is invalid or expired, or not bound to this browser by the authorize API; or the identity has been attached to another PenguinID"
+// @Failure   401      {object}  pgerr.PenguinError          "Authenticated with an API key, which could not manage the account"
+// @Failure   500      {object}  pgerr.PenguinError          "An unexpected error occurred"
+// @Router    /PenguinStats/api/v2/users/oauth/callback [POST]
+func (c *Account) HandleOAuthCallback(ctx *fiber.Ctx) error {
+	var request types.OAuthCallbackRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	account, err := c.AccountOAuthService.HandleCallback(ctx.Context(), request.Code, request.State, ctx.Cookies(constant.OAuthStateCookieKey))
+	if err != nil {
+		return err
+	}
+
+	// the state has been consumed
+	setOAuthStateCookie(ctx, "", -1)
+	pgid.Inject(ctx, account.PenguinID)
+	cachectrl.OptOut(ctx)
+
+	return ctx.JSON(&modelv2.LoginResponse{
+		UserID: account.PenguinID,
+	})
+}
+
+// setOAuthStateCookie binds the state of the pending OAuth authorization to the browser for maxAge seconds, or
+// unbinds it when maxAge is negative. The cookie is sent along with cross-site requests the same way as PenguinID
+// cookies, as the frontend could be hosted on another site.
+func setOAuthStateCookie(ctx *fiber.Ctx, state string, maxAge int) {
+	ctx.Cookie(&fiber.Cookie{
+		Name:     constant.OAuthStateCookieKey,
+		Value:    state,
+		MaxAge:   maxAge,
+		Path:     "/",
+		Expires:  time.Now().Add(time.Second * time.Duration(maxAge)),
+		Domain:   "." + ctx.Get("Host", constant.SiteDefaultHost),
+		SameSite: "None",
+		Secure:   true,
+		HTTPOnly: true,
+	})
+}
+
+// @Summary   Get Attached Identities
+// @Tags      Account
+// @Produce   json
+// @Success   200  {array}   model.AccountIdentity
+// @Failure   401  {object}  pgerr.PenguinError  "Authenticated with an API key, which could not manage the account"
+// @Failure   500  {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/users/identities [GET]
+func (c *Account) GetIdentities(ctx *fiber.Ctx) error {
+	account, err := c.AccountService.GetAccountFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	identities, err := c.AccountOAuthService.GetIdentities(ctx.Context(), account.AccountID)
+	if err != nil {
+		return err
+	}
+
+	cachectrl.OptOut(ctx)
+
+	return ctx.JSON(identities)
+}
+
+// @Summary   Detach Identity
+// @Tags      Account
+// @Param     provider  path  string  true  "Identity provider"  Enums(github, google)
+// @Success   204
+// @Failure   404  {object}  pgerr.PenguinError  "No identity of the provider is attached"
+// @Failure   401  {object}  pgerr.PenguinError  "Authenticated with an API key, which could not manage the account"
+// @Failure   500  {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/users/identities/{provider} [DELETE]
+func (c *Account) DetachIdentity(ctx *fiber.Ctx) error {
+	account, err := c.AccountService.GetAccountFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.AccountOAuthService.DetachIdentity(ctx.Context(), account.AccountID, ctx.Params("provider")); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
+
+// @Summary   Get API Keys
+// @Tags      Account
+// @Produce   json
+// @Success   200  {array}   model.APIKey
+// @Failure   401  {object}  pgerr.PenguinError  "Authenticated with an API key, which could not manage the account"
+// @Failure   500  {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/users/apikeys [GET]
+func (c *Account) GetAPIKeys(ctx *fiber.Ctx) error {
+	account, err := c.AccountService.GetAccountFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := c.APIKeyService.GetAPIKeys(ctx.Context(), account.AccountID)
+	if err != nil {
+		return err
+	}
+
+	cachectrl.OptOut(ctx)
+
+	return ctx.JSON(keys)
+}
+
+// @Summary   Create API Key
+// @Tags      Account
+// @Accept    json
+// @Produce   json
+// @Param     request  body      types.APIKeyCreateRequest  true  "API key to create"
+// @Success   200      {object}  model.CreatedAPIKey        "API key created. The key itself is only returned here, and shall be sent as `Authorization: Bearer <key>` by automated reporters."
+// @Failure   400      {object}  pgerr.PenguinError         "Maximum number of API keys reached"
+// @Failure   401      {object}  pgerr.PenguinError         "Authenticated with an API key, which could not manage the account"
+// @Failure   500      {object}  pgerr.PenguinError         "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/users/apikeys [POST]
+func (c *Account) CreateAPIKey(ctx *fiber.Ctx) error {
+	var request types.APIKeyCreateRequest
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	account, err := c.AccountService.GetAccountFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	key, plain, err := c.APIKeyService.CreateAPIKey(ctx.Context(), account.AccountID, request.Name)
+	if err != nil {
+		return err
+	}
+
+	cachectrl.OptOut(ctx)
+
+	return ctx.JSON(&model.CreatedAPIKey{APIKey: key, Key: plain})
+}
+
+// @Summary   Revoke API Key
+// @Tags      Account
+// @Param     id  path  int  true  "API key ID"
+// @Success   204
+// @Failure   400  {object}  pgerr.PenguinError  "API key not found"
+// @Failure   401  {object}  pgerr.PenguinError  "Authenticated with an API key, which could not manage the account"
+// @Failure   500  {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Security  PenguinIDAuth
+// @Router    /PenguinStats/api/v2/users/apikeys/{id} [DELETE]
+func (c *Account) RevokeAPIKey(ctx *fiber.Ctx) error {
+	keyId, err := strconv.Atoi(ctx.Params("id"))
+	if err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid api key id")
+	}
+
+	account, err := c.AccountService.GetAccountFromRequest(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := c.APIKeyService.RevokeAPIKey(ctx.Context(), account.AccountID, keyId); err != nil {
+		return err
+	}
+
+	return ctx.SendStatus(fiber.StatusNoContent)
+}
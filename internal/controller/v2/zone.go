@@ -11,6 +11,7 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/pkg/cachectrl"
 	"github.com/penguin-statistics/backend-next/internal/server/svr"
 	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
 )
 
 var _ modelv2.Dummy
@@ -29,10 +30,16 @@ func RegisterZone(v2 *svr.V2, c Zone) {
 // @Summary  Get All Zones
 // @Tags     Zone
 // @Produce  json
+// @Param    lang  query     string  false  "Only include names in this language, or negotiate it by Accept-Language with `auto`"  Enums(zh, en, ja, ko, auto)
 // @Success  200  {array}   modelv2.Zone{existence=model.Existence,zoneName_i18n=model.I18nString}
 // @Failure  500  {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router   /PenguinStats/api/v2/zones [GET]
 func (c *Zone) GetZones(ctx *fiber.Ctx) error {
+	lang, err := rekuest.NameLanguage(ctx)
+	if err != nil {
+		return err
+	}
+
 	zones, err := c.ZoneService.GetShimZones(ctx.Context())
 	if err != nil {
 		return err
@@ -42,6 +49,9 @@ func (c *Zone) GetZones(ctx *fiber.Ctx) error {
 		lastModifiedTime = time.Now()
 	}
 	cachectrl.OptIn(ctx, lastModifiedTime)
+	if lang != "" {
+		return ctx.JSON(zonesWithNames(zones, lang))
+	}
 	return ctx.JSON(zones)
 }
 
@@ -49,16 +59,24 @@ func (c *Zone) GetZones(ctx *fiber.Ctx) error {
 // @Tags     Zone
 // @Produce  json
 // @Param    zoneId  path      int  true  "Zone ID"
+// @Param    lang    query     string  false  "Only include names in this language, or negotiate it by Accept-Language with `auto`"  Enums(zh, en, ja, ko, auto)
 // @Success  200     {object}  modelv2.Zone{existence=model.Existence,zoneName_i18n=model.I18nString}
 // @Failure  400     {object}  pgerr.PenguinError  "Invalid or missing zoneId. Notice that this shall be the **string ID** of the zone, instead of the v3 API internally used numerical ID of the zone."
 // @Failure  500     {object}  pgerr.PenguinError  "An unexpected error occurred"
 // @Router   /PenguinStats/api/v2/zones/{zoneId} [GET]
 func (c *Zone) GetZoneByArkId(ctx *fiber.Ctx) error {
 	zoneId := ctx.Params("zoneId")
+	lang, err := rekuest.NameLanguage(ctx)
+	if err != nil {
+		return err
+	}
 
 	zone, err := c.ZoneService.GetShimZoneByArkId(ctx.Context(), zoneId)
 	if err != nil {
 		return err
 	}
+	if lang != "" {
+		return ctx.JSON(zoneWithNames(zone, lang))
+	}
 	return ctx.JSON(zone)
 }
@@ -0,0 +1,127 @@
+package v3
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"go.uber.org/fx"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	modelv3 "github.com/penguin-statistics/backend-next/internal/model/v3"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/pkg/schemaver"
+	"github.com/penguin-statistics/backend-next/internal/server/svr"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+)
+
+type Result struct {
+	fx.In
+
+	AccountService       *service.Account
+	AdvancedQueryService *service.AdvancedQuery
+	DropMatrixService    *service.DropMatrix
+	FeatureFlagService   *service.FeatureFlag
+}
+
+func RegisterResult(v3 *svr.V3, c Result) {
+	v3.Get("/result/matrix", c.GetDropMatrix)
+	v3.Post("/result/advanced", limiter.New(limiter.Config{
+		LimitReached: func(c *fiber.Ctx) error {
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"code":    "TOO_MANY_REQUESTS",
+				"message": "Your client is sending requests too frequently. The Penguin Stats advanced query API is limited to 30 requests per 5 minutes.",
+			})
+		},
+		Max:        30,
+		Expiration: time.Minute * 5,
+	}), c.AdvancedQuery)
+}
+
+// @Summary      Get Drop Matrix
+// @Description  Get the global drop matrix of a server in the v3 schema of result payloads, which is the same as requesting the v2 endpoint with the `X-Penguin-Schema: 3` header. As with every v3 endpoint, the request has to accept `application/vnd.penguin.v3+json` to opt in to the alpha version of the API.
+// @Tags         Result
+// @Produce      application/vnd.penguin.v3+json
+// @Param        server             query     string              true   "Server; default to CN"  Enums(CN, US, JP, KR)
+// @Param        show_closed_zones  query     bool                false  "Whether to show closed stages or not"
+// @Param        low_sample         query     string              false  "How elements sampled too few times to be accurate are treated; default to the server configuration"  Enums(flag, exclude)
+// @Success      200                {object}  modelv3.DropMatrix  "Drop matrix of the server"
+// @Failure      400                {object}  pgerr.PenguinError  "Invalid request, or the request does not accept application/vnd.penguin.v3+json"
+// @Failure      500                {object}  pgerr.PenguinError  "An unexpected error occurred"
+// @Router       /api/v3-alpha/result/matrix [GET]
+func (c *Result) GetDropMatrix(ctx *fiber.Ctx) error {
+	server := ctx.Query("server", "CN")
+	if err := rekuest.ValidServer(ctx, server); err != nil {
+		return err
+	}
+	showClosedZones, err := strconv.ParseBool(ctx.Query("show_closed_zones", "false"))
+	if err != nil {
+		return err
+	}
+	lowSample := ctx.Query("low_sample")
+	if lowSample != "" && lowSample != constant.LowSampleModeFlag && lowSample != constant.LowSampleModeExclude {
+		return pgerr.ErrInvalidReq.Msg("low_sample must be either `flag` or `exclude`")
+	}
+
+	result, err := c.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx.Context(), server, showClosedZones, "", "", null.NewInt(0, false))
+	if err != nil {
+		return err
+	}
+	result = c.DropMatrixService.GateLowSample(result, lowSample)
+
+	schemaver.Respond(ctx, schemaver.V3, nil)
+	return ctx.JSON(modelv3.NewDropMatrix(server, result))
+}
+
+// @Summary      Execute Advanced Queries in Batch
+// @Description  Execute a batch of advanced queries in one request. Each query covers a set of stages, optionally limited to a set of items, on one or more servers, within an optional time range; with `interval`, the results are bucketed into a trend of intervals of that length instead of a drop matrix. Queries on the same server, time range, interval and source are calculated together, hence batching queries is much cheaper than requesting them one by one. A result is returned per query per server, in the order of the queries and of their `servers`. As with every v3 endpoint, the request has to accept `application/vnd.penguin.v3+json` to opt in to the alpha version of the API.
+// @Tags         Result
+// @Accept       json
+// @Produce      application/vnd.penguin.v3+json
+// @Param        query  body      types.AdvancedQueryV3Request  true  "Queries"
+// @Success      200    {object}  modelv3.AdvancedQueryResult   "Results of the queries"
+// @Failure      400    {object}  pgerr.PenguinError            "Invalid request, or the request does not accept application/vnd.penguin.v3+json"
+// @Failure      404    {object}  pgerr.PenguinError            "Stage or item not found, or the advanced query is not available on a server queried"
+// @Failure      429    {object}  pgerr.PenguinError            "Too many requests"
+// @Failure      500    {object}  pgerr.PenguinError            "An unexpected error occurred"
+// @Security     PenguinIDAuth
+// @Router       /api/v3-alpha/result/advanced [POST]
+func (c *Result) AdvancedQuery(ctx *fiber.Ctx) error {
+	var request types.AdvancedQueryV3Request
+	if err := rekuest.ValidBody(ctx, &request); err != nil {
+		return err
+	}
+
+	for _, query := range request.Queries {
+		for _, server := range query.Servers {
+			if !c.FeatureFlagService.EnabledForRequest(ctx, constant.FeatureFlagV3AdvancedQuery, server, true) {
+				return service.ErrFeatureDisabled
+			}
+		}
+	}
+
+	// personal queries are answered with the account of the request, which is only required if any query is personal
+	accountId := null.NewInt(0, false)
+	for _, query := range request.Queries {
+		if !query.IsPersonal {
+			continue
+		}
+		account, err := c.AccountService.GetAccountFromRequest(ctx)
+		if err != nil {
+			return err
+		}
+		accountId = null.IntFrom(int64(account.AccountID))
+		break
+	}
+
+	var result *modelv3.AdvancedQueryResult
+	result, err := c.AdvancedQueryService.Execute(ctx.Context(), request.Queries, accountId)
+	if err != nil {
+		return err
+	}
+	return ctx.JSON(result)
+}
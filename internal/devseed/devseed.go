@@ -0,0 +1,60 @@
+// Package devseed holds the fixture set loaded by `--dev-seed`, so that the service could be run locally with the
+// full report pipeline, without dumps of the production database.
+//
+// The fixtures are a zone of stages along with items dropped from them, which are loaded into every server with a
+// time range covering all time, and a handful of reports of those stages, which are queued on every server the
+// fixtures are newly loaded into.
+package devseed
+
+import (
+	_ "embed"
+	"encoding/json"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+//go:embed fixtures.json
+var fixturesJSON []byte
+
+// FixtureSet is the fixture set loaded by `--dev-seed`.
+type FixtureSet struct {
+	Items   []*model.Item    `json:"items"`
+	Zone    *model.Zone      `json:"zone"`
+	Stages  []*FixtureStage  `json:"stages"`
+	Reports []*FixtureReport `json:"reports"`
+}
+
+// FixtureStage is a stage of FixtureSet.Zone, along with its drop infos.
+type FixtureStage struct {
+	*model.Stage
+
+	DropInfos []*FixtureDropInfo `json:"dropInfos"`
+}
+
+// FixtureDropInfo is a drop info of a stage, referring to its item by the ark item id, as penguin item ids are only
+// assigned once items are created. Items are omitted for drop infos bounding the kinds of items of a drop type.
+type FixtureDropInfo struct {
+	DropType    string        `json:"dropType"`
+	ArkItemID   string        `json:"itemId,omitempty"`
+	Accumulable bool          `json:"accumulable"`
+	Bounds      *model.Bounds `json:"bounds"`
+}
+
+// FixtureReport is a report of a stage, queued on every server.
+type FixtureReport struct {
+	types.FragmentStageID
+
+	Drops []types.ArkDrop `json:"drops"`
+	Times int             `json:"times,omitempty"`
+}
+
+// Load returns the fixture set. Every call returns a new copy, so that it could be modified freely, e.g. when ids are
+// assigned to fixtures created.
+func Load() (*FixtureSet, error) {
+	var fixtures FixtureSet
+	if err := json.Unmarshal(fixturesJSON, &fixtures); err != nil {
+		return nil, err
+	}
+	return &fixtures, nil
+}
@@ -7,6 +7,12 @@ func Module() fx.Option {
 		NATS,
 		Redis,
 		Postgres,
+		PostgresRouter,
 		GeoIPDatabase,
+		ObjectStore,
+		ReportSpool,
+		WorkerElector,
+		Locker,
+		FeatureFlags,
 	))
 }
@@ -0,0 +1,13 @@
+package infra
+
+import (
+	"github.com/go-redis/redis/v8"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
+)
+
+// Locker returns the locker of distributed locks shared by all instances.
+func Locker(conf *config.Config, client *redis.Client) *dlock.Locker {
+	return dlock.New(client, conf.DistributedLockTTL)
+}
@@ -0,0 +1,12 @@
+package infra
+
+import (
+	"github.com/go-redis/redis/v8"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/featureflag"
+)
+
+// FeatureFlags returns the store of feature flags shared by all instances.
+func FeatureFlags(client *redis.Client) *featureflag.Store {
+	return featureflag.New(client)
+}
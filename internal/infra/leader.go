@@ -0,0 +1,46 @@
+package infra
+
+import (
+	"context"
+
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/leader"
+)
+
+// WorkerElector returns the elector of the instance running the calculator and recent matrix workers, or nil, which
+// is always the leader, when workers are disabled or leader election is not configured.
+func WorkerElector(conf *config.Config, client *redis.Client, lc fx.Lifecycle) *leader.Elector {
+	if !conf.WorkerEnabled || !conf.WorkerLeaderElection {
+		return nil
+	}
+
+	elector := leader.New(client, constant.WorkerLeaderElectionCalc,
+		constant.WorkerLeaderKeyPrefix+constant.WorkerLeaderElectionCalc, conf.WorkerLeaderLeaseTTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(startCtx context.Context) error {
+			// campaign right away, so that jobs run as soon as the application starts know whether to run
+			elector.Campaign(startCtx)
+			go func() {
+				defer close(done)
+				elector.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+	return elector
+}
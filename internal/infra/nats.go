@@ -1,15 +1,18 @@
 package infra
 
 import (
+	"context"
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
 
 	"github.com/penguin-statistics/backend-next/internal/config"
 )
 
-func NATS(conf *config.Config) (*nats.Conn, nats.JetStreamContext, error) {
+func NATS(conf *config.Config, lc fx.Lifecycle) (*nats.Conn, nats.JetStreamContext, error) {
 	nc, err := nats.Connect(conf.NatsURL, nats.PingInterval(time.Second*20))
 	if err != nil {
 		return nil, nil, err
@@ -20,23 +23,60 @@ func NATS(conf *config.Config) (*nats.Conn, nats.JetStreamContext, error) {
 		return nil, nil, err
 	}
 
-	_, err = js.AddStream(&nats.StreamConfig{
-		Name: "penguin-reports",
-		Subjects: []string{
-			"REPORT.*",
+	// MaxAckPending should equal to (worker count * worker channel buffer size)
+
+	if err := EnsureStreams(js); err != nil {
+		log.Warn().Err(err).Msg("failed to create jetstream streams")
+	}
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return closeWithDeadline(ctx, "NATS connection", func() error {
+				// publishes and acks still buffered are sent before the connection is closed
+				err := nc.FlushTimeout(closeTimeout)
+				nc.Close()
+				return err
+			})
 		},
-		Retention:  nats.WorkQueuePolicy,
-		Discard:    nats.DiscardOld,
-		Storage:    nats.FileStorage,
-		Replicas:   1,
-		Duplicates: time.Minute * 10,
 	})
 
-	// MaxAckPending should equal to (worker count * worker channel buffer size)
+	return nc, js, nil
+}
 
-	if err != nil {
-		log.Warn().Err(err).Msg("failed to create jetstream stream: is it already created?")
+// ReportStream is the JetStream stream report tasks, including those dead-lettered, are queued in.
+const ReportStream = "penguin-reports"
+
+func streamConfigs() []*nats.StreamConfig {
+	return []*nats.StreamConfig{
+		{
+			Name: ReportStream,
+			Subjects: []string{
+				"REPORT.*",
+			},
+			Retention:  nats.WorkQueuePolicy,
+			Discard:    nats.DiscardOld,
+			Storage:    nats.FileStorage,
+			Replicas:   1,
+			Duplicates: time.Minute * 10,
+		},
 	}
+}
 
-	return nc, js, nil
+// EnsureStreams creates JetStream streams the service relies on, unless they already exist. Streams already
+// existing are left as they are.
+func EnsureStreams(js nats.JetStreamContext) error {
+	for _, streamConfig := range streamConfigs() {
+		_, err := js.StreamInfo(streamConfig.Name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, nats.ErrStreamNotFound) {
+			return errors.Wrapf(err, "failed to get jetstream stream %s", streamConfig.Name)
+		}
+		if _, err := js.AddStream(streamConfig); err != nil {
+			return errors.Wrapf(err, "failed to create jetstream stream %s", streamConfig.Name)
+		}
+		log.Info().Str("stream", streamConfig.Name).Msg("jetstream stream created")
+	}
+	return nil
 }
@@ -0,0 +1,20 @@
+package infra
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/pkg/objstore"
+)
+
+// ObjectStore returns the client of the object storage dataset snapshots are uploaded to, or nil when it is not
+// configured.
+func ObjectStore(conf *config.Config) (*objstore.Client, error) {
+	if conf.SnapshotS3Endpoint == "" || conf.SnapshotS3Bucket == "" {
+		log.Info().Msg("object storage is not configured: dataset snapshots are disabled")
+		return nil, nil
+	}
+
+	return objstore.New(conf.SnapshotS3Endpoint, conf.SnapshotS3Region, conf.SnapshotS3Bucket,
+		conf.SnapshotS3AccessKeyID, conf.SnapshotS3SecretAccessKey)
+}
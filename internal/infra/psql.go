@@ -11,20 +11,22 @@ import (
 	"github.com/uptrace/bun/driver/pgdriver"
 	"github.com/uptrace/bun/extra/bundebug"
 	"github.com/uptrace/bun/extra/bunotel"
+	"go.uber.org/fx"
 
 	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbguard"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbrouter"
 )
 
-func Postgres(conf *config.Config) (*bun.DB, error) {
-	// Open a Postgres database.
-	pgdb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(conf.PostgresDSN), pgdriver.WithApplicationName("penguin-backend")))
+const (
+	// postgresReplicaCheckInterval is the interval in-between health checks of the read replica
+	postgresReplicaCheckInterval = time.Second * 5
+	// postgresReplicaCheckTimeout is the timeout of a single health check of the read replica
+	postgresReplicaCheckTimeout = time.Second * 2
+)
 
-	// Create a Bun db on top of it.
-	db := bun.NewDB(pgdb, pgdialect.New())
-	if conf.DevMode {
-		db.AddQueryHook(bundebug.NewQueryHook(bundebug.WithEnabled(true), bundebug.WithVerbose(conf.BunDebugVerbose)))
-		db.AddQueryHook(bunotel.NewQueryHook(bunotel.WithDBName("penguin_structured")))
-	}
+func Postgres(conf *config.Config, lc fx.Lifecycle) (*bun.DB, error) {
+	db := openPostgres(conf, conf.PostgresDSN, "penguin_structured")
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
 	defer cancel()
@@ -33,10 +35,62 @@ func Postgres(conf *config.Config) (*bun.DB, error) {
 		return nil, err
 	}
 
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return closeWithDeadline(ctx, "Postgres", db.Close)
+		},
+	})
+
+	return db, nil
+}
+
+// PostgresRouter routes read-only queries to the read replica at config.Config.PostgresReplicaDSN while it is
+// available. Without a replica configured, all queries are routed to db. Unlike the primary, an unavailable replica
+// does not fail the startup.
+func PostgresRouter(conf *config.Config, db *bun.DB, lc fx.Lifecycle) *dbrouter.Router {
+	if conf.PostgresReplicaDSN == "" {
+		return dbrouter.New(db, nil)
+	}
+
+	replica := openPostgres(conf, conf.PostgresReplicaDSN, "penguin_structured_replica")
+	router := dbrouter.New(db, replica)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			checkCtx, checkCancel := context.WithTimeout(ctx, postgresReplicaCheckTimeout)
+			defer checkCancel()
+			router.CheckReplica(checkCtx)
+
+			go router.Watch(ctx, postgresReplicaCheckInterval, postgresReplicaCheckTimeout)
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			cancel()
+			return closeWithDeadline(ctx, "Postgres replica", replica.Close)
+		},
+	})
+	return router
+}
+
+func openPostgres(conf *config.Config, dsn string, dbName string) *bun.DB {
+	// Open a Postgres database.
+	pgdb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn), pgdriver.WithApplicationName("penguin-backend")))
+
+	// Create a Bun db on top of it.
+	db := bun.NewDB(pgdb, pgdialect.New())
+	db.AddQueryHook(dbguard.NewQueryHook(conf.PostgresQueryTimeout, conf.PostgresSlowQueryThreshold))
+	if conf.DevMode {
+		db.AddQueryHook(bundebug.NewQueryHook(bundebug.WithEnabled(true), bundebug.WithVerbose(conf.BunDebugVerbose)))
+	}
+	if conf.DevMode || conf.TracingEnabled {
+		db.AddQueryHook(bunotel.NewQueryHook(bunotel.WithDBName(dbName)))
+	}
+
 	pgdb.SetMaxOpenConns(runtime.NumCPU() * 2)
 	pgdb.SetMaxIdleConns(2)
 	pgdb.SetConnMaxLifetime(time.Minute * 5)
 	pgdb.SetConnMaxIdleTime(time.Minute * 5)
 
-	return db, nil
+	return db
 }
@@ -5,11 +5,13 @@ import (
 	"time"
 
 	"github.com/go-redis/redis/v8"
+	"go.uber.org/fx"
 
 	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
 )
 
-func Redis(conf *config.Config) (*redis.Client, error) {
+func Redis(conf *config.Config, lc fx.Lifecycle) (*redis.Client, error) {
 	u, err := redis.ParseURL(conf.RedisURL)
 	if err != nil {
 		return nil, err
@@ -17,6 +19,9 @@ func Redis(conf *config.Config) (*redis.Client, error) {
 
 	// Open a Redis Client
 	client := redis.NewClient(u)
+	if conf.TracingEnabled {
+		client.AddHook(observability.RedisTracingHook{})
+	}
 
 	// check redis connection
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
@@ -26,5 +31,11 @@ func Redis(conf *config.Config) (*redis.Client, error) {
 		return nil, ping.Err()
 	}
 
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return closeWithDeadline(ctx, "Redis client", client.Close)
+		},
+	})
+
 	return client, nil
 }
@@ -0,0 +1,22 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/async"
+)
+
+// closeTimeout is the timeout of closing a single infrastructure on shutdown. Infrastructures are closed after
+// everything depending on them has stopped, so there should be nothing left but idle connections.
+const closeTimeout = time.Second * 5
+
+// closeWithDeadline closes an infrastructure of name with closeFn, giving up after closeTimeout or once ctx is done.
+func closeWithDeadline(ctx context.Context, name string, closeFn func() error) error {
+	if err := <-async.Deadline(ctx, closeTimeout, closeFn); err != nil {
+		return errors.Wrapf(err, "failed to close %s", name)
+	}
+	return nil
+}
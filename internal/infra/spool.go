@@ -0,0 +1,31 @@
+package infra
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/pkg/spool"
+)
+
+// ReportSpool returns the local spool of report tasks which could not be published, or nil when it is not
+// configured.
+func ReportSpool(conf *config.Config, lc fx.Lifecycle) (*spool.Spool, error) {
+	if conf.ReportSpoolPath == "" {
+		return nil, nil
+	}
+
+	// the spool is locked by a single process at a time, so a second process on the same path fails fast
+	s, err := spool.Open(conf.ReportSpoolPath, time.Second*5)
+	if err != nil {
+		return nil, err
+	}
+	lc.Append(fx.Hook{
+		OnStop: func(_ context.Context) error {
+			return s.Close()
+		},
+	})
+	return s, nil
+}
@@ -0,0 +1,441 @@
+-- Baseline of the schema, as it has been managed by hand before migrations were introduced. Tables are only
+-- created if they do not exist, so that the baseline is a no-op for databases which have been set up already, and
+-- sets up empty databases, e.g. for local development.
+--
+-- drop_reports is created unpartitioned; run `--migrate --partition-drop-reports` to partition it by month. There is
+-- no down migration, as rolling back the baseline would drop every table.
+
+CREATE TABLE IF NOT EXISTS accounts (
+    account_id BIGSERIAL NOT NULL,
+    penguin_id VARCHAR,
+    weight DOUBLE PRECISION,
+    created_at TIMESTAMPTZ,
+    PRIMARY KEY (account_id)
+);
+
+CREATE TABLE IF NOT EXISTS account_identities (
+    identity_id BIGSERIAL NOT NULL,
+    account_id BIGINT,
+    provider VARCHAR,
+    subject VARCHAR,
+    name VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (identity_id)
+);
+
+CREATE TABLE IF NOT EXISTS account_trust_scores (
+    account_id BIGINT NOT NULL,
+    score DOUBLE PRECISION,
+    reports BIGINT,
+    rejected BIGINT,
+    recalled BIGINT,
+    anomalies BIGINT,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (account_id)
+);
+
+CREATE TABLE IF NOT EXISTS activities (
+    activity_id BIGSERIAL NOT NULL,
+    start_time TIMESTAMPTZ,
+    end_time TIMESTAMPTZ,
+    name JSONB,
+    existence JSONB,
+    PRIMARY KEY (activity_id)
+);
+
+CREATE TABLE IF NOT EXISTS anomalies (
+    anomaly_id BIGSERIAL NOT NULL,
+    server VARCHAR,
+    stage_id BIGINT,
+    item_id BIGINT,
+    window_start TIMESTAMPTZ,
+    window_end TIMESTAMPTZ,
+    baseline_times BIGINT,
+    baseline_rate DOUBLE PRECISION,
+    recent_times BIGINT,
+    recent_rate DOUBLE PRECISION,
+    z_score DOUBLE PRECISION,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (anomaly_id)
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    key_id BIGSERIAL NOT NULL,
+    account_id BIGINT,
+    name VARCHAR,
+    prefix VARCHAR,
+    key_hash VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    PRIMARY KEY (key_id)
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    audit_id BIGSERIAL NOT NULL,
+    actor VARCHAR,
+    action VARCHAR,
+    path VARCHAR,
+    request_id VARCHAR,
+    ip VARCHAR,
+    status_code BIGINT,
+    request JSONB,
+    "before" JSONB,
+    "after" JSONB,
+    diff JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (audit_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_infos (
+    drop_id BIGSERIAL NOT NULL,
+    server VARCHAR,
+    stage_id BIGINT,
+    item_id BIGINT,
+    drop_type VARCHAR,
+    range_id BIGINT,
+    accumulable BOOLEAN,
+    bounds JSONB,
+    extras JSONB,
+    PRIMARY KEY (drop_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_matrix_elements (
+    element_id BIGSERIAL NOT NULL,
+    stage_id BIGINT,
+    item_id BIGINT,
+    range_id BIGINT,
+    quantity BIGINT,
+    times BIGINT,
+    quantity_buckets JSONB,
+    server VARCHAR,
+    source_category VARCHAR,
+    PRIMARY KEY (element_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_matrix_snapshots (
+    snapshot_id BIGSERIAL NOT NULL,
+    server VARCHAR,
+    "date" DATE,
+    elements BIGINT,
+    payload BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (snapshot_id),
+    UNIQUE (server, date)
+);
+
+CREATE TABLE IF NOT EXISTS drop_patterns (
+    pattern_id BIGSERIAL NOT NULL,
+    hash VARCHAR,
+    original_fingerprint VARCHAR,
+    PRIMARY KEY (pattern_id),
+    UNIQUE (hash)
+);
+
+CREATE TABLE IF NOT EXISTS drop_pattern_elements (
+    element_id BIGSERIAL NOT NULL,
+    drop_pattern_id BIGINT,
+    item_id BIGINT,
+    quantity BIGINT,
+    PRIMARY KEY (element_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_reports (
+    report_id BIGSERIAL NOT NULL,
+    stage_id BIGINT,
+    pattern_id BIGINT,
+    times BIGINT,
+    created_at TIMESTAMPTZ,
+    reliability BIGINT,
+    server VARCHAR,
+    account_id BIGINT,
+    deleted_at TIMESTAMPTZ,
+    reliability_before_deletion BIGINT,
+    PRIMARY KEY (report_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_report_corrections (
+    correction_id BIGSERIAL NOT NULL,
+    report_id BIGINT,
+    corrected_report_id BIGINT,
+    ip VARCHAR,
+    reason VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (correction_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_report_extras (
+    report_id BIGINT NOT NULL,
+    ip VARCHAR,
+    source_name VARCHAR,
+    version VARCHAR,
+    metadata JSONB,
+    md5 VARCHAR,
+    api_key_id BIGINT,
+    fraud_score DOUBLE PRECISION,
+    PRIMARY KEY (report_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_report_recalls (
+    recall_id BIGSERIAL NOT NULL,
+    report_id BIGINT,
+    account_id BIGINT,
+    ip VARCHAR,
+    reason VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (recall_id)
+);
+
+CREATE TABLE IF NOT EXISTS drop_types (
+    drop_type VARCHAR NOT NULL,
+    api_name VARCHAR,
+    aliases VARCHAR[],
+    reportable BOOLEAN,
+    in_pattern BOOLEAN,
+    description VARCHAR,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (drop_type)
+);
+
+CREATE TABLE IF NOT EXISTS game_data_changes (
+    change_id BIGSERIAL NOT NULL,
+    kind VARCHAR,
+    ark_id VARCHAR,
+    action VARCHAR,
+    "before" JSONB,
+    "after" JSONB,
+    status VARCHAR,
+    source VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    reviewed_at TIMESTAMPTZ,
+    PRIMARY KEY (change_id)
+);
+
+CREATE TABLE IF NOT EXISTS items (
+    item_id BIGSERIAL NOT NULL,
+    ark_item_id VARCHAR,
+    name JSONB,
+    existence JSONB,
+    sort_id BIGINT,
+    rarity BIGINT,
+    "group" VARCHAR,
+    sprite VARCHAR,
+    keywords JSONB,
+    PRIMARY KEY (item_id),
+    UNIQUE (ark_item_id)
+);
+
+CREATE TABLE IF NOT EXISTS job_runs (
+    run_id BIGSERIAL NOT NULL,
+    job VARCHAR,
+    "trigger" VARCHAR,
+    instance VARCHAR,
+    started_at TIMESTAMPTZ,
+    duration_ms BIGINT,
+    result VARCHAR,
+    error VARCHAR,
+    PRIMARY KEY (run_id)
+);
+
+CREATE TABLE IF NOT EXISTS matrix_watermarks (
+    matrix VARCHAR NOT NULL,
+    server VARCHAR NOT NULL,
+    report_id BIGINT,
+    full_refreshed_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (matrix,
+    server)
+);
+
+CREATE TABLE IF NOT EXISTS notices (
+    notice_id BIGSERIAL NOT NULL,
+    existence JSONB,
+    severity BIGINT,
+    content JSONB,
+    PRIMARY KEY (notice_id)
+);
+
+CREATE TABLE IF NOT EXISTS pattern_matrix_elements (
+    element_id BIGSERIAL NOT NULL,
+    stage_id BIGINT,
+    pattern_id BIGINT,
+    range_id BIGINT,
+    quantity BIGINT,
+    times BIGINT,
+    "lower" DOUBLE PRECISION,
+    "upper" DOUBLE PRECISION,
+    server VARCHAR,
+    source_category VARCHAR,
+    PRIMARY KEY (element_id)
+);
+
+CREATE TABLE IF NOT EXISTS properties (
+    property_id BIGSERIAL NOT NULL,
+    "key" VARCHAR,
+    value VARCHAR,
+    PRIMARY KEY (property_id),
+    UNIQUE (key)
+);
+
+CREATE TABLE IF NOT EXISTS recognition_bundles (
+    hash VARCHAR NOT NULL,
+    version VARCHAR,
+    content_type VARCHAR,
+    size BIGINT,
+    content BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (hash)
+);
+
+CREATE TABLE IF NOT EXISTS recognition_releases (
+    server VARCHAR NOT NULL,
+    hash VARCHAR,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (server)
+);
+
+CREATE TABLE IF NOT EXISTS reject_rules (
+    rule_id BIGSERIAL NOT NULL,
+    created_at TIMESTAMPTZ,
+    updated_at TIMESTAMPTZ,
+    status BIGINT,
+    expr VARCHAR,
+    with_reliability BIGINT,
+    PRIMARY KEY (rule_id)
+);
+
+CREATE TABLE IF NOT EXISTS rejected_report_tasks (
+    rejected_task_id BIGSERIAL NOT NULL,
+    task_id VARCHAR,
+    subject VARCHAR,
+    task JSONB,
+    error VARCHAR,
+    attempts BIGINT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (rejected_task_id)
+);
+
+CREATE TABLE IF NOT EXISTS report_gates (
+    gate_id BIGSERIAL NOT NULL,
+    source VARCHAR,
+    version_from VARCHAR,
+    version_before VARCHAR,
+    action VARCHAR,
+    reason VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (gate_id)
+);
+
+CREATE TABLE IF NOT EXISTS report_purges (
+    purge_id BIGSERIAL NOT NULL,
+    filter JSONB,
+    reason VARCHAR,
+    ip VARCHAR,
+    affected_reports BIGINT,
+    refresh_id VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (purge_id)
+);
+
+CREATE TABLE IF NOT EXISTS shadow_bans (
+    ban_id BIGSERIAL NOT NULL,
+    account_id BIGINT,
+    ip_range VARCHAR,
+    reason VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    expires_at TIMESTAMPTZ,
+    PRIMARY KEY (ban_id)
+);
+
+CREATE TABLE IF NOT EXISTS stages (
+    stage_id BIGSERIAL NOT NULL,
+    ark_stage_id VARCHAR,
+    zone_id BIGINT,
+    stage_type VARCHAR,
+    extra_process_type VARCHAR,
+    code JSONB,
+    sanity BIGINT,
+    existence JSONB,
+    min_clear_time BIGINT,
+    PRIMARY KEY (stage_id),
+    UNIQUE (ark_stage_id)
+);
+
+CREATE TABLE IF NOT EXISTS stage_rewrite_rules (
+    rule_id BIGSERIAL NOT NULL,
+    name VARCHAR,
+    source VARCHAR,
+    version_from VARCHAR,
+    version_before VARCHAR,
+    stage_id_pattern VARCHAR,
+    rewrite VARCHAR,
+    active_from TIMESTAMPTZ,
+    active_until TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (rule_id)
+);
+
+CREATE TABLE IF NOT EXISTS time_ranges (
+    range_id BIGSERIAL NOT NULL,
+    name VARCHAR,
+    start_time TIMESTAMPTZ,
+    end_time TIMESTAMPTZ,
+    comment VARCHAR,
+    server VARCHAR,
+    PRIMARY KEY (range_id)
+);
+
+CREATE TABLE IF NOT EXISTS trend_elements (
+    element_id BIGSERIAL NOT NULL,
+    stage_id BIGINT,
+    item_id BIGINT,
+    group_id BIGINT,
+    start_time TIMESTAMPTZ,
+    end_time TIMESTAMPTZ,
+    quantity BIGINT,
+    times BIGINT,
+    server VARCHAR,
+    source_category VARCHAR,
+    granularity VARCHAR,
+    PRIMARY KEY (element_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    webhook_id BIGSERIAL NOT NULL,
+    url VARCHAR,
+    secret VARCHAR,
+    events VARCHAR[],
+    description VARCHAR,
+    enabled BOOLEAN,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    PRIMARY KEY (webhook_id)
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id BIGSERIAL NOT NULL,
+    webhook_id BIGINT,
+    event VARCHAR,
+    payload JSONB,
+    status VARCHAR,
+    attempts BIGINT,
+    response_status BIGINT,
+    last_error VARCHAR,
+    next_attempt_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT current_timestamp,
+    delivered_at TIMESTAMPTZ,
+    PRIMARY KEY (delivery_id)
+);
+
+CREATE TABLE IF NOT EXISTS zones (
+    zone_id BIGSERIAL NOT NULL,
+    ark_zone_id VARCHAR,
+    "index" BIGINT,
+    category VARCHAR,
+    "type" VARCHAR,
+    name JSONB,
+    existence JSONB,
+    background VARCHAR,
+    PRIMARY KEY (zone_id),
+    UNIQUE (ark_zone_id)
+);
@@ -0,0 +1,25 @@
+ALTER TABLE trend_elements DROP COLUMN IF EXISTS granularity;
+
+--bun:split
+
+ALTER TABLE pattern_matrix_elements DROP COLUMN IF EXISTS "upper";
+
+--bun:split
+
+ALTER TABLE pattern_matrix_elements DROP COLUMN IF EXISTS "lower";
+
+--bun:split
+
+ALTER TABLE drop_report_extras DROP COLUMN IF EXISTS fraud_score;
+
+--bun:split
+
+ALTER TABLE drop_report_extras DROP COLUMN IF EXISTS api_key_id;
+
+--bun:split
+
+ALTER TABLE drop_reports DROP COLUMN IF EXISTS reliability_before_deletion;
+
+--bun:split
+
+ALTER TABLE drop_reports DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,33 @@
+-- Columns added to tables which have existed before migrations were introduced. The baseline only creates tables
+-- which do not exist, so databases set up before carry none of these columns.
+
+ALTER TABLE drop_reports ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+--bun:split
+
+ALTER TABLE drop_reports ADD COLUMN IF NOT EXISTS reliability_before_deletion BIGINT;
+
+--bun:split
+
+ALTER TABLE drop_report_extras ADD COLUMN IF NOT EXISTS api_key_id BIGINT;
+
+--bun:split
+
+ALTER TABLE drop_report_extras ADD COLUMN IF NOT EXISTS fraud_score DOUBLE PRECISION;
+
+--bun:split
+
+ALTER TABLE pattern_matrix_elements ADD COLUMN IF NOT EXISTS "lower" DOUBLE PRECISION;
+
+--bun:split
+
+ALTER TABLE pattern_matrix_elements ADD COLUMN IF NOT EXISTS "upper" DOUBLE PRECISION;
+
+--bun:split
+
+ALTER TABLE trend_elements ADD COLUMN IF NOT EXISTS granularity VARCHAR;
+
+--bun:split
+
+-- trend elements computed before granularities were introduced are daily
+UPDATE trend_elements SET granularity = 'day' WHERE granularity IS NULL;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS drop_reports_reliability_created_at_idx;
+
+--bun:split
+
+DROP INDEX IF EXISTS drop_reports_server_stage_id_created_at_idx;
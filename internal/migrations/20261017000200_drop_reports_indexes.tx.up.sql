@@ -0,0 +1,9 @@
+-- Indexes of drop_reports supporting queries of drop matrices and trends, which scan reports of a stage within a time
+-- range, and queries of aggregate views and statistics, which scan reliable reports within a time range. Indexes of a
+-- partitioned drop_reports are created on every partition.
+
+CREATE INDEX IF NOT EXISTS drop_reports_server_stage_id_created_at_idx ON drop_reports (server, stage_id, created_at);
+
+--bun:split
+
+CREATE INDEX IF NOT EXISTS drop_reports_reliability_created_at_idx ON drop_reports (reliability, created_at);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS drop_reports_account_id_created_at_report_id_idx;
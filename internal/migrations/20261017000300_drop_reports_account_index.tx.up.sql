@@ -0,0 +1,3 @@
+-- Index of drop_reports supporting the keyset pagination of reports of an account, ordered by creation.
+
+CREATE INDEX IF NOT EXISTS drop_reports_account_id_created_at_report_id_idx ON drop_reports (account_id, created_at DESC, report_id DESC);
@@ -0,0 +1 @@
+DELETE FROM stage_rewrite_rules WHERE name = 'maa-act18d3-perm-as-rep';
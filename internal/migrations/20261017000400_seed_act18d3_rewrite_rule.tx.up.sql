@@ -0,0 +1,6 @@
+-- Seeds the stage rewrite rule replacing the former hardcoded mitigation of MeoAssistant reporting act18d3 stages
+-- as `_perm` instead of `_rep`, until 2022-06-08T20:00:00Z.
+
+INSERT INTO stage_rewrite_rules (name, source, stage_id_pattern, rewrite, active_until)
+SELECT 'maa-act18d3-perm-as-rep', 'MeoAssistant', '^act18d3_(0[1-9])_perm$', 'act18d3_${1}_rep', '2022-06-08T20:00:00Z'
+WHERE NOT EXISTS (SELECT 1 FROM stage_rewrite_rules WHERE name = 'maa-act18d3-perm-as-rep');
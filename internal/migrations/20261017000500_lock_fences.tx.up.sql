@@ -0,0 +1,8 @@
+-- Fencing tokens of distributed locks which have written under them, refusing writes of holders which have lost
+-- their locks since.
+
+CREATE TABLE IF NOT EXISTS lock_fences (
+    name VARCHAR NOT NULL,
+    token BIGINT NOT NULL,
+    PRIMARY KEY (name)
+);
@@ -0,0 +1,24 @@
+// Package migrations holds the migrations of the database schema, as SQL files embedded into the binary.
+//
+// Migrations are named `<14-digit timestamp>_<name>.[tx.]up.sql`, along with `.[tx.]down.sql` reversing them, and
+// are applied in the order of their timestamps. Migrations with `.tx.` run in a single transaction. Statements of a
+// file are separated by `--bun:split` lines. A migration without a down migration could not be rolled back.
+package migrations
+
+import (
+	"embed"
+
+	"github.com/uptrace/bun/migrate"
+)
+
+//go:embed *.sql
+var sqlMigrations embed.FS
+
+// Migrations are all migrations of the database schema.
+var Migrations = migrate.NewMigrations()
+
+func init() {
+	if err := Migrations.Discover(sqlMigrations); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AccountIdentity is an external identity, e.g. a GitHub user, attached to an account. Logging in with the
+// identity logs in as the account.
+type AccountIdentity struct {
+	bun.BaseModel `bun:"account_identities,alias:ai"`
+
+	IdentityID int `bun:",pk,autoincrement" json:"id"`
+	AccountID  int `json:"-"`
+	// Provider is the name of the identity provider, e.g. "github". Provider and Subject are unique together.
+	Provider  string     `json:"provider"`
+	Subject   string     `json:"subject"`
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
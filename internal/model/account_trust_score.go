@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// AccountTrustScore is the trust score of an account, derived from the historical reliability of its reports.
+type AccountTrustScore struct {
+	bun.BaseModel `bun:"account_trust_scores,alias:ats"`
+
+	AccountID int `bun:",pk" json:"accountId"`
+	// Score ranges in [0, 1], where a higher score represents a more trustworthy account.
+	Score float64 `json:"score"`
+	// Reports is the number of reports submitted by the account.
+	Reports int `json:"reports"`
+	// Rejected is the number of reports submitted by the account which have been rejected by any verifier.
+	Rejected int `json:"rejected"`
+	// Recalled is the number of reports submitted by the account which have been recalled afterwards.
+	Recalled int `json:"recalled"`
+	// Anomalies is the number of rejected reports whose violation indicates an abusive submitter.
+	Anomalies int        `json:"anomalies"`
+	UpdatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updatedAt"`
+}
+
+// AccountReportStats is the aggregation of reports of an account, from which its trust score is calculated.
+type AccountReportStats struct {
+	Reports   int `bun:"reports"`
+	Rejected  int `bun:"rejected"`
+	Recalled  int `bun:"recalled"`
+	Anomalies int `bun:"anomalies"`
+}
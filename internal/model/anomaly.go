@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Anomaly records a statistically significant deviation of the recent drop rate of an item in a stage from its
+// historical baseline, which may be caused by a change of game data or by coordinated fake reporting.
+type Anomaly struct {
+	bun.BaseModel `bun:"anomalies,alias:an"`
+
+	AnomalyID int    `bun:",pk,autoincrement" json:"id"`
+	Server    string `json:"server"`
+	StageID   int    `json:"stageId"`
+	ItemID    int    `json:"itemId"`
+	// WindowStart and WindowEnd describe the recent window compared against the baseline right before it.
+	WindowStart   time.Time `json:"windowStart"`
+	WindowEnd     time.Time `json:"windowEnd"`
+	BaselineTimes int       `json:"baselineTimes"`
+	BaselineRate  float64   `json:"baselineRate"`
+	RecentTimes   int       `json:"recentTimes"`
+	RecentRate    float64   `json:"recentRate"`
+	// ZScore is the z-score of the difference between RecentRate and BaselineRate.
+	ZScore    float64    `json:"zScore"`
+	CreatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// APIKey authenticates automated reporters on behalf of an account, without the PenguinID of the account.
+// Only the SHA-256 hash of the key is stored.
+type APIKey struct {
+	bun.BaseModel `bun:"api_keys,alias:ak"`
+
+	KeyID     int    `bun:",pk,autoincrement" json:"id"`
+	AccountID int    `json:"-"`
+	Name      string `json:"name"`
+	// Prefix is the beginning of the key, for users to tell keys apart.
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	CreatedAt  *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	// RevokedAt is when the key has been revoked. Null when the key is active.
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+// CreatedAPIKey is an API key just created, along with the key itself, which is only returned once on creation.
+type CreatedAPIKey struct {
+	*APIKey
+
+	Key string `json:"key" example:"pgk_xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"`
+}
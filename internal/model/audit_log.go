@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// AuditLog is the record of a mutation made through the admin API, kept for compliance review.
+type AuditLog struct {
+	bun.BaseModel `bun:"audit_logs,alias:al"`
+
+	AuditID int64 `bun:",pk,autoincrement" json:"id"`
+	// Actor is who has made the request, as identified by the admin key it has authenticated with, or "admin" if
+	// authenticated with the shared admin key.
+	Actor string `json:"actor" example:"alice"`
+	// Action is the method and the route of the request.
+	Action string `json:"action" example:"PATCH /api/admin/gamedata/items/:itemId"`
+	// Path is the path and the query of the request.
+	Path      string      `json:"path" example:"/api/admin/gamedata/items/30012"`
+	RequestID null.String `json:"requestId" swaggertype:"string"`
+	IP        string      `json:"ip"`
+	// StatusCode is the status code the request has been responded with.
+	StatusCode int `json:"statusCode"`
+	// Request is the body of the request, if it is in JSON.
+	Request json.RawMessage `bun:"type:jsonb" json:"request,omitempty" swaggertype:"object"`
+	// Before and After are the record changed by the request, before and after being changed, if recorded by the
+	// handler.
+	Before json.RawMessage `bun:"type:jsonb" json:"before,omitempty" swaggertype:"object"`
+	After  json.RawMessage `bun:"type:jsonb" json:"after,omitempty" swaggertype:"object"`
+	// Diff holds changes between top-level fields of Before and After, keyed by field names.
+	Diff      json.RawMessage `bun:"type:jsonb" json:"diff,omitempty" swaggertype:"object"`
+	CreatedAt *time.Time      `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
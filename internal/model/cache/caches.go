@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/go-redis/redis/v8"
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/penguin-statistics/backend-next/internal/model"
@@ -20,19 +21,29 @@ var (
 	AccountByID        *cache.Set[model.Account]
 	AccountByPenguinID *cache.Set[model.Account]
 
+	APIKeyByHash *cache.Set[model.APIKey]
+
 	ItemDropSetByStageIDAndRangeID   *cache.Set[[]int]
 	ItemDropSetByStageIdAndTimeRange *cache.Set[[]int]
+	CurrentDropInfosByArkStageID     *cache.Tiered[model.CurrentDropInfos]
+
+	DropTypes *cache.Singular[[]*model.DropType]
 
 	ShimMaxAccumulableDropMatrixResults *cache.Set[modelv2.DropMatrixQueryResult]
+	PersonalDropMatrixResults           *cache.Tiered[model.DropMatrixQueryResult]
+	PersonalHistoryResults              *cache.Tiered[modelv2.PersonalHistoryQueryResult]
+	RecentDropMatrixResults             *cache.Set[model.DropMatrixQueryResult]
+	DropMatrixHistoryResults            *cache.Set[modelv2.DropMatrixHistoryResult]
 
 	Formula *cache.Singular[json.RawMessage]
 
 	Items           *cache.Singular[[]*model.Item]
-	ItemByArkID     *cache.Set[model.Item]
+	ItemByArkID     *cache.Tiered[model.Item]
 	ShimItems       *cache.Singular[[]*modelv2.Item]
 	ShimItemByArkID *cache.Set[modelv2.Item]
 	ItemsMapById    *cache.Singular[map[int]*model.Item]
 	ItemsMapByArkID *cache.Singular[map[string]*model.Item]
+	ItemGameModes   *cache.Singular[map[string][]string]
 
 	Notices *cache.Singular[[]*model.Notice]
 
@@ -41,7 +52,8 @@ var (
 
 	ShimLatestPatternMatrixResults *cache.Set[modelv2.PatternMatrixQueryResult]
 
-	ShimSiteStats *cache.Set[modelv2.SiteStats]
+	ShimSiteStats     *cache.Set[modelv2.SiteStats]
+	SiteReporterStats *cache.Tiered[modelv2.SiteReporterStats]
 
 	Stages           *cache.Singular[[]*model.Stage]
 	StageByArkID     *cache.Set[model.Stage]
@@ -50,6 +62,8 @@ var (
 	StagesMapByID    *cache.Singular[map[int]*model.Stage]
 	StagesMapByArkID *cache.Singular[map[string]*model.Stage]
 
+	StageExtraProcessTypeByArkID *cache.Tiered[null.String]
+
 	TimeRanges               *cache.Set[[]*model.TimeRange]
 	TimeRangeByID            *cache.Set[model.TimeRange]
 	TimeRangesMap            *cache.Set[map[int]*model.TimeRange]
@@ -63,9 +77,15 @@ var (
 	ShimZoneByArkID *cache.Set[modelv2.Zone]
 
 	DropPatternElementsByPatternID *cache.Set[[]*model.DropPatternElement]
+	DropPatternIDByHash            *cache.Set[int]
+
+	RecognitionBundleContentByHash *cache.Tiered[model.RecognitionBundleContent]
+	RecognitionReleaseByServer     *cache.Set[modelv2.RecognitionRelease]
 
 	LastModifiedTime *cache.Set[time.Time]
 
+	CacheVersions *cache.Set[int64]
+
 	Properties map[string]string
 
 	once sync.Once
@@ -74,9 +94,9 @@ var (
 	SingularFlusherMap map[string]Flusher
 )
 
-func Initialize(propertyRepo *repo.Property) {
+func Initialize(propertyRepo *repo.Property, redisClient *redis.Client) {
 	once.Do(func() {
-		initializeCaches()
+		initializeCaches(redisClient)
 		populateProperties(propertyRepo)
 	})
 }
@@ -102,7 +122,47 @@ func Delete(name string, key null.String) error {
 	return nil
 }
 
-func initializeCaches() {
+// FlushLocalGameData removes in-process copies of game data, i.e. items, stages, zones, activities, time ranges,
+// drop infos and drop types, after game data has been updated. Hot lookups shared with other instances through
+// Redis are not removed from Redis.
+func FlushLocalGameData() {
+	_ = ItemByArkID.FlushLocal()
+	_ = StageExtraProcessTypeByArkID.FlushLocal()
+	_ = CurrentDropInfosByArkStageID.FlushLocal()
+
+	_ = Items.Delete()
+	_ = ShimItems.Delete()
+	_ = ShimItemByArkID.Flush()
+	_ = ItemsMapById.Delete()
+	_ = ItemsMapByArkID.Delete()
+
+	_ = Stages.Delete()
+	_ = StageByArkID.Flush()
+	_ = ShimStages.Flush()
+	_ = ShimStageByArkID.Flush()
+	_ = StagesMapByID.Delete()
+	_ = StagesMapByArkID.Delete()
+
+	_ = Zones.Delete()
+	_ = ZoneByArkID.Flush()
+	_ = ShimZones.Delete()
+	_ = ShimZoneByArkID.Flush()
+
+	_ = Activities.Delete()
+	_ = ShimActivities.Delete()
+
+	_ = TimeRanges.Flush()
+	_ = TimeRangeByID.Flush()
+	_ = TimeRangesMap.Flush()
+	_ = MaxAccumulableTimeRanges.Flush()
+
+	_ = ItemDropSetByStageIDAndRangeID.Flush()
+	_ = ItemDropSetByStageIdAndTimeRange.Flush()
+
+	_ = DropTypes.Delete()
+}
+
+func initializeCaches(redisClient *redis.Client) {
 	SetMap = make(map[string]Flusher)
 	SingularFlusherMap = make(map[string]Flusher)
 
@@ -113,6 +173,11 @@ func initializeCaches() {
 	SetMap["account#accountId"] = AccountByID.Flush
 	SetMap["account#penguinId"] = AccountByPenguinID.Flush
 
+	// api_key
+	APIKeyByHash = cache.NewSet[model.APIKey]("apiKey#keyHash")
+
+	SetMap["apiKey#keyHash"] = APIKeyByHash.Flush
+
 	// drop_info
 	ItemDropSetByStageIDAndRangeID = cache.NewSet[[]int]("itemDropSet#server|stageId|rangeId")
 	ItemDropSetByStageIdAndTimeRange = cache.NewSet[[]int]("itemDropSet#server|stageId|startTime|endTime")
@@ -120,22 +185,48 @@ func initializeCaches() {
 	SetMap["itemDropSet#server|stageId|rangeId"] = ItemDropSetByStageIDAndRangeID.Flush
 	SetMap["itemDropSet#server|stageId|startTime|endTime"] = ItemDropSetByStageIdAndTimeRange.Flush
 
+	// drop infos of the current time range change as time ranges start and end, which is checked on every read
+	CurrentDropInfosByArkStageID = cache.NewTiered[model.CurrentDropInfos]("currentDropInfos#server|arkStageId", redisClient, 4096, time.Second*30, time.Minute*2)
+
+	SetMap["currentDropInfos#server|arkStageId"] = CurrentDropInfosByArkStageID.Flush
+
+	// drop_type
+	DropTypes = cache.NewSingular[[]*model.DropType]("dropTypes")
+	SingularFlusherMap["dropTypes"] = DropTypes.Delete
+
 	// drop_matrix
 	ShimMaxAccumulableDropMatrixResults = cache.NewSet[modelv2.DropMatrixQueryResult]("shimMaxAccumulableDropMatrixResults#server|showClosedZoned")
 
 	SetMap["shimMaxAccumulableDropMatrixResults#server|showClosedZoned"] = ShimMaxAccumulableDropMatrixResults.Flush
 
+	RecentDropMatrixResults = cache.NewSet[model.DropMatrixQueryResult]("recentDropMatrixResults#server")
+
+	SetMap["recentDropMatrixResults#server"] = RecentDropMatrixResults.Flush
+
+	DropMatrixHistoryResults = cache.NewSet[modelv2.DropMatrixHistoryResult]("dropMatrixHistoryResults#snapshotId|createdAt")
+
+	SetMap["dropMatrixHistoryResults#snapshotId|createdAt"] = DropMatrixHistoryResults.Flush
+
+	// personal results are keyed by the version of personal caches of the account, which is bumped once the account
+	// reports or recalls, so that results left behind are never read again and only expire
+	PersonalDropMatrixResults = cache.NewTiered[model.DropMatrixQueryResult]("personalDropMatrixResults#accountId|version|server", redisClient, 256, time.Minute*10, time.Hour)
+	PersonalHistoryResults = cache.NewTiered[modelv2.PersonalHistoryQueryResult]("personalHistoryResults#accountId|version|server|interval", redisClient, 256, time.Minute*10, time.Hour)
+
+	SetMap["personalDropMatrixResults#accountId|version|server"] = PersonalDropMatrixResults.Flush
+	SetMap["personalHistoryResults#accountId|version|server|interval"] = PersonalHistoryResults.Flush
+
 	// formula
 	Formula = cache.NewSingular[json.RawMessage]("formula")
 	SingularFlusherMap["formula"] = Formula.Delete
 
 	// item
 	Items = cache.NewSingular[[]*model.Item]("items")
-	ItemByArkID = cache.NewSet[model.Item]("item#arkItemId")
+	ItemByArkID = cache.NewTiered[model.Item]("item#arkItemId", redisClient, 2048, time.Minute*10, time.Hour)
 	ShimItems = cache.NewSingular[[]*modelv2.Item]("shimItems")
 	ShimItemByArkID = cache.NewSet[modelv2.Item]("shimItem#arkItemId")
 	ItemsMapById = cache.NewSingular[map[int]*model.Item]("itemsMapById")
 	ItemsMapByArkID = cache.NewSingular[map[string]*model.Item]("itemsMapByArkId")
+	ItemGameModes = cache.NewSingular[map[string][]string]("itemGameModes")
 
 	SingularFlusherMap["items"] = Items.Delete
 	SetMap["item#arkItemId"] = ItemByArkID.Flush
@@ -143,6 +234,7 @@ func initializeCaches() {
 	SetMap["shimItem#arkItemId"] = ShimItemByArkID.Flush
 	SingularFlusherMap["itemsMapById"] = ItemsMapById.Delete
 	SingularFlusherMap["itemsMapByArkId"] = ItemsMapByArkID.Delete
+	SingularFlusherMap["itemGameModes"] = ItemGameModes.Delete
 
 	// notice
 	Notices = cache.NewSingular[[]*model.Notice]("notices")
@@ -166,6 +258,12 @@ func initializeCaches() {
 
 	SetMap["shimSiteStats#server"] = ShimSiteStats.Flush
 
+	// reporter stats are keyed by the version of site stats, which the worker bumps before recalculating them, so that
+	// they are calculated once and shared by all instances
+	SiteReporterStats = cache.NewTiered[modelv2.SiteReporterStats]("siteReporterStats#server|version", redisClient, 16, time.Minute*10, time.Hour)
+
+	SetMap["siteReporterStats#server|version"] = SiteReporterStats.Flush
+
 	// stage
 	Stages = cache.NewSingular[[]*model.Stage]("stages")
 	StageByArkID = cache.NewSet[model.Stage]("stage#arkStageId")
@@ -181,6 +279,10 @@ func initializeCaches() {
 	SingularFlusherMap["stagesMapById"] = StagesMapByID.Delete
 	SingularFlusherMap["stagesMapByArkId"] = StagesMapByArkID.Delete
 
+	StageExtraProcessTypeByArkID = cache.NewTiered[null.String]("stageExtraProcessType#arkStageId", redisClient, 4096, time.Minute*10, time.Hour)
+
+	SetMap["stageExtraProcessType#arkStageId"] = StageExtraProcessTypeByArkID.Flush
+
 	// time_range
 	TimeRanges = cache.NewSet[[]*model.TimeRange]("timeRanges#server")
 	TimeRangeByID = cache.NewSet[model.TimeRange]("timeRange#rangeId")
@@ -193,9 +295,9 @@ func initializeCaches() {
 	SetMap["maxAccumulableTimeRanges#server"] = MaxAccumulableTimeRanges.Flush
 
 	// trend
-	ShimSavedTrendResults = cache.NewSet[modelv2.TrendQueryResult]("shimSavedTrendResults#server")
+	ShimSavedTrendResults = cache.NewSet[modelv2.TrendQueryResult]("shimSavedTrendResults#server|granularity")
 
-	SetMap["shimSavedTrendResults#server"] = ShimSavedTrendResults.Flush
+	SetMap["shimSavedTrendResults#server|granularity"] = ShimSavedTrendResults.Flush
 
 	// zone
 	Zones = cache.NewSingular[[]*model.Zone]("zones")
@@ -213,10 +315,28 @@ func initializeCaches() {
 
 	SetMap["dropPatternElements#patternId"] = DropPatternElementsByPatternID.Flush
 
+	// drop_patterns
+	DropPatternIDByHash = cache.NewSet[int]("dropPatternId#hash")
+
+	SetMap["dropPatternId#hash"] = DropPatternIDByHash.Flush
+
+	// recognition
+	// bundles are addressed by the hash of their content and never change, hence only a few of them are kept
+	// in-process, as they are large
+	RecognitionBundleContentByHash = cache.NewTiered[model.RecognitionBundleContent]("recognitionBundleContent#hash", redisClient, 8, time.Hour, time.Hour*24)
+	RecognitionReleaseByServer = cache.NewSet[modelv2.RecognitionRelease]("recognitionRelease#server")
+
+	SetMap["recognitionBundleContent#hash"] = RecognitionBundleContentByHash.Flush
+	SetMap["recognitionRelease#server"] = RecognitionReleaseByServer.Flush
+
 	// others
 	LastModifiedTime = cache.NewSet[time.Time]("lastModifiedTime#key")
 
 	SetMap["lastModifiedTime#key"] = LastModifiedTime.Flush
+
+	CacheVersions = cache.NewSet[int64]("cacheVersion#name|key")
+
+	SetMap["cacheVersion#name|key"] = CacheVersions.Flush
 }
 
 func populateProperties(repo *repo.Property) {
@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/uptrace/bun"
 	"gopkg.in/guregu/null.v3"
@@ -21,6 +22,13 @@ type DropInfo struct {
 	Extras      json.RawMessage `json:"extras,omitempty"`
 }
 
+// CurrentDropInfos are drop infos of a stage within the current time range, which stay current until ValidUntil,
+// when a time range of the server starts or ends. ValidUntil is zero if no time range starts or ends afterwards.
+type CurrentDropInfos struct {
+	DropInfos  []*DropInfo `json:"dropInfos"`
+	ValidUntil time.Time   `json:"validUntil"`
+}
+
 type Bounds struct {
 	Upper      int   `json:"upper"`
 	Lower      int   `json:"lower"`
@@ -30,3 +38,28 @@ type Bounds struct {
 func (b *Bounds) Scan(src any) error {
 	return json.Unmarshal(src.([]byte), b)
 }
+
+// BoundsImpact is how reports of an item are judged by its current and proposed bounds.
+type BoundsImpact struct {
+	// Checked is the number of reports checked against the bounds.
+	Checked int `bun:"checked" json:"checked"`
+	// OutOfBounds is the number of reports out of the proposed bounds.
+	OutOfBounds int `bun:"out_of_bounds" json:"outOfBounds"`
+	// NewlyOutOfBounds is the number of reports out of the proposed bounds, but within the current bounds, i.e.
+	// reports that would become invalid once the proposed bounds are committed.
+	NewlyOutOfBounds int `bun:"newly_out_of_bounds" json:"newlyOutOfBounds"`
+}
+
+// DropInfoBoundsPreview is the preview of changing bounds of a drop info against recent reports.
+type DropInfoBoundsPreview struct {
+	*BoundsImpact
+
+	DropInfo  *DropInfo `json:"dropInfo"`
+	Committed bool      `json:"committed"`
+	// CurrentBounds and ProposedBounds are bounds of the item across all its drop types, as quantities of reports
+	// are not told apart by drop types.
+	CurrentBounds  *Bounds   `json:"currentBounds"`
+	ProposedBounds *Bounds   `json:"proposedBounds"`
+	Since          time.Time `json:"since"`
+	Until          time.Time `json:"until"`
+}
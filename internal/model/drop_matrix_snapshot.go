@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// DropMatrixSnapshot is the drop matrix of a server as it has been on a day, kept for looking up historical drop
+// rates.
+type DropMatrixSnapshot struct {
+	bun.BaseModel `bun:"drop_matrix_snapshots,alias:dms"`
+
+	SnapshotID int    `bun:",pk,autoincrement" json:"id"`
+	Server     string `json:"server"`
+	// Date is the UTC date the snapshot has been taken on. There is at most one snapshot of a server per date.
+	Date time.Time `bun:"type:date" json:"date"`
+	// Elements is the number of drop matrix elements of the snapshot.
+	Elements int `json:"elements"`
+	// Payload is the gzip'd JSON of the drop matrix elements, in the shape of the v2 drop matrix.
+	Payload   []byte     `bun:"type:bytea" json:"-"`
+	CreatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
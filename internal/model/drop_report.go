@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
 )
 
 type DropReport struct {
@@ -17,4 +18,8 @@ type DropReport struct {
 	Reliability int        `json:"reliability"`
 	Server      string     `json:"server"`
 	AccountID   int        `json:"accountId"`
+	// DeletedAt is when the report has been recalled or purged. Reports are only soft-deleted, and ReliabilityBeforeDeletion
+	// is the reliability to be restored once a deletion is reversed.
+	DeletedAt                 *time.Time `bun:",nullzero" json:"deletedAt,omitempty"`
+	ReliabilityBeforeDeletion null.Int   `json:"-"`
 }
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// DropReportCorrection is the audit record linking a drop report to the report which has corrected it.
+type DropReportCorrection struct {
+	bun.BaseModel `bun:"drop_report_corrections,alias:drc"`
+
+	CorrectionID int `bun:",pk,autoincrement" json:"id"`
+	// ReportID is the report superseded by the correction.
+	ReportID int `json:"reportId"`
+	// CorrectedReportID is the report the correction has been persisted as.
+	CorrectedReportID int         `json:"correctedReportId"`
+	IP                string      `json:"ip"`
+	Reason            null.String `json:"reason" swaggertype:"string"`
+	CreatedAt         *time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
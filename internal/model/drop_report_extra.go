@@ -16,4 +16,7 @@ type DropReportExtra struct {
 	Version  string                       `json:"version"`
 	Metadata *types.ReportRequestMetadata `json:"metadata"`
 	MD5      null.String                  `json:"md5" swaggertype:"string"`
+	APIKeyID null.Int                     `json:"apiKeyId" swaggertype:"integer"`
+	// FraudScore is how likely the report is fake, as scored on consumption. Null when not scored.
+	FraudScore null.Float `json:"fraudScore" swaggertype:"number"`
 }
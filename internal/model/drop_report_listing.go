@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+)
+
+// ListedDropReport is a drop report in listings, along with its drops.
+type ListedDropReport struct {
+	ReportID   int       `bun:"report_id" json:"id"`
+	ArkStageID string    `bun:"ark_stage_id" json:"stageId"`
+	PatternID  int       `bun:"pattern_id" json:"-"`
+	Times      int       `bun:"times" json:"times"`
+	CreatedAt  time.Time `bun:"created_at" json:"createdAt"`
+	Server     string    `bun:"server" json:"server"`
+	// Reliability, AccountID and DeletedAt are omitted in listings of an account's own reports.
+	Reliability *int        `bun:"reliability" json:"reliability,omitempty"`
+	AccountID   *int        `bun:"account_id" json:"accountId,omitempty"`
+	Source      null.String `bun:"source_name" json:"source" swaggertype:"string"`
+	Version     null.String `bun:"version" json:"version" swaggertype:"string"`
+	DeletedAt   *time.Time  `bun:"deleted_at" json:"deletedAt,omitempty"`
+
+	Drops []*ListedDrop `bun:"-" json:"drops"`
+}
+
+// ListedDrop is a drop of a ListedDropReport.
+type ListedDrop struct {
+	DropPatternID int    `bun:"drop_pattern_id" json:"-"`
+	ArkItemID     string `bun:"ark_item_id" json:"itemId"`
+	Quantity      int    `bun:"quantity" json:"quantity"`
+}
+
+// DropReportPage is a page of a listing of drop reports, latest first.
+type DropReportPage struct {
+	Reports []*ListedDropReport `json:"reports"`
+	// NextCursor is the cursor of the next page, which is omitted on the last page.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
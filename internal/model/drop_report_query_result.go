@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+)
 
 // DropMatrix
 type TotalQuantityResultForDropMatrix struct {
@@ -14,6 +18,21 @@ type TotalTimesResult struct {
 	TotalTimes int `json:"totalTimes" bun:"total_times"`
 }
 
+// WeightedTimesResult is the times of a stage with each report weighted by its recency.
+type WeightedTimesResult struct {
+	StageID       int     `json:"stageId" bun:"stage_id"`
+	WeightedTimes float64 `json:"weightedTimes" bun:"weighted_times"`
+}
+
+// WeightedQuantityResult is the quantity of an item dropped from a stage with each report weighted by its recency,
+// along with the weighted sum of squared quantities for the standard deviation.
+type WeightedQuantityResult struct {
+	StageID                 int     `json:"stageId" bun:"stage_id"`
+	ItemID                  int     `json:"itemId" bun:"item_id"`
+	WeightedQuantity        float64 `json:"weightedQuantity" bun:"weighted_quantity"`
+	WeightedQuantitySquared float64 `json:"weightedQuantitySquared" bun:"weighted_quantity_squared"`
+}
+
 type QuantityUniqCountResultForDropMatrix struct {
 	StageID  int `json:"stageId" bun:"stage_id"`
 	ItemID   int `json:"itemId" bun:"item_id"`
@@ -67,6 +86,8 @@ type OnePatternMatrixElement struct {
 	TimeRange *TimeRange `json:"timeRange"`
 	Times     int        `json:"times"`
 	Quantity  int        `json:"quantity"`
+	Lower     float64    `json:"lower"`
+	Upper     float64    `json:"upper"`
 }
 
 // Trend
@@ -114,3 +135,22 @@ type ItemTrend struct {
 	MinGroupID int        `json:"-"`
 	MaxGroupID int        `json:"-"`
 }
+
+// SiteStats
+type TotalSourceSubmissionsResult struct {
+	SourceName   string `json:"sourceName" bun:"source_name"`
+	TotalReports int    `json:"totalReports" bun:"total_reports"`
+	TotalTimes   int    `json:"totalTimes" bun:"total_times"`
+}
+
+// DatasetSnapshot
+type DailyDropAggregate struct {
+	// Day is the game day in the time zone of the server, e.g. `2022-05-01`
+	Day     string `json:"day" bun:"day"`
+	StageID string `json:"stageId" bun:"ark_stage_id"`
+	// ItemID is null when no item has dropped in any report of the stage on the day
+	ItemID null.String `json:"itemId" bun:"ark_item_id"`
+	// Times is the total times of the stage on the day, shared by all items of the stage
+	Times    int `json:"times" bun:"times"`
+	Quantity int `json:"quantity" bun:"quantity"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// DropReportRecall is the audit record of a recalled drop report.
+type DropReportRecall struct {
+	bun.BaseModel `bun:"drop_report_recalls,alias:drr"`
+
+	RecallID int `bun:",pk,autoincrement" json:"id"`
+	ReportID int `json:"reportId"`
+	// AccountID is the account which requested the recall. Null when the request is not authenticated.
+	AccountID null.Int    `json:"accountId" swaggertype:"integer"`
+	IP        string      `json:"ip"`
+	Reason    null.String `json:"reason" swaggertype:"string"`
+	CreatedAt *time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
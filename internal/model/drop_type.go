@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// DropType is a category drops are of, such as regular drops and furniture, as registered in the drop type
+// registry.
+type DropType struct {
+	bun.BaseModel `bun:"drop_types,alias:dt"`
+
+	// Name is the drop type in the database, which drop infos and drops of reports are of.
+	Name string `bun:"drop_type,pk" json:"dropType" example:"REGULAR"`
+	// APIName is the drop type in API responses.
+	APIName string `json:"apiName" example:"NORMAL_DROP"`
+	// Aliases are drop types reports could be submitted with besides APIName.
+	Aliases []string `bun:",array" json:"aliases" example:"REGULAR_DROP"`
+	// Reportable is whether drops of the type are accepted from reports. Drop types only known to recognizers, such
+	// as RECOGNITION_ONLY, are not.
+	Reportable bool `json:"reportable"`
+	// InPattern is whether drops of the type are counted in drop patterns, and hence in drop matrices and pattern
+	// matrices.
+	InPattern   bool   `json:"inPattern"`
+	Description string `json:"description"`
+	// Builtin is whether the drop type is one of the builtin drop types, which are registered even when not stored in
+	// the database.
+	Builtin   bool       `bun:"-" json:"builtin"`
+	UpdatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updatedAt"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// GameDataChange is a change to an item or a stage found by syncing from the external gamedata repository, staged
+// for a moderator to approve before being applied.
+type GameDataChange struct {
+	bun.BaseModel `bun:"game_data_changes,alias:gdc"`
+
+	ChangeID int `bun:",pk,autoincrement" json:"id"`
+	// Kind is the kind of the record changed, either "item" or "stage".
+	Kind string `json:"kind"`
+	// ArkID is the itemId or the stageId of the record changed.
+	ArkID string `json:"arkId"`
+	// Action is either "create" or "update".
+	Action string `json:"action"`
+	// Before holds fields of the record changed as they were when the change was staged. Null for creations.
+	Before json.RawMessage `bun:"type:jsonb" json:"before,omitempty" swaggertype:"object"`
+	// After is the record to be created, or the fields of the record to be updated, in the JSON form of the record.
+	After json.RawMessage `bun:"type:jsonb" json:"after" swaggertype:"object"`
+	// Status is one of "pending", "approved", "rejected" and "superseded".
+	Status string `json:"status"`
+	// Source is the URL the change was synced from.
+	Source     string     `json:"source"`
+	CreatedAt  *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+	ReviewedAt *time.Time `bun:",nullzero" json:"reviewedAt,omitempty"`
+}
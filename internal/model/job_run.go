@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// JobRun is the record of a run of a scheduled job.
+type JobRun struct {
+	bun.BaseModel `bun:"job_runs,alias:jr"`
+
+	RunID int    `bun:",pk,autoincrement" json:"id"`
+	Job   string `json:"job"`
+	// Trigger is what has started the run, one of constant.JobTrigger*.
+	Trigger string `json:"trigger"`
+	// Instance is the hostname of the instance the job has run on.
+	Instance  string     `json:"instance"`
+	StartedAt *time.Time `json:"startedAt"`
+	// DurationMs is the duration of the run in milliseconds. Null while the job is running.
+	DurationMs null.Int `json:"durationMs" swaggertype:"integer"`
+	// Result is the result of the run, one of constant.JobResult*.
+	Result string `json:"result"`
+	// Error is the error the run has failed with.
+	Error null.String `json:"error" swaggertype:"string"`
+}
+
+// JobStatus describes a job registered to the scheduler of an instance.
+type JobStatus struct {
+	Name string `json:"name"`
+	Spec string `json:"spec"`
+	// Enabled is false when the job has been disabled by configuration, in which case it is only run manually.
+	Enabled bool `json:"enabled"`
+	// LeaderOnly is whether the job is only run on schedule by the leader of the instances.
+	LeaderOnly bool `json:"leaderOnly"`
+	Running    bool `json:"running"`
+	// NextRunAt is when the job is run next on schedule. Null when the job is not scheduled.
+	NextRunAt *time.Time `json:"nextRunAt"`
+}
@@ -0,0 +1,12 @@
+package model
+
+import "github.com/uptrace/bun"
+
+// LockFence is the highest fencing token of a distributed lock which has written under it, so that writes of holders
+// which have lost the lock since could be refused. See dlock.Lock.
+type LockFence struct {
+	bun.BaseModel `bun:"lock_fences,alias:lf"`
+
+	Name  string `bun:",pk" json:"name"`
+	Token int64  `json:"token"`
+}
@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// MatrixWatermark is the high-water mark of drop reports a calculated matrix of a server has included, so that
+// the matrix could be refreshed incrementally with reports submitted afterwards only.
+type MatrixWatermark struct {
+	bun.BaseModel `bun:"matrix_watermarks,alias:mw"`
+
+	// Matrix is the name of the matrix, e.g. "drop"
+	Matrix string `bun:",pk" json:"matrix"`
+	Server string `bun:",pk" json:"server"`
+	// ReportID is the id of the latest drop report included in the matrix.
+	ReportID int `json:"reportId"`
+	// FullRefreshedAt is the time the matrix was last recalculated from all reports.
+	FullRefreshedAt *time.Time `json:"fullRefreshedAt"`
+	UpdatedAt       *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updatedAt"`
+}
+
+// TouchedStage describes the created_at range of reports of a stage submitted after a matrix watermark.
+type TouchedStage struct {
+	StageID      int       `bun:"stage_id"`
+	MinCreatedAt time.Time `bun:"min_created_at"`
+	MaxCreatedAt time.Time `bun:"max_created_at"`
+}
@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// MigrationState describes a migration of the database schema known to the binary.
+type MigrationState struct {
+	// Name is the timestamp the migration is named and ordered by.
+	Name    string `json:"name" example:"20261017000000"`
+	Applied bool   `json:"applied"`
+	// GroupID is the id of the run which has applied the migration, as migrations are rolled back by runs. Omitted
+	// when the migration is not applied.
+	GroupID    int64      `json:"groupId,omitempty"`
+	MigratedAt *time.Time `json:"migratedAt,omitempty"`
+	// Reversible is whether the migration has a down migration, i.e. whether it could be rolled back.
+	Reversible bool `json:"reversible"`
+}
+
+// MigrationStatus describes the migrations of the database schema, applied or not.
+type MigrationStatus struct {
+	Migrations []*MigrationState `json:"migrations"`
+	// Pending is the number of migrations known to the binary but not applied yet.
+	Pending int `json:"pending"`
+	// Unknown are names of migrations applied to the database but unknown to the binary, i.e. the database has been
+	// migrated by a newer binary.
+	Unknown []string `json:"unknown"`
+	// LastGroupID is the id of the latest run, which is the one rolled back next.
+	LastGroupID int64 `json:"lastGroupId"`
+	// Locked is whether migrations are being applied or rolled back right now. A lock left by a run which has crashed
+	// is released with `--unlock`.
+	Locked bool `json:"locked"`
+}
@@ -7,12 +7,14 @@ import (
 type PatternMatrixElement struct {
 	bun.BaseModel `bun:"pattern_matrix_elements,alias:pme"`
 
-	ElementID      int    `bun:",pk,autoincrement" json:"id"`
-	StageID        int    `json:"stageId"`
-	PatternID      int    `json:"patternId"`
-	RangeID        int    `json:"rangeId"`
-	Quantity       int    `json:"quantity"`
-	Times          int    `json:"times"`
-	Server         string `json:"server"`
-	SourceCategory string `json:"sourceCategory"` // sourceCategory can be: "automated", "manual", "all"
+	ElementID      int     `bun:",pk,autoincrement" json:"id"`
+	StageID        int     `json:"stageId"`
+	PatternID      int     `json:"patternId"`
+	RangeID        int     `json:"rangeId"`
+	Quantity       int     `json:"quantity"`
+	Times          int     `json:"times"`
+	Lower          float64 `json:"lower"` // lower bound of the Wilson score interval of the proportion of the pattern
+	Upper          float64 `json:"upper"` // upper bound of the Wilson score interval of the proportion of the pattern
+	Server         string  `json:"server"`
+	SourceCategory string  `json:"sourceCategory"` // sourceCategory can be: "automated", "manual", "all"
 }
@@ -0,0 +1,218 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.19.1
+// source: report.proto
+
+package protos
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// ReportServiceClient is the client API for ReportService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ReportServiceClient interface {
+	// SubmitSingularReport queues a singular report, like POST /PenguinStats/api/v2/report.
+	SubmitSingularReport(ctx context.Context, in *SingularReportRequest, opts ...grpc.CallOption) (*ReportResponse, error)
+	// SubmitBatchReport queues a batch of reports, like POST /PenguinStats/api/v2/report/recognition.
+	SubmitBatchReport(ctx context.Context, in *BatchReportRequest, opts ...grpc.CallOption) (*ReportResponse, error)
+	// SubmitBatchReportStream queues batches of reports sent over the stream, responding to each batch in the
+	// order they have been received. Failure of a batch does not terminate the stream.
+	SubmitBatchReportStream(ctx context.Context, opts ...grpc.CallOption) (ReportService_SubmitBatchReportStreamClient, error)
+}
+
+type reportServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReportServiceClient(cc grpc.ClientConnInterface) ReportServiceClient {
+	return &reportServiceClient{cc}
+}
+
+func (c *reportServiceClient) SubmitSingularReport(ctx context.Context, in *SingularReportRequest, opts ...grpc.CallOption) (*ReportResponse, error) {
+	out := new(ReportResponse)
+	err := c.cc.Invoke(ctx, "/ReportService/SubmitSingularReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reportServiceClient) SubmitBatchReport(ctx context.Context, in *BatchReportRequest, opts ...grpc.CallOption) (*ReportResponse, error) {
+	out := new(ReportResponse)
+	err := c.cc.Invoke(ctx, "/ReportService/SubmitBatchReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reportServiceClient) SubmitBatchReportStream(ctx context.Context, opts ...grpc.CallOption) (ReportService_SubmitBatchReportStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &ReportService_ServiceDesc.Streams[0], "/ReportService/SubmitBatchReportStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &reportServiceSubmitBatchReportStreamClient{stream}
+	return x, nil
+}
+
+type ReportService_SubmitBatchReportStreamClient interface {
+	Send(*BatchReportRequest) error
+	Recv() (*ReportResponse, error)
+	grpc.ClientStream
+}
+
+type reportServiceSubmitBatchReportStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *reportServiceSubmitBatchReportStreamClient) Send(m *BatchReportRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *reportServiceSubmitBatchReportStreamClient) Recv() (*ReportResponse, error) {
+	m := new(ReportResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReportServiceServer is the server API for ReportService service.
+// All implementations must embed UnimplementedReportServiceServer
+// for forward compatibility
+type ReportServiceServer interface {
+	// SubmitSingularReport queues a singular report, like POST /PenguinStats/api/v2/report.
+	SubmitSingularReport(context.Context, *SingularReportRequest) (*ReportResponse, error)
+	// SubmitBatchReport queues a batch of reports, like POST /PenguinStats/api/v2/report/recognition.
+	SubmitBatchReport(context.Context, *BatchReportRequest) (*ReportResponse, error)
+	// SubmitBatchReportStream queues batches of reports sent over the stream, responding to each batch in the
+	// order they have been received. Failure of a batch does not terminate the stream.
+	SubmitBatchReportStream(ReportService_SubmitBatchReportStreamServer) error
+	mustEmbedUnimplementedReportServiceServer()
+}
+
+// UnimplementedReportServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedReportServiceServer struct {
+}
+
+func (UnimplementedReportServiceServer) SubmitSingularReport(context.Context, *SingularReportRequest) (*ReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitSingularReport not implemented")
+}
+func (UnimplementedReportServiceServer) SubmitBatchReport(context.Context, *BatchReportRequest) (*ReportResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitBatchReport not implemented")
+}
+func (UnimplementedReportServiceServer) SubmitBatchReportStream(ReportService_SubmitBatchReportStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubmitBatchReportStream not implemented")
+}
+func (UnimplementedReportServiceServer) mustEmbedUnimplementedReportServiceServer() {}
+
+// UnsafeReportServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReportServiceServer will
+// result in compilation errors.
+type UnsafeReportServiceServer interface {
+	mustEmbedUnimplementedReportServiceServer()
+}
+
+func RegisterReportServiceServer(s grpc.ServiceRegistrar, srv ReportServiceServer) {
+	s.RegisterService(&ReportService_ServiceDesc, srv)
+}
+
+func _ReportService_SubmitSingularReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SingularReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReportServiceServer).SubmitSingularReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ReportService/SubmitSingularReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReportServiceServer).SubmitSingularReport(ctx, req.(*SingularReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReportService_SubmitBatchReport_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReportServiceServer).SubmitBatchReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ReportService/SubmitBatchReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReportServiceServer).SubmitBatchReport(ctx, req.(*BatchReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ReportService_SubmitBatchReportStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ReportServiceServer).SubmitBatchReportStream(&reportServiceSubmitBatchReportStreamServer{stream})
+}
+
+type ReportService_SubmitBatchReportStreamServer interface {
+	Send(*ReportResponse) error
+	Recv() (*BatchReportRequest, error)
+	grpc.ServerStream
+}
+
+type reportServiceSubmitBatchReportStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *reportServiceSubmitBatchReportStreamServer) Send(m *ReportResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *reportServiceSubmitBatchReportStreamServer) Recv() (*BatchReportRequest, error) {
+	m := new(BatchReportRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ReportService_ServiceDesc is the grpc.ServiceDesc for ReportService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ReportService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ReportService",
+	HandlerType: (*ReportServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitSingularReport",
+			Handler:    _ReportService_SubmitSingularReport_Handler,
+		},
+		{
+			MethodName: "SubmitBatchReport",
+			Handler:    _ReportService_SubmitBatchReport_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubmitBatchReportStream",
+			Handler:       _ReportService_SubmitBatchReportStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "report.proto",
+}
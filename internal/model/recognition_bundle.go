@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// RecognitionBundle is a bundle of item recognition definitions, addressed by the SHA-256 hash of its content, which
+// the recognition frontend and other recognizers such as MAA fetch to recognize drops from screenshots.
+type RecognitionBundle struct {
+	bun.BaseModel `bun:"recognition_bundles,alias:rb"`
+
+	// Hash is the hex-encoded SHA-256 hash of Content.
+	Hash string `bun:",pk" json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	// Version is a human-readable label of the bundle, e.g. the version of the recognizer it is built for.
+	Version     string `json:"version" example:"4.2.0"`
+	ContentType string `json:"contentType" example:"application/zip"`
+	Size        int64  `json:"size" example:"1048576"`
+	// Content is omitted when bundles are listed.
+	Content   []byte     `json:"-"`
+	CreatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
+
+// RecognitionBundleContent is the content of a RecognitionBundle, which is cached as bundles never change.
+type RecognitionBundleContent struct {
+	ContentType string `json:"contentType"`
+	Content     []byte `json:"content"`
+}
+
+// RecognitionRelease points a server to the latest recognition bundle of it.
+type RecognitionRelease struct {
+	bun.BaseModel `bun:"recognition_releases,alias:rr"`
+
+	Server    string     `bun:",pk" json:"server" example:"CN"`
+	Hash      string     `json:"hash"`
+	UpdatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updatedAt"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// RejectedReportTask is a report task dead-lettered after the report worker failed to consume it.
+type RejectedReportTask struct {
+	bun.BaseModel `bun:"rejected_report_tasks,alias:rrt"`
+
+	RejectedTaskID int    `bun:",pk,autoincrement" json:"id"`
+	TaskID         string `json:"taskId"`
+	// Subject is the NATS subject the task has been originally published to, e.g. "REPORT.SINGLE".
+	Subject string `json:"subject"`
+	// Task is the original message of the task.
+	Task json.RawMessage `bun:"type:jsonb" json:"task" swaggertype:"object"`
+	// Error is the error occurred in the last attempt of consuming the task.
+	Error     string     `json:"error"`
+	Attempts  int        `json:"attempts"`
+	CreatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// ReportGate matches reports from a source within a version range, and allows, rejects or down-weights them
+// according to Action. See constant.ReportGateActionAllow and its siblings.
+type ReportGate struct {
+	bun.BaseModel `bun:"report_gates,alias:rg"`
+
+	GateID int    `bun:",pk,autoincrement" json:"id"`
+	Source string `json:"source" example:"MeoAssistant"`
+	// VersionFrom is the lowest version matched, inclusive. Null when there is no lower bound.
+	VersionFrom null.String `json:"versionFrom" swaggertype:"string" example:"v3.0.0"`
+	// VersionBefore is the version after the highest version matched, exclusive. Null when there is no upper bound.
+	VersionBefore null.String `json:"versionBefore" swaggertype:"string" example:"v3.10.4"`
+	Action        string      `json:"action" example:"reject"`
+	// Reason describes why reports are gated, and is disclosed to submitters of rejected reports.
+	Reason    string     `json:"reason"`
+	CreatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+// ReportPurge is the audit record of a bulk purge of drop reports.
+type ReportPurge struct {
+	bun.BaseModel `bun:"report_purges,alias:rp"`
+
+	PurgeID int                      `bun:",pk,autoincrement" json:"id"`
+	Filter  *types.ReportPurgeFilter `bun:"type:jsonb" json:"filter"`
+	Reason  string                   `json:"reason"`
+	// IP is the IP of the admin who requested the purge.
+	IP              string `json:"ip"`
+	AffectedReports int    `json:"affectedReports"`
+	// RefreshID is the id of the drop matrix refresh started after the purge. Null when no report is affected.
+	RefreshID null.String `json:"refreshId" swaggertype:"string"`
+	CreatedAt *time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
+
+// DropReportFilter selects drop reports of Server created within [StartTime, EndTime).
+type DropReportFilter struct {
+	Server    string
+	StartTime time.Time
+	EndTime   time.Time
+	AccountID null.Int
+	StageID   null.Int
+	// IPRange is an IP range in CIDR notation.
+	IPRange    null.String
+	SourceName null.String
+	Version    null.String
+}
+
+// PurgedStage describes reports of a stage purged, and the range of their created_at.
+type PurgedStage struct {
+	StageID      int       `bun:"stage_id"`
+	Reports      int       `bun:"reports"`
+	MinCreatedAt time.Time `bun:"min_created_at"`
+	MaxCreatedAt time.Time `bun:"max_created_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// ShadowBan bans either an account or an IP range. Reports from banned submitters are accepted as usual, but
+// persisted with a reliability which excludes them from any calculation.
+type ShadowBan struct {
+	bun.BaseModel `bun:"shadow_bans,alias:sb"`
+
+	BanID     int      `bun:",pk,autoincrement" json:"id"`
+	AccountID null.Int `json:"accountId" swaggertype:"integer"`
+	// IPRange is the banned IP range in CIDR notation, e.g. "192.0.2.0/24".
+	IPRange   null.String `json:"ipRange" swaggertype:"string"`
+	Reason    string      `json:"reason"`
+	CreatedAt *time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+	// ExpiresAt is when the ban is lifted. Null when the ban never expires.
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// StageRewriteRule rewrites the stageId of reports affected by a known client-side defect, before they enter
+// the report pipeline. Rules are evaluated in order of RuleID, and only the first matching one is applied.
+type StageRewriteRule struct {
+	bun.BaseModel `bun:"stage_rewrite_rules,alias:srr"`
+
+	RuleID int `bun:",pk,autoincrement" json:"id"`
+	// Name uniquely identifies the rule, and is exposed by the mitigation preview API.
+	Name string `json:"name" example:"maa-act18d3-perm-as-rep"`
+	// Source is the report source matched. Null when reports from any source are matched.
+	Source null.String `json:"source" swaggertype:"string" example:"MeoAssistant"`
+	// VersionFrom and VersionBefore bound the versions matched to [VersionFrom, VersionBefore). A null bound
+	// is unbounded.
+	VersionFrom   null.String `json:"versionFrom" swaggertype:"string"`
+	VersionBefore null.String `json:"versionBefore" swaggertype:"string"`
+	// StageIDPattern is the regular expression stageIds matched shall match.
+	StageIDPattern string `bun:"stage_id_pattern" json:"stageIdPattern" example:"^act18d3_(0[1-9])_perm$"`
+	// Rewrite is the template the matched stageId is rewritten to, which could refer to submatches of
+	// StageIDPattern as in regexp.Regexp.Expand, e.g. `${1}`.
+	Rewrite string `json:"rewrite" example:"act18d3_${1}_rep"`
+	// ActiveFrom and ActiveUntil bound the time reports matched are submitted at to [ActiveFrom, ActiveUntil).
+	// A null bound is unbounded.
+	ActiveFrom  *time.Time `json:"activeFrom"`
+	ActiveUntil *time.Time `json:"activeUntil"`
+	CreatedAt   *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+}
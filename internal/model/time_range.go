@@ -20,6 +20,15 @@ type TimeRange struct {
 	Server    string      `json:"server"`
 }
 
+// TimeRangeSplit is the result of splitting a time range of a stage: drop infos of the stage are of Before until
+// the split, and of After since then. VacatedRangeID is the time range the stage has been moved out of, if it is
+// shared with other stages and has been left intact, or 0 if it has been split in place.
+type TimeRangeSplit struct {
+	Before         *TimeRange `json:"before"`
+	After          *TimeRange `json:"after"`
+	VacatedRangeID int        `json:"vacatedRangeId,omitempty"`
+}
+
 func (tr *TimeRange) String() string {
 	return strconv.FormatInt(tr.StartTime.UnixMilli(), 10) + "-" + strconv.FormatInt(tr.EndTime.UnixMilli(), 10)
 }
@@ -19,4 +19,5 @@ type TrendElement struct {
 	Times          int        `json:"times"`
 	Server         string     `json:"server"`
 	SourceCategory string     `json:"sourceCategory"` // sourceCategory can be: "automated", "manual", "all"
+	Granularity    string     `json:"granularity"`    // granularity can be: "hour", "day", "week"
 }
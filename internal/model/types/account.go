@@ -0,0 +1,18 @@
+package types
+
+// OAuthCallbackRequest completes an OAuth authorization with the code and the state the identity provider
+// redirected back with.
+type OAuthCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+type OAuthAuthorizeResponse struct {
+	// URL is the URL of the identity provider to redirect the user to.
+	URL string `json:"url"`
+}
+
+type APIKeyCreateRequest struct {
+	// Name is a free-form description of what the API key is used for.
+	Name string `json:"name" validate:"required,max=64" example:"MAA on my phone"`
+}
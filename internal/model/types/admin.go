@@ -35,3 +35,216 @@ type PurgeCachePair struct {
 	Name string      `json:"name"`
 	Key  null.String `json:"key" swaggertype:"string"`
 }
+
+// ShadowBanRequest bans either AccountID or IPRange.
+type ShadowBanRequest struct {
+	AccountID int `json:"accountId" validate:"required_without=IPRange,excluded_with=IPRange"`
+	// IPRange is an IP range in CIDR notation, or a single IP.
+	IPRange string `json:"ipRange" validate:"required_without=AccountID"`
+	Reason  string `json:"reason" validate:"required"`
+	// ExpiresAt is when the ban is lifted, in milliseconds since the epoch. The ban never expires when omitted.
+	ExpiresAt int64 `json:"expiresAt" validate:"omitempty,gt=0"`
+}
+
+// ReportGateRequest gates reports from Source within [VersionFrom, VersionBefore). The version range is unbounded
+// on either side when omitted.
+type ReportGateRequest struct {
+	Source        string `json:"source" validate:"required" example:"MeoAssistant"`
+	VersionFrom   string `json:"versionFrom" validate:"omitempty,semverprefixed" example:"v3.0.0"`
+	VersionBefore string `json:"versionBefore" validate:"omitempty,semverprefixed" example:"v3.10.4"`
+	Action        string `json:"action" validate:"required,oneof=allow reject downweight" example:"reject"`
+	Reason        string `json:"reason" validate:"required"`
+}
+
+// StageRewriteRuleRequest rewrites stageIds matching StageIDPattern to Rewrite, for reports from Source within
+// [VersionFrom, VersionBefore), submitted within [ActiveFrom, ActiveUntil). Each bound is unbounded when omitted,
+// and reports from any source are matched when Source is omitted.
+type StageRewriteRuleRequest struct {
+	Name           string `json:"name" validate:"required,max=64" example:"maa-act18d3-perm-as-rep"`
+	Source         string `json:"source" example:"MeoAssistant"`
+	VersionFrom    string `json:"versionFrom" validate:"omitempty,semverprefixed"`
+	VersionBefore  string `json:"versionBefore" validate:"omitempty,semverprefixed"`
+	StageIDPattern string `json:"stageIdPattern" validate:"required" example:"^act18d3_(0[1-9])_perm$"`
+	Rewrite        string `json:"rewrite" validate:"required" example:"act18d3_${1}_rep"`
+	// ActiveFrom and ActiveUntil are in milliseconds since the epoch.
+	ActiveFrom  int64 `json:"activeFrom" validate:"omitempty,gt=0"`
+	ActiveUntil int64 `json:"activeUntil" validate:"omitempty,gt=0"`
+}
+
+// MatrixRefreshRequest refreshes the drop matrix of a stage within time ranges overlapping [StartTime, EndTime).
+type MatrixRefreshRequest struct {
+	Server string `json:"server" validate:"required,oneof=CN US JP KR"`
+	// StageID is the ark stage id of the stage to refresh.
+	StageID string `json:"stageId" validate:"required"`
+	// StartTime is in milliseconds since the epoch. Refreshes since the earliest time range when omitted.
+	StartTime int64 `json:"startTime" validate:"omitempty,gt=0"`
+	// EndTime is in milliseconds since the epoch. Refreshes until now when omitted.
+	EndTime int64 `json:"endTime" validate:"omitempty,gtfield=StartTime"`
+}
+
+// TimeRangeSplitRequest splits time range RangeID of a stage at At, e.g. when drop rates of the stage have changed
+// in the middle of an event, so that reports before and after At are aggregated separately.
+type TimeRangeSplitRequest struct {
+	Server string `json:"server" validate:"required,oneof=CN US JP KR"`
+	// StageID is the ark stage id of the stage to split the time range of.
+	StageID string `json:"stageId" validate:"required"`
+	RangeID int    `json:"rangeId" validate:"required"`
+	// At is in milliseconds since the epoch, and shall be within the time range.
+	At int64 `json:"at" validate:"required,gt=0"`
+}
+
+// MatrixRefreshProgress is published to the NATS subject of a matrix refresh as it proceeds.
+type MatrixRefreshProgress struct {
+	RefreshID string `json:"refreshId"`
+	Server    string `json:"server"`
+	// StageID is the ark stage id of the stage refreshed. Omitted when multiple stages are refreshed.
+	StageID string `json:"stageId,omitempty"`
+	State   string `json:"state"`
+	// Done is the number of time ranges refreshed, out of Total.
+	Done  int    `json:"done"`
+	Total int    `json:"total"`
+	Error string `json:"error,omitempty"`
+}
+
+type MatrixRefreshResponse struct {
+	RefreshID string `json:"refreshId"`
+	// Subject is the NATS subject progress of the refresh is published to.
+	Subject string `json:"subject"`
+}
+
+// PatternDuplicate is a group of drop patterns of the same drops, merged into the pattern of SurvivorID.
+type PatternDuplicate struct {
+	// Hash and Fingerprint are the canonical hash and fingerprint of the drops.
+	Hash        string `json:"hash"`
+	Fingerprint string `json:"fingerprint"`
+	// SurvivorID is the id of the pattern kept. When no pattern holds the canonical hash, a pattern of the canonical
+	// hash is created on dedup, which the pattern previewed as the survivor is merged into as well.
+	SurvivorID int `json:"survivorId"`
+	// DuplicateIDs are ids of patterns merged into the survivor. Empty when only the fingerprint or the elements of
+	// the survivor are not canonical.
+	DuplicateIDs []int `json:"duplicateIds"`
+}
+
+type PatternDedupResult struct {
+	Duplicates []*PatternDuplicate `json:"duplicates"`
+	// MergedPatterns is the number of duplicate patterns deleted, and RewrittenReports the number of drop reports
+	// pointed to survivors instead.
+	MergedPatterns   int   `json:"mergedPatterns"`
+	RewrittenReports int64 `json:"rewrittenReports"`
+}
+
+// ReportPurgeFilter selects reliable drop reports of Server created within [StartTime, EndTime). All filters given
+// are combined, and at least one of AccountID, IPRange, Source and StageID is required.
+type ReportPurgeFilter struct {
+	Server    string `json:"server" validate:"required,oneof=CN US JP KR"`
+	AccountID int    `json:"accountId,omitempty" validate:"omitempty,gt=0"`
+	// IPRange is an IP range in CIDR notation, or a single IP.
+	IPRange string `json:"ipRange,omitempty"`
+	// Source and Version match the source name and version of the client which submitted the report.
+	Source  string `json:"source,omitempty"`
+	Version string `json:"version,omitempty" validate:"excluded_without=Source"`
+	// StageID is the ark stage id of the stage of the report.
+	StageID string `json:"stageId,omitempty"`
+	// StartTime is in milliseconds since the epoch.
+	StartTime int64 `json:"startTime" validate:"required,gt=0"`
+	// EndTime is in milliseconds since the epoch. Purges until now when omitted.
+	EndTime int64 `json:"endTime,omitempty" validate:"omitempty,gtfield=StartTime"`
+}
+
+// ReportBrowseQuery searches drop reports for abuse investigations, in the query string. All filters given are
+// combined, and reports are not filtered on those omitted.
+type ReportBrowseQuery struct {
+	Server    string `query:"server" validate:"omitempty,oneof=CN US JP KR"`
+	AccountID int    `query:"accountId" validate:"omitempty,gt=0"`
+	PenguinID string `query:"penguinId" validate:"excluded_with=AccountID"`
+	// IP is an IP range in CIDR notation, or a single IP.
+	IP     string `query:"ip"`
+	Source string `query:"source"`
+	// StageID is the ark stage id of the stage of the report.
+	StageID     string `query:"stageId"`
+	Reliability string `query:"reliability" validate:"omitempty,number"`
+	// StartTime and EndTime are in milliseconds since the epoch.
+	StartTime int64 `query:"startTime" validate:"omitempty,gt=0"`
+	EndTime   int64 `query:"endTime" validate:"omitempty,gtfield=StartTime"`
+}
+
+type ReportPurgeRequest struct {
+	Filter ReportPurgeFilter `json:"filter" validate:"required"`
+	Reason string            `json:"reason" validate:"required"`
+	// DryRun only counts the reports which would be purged, without purging them.
+	DryRun bool `json:"dryRun"`
+}
+
+type ReportPurgeResponse struct {
+	// PurgeID is the id of the audit record of the purge. Omitted for dry runs.
+	PurgeID         int `json:"purgeId,omitempty"`
+	AffectedReports int `json:"affectedReports"`
+	// Stages are ark stage ids of stages with reports purged.
+	Stages []string `json:"stages"`
+	// RefreshID and Subject describe the drop matrix refresh of the stages affected, as in MatrixRefreshResponse.
+	RefreshID string `json:"refreshId,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+}
+
+// ReportRestoreResponse describes a drop report restored after having been recalled or purged.
+type ReportRestoreResponse struct {
+	ReportID    int `json:"reportId"`
+	Reliability int `json:"reliability"`
+	// RefreshID and Subject describe the drop matrix refresh of the stage of the report, as in MatrixRefreshResponse.
+	RefreshID string `json:"refreshId"`
+	Subject   string `json:"subject"`
+}
+
+// CacheInvalidation is published to constant.CacheInvalidationSubject when the version of a cached aggregate is bumped.
+type CacheInvalidation struct {
+	Name    string `json:"name"`
+	Key     string `json:"key"`
+	Version int64  `json:"version"`
+}
+
+// FeatureFlagRequest creates or replaces a feature flag. See featureflag.Flag.
+type FeatureFlagRequest struct {
+	Enabled bool `json:"enabled"`
+	// Servers limits the flag to these servers. The flag is enabled on all servers when omitted.
+	Servers []string `json:"servers" validate:"dive,oneof=CN US JP KR" example:"CN,US"`
+	// Percentage is the percentage of subjects the flag is enabled for, from 0 to 100.
+	Percentage  int    `json:"percentage" validate:"min=0,max=100" example:"100"`
+	Description string `json:"description"`
+}
+
+// AuditLogQuery searches the audit log of admin mutations, in the query string. All filters given are combined.
+type AuditLogQuery struct {
+	Actor string `query:"actor"`
+	// Action is the method and the route of requests, e.g. `PATCH /api/admin/gamedata/items/:itemId`, or only the
+	// route to match requests of all methods.
+	Action string `query:"action"`
+	// StartTime and EndTime are in milliseconds since the epoch.
+	StartTime int64 `query:"startTime" validate:"omitempty,gt=0"`
+	EndTime   int64 `query:"endTime" validate:"omitempty,gtfield=StartTime"`
+	// Before lists records older than the record of this id, i.e. the id of the last record of the previous page.
+	Before int64 `query:"before" validate:"omitempty,gt=0"`
+	Limit  int   `query:"limit" validate:"omitempty,gt=0,lte=500"`
+}
+
+// WebhookRequest creates a webhook, or replaces the URL, events, description and whether it is enabled of a webhook.
+type WebhookRequest struct {
+	URL    string   `json:"url" validate:"required,url,startswith=http,max=2048" example:"https://example.com/penguin/webhook"`
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=activity.published matrix.refreshed anomaly.detected" example:"matrix.refreshed"`
+	// Enabled defaults to true when omitted.
+	Enabled     *bool  `json:"enabled"`
+	Description string `json:"description" validate:"max=256"`
+}
+
+// RecognitionReleaseRequest makes an uploaded recognition bundle the latest one of a server.
+type RecognitionReleaseRequest struct {
+	Hash string `json:"hash" validate:"required,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// DropTypeRequest registers a drop type, or replaces a registered one.
+type DropTypeRequest struct {
+	APIName     string   `json:"apiName" validate:"required,max=32,printascii" example:"NORMAL_DROP"`
+	Aliases     []string `json:"aliases" validate:"max=8,dive,required,max=32,printascii" example:"REGULAR_DROP"`
+	Reportable  bool     `json:"reportable"`
+	InPattern   bool     `json:"inPattern"`
+	Description string   `json:"description" validate:"max=256"`
+}
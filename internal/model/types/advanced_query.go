@@ -15,3 +15,23 @@ type AdvancedQuery struct {
 	EndTime    null.Int  `json:"end" swaggertype:"integer"`
 	Interval   null.Int  `json:"interval" swaggertype:"integer"`
 }
+
+// AdvancedQueryV3Request is a batch of advanced queries executed together. Queries on the same server, time range,
+// interval and source share the same calculation.
+type AdvancedQueryV3Request struct {
+	Queries []*AdvancedQueryV3 `json:"queries" validate:"required,min=1,max=20,dive"`
+}
+
+type AdvancedQueryV3 struct {
+	// ID is an optional id of the query, echoed in its results so that clients could tell them apart.
+	ID       string   `json:"id,omitempty" validate:"max=64"`
+	Servers  []string `json:"servers" validate:"required,min=1,max=4,unique,dive,oneof=CN US JP KR" required:"true"`
+	StageIDs []string `json:"stageIds" validate:"required,min=1,max=50,dive,required" required:"true"`
+	// ItemIDs limits the results to these items. All items are included when empty.
+	ItemIDs    []string `json:"itemIds" validate:"max=100,dive,required"`
+	IsPersonal bool     `json:"isPersonal"`
+	StartTime  null.Int `json:"start" swaggertype:"integer"`
+	EndTime    null.Int `json:"end" swaggertype:"integer"`
+	// Interval buckets the results into a trend of intervals of this length, in milliseconds, when specified.
+	Interval null.Int `json:"interval" swaggertype:"integer"`
+}
@@ -0,0 +1,16 @@
+package types
+
+// StageEfficiencyQuery ranks stages by the sanity spent per item of ItemID dropped on Server. Drop rates are of the
+// drop matrix unless either StartTime or EndTime is given, in which case they are of reports within [StartTime,
+// EndTime), in milliseconds since the epoch, defaulting to the start of the server and now respectively.
+type StageEfficiencyQuery struct {
+	Server    string `query:"server"`
+	ItemID    string `query:"-" validate:"required"`
+	StartTime int64  `query:"start" validate:"omitempty,gt=0"`
+	EndTime   int64  `query:"end" validate:"omitempty,gt=0"`
+	// MinTimes leaves out stages sampled fewer times, defaulting to the low sample threshold of drop matrices.
+	MinTimes int `query:"min_times" validate:"omitempty,gt=0"`
+	// Limit is the number of stages ranked, defaulting to 10.
+	Limit           int  `query:"limit" validate:"omitempty,gt=0,lte=100"`
+	ShowClosedZones bool `query:"show_closed_zones"`
+}
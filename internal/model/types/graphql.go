@@ -0,0 +1,7 @@
+package types
+
+type GraphQLRequest struct {
+	Query         string         `json:"query" validate:"required" required:"true"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables" swaggertype:"object"`
+}
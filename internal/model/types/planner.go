@@ -0,0 +1,14 @@
+package types
+
+// PlanRequest requests a farming plan obtaining Requirements on Server with the least sanity expected.
+type PlanRequest struct {
+	Server       string             `json:"server" validate:"required,oneof=CN US JP KR" example:"CN"`
+	Requirements []*PlanRequirement `json:"requirements" validate:"required,min=1,max=50,dive,required"`
+	// ExcludeStages are ark stage ids of stages not to be farmed.
+	ExcludeStages []string `json:"excludeStages" validate:"max=200" example:"main_01-07"`
+}
+
+type PlanRequirement struct {
+	ItemID   string `json:"itemId" validate:"required" example:"30012"`
+	Quantity int    `json:"quantity" validate:"required,gt=0,lte=1000000" example:"100"`
+}
@@ -0,0 +1,14 @@
+package types
+
+// RateLimitStatus is the state of the token bucket a request has been counted towards.
+type RateLimitStatus struct {
+	// Limit is the capacity of the bucket.
+	Limit int
+	// Remaining is the number of requests which could still be made right away.
+	Remaining int
+	// Reset is the number of seconds until the bucket is full again.
+	Reset int
+	// RetryAfter is the number of seconds until the next request would be allowed. Zero when allowed.
+	RetryAfter int
+	Allowed    bool
+}
@@ -1,7 +1,7 @@
 package types
 
 type ArkDrop struct {
-	DropType string `json:"dropType" validate:"required,oneof=REGULAR_DROP NORMAL_DROP SPECIAL_DROP EXTRA_DROP FURNITURE"`
+	DropType string `json:"dropType" validate:"required,max=32,printascii" example:"NORMAL_DROP"`
 	ItemID   string `json:"itemId" validate:"required,printascii" example:"30013"`
 	Quantity int    `json:"quantity" validate:"required,lte=1000"`
 }
@@ -18,12 +18,33 @@ type SingleReportRequest struct {
 
 	Drops     []ArkDrop `json:"drops" validate:"dive"`
 	PenguinID string    `json:"-"`
+	// Times is the number of runs the drops are aggregated from, for clients farming the same stage repeatedly.
+	// Defaults to 1 when omitted.
+	Times int `json:"times,omitempty" validate:"omitempty,gte=1,lte=6" example:"1"`
 
 	Metadata *ReportRequestMetadata `json:"metadata" validate:"omitempty,dive"`
 }
 
 type SingleReportRecallRequest struct {
 	ReportHash string `json:"reportHash" validate:"required,printascii" example:"0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"`
+	// Reason is an optional free-form description of why the report is recalled, kept for moderation.
+	Reason string `json:"reason,omitempty" validate:"max=256"`
+}
+
+type BatchReportRecallRequest struct {
+	ReportHashes []string `json:"reportHashes" validate:"required,min=1,max=100,dive,required,printascii" example:"0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"`
+	// Reason is an optional free-form description of why the reports are recalled, kept for moderation.
+	Reason string `json:"reason,omitempty" validate:"max=256"`
+}
+
+// ReportCorrectionRequest corrects the drops of a recently submitted report, which is then superseded by the
+// corrected report.
+type ReportCorrectionRequest struct {
+	Drops []ArkDrop `json:"drops" validate:"dive"`
+	// Times is the number of runs the drops are aggregated from. Defaults to 1 when omitted.
+	Times int `json:"times,omitempty" validate:"omitempty,gte=1,lte=6" example:"1"`
+	// Reason is an optional free-form description of what has been corrected, kept for moderation.
+	Reason string `json:"reason,omitempty" validate:"max=256"`
 }
 
 type BatchReportDrop struct {
@@ -38,18 +59,81 @@ type ReportRequestMetadata struct {
 	MD5          string `json:"md5,omitempty" validate:"lte=32" swaggertype:"string"`
 	FileName     string `json:"fileName,omitempty" validate:"lte=512"`
 	LastModified int    `json:"lastModified,omitempty"`
+	// ItemCount is the total quantity of items recognized from the screenshot, which shall match the drops.
+	ItemCount int `json:"itemCount,omitempty" validate:"gte=0"`
 
 	RecognizerVersion       string `json:"recognizerVersion,omitempty" validate:"omitempty,lte=32,semverprefixed" swaggertype:"string"`
 	RecognizerAssetsVersion string `json:"recognizerAssetsVersion,omitempty" validate:"omitempty,lte=32,semverprefixed" swaggertype:"string"`
+
+	// Recognition is the raw output of the recognizer, which is cross-checked against the drops on submission.
+	// Reports with recognition output of low confidence are refused or down-weighted.
+	Recognition *RecognitionOutput `json:"recognition,omitempty" validate:"omitempty"`
+}
+
+// RecognitionOutput is the raw output of recognizing the screenshot of a report.
+type RecognitionOutput struct {
+	// Items are items recognized from the screenshot, one per item slot.
+	Items []*RecognizedItem `json:"items" validate:"max=64,dive,required"`
+}
+
+type RecognizedItem struct {
+	DropType string `json:"dropType" validate:"required,max=32,printascii" example:"NORMAL_DROP"`
+	ItemID   string `json:"itemId" validate:"required,printascii" example:"30013"`
+	Quantity int    `json:"quantity" validate:"required,lte=1000"`
+	// Confidence is how confident the recognizer is of the item, from 0 to 1.
+	Confidence float64 `json:"confidence" validate:"gte=0,lte=1" example:"0.98"`
+	// QuantityConfidence is how confident the recognizer is of the quantity, from 0 to 1.
+	QuantityConfidence float64 `json:"quantityConfidence" validate:"gte=0,lte=1" example:"0.95"`
+	// Box is where the item has been recognized in the screenshot.
+	Box RecognitionBox `json:"box"`
+}
+
+// RecognitionBox is a bounding box in the screenshot, in pixels from the top left corner.
+type RecognitionBox struct {
+	X      int `json:"x" validate:"gte=0"`
+	Y      int `json:"y" validate:"gte=0"`
+	Width  int `json:"width" validate:"gt=0"`
+	Height int `json:"height" validate:"gt=0"`
 }
 
 type BatchReportRequest struct {
 	FragmentReportCommon
 
-	BatchDrops []BatchReportDrop `json:"batchDrops" validate:"dive"`
+	BatchDrops []BatchReportDrop `json:"batchDrops" validate:"min=1,dive"`
 }
 
 type BatchReportError struct {
 	Index  int    `json:"index"`
 	Reason string `json:"reason,omitempty"`
 }
+
+// ReportCorrectionSuggestion is a machine-readable suggestion attached to the error response of a rejected
+// report, which clients could apply to correct the report and retry.
+type ReportCorrectionSuggestion struct {
+	// StageID is the canonical stage id, suggested when the reported `stageId` is not a canonical one.
+	StageID string `json:"stageId,omitempty" example:"main_01-07"`
+	// MinimumVersion is the minimum accepted version of the source, suggested when the reported `version` is outdated.
+	MinimumVersion string `json:"minimumVersion,omitempty" example:"v3.0.0"`
+	// DropTypes lists the correct drop types, for those drops with an incorrect `dropType`.
+	DropTypes []*DropTypeSuggestion `json:"dropTypes,omitempty"`
+}
+
+type DropTypeSuggestion struct {
+	// Index is the index of the drop in `drops` of the report request.
+	Index    int    `json:"index"`
+	DropType string `json:"dropType" example:"NORMAL_DROP"`
+}
+
+// ReportImportRecord is a single historical report to be imported in bulk, one JSON object per line.
+type ReportImportRecord struct {
+	FragmentStageID
+	FragmentReportCommon
+
+	Drops    []ArkDrop              `json:"drops" validate:"dive"`
+	Metadata *ReportRequestMetadata `json:"metadata" validate:"omitempty,dive"`
+
+	AccountID int    `json:"accountId" validate:"required"`
+	IP        string `json:"ip"`
+	// CreatedAt is the time the report was originally submitted, in milliseconds since the epoch.
+	CreatedAt int64 `json:"createdAt" validate:"required"`
+}
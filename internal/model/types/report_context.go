@@ -1,5 +1,7 @@
 package types
 
+import "encoding/json"
+
 type ReportTaskSingleReport struct {
 	FragmentStageID
 
@@ -8,6 +10,21 @@ type ReportTaskSingleReport struct {
 
 	// Metadata is optional
 	Metadata *ReportRequestMetadata `json:"metadata" validate:"dive"`
+
+	// Supersedes is the id of the report this report corrects, which is superseded once this report is persisted
+	// as reliable.
+	// Zero when the report is not a correction.
+	Supersedes int `json:"supersedes,omitempty"`
+	// CorrectionReason is the reason of the correction given by the submitter, if any.
+	CorrectionReason string `json:"correctionReason,omitempty"`
+
+	// RecognitionFlag is why the recognition output of the report is of low confidence, as judged on submission.
+	// Flagged reports are persisted but down-weighted. Empty when the report is not flagged.
+	RecognitionFlag string `json:"recognitionFlag,omitempty"`
+
+	// FraudScore is how likely the report is fake, from 0 to 1, as scored by the external scoring service once
+	// verified. Nil when the report has not been scored.
+	FraudScore *float64 `json:"fraudScore,omitempty"`
 }
 
 type ReportTask struct {
@@ -20,4 +37,21 @@ type ReportTask struct {
 
 	AccountID int    `json:"accountId"`
 	IP        string `json:"ip"`
+	// APIKeyID is the id of the API key the task has been submitted with. Zero when not submitted with an API key.
+	APIKeyID int `json:"apiKeyId,omitempty"`
+
+	// TraceContext is the trace context of the submission, so that consumers of the task could continue the trace.
+	// Empty when tracing is disabled.
+	TraceContext map[string]string `json:"traceContext,omitempty"`
+}
+
+// DeadLetterReportTask is the message published to the dead-letter subject, when a report task could not be
+// consumed after retries.
+type DeadLetterReportTask struct {
+	// Subject is the subject the task has been originally published to.
+	Subject  string `json:"subject"`
+	Error    string `json:"error"`
+	Attempts int    `json:"attempts"`
+	// Task is the original message of the task.
+	Task json.RawMessage `json:"task"`
 }
@@ -0,0 +1,53 @@
+package types
+
+import "encoding/json"
+
+// ReportReplayRequest replays report tasks through the current report pipeline, either rejected report tasks of
+// RejectedIDs or archived ones of Tasks.
+type ReportReplayRequest struct {
+	RejectedIDs []int `json:"rejectedIds" validate:"required_without=Tasks,max=1000,dive,gt=0"`
+	// Tasks are archived report tasks, each being a ReportTask, or a DeadLetterReportTask or rejected report task
+	// wrapping one.
+	Tasks []json.RawMessage `json:"tasks" validate:"required_without=RejectedIDs,max=1000" swaggertype:"array,object"`
+	// DryRun verifies the tasks without persisting their reports.
+	DryRun bool `json:"dryRun"`
+}
+
+type ReportReplayResponse struct {
+	Summary *ReportReplaySummary  `json:"summary"`
+	Results []*ReportReplayResult `json:"results"`
+}
+
+type ReportReplaySummary struct {
+	Total int64 `json:"total"`
+	// Replayed is the number of tasks went through the pipeline, regardless of violations of their reports.
+	Replayed int64 `json:"replayed"`
+	Failed   int64 `json:"failed"`
+	// Skipped is the number of tasks not replayed as their reports have been persisted, or they have been replayed
+	// already. Dry runs skip no tasks.
+	Skipped int64 `json:"skipped"`
+	// Rejected is the number of reports with violations, of the tasks replayed.
+	Rejected int64 `json:"rejected"`
+}
+
+// ReportReplayResult is the outcome of replaying a report task.
+type ReportReplayResult struct {
+	// Index is of the task in the input, i.e. its line for files, or its position in the request.
+	Index  int    `json:"index"`
+	TaskID string `json:"taskId,omitempty"`
+	// RejectedID is the id of the rejected report task replayed, if replayed from rejected report tasks.
+	RejectedID int `json:"rejectedId,omitempty"`
+	// Verdicts are of reports of the task, in order. Omitted when the task failed.
+	Verdicts []*ReportReplayVerdict `json:"verdicts,omitempty"`
+	// Skipped is whether the task is skipped, with the reason in Error.
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ReportReplayVerdict struct {
+	// Reliability is the reliability the report is, or would be for dry runs, persisted with.
+	Reliability int `json:"reliability"`
+	// Verifier is the name of the verifier rejected the report. Omitted when the report is accepted.
+	Verifier string `json:"verifier,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
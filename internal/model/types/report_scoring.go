@@ -0,0 +1,15 @@
+package types
+
+// ReportScoringRequest is POSTed to the external scoring service, with verified reports of a report task to be scored.
+type ReportScoringRequest struct {
+	TaskID string `json:"taskId"`
+	FragmentReportCommon
+	AccountID int                       `json:"accountId,omitempty"`
+	Reports   []*ReportTaskSingleReport `json:"reports"`
+}
+
+// ReportScoringResponse is the response of the external scoring service to ReportScoringRequest.
+type ReportScoringResponse struct {
+	// Scores are how likely each report of the request is fake, from 0 to 1, in the order of the reports.
+	Scores []float64 `json:"scores"`
+}
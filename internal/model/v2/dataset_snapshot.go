@@ -0,0 +1,13 @@
+package v2
+
+type DatasetSnapshot struct {
+	Server string `json:"server" example:"CN"`
+	// Name is the file name of the snapshot
+	Name string `json:"name" example:"drop-reports-daily-20220501T040000Z.ndjson.gz"`
+	// Size is the size of the snapshot in bytes
+	Size int64 `json:"size" example:"1048576"`
+	// CreatedAt is the time the snapshot has been generated at, in milliseconds since the epoch
+	CreatedAt int64 `json:"createdAt" example:"1651377600000"`
+	// URL is a signed URL to download the snapshot, which expires after a while
+	URL string `json:"url"`
+}
@@ -15,6 +15,117 @@ type OneDropMatrixElement struct {
 	StdDev    float64  `json:"stdDev" example:"0.114514"`
 	StartTime int64    `json:"start" example:"1556676000000"`
 	EndTime   null.Int `json:"end,omitempty" swaggertype:"integer"`
+	// LowSample is true when the element has been sampled fewer times than the low sample threshold, and its rate
+	// is likely to be inaccurate.
+	LowSample bool `json:"low_sample,omitempty"`
+}
+
+// DropMatrixHistoryResult is the drop matrix of a server as it has been snapshotted on a date.
+type DropMatrixHistoryResult struct {
+	Server string `json:"server" example:"CN"`
+	// Date is the UTC date the snapshot has been taken on, which is the latest one on or before the date requested.
+	Date string `json:"date" example:"2022-05-01"`
+	// SnapshotAt is when the snapshot has been taken, in milliseconds since the epoch.
+	SnapshotAt int64                   `json:"snapshotAt" example:"1651363200000"`
+	Matrix     []*OneDropMatrixElement `json:"matrix"`
+}
+
+// ServerComparison is the drop matrix element of a stage and an item on each server, side by side.
+type ServerComparison struct {
+	StageID string `json:"stageId" example:"main_01-07"`
+	ItemID  string `json:"itemId" example:"30012"`
+	// Servers lists the element on each server the item has been reported to drop from the stage on.
+	Servers []*ServerComparisonElement `json:"servers"`
+}
+
+type ServerComparisonElement struct {
+	Server   string  `json:"server" example:"CN"`
+	Times    int     `json:"times" example:"1061347"`
+	Quantity int     `json:"quantity" example:"1322056"`
+	Rate     float64 `json:"rate" example:"1.2456"`
+	// Lower and Upper bound the confidence interval of Rate at the 95% confidence level.
+	Lower     float64  `json:"lower" example:"1.2454"`
+	Upper     float64  `json:"upper" example:"1.2458"`
+	StartTime int64    `json:"start" example:"1556676000000"`
+	EndTime   null.Int `json:"end,omitempty" swaggertype:"integer"`
+}
+
+// ItemDropStages lists the stages an item drops from on a server, with their drop rates and sanity costs.
+type ItemDropStages struct {
+	ItemID string           `json:"itemId" example:"30012"`
+	Server string           `json:"server" example:"CN"`
+	Stages []*ItemDropStage `json:"stages"`
+}
+
+type ItemDropStage struct {
+	StageID  string  `json:"stageId" example:"main_01-07"`
+	Times    int     `json:"times" example:"1061347"`
+	Quantity int     `json:"quantity" example:"1322056"`
+	Rate     float64 `json:"rate" example:"1.2456"`
+	// Lower and Upper bound the confidence interval of Rate at the 95% confidence level.
+	Lower float64 `json:"lower" example:"1.2454"`
+	Upper float64 `json:"upper" example:"1.2458"`
+	// ApCost is the sanity cost of a run of the stage. Null when the cost of the stage is unknown.
+	ApCost null.Int `json:"apCost" swaggertype:"integer" example:"6"`
+	// ApPerItem is the expected sanity spent per item dropped, i.e. ApCost divided by Rate. Null when the cost of the
+	// stage is unknown, or the item has never been reported to drop from the stage.
+	ApPerItem null.Float `json:"apPerItem" swaggertype:"number" example:"4.8170"`
+	StartTime int64      `json:"start" example:"1556676000000"`
+	EndTime   null.Int   `json:"end,omitempty" swaggertype:"integer"`
+	LowSample bool       `json:"low_sample,omitempty"`
+}
+
+// StageEfficiencyRanking ranks stages an item drops from on a server by the sanity spent per item dropped.
+type StageEfficiencyRanking struct {
+	ItemID string `json:"itemId" example:"30012"`
+	Server string `json:"server" example:"CN"`
+	// StartTime and EndTime bound the reports drop rates are of. Omitted when drop rates are of the drop matrix.
+	StartTime null.Int `json:"start,omitempty" swaggertype:"integer"`
+	EndTime   null.Int `json:"end,omitempty" swaggertype:"integer"`
+	// Stages are ranked from the most efficient one.
+	Stages []*StageEfficiency `json:"stages"`
+}
+
+type StageEfficiency struct {
+	// Rank starts from 1.
+	Rank int `json:"rank" example:"1"`
+	*ItemDropStage
+	// ItemsPerAp is the expected number of items dropped per sanity spent.
+	ItemsPerAp float64 `json:"itemsPerAp" example:"0.2076"`
+}
+
+// Plan is a farming plan obtaining the items required with the least sanity expected.
+type Plan struct {
+	Server string `json:"server" example:"CN"`
+	// TotalAp is the sanity expected to be spent by the plan.
+	TotalAp float64 `json:"totalAp" example:"1280.5"`
+	// Stages are the stages to farm, from the one to farm the most sanity on.
+	Stages []*PlanStage `json:"stages"`
+}
+
+type PlanStage struct {
+	StageID string `json:"stageId" example:"main_01-07"`
+	// Runs is the expected number of runs, which is fractional as it is of expectations.
+	Runs   float64 `json:"runs" example:"213.4"`
+	ApCost int     `json:"apCost" example:"6"`
+	// Items are the expected drops of the runs, of the items required only.
+	Items []*PlanItem `json:"items"`
+}
+
+type PlanItem struct {
+	ItemID   string  `json:"itemId" example:"30012"`
+	Quantity float64 `json:"quantity" example:"265.8"`
+}
+
+// PublicAggregate is everything third-party tools usually need of a server in one document: its drop matrix, with
+// closed zones, along with its stages and all items.
+type PublicAggregate struct {
+	Server string `json:"server" example:"CN"`
+	// GeneratedAt is when the aggregate has been rendered, in milliseconds since the epoch.
+	GeneratedAt int64                   `json:"generatedAt" example:"1651363200000"`
+	Matrix      []*OneDropMatrixElement `json:"matrix"`
+	Stages      []*Stage                `json:"stages"`
+	Items       []*Item                 `json:"items"`
 }
 
 // DropPattern
@@ -27,6 +138,8 @@ type OnePatternMatrixElement struct {
 	Pattern   *Pattern `json:"pattern"`
 	Times     int      `json:"times" example:"641734"`
 	Quantity  int      `json:"quantity" example:"159486"`
+	Lower     float64  `json:"lower" example:"0.2476"`
+	Upper     float64  `json:"upper" example:"0.2495"`
 	StartTime int64    `json:"start" example:"1633032000000"`
 	EndTime   null.Int `json:"end,omitempty" swaggertype:"integer" extensions:"x-nullable"`
 }
@@ -55,6 +168,30 @@ type OneItemTrend struct {
 	Times    []int `json:"times"`
 }
 
+// Personal History
+type PersonalHistoryQueryResult struct {
+	Interval string                   `json:"interval" example:"day"`
+	Buckets  []*PersonalHistoryBucket `json:"buckets"`
+}
+
+type PersonalHistoryBucket struct {
+	StartTime int64                   `json:"start" example:"1633032000000"`
+	EndTime   int64                   `json:"end" example:"1633118400000"`
+	Stages    []*PersonalHistoryStage `json:"stages"`
+}
+
+type PersonalHistoryStage struct {
+	StageID string                 `json:"stageId" example:"main_01-07"`
+	Times   int                    `json:"times" example:"12"`
+	Items   []*PersonalHistoryItem `json:"items"`
+}
+
+type PersonalHistoryItem struct {
+	ItemID   string  `json:"itemId" example:"30012"`
+	Quantity int     `json:"quantity" example:"15"`
+	Rate     float64 `json:"rate" example:"1.25"`
+}
+
 // Advanced Query
 type AdvancedQueryResult struct {
 	AdvancedResults []any `json:"advanced_results"`
@@ -0,0 +1,16 @@
+package v2
+
+// RecognitionRelease describes the latest recognition bundle of a server.
+type RecognitionRelease struct {
+	Server string `json:"server" example:"CN"`
+	// Hash is the hex-encoded SHA-256 hash of the bundle, which addresses it
+	Hash    string `json:"hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Version string `json:"version" example:"4.2.0"`
+	// Size is the size of the bundle in bytes
+	Size        int64  `json:"size" example:"1048576"`
+	ContentType string `json:"contentType" example:"application/zip"`
+	// ReleasedAt is the time the bundle has become the latest one of the server, in milliseconds since the epoch
+	ReleasedAt int64 `json:"releasedAt" example:"1651377600000"`
+	// URL is the path to download the bundle from, which never changes and could be cached forever
+	URL string `json:"url" example:"/PenguinStats/api/v2/recognition/bundles/9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
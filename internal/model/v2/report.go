@@ -1,10 +1,79 @@
 package v2
 
+import (
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
 type ReportResponse struct {
 	ReportHash string `json:"reportHash" example:"0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"`
+	// Verdict is the final verdict of the report. Only present when the report is submitted in synchronous mode.
+	Verdict *ReportVerdict `json:"verdict,omitempty"`
 }
 
 type RecognitionReportResponse struct {
 	TaskId string   `json:"taskId" example:"0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"`
 	Errors []string `json:"errors"`
+	// Verdicts are the final verdicts of each report. Only present when the reports are submitted in synchronous mode.
+	Verdicts []*ReportVerdict `json:"verdicts,omitempty"`
+}
+
+type ReportVerdict struct {
+	// Reliability is the reliability the report has been persisted with; 0 means the report passed all verifications.
+	Reliability int  `json:"reliability" example:"0"`
+	Accepted    bool `json:"accepted" example:"true"`
+	// Verifier is the name of the verifier rejected the report. Omitted when the report is accepted.
+	Verifier string `json:"verifier,omitempty" example:"drop"`
+	// Message describes why the report is rejected. Omitted when the report is accepted.
+	Message string `json:"message,omitempty"`
+	// Reason is the structured reason of why the report is rejected. Omitted when the report is accepted.
+	Reason *pgerr.Reason `json:"reason,omitempty"`
+}
+
+type BatchRecallResponse struct {
+	Results []*BatchRecallResult `json:"results"`
+}
+
+type BatchRecallResult struct {
+	ReportHash string `json:"reportHash" example:"0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"`
+	Recalled   bool   `json:"recalled" example:"true"`
+	// Reason describes why the report could not be recalled. Omitted when the report has been recalled.
+	Reason string `json:"reason,omitempty"`
+	// ReasonDetail is the structured reason of why the report could not be recalled. Omitted when the report has
+	// been recalled.
+	ReasonDetail *pgerr.Reason `json:"reasonDetail,omitempty"`
+}
+
+type ReportTaskStatus struct {
+	TaskID string `json:"taskId" example:"0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"`
+	// State is one of "queued", "verified", "persisted", "rejected" and "failed". Reports of a "rejected" task are
+	// still persisted, with a reliability excluding them from statistics.
+	State string `json:"state" example:"persisted"`
+	// Rejections lists why reports of the task are rejected. Only present when State is "rejected".
+	Rejections []*ReportTaskRejection `json:"rejections,omitempty"`
+	// Error describes why the task could not be processed. Only present when State is "failed".
+	Error string `json:"error,omitempty"`
+	// ReportHash is the hash to recall reports of the task with. Only present once reports have been persisted,
+	// i.e. when State is "persisted" or "rejected".
+	ReportHash string `json:"reportHash,omitempty" example:"0522ce0083000000-1wE2I9dvMFXXzBMpSCYM81rJ0T3tLrAQ"`
+	UpdatedAt  int64  `json:"updatedAt" example:"1654718400000"`
+}
+
+type ReportTaskRejection struct {
+	// Index is the index of the rejected report in the task.
+	Index       int    `json:"index"`
+	Verifier    string `json:"verifier" example:"drop"`
+	Reliability int    `json:"reliability"`
+	Message     string `json:"message"`
+	// Reason is the structured reason of why the report is rejected. Omitted when the verifier is not known.
+	Reason *pgerr.Reason `json:"reason,omitempty"`
+}
+
+type MitigationPreviewResponse struct {
+	StageID          string `json:"stageId" example:"act18d3_01_perm"`
+	RewrittenStageID string `json:"rewrittenStageId" example:"act18d3_01_rep"`
+	Rewritten        bool   `json:"rewritten" example:"true"`
+	// MatchedRule is the name of the mitigation rule that rewrote the stageId; null when no rule matched.
+	MatchedRule null.String `json:"matchedRule" swaggertype:"string" example:"maa-act18d3-perm-as-rep"`
 }
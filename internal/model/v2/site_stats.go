@@ -5,6 +5,19 @@ type SiteStats struct {
 	TotalStageTimes24H  []*TotalStageTime    `json:"totalStageTimes_24h"`
 	TotalItemQuantities []*TotalItemQuantity `json:"totalItemQuantities"`
 	TotalSanityCost     int                  `json:"totalApCost"`
+
+	SiteReporterStats
+}
+
+// SiteReporterStats describes who submitted reports recently.
+type SiteReporterStats struct {
+	// UniqueReporters24H and UniqueReporters7D are the numbers of distinct accounts submitting reports in the last
+	// 24 hours and 7 days
+	UniqueReporters24H int `json:"uniqueReporters_24h" example:"1024"`
+	UniqueReporters7D  int `json:"uniqueReporters_7d" example:"4096"`
+	// SourceBreakdown24H and SourceBreakdown7D break down submissions in the last 24 hours and 7 days by source
+	SourceBreakdown24H []*SourceSubmissions `json:"sourceBreakdown_24h"`
+	SourceBreakdown7D  []*SourceSubmissions `json:"sourceBreakdown_7d"`
 }
 
 type TotalItemQuantity struct {
@@ -16,3 +29,10 @@ type TotalStageTime struct {
 	StageID string `json:"stageId" bun:"ark_stage_id"`
 	Times   int    `json:"times" bun:"total_times"`
 }
+
+type SourceSubmissions struct {
+	// Source is one of `maa`, `frontend` and `others`
+	Source  string `json:"source" example:"maa"`
+	Reports int    `json:"reports" example:"100"`
+	Times   int    `json:"times" example:"120"`
+}
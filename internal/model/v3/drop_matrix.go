@@ -0,0 +1,59 @@
+package v3
+
+import (
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+// DropMatrix is the drop matrix of a server in the v3 schema of result payloads.
+type DropMatrix struct {
+	Server   string               `json:"server" example:"CN"`
+	Elements []*DropMatrixElement `json:"elements"`
+}
+
+// DropMatrixElement is an element of DropMatrix. Unlike modelv2.OneDropMatrixElement, members are consistently
+// named in camel case, and the rate is included so that clients need not calculate it themselves.
+type DropMatrixElement struct {
+	StageID  string  `json:"stageId" example:"main_01-07"`
+	ItemID   string  `json:"itemId" example:"30012"`
+	Times    int     `json:"times" example:"1061347"`
+	Quantity int     `json:"quantity" example:"1322056"`
+	Rate     float64 `json:"rate" example:"1.2456"`
+	StdDev   float64 `json:"stdDev" example:"0.114514"`
+	// StartTime and EndTime are the time range the element has been accumulated in, in milliseconds since the
+	// epoch. EndTime is omitted if the range has not ended yet.
+	StartTime int64    `json:"startTime" example:"1556676000000"`
+	EndTime   null.Int `json:"endTime,omitempty" swaggertype:"integer"`
+	// LowSample is true when the element has been sampled fewer times than the low sample threshold, and its rate
+	// is likely to be inaccurate.
+	LowSample bool `json:"lowSample,omitempty"`
+}
+
+// NewDropMatrix shapes result, being the drop matrix of server in the v2 schema, in the v3 schema.
+func NewDropMatrix(server string, result *modelv2.DropMatrixQueryResult) *DropMatrix {
+	matrix := &DropMatrix{
+		Server:   server,
+		Elements: make([]*DropMatrixElement, 0, len(result.Matrix)),
+	}
+	for _, el := range result.Matrix {
+		rate := 0.0
+		if el.Times > 0 {
+			rate = util.RoundFloat64(float64(el.Quantity)/float64(el.Times), constant.StdDevDigits)
+		}
+		matrix.Elements = append(matrix.Elements, &DropMatrixElement{
+			StageID:   el.StageID,
+			ItemID:    el.ItemID,
+			Times:     el.Times,
+			Quantity:  el.Quantity,
+			Rate:      rate,
+			StdDev:    el.StdDev,
+			StartTime: el.StartTime,
+			EndTime:   el.EndTime,
+			LowSample: el.LowSample,
+		})
+	}
+	return matrix
+}
@@ -0,0 +1,21 @@
+package v3
+
+import (
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+)
+
+// AdvancedQueryResult holds the results of a batch of advanced queries, with a result per query per server in the
+// order of the queries and of their servers.
+type AdvancedQueryResult struct {
+	Results []*AdvancedQueryResultEntry `json:"results"`
+}
+
+type AdvancedQueryResultEntry struct {
+	// ID is the id of the query given by the client, if any.
+	ID     string `json:"id,omitempty"`
+	Server string `json:"server" example:"CN"`
+	// Matrix is the drop matrix of the query, when the query has no `interval`.
+	Matrix []*modelv2.OneDropMatrixElement `json:"matrix,omitempty"`
+	// Trend is the trend of each stage of the query, when the query has an `interval`.
+	Trend map[string]*modelv2.StageTrend `json:"trend,omitempty"`
+}
@@ -0,0 +1,74 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+)
+
+// Webhook is an endpoint events it subscribes to are POSTed to, signed with its secret.
+type Webhook struct {
+	bun.BaseModel `bun:"webhooks,alias:wh"`
+
+	WebhookID int    `bun:",pk,autoincrement" json:"id"`
+	URL       string `json:"url" example:"https://example.com/penguin/webhook"`
+	// Secret signs deliveries to the webhook. It is only returned once on creation.
+	Secret string `json:"-"`
+	// Events are events the webhook subscribes to, of constant.WebhookEvents.
+	Events      []string `bun:",array" json:"events" example:"matrix.refreshed,anomaly.detected"`
+	Description string   `json:"description"`
+	// Enabled is whether events are delivered to the webhook. Deliveries pending when disabled are kept until it is
+	// enabled again.
+	Enabled   bool       `json:"enabled"`
+	CreatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+	UpdatedAt *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"updatedAt"`
+}
+
+// CreatedWebhook is a webhook just created, along with its secret, which is only returned once on creation.
+type CreatedWebhook struct {
+	*Webhook
+
+	Secret string `json:"secret" example:"whsec_xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"`
+}
+
+// WebhookDelivery is the delivery of an event to a webhook, which is retried with backoff until it succeeds or
+// config.Config WebhookMaxAttempts is reached.
+type WebhookDelivery struct {
+	bun.BaseModel `bun:"webhook_deliveries,alias:whd"`
+
+	DeliveryID int    `bun:",pk,autoincrement" json:"id"`
+	WebhookID  int    `json:"webhookId"`
+	Event      string `json:"event" example:"matrix.refreshed"`
+	// Payload is the body POSTed to the webhook, which is a WebhookPayload.
+	Payload json.RawMessage `bun:"type:jsonb" json:"payload" swaggertype:"object"`
+	// Status is one of constant.WebhookDeliveryStatus*.
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// ResponseStatus is the status code the webhook has responded the last attempt with. Null if it has not
+	// responded.
+	ResponseStatus null.Int `json:"responseStatus" swaggertype:"integer"`
+	// LastError is the error the last attempt has failed with.
+	LastError null.String `json:"lastError" swaggertype:"string"`
+	// NextAttemptAt is when the delivery is attempted next while pending.
+	NextAttemptAt *time.Time `json:"nextAttemptAt"`
+	CreatedAt     *time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"createdAt"`
+	DeliveredAt   *time.Time `bun:",nullzero" json:"deliveredAt,omitempty"`
+}
+
+// WebhookPayload is the body POSTed to webhooks. Deliveries of the same event to different webhooks share the same
+// EventID, by which receivers could deduplicate retried deliveries.
+type WebhookPayload struct {
+	EventID   string    `json:"eventId"`
+	Event     string    `json:"event" example:"matrix.refreshed"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// WebhookMatrixRefreshed is the data of constant.WebhookEventMatrixRefreshed.
+type WebhookMatrixRefreshed struct {
+	Server string `json:"server"`
+	// StageIDs are ark stage ids of stages refreshed. Omitted when all stages have been refreshed.
+	StageIDs []string `json:"stageIds,omitempty"`
+}
@@ -0,0 +1,24 @@
+package async
+
+import "golang.org/x/sync/singleflight"
+
+// Flight coalesces concurrent calls of the same key, so that only one of them executes fn while the others wait
+// for, and share, its result. Results are shared by all callers and shall never be modified.
+// The zero value of Flight is ready to use.
+type Flight[T any] struct {
+	group singleflight.Group
+}
+
+// Do executes fn and returns its result, unless a call of key is already in flight, in which case it waits for
+// and returns the result of that call instead. Since fn runs on behalf of all callers of key, it shall not depend
+// on anything specific to one caller except key.
+func (f *Flight[T]) Do(key string, fn func() (T, error)) (T, error) {
+	value, err, _ := f.group.Do(key, func() (any, error) {
+		return fn()
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
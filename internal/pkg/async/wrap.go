@@ -1,5 +1,10 @@
 package async
 
+import (
+	"context"
+	"time"
+)
+
 func Errable(fn func() error) <-chan error {
 	ch := make(chan error)
 	go func() {
@@ -8,3 +13,28 @@ func Errable(fn func() error) <-chan error {
 	}()
 	return ch
 }
+
+// Deadline is like Errable, but stops waiting for fn once timeout has passed or ctx is done, whichever comes
+// first, in which case the error of the context is sent instead. fn keeps running in background if so.
+func Deadline(ctx context.Context, timeout time.Duration, fn func() error) <-chan error {
+	ch := make(chan error)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		// buffered so that fn is never blocked on an abandoned result
+		done := make(chan error, 1)
+		go func() {
+			done <- fn()
+		}()
+
+		select {
+		case err := <-done:
+			ch <- err
+		case <-ctx.Done():
+			ch <- ctx.Err()
+		}
+		close(ch)
+	}()
+	return ch
+}
@@ -0,0 +1,150 @@
+// Package audit collects snapshots of records an admin request changes, which are recorded to the audit log once the
+// request has been handled.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+)
+
+// contextKey is the key of the Trail of a request in fiber locals. It is a string so that the Trail could be found
+// from ctx.Context() of the request, as fasthttp looks up values of string keys from user values, which are where
+// fiber locals are kept.
+const contextKey = "audittrail"
+
+// Trail holds snapshots of the record a request changes, in JSON.
+type Trail struct {
+	mu     sync.Mutex
+	before json.RawMessage
+	after  json.RawMessage
+}
+
+// Begin starts the Trail of the request of ctx, which Before and After called with the context of the request record
+// snapshots to.
+func Begin(ctx *fiber.Ctx) *Trail {
+	trail := &Trail{}
+	ctx.Locals(contextKey, trail)
+	return trail
+}
+
+func fromContext(ctx context.Context) *Trail {
+	trail, _ := ctx.Value(contextKey).(*Trail)
+	return trail
+}
+
+// Before records v as the record before being changed. Only the first snapshot of a request is kept. It does nothing
+// if ctx is not of an audited request, e.g. when the change is made by a worker, so that it is safe to be called
+// from anywhere.
+func Before(ctx context.Context, v any) {
+	if trail := fromContext(ctx); trail != nil {
+		trail.record(&trail.before, v, false)
+	}
+}
+
+// After records v as the record after being changed. The last snapshot of a request is kept.
+func After(ctx context.Context, v any) {
+	if trail := fromContext(ctx); trail != nil {
+		trail.record(&trail.after, v, true)
+	}
+}
+
+// record marshals v right away, as the record could be modified after being recorded.
+func (t *Trail) record(dst *json.RawMessage, v any, overwrite bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to marshal audit snapshot, ignoring it")
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if *dst == nil || overwrite {
+		*dst = data
+	}
+}
+
+// Snapshots returns the snapshots recorded before and after the change, either of which is nil if not recorded.
+func (t *Trail) Snapshots() (before, after json.RawMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.before, t.after
+}
+
+// Change is the change of a field, which is absent from Before when the field is added and from After when removed.
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Diff returns changes between top-level fields of before and after, keyed by field names, or nil if either of them
+// is not a JSON object.
+func Diff(before, after json.RawMessage) map[string]Change {
+	var a, b map[string]json.RawMessage
+	if json.Unmarshal(before, &a) != nil || json.Unmarshal(after, &b) != nil || a == nil || b == nil {
+		return nil
+	}
+
+	changes := make(map[string]Change)
+	for field, value := range a {
+		if !jsonEqual(value, b[field]) {
+			changes[field] = Change{Before: value, After: b[field]}
+		}
+	}
+	for field, value := range b {
+		if _, ok := a[field]; !ok {
+			changes[field] = Change{After: value}
+		}
+	}
+	return changes
+}
+
+// Redact returns a copy of data with values of fields for which isSecret returns true replaced by
+// constant.AuditRedactedValue, at any depth. data is returned as is if it is not valid JSON.
+func Redact(data json.RawMessage, isSecret func(field string) bool) json.RawMessage {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return append(json.RawMessage(nil), data...)
+	}
+	redacted, err := json.Marshal(redact(v, isSecret))
+	if err != nil {
+		return append(json.RawMessage(nil), data...)
+	}
+	return redacted
+}
+
+func redact(v any, isSecret func(field string) bool) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for field, value := range v {
+			if isSecret(field) {
+				v[field] = constant.AuditRedactedValue
+			} else {
+				v[field] = redact(value, isSecret)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redact(value, isSecret)
+		}
+	}
+	return v
+}
+
+// jsonEqual compares JSON values semantically, so that formatting and the order of keys do not count as changes.
+func jsonEqual(a, b json.RawMessage) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	var x, y any
+	if json.Unmarshal(a, &x) != nil || json.Unmarshal(b, &y) != nil {
+		return string(a) == string(b)
+	}
+	return reflect.DeepEqual(x, y)
+}
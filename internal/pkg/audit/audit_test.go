@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		before   string
+		after    string
+		expected map[string][2]string
+	}{
+		{`{"a":1,"b":"x"}`, `{"b":"x","a":1}`, map[string][2]string{}},
+		{`{"a":1,"b":{"c":[1,2]}}`, `{"a":1,"b":{"c":[1, 2]}}`, map[string][2]string{}},
+		{`{"a":1}`, `{"a":2}`, map[string][2]string{"a": {"1", "2"}}},
+		{`{"a":1}`, `{"a":1,"b":true}`, map[string][2]string{"b": {"", "true"}}},
+		{`{"a":1,"b":true}`, `{"a":1}`, map[string][2]string{"b": {"true", ""}}},
+		{`{"a":null}`, `{}`, map[string][2]string{"a": {"null", ""}}},
+		{`[1]`, `{"a":1}`, nil},
+		{`{"a":1}`, `null`, nil},
+		{``, `{"a":1}`, nil},
+	}
+	for _, test := range tests {
+		changes := Diff(json.RawMessage(test.before), json.RawMessage(test.after))
+		if (changes == nil) != (test.expected == nil) || len(changes) != len(test.expected) {
+			t.Errorf("Diff(%s, %s): expected %v, got %v", test.before, test.after, test.expected, changes)
+			continue
+		}
+		for field, expected := range test.expected {
+			change, ok := changes[field]
+			if !ok || string(change.Before) != expected[0] || string(change.After) != expected[1] {
+				t.Errorf("Diff(%s, %s): expected change of %s from %q to %q, got %+v",
+					test.before, test.after, field, expected[0], expected[1], change)
+			}
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	isSecret := func(field string) bool {
+		return strings.Contains(strings.ToLower(field), "token")
+	}
+	tests := []struct {
+		data     string
+		expected string
+	}{
+		{`{"name":"alerts","token":"abc"}`, `{"name":"alerts","token":"<redacted>"}`},
+		{`{"channels":[{"url":"https://example.com","botToken":{"v":1}}]}`, `{"channels":[{"botToken":"<redacted>","url":"https://example.com"}]}`},
+		{`[1,"token"]`, `[1,"token"]`},
+		{`not json`, `not json`},
+	}
+	for _, test := range tests {
+		redacted := Redact(json.RawMessage(test.data), isSecret)
+		if !jsonEqual(redacted, json.RawMessage(test.expected)) {
+			t.Errorf("Redact(%s): expected %s, got %s", test.data, test.expected, redacted)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+// Breaker is a circuit breaker which opens after a number of consecutive failures, rejecting calls for a cooldown
+// period. Once the cooldown has passed, a single trial call is allowed: the breaker closes if the trial succeeds,
+// and opens for another cooldown period otherwise.
+//
+// A nil Breaker never opens.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	// onStateChange is called with whether the breaker is open, whenever the breaker opens or closes
+	onStateChange func(open bool)
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	open     bool
+	trialing bool
+}
+
+// New creates a Breaker opening after threshold consecutive failures for cooldown. onStateChange could be nil.
+func New(threshold int, cooldown time.Duration, onStateChange func(open bool)) *Breaker {
+	return &Breaker{
+		threshold:     threshold,
+		cooldown:      cooldown,
+		onStateChange: onStateChange,
+	}
+}
+
+// Allow returns whether a call is allowed. Every allowed call shall be followed by either Success, Failure or
+// Abandon.
+func (b *Breaker) Allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return true
+	}
+	if b.trialing || time.Since(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.trialing = true
+	return true
+}
+
+// Success records a successful call, closing the breaker.
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trialing = false
+	if b.open {
+		b.open = false
+		b.notify(false)
+	}
+}
+
+// Failure records a failed call, opening the breaker once the threshold of consecutive failures is reached, or
+// when the trial call has failed.
+func (b *Breaker) Failure() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.open {
+		// the trial call has failed
+		b.trialing = false
+		b.openedAt = time.Now()
+		return
+	}
+	if b.failures >= b.threshold {
+		b.open = true
+		b.openedAt = time.Now()
+		b.notify(true)
+	}
+}
+
+// Abandon records a call abandoned before its outcome is known, e.g. when its caller has gone away, leaving the
+// state of the breaker as is.
+func (b *Breaker) Abandon() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trialing = false
+}
+
+func (b *Breaker) notify(open bool) {
+	if b.onStateChange != nil {
+		b.onStateChange(open)
+	}
+}
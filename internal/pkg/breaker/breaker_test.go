@@ -0,0 +1,68 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+)
+
+const testCooldown = time.Millisecond * 20
+
+// step is a call of the breaker: op is one of `allow`, `deny`, `success`, `failure`, `abandon` and `wait`, where
+// `allow` and `deny` expect Allow to return true and false respectively, and `wait` passes the cooldown.
+type step string
+
+func TestBreaker(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []step
+		changes []bool
+	}{
+		{"closed below the threshold", []step{"failure", "failure", "allow", "success", "failure", "failure", "allow"}, nil},
+		{"opens at the threshold", []step{"failure", "failure", "failure", "deny"}, []bool{true}},
+		{"closes after a successful trial", []step{"failure", "failure", "failure", "wait", "allow", "deny", "success", "allow", "allow"}, []bool{true, false}},
+		{"reopens after a failed trial", []step{"failure", "failure", "failure", "wait", "allow", "failure", "deny", "wait", "allow"}, []bool{true}},
+		{"allows another trial once abandoned", []step{"failure", "failure", "failure", "wait", "allow", "abandon", "allow"}, []bool{true}},
+	}
+	for _, test := range tests {
+		var changes []bool
+		b := New(3, testCooldown, func(open bool) {
+			changes = append(changes, open)
+		})
+		for i, s := range test.steps {
+			switch s {
+			case "allow", "deny":
+				if allowed := b.Allow(); allowed != (s == "allow") {
+					t.Errorf("%s: step %d: expected Allow to return %v, got %v", test.name, i, s == "allow", allowed)
+				}
+			case "success":
+				b.Success()
+			case "failure":
+				b.Failure()
+			case "abandon":
+				b.Abandon()
+			case "wait":
+				time.Sleep(testCooldown + time.Millisecond*5)
+			}
+		}
+		if len(changes) != len(test.changes) {
+			t.Errorf("%s: expected state changes %v, got %v", test.name, test.changes, changes)
+			continue
+		}
+		for i := range changes {
+			if changes[i] != test.changes[i] {
+				t.Errorf("%s: expected state changes %v, got %v", test.name, test.changes, changes)
+				break
+			}
+		}
+	}
+}
+
+func TestNilBreaker(t *testing.T) {
+	var b *Breaker
+	b.Failure()
+	b.Abandon()
+	b.Success()
+	if !b.Allow() {
+		t.Errorf("Expected a nil breaker to allow calls, got denied")
+	}
+}
@@ -1,12 +1,13 @@
 package cache
 
 import (
+	"context"
 	"reflect"
-	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
 	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
 )
 
 func NewSet[T any](prefix string) *Set[T] {
@@ -17,8 +18,8 @@ func NewSet[T any](prefix string) *Set[T] {
 }
 
 type Set[T any] struct {
-	// m is a mutex for MutexGetSet for concurrent prevention
-	m sync.Mutex
+	// group coalesces concurrent misses of the same key in MutexGetSet
+	group singleflight.Group
 
 	prefix string
 
@@ -59,39 +60,41 @@ func (c *Set[T]) Set(key string, value T, expire time.Duration) {
 }
 
 // MutexGetSet gets value from cache and writes to dest, or if the key does not exist, it executes valueFunc
-// to get cache value if the key still not exists when serially dispatched, sets value to cache and
-// writes value to dest.
+// to get cache value if the key still not exists, sets value to cache and writes value to dest.
+// Concurrent misses of the same key are coalesced into a single execution of valueFunc, whose value or error
+// is shared by all of them, while misses of different keys are calculated in parallel. valueFunc is given ctx
+// detached from cancellation, as it runs on behalf of all callers rather than the one who happened to start it.
 // The first return value means whether the value is got from cache or not. True means calculated; False means got from cache.
-func (c *Set[T]) MutexGetSet(key string, dest *T, valueFunc func() (*T, error), expire time.Duration) (bool, error) {
+func (c *Set[T]) MutexGetSet(ctx context.Context, key string, dest *T, valueFunc func(ctx context.Context) (*T, error), expire time.Duration) (bool, error) {
 	err := c.Get(key, dest)
 	if err == nil {
 		return false, nil
 	}
 	// onwards, cache key does not exist
 
-	return true, c.slowMutexGetSet(key, dest, valueFunc, expire)
+	return true, c.slowMutexGetSet(ctx, key, dest, valueFunc, expire)
 }
 
-func (c *Set[T]) slowMutexGetSet(key string, dest *T, valueFunc func() (*T, error), expire time.Duration) error {
-	c.m.Lock()
-	defer c.m.Unlock()
-	err := c.Get(key, dest)
+func (c *Set[T]) slowMutexGetSet(ctx context.Context, key string, dest *T, valueFunc func(ctx context.Context) (*T, error), expire time.Duration) error {
+	result, err, _ := c.group.Do(key, func() (any, error) {
+		var cached T
+		if err := c.Get(key, &cached); err == nil {
+			return &cached, nil
+		}
 
-	if err == nil {
-		return nil
-	}
+		value, err := valueFunc(detach(ctx))
+		if err != nil {
+			log.Error().Err(err).Str("key", key).Msg("failed to get value from valueFunc() in MutexGetSet")
+			return nil, err
+		}
 
-	value, err := valueFunc()
+		c.Set(key, *value, expire)
+		return value, nil
+	})
 	if err != nil {
-		log.Error().Err(err).Str("key", key).Msg("failed to get value from valueFunc() in MutexGetSet")
 		return err
 	}
-
-	c.Set(key, *value, expire)
-	// if err != nil {
-	// 	log.Error().Err(err).Str("key", key).Msg("failed to set value to cache in MutexGetSet")
-	// 	return err
-	// }
+	value := result.(*T)
 
 	// copy value to dest
 	var r reflect.Value
@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/singleflight"
+)
+
+// flushTimeout bounds Flush, which scans the Redis tier for keys of the cache.
+const flushTimeout = time.Second * 10
+
+// NewTiered creates a Tiered cache holding at most size entries in-process for localTTL, in front of Redis
+// entries kept for remoteTTL.
+func NewTiered[T any](prefix string, redisClient *redis.Client, size int, localTTL time.Duration, remoteTTL time.Duration) *Tiered[T] {
+	return &Tiered[T]{
+		prefix:    "cache:" + prefix + ":",
+		local:     expirable.NewLRU[string, T](size, nil, localTTL),
+		redis:     redisClient,
+		remoteTTL: remoteTTL,
+	}
+}
+
+// Tiered is a two-tier cache for hot lookups: an in-process LRU in front of Redis, which is shared by all instances.
+// Concurrent misses of the same key are coalesced, so that only one of them reaches Redis and valueFunc.
+// Values are shared by all callers and shall never be modified.
+type Tiered[T any] struct {
+	prefix string
+
+	local     *expirable.LRU[string, T]
+	redis     *redis.Client
+	remoteTTL time.Duration
+
+	group singleflight.Group
+}
+
+func (c *Tiered[T]) key(key string) string {
+	return c.prefix + key
+}
+
+// GetSet returns the value of key from the in-process LRU, or from Redis, or otherwise executes valueFunc to get
+// the value, and populates the tiers it was missing from. Errors of valueFunc are never cached. Coalesced misses are
+// loaded with ctx detached from cancellation, as they are loaded on behalf of all callers.
+func (c *Tiered[T]) GetSet(ctx context.Context, key string, valueFunc func(ctx context.Context) (T, error)) (T, error) {
+	if value, ok := c.local.Get(key); ok {
+		return value, nil
+	}
+
+	value, err, _ := c.group.Do(key, func() (any, error) {
+		ctx := detach(ctx)
+		if value, ok := c.local.Get(key); ok {
+			return value, nil
+		}
+
+		remoteKey := c.key(key)
+		cached, err := c.redis.Get(ctx, remoteKey).Bytes()
+		if err == nil {
+			var value T
+			if err := json.Unmarshal(cached, &value); err == nil {
+				c.local.Add(key, value)
+				return value, nil
+			}
+		} else if !errors.Is(err, redis.Nil) {
+			log.Warn().Err(err).Str("key", remoteKey).Msg("failed to get value from redis tier of cache")
+		}
+
+		value, err := valueFunc(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if encoded, err := json.Marshal(value); err == nil {
+			if err := c.redis.Set(ctx, remoteKey, encoded, c.remoteTTL).Err(); err != nil {
+				log.Warn().Err(err).Str("key", remoteKey).Msg("failed to set value to redis tier of cache")
+			}
+		}
+		c.local.Add(key, value)
+		return value, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return value.(T), nil
+}
+
+// Delete removes key from both tiers. Other instances keep their in-process copies until they are flushed with
+// FlushLocal, or expire.
+func (c *Tiered[T]) Delete(ctx context.Context, keys ...string) error {
+	remoteKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		c.local.Remove(key)
+		remoteKeys = append(remoteKeys, c.key(key))
+	}
+	if len(remoteKeys) == 0 {
+		return nil
+	}
+	return c.redis.Del(ctx, remoteKeys...).Err()
+}
+
+// Flush removes all entries from both tiers. Other instances keep their in-process copies until they are flushed
+// with FlushLocal, or expire.
+func (c *Tiered[T]) Flush() error {
+	c.local.Purge()
+
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+	iter := c.redis.Scan(ctx, 0, c.prefix+"*", 1000).Iterator()
+	keys := make([]string, 0)
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return errors.Wrapf(err, "failed to scan redis tier of cache %s", c.prefix)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return c.redis.Del(ctx, keys...).Err()
+}
+
+// FlushLocal removes all entries from the in-process tier only.
+func (c *Tiered[T]) FlushLocal() error {
+	c.local.Purge()
+	return nil
+}
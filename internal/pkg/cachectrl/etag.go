@@ -0,0 +1,78 @@
+package cachectrl
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bootNonce is mixed into every entity tag, so that tags issued before a restart never match afterwards, e.g. when
+// versions kept in Redis are lost and counted from 0 again while the underlying data has moved on.
+var bootNonce = newBootNonce()
+
+func newBootNonce() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ETag returns a strong entity tag of the representation identified by parts, e.g. the cache key and the version of
+// a cached value, followed by anything else the response body differs by, such as the response format. Tags differ
+// between boots of the application, which costs a full response to clients revalidating against another instance.
+func ETag(parts ...string) string {
+	sum := sha256.Sum256([]byte(bootNonce + "\x00" + strings.Join(parts, "\x00")))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified sets the ETag header to etag, and reports whether If-None-Match of the request matches etag, in which
+// case the caller shall respond with 304 Not Modified instead of the body.
+func NotModified(ctx *fiber.Ctx, etag string) bool {
+	ctx.Set(fiber.HeaderETag, etag)
+
+	noneMatch := ctx.Get(fiber.HeaderIfNoneMatch)
+	if noneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(noneMatch) == "*" {
+		return true
+	}
+	// If-None-Match uses the weak comparison, see https://www.rfc-editor.org/rfc/rfc7232#section-3.2
+	for _, tag := range strings.Split(noneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator revalidates responses of a cached value against the version of the value. It shall be created ahead of
+// loading the value, with the version read by then, so that the ETag never claims a newer version than the value.
+type Validator struct {
+	version   string
+	versioned bool
+}
+
+// NewValidator returns a Validator of version. Responses are served without an ETag if the version could not be read,
+// i.e. versioned is false, rather than failed.
+func NewValidator(version string, versioned bool) Validator {
+	return Validator{version: version, versioned: versioned}
+}
+
+// Version returns the version of the Validator, or false if it could not be read.
+func (v Validator) Version() (string, bool) {
+	return v.version, v.versioned
+}
+
+// NotModified is NotModified with the ETag of the value cached by cacheKey at the version of the Validator, followed
+// by parts the response body differs by. It never reports true if the version could not be read.
+func (v Validator) NotModified(ctx *fiber.Ctx, cacheKey string, parts ...string) bool {
+	if !v.versioned {
+		return false
+	}
+	return NotModified(ctx, ETag(append([]string{cacheKey, v.version}, parts...)...))
+}
@@ -0,0 +1,87 @@
+package cachectrl
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestETag(t *testing.T) {
+	tag := ETag("matrix|CN", "3")
+	if tag != ETag("matrix|CN", "3") {
+		t.Errorf("Expected the same parts to have the same tag, got %s and %s", tag, ETag("matrix|CN", "3"))
+	}
+	if tag[0] != '"' || tag[len(tag)-1] != '"' {
+		t.Errorf("Expected a quoted strong tag, got %s", tag)
+	}
+
+	differing := [][]string{
+		{"matrix|CN", "4"},
+		{"matrix|CN", "3", "csv"},
+		{"matrix|CN3"},
+		{"matrix|CN3", ""},
+	}
+	for _, parts := range differing {
+		if other := ETag(parts...); other == tag {
+			t.Errorf("ETag(%q): expected to differ from %s, got the same", parts, tag)
+		}
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := ETag("matrix|CN", "3")
+	tests := []struct {
+		ifNoneMatch string
+		expected    bool
+	}{
+		{"", false},
+		{etag, true},
+		{"*", true},
+		{"W/" + etag, true},
+		{`"other", ` + etag, true},
+		{`"other"`, false},
+	}
+
+	app := fiber.New()
+	for _, test := range tests {
+		ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+		if test.ifNoneMatch != "" {
+			ctx.Request().Header.Set(fiber.HeaderIfNoneMatch, test.ifNoneMatch)
+		}
+		if notModified := NotModified(ctx, etag); notModified != test.expected {
+			t.Errorf("NotModified with If-None-Match %q: expected %v, got %v", test.ifNoneMatch, test.expected, notModified)
+		}
+		if got := string(ctx.Response().Header.Peek(fiber.HeaderETag)); got != etag {
+			t.Errorf("Expected ETag header %s, got %s", etag, got)
+		}
+		app.ReleaseCtx(ctx)
+	}
+}
+
+func TestValidatorNotModified(t *testing.T) {
+	etag := ETag("matrix|CN", "3", "csv")
+	tests := []struct {
+		validator Validator
+		expected  bool
+		header    string
+	}{
+		{NewValidator("3", true), true, etag},
+		{NewValidator("4", true), false, ETag("matrix|CN", "4", "csv")},
+		// without a version, the response is served without an ETag, even if any would match
+		{NewValidator("", false), false, ""},
+	}
+
+	app := fiber.New()
+	for _, test := range tests {
+		ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+		ctx.Request().Header.Set(fiber.HeaderIfNoneMatch, etag)
+		if notModified := test.validator.NotModified(ctx, "matrix|CN", "csv"); notModified != test.expected {
+			t.Errorf("%+v: expected %v, got %v", test.validator, test.expected, notModified)
+		}
+		if got := string(ctx.Response().Header.Peek(fiber.HeaderETag)); got != test.header {
+			t.Errorf("%+v: expected ETag header %q, got %q", test.validator, test.header, got)
+		}
+		app.ReleaseCtx(ctx)
+	}
+}
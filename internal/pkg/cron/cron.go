@@ -0,0 +1,181 @@
+// Package cron parses cron specs into schedules of jobs.
+package cron
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Schedule describes when a job runs.
+type Schedule interface {
+	// Next returns the first time the job runs after t, or the zero time if it never runs again.
+	Next(t time.Time) time.Time
+}
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type bounds struct {
+	min, max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	// 7 is Sunday as well as 0
+	dowBounds = bounds{0, 7}
+)
+
+// Parse parses spec, which is either a standard cron spec of five fields (minute, hour, day of month, month and day
+// of week, in UTC) with numeric values only, one of the descriptors @yearly, @annually, @monthly, @weekly, @daily,
+// @midnight and @hourly, or "@every <duration>", which runs the duration after the previous run.
+func Parse(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid cron spec %q", spec)
+		}
+		if d < time.Second {
+			return nil, errors.Errorf("invalid cron spec %q: duration must be at least 1s", spec)
+		}
+		return every(d), nil
+	}
+	if expanded, ok := descriptors[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, errors.Errorf("invalid cron spec %q: expected 5 fields, got %d", spec, len(fields))
+	}
+	s := &specSchedule{
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}
+	var err error
+	for i, f := range []struct {
+		field *uint64
+		b     bounds
+	}{
+		{&s.minute, minuteBounds},
+		{&s.hour, hourBounds},
+		{&s.dom, domBounds},
+		{&s.month, monthBounds},
+		{&s.dow, dowBounds},
+	} {
+		if *f.field, err = parseField(fields[i], f.b); err != nil {
+			return nil, errors.Wrapf(err, "invalid cron spec %q", spec)
+		}
+	}
+	// fold Sunday as 7 into Sunday as 0
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	return s, nil
+}
+
+// parseField parses a comma separated list of `*`, `n`, `n-m`, each optionally followed by a step `/s`, into a bit
+// set of values within b.
+func parseField(field string, b bounds) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			rangePart = item[:i]
+			var err error
+			if step, err = strconv.Atoi(item[i+1:]); err != nil || step <= 0 {
+				return 0, errors.Errorf("invalid step in %q", item)
+			}
+		}
+
+		start, end := b.min, b.max
+		if rangePart != "*" {
+			bound := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if start, err = strconv.Atoi(bound[0]); err != nil {
+				return 0, errors.Errorf("invalid value in %q", item)
+			}
+			end = start
+			if len(bound) == 2 {
+				if end, err = strconv.Atoi(bound[1]); err != nil {
+					return 0, errors.Errorf("invalid value in %q", item)
+				}
+			} else if step > 1 {
+				// `n/s` steps from n to the maximum
+				end = b.max
+			}
+		}
+		if start < b.min || end > b.max || start > end {
+			return 0, errors.Errorf("%q is out of range %d-%d", item, b.min, b.max)
+		}
+		for v := start; v <= end; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+type every time.Duration
+
+func (e every) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(e))
+}
+
+// specSchedule is a schedule of a standard cron spec, with bit sets of values of each field.
+type specSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar are whether day of month and day of week are `*`. When neither is, the job runs on days
+	// matching either of them, as in the standard cron.
+	domStar, dowStar bool
+}
+
+// maxSearchYears bounds the search for the next run, for specs which never match, such as February 30th.
+const maxSearchYears = 5
+
+func (s *specSchedule) Next(t time.Time) time.Time {
+	t = t.UTC().Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatches := s.dom&(1<<uint(t.Day())) != 0
+	dowMatches := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatches && dowMatches
+	}
+	return domMatches || dowMatches
+}
@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Wednesday
+	now := time.Date(2022, 6, 15, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"*/15 * * * *", time.Date(2022, 6, 15, 10, 45, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2022, 6, 16, 10, 30, 0, 0, time.UTC)},
+		{"0 4 * * *", time.Date(2022, 6, 16, 4, 0, 0, 0, time.UTC)},
+		{"5-10/2 * * * *", time.Date(2022, 6, 15, 11, 5, 0, 0, time.UTC)},
+		{"0 12 * * 1-5", time.Date(2022, 6, 15, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2022, 6, 17, 0, 0, 0, 0, time.UTC)},
+		{"0,20 9,11 * * *", time.Date(2022, 6, 15, 11, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2022, 6, 15, 11, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2022, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2022, 6, 15, 10, 31, 30, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.spec)
+		if err != nil {
+			t.Errorf("Parse(%q): expected no error, got %v", test.spec, err)
+			continue
+		}
+		if next := schedule.Next(now); !next.Equal(test.expected) {
+			t.Errorf("Parse(%q).Next(%v): expected %v, got %v", test.spec, now, test.expected, next)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@every 500ms",
+		"@every soon",
+		"@fortnightly",
+	}
+	for _, spec := range specs {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q): expected error, got nil", spec)
+		}
+	}
+}
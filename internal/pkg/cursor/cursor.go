@@ -0,0 +1,53 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// Cursor is the position of a report in listings of reports, which are ordered by creation time and then report id,
+// both descending, so that the order is stable even if reports are created at the same time.
+type Cursor struct {
+	CreatedAt time.Time
+	ReportID  int
+}
+
+type encoded struct {
+	CreatedAt int64 `json:"t"`
+	ReportID  int   `json:"r"`
+}
+
+// Encode returns the opaque form of c, to be responded to clients.
+func (c *Cursor) Encode() string {
+	// a struct of an int64 and an int could never fail to be marshalled
+	b, _ := json.Marshal(encoded{
+		CreatedAt: c.CreatedAt.UnixMicro(),
+		ReportID:  c.ReportID,
+	})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode parses s, the opaque form of a cursor given by Encode. nil is returned if s is empty, i.e. the listing
+// starts from the latest report.
+func Decode(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, pgerr.ErrInvalidReq.Msg("invalid cursor")
+	}
+	var e encoded
+	if err := json.Unmarshal(b, &e); err != nil || e.ReportID <= 0 {
+		return nil, pgerr.ErrInvalidReq.Msg("invalid cursor")
+	}
+
+	return &Cursor{
+		CreatedAt: time.UnixMicro(e.CreatedAt),
+		ReportID:  e.ReportID,
+	}, nil
+}
@@ -0,0 +1,40 @@
+package cursor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	c := &Cursor{CreatedAt: time.Date(2022, 6, 15, 4, 0, 0, 123456000, time.UTC), ReportID: 42}
+	decoded, err := Decode(c.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.CreatedAt.Equal(c.CreatedAt) || decoded.ReportID != c.ReportID {
+		t.Errorf("Expected cursor %v, got %v", c, decoded)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		s       string
+		isNil   bool
+		wantErr bool
+	}{
+		{"", true, false},
+		{"!!!", true, true},
+		{"bm90IGpzb24", true, true},          // not json
+		{"eyJ0IjoxLCJyIjowfQ", true, true},   // {"t":1,"r":0}
+		{"eyJ0IjoxLCJyIjoxfQ", false, false}, // {"t":1,"r":1}
+	}
+	for _, test := range tests {
+		c, err := Decode(test.s)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Decode(%q): expected error %v, got %v", test.s, test.wantErr, err)
+		}
+		if (c == nil) != test.isNil {
+			t.Errorf("Decode(%q): expected nil cursor %v, got %v", test.s, test.isNil, c)
+		}
+	}
+}
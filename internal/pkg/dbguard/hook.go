@@ -0,0 +1,137 @@
+package dbguard
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+)
+
+// maxSummaryLength is the maximum length of the SQL summary of a logged slow query.
+const maxSummaryLength = 256
+
+type cancelStashKey struct{}
+
+type timeoutContextKey struct{}
+
+// WithQueryTimeout overrides the timeout of queries executed with ctx. Queries are bound by the deadline of ctx
+// only when timeout is not positive, e.g. for workers recalculating matrices within their own deadline.
+func WithQueryTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, timeoutContextKey{}, timeout)
+}
+
+// QueryHook bounds queries built with bun query builders by a timeout, on top of the deadline of their context,
+// and logs and counts queries slower than a threshold.
+//
+// Raw queries executed with QueryContext or QueryRowContext are not bound by the timeout, as their rows are read
+// after the hook returns, but are still logged and counted when slow.
+type QueryHook struct {
+	timeout       time.Duration
+	slowThreshold time.Duration
+}
+
+var _ bun.QueryHook = (*QueryHook)(nil)
+
+// NewQueryHook creates a QueryHook. Queries are not bound by a timeout when timeout is not positive, and slow
+// queries are not logged when slowThreshold is not positive.
+func NewQueryHook(timeout time.Duration, slowThreshold time.Duration) *QueryHook {
+	return &QueryHook{
+		timeout:       timeout,
+		slowThreshold: slowThreshold,
+	}
+}
+
+func (h *QueryHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	if event.IQuery == nil {
+		return ctx
+	}
+
+	timeout := h.timeout
+	if override, ok := ctx.Value(timeoutContextKey{}).(time.Duration); ok {
+		timeout = override
+	}
+	if timeout <= 0 {
+		return ctx
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	if event.Stash == nil {
+		event.Stash = make(map[interface{}]interface{})
+	}
+	event.Stash[cancelStashKey{}] = cancel
+	return ctx
+}
+
+func (h *QueryHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	timedOut := errors.Is(ctx.Err(), context.DeadlineExceeded) || errors.Is(event.Err, context.DeadlineExceeded)
+	if cancel, ok := event.Stash[cancelStashKey{}].(context.CancelFunc); ok {
+		cancel()
+	}
+
+	duration := time.Since(event.StartTime)
+	slow := h.slowThreshold > 0 && duration >= h.slowThreshold
+	if !timedOut && !slow {
+		return
+	}
+
+	operation := event.Operation()
+	caller := queryCaller()
+	logger := log.Warn().
+		Dur("duration", duration).
+		Str("operation", operation).
+		Str("caller", caller).
+		Str("query", summarize(event.Query))
+	if timedOut {
+		observability.DBQueryTimeouts.WithLabelValues(operation, caller).Inc()
+		logger.Err(event.Err).Msg("query timed out")
+	} else {
+		observability.DBSlowQueries.WithLabelValues(operation, caller).Inc()
+		logger.Msg("slow query")
+	}
+}
+
+// queryCaller returns the name of the innermost function outside of bun, database/sql and this package on the
+// call stack, which is usually the repo method executing the query.
+func queryCaller() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isInternalFrame(frame.Function) {
+			return trimModulePath(frame.Function)
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+func isInternalFrame(function string) bool {
+	return strings.HasPrefix(function, "github.com/uptrace/bun") ||
+		strings.HasPrefix(function, "database/sql") ||
+		strings.HasPrefix(function, "github.com/penguin-statistics/backend-next/internal/pkg/dbguard")
+}
+
+// trimModulePath trims the import path off function, leaving e.g. `repo.(*DropReport).CalcTotalTimes`.
+func trimModulePath(function string) string {
+	if i := strings.LastIndexByte(function, '/'); i >= 0 {
+		return function[i+1:]
+	}
+	return function
+}
+
+// summarize collapses whitespaces of query and truncates it to maxSummaryLength.
+func summarize(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > maxSummaryLength {
+		return query[:maxSummaryLength] + "..."
+	}
+	return query
+}
@@ -0,0 +1,93 @@
+package dbrouter
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+// Router routes read-only queries to a read replica while the replica is available, and falls back to the primary
+// otherwise. Queries that write, or have to observe writes made just before them, shall use the primary directly.
+type Router struct {
+	primary *bun.DB
+	replica *bun.DB
+
+	// replicaUp is 1 when the last health check of replica succeeded
+	replicaUp int32
+}
+
+// New creates a Router. replica could be nil, in which case all queries are routed to primary. The replica is
+// considered unavailable until CheckReplica succeeds.
+func New(primary *bun.DB, replica *bun.DB) *Router {
+	return &Router{
+		primary: primary,
+		replica: replica,
+	}
+}
+
+// Primary returns the primary database.
+func (r *Router) Primary() *bun.DB {
+	return r.primary
+}
+
+type primaryContextKey struct{}
+
+// WithPrimary returns a copy of ctx, read-only queries made with which are routed to the primary, e.g. those of
+// refreshes that have to observe reports written just before them, which the replica might lag behind.
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryContextKey{}, true)
+}
+
+// Read returns the database read-only queries made with ctx shall be routed to: the replica if it is available and
+// ctx is not marked by WithPrimary, or the primary.
+func (r *Router) Read(ctx context.Context) *bun.DB {
+	if primary, _ := ctx.Value(primaryContextKey{}).(bool); primary {
+		return r.primary
+	}
+	if r.replica != nil && atomic.LoadInt32(&r.replicaUp) == 1 {
+		return r.replica
+	}
+	return r.primary
+}
+
+// CheckReplica pings the replica, and marks it available or unavailable depending on the result.
+func (r *Router) CheckReplica(ctx context.Context) {
+	if r.replica == nil {
+		return
+	}
+
+	err := r.replica.PingContext(ctx)
+	if err != nil {
+		if atomic.SwapInt32(&r.replicaUp, 0) == 1 {
+			log.Warn().Err(err).Msg("read replica is unavailable, routing reads to primary")
+		}
+		return
+	}
+	if atomic.SwapInt32(&r.replicaUp, 1) == 0 {
+		log.Info().Msg("read replica is available, routing reads to replica")
+	}
+}
+
+// Watch checks the replica every interval until ctx is done.
+func (r *Router) Watch(ctx context.Context, interval time.Duration, timeout time.Duration) {
+	if r.replica == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		r.CheckReplica(checkCtx)
+		cancel()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
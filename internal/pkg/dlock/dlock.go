@@ -0,0 +1,214 @@
+// Package dlock provides distributed locks backed by Redis, for operations that must not run concurrently across
+// instances.
+package dlock
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// keyPrefix prefixes the Redis key of a lock, followed by the name of the lock. The key holds the fencing token
+	// of the holder.
+	keyPrefix = "dlock:"
+	// fenceKeyPrefix prefixes the Redis key of the last fencing token issued for a lock, followed by the name of the
+	// lock.
+	fenceKeyPrefix = "dlock-fence:"
+
+	// pollInterval is the interval in-between attempts to acquire a lock held by another holder
+	pollInterval = time.Second
+)
+
+var (
+	ErrNotAcquired = errors.New("lock is held by another holder")
+	// ErrFenced is returned by writes fenced by a lock, which has been acquired by another holder since.
+	ErrFenced = errors.New("lock has been acquired by another holder since")
+)
+
+type lockContextKey struct{}
+
+// acquireScript acquires the lock at KEYS[1] for ARGV[1] milliseconds if it is free, with a fencing token
+// incremented from KEYS[2]. It returns the fencing token, or 0 if the lock is held. Tokens are never less than the
+// time of Redis in microseconds, so that they keep increasing even if KEYS[2] has been lost, as tokens could have
+// been recorded elsewhere to fence writes.
+var acquireScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return 0
+end
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local token = math.max(tonumber(redis.call("GET", KEYS[2]) or "0") + 1, now)
+redis.call("SET", KEYS[2], string.format("%d", token))
+redis.call("SET", KEYS[1], string.format("%d", token), "PX", ARGV[1])
+return token
+`)
+
+// renewScript extends the lock at KEYS[1] for ARGV[2] milliseconds if it is held with the fencing token ARGV[1].
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript releases the lock at KEYS[1] if it is held with the fencing token ARGV[1].
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Locker acquires locks which expire after ttl unless renewed. Locks are renewed automatically while held.
+type Locker struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func New(client *redis.Client, ttl time.Duration) *Locker {
+	return &Locker{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+// Lock is a held lock. Each acquisition of a lock is issued a fencing token greater than those of all previous
+// acquisitions of the same lock, so that writes made under a lock that has since been lost could be told apart from
+// those of the current holder.
+type Lock struct {
+	locker *Locker
+	name   string
+	token  int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// TryLock acquires the lock of name, or returns ErrNotAcquired if it is held by another holder. The context of the
+// lock derives from ctx.
+func (l *Locker) TryLock(ctx context.Context, name string) (*Lock, error) {
+	token, err := acquireScript.Run(ctx, l.client, []string{keyPrefix + name, fenceKeyPrefix + name}, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to acquire lock %s", name)
+	}
+	if token == 0 {
+		return nil, ErrNotAcquired
+	}
+
+	lockCtx, cancel := context.WithCancel(ctx)
+	lock := &Lock{
+		locker: l,
+		name:   name,
+		token:  token,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	// the context carries the lock, so that writes made with it could be fenced by its token
+	lock.ctx = context.WithValue(lockCtx, lockContextKey{}, lock)
+	go lock.renew()
+	return lock, nil
+}
+
+// Lock acquires the lock of name, waiting for it to be released by other holders until ctx is done.
+func (l *Locker) Lock(ctx context.Context, name string) (*Lock, error) {
+	for {
+		lock, err := l.TryLock(ctx, name)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "failed to acquire lock %s", name)
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Do runs fn while holding the lock of name, waiting for it to be released by other holders until ctx is done. fn is
+// called with the context of the lock, which is cancelled once the lock is lost.
+func (l *Locker) Do(ctx context.Context, name string, fn func(ctx context.Context) error) error {
+	lock, err := l.Lock(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return fn(lock.Context())
+}
+
+// FromContext returns the lock whose context ctx derives from, or nil if there is none. See Lock.Context.
+func FromContext(ctx context.Context) *Lock {
+	lock, _ := ctx.Value(lockContextKey{}).(*Lock)
+	return lock
+}
+
+// Name returns the name of the lock.
+func (lk *Lock) Name() string {
+	return lk.name
+}
+
+// Token returns the fencing token of the lock.
+func (lk *Lock) Token() int64 {
+	return lk.token
+}
+
+// Context returns the context of the lock, which is cancelled once the lock is lost, e.g. after failing to renew it
+// in time, or unlocked.
+func (lk *Lock) Context() context.Context {
+	return lk.ctx
+}
+
+// Unlock releases the lock, if it is still held.
+func (lk *Lock) Unlock() {
+	lk.cancel()
+	<-lk.done
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := releaseScript.Run(ctx, lk.locker.client, []string{keyPrefix + lk.name}, strconv.FormatInt(lk.token, 10)).Err(); err != nil {
+		log.Warn().Err(err).Str("lock", lk.name).Msg("failed to release lock, it expires by itself")
+	}
+}
+
+// renew extends the lock every third of its ttl until it is unlocked, and cancels its context once it could not be
+// extended, as another holder might have acquired it after it expired.
+func (lk *Lock) renew() {
+	defer close(lk.done)
+
+	ticker := time.NewTicker(lk.locker.ttl / 3)
+	defer ticker.Stop()
+
+	renewedAt := time.Now()
+	for {
+		select {
+		case <-lk.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		renewed, err := renewScript.Run(lk.ctx, lk.locker.client, []string{keyPrefix + lk.name},
+			strconv.FormatInt(lk.token, 10), lk.locker.ttl.Milliseconds()).Int()
+		if lk.ctx.Err() != nil {
+			return
+		}
+		if err == nil && renewed == 1 {
+			renewedAt = time.Now()
+			continue
+		}
+		// a failed renewal is retried on the next tick, unless the lock would have expired by then
+		if err != nil && time.Since(renewedAt) < lk.locker.ttl*2/3 {
+			log.Warn().Err(err).Str("lock", lk.name).Msg("failed to renew lock, retrying")
+			continue
+		}
+		log.Error().Err(err).Str("lock", lk.name).Int64("token", lk.token).Msg("lock lost, cancelling its holder")
+		lk.cancel()
+		return
+	}
+}
@@ -0,0 +1,179 @@
+// Package featureflag provides feature flags stored in Redis, which gate behaviors per server and for a percentage of
+// subjects, and could be toggled at runtime.
+package featureflag
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/zeebo/xxh3"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/snapshot"
+)
+
+const (
+	// flagsKey is the Redis hash of flags, with keys of flags as fields and flags in JSON as values.
+	flagsKey = "feature-flags"
+
+	// refreshInterval is how often flags are reloaded from Redis, which bounds how long changes made by other
+	// instances take to apply.
+	refreshInterval = time.Second * 10
+)
+
+// Flag gates a behavior. A flag is enabled for a subject if it is Enabled, the subject is of one of Servers, and the
+// subject falls in the first Percentage of 100 buckets subjects are hashed into.
+type Flag struct {
+	Key     string `json:"key" example:"v3_advanced_query"`
+	Enabled bool   `json:"enabled"`
+	// Servers limits the flag to subjects of these servers. The flag is enabled on all servers when empty.
+	Servers []string `json:"servers" example:"CN,US"`
+	// Percentage is the percentage of subjects the flag is enabled for, from 0 to 100.
+	Percentage  int        `json:"percentage" example:"100"`
+	Description string     `json:"description"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+// Subject is who a flag is evaluated for.
+type Subject struct {
+	Server string
+	// ID identifies the subject, e.g. an account or an IP, so that the same subject always falls in the same bucket
+	// of a flag. Subjects without an ID are only enabled when the flag is enabled for 100% of subjects.
+	ID string
+}
+
+// EnabledFor returns whether the flag is enabled for subject.
+func (f *Flag) EnabledFor(subject Subject) bool {
+	if !f.Enabled {
+		return false
+	}
+	if len(f.Servers) > 0 && !lo.Contains(f.Servers, subject.Server) {
+		return false
+	}
+	if f.Percentage >= 100 {
+		return true
+	}
+	if f.Percentage <= 0 || subject.ID == "" {
+		return false
+	}
+	// buckets are hashed with the key of the flag, so that subjects fall in different buckets of different flags
+	return int(xxh3.HashString(f.Key+":"+subject.ID)%100) < f.Percentage
+}
+
+// Store keeps flags in Redis, and caches them in-process for refreshInterval.
+type Store struct {
+	client *redis.Client
+
+	flags *snapshot.Snapshot[map[string]*Flag]
+}
+
+func New(client *redis.Client) *Store {
+	s := &Store{
+		client: client,
+	}
+	s.flags = snapshot.New("featureFlags", refreshInterval, s.load)
+	return s
+}
+
+// Enabled returns whether the flag of key is enabled for subject, or fallback if the flag does not exist. Flags are
+// taken from the in-process snapshot, so only the first call waits for flags to load.
+func (s *Store) Enabled(_ context.Context, key string, subject Subject, fallback bool) bool {
+	flag, ok := s.flags.Get()[key]
+	if !ok {
+		return fallback
+	}
+	return flag.EnabledFor(subject)
+}
+
+// List returns all flags, ordered by key.
+func (s *Store) List(ctx context.Context) ([]*Flag, error) {
+	flags, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := lo.Values(flags)
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list, nil
+}
+
+// Get returns the flag of key, or nil if it does not exist.
+func (s *Store) Get(ctx context.Context, key string) (*Flag, error) {
+	value, err := s.client.HGet(ctx, flagsKey, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get feature flag")
+	}
+
+	var flag Flag
+	if err := json.Unmarshal([]byte(value), &flag); err != nil {
+		return nil, errors.Wrap(err, "invalid feature flag")
+	}
+	return &flag, nil
+}
+
+// Set creates or replaces the flag of flag.Key.
+func (s *Store) Set(ctx context.Context, flag *Flag) error {
+	now := time.Now()
+	flag.UpdatedAt = &now
+	flagJSON, err := json.Marshal(flag)
+	if err != nil {
+		return err
+	}
+	if err := s.client.HSet(ctx, flagsKey, flag.Key, flagJSON).Err(); err != nil {
+		return errors.Wrap(err, "failed to set feature flag")
+	}
+	s.reload(ctx)
+	return nil
+}
+
+// Delete deletes the flag of key, which falls back to the default of each caller afterwards. It returns whether the
+// flag existed.
+func (s *Store) Delete(ctx context.Context, key string) (bool, error) {
+	deleted, err := s.client.HDel(ctx, flagsKey, key).Result()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to delete feature flag")
+	}
+	s.reload(ctx)
+	return deleted > 0, nil
+}
+
+// reload reloads flags cached in-process, so that changes made by this instance apply right away. Flags cached are
+// left to be reloaded after refreshInterval if reloading fails.
+func (s *Store) reload(ctx context.Context) {
+	flags, err := s.load(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to reload feature flags")
+		return
+	}
+	s.flags.Set(flags)
+}
+
+func (s *Store) load(ctx context.Context) (map[string]*Flag, error) {
+	values, err := s.client.HGetAll(ctx, flagsKey).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load feature flags")
+	}
+
+	flags := make(map[string]*Flag, len(values))
+	for key, value := range values {
+		var flag Flag
+		if err := json.Unmarshal([]byte(value), &flag); err != nil {
+			log.Warn().Err(err).Str("flag", key).Msg("invalid feature flag, ignoring it")
+			continue
+		}
+		flags[key] = &flag
+	}
+	return flags, nil
+}
+
+// AccountSubjectID returns the subject ID of an account.
+func AccountSubjectID(accountId int) string {
+	return "account:" + strconv.Itoa(accountId)
+}
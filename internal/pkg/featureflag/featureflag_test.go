@@ -0,0 +1,68 @@
+package featureflag
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestEnabledFor(t *testing.T) {
+	tests := []struct {
+		flag     Flag
+		subject  Subject
+		expected bool
+	}{
+		{Flag{Key: "f", Enabled: false, Percentage: 100}, Subject{Server: "CN", ID: "account:1"}, false},
+		{Flag{Key: "f", Enabled: true, Percentage: 100}, Subject{Server: "CN", ID: "account:1"}, true},
+		{Flag{Key: "f", Enabled: true, Percentage: 100}, Subject{Server: "CN"}, true},
+		{Flag{Key: "f", Enabled: true, Percentage: 0}, Subject{Server: "CN", ID: "account:1"}, false},
+		{Flag{Key: "f", Enabled: true, Percentage: 99}, Subject{Server: "CN"}, false},
+		{Flag{Key: "f", Enabled: true, Servers: []string{"CN", "US"}, Percentage: 100}, Subject{Server: "US"}, true},
+		{Flag{Key: "f", Enabled: true, Servers: []string{"CN", "US"}, Percentage: 100}, Subject{Server: "JP"}, false},
+	}
+	for _, test := range tests {
+		if enabled := test.flag.EnabledFor(test.subject); enabled != test.expected {
+			t.Errorf("%+v.EnabledFor(%+v): expected %v, got %v", test.flag, test.subject, test.expected, enabled)
+		}
+	}
+}
+
+func TestEnabledForPercentage(t *testing.T) {
+	const subjects = 2000
+	low := Flag{Key: "v3_advanced_query", Enabled: true, Percentage: 30}
+	high := Flag{Key: "v3_advanced_query", Enabled: true, Percentage: 60}
+
+	var enabledLow, enabledHigh int
+	for i := 0; i < subjects; i++ {
+		subject := Subject{Server: "CN", ID: AccountSubjectID(i)}
+		lowEnabled, highEnabled := low.EnabledFor(subject), high.EnabledFor(subject)
+		if lowEnabled && !highEnabled {
+			t.Errorf("Expected subject %s enabled at 30%% to be enabled at 60%%, got disabled", subject.ID)
+		}
+		if lowEnabled != low.EnabledFor(subject) {
+			t.Errorf("Expected subject %s to fall in the same bucket every time", subject.ID)
+		}
+		if lowEnabled {
+			enabledLow++
+		}
+		if highEnabled {
+			enabledHigh++
+		}
+	}
+	// buckets are uniform enough for the ratios to land within 5 percent points of the percentages
+	for _, test := range []struct {
+		percentage int
+		enabled    int
+	}{{30, enabledLow}, {60, enabledHigh}} {
+		if ratio := test.enabled * 100 / subjects; ratio < test.percentage-5 || ratio > test.percentage+5 {
+			t.Errorf("Expected about %d%% of subjects enabled, got %d%%", test.percentage, ratio)
+		}
+	}
+}
+
+func TestAccountSubjectID(t *testing.T) {
+	for _, id := range []int{0, 1, 42} {
+		if subjectId := AccountSubjectID(id); subjectId != "account:"+strconv.Itoa(id) {
+			t.Errorf("Expected subject id account:%d, got %s", id, subjectId)
+		}
+	}
+}
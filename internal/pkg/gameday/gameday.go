@@ -6,10 +6,25 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/constant"
 )
 
+const (
+	// Day is the length of a game day.
+	Day = time.Hour * 24
+	// Week is the length of a game week.
+	Week = Day * 7
+)
+
+// Location returns the time zone of server. UTC is returned for unknown servers.
+func Location(server string) *time.Location {
+	if loc, ok := constant.LocMap[server]; ok {
+		return loc
+	}
+	return time.UTC
+}
+
 func StartTime(server string, t time.Time) time.Time {
-	loc := constant.LocMap[server]
+	loc := Location(server)
 	t = t.In(loc)
-	if t.Hour() < 4 {
+	if t.Hour() < constant.GameDayStartHour {
 		t = t.Add(time.Hour * -24)
 	}
 	newT := time.Date(t.Year(), t.Month(), t.Day(), constant.GameDayStartHour, constant.GameDayStartMinute, constant.GameDayStartSecond, constant.GameDayStartNano, loc)
@@ -21,10 +36,22 @@ func EndTime(server string, t time.Time) time.Time {
 }
 
 func IsStartTime(server string, t time.Time) bool {
-	loc := constant.LocMap[server]
+	loc := Location(server)
 	t = t.In(loc)
 	return t.Hour() == constant.GameDayStartHour &&
 		t.Minute() == constant.GameDayStartMinute &&
 		t.Second() == constant.GameDayStartSecond &&
 		t.Nanosecond() == constant.GameDayStartNano
 }
+
+// WeekStartTime returns the start of the game week containing t on server, which starts on Monday with the
+// daily reset.
+func WeekStartTime(server string, t time.Time) time.Time {
+	dayStart := StartTime(server, t)
+	daysSinceMonday := (int(dayStart.Weekday()) + 6) % 7
+	return dayStart.AddDate(0, 0, -daysSinceMonday)
+}
+
+func WeekEndTime(server string, t time.Time) time.Time {
+	return WeekStartTime(server, t).AddDate(0, 0, 7)
+}
@@ -0,0 +1,34 @@
+package gameday
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWeekStartTime(t *testing.T) {
+	tests := []struct {
+		server   string
+		t        time.Time
+		expected time.Time
+	}{
+		// Wednesday 2022-06-15 12:00 UTC+8 is in the week starting on Monday 2022-06-13 04:00 UTC+8
+		{"CN", time.Date(2022, 6, 15, 4, 0, 0, 0, time.UTC), time.Date(2022, 6, 12, 20, 0, 0, 0, time.UTC)},
+		// Monday 2022-06-13 03:59 UTC+8 is before the reset, so it is still in the previous week
+		{"CN", time.Date(2022, 6, 12, 19, 59, 0, 0, time.UTC), time.Date(2022, 6, 5, 20, 0, 0, 0, time.UTC)},
+		// Monday 2022-06-13 04:00 UTC+8 is the start of the week itself
+		{"CN", time.Date(2022, 6, 12, 20, 0, 0, 0, time.UTC), time.Date(2022, 6, 12, 20, 0, 0, 0, time.UTC)},
+		// Sunday 2022-06-19 23:00 UTC-7 is in the week starting on Monday 2022-06-13 04:00 UTC-7
+		{"US", time.Date(2022, 6, 20, 6, 0, 0, 0, time.UTC), time.Date(2022, 6, 13, 11, 0, 0, 0, time.UTC)},
+		// unknown servers are in UTC
+		{"XX", time.Date(2022, 6, 15, 0, 0, 0, 0, time.UTC), time.Date(2022, 6, 13, 4, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		start := WeekStartTime(test.server, test.t)
+		if !start.Equal(test.expected) {
+			t.Errorf("WeekStartTime(%s, %v): expected %v, got %v", test.server, test.t, test.expected, start)
+		}
+		if end := WeekEndTime(test.server, test.t); !end.Equal(test.expected.AddDate(0, 0, 7)) {
+			t.Errorf("WeekEndTime(%s, %v): expected %v, got %v", test.server, test.t, test.expected.AddDate(0, 0, 7), end)
+		}
+	}
+}
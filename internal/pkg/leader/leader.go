@@ -0,0 +1,130 @@
+package leader
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+)
+
+// campaignScript acquires the lease at KEYS[1] for ARGV[1] for ARGV[2] milliseconds if it is free, or extends it if
+// it is held by ARGV[1] already. It returns 1 if ARGV[1] holds the lease afterwards.
+var campaignScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder == false then
+	redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+	return 1
+elseif holder == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// resignScript releases the lease at KEYS[1] if it is held by ARGV[1].
+var resignScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Elector elects a single leader among instances campaigning for the same election, with a lease in Redis which the
+// leader renews periodically. Once the leader is gone, the lease expires and another instance takes over.
+//
+// A nil Elector is always the leader, so that leader election could be disabled by not creating one.
+type Elector struct {
+	client   *redis.Client
+	election string
+	key      string
+	id       string
+	ttl      time.Duration
+
+	// leader is 1 while this instance holds the lease
+	leader int32
+}
+
+// New creates an Elector for election, holding leases of ttl under key.
+func New(client *redis.Client, election string, key string, ttl time.Duration) *Elector {
+	hostname, _ := os.Hostname()
+	return &Elector{
+		client:   client,
+		election: election,
+		key:      key,
+		id:       hostname + "-" + uniuri.NewLen(8),
+		ttl:      ttl,
+	}
+}
+
+// IsLeader returns whether this instance is the leader.
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+	return atomic.LoadInt32(&e.leader) == 1
+}
+
+// Run campaigns for leadership every third of the lease ttl until ctx is done, and resigns afterwards so that
+// another instance could take over right away.
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		e.Campaign(ctx)
+		select {
+		case <-ctx.Done():
+			e.resign()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Campaign acquires the lease if it is free, or renews it if this instance holds it already, and updates whether
+// this instance is the leader accordingly.
+func (e *Elector) Campaign(ctx context.Context) {
+	held, err := campaignScript.Run(ctx, e.client, []string{e.key}, e.id, e.ttl.Milliseconds()).Int()
+	if err != nil {
+		// the lease could not be renewed and might expire, so step down before another instance takes over
+		if ctx.Err() == nil {
+			log.Warn().Err(err).Str("election", e.election).Msg("failed to campaign for leadership")
+		}
+		e.setLeader(false)
+		return
+	}
+	e.setLeader(held == 1)
+}
+
+func (e *Elector) resign() {
+	if !e.IsLeader() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := resignScript.Run(ctx, e.client, []string{e.key}, e.id).Err(); err != nil {
+		log.Warn().Err(err).Str("election", e.election).Msg("failed to resign leadership")
+	}
+	e.setLeader(false)
+}
+
+func (e *Elector) setLeader(leader bool) {
+	var value int32
+	if leader {
+		value = 1
+	}
+	if atomic.SwapInt32(&e.leader, value) != value {
+		if leader {
+			log.Info().Str("election", e.election).Str("id", e.id).Msg("elected as leader")
+		} else {
+			log.Info().Str("election", e.election).Str("id", e.id).Msg("no longer the leader")
+		}
+	}
+	observability.WorkerLeader.WithLabelValues(e.election).Set(float64(value))
+}
@@ -0,0 +1,144 @@
+// Package lp solves linear programs small enough for a dense simplex tableau, such as farming plans over the stages
+// dropping a handful of items.
+package lp
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// epsilon is the tolerance of comparisons against zero, absorbing rounding errors accumulated by pivoting.
+const epsilon = 1e-9
+
+var (
+	// ErrUnbounded is returned when the objective could grow without bound, i.e. the dual problem is infeasible.
+	ErrUnbounded = errors.New("lp: problem is unbounded")
+	// ErrIterationLimit is returned when the solver has not converged within its iteration limit.
+	ErrIterationLimit = errors.New("lp: iteration limit exceeded")
+)
+
+// Solution is the optimal solution of a problem solved by Maximize.
+type Solution struct {
+	// X is the optimal value of every variable.
+	X []float64
+	// Value is the optimal value of the objective.
+	Value float64
+	// Duals are the shadow prices of every constraint, which are also the optimal solution of the dual problem:
+	// minimize b·u subject to Aᵀu >= c and u >= 0.
+	Duals []float64
+}
+
+// Maximize solves the problem of maximizing c·x subject to Ax <= b and x >= 0, where every element of b is
+// non-negative so that the origin is feasible. Pivots are chosen by Bland's rule, so that the solver never cycles.
+func Maximize(c []float64, a [][]float64, b []float64) (*Solution, error) {
+	n, m := len(c), len(b)
+	if len(a) != m {
+		return nil, errors.Errorf("lp: %d rows of constraints for %d bounds", len(a), m)
+	}
+	for i, row := range a {
+		if len(row) != n {
+			return nil, errors.Errorf("lp: %d coefficients of constraint %d for %d variables", len(row), i, n)
+		}
+		if b[i] < 0 {
+			return nil, errors.Errorf("lp: negative bound %g of constraint %d", b[i], i)
+		}
+	}
+
+	// the tableau has a row per constraint, followed by the objective row, and a column per variable and slack
+	// variable, followed by the right-hand side
+	width := n + m + 1
+	tableau := make([][]float64, m+1)
+	for i := 0; i < m; i++ {
+		tableau[i] = make([]float64, width)
+		copy(tableau[i], a[i])
+		tableau[i][n+i] = 1
+		tableau[i][width-1] = b[i]
+	}
+	objective := make([]float64, width)
+	for j, cj := range c {
+		objective[j] = -cj
+	}
+	tableau[m] = objective
+
+	// basis is the variable of every row, starting with slack variables
+	basis := make([]int, m)
+	for i := range basis {
+		basis[i] = n + i
+	}
+
+	// Bland's rule terminates within the number of bases, which is far beyond this limit for any useful problem
+	maxIterations := 50 * (n + m + 1)
+	for iteration := 0; ; iteration++ {
+		if iteration >= maxIterations {
+			return nil, ErrIterationLimit
+		}
+
+		entering := -1
+		for j := 0; j < n+m; j++ {
+			if objective[j] < -epsilon {
+				entering = j
+				break
+			}
+		}
+		if entering < 0 {
+			break
+		}
+
+		leaving := -1
+		minRatio := math.Inf(1)
+		for i := 0; i < m; i++ {
+			coefficient := tableau[i][entering]
+			if coefficient <= epsilon {
+				continue
+			}
+			ratio := tableau[i][width-1] / coefficient
+			if ratio < minRatio-epsilon || (ratio < minRatio+epsilon && leaving >= 0 && basis[i] < basis[leaving]) {
+				leaving = i
+				minRatio = ratio
+			}
+		}
+		if leaving < 0 {
+			return nil, ErrUnbounded
+		}
+
+		pivot(tableau, leaving, entering)
+		basis[leaving] = entering
+	}
+
+	solution := &Solution{
+		X:     make([]float64, n),
+		Value: objective[width-1],
+		Duals: make([]float64, m),
+	}
+	for i, variable := range basis {
+		if variable < n {
+			solution.X[variable] = tableau[i][width-1]
+		}
+	}
+	for i := 0; i < m; i++ {
+		solution.Duals[i] = objective[n+i]
+	}
+	return solution, nil
+}
+
+// pivot makes the variable of column the basic variable of row.
+func pivot(tableau [][]float64, row, column int) {
+	pivotRow := tableau[row]
+	factor := pivotRow[column]
+	for j := range pivotRow {
+		pivotRow[j] /= factor
+	}
+	for i, other := range tableau {
+		if i == row {
+			continue
+		}
+		ratio := other[column]
+		if ratio == 0 {
+			continue
+		}
+		for j := range other {
+			other[j] -= ratio * pivotRow[j]
+		}
+	}
+}
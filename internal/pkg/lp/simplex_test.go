@@ -0,0 +1,91 @@
+package lp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMaximize(t *testing.T) {
+	tests := []struct {
+		name  string
+		c     []float64
+		a     [][]float64
+		b     []float64
+		x     []float64
+		value float64
+		duals []float64
+	}{
+		{
+			"textbook",
+			[]float64{3, 5},
+			[][]float64{{1, 0}, {0, 2}, {3, 2}},
+			[]float64{4, 12, 18},
+			[]float64{2, 6}, 36, []float64{0, 1.5, 1},
+		},
+		{
+			"fractional vertex",
+			[]float64{1, 1},
+			[][]float64{{1, 2}, {3, 1}},
+			[]float64{4, 6},
+			[]float64{1.6, 1.2}, 2.8, []float64{0.4, 0.2},
+		},
+		{
+			"degenerate origin",
+			[]float64{1, 1},
+			[][]float64{{1, 0}, {0, 1}},
+			[]float64{0, 0},
+			[]float64{0, 0}, 0, []float64{1, 1},
+		},
+		{
+			"no improving variable",
+			[]float64{-1, 0},
+			[][]float64{{1, 1}},
+			[]float64{1},
+			[]float64{0, 0}, 0, []float64{0},
+		},
+	}
+	for _, test := range tests {
+		solution, err := Maximize(test.c, test.a, test.b)
+		if err != nil {
+			t.Errorf("%s: expected no error, got %v", test.name, err)
+			continue
+		}
+		if !approxEqual(solution.X, test.x) || math.Abs(solution.Value-test.value) > 1e-6 || !approxEqual(solution.Duals, test.duals) {
+			t.Errorf("%s: expected x %v, value %v and duals %v, got %v, %v and %v",
+				test.name, test.x, test.value, test.duals, solution.X, solution.Value, solution.Duals)
+		}
+	}
+}
+
+func TestMaximizeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		c    []float64
+		a    [][]float64
+		b    []float64
+		err  error
+	}{
+		{"unbounded", []float64{1}, [][]float64{{-1}}, []float64{1}, ErrUnbounded},
+		{"rows mismatch bounds", []float64{1}, [][]float64{{1}, {1}}, []float64{1}, nil},
+		{"coefficients mismatch variables", []float64{1, 1}, [][]float64{{1}}, []float64{1}, nil},
+		{"negative bound", []float64{1}, [][]float64{{1}}, []float64{-1}, nil},
+	}
+	for _, test := range tests {
+		_, err := Maximize(test.c, test.a, test.b)
+		if err == nil || (test.err != nil && err != test.err) {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func approxEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-6 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"context"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// AuthenticateAPIKey authenticates requests carrying an API key in the `Authorization: Bearer` header with
+// authenticate, and makes them act as the account of the key. Requests without an API key are passed through.
+func AuthenticateAPIKey(authenticate func(ctx context.Context, key string) (penguinId string, keyId int, err error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		authorization := ctx.Get(fiber.HeaderAuthorization)
+		if !strings.HasPrefix(authorization, constant.APIKeyAuthorizationRealm+" ") {
+			return ctx.Next()
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(authorization, constant.APIKeyAuthorizationRealm))
+		if !strings.HasPrefix(key, constant.APIKeyPrefix) {
+			return ctx.Next()
+		}
+
+		penguinId, keyId, err := authenticate(ctx.Context(), key)
+		if err != nil {
+			return err
+		}
+		ctx.Locals(constant.ContextKeyPenguinID, penguinId)
+		ctx.Locals(constant.ContextKeyAPIKeyID, keyId)
+		return ctx.Next()
+	}
+}
+
+// RefuseAPIKey refuses requests authenticated with an API key, for routes managing the account itself, so that a
+// leaked key, meant for automated reporters only, could not be escalated into taking over the account.
+func RefuseAPIKey() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Locals(constant.ContextKeyAPIKeyID) != nil {
+			return pgerr.ErrUnauthorized.Msg("unauthorized: the account could not be managed with an api key")
+		}
+		return ctx.Next()
+	}
+}
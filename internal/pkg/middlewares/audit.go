@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+)
+
+// Audit records requests which could mutate, i.e. those not of GET, HEAD or OPTIONS, with record after they have
+// been handled. Handlers could attach snapshots of records they change to the audit.Trail of the request via
+// audit.Before and audit.After with the context of the request.
+func Audit(record func(ctx *fiber.Ctx, trail *audit.Trail, err error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		switch ctx.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return ctx.Next()
+		}
+
+		trail := audit.Begin(ctx)
+		err := ctx.Next()
+		record(ctx, trail, err)
+		return err
+	}
+}
@@ -0,0 +1,46 @@
+package middlewares
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+)
+
+// Metrics records the count, the duration and the response size of requests to Prometheus, per route template,
+// method and status. Requests matching no route are recorded with the route template of the last middleware they
+// have passed through.
+func Metrics(metricsPath string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		// the route is only resolved to the matching route template after the handlers have run
+		route := c.Route().Path
+		if route == metricsPath {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// the status of errors is only written later by the error handler
+			status = fiber.StatusInternalServerError
+			var fiberErr *fiber.Error
+			if errors.As(err, &fiberErr) {
+				status = fiberErr.Code
+			}
+		}
+
+		labels := []string{route, c.Method(), strconv.Itoa(status)}
+		observability.HTTPRequests.WithLabelValues(labels...).Inc()
+		observability.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+		// the size of streamed responses is unknown until they have been written
+		if !c.Response().IsBodyStream() {
+			observability.HTTPResponseSize.WithLabelValues(labels...).Observe(float64(len(c.Response().Body())))
+		}
+		return err
+	}
+}
@@ -0,0 +1,45 @@
+package middlewares
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/flog"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// RateLimit counts requests with take, sets the standard RateLimit-* headers, and rejects requests exceeding
+// the limit with pgerr.ErrRateLimited, along with the Retry-After header. Rate limiting is best-effort: requests are let through if take fails.
+func RateLimit(take func(ctx *fiber.Ctx) (*types.RateLimitStatus, error)) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if ctx.Method() == fiber.MethodOptions {
+			return ctx.Next()
+		}
+
+		status, err := take(ctx)
+		if err != nil {
+			flog.WarnFrom(ctx).Err(err).Msg("failed to rate limit request")
+			return ctx.Next()
+		}
+		if status == nil {
+			return ctx.Next()
+		}
+
+		ctx.Set(constant.RateLimitLimitHeader, strconv.Itoa(status.Limit))
+		ctx.Set(constant.RateLimitRemainingHeader, strconv.Itoa(status.Remaining))
+		ctx.Set(constant.RateLimitResetHeader, strconv.Itoa(status.Reset))
+
+		if !status.Allowed {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(status.RetryAfter))
+			return pgerr.ErrRateLimited.Msg("too many requests: at most %d requests are accepted in a burst", status.Limit).
+				WithExtras(pgerr.Extras{
+					"limit":      status.Limit,
+					"retryAfter": status.RetryAfter,
+				})
+		}
+		return ctx.Next()
+	}
+}
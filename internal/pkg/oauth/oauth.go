@@ -0,0 +1,177 @@
+// Package oauth implements the OAuth2 authorization code grant against external identity providers, which is
+// used to attach external identities to PenguinIDs.
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGoogle = "google"
+)
+
+var client = &http.Client{
+	Timeout: time.Second * 10,
+}
+
+// Identity is the identity of a user at an identity provider.
+type Identity struct {
+	// Subject uniquely identifies the user at the identity provider, and never changes.
+	Subject string
+	// Name is the display name of the user, for users to tell attached identities apart.
+	Name string
+}
+
+// Provider is an OAuth2 identity provider supporting the authorization code grant.
+type Provider struct {
+	Name         string
+	ClientID     string
+	ClientSecret string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	Scopes       []string
+
+	// parseIdentity extracts the identity from the response body of UserInfoURL
+	parseIdentity func(body []byte) (*Identity, error)
+}
+
+func GitHub(clientId, clientSecret string) *Provider {
+	return &Provider{
+		Name:         ProviderGitHub,
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://github.com/login/oauth/authorize",
+		TokenURL:     "https://github.com/login/oauth/access_token",
+		UserInfoURL:  "https://api.github.com/user",
+		Scopes:       []string{"read:user"},
+		parseIdentity: func(body []byte) (*Identity, error) {
+			var user struct {
+				ID    int64  `json:"id"`
+				Login string `json:"login"`
+			}
+			if err := json.Unmarshal(body, &user); err != nil {
+				return nil, err
+			}
+			if user.ID == 0 {
+				return nil, errors.New("github user id not found")
+			}
+			return &Identity{Subject: strconv.FormatInt(user.ID, 10), Name: user.Login}, nil
+		},
+	}
+}
+
+func Google(clientId, clientSecret string) *Provider {
+	return &Provider{
+		Name:         ProviderGoogle,
+		ClientID:     clientId,
+		ClientSecret: clientSecret,
+		AuthURL:      "https://accounts.google.com/o/oauth2/v2/auth",
+		TokenURL:     "https://oauth2.googleapis.com/token",
+		UserInfoURL:  "https://openidconnect.googleapis.com/v1/userinfo",
+		Scopes:       []string{"openid", "profile"},
+		parseIdentity: func(body []byte) (*Identity, error) {
+			var user struct {
+				Sub  string `json:"sub"`
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(body, &user); err != nil {
+				return nil, err
+			}
+			if user.Sub == "" {
+				return nil, errors.New("google user subject not found")
+			}
+			return &Identity{Subject: user.Sub, Name: user.Name}, nil
+		},
+	}
+}
+
+// AuthCodeURL returns the URL to redirect the user to for authorization, which redirects back to redirectURI
+// with the authorization code and state.
+func (p *Provider) AuthCodeURL(state string, redirectURI string) string {
+	v := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.ClientID},
+		"redirect_uri":  {redirectURI},
+		"scope":         {strings.Join(p.Scopes, " ")},
+		"state":         {state},
+	}
+	return p.AuthURL + "?" + v.Encode()
+}
+
+// Exchange exchanges the authorization code for an access token. redirectURI must be the same as the one
+// the authorization code has been requested with.
+func (p *Provider) Exchange(ctx context.Context, code string, redirectURI string) (string, error) {
+	v := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenURL, strings.NewReader(v.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	body, err := do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to exchange authorization code")
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", err
+	}
+	if token.AccessToken == "" {
+		return "", errors.Errorf("failed to exchange authorization code: %s", token.Error)
+	}
+	return token.AccessToken, nil
+}
+
+// FetchIdentity returns the identity of the user who granted accessToken.
+func (p *Provider) FetchIdentity(ctx context.Context, accessToken string) (*Identity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.UserInfoURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/json")
+
+	body, err := do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fetch identity")
+	}
+	return p.parseIdentity(body)
+}
+
+func do(req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, errors.Errorf("%s responded with status %d", req.URL.Host, resp.StatusCode)
+	}
+	return body, nil
+}
@@ -0,0 +1,304 @@
+// Package objstore implements a minimal client of S3-compatible object storage, which signs requests with AWS
+// Signature Version 4. Objects are addressed in path style, which all S3-compatible providers support.
+package objstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	signingAlgorithm = "AWS4-HMAC-SHA256"
+	unsignedPayload  = "UNSIGNED-PAYLOAD"
+	// emptyPayloadHash is the SHA-256 hash of an empty payload
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	amzDateFormat   = "20060102T150405Z"
+	amzScopeFormat  = "20060102"
+	maxPresignedTTL = time.Hour * 24 * 7
+)
+
+var client = &http.Client{
+	Timeout: time.Minute * 10,
+}
+
+// Client accesses objects of a bucket of S3-compatible object storage.
+type Client struct {
+	endpoint        *url.URL
+	region          string
+	bucket          string
+	accessKeyId     string
+	secretAccessKey string
+}
+
+// Object describes an object in a bucket.
+type Object struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// New returns a client of bucket at endpoint, e.g. `https://s3.us-east-1.amazonaws.com` or the URL of a MinIO
+// server.
+func New(endpoint, region, bucket, accessKeyId, secretAccessKey string) (*Client, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid object storage endpoint")
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, errors.Errorf("invalid object storage endpoint `%s`: scheme and host are required", endpoint)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	return &Client{
+		endpoint:        u,
+		region:          region,
+		bucket:          bucket,
+		accessKeyId:     accessKeyId,
+		secretAccessKey: secretAccessKey,
+	}, nil
+}
+
+// PutObject uploads size bytes of body as the object of key.
+func (c *Client) PutObject(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.objectURL(key, nil).String(), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	c.sign(req, unsignedPayload, time.Now())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to put object `%s`", key)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp, "put object `"+key+"`")
+}
+
+// ListObjects returns all objects whose key starts with prefix, in the lexicographical order of keys.
+func (c *Client) ListObjects(ctx context.Context, prefix string) ([]*Object, error) {
+	var result struct {
+		IsTruncated           bool   `xml:"IsTruncated"`
+		NextContinuationToken string `xml:"NextContinuationToken"`
+		Contents              []struct {
+			Key          string    `xml:"Key"`
+			Size         int64     `xml:"Size"`
+			LastModified time.Time `xml:"LastModified"`
+		} `xml:"Contents"`
+	}
+
+	objects := make([]*Object, 0)
+	continuationToken := ""
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"prefix":    {prefix},
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.objectURL("", query).String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		c.sign(req, emptyPayloadHash, time.Now())
+
+		err = func() error {
+			resp, err := client.Do(req)
+			if err != nil {
+				return errors.Wrap(err, "failed to list objects")
+			}
+			defer resp.Body.Close()
+			if err := checkResponse(resp, "list objects"); err != nil {
+				return err
+			}
+			result.IsTruncated, result.NextContinuationToken, result.Contents = false, "", nil
+			return xml.NewDecoder(resp.Body).Decode(&result)
+		}()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, content := range result.Contents {
+			objects = append(objects, &Object{
+				Key:          content.Key,
+				Size:         content.Size,
+				LastModified: content.LastModified,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return objects, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+// DeleteObject deletes the object of key. Deleting an object which does not exist succeeds.
+func (c *Client) DeleteObject(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.objectURL(key, nil).String(), nil)
+	if err != nil {
+		return err
+	}
+	c.sign(req, emptyPayloadHash, time.Now())
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete object `%s`", key)
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp, "delete object `"+key+"`")
+}
+
+// PresignGetObject returns a URL to download the object of key without credentials, which expires after ttl.
+// ttl is capped at 7 days, the longest validity of presigned URLs.
+func (c *Client) PresignGetObject(key string, ttl time.Duration) string {
+	return c.presign(c.objectURL(key, nil), ttl, time.Now())
+}
+
+// presign signs u with query parameters as of t, which expires after ttl.
+func (c *Client) presign(u *url.URL, ttl time.Duration, t time.Time) string {
+	if ttl > maxPresignedTTL {
+		ttl = maxPresignedTTL
+	}
+	t = t.UTC()
+	query := url.Values{
+		"X-Amz-Algorithm":     {signingAlgorithm},
+		"X-Amz-Credential":    {c.accessKeyId + "/" + c.scope(t)},
+		"X-Amz-Date":          {t.Format(amzDateFormat)},
+		"X-Amz-Expires":       {strconv.Itoa(int(ttl.Seconds()))},
+		"X-Amz-SignedHeaders": {"host"},
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	canonicalRequest := strings.Join([]string{
+		http.MethodGet,
+		u.EscapedPath(),
+		u.RawQuery,
+		"host:" + u.Host + "\n",
+		"host",
+		unsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", c.signature(t, canonicalRequest))
+	u.RawQuery = canonicalQuery(query)
+	return u.String()
+}
+
+// objectURL returns the URL of the object of key, or of the bucket when key is empty, with query.
+func (c *Client) objectURL(key string, query url.Values) *url.URL {
+	path := c.endpoint.Path + "/" + c.bucket
+	if key != "" {
+		path += "/" + key
+	}
+	u := *c.endpoint
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+	return &u
+}
+
+// sign signs req with the authorization header, where payloadHash is either the hex encoded SHA-256 hash of the
+// payload or unsignedPayload.
+func (c *Client) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	req.Header.Set("X-Amz-Date", t.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + t.Format(amzDateFormat) + "\n"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signingAlgorithm, c.accessKeyId, c.scope(t), signedHeaders, c.signature(t, canonicalRequest)))
+}
+
+func (c *Client) scope(t time.Time) string {
+	return t.Format(amzScopeFormat) + "/" + c.region + "/s3/aws4_request"
+}
+
+func (c *Client) signature(t time.Time, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		signingAlgorithm,
+		t.Format(amzDateFormat),
+		c.scope(t),
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.secretAccessKey), t.Format(amzScopeFormat))
+	key = hmacSHA256(key, c.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query sorted by keys, as required by Signature Version 4.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(query))
+	for _, key := range keys {
+		values := append([]string(nil), query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes s except for unreserved characters, and slashes unless encodeSlash is true.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ('A' <= ch && ch <= 'Z') || ('a' <= ch && ch <= 'z') || ('0' <= ch && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' || (ch == '/' && !encodeSlash) {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func checkResponse(resp *http.Response, action string) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return errors.Errorf("failed to %s: unexpected status %d: %s", action, resp.StatusCode, body)
+}
@@ -15,13 +15,122 @@ var (
 		Help:    "Duration of report verification in seconds",
 		Buckets: prometheus.ExponentialBuckets(0.01, 2, 10),
 	}, []string{"verifier"})
+	ReportVerifyRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "verify_rejections"),
+		Help: "Count of reports rejected by each verifier",
+	}, []string{"verifier"})
 	ReportConsumeDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
 		Name:    prometheus.BuildFQName(ServiceName, "report", "consume_duration_seconds"),
 		Help:    "Duration of report consumption in seconds",
 		Buckets: prometheus.ExponentialBuckets(0.01, 2, 10),
 	}, []string{})
+	ReportStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prometheus.BuildFQName(ServiceName, "report", "stage_duration_seconds"),
+		Help:    "Duration of each stage of the report pipeline in seconds, excluding stages after it",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 14),
+	}, []string{"stage"})
+	ReportStageFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "stage_failures"),
+		Help: "Count of report tasks failed by each stage of the report pipeline, excluding failures of stages after it",
+	}, []string{"stage"})
 	ReportReliability = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: prometheus.BuildFQName(ServiceName, "report", "reliability"),
 		Help: "Reliability distribution of report consumption",
 	}, []string{"reliability", "source_name"})
+	ReportFixedDropContradiction = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "fixed_drop_contradiction"),
+		Help: "Count of reports whose drop quantity contradicts a fixed-rate drop",
+	}, []string{"stage", "item"})
+	ReportConsumerPending = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report_consumer", "pending_messages"),
+		Help: "Number of report tasks in the stream not yet delivered to the consumer",
+	}, []string{"consumer"})
+	ReportConsumerAckPending = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report_consumer", "ack_pending_messages"),
+		Help: "Number of report tasks delivered to the consumer but not yet acked",
+	}, []string{"consumer"})
+	ReportConsumerRedelivered = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report_consumer", "redelivered_messages"),
+		Help: "Number of un-acked report tasks that have been delivered more than once",
+	}, []string{"consumer"})
+	ReportConsumerWaiting = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report_consumer", "waiting_pull_requests"),
+		Help: "Number of pull requests waiting for report tasks. Zero while pending is high means workers are saturated",
+	}, []string{"consumer"})
+	ReportConsumerAckWait = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report_consumer", "ack_wait_seconds"),
+		Help: "Configured duration the consumer waits for an ack before redelivering a report task",
+	}, []string{"consumer"})
+	ReportDeliveryLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prometheus.BuildFQName(ServiceName, "report_consumer", "delivery_lag_seconds"),
+		Help:    "Duration between a report task being published and being delivered to a worker, per subject",
+		Buckets: prometheus.ExponentialBuckets(0.01, 2, 14),
+	}, []string{"subject"})
+	ReportDeliveries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report_consumer", "deliveries"),
+		Help: "Count of report task deliveries per subject, by whether the delivery is a redelivery",
+	}, []string{"subject", "redelivery"})
+	ReportInFlight = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report_consumer", "in_flight_messages"),
+		Help: "Number of report tasks being processed by this instance, per subject",
+	}, []string{"subject"})
+	ReportFraudScore = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prometheus.BuildFQName(ServiceName, "report", "fraud_score"),
+		Help:    "Distribution of how likely reports are fake as scored by the scoring service, per source",
+		Buckets: prometheus.LinearBuckets(0.1, 0.1, 10),
+	}, []string{"source_name"})
+	ReportScoringFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "scoring_failures"),
+		Help: "Count of report tasks failed to be scored, by reason: error, timeout, invalid or circuit_open",
+	}, []string{"reason"})
+	ReportPublishFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "publish_failures"),
+		Help: "Count of failed attempts to publish report tasks per subject, by reason: error, timeout or circuit_open",
+	}, []string{"subject", "reason"})
+	ReportPublishRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "publish_retries"),
+		Help: "Count of retries of publishing report tasks per subject",
+	}, []string{"subject"})
+	ReportPublishBreakerOpen = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "publish_breaker_open"),
+		Help: "Whether publishing report tasks is being rejected after repeated failures, 1 if so",
+	})
+	ReportSpooled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "spooled"),
+		Help: "Count of report tasks written to the local spool as they could not be published, per subject",
+	}, []string{"subject"})
+	ReportSpoolReplayed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "spool_replayed"),
+		Help: "Count of report tasks replayed from the local spool to NATS, per subject",
+	}, []string{"subject"})
+	ReportSpoolPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "report", "spool_pending"),
+		Help: "Number of report tasks in the local spool waiting to be replayed",
+	})
+	WorkerLeader = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: prometheus.BuildFQName(ServiceName, "worker", "leader"),
+		Help: "Whether this instance is the leader running workers of the election, 1 if so",
+	}, []string{"election"})
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "http", "requests_total"),
+		Help: "Count of HTTP requests per route template, method and status",
+	}, []string{"route", "method", "status"})
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prometheus.BuildFQName(ServiceName, "http", "request_duration_seconds"),
+		Help:    "Duration of HTTP requests in seconds per route template, method and status",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 15),
+	}, []string{"route", "method", "status"})
+	HTTPResponseSize = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    prometheus.BuildFQName(ServiceName, "http", "response_size_bytes"),
+		Help:    "Size of bodies of HTTP responses in bytes per route template, method and status, excluding streamed responses",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 10),
+	}, []string{"route", "method", "status"})
+	DBSlowQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "db", "slow_queries"),
+		Help: "Count of database queries slower than the slow query threshold, per operation and caller",
+	}, []string{"operation", "caller"})
+	DBQueryTimeouts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: prometheus.BuildFQName(ServiceName, "db", "query_timeouts"),
+		Help: "Count of database queries cancelled for exceeding their timeout, per operation and caller",
+	}, []string{"operation", "caller"})
 )
@@ -0,0 +1,48 @@
+package observability
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/penguin-statistics/fiberotel"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer spans of this service are started with.
+const TracerName = "backendv3"
+
+// Tracer returns the tracer of this service, from the global tracer provider. Spans are not recorded unless
+// tracing has been enabled with config.Config TracingEnabled.
+func Tracer() trace.Tracer {
+	return otel.Tracer(TracerName)
+}
+
+// FiberContext returns the context carrying the span of the request started by the tracing middleware, or the
+// context of the request itself when tracing is disabled.
+func FiberContext(ctx *fiber.Ctx) context.Context {
+	if otelCtx, ok := ctx.Locals(fiberotel.LocalsCtxKey).(context.Context); ok {
+		return otelCtx
+	}
+	return ctx.Context()
+}
+
+// InjectTraceContext returns the trace context of ctx, to be carried by messages such as report tasks so that
+// their consumers could continue the trace. Returns nil when ctx carries no trace or tracing is disabled.
+func InjectTraceContext(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// ExtractTraceContext returns ctx continuing the trace carried by carrier, as returned by InjectTraceContext.
+func ExtractTraceContext(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
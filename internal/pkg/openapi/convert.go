@@ -0,0 +1,343 @@
+// Package openapi converts the Swagger 2.0 document generated by swag from annotations of controllers into an
+// OpenAPI 3.0 document, so that client SDKs are generated from a spec that never drifts from the controllers.
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Version is the version of OpenAPI documents converted to.
+const Version = "3.0.3"
+
+// References to definitions of Swagger 2.0 documents are prefixed by swagger2DefinitionsRef, and are rewritten to
+// references to schemas of components of OpenAPI 3.0 documents, prefixed by openAPI3SchemasRef.
+const (
+	swagger2DefinitionsRef = "#/definitions/"
+	openAPI3SchemasRef     = "#/components/schemas/"
+)
+
+// parameterSchemaKeys are keys of non-body parameters of Swagger 2.0 documents which are keys of schemas of
+// parameters in OpenAPI 3.0 documents instead.
+var parameterSchemaKeys = []string{
+	"type", "format", "items", "default", "enum", "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+	"minLength", "maxLength", "pattern", "minItems", "maxItems", "uniqueItems", "multipleOf",
+}
+
+// FromSwagger2 converts doc, being a Swagger 2.0 document in JSON, into an OpenAPI 3.0 document in JSON.
+func FromSwagger2(doc []byte) ([]byte, error) {
+	var swagger map[string]any
+	if err := json.Unmarshal(doc, &swagger); err != nil {
+		return nil, errors.Wrap(err, "invalid swagger document")
+	}
+	if version, _ := swagger["swagger"].(string); version != "2.0" {
+		return nil, errors.Errorf("unsupported swagger version %q", version)
+	}
+
+	openapi := map[string]any{
+		"openapi": Version,
+		"info":    swagger["info"],
+		"servers": servers(swagger),
+		"paths":   map[string]any{},
+	}
+	if tags, ok := swagger["tags"]; ok {
+		openapi["tags"] = tags
+	}
+	if security, ok := swagger["security"]; ok {
+		openapi["security"] = security
+	}
+
+	components := map[string]any{}
+	if definitions, ok := swagger["definitions"].(map[string]any); ok {
+		components["schemas"] = definitions
+	}
+	if securityDefinitions, ok := swagger["securityDefinitions"].(map[string]any); ok {
+		schemes := make(map[string]any, len(securityDefinitions))
+		for name, definition := range securityDefinitions {
+			schemes[name] = securityScheme(asMap(definition))
+		}
+		components["securitySchemes"] = schemes
+	}
+	openapi["components"] = components
+
+	globalConsumes := asStrings(swagger["consumes"])
+	globalProduces := asStrings(swagger["produces"])
+	paths := openapi["paths"].(map[string]any)
+	for path, item := range asMap(swagger["paths"]) {
+		converted := map[string]any{}
+		for method, operation := range asMap(item) {
+			if method == "parameters" {
+				converted[method] = parameters(asSlice(operation))
+				continue
+			}
+			converted[method] = convertOperation(asMap(operation), globalConsumes, globalProduces)
+		}
+		paths[path] = converted
+	}
+
+	rewriteRefs(openapi)
+	return json.MarshalIndent(openapi, "", "    ")
+}
+
+// servers returns servers of swagger, formed by its schemes, host and base path.
+func servers(swagger map[string]any) []any {
+	host, _ := swagger["host"].(string)
+	basePath, _ := swagger["basePath"].(string)
+	basePath = strings.TrimSuffix(basePath, "/")
+	if host == "" {
+		return []any{map[string]any{"url": basePath + "/"}}
+	}
+
+	schemes := asStrings(swagger["schemes"])
+	if len(schemes) == 0 {
+		schemes = []string{"https"}
+	}
+	servers := make([]any, 0, len(schemes))
+	for _, scheme := range schemes {
+		servers = append(servers, map[string]any{"url": scheme + "://" + host + basePath})
+	}
+	return servers
+}
+
+func convertOperation(operation map[string]any, globalConsumes, globalProduces []string) map[string]any {
+	consumes := asStrings(operation["consumes"])
+	if len(consumes) == 0 {
+		consumes = globalConsumes
+	}
+	if len(consumes) == 0 {
+		consumes = []string{"application/json"}
+	}
+	produces := asStrings(operation["produces"])
+	if len(produces) == 0 {
+		produces = globalProduces
+	}
+	if len(produces) == 0 {
+		produces = []string{"application/json"}
+	}
+
+	converted := map[string]any{}
+	for key, value := range operation {
+		switch key {
+		case "consumes", "produces", "parameters", "responses", "schemes":
+		default:
+			converted[key] = value
+		}
+	}
+
+	var (
+		params     []any
+		body       map[string]any
+		formSchema map[string]any
+	)
+	for _, parameter := range asSlice(operation["parameters"]) {
+		parameter := asMap(parameter)
+		switch parameter["in"] {
+		case "body":
+			body = map[string]any{"content": contentOf(consumes, parameter["schema"])}
+			if description, ok := parameter["description"]; ok {
+				body["description"] = description
+			}
+			if required, ok := parameter["required"]; ok {
+				body["required"] = required
+			}
+		case "formData":
+			if formSchema == nil {
+				formSchema = map[string]any{"type": "object", "properties": map[string]any{}}
+			}
+			formSchema["properties"].(map[string]any)[parameter["name"].(string)] = parameterSchema(parameter)
+			if required, _ := parameter["required"].(bool); required {
+				formSchema["required"] = append(asSlice(formSchema["required"]), parameter["name"])
+			}
+		default:
+			params = append(params, parameters([]any{parameter})...)
+		}
+	}
+	if len(params) > 0 {
+		converted["parameters"] = params
+	}
+	if formSchema != nil {
+		formConsumes := []string{"application/x-www-form-urlencoded"}
+		if strings.HasPrefix(consumes[0], "multipart/") {
+			formConsumes = consumes[:1]
+		}
+		body = map[string]any{"content": contentOf(formConsumes, formSchema)}
+	}
+	if body != nil {
+		converted["requestBody"] = body
+	}
+
+	responses := map[string]any{}
+	for code, response := range asMap(operation["responses"]) {
+		response := asMap(response)
+		convertedResponse := map[string]any{"description": response["description"]}
+		if convertedResponse["description"] == nil {
+			convertedResponse["description"] = ""
+		}
+		if schema, ok := response["schema"]; ok {
+			convertedResponse["content"] = contentOf(produces, schema)
+		}
+		if headers, ok := response["headers"].(map[string]any); ok {
+			convertedHeaders := make(map[string]any, len(headers))
+			for name, header := range headers {
+				header := asMap(header)
+				convertedHeader := map[string]any{"schema": parameterSchema(header)}
+				if description, ok := header["description"]; ok {
+					convertedHeader["description"] = description
+				}
+				convertedHeaders[name] = convertedHeader
+			}
+			convertedResponse["headers"] = convertedHeaders
+		}
+		responses[code] = convertedResponse
+	}
+	converted["responses"] = responses
+	return converted
+}
+
+// parameters converts non-body parameters, moving their types into schemas.
+func parameters(params []any) []any {
+	converted := make([]any, 0, len(params))
+	for _, parameter := range params {
+		parameter := asMap(parameter)
+		if ref, ok := parameter["$ref"]; ok {
+			converted = append(converted, map[string]any{"$ref": ref})
+			continue
+		}
+		convertedParameter := map[string]any{
+			"name":   parameter["name"],
+			"in":     parameter["in"],
+			"schema": parameterSchema(parameter),
+		}
+		for _, key := range []string{"description", "required", "allowEmptyValue"} {
+			if value, ok := parameter[key]; ok {
+				convertedParameter[key] = value
+			}
+		}
+		if parameter["in"] == "path" {
+			convertedParameter["required"] = true
+		}
+		// only query parameters are delimited differently from the default simple style
+		if parameter["in"] != "query" {
+			converted = append(converted, convertedParameter)
+			continue
+		}
+		switch parameter["collectionFormat"] {
+		case "csv":
+			convertedParameter["style"], convertedParameter["explode"] = "form", false
+		case "ssv":
+			convertedParameter["style"] = "spaceDelimited"
+		case "pipes":
+			convertedParameter["style"] = "pipeDelimited"
+		case "multi":
+			convertedParameter["style"], convertedParameter["explode"] = "form", true
+		}
+		converted = append(converted, convertedParameter)
+	}
+	return converted
+}
+
+// parameterSchema returns the schema of a non-body parameter or header, formed by its type and constraints.
+func parameterSchema(parameter map[string]any) map[string]any {
+	schema := map[string]any{}
+	for _, key := range parameterSchemaKeys {
+		if value, ok := parameter[key]; ok {
+			schema[key] = value
+		}
+	}
+	if schema["type"] == "file" {
+		schema["type"], schema["format"] = "string", "binary"
+	}
+	if example, ok := parameter["example"]; ok {
+		schema["example"] = example
+	}
+	return schema
+}
+
+func securityScheme(definition map[string]any) map[string]any {
+	switch definition["type"] {
+	case "basic":
+		return map[string]any{"type": "http", "scheme": "basic", "description": definition["description"]}
+	case "oauth2":
+		flow := map[string]any{"scopes": definition["scopes"]}
+		if url, ok := definition["authorizationUrl"]; ok {
+			flow["authorizationUrl"] = url
+		}
+		if url, ok := definition["tokenUrl"]; ok {
+			flow["tokenUrl"] = url
+		}
+		flows := map[string]string{
+			"implicit":    "implicit",
+			"password":    "password",
+			"application": "clientCredentials",
+			"accessCode":  "authorizationCode",
+		}
+		flowName, _ := definition["flow"].(string)
+		return map[string]any{"type": "oauth2", "flows": map[string]any{flows[flowName]: flow}}
+	default:
+		return definition
+	}
+}
+
+// contentOf returns the content of schema in each of mediaTypes.
+func contentOf(mediaTypes []string, schema any) map[string]any {
+	content := make(map[string]any, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		content[mediaType] = map[string]any{"schema": schema}
+	}
+	return content
+}
+
+// rewriteRefs rewrites references to definitions to references to component schemas, and the Swagger 2.0 only
+// `x-nullable` extension to `nullable`, throughout v.
+func rewriteRefs(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		// keys are collected ahead, as v is modified while being walked
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			value := v[key]
+			if ref, ok := value.(string); ok && key == "$ref" {
+				if strings.HasPrefix(ref, swagger2DefinitionsRef) {
+					v[key] = openAPI3SchemasRef + strings.TrimPrefix(ref, swagger2DefinitionsRef)
+				}
+				continue
+			}
+			if key == "x-nullable" {
+				delete(v, key)
+				v["nullable"] = value
+				continue
+			}
+			rewriteRefs(value)
+		}
+	case []any:
+		for _, value := range v {
+			rewriteRefs(value)
+		}
+	}
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+func asStrings(v any) []string {
+	values := asSlice(v)
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,238 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, doc string) map[string]any {
+	t.Helper()
+	var m map[string]any
+	if err := json.Unmarshal([]byte(doc), &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestFromSwagger2RejectsOtherVersions(t *testing.T) {
+	tests := []string{
+		`not json`,
+		`{}`,
+		`{"swagger": "1.2"}`,
+		`{"openapi": "3.0.3"}`,
+	}
+	for _, doc := range tests {
+		if _, err := FromSwagger2([]byte(doc)); err == nil {
+			t.Errorf("FromSwagger2(%s): expected error, got nil", doc)
+		}
+	}
+}
+
+func TestFromSwagger2(t *testing.T) {
+	swagger := `{
+		"swagger": "2.0",
+		"info": {"title": "Penguin Statistics API", "version": "3.0.0"},
+		"host": "penguin-stats.io",
+		"basePath": "/api/",
+		"schemes": ["https"],
+		"securityDefinitions": {"Basic": {"type": "basic", "description": "admin"}},
+		"paths": {
+			"/PenguinStats/api/v2/report": {
+				"post": {
+					"consumes": ["application/json"],
+					"parameters": [
+						{"in": "body", "name": "report", "required": true, "schema": {"$ref": "#/definitions/types.SingleReportRequest"}},
+						{"in": "header", "name": "Authorization", "type": "string", "description": "PenguinID"}
+					],
+					"responses": {
+						"201": {
+							"description": "Report has been submitted",
+							"schema": {"$ref": "#/definitions/v2.ReportResponse"},
+							"headers": {"X-Penguin-Set-PenguinID": {"type": "string", "description": "PenguinID"}}
+						},
+						"400": {"schema": {"$ref": "#/definitions/pgerr.PenguinError"}}
+					}
+				}
+			},
+			"/PenguinStats/api/v2/stages/{stageId}": {
+				"get": {
+					"parameters": [
+						{"in": "path", "name": "stageId", "type": "string"},
+						{"in": "query", "name": "server", "type": "string", "enum": ["CN", "US"], "default": "CN"},
+						{"in": "query", "name": "itemFilter", "type": "array", "items": {"type": "string"}, "collectionFormat": "csv"}
+					],
+					"responses": {"200": {"description": "OK", "schema": {"$ref": "#/definitions/v2.Stage"}}}
+				}
+			},
+			"/PenguinStats/api/v2/recognition": {
+				"post": {
+					"consumes": ["multipart/form-data"],
+					"parameters": [
+						{"in": "formData", "name": "screenshot", "type": "file", "required": true},
+						{"in": "formData", "name": "server", "type": "string"}
+					],
+					"responses": {"200": {"description": "OK"}}
+				}
+			}
+		},
+		"definitions": {
+			"v2.Stage": {"type": "object", "properties": {
+				"zone": {"$ref": "#/definitions/v2.Zone"},
+				"minClearTime": {"type": "integer", "x-nullable": true}
+			}}
+		}
+	}`
+	expected := `{
+		"openapi": "3.0.3",
+		"info": {"title": "Penguin Statistics API", "version": "3.0.0"},
+		"servers": [{"url": "https://penguin-stats.io/api"}],
+		"components": {
+			"securitySchemes": {"Basic": {"type": "http", "scheme": "basic", "description": "admin"}},
+			"schemas": {
+				"v2.Stage": {"type": "object", "properties": {
+					"zone": {"$ref": "#/components/schemas/v2.Zone"},
+					"minClearTime": {"type": "integer", "nullable": true}
+				}}
+			}
+		},
+		"paths": {
+			"/PenguinStats/api/v2/report": {
+				"post": {
+					"parameters": [
+						{"in": "header", "name": "Authorization", "schema": {"type": "string"}, "description": "PenguinID"}
+					],
+					"requestBody": {
+						"required": true,
+						"content": {"application/json": {"schema": {"$ref": "#/components/schemas/types.SingleReportRequest"}}}
+					},
+					"responses": {
+						"201": {
+							"description": "Report has been submitted",
+							"content": {"application/json": {"schema": {"$ref": "#/components/schemas/v2.ReportResponse"}}},
+							"headers": {"X-Penguin-Set-PenguinID": {"schema": {"type": "string"}, "description": "PenguinID"}}
+						},
+						"400": {
+							"description": "",
+							"content": {"application/json": {"schema": {"$ref": "#/components/schemas/pgerr.PenguinError"}}}
+						}
+					}
+				}
+			},
+			"/PenguinStats/api/v2/stages/{stageId}": {
+				"get": {
+					"parameters": [
+						{"in": "path", "name": "stageId", "required": true, "schema": {"type": "string"}},
+						{"in": "query", "name": "server", "schema": {"type": "string", "enum": ["CN", "US"], "default": "CN"}},
+						{"in": "query", "name": "itemFilter", "schema": {"type": "array", "items": {"type": "string"}}, "style": "form", "explode": false}
+					],
+					"responses": {
+						"200": {"description": "OK", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/v2.Stage"}}}}
+					}
+				}
+			},
+			"/PenguinStats/api/v2/recognition": {
+				"post": {
+					"requestBody": {
+						"content": {"multipart/form-data": {"schema": {
+							"type": "object",
+							"properties": {
+								"screenshot": {"type": "string", "format": "binary"},
+								"server": {"type": "string"}
+							},
+							"required": ["screenshot"]
+						}}}
+					},
+					"responses": {"200": {"description": "OK"}}
+				}
+			}
+		}
+	}`
+
+	converted, err := FromSwagger2([]byte(swagger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := decode(t, string(converted)), decode(t, expected); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %s, got %s", expected, converted)
+	}
+}
+
+func TestServers(t *testing.T) {
+	tests := []struct {
+		swagger  string
+		expected []string
+	}{
+		{`{}`, []string{"/"}},
+		{`{"basePath": "/api/"}`, []string{"/api/"}},
+		{`{"host": "penguin-stats.io"}`, []string{"https://penguin-stats.io"}},
+		{`{"host": "penguin-stats.io", "basePath": "/api/", "schemes": ["http", "https"]}`, []string{"http://penguin-stats.io/api", "https://penguin-stats.io/api"}},
+	}
+	for _, test := range tests {
+		got := servers(decode(t, test.swagger))
+		urls := make([]string, 0, len(got))
+		for _, server := range got {
+			urls = append(urls, asMap(server)["url"].(string))
+		}
+		if !reflect.DeepEqual(urls, test.expected) {
+			t.Errorf("servers(%s): expected %v, got %v", test.swagger, test.expected, urls)
+		}
+	}
+}
+
+func TestParametersCollectionFormat(t *testing.T) {
+	tests := []struct {
+		in               string
+		collectionFormat string
+		style            any
+		explode          any
+	}{
+		{"query", "csv", "form", false},
+		{"query", "ssv", "spaceDelimited", nil},
+		{"query", "pipes", "pipeDelimited", nil},
+		{"query", "multi", "form", true},
+		{"query", "", nil, nil},
+		{"header", "csv", nil, nil},
+	}
+	for _, test := range tests {
+		parameter := map[string]any{"in": test.in, "name": "ids", "type": "array", "collectionFormat": test.collectionFormat}
+		converted := asMap(parameters([]any{parameter})[0])
+		if converted["style"] != test.style || converted["explode"] != test.explode {
+			t.Errorf("parameters(%s %q): expected style %v and explode %v, got %v and %v",
+				test.in, test.collectionFormat, test.style, test.explode, converted["style"], converted["explode"])
+		}
+	}
+}
+
+func TestSecurityScheme(t *testing.T) {
+	tests := []struct {
+		definition string
+		expected   string
+	}{
+		{
+			`{"type": "apiKey", "in": "header", "name": "Authorization"}`,
+			`{"type": "apiKey", "in": "header", "name": "Authorization"}`,
+		},
+		{
+			`{"type": "basic"}`,
+			`{"type": "http", "scheme": "basic", "description": null}`,
+		},
+		{
+			`{"type": "oauth2", "flow": "accessCode", "authorizationUrl": "https://a", "tokenUrl": "https://t", "scopes": {}}`,
+			`{"type": "oauth2", "flows": {"authorizationCode": {"authorizationUrl": "https://a", "tokenUrl": "https://t", "scopes": {}}}}`,
+		},
+		{
+			`{"type": "oauth2", "flow": "application", "tokenUrl": "https://t", "scopes": {}}`,
+			`{"type": "oauth2", "flows": {"clientCredentials": {"tokenUrl": "https://t", "scopes": {}}}}`,
+		},
+	}
+	for _, test := range tests {
+		got, err := json.Marshal(securityScheme(decode(t, test.definition)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decode(t, string(got)), decode(t, test.expected)) {
+			t.Errorf("securityScheme(%s): expected %s, got %s", test.definition, test.expected, got)
+		}
+	}
+}
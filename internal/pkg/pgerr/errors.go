@@ -10,6 +10,8 @@ const (
 	CodeNotFound       = "NOT_FOUND"
 	CodeInvalidRequest = "INVALID_REQUEST"
 	CodeInternalError  = "INTERNAL_ERROR"
+	CodeRateLimited    = "RATE_LIMITED"
+	CodeUnauthorized   = "UNAUTHORIZED"
 )
 
 var (
@@ -22,6 +24,13 @@ var (
 	// ErrInternalError is returned when an internal error occurs.
 	ErrInternalError = New(fiber.StatusInternalServerError, CodeInternalError, "internal server error occurred")
 
+	// ErrRateLimited is returned when a quota is exceeded. Extras could carry `retryAfter` in seconds, which is
+	// also sent as the Retry-After header.
+	ErrRateLimited = New(fiber.StatusTooManyRequests, CodeRateLimited, "too many requests: quota exceeded")
+
+	// ErrUnauthorized is returned when the credential provided is invalid.
+	ErrUnauthorized = New(fiber.StatusUnauthorized, CodeUnauthorized, "unauthorized: credential is invalid")
+
 	ErrInternalErrorImmutable = NewImmutable(fiber.StatusInternalServerError, CodeInternalError, "internal server error occurred")
 )
 
@@ -32,6 +41,8 @@ type PenguinError struct {
 	ErrorCode  string  `json:"code" example:"INVALID_REQUEST"`
 	Message    string  `json:"message" example:"invalid request: some or all request parameters are invalid"`
 	Extras     *Extras `json:"-"`
+	// Reason is the structured reason of the error. See WithReason.
+	Reason *Reason `json:"-"`
 }
 
 func New(statusCode int, errorCode, message string) *PenguinError {
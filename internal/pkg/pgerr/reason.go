@@ -0,0 +1,72 @@
+package pgerr
+
+import (
+	"errors"
+	"strings"
+)
+
+// ReasonDocsURL is the documentation page describing each reason code, anchored by the code in lower case.
+const ReasonDocsURL = "https://developer.penguin-stats.io/docs/report-rejections"
+
+// Reason codes of reports being rejected, or failing to be recalled.
+const (
+	ReasonReportNotFound         = "REPORT_NOT_FOUND"
+	ReasonRecallWindowExceeded   = "RECALL_WINDOW_EXCEEDED"
+	ReasonSyncReportTimeout      = "SYNC_REPORT_TIMEOUT"
+	ReasonGachaboxTimes          = "GACHABOX_TIMES_UNSUPPORTED"
+	ReasonReportCorrectable      = "REPORT_CORRECTABLE"
+	ReasonReportDuplicated       = "REPORT_DUPLICATED"
+	ReasonReportQuotaExceeded    = "REPORT_QUOTA_EXCEEDED"
+	ReasonReportGated            = "REPORT_GATED"
+	ReasonReportQueueUnavailable = "REPORT_QUEUE_UNAVAILABLE"
+	ReasonRecognitionRejected    = "RECOGNITION_REJECTED"
+
+	// ReasonVerifierPrefix prefixes codes of reports rejected by a report verifier, followed by the name of
+	// the verifier in upper case, e.g. `REJECTED_BY_DROP`.
+	ReasonVerifierPrefix = "REJECTED_BY_"
+)
+
+// Reason is a structured description of why a report is rejected or could not be recalled, for client authors
+// to show actionable messages.
+type Reason struct {
+	Code string `json:"code" example:"REJECTED_BY_DROP"`
+	// Verifier is the name of the report verifier which rejected the report. Omitted when not rejected by a verifier.
+	Verifier string `json:"verifier,omitempty" example:"drop"`
+	Message  string `json:"message"`
+	DocsURL  string `json:"docsUrl" example:"https://developer.penguin-stats.io/docs/report-rejections#rejected_by_drop"`
+}
+
+func NewReason(code, message string) *Reason {
+	return &Reason{
+		Code:    code,
+		Message: message,
+		DocsURL: ReasonDocsURL + "#" + strings.ToLower(code),
+	}
+}
+
+// NewVerifierReason returns the reason of a report rejected by verifier.
+func NewVerifierReason(verifier, message string) *Reason {
+	reason := NewReason(ReasonVerifierPrefix+strings.ToUpper(verifier), message)
+	reason.Verifier = verifier
+	return reason
+}
+
+// WithReason attaches the reason of code, described by the message of e, which is sent as the `reason` field
+// of the error response.
+func (e PenguinError) WithReason(code string) *PenguinError {
+	e.Reason = NewReason(code, e.Message)
+	return &e
+}
+
+// ReasonOf returns the reason attached to err, or a reason made up of the error code and the message of err
+// if err is a PenguinError without a reason. nil is returned if err is not a PenguinError.
+func ReasonOf(err error) *Reason {
+	var e *PenguinError
+	if !errors.As(err, &e) {
+		return nil
+	}
+	if e.Reason != nil {
+		return e.Reason
+	}
+	return NewReason(e.ErrorCode, e.Message)
+}
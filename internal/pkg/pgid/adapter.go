@@ -11,6 +11,11 @@ import (
 )
 
 func Extract(ctx *fiber.Ctx) string {
+	// requests authenticated with an API key act as the account of the key
+	if penguinId, ok := ctx.Locals(constant.ContextKeyPenguinID).(string); ok {
+		return penguinId
+	}
+
 	penguinId := strings.TrimSpace(strings.TrimPrefix(ctx.Get(fiber.HeaderAuthorization), constant.PenguinIDAuthorizationRealm))
 
 	if penguinId == "" {
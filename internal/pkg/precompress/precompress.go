@@ -0,0 +1,177 @@
+// Package precompress compresses response bodies ahead of requests, so that cached responses are served in the
+// encoding a client accepts without compressing them per request.
+package precompress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gofiber/fiber/v2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// Content codings bodies are compressed in, as of Accept-Encoding and Content-Encoding.
+const (
+	EncodingBrotli   = "br"
+	EncodingZstd     = "zstd"
+	EncodingGzip     = "gzip"
+	EncodingIdentity = "identity"
+)
+
+// Encodings lists all encodings bodies could be compressed in, from the one preferred by servers, i.e. the one
+// compressing the best, when clients accept more than one of them equally.
+var Encodings = []string{EncodingBrotli, EncodingZstd, EncodingGzip}
+
+// Entry is a response body compressed ahead, in one or more encodings.
+type Entry struct {
+	// Bodies are the body in each encoding it has been compressed in.
+	Bodies map[string][]byte
+}
+
+// Compress compresses body in each of encodings.
+func Compress(body []byte, encodings []string) (*Entry, error) {
+	entry := &Entry{Bodies: make(map[string][]byte, len(encodings))}
+	for _, encoding := range encodings {
+		compressed, err := CompressIn(body, encoding)
+		if err != nil {
+			return nil, err
+		}
+		entry.Bodies[encoding] = compressed
+	}
+	return entry, nil
+}
+
+// CompressIn compresses body in encoding.
+func CompressIn(body []byte, encoding string) ([]byte, error) {
+	var buf bytes.Buffer
+	w, err := newWriter(&buf, encoding)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(body); err != nil {
+		return nil, errors.Wrapf(err, "failed to compress body in %s", encoding)
+	}
+	if err := w.Close(); err != nil {
+		return nil, errors.Wrapf(err, "failed to compress body in %s", encoding)
+	}
+	return buf.Bytes(), nil
+}
+
+// Identity returns the uncompressed body, decompressed from any encoding the entry holds.
+func (e *Entry) Identity() ([]byte, error) {
+	if body, ok := e.Bodies[EncodingIdentity]; ok {
+		return body, nil
+	}
+	for _, encoding := range Encodings {
+		body, ok := e.Bodies[encoding]
+		if !ok {
+			continue
+		}
+		r, err := newReader(bytes.NewReader(body), encoding)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+	return nil, errors.New("precompress: entry holds no body")
+}
+
+// Negotiate returns the encoding out of encodings to respond with, according to acceptEncoding being the
+// Accept-Encoding of the request, which is EncodingIdentity when none of encodings is accepted. Encodings accepted
+// equally are preferred in the order of Encodings.
+func Negotiate(acceptEncoding string, encodings []string) string {
+	accepted := parseAcceptEncoding(acceptEncoding)
+	best, bestQ := EncodingIdentity, 0.0
+	for _, encoding := range Encodings {
+		if !lo.Contains(encodings, encoding) {
+			continue
+		}
+		q, ok := accepted[encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// Send responds with the entry in encoding as JSON, or uncompressed if the entry has not been compressed in encoding,
+// e.g. when encoding is EncodingIdentity. Vary is set, as the response differs by the Accept-Encoding of requests.
+func Send(ctx *fiber.Ctx, entry *Entry, encoding string) error {
+	ctx.Vary(fiber.HeaderAcceptEncoding)
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	if body, ok := entry.Bodies[encoding]; ok && encoding != EncodingIdentity {
+		ctx.Set(fiber.HeaderContentEncoding, encoding)
+		return ctx.Send(body)
+	}
+	body, err := entry.Identity()
+	if err != nil {
+		return err
+	}
+	return ctx.Send(body)
+}
+
+// parseAcceptEncoding returns the quality values of codings of acceptEncoding. Codings of a quality value of 0 are
+// kept, as they are refused explicitly even if `*` is accepted.
+func parseAcceptEncoding(acceptEncoding string) map[string]float64 {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimPrefix(params, "q=")), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		accepted[coding] = q
+	}
+	return accepted
+}
+
+// newWriter returns a writer compressing in encoding. Levels are moderate rather than the best, as bodies could be
+// compressed on the request path: the best level of brotli takes tens of seconds on a drop matrix of a few MBs.
+func newWriter(w io.Writer, encoding string) (io.WriteCloser, error) {
+	switch encoding {
+	case EncodingBrotli:
+		return brotli.NewWriterLevel(w, 5), nil
+	case EncodingZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedDefault))
+	case EncodingGzip:
+		return gzip.NewWriterLevel(w, gzip.DefaultCompression)
+	default:
+		return nil, errors.Errorf("precompress: unsupported encoding %q", encoding)
+	}
+}
+
+func newReader(r io.Reader, encoding string) (io.ReadCloser, error) {
+	switch encoding {
+	case EncodingBrotli:
+		return io.NopCloser(brotli.NewReader(r)), nil
+	case EncodingZstd:
+		decoder, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		return decoder.IOReadCloser(), nil
+	case EncodingGzip:
+		return gzip.NewReader(r)
+	default:
+		return nil, errors.Errorf("precompress: unsupported encoding %q", encoding)
+	}
+}
@@ -0,0 +1,58 @@
+package precompress
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	all := Encodings
+	tests := []struct {
+		acceptEncoding string
+		encodings      []string
+		expected       string
+	}{
+		{"", all, EncodingIdentity},
+		{"gzip", all, EncodingGzip},
+		{"gzip, deflate, br", all, EncodingBrotli},
+		{"gzip, zstd", all, EncodingZstd},
+		{"br;q=0.5, gzip", all, EncodingGzip},
+		{"BR", all, EncodingBrotli},
+		{"*", all, EncodingBrotli},
+		{"*, br;q=0", all, EncodingZstd},
+		{"gzip;q=0", all, EncodingIdentity},
+		{"br;q=invalid, gzip", all, EncodingGzip},
+		{"deflate", all, EncodingIdentity},
+		{"br, gzip", []string{EncodingGzip}, EncodingGzip},
+		{"br", []string{EncodingGzip}, EncodingIdentity},
+	}
+	for _, test := range tests {
+		if encoding := Negotiate(test.acceptEncoding, test.encodings); encoding != test.expected {
+			t.Errorf("Negotiate(%q, %v): expected %s, got %s", test.acceptEncoding, test.encodings, test.expected, encoding)
+		}
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	body := bytes.Repeat([]byte(`{"stageId":"main_01-07","itemId":"30012","quantity":1,"times":2}`), 64)
+	for _, encoding := range Encodings {
+		entry, err := Compress(body, []string{encoding})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entry.Bodies[encoding]) >= len(body) {
+			t.Errorf("Expected body compressed in %s to be smaller than %d bytes, got %d", encoding, len(body), len(entry.Bodies[encoding]))
+		}
+		identity, err := entry.Identity()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(identity, body) {
+			t.Errorf("Expected body decompressed from %s to equal the original", encoding)
+		}
+	}
+
+	if _, err := (&Entry{Bodies: map[string][]byte{}}).Identity(); err == nil {
+		t.Errorf("Expected error of an empty entry, got nil")
+	}
+}
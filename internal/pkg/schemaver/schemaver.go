@@ -0,0 +1,82 @@
+// Package schemaver negotiates versions of the schemas result payloads are shaped in, so that breaking changes to
+// payloads are rolled out as new versions, while clients still on former versions are told when those versions are
+// to be removed.
+package schemaver
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// Versions of schemas of result payloads.
+const (
+	// V2 is the schema result payloads have been shaped in since the v2 API, which MAA and other tools rely on.
+	V2 = 2
+	// V3 is the schema of result payloads with consistent naming and derived values such as rates included.
+	V3 = 3
+)
+
+const (
+	// HeaderKey is the request header clients opt in to a schema version with, either as `3` or `v3`, and the
+	// response header telling the version a payload has been shaped in.
+	HeaderKey = "X-Penguin-Schema"
+	// MediaTypeV3 is the media type clients could also accept to opt in to V3.
+	MediaTypeV3 = "application/vnd.penguin.v3+json"
+)
+
+// ErrUnsupportedVersion is returned when a request opts in to a schema version which does not exist.
+var ErrUnsupportedVersion = pgerr.ErrInvalidReq.Msg("unsupported schema version requested with the " + HeaderKey + " header; supported versions are 2 and 3")
+
+// Deprecation describes when a schema version has been deprecated, and when it is to be removed. Zero times are
+// left unannounced.
+type Deprecation struct {
+	// Since is when the version has been deprecated.
+	Since time.Time
+	// Sunset is when the version is to be removed.
+	Sunset time.Time
+	// Link is the URL of the documentation on migrating off the version.
+	Link string
+}
+
+// Negotiate returns the schema version requested by ctx with HeaderKey, or with MediaTypeV3 in the Accept header,
+// falling back to fallback, which is the version of the path requested.
+func Negotiate(ctx *fiber.Ctx, fallback int) (int, error) {
+	if value := strings.TrimSpace(ctx.Get(HeaderKey)); value != "" {
+		version, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(value), "v"))
+		if err != nil || (version != V2 && version != V3) {
+			return 0, ErrUnsupportedVersion
+		}
+		return version, nil
+	}
+	if strings.Contains(ctx.Get(fiber.HeaderAccept), MediaTypeV3) {
+		return V3, nil
+	}
+	return fallback, nil
+}
+
+// Respond sets HeaderKey of the response to version, and announces the deprecation of version if it is deprecated.
+// Vary is set, as payloads differ by the headers versions are negotiated with.
+func Respond(ctx *fiber.Ctx, version int, deprecation *Deprecation) {
+	ctx.Vary(HeaderKey, fiber.HeaderAccept)
+	ctx.Set(HeaderKey, strconv.Itoa(version))
+	if deprecation == nil {
+		return
+	}
+	// Deprecation is a Structured Field Date, see https://www.rfc-editor.org/rfc/rfc9745
+	if !deprecation.Since.IsZero() {
+		ctx.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+	}
+	// Sunset is an HTTP-date, see https://www.rfc-editor.org/rfc/rfc8594
+	if !deprecation.Sunset.IsZero() {
+		ctx.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if deprecation.Link != "" && (!deprecation.Since.IsZero() || !deprecation.Sunset.IsZero()) {
+		ctx.Append(fiber.HeaderLink, `<`+deprecation.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+}
@@ -0,0 +1,87 @@
+package schemaver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		header   string
+		accept   string
+		fallback int
+		expected int
+		wantErr  bool
+	}{
+		{"", "", V2, V2, false},
+		{"", "", V3, V3, false},
+		{"3", "", V2, V3, false},
+		{"v3", "", V2, V3, false},
+		{" V2 ", "", V3, V2, false},
+		{"", MediaTypeV3, V2, V3, false},
+		{"2", MediaTypeV3, V3, V2, false},
+		{"4", "", V2, 0, true},
+		{"latest", "", V2, 0, true},
+	}
+
+	app := fiber.New()
+	for _, test := range tests {
+		ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+		if test.header != "" {
+			ctx.Request().Header.Set(HeaderKey, test.header)
+		}
+		if test.accept != "" {
+			ctx.Request().Header.Set(fiber.HeaderAccept, test.accept)
+		}
+		version, err := Negotiate(ctx, test.fallback)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Negotiate(%q, %q, %d): expected error %v, got %v", test.header, test.accept, test.fallback, test.wantErr, err)
+		}
+		if version != test.expected {
+			t.Errorf("Negotiate(%q, %q, %d): expected %d, got %d", test.header, test.accept, test.fallback, test.expected, version)
+		}
+		app.ReleaseCtx(ctx)
+	}
+}
+
+func TestRespond(t *testing.T) {
+	since := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2022, 12, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		deprecation *Deprecation
+		deprecated  string
+		sunset      string
+		link        string
+	}{
+		{nil, "", "", ""},
+		{&Deprecation{Link: "https://penguin-stats.io/docs"}, "", "", ""},
+		{&Deprecation{Since: since}, "@1654041600", "", ""},
+		{
+			&Deprecation{Since: since, Sunset: sunset, Link: "https://penguin-stats.io/docs"},
+			"@1654041600", "Thu, 01 Dec 2022 00:00:00 GMT", `<https://penguin-stats.io/docs>; rel="deprecation"; type="text/html"`,
+		},
+	}
+
+	app := fiber.New()
+	for i, test := range tests {
+		ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+		Respond(ctx, V2, test.deprecation)
+		header := &ctx.Response().Header
+		if got := string(header.Peek(HeaderKey)); got != "2" {
+			t.Errorf("#%d: expected %s 2, got %q", i, HeaderKey, got)
+		}
+		if got := string(header.Peek("Deprecation")); got != test.deprecated {
+			t.Errorf("#%d: expected Deprecation %q, got %q", i, test.deprecated, got)
+		}
+		if got := string(header.Peek("Sunset")); got != test.sunset {
+			t.Errorf("#%d: expected Sunset %q, got %q", i, test.sunset, got)
+		}
+		if got := string(header.Peek(fiber.HeaderLink)); got != test.link {
+			t.Errorf("#%d: expected Link %q, got %q", i, test.link, got)
+		}
+		app.ReleaseCtx(ctx)
+	}
+}
@@ -0,0 +1,197 @@
+// Package sdkgen generates client SDK artifacts from the OpenAPI 3 document of the API, so that clients such as the
+// frontend and MAA consume typed models of requests and responses instead of copying structs by hand.
+package sdkgen
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Names of artifacts generated.
+const (
+	// ArtifactSchemaBundle is the JSON Schema bundle of all models, as definitions keyed by their qualified names.
+	ArtifactSchemaBundle = "schema.json"
+	// ArtifactTypeScript is the TypeScript declarations of all models, in a namespace per Go package.
+	ArtifactTypeScript = "models.d.ts"
+)
+
+// schemaDialect is the dialect of JSON Schema bundles generated.
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// References to component schemas of OpenAPI 3.0 documents, prefixed by openAPI3SchemasRef, are rewritten to
+// references to definitions of bundles, prefixed by defsRef.
+const (
+	openAPI3SchemasRef = "#/components/schemas/"
+	defsRef            = "#/$defs/"
+)
+
+// Artifact is a file of a generated SDK.
+type Artifact struct {
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	// SHA256 is the hex-encoded SHA-256 digest of Body, for clients to verify artifacts downloaded.
+	SHA256 string `json:"sha256"`
+	Body   []byte `json:"-"`
+}
+
+// SDK is a set of artifacts generated for a version of the API.
+type SDK struct {
+	Version   string      `json:"version"`
+	Artifacts []*Artifact `json:"artifacts"`
+}
+
+// Artifact returns the artifact of name, or nil if there is none.
+func (s *SDK) Artifact(name string) *Artifact {
+	for _, artifact := range s.Artifacts {
+		if artifact.Name == name {
+			return artifact
+		}
+	}
+	return nil
+}
+
+// Generate generates the SDK of version from spec, being an OpenAPI 3 document in JSON. Only schemas of requests and
+// responses of the API, i.e. those referred to by its paths, are generated, so that internal models never leak into
+// public SDKs even if they happen to be among the components of spec.
+func Generate(spec []byte, version string) (*SDK, error) {
+	var openapi struct {
+		Paths      map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(spec, &openapi); err != nil {
+		return nil, errors.Wrap(err, "invalid OpenAPI document")
+	}
+
+	defs := referredSchemas(openapi.Paths, openapi.Components.Schemas)
+	toJSONSchemas(defs)
+
+	bundle, err := json.MarshalIndent(map[string]any{
+		"$schema":  schemaDialect,
+		"$comment": "Generated from the Penguin Statistics API " + version,
+		"$defs":    defs,
+	}, "", "    ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &SDK{
+		Version: version,
+		Artifacts: []*Artifact{
+			newArtifact(ArtifactSchemaBundle, "application/schema+json", bundle),
+			newArtifact(ArtifactTypeScript, "application/typescript; charset=utf-8", []byte(typeScript(defs, version))),
+		},
+	}, nil
+}
+
+func newArtifact(name string, contentType string, body []byte) *Artifact {
+	sum := sha256.Sum256(body)
+	return &Artifact{
+		Name:        name,
+		ContentType: contentType,
+		SHA256:      hex.EncodeToString(sum[:]),
+		Body:        body,
+	}
+}
+
+// toJSONSchema rewrites schema, being a schema of OpenAPI 3.0, and its subschemas into JSON Schema: references to
+// component schemas refer to definitions of the bundle instead, `nullable` becomes a type of null, and `example`
+// becomes `examples`.
+func toJSONSchema(schema map[string]any) {
+	if ref, ok := schema["$ref"].(string); ok && strings.HasPrefix(ref, openAPI3SchemasRef) {
+		schema["$ref"] = defsRef + strings.TrimPrefix(ref, openAPI3SchemasRef)
+	}
+	if nullable, ok := schema["nullable"].(bool); ok {
+		delete(schema, "nullable")
+		if typ, ok := schema["type"].(string); ok && nullable {
+			schema["type"] = []any{typ, "null"}
+		}
+	}
+	if example, ok := schema["example"]; ok {
+		delete(schema, "example")
+		schema["examples"] = []any{example}
+	}
+
+	for _, key := range []string{"items", "additionalProperties", "not"} {
+		if subschema, ok := schema[key].(map[string]any); ok {
+			toJSONSchema(subschema)
+		}
+	}
+	for _, key := range []string{"allOf", "anyOf", "oneOf"} {
+		for _, subschema := range asSlice(schema[key]) {
+			if subschema, ok := subschema.(map[string]any); ok {
+				toJSONSchema(subschema)
+			}
+		}
+	}
+	if properties, ok := schema["properties"].(map[string]any); ok {
+		toJSONSchemas(properties)
+	}
+}
+
+// toJSONSchemas rewrites every schema of schemas, keyed by their names, by toJSONSchema.
+func toJSONSchemas(schemas map[string]any) {
+	for _, schema := range schemas {
+		if schema, ok := schema.(map[string]any); ok {
+			toJSONSchema(schema)
+		}
+	}
+}
+
+// referredSchemas returns schemas of schemas, keyed by their names, which are referred to by paths, either directly
+// or through other schemas referred to.
+func referredSchemas(paths map[string]any, schemas map[string]any) map[string]any {
+	referred := map[string]any{}
+	pending := refsOf(paths, nil)
+	for len(pending) > 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		schema, ok := schemas[name]
+		if _, seen := referred[name]; seen || !ok {
+			continue
+		}
+		referred[name] = schema
+		pending = refsOf(schema, pending)
+	}
+	return referred
+}
+
+// refsOf appends names of component schemas referred to throughout v to names.
+func refsOf(v any, names []string) []string {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" && strings.HasPrefix(ref, openAPI3SchemasRef) {
+				names = append(names, strings.TrimPrefix(ref, openAPI3SchemasRef))
+				continue
+			}
+			names = refsOf(value, names)
+		}
+	case []any:
+		for _, value := range v {
+			names = refsOf(value, names)
+		}
+	}
+	return names
+}
+
+func asSlice(v any) []any {
+	s, _ := v.([]any)
+	return s
+}
+
+// sortedKeys returns keys of m in order, so that artifacts generated from the same models are identical.
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,53 @@
+package sdkgen
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files of testdata")
+
+func TestGenerateGolden(t *testing.T) {
+	spec, err := os.ReadFile(filepath.Join("testdata", "openapi.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdk, err := Generate(spec, "v3.0.0-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{ArtifactSchemaBundle, ArtifactTypeScript} {
+		artifact := sdk.Artifact(name)
+		if artifact == nil {
+			t.Fatalf("Expected artifact %s, got none", name)
+		}
+		if strings.Contains(string(artifact.Body), "ReportTask") {
+			t.Errorf("Expected artifact %s to exclude schemas not referred to by paths, got types.ReportTask", name)
+		}
+
+		golden := filepath.Join("testdata", name+".golden")
+		if *update {
+			if err := os.WriteFile(golden, artifact.Body, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := os.ReadFile(golden)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(artifact.Body) != string(expected) {
+			t.Errorf("Expected artifact %s to match %s, got:\n%s", name, golden, artifact.Body)
+		}
+	}
+}
+
+func TestGenerateRejectsInvalidSpec(t *testing.T) {
+	if _, err := Generate([]byte("not json"), "v3.0.0-test"); err == nil {
+		t.Errorf("Expected error, got nil")
+	}
+}
@@ -0,0 +1,191 @@
+package sdkgen
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// typeScript declares every definition of defs in TypeScript, in a namespace per Go package the definition is
+// qualified by, e.g. `types.SingleReportRequest` is declared as `SingleReportRequest` of the namespace `types`.
+func typeScript(defs map[string]any, version string) string {
+	namespaces := map[string][]string{}
+	for _, name := range sortedKeys(defs) {
+		namespace, _ := splitDefName(name)
+		namespaces[namespace] = append(namespaces[namespace], name)
+	}
+	namespaceNames := make([]string, 0, len(namespaces))
+	for namespace := range namespaces {
+		namespaceNames = append(namespaceNames, namespace)
+	}
+	sort.Strings(namespaceNames)
+
+	var b strings.Builder
+	b.WriteString("// Generated from the Penguin Statistics API " + version + ". DO NOT EDIT.\n")
+	for _, namespace := range namespaceNames {
+		indent := ""
+		b.WriteString("\n")
+		if namespace != "" {
+			b.WriteString("export namespace " + namespace + " {\n")
+			indent = "    "
+		}
+		for i, name := range namespaces[namespace] {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			_, typeName := splitDefName(name)
+			writeDeclaration(&b, indent, typeName, asMap(defs[name]))
+		}
+		if namespace != "" {
+			b.WriteString("}\n")
+		}
+	}
+	return b.String()
+}
+
+func writeDeclaration(b *strings.Builder, indent string, name string, schema map[string]any) {
+	writeComment(b, indent, schema)
+	if properties, ok := schema["properties"].(map[string]any); ok && schema["additionalProperties"] == nil {
+		b.WriteString(indent + "export interface " + name + " ")
+		writeProperties(b, indent, properties, asSlice(schema["required"]))
+		b.WriteString("\n")
+		return
+	}
+	b.WriteString(indent + "export type " + name + " = " + tsType(schema, indent) + ";\n")
+}
+
+// writeProperties writes properties as the body of an object type, with those not in required being optional.
+func writeProperties(b *strings.Builder, indent string, properties map[string]any, required []any) {
+	requiredSet := make(map[string]bool, len(required))
+	for _, name := range required {
+		if name, ok := name.(string); ok {
+			requiredSet[name] = true
+		}
+	}
+
+	b.WriteString("{\n")
+	for _, name := range sortedKeys(properties) {
+		property := asMap(properties[name])
+		writeComment(b, indent+"    ", property)
+		key := name
+		if !tsIdentifier.MatchString(name) {
+			quoted, _ := json.Marshal(name)
+			key = string(quoted)
+		}
+		if !requiredSet[name] {
+			key += "?"
+		}
+		b.WriteString(indent + "    " + key + ": " + tsType(property, indent+"    ") + ";\n")
+	}
+	b.WriteString(indent + "}")
+}
+
+func writeComment(b *strings.Builder, indent string, schema map[string]any) {
+	description, _ := schema["description"].(string)
+	if description = strings.TrimSpace(description); description == "" {
+		return
+	}
+	description = strings.ReplaceAll(description, "*/", "*\\/")
+	b.WriteString(indent + "/**\n")
+	for _, line := range strings.Split(description, "\n") {
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+}
+
+// tsType returns the TypeScript type of schema.
+func tsType(schema map[string]any, indent string) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		namespace, name := splitDefName(strings.TrimPrefix(ref, defsRef))
+		if namespace == "" {
+			return name
+		}
+		return namespace + "." + name
+	}
+	for _, composition := range []struct{ key, separator string }{{"allOf", " & "}, {"oneOf", " | "}, {"anyOf", " | "}} {
+		if subschemas := asSlice(schema[composition.key]); len(subschemas) > 0 {
+			types := make([]string, 0, len(subschemas))
+			for _, subschema := range subschemas {
+				types = append(types, tsType(asMap(subschema), indent))
+			}
+			if len(types) == 1 {
+				return types[0]
+			}
+			return "(" + strings.Join(types, composition.separator) + ")"
+		}
+	}
+	if enum := asSlice(schema["enum"]); len(enum) > 0 {
+		literals := make([]string, 0, len(enum))
+		for _, value := range enum {
+			literal, _ := json.Marshal(value)
+			literals = append(literals, string(literal))
+		}
+		return strings.Join(literals, " | ")
+	}
+
+	switch typ := schema["type"].(type) {
+	case string:
+		return tsPrimitiveType(typ, schema, indent)
+	case []any:
+		types := make([]string, 0, len(typ))
+		for _, t := range typ {
+			if t, ok := t.(string); ok {
+				types = append(types, tsPrimitiveType(t, schema, indent))
+			}
+		}
+		if len(types) == 0 {
+			return "unknown"
+		}
+		return strings.Join(types, " | ")
+	default:
+		return "unknown"
+	}
+}
+
+func tsPrimitiveType(typ string, schema map[string]any, indent string) string {
+	switch typ {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "null":
+		return "null"
+	case "array":
+		items := asMap(schema["items"])
+		if items == nil {
+			return "unknown[]"
+		}
+		return "Array<" + tsType(items, indent) + ">"
+	case "object":
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			var b strings.Builder
+			writeProperties(&b, indent, properties, asSlice(schema["required"]))
+			return b.String()
+		}
+		if additional := asMap(schema["additionalProperties"]); additional != nil {
+			return "{ [key: string]: " + tsType(additional, indent) + " }"
+		}
+		return "{ [key: string]: unknown }"
+	default:
+		return "unknown"
+	}
+}
+
+// splitDefName splits the name of a definition into the namespace, i.e. the Go package it is qualified by, and the
+// name of the type.
+func splitDefName(name string) (string, string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+func asMap(v any) map[string]any {
+	m, _ := v.(map[string]any)
+	return m
+}
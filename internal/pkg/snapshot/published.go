@@ -0,0 +1,49 @@
+package snapshot
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+// Publish publishes values, e.g. rules stored in the database, as JSON to the Redis key, from where every instance
+// loads them with LoadPublished.
+func Publish[T any](ctx context.Context, rdb *redis.Client, key string, values []T) error {
+	valuesJSON, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return rdb.Set(ctx, key, valuesJSON, 0).Err()
+}
+
+// LoadPublished loads values published to the Redis key by Publish. Values are loaded with loadStored, from where
+// they are stored, when they are missing from Redis or Redis fails, so that values are never lost along with Redis.
+// Values missing are published again, unless published by others in the meantime.
+func LoadPublished[T any](ctx context.Context, rdb *redis.Client, key string, loadStored func(ctx context.Context) ([]T, error)) ([]T, error) {
+	valuesJSON, err := rdb.Get(ctx, key).Bytes()
+	if err == nil {
+		var values []T
+		if err := json.Unmarshal(valuesJSON, &values); err != nil {
+			return nil, errors.Wrapf(err, "invalid values published to %s", key)
+		}
+		return values, nil
+	}
+
+	values, storedErr := loadStored(ctx)
+	if storedErr != nil {
+		return nil, storedErr
+	}
+	if !errors.Is(err, redis.Nil) {
+		log.Warn().Err(err).Str("key", key).Msg("failed to load published values from redis, loaded from where they are stored instead")
+		return values, nil
+	}
+	if valuesJSON, err := json.Marshal(values); err == nil {
+		if err := rdb.SetNX(ctx, key, valuesJSON, 0).Err(); err != nil {
+			log.Warn().Err(err).Str("key", key).Msg("failed to publish values to redis")
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,90 @@
+// Package snapshot caches values reloaded periodically, e.g. rules loaded from Redis or the database, which are
+// consulted on every request and shall never hold requests up on each other.
+package snapshot
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/async"
+)
+
+// loadTimeout bounds every load of a Snapshot, which runs on behalf of all of its callers.
+const loadTimeout = time.Second * 5
+
+// Snapshot caches the value returned by load, which is reloaded once older than the refresh interval. Only the
+// first load blocks callers; later reloads run in background, while callers keep taking the value loaded before.
+// A failed load keeps the value loaded before, if any, and is retried after another refresh interval. No lock is
+// held while loading.
+type Snapshot[T any] struct {
+	name     string
+	interval time.Duration
+	load     func(ctx context.Context) (T, error)
+
+	mu          sync.RWMutex
+	value       T
+	refreshedAt time.Time
+
+	// reloading is whether a background reload is running
+	reloading int32
+	flight    async.Flight[T]
+}
+
+// New creates a Snapshot of the value returned by load, reloaded every interval. name labels logs of failed loads.
+func New[T any](name string, interval time.Duration, load func(ctx context.Context) (T, error)) *Snapshot[T] {
+	return &Snapshot[T]{
+		name:     name,
+		interval: interval,
+		load:     load,
+	}
+}
+
+// Get returns the value cached, loading it if it has never been loaded. The zero value of T is returned if it has
+// never been loaded successfully.
+func (s *Snapshot[T]) Get() T {
+	s.mu.RLock()
+	value, refreshedAt := s.value, s.refreshedAt
+	s.mu.RUnlock()
+
+	if refreshedAt.IsZero() {
+		// concurrent callers share the first load, which fails them all alike
+		value, _ = s.flight.Do("", s.reload)
+		return value
+	}
+	if time.Since(refreshedAt) >= s.interval && atomic.CompareAndSwapInt32(&s.reloading, 0, 1) {
+		go func() {
+			defer atomic.StoreInt32(&s.reloading, 0)
+			_, _ = s.flight.Do("", s.reload)
+		}()
+	}
+	return value
+}
+
+// Set replaces the value cached with value, e.g. right after the source of it has been changed by this instance.
+func (s *Snapshot[T]) Set(value T) {
+	s.mu.Lock()
+	s.value = value
+	s.refreshedAt = time.Now()
+	s.mu.Unlock()
+}
+
+// reload loads the value, and returns the value cached afterwards.
+func (s *Snapshot[T]) reload() (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+	defer cancel()
+	value, err := s.load(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refreshedAt = time.Now()
+	if err != nil {
+		log.Warn().Err(err).Str("snapshot", s.name).Msg("failed to load snapshot, keeping the one loaded before")
+		return s.value, err
+	}
+	s.value = value
+	return s.value, nil
+}
@@ -0,0 +1,123 @@
+package spool
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	bolt "go.etcd.io/bbolt"
+)
+
+var bucketName = []byte("messages")
+
+// Message is a message spooled to be published later.
+type Message struct {
+	Subject string `json:"subject"`
+	// MsgID is the id the message is published with, so that the message is deduplicated by JetStream if it has
+	// been published already.
+	MsgID string `json:"msgId"`
+	Data  []byte `json:"data"`
+}
+
+// Spool is a write-ahead spool of messages on local disk, kept in the order they are spooled.
+type Spool struct {
+	db *bolt.DB
+}
+
+// Open opens the spool at path, creating it if it does not exist yet. The spool is locked exclusively until
+// closed, and Open fails if it could not be locked within timeout.
+func Open(path string, timeout time.Duration) (*Spool, error) {
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: timeout})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open spool at %s", path)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucketName)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Spool{db: db}, nil
+}
+
+// Put appends msg to the spool. The message is synced to disk once Put returns.
+func (s *Spool) Put(msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(bucketName)
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		return bucket.Put(sequenceKey(seq), data)
+	})
+}
+
+// Len returns the number of messages in the spool.
+func (s *Spool) Len() (int, error) {
+	var n int
+	err := s.db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(bucketName).Stats().KeyN
+		return nil
+	})
+	return n, err
+}
+
+// Drain calls fn with spooled messages in the order they were spooled, removing each message fn succeeds with.
+// Drain stops at the first message fn fails with, leaving it and the messages after it in the spool, and returns
+// the number of messages removed along with the error.
+func (s *Spool) Drain(fn func(msg *Message) error) (int, error) {
+	var drained int
+	for {
+		var (
+			key []byte
+			msg Message
+		)
+		err := s.db.View(func(tx *bolt.Tx) error {
+			k, v := tx.Bucket(bucketName).Cursor().First()
+			if k == nil {
+				return nil
+			}
+			key = append([]byte(nil), k...)
+			return json.Unmarshal(v, &msg)
+		})
+		if err != nil {
+			return drained, err
+		}
+		if key == nil {
+			return drained, nil
+		}
+
+		if err := fn(&msg); err != nil {
+			return drained, err
+		}
+
+		err = s.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(bucketName).Delete(key)
+		})
+		if err != nil {
+			return drained, err
+		}
+		drained++
+	}
+}
+
+// Close closes the spool, releasing its lock.
+func (s *Spool) Close() error {
+	return s.db.Close()
+}
+
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
@@ -0,0 +1,93 @@
+package spool
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func openTestSpool(t *testing.T) (*Spool, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "spool.db")
+	s, err := Open(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+func TestDrain(t *testing.T) {
+	errPublish := errors.New("publish failed")
+	tests := []struct {
+		name      string
+		spooled   int
+		failAt    int
+		drained   int
+		remaining int
+	}{
+		{"empty", 0, -1, 0, 0},
+		{"all", 5, -1, 5, 0},
+		{"stops at the first failure", 5, 2, 2, 3},
+		{"fails at the first message", 3, 0, 0, 3},
+	}
+	for _, test := range tests {
+		s, _ := openTestSpool(t)
+		for i := 0; i < test.spooled; i++ {
+			if err := s.Put(&Message{Subject: "REPORT.SINGLE", MsgID: string(rune('a' + i)), Data: []byte{byte(i)}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var seen []string
+		drained, err := s.Drain(func(msg *Message) error {
+			if len(seen) == test.failAt {
+				return errPublish
+			}
+			seen = append(seen, msg.MsgID)
+			return nil
+		})
+		if test.failAt >= 0 && test.failAt < test.spooled && err != errPublish {
+			t.Errorf("%s: expected error %v, got %v", test.name, errPublish, err)
+		}
+		if drained != test.drained {
+			t.Errorf("%s: expected %d messages drained, got %d", test.name, test.drained, drained)
+		}
+		for i, id := range seen {
+			if id != string(rune('a'+i)) {
+				t.Errorf("%s: expected messages drained in the order spooled, got %v", test.name, seen)
+				break
+			}
+		}
+		if n, err := s.Len(); err != nil || n != test.remaining {
+			t.Errorf("%s: expected %d messages remaining, got %d (%v)", test.name, test.remaining, n, err)
+		}
+		_ = s.Close()
+	}
+}
+
+func TestReopen(t *testing.T) {
+	s, path := openTestSpool(t)
+	if err := s.Put(&Message{Subject: "REPORT.SINGLE", MsgID: "task-1", Data: []byte(`{}`)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path, time.Millisecond*50); err == nil {
+		t.Errorf("Expected opening a locked spool to fail, got nil")
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Open(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var msg *Message
+	if _, err := s.Drain(func(m *Message) error { msg = m; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if msg == nil || msg.MsgID != "task-1" || string(msg.Data) != `{}` {
+		t.Errorf("Expected the spooled message to survive reopening, got %+v", msg)
+	}
+}
@@ -9,19 +9,40 @@ func Module() fx.Option {
 		NewItem,
 		NewZone,
 		NewAdmin,
+		NewAnomaly,
 		NewStage,
 		NewNotice,
 		NewAccount,
+		NewAccountIdentity,
+		NewAPIKey,
+		NewAccountTrustScore,
 		NewActivity,
 		NewDropInfo,
+		NewDropType,
 		NewProperty,
 		NewTimeRange,
+		NewAggregateView,
 		NewDropReport,
+		NewDropReportPartition,
 		NewRejectRule,
+		NewReportPurge,
+		NewGameDataChange,
+		NewJobRun,
+		NewAuditLog,
+		NewWebhook,
+		NewRecognition,
+		NewRejectedReportTask,
+		NewShadowBan,
+		NewReportGate,
+		NewStageRewriteRule,
 		NewDropPattern,
 		NewTrendElement,
 		NewDropReportExtra,
+		NewDropReportRecall,
+		NewDropReportCorrection,
 		NewDropMatrixElement,
+		NewDropMatrixSnapshot,
+		NewMatrixWatermark,
 		NewDropPatternElement,
 		NewPatternMatrixElement,
 	))
@@ -63,7 +63,6 @@ func (c *Account) GetAccountById(ctx context.Context, accountId string) (*model.
 
 	err := c.db.NewSelect().
 		Model(&account).
-		Column("account_id").
 		Where("account_id = ?", accountId).
 		Scan(ctx)
 
@@ -0,0 +1,75 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type AccountIdentity struct {
+	DB *bun.DB
+}
+
+func NewAccountIdentity(db *bun.DB) *AccountIdentity {
+	return &AccountIdentity{DB: db}
+}
+
+func (r *AccountIdentity) GetIdentity(ctx context.Context, provider string, subject string) (*model.AccountIdentity, error) {
+	var identity model.AccountIdentity
+	err := r.DB.NewSelect().
+		Model(&identity).
+		Where("provider = ?", provider).
+		Where("subject = ?", subject).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (r *AccountIdentity) GetIdentitiesByAccountId(ctx context.Context, accountId int) ([]*model.AccountIdentity, error) {
+	identities := make([]*model.AccountIdentity, 0)
+	err := r.DB.NewSelect().
+		Model(&identities).
+		Where("account_id = ?", accountId).
+		Order("identity_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (r *AccountIdentity) CreateIdentity(ctx context.Context, identity *model.AccountIdentity) error {
+	_, err := r.DB.NewInsert().
+		Model(identity).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *AccountIdentity) DeleteIdentity(ctx context.Context, accountId int, provider string) error {
+	res, err := r.DB.NewDelete().
+		Model((*model.AccountIdentity)(nil)).
+		Where("account_id = ?", accountId).
+		Where("provider = ?", provider).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return pgerr.ErrNotFound
+	}
+	return nil
+}
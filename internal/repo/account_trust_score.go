@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type AccountTrustScore struct {
+	DB *bun.DB
+}
+
+func NewAccountTrustScore(db *bun.DB) *AccountTrustScore {
+	return &AccountTrustScore{DB: db}
+}
+
+func (c *AccountTrustScore) GetAccountTrustScoreByAccountId(ctx context.Context, accountId int) (*model.AccountTrustScore, error) {
+	var score model.AccountTrustScore
+	err := c.DB.NewSelect().
+		Model(&score).
+		Where("account_id = ?", accountId).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &score, nil
+}
+
+// GetLowestAccountTrustScores returns at most limit accounts with the lowest trust scores.
+func (c *AccountTrustScore) GetLowestAccountTrustScores(ctx context.Context, limit int) ([]*model.AccountTrustScore, error) {
+	scores := make([]*model.AccountTrustScore, 0)
+	err := c.DB.NewSelect().
+		Model(&scores).
+		Order("score ASC", "account_id ASC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return scores, nil
+}
+
+func (c *AccountTrustScore) UpsertAccountTrustScore(ctx context.Context, score *model.AccountTrustScore) error {
+	_, err := c.DB.NewInsert().
+		Model(score).
+		On("CONFLICT (account_id) DO UPDATE").
+		Set("score = EXCLUDED.score").
+		Set("reports = EXCLUDED.reports").
+		Set("rejected = EXCLUDED.rejected").
+		Set("recalled = EXCLUDED.recalled").
+		Set("anomalies = EXCLUDED.anomalies").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+
+	return err
+}
+
+// CalcAccountReportStats aggregates reports of an account. Reports with any of anomalyReliabilities are
+// counted as anomalies, in addition to being counted as rejected.
+func (c *AccountTrustScore) CalcAccountReportStats(ctx context.Context, accountId int, anomalyReliabilities []int) (*model.AccountReportStats, error) {
+	var stats model.AccountReportStats
+	err := c.DB.NewSelect().
+		TableExpr("drop_reports AS dr").
+		ColumnExpr("COUNT(*) AS reports").
+		ColumnExpr("COUNT(*) FILTER (WHERE dr.reliability > 0) AS rejected").
+		ColumnExpr("COUNT(*) FILTER (WHERE EXISTS (SELECT 1 FROM drop_report_recalls AS drr WHERE drr.report_id = dr.report_id)) AS recalled").
+		ColumnExpr("COUNT(*) FILTER (WHERE dr.reliability IN (?)) AS anomalies", bun.In(anomalyReliabilities)).
+		Where("dr.account_id = ?", accountId).
+		Scan(ctx, &stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/uptrace/bun"
 
 	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 )
 
 type Activity struct {
@@ -30,3 +31,19 @@ func (c *Activity) GetActivities(ctx context.Context) ([]*model.Activity, error)
 
 	return activities, nil
 }
+
+func (c *Activity) GetActivityById(ctx context.Context, activityId int) (*model.Activity, error) {
+	var activity model.Activity
+	err := c.DB.NewSelect().
+		Model(&activity).
+		Where("activity_id = ?", activityId).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &activity, nil
+}
@@ -2,10 +2,13 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 
+	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 
 	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 )
 
 type Admin struct {
@@ -55,3 +58,127 @@ func (r *Admin) SaveDropInfos(ctx context.Context, tx bun.Tx, dropInfos *[]*mode
 		Exec(ctx)
 	return err
 }
+
+func (r *Admin) SaveItems(ctx context.Context, tx bun.Tx, items *[]*model.Item) error {
+	_, err := tx.NewInsert().
+		On("CONFLICT (ark_item_id) DO UPDATE").
+		Model(items).
+		Exec(ctx)
+	return err
+}
+
+func (r *Admin) UpdateItem(ctx context.Context, tx bun.Tx, item *model.Item) error {
+	_, err := tx.NewUpdate().
+		Model(item).
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+func (r *Admin) UpdateStage(ctx context.Context, tx bun.Tx, stage *model.Stage) error {
+	_, err := tx.NewUpdate().
+		Model(stage).
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+func (r *Admin) UpdateZone(ctx context.Context, tx bun.Tx, zone *model.Zone) error {
+	_, err := tx.NewUpdate().
+		Model(zone).
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+func (r *Admin) UpdateActivity(ctx context.Context, tx bun.Tx, activity *model.Activity) error {
+	_, err := tx.NewUpdate().
+		Model(activity).
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+func (r *Admin) UpdateDropInfo(ctx context.Context, tx bun.Tx, dropInfo *model.DropInfo) error {
+	_, err := tx.NewUpdate().
+		Model(dropInfo).
+		WherePK().
+		Exec(ctx)
+	return err
+}
+
+// GetForUpdate scans the row matched by where into dest within tx, locking it until tx ends. dest shall be a pointer
+// to a model.
+func (r *Admin) GetForUpdate(ctx context.Context, tx bun.Tx, dest any, where string, args ...any) error {
+	err := tx.NewSelect().
+		Model(dest).
+		Where(where, args...).
+		For("UPDATE").
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgerr.ErrNotFound
+	}
+	return err
+}
+
+// Get scans the row matched by where into dest within tx. dest shall be a pointer to a model.
+func (r *Admin) Get(ctx context.Context, tx bun.Tx, dest any, where string, args ...any) error {
+	err := tx.NewSelect().
+		Model(dest).
+		Where(where, args...).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgerr.ErrNotFound
+	}
+	return err
+}
+
+// Exists reports whether any row of the table of m is matched by where within tx. m shall be a pointer to a model,
+// which could be nil, e.g. (*model.Zone)(nil).
+func (r *Admin) Exists(ctx context.Context, tx bun.Tx, m any, where string, args ...any) (bool, error) {
+	return tx.NewSelect().
+		Model(m).
+		Where(where, args...).
+		Exists(ctx)
+}
+
+func (r *Admin) GetArkStageIdsByStageIds(ctx context.Context, tx bun.Tx, stageIds []int) ([]string, error) {
+	var arkStageIds []string
+	err := tx.NewSelect().
+		Model((*model.Stage)(nil)).
+		Column("ark_stage_id").
+		Where("stage_id IN (?)", bun.In(stageIds)).
+		Scan(ctx, &arkStageIds)
+	return arkStageIds, err
+}
+
+// GetItemDropInfosForUpdate returns drop infos of itemId, across drop types, of the stage and time range within tx,
+// locking them until tx ends.
+func (r *Admin) GetItemDropInfosForUpdate(ctx context.Context, tx bun.Tx, server string, stageId int, itemId int, rangeId int) ([]*model.DropInfo, error) {
+	var dropInfos []*model.DropInfo
+	err := tx.NewSelect().
+		Model(&dropInfos).
+		Where("server = ?", server).
+		Where("stage_id = ?", stageId).
+		Where("item_id = ?", itemId).
+		Where("range_id = ?", rangeId).
+		For("UPDATE").
+		Scan(ctx)
+	return dropInfos, err
+}
+
+// GetStageDropInfosForUpdate returns drop infos of stageId within time range rangeId of server within tx, locking
+// them until tx ends.
+func (r *Admin) GetStageDropInfosForUpdate(ctx context.Context, tx bun.Tx, server string, stageId int, rangeId int) ([]*model.DropInfo, error) {
+	var dropInfos []*model.DropInfo
+	err := tx.NewSelect().
+		Model(&dropInfos).
+		Where("server = ?", server).
+		Where("stage_id = ?", stageId).
+		Where("range_id = ?", rangeId).
+		For("UPDATE").
+		Scan(ctx)
+	return dropInfos, err
+}
@@ -0,0 +1,253 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbrouter"
+)
+
+// aggregateView is a materialized view pre-aggregating global reports per time range, which has to be refreshed to
+// include reports submitted since.
+type aggregateView struct {
+	name string
+	// query is the query the view is materialized from
+	query string
+	// uniqueColumns are the columns of the unique index of the view, which is required to refresh it concurrently
+	uniqueColumns string
+}
+
+// aggregateViewJoins joins reports with each time range of drop infos of their stages, and with their source.
+const aggregateViewJoins = `
+	JOIN time_ranges AS tr ON tr.server = dr.server AND dr.created_at >= tr.start_time AND dr.created_at < tr.end_time
+	LEFT JOIN drop_report_extras AS dre ON dre.report_id = dr.report_id`
+
+// aggregateViewConditions selects global reports, within time ranges of drop infos of their stages.
+const aggregateViewConditions = `
+	WHERE dr.reliability = 0
+		AND EXISTS (SELECT 1 FROM drop_infos AS di WHERE di.server = dr.server AND di.stage_id = dr.stage_id AND di.range_id = tr.range_id)`
+
+var aggregateViews = []aggregateView{
+	{
+		name: "drop_matrix_quantities_mv",
+		query: `SELECT dr.server, tr.range_id, dr.stage_id, dpe.item_id, dpe.quantity, dre.source_name, COUNT(*) AS count
+	FROM drop_reports AS dr
+	JOIN drop_pattern_elements AS dpe ON dpe.drop_pattern_id = dr.pattern_id` + aggregateViewJoins + aggregateViewConditions + `
+	GROUP BY dr.server, tr.range_id, dr.stage_id, dpe.item_id, dpe.quantity, dre.source_name`,
+		uniqueColumns: "server, range_id, stage_id, item_id, quantity, source_name",
+	},
+	{
+		name: "drop_matrix_times_mv",
+		query: `SELECT dr.server, tr.range_id, dr.stage_id, dre.source_name,
+		SUM(dr.times) AS total_times, COUNT(*) FILTER (WHERE dr.times = 1) AS single_times
+	FROM drop_reports AS dr` + aggregateViewJoins + aggregateViewConditions + `
+	GROUP BY dr.server, tr.range_id, dr.stage_id, dre.source_name`,
+		uniqueColumns: "server, range_id, stage_id, source_name",
+	},
+	{
+		name: "pattern_matrix_quantities_mv",
+		query: `SELECT dr.server, tr.range_id, dr.stage_id, dr.pattern_id, dre.source_name, COUNT(*) AS count
+	FROM drop_reports AS dr` + aggregateViewJoins + aggregateViewConditions + `
+		AND dr.times = 1
+	GROUP BY dr.server, tr.range_id, dr.stage_id, dr.pattern_id, dre.source_name`,
+		uniqueColumns: "server, range_id, stage_id, pattern_id, source_name",
+	},
+}
+
+// AggregateView reads aggregates of global reports used by the global drop matrix and pattern matrix from
+// materialized views, instead of aggregating reports on every calculation. The views are only as fresh as their
+// last refresh.
+type AggregateView struct {
+	DB     *bun.DB
+	Router *dbrouter.Router
+
+	// Enabled is whether aggregates are read from the views instead of the reports
+	Enabled bool
+}
+
+func NewAggregateView(db *bun.DB, router *dbrouter.Router, conf *config.Config) *AggregateView {
+	return &AggregateView{
+		DB:      db,
+		Router:  router,
+		Enabled: conf.MatrixMaterializedViews,
+	}
+}
+
+// EnsureViews creates the views, without data, and their unique indexes if they do not exist yet.
+func (s *AggregateView) EnsureViews(ctx context.Context) error {
+	for _, view := range aggregateViews {
+		if _, err := s.DB.ExecContext(ctx, "CREATE MATERIALIZED VIEW IF NOT EXISTS "+view.name+" AS "+view.query+" WITH NO DATA"); err != nil {
+			return err
+		}
+		if _, err := s.DB.ExecContext(ctx, "CREATE UNIQUE INDEX IF NOT EXISTS "+view.name+"_key ON "+view.name+" ("+view.uniqueColumns+")"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RefreshViews refreshes all views concurrently, so that they could still be read while being refreshed. Views are
+// refreshed in the same repeatable read transaction, so that all of them are of the same set of reports. Views which
+// have never been populated are populated non-concurrently, as required by Postgres.
+func (s *AggregateView) RefreshViews(ctx context.Context) error {
+	if err := s.EnsureViews(ctx); err != nil {
+		return err
+	}
+
+	return s.DB.RunInTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead}, func(ctx context.Context, tx bun.Tx) error {
+		for _, view := range aggregateViews {
+			var populated bool
+			if err := tx.NewSelect().
+				TableExpr("pg_matviews").
+				Column("ispopulated").
+				Where("matviewname = ?", view.name).
+				Scan(ctx, &populated); err != nil {
+				return err
+			}
+
+			refresh := "REFRESH MATERIALIZED VIEW CONCURRENTLY " + view.name
+			if !populated {
+				log.Info().Str("view", view.name).Msg("populating aggregate view for the first time")
+				refresh = "REFRESH MATERIALIZED VIEW " + view.name
+			}
+			if _, err := tx.ExecContext(ctx, refresh); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// CalcTotalQuantityForDropMatrix is repo.DropReport CalcTotalQuantityForDropMatrix of global reports within the time
+// range of rangeId.
+func (s *AggregateView) CalcTotalQuantityForDropMatrix(
+	ctx context.Context, server string, rangeId int, stageIdItemIdMap map[int][]int, sourceCategory string,
+) ([]*model.TotalQuantityResultForDropMatrix, error) {
+	results := make([]*model.TotalQuantityResultForDropMatrix, 0)
+	if len(stageIdItemIdMap) == 0 {
+		return results, nil
+	}
+
+	query := s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_matrix_quantities_mv AS mv").
+		Column("stage_id", "item_id").
+		ColumnExpr("SUM(quantity * count) AS total_quantity")
+	s.handleServerAndRange(query, server, rangeId)
+	s.handleStagesAndItems(query, stageIdItemIdMap)
+	s.handleSourceName(query, sourceCategory)
+
+	if err := query.
+		Group("stage_id", "item_id").
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CalcQuantityUniqCount is repo.DropReport CalcQuantityUniqCount of global reports within the time range of rangeId.
+func (s *AggregateView) CalcQuantityUniqCount(
+	ctx context.Context, server string, rangeId int, stageIdItemIdMap map[int][]int, sourceCategory string,
+) ([]*model.QuantityUniqCountResultForDropMatrix, error) {
+	results := make([]*model.QuantityUniqCountResultForDropMatrix, 0)
+	if len(stageIdItemIdMap) == 0 {
+		return results, nil
+	}
+
+	query := s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_matrix_quantities_mv AS mv").
+		Column("stage_id", "item_id", "quantity").
+		ColumnExpr("SUM(count) AS count")
+	s.handleServerAndRange(query, server, rangeId)
+	s.handleStagesAndItems(query, stageIdItemIdMap)
+	s.handleSourceName(query, sourceCategory)
+
+	if err := query.
+		Group("stage_id", "item_id", "quantity").
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CalcTotalTimes is repo.DropReport CalcTotalTimes of global reports within the time range of rangeId.
+func (s *AggregateView) CalcTotalTimes(
+	ctx context.Context, server string, rangeId int, stageIds []int, excludeNonOneTimes bool, sourceCategory string,
+) ([]*model.TotalTimesResult, error) {
+	results := make([]*model.TotalTimesResult, 0)
+	if len(stageIds) == 0 {
+		return results, nil
+	}
+
+	column := "total_times"
+	if excludeNonOneTimes {
+		column = "single_times"
+	}
+	query := s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_matrix_times_mv AS mv").
+		Column("stage_id").
+		ColumnExpr("SUM(?) AS total_times", bun.Ident(column)).
+		Where("stage_id IN (?)", bun.In(stageIds))
+	s.handleServerAndRange(query, server, rangeId)
+	s.handleSourceName(query, sourceCategory)
+
+	if err := query.
+		Group("stage_id").
+		Having("SUM(?) > 0", bun.Ident(column)).
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CalcTotalQuantityForPatternMatrix is repo.DropReport CalcTotalQuantityForPatternMatrix of global reports within
+// the time range of rangeId.
+func (s *AggregateView) CalcTotalQuantityForPatternMatrix(
+	ctx context.Context, server string, rangeId int, stageIds []int, sourceCategory string,
+) ([]*model.TotalQuantityResultForPatternMatrix, error) {
+	results := make([]*model.TotalQuantityResultForPatternMatrix, 0)
+	if len(stageIds) == 0 {
+		return results, nil
+	}
+
+	query := s.Router.Read(ctx).NewSelect().
+		TableExpr("pattern_matrix_quantities_mv AS mv").
+		Column("stage_id", "pattern_id").
+		ColumnExpr("SUM(count) AS total_quantity").
+		Where("stage_id IN (?)", bun.In(stageIds))
+	s.handleServerAndRange(query, server, rangeId)
+	s.handleSourceName(query, sourceCategory)
+
+	if err := query.
+		Group("stage_id", "pattern_id").
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (s *AggregateView) handleServerAndRange(query *bun.SelectQuery, server string, rangeId int) {
+	query.Where("server = ?", server).Where("range_id = ?", rangeId)
+}
+
+func (s *AggregateView) handleStagesAndItems(query *bun.SelectQuery, stageIdItemIdMap map[int][]int) {
+	query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		for stageId, itemIds := range stageIdItemIdMap {
+			q = q.WhereOr("stage_id = ? AND item_id IN (?)", stageId, bun.In(itemIds))
+		}
+		return q
+	})
+}
+
+func (s *AggregateView) handleSourceName(query *bun.SelectQuery, sourceCategory string) {
+	if sourceCategory == constant.SourceCategoryManual {
+		query.Where("source_name IN (?)", bun.In(constant.ManualSources))
+	} else if sourceCategory == constant.SourceCategoryAutomated {
+		query.Where("source_name NOT IN (?)", bun.In(constant.ManualSources))
+	}
+}
@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type Anomaly struct {
+	DB *bun.DB
+}
+
+func NewAnomaly(db *bun.DB) *Anomaly {
+	return &Anomaly{DB: db}
+}
+
+func (r *Anomaly) CreateAnomalies(ctx context.Context, anomalies []*model.Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+	_, err := r.DB.NewInsert().
+		Model(&anomalies).
+		Exec(ctx)
+
+	return err
+}
+
+// GetAnomaliesSince returns anomalies of server flagged at or after since, latest first.
+func (r *Anomaly) GetAnomaliesSince(ctx context.Context, server string, since time.Time) ([]*model.Anomaly, error) {
+	anomalies := make([]*model.Anomaly, 0)
+	err := r.DB.NewSelect().
+		Model(&anomalies).
+		Where("server = ?", server).
+		Where("created_at >= ?", since).
+		Order("anomaly_id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return anomalies, nil
+}
@@ -0,0 +1,123 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type APIKey struct {
+	DB *bun.DB
+}
+
+func NewAPIKey(db *bun.DB) *APIKey {
+	return &APIKey{DB: db}
+}
+
+// CreateAPIKey creates key, unless the account of key already has limit active API keys, in which case false is
+// returned. The account is locked while its keys are counted, so that concurrent creations never exceed limit.
+func (r *APIKey) CreateAPIKey(ctx context.Context, key *model.APIKey, limit int) (created bool, err error) {
+	err = r.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewSelect().
+			Model((*model.Account)(nil)).
+			Column("account_id").
+			Where("account_id = ?", key.AccountID).
+			For("UPDATE").
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		count, err := tx.NewSelect().
+			Model((*model.APIKey)(nil)).
+			Where("account_id = ?", key.AccountID).
+			Where("revoked_at IS NULL").
+			Count(ctx)
+		if err != nil {
+			return err
+		}
+		if count >= limit {
+			return nil
+		}
+
+		_, err = tx.NewInsert().
+			Model(key).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, err
+}
+
+func (r *APIKey) GetActiveAPIKeyByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.DB.NewSelect().
+		Model(&key).
+		Where("key_hash = ?", keyHash).
+		Where("revoked_at IS NULL").
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// GetActiveAPIKeysByAccountId returns active API keys of accountId, oldest first.
+func (r *APIKey) GetActiveAPIKeysByAccountId(ctx context.Context, accountId int) ([]*model.APIKey, error) {
+	keys := make([]*model.APIKey, 0)
+	err := r.DB.NewSelect().
+		Model(&keys).
+		Where("account_id = ?", accountId).
+		Where("revoked_at IS NULL").
+		Order("key_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes the active API key keyId of accountId, and returns the revoked key.
+func (r *APIKey) RevokeAPIKey(ctx context.Context, accountId int, keyId int) (*model.APIKey, error) {
+	var key model.APIKey
+	res, err := r.DB.NewUpdate().
+		Model(&key).
+		Set("revoked_at = ?", time.Now()).
+		Where("key_id = ?", keyId).
+		Where("account_id = ?", accountId).
+		Where("revoked_at IS NULL").
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, pgerr.ErrNotFound
+	}
+	return &key, nil
+}
+
+func (r *APIKey) TouchAPIKey(ctx context.Context, keyId int, usedAt time.Time) error {
+	_, err := r.DB.NewUpdate().
+		Model((*model.APIKey)(nil)).
+		Set("last_used_at = ?", usedAt).
+		Where("key_id = ?", keyId).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type AuditLog struct {
+	DB *bun.DB
+}
+
+func NewAuditLog(db *bun.DB) *AuditLog {
+	return &AuditLog{DB: db}
+}
+
+func (r *AuditLog) CreateAuditLog(ctx context.Context, auditLog *model.AuditLog) error {
+	_, err := r.DB.NewInsert().
+		Model(auditLog).
+		Returning("audit_id").
+		Exec(ctx)
+
+	return err
+}
+
+// AuditLogQuery filters audit logs. Zero values are not filtered on.
+type AuditLogQuery struct {
+	Actor string
+	// Action matches audit logs of the action, or of the route regardless of the method if it has no method.
+	Action    string
+	StartTime time.Time
+	EndTime   time.Time
+	// BeforeID lists audit logs older than the audit log of this id.
+	BeforeID int64
+	Limit    int
+}
+
+// GetAuditLogs returns up to query.Limit audit logs matching query, latest first.
+func (r *AuditLog) GetAuditLogs(ctx context.Context, query *AuditLogQuery) ([]*model.AuditLog, error) {
+	logs := make([]*model.AuditLog, 0)
+	q := r.DB.NewSelect().
+		Model(&logs).
+		Order("audit_id DESC").
+		Limit(query.Limit)
+	if query.Actor != "" {
+		q = q.Where("actor = ?", query.Actor)
+	}
+	if query.Action != "" {
+		q = q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("action = ?", query.Action).WhereOr("split_part(action, ' ', 2) = ?", query.Action)
+		})
+	}
+	if !query.StartTime.IsZero() {
+		q = q.Where("created_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		q = q.Where("created_at < ?", query.EndTime)
+	}
+	if query.BeforeID != 0 {
+		q = q.Where("audit_id < ?", query.BeforeID)
+	}
+	if err := q.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
@@ -32,7 +32,7 @@ func (s *DropInfo) GetDropInfo(ctx context.Context, id int) (*model.DropInfo, er
 	var dropInfo model.DropInfo
 	err := s.DB.NewSelect().
 		Model(&dropInfo).
-		Where("id = ?", id).
+		Where("drop_id = ?", id).
 		Scan(ctx)
 
 	if errors.Is(err, sql.ErrNoRows) {
@@ -106,6 +106,23 @@ func (s *DropInfo) GetForCurrentTimeRange(ctx context.Context, query *DropInfoQu
 	return dropInfo, nil
 }
 
+// GetNextTimeRangeChange returns when a time range of server starts or ends next, after which drop infos of the
+// current time range change, or the zero time if none starts or ends afterwards.
+func (s *DropInfo) GetNextTimeRangeChange(ctx context.Context, server string) (time.Time, error) {
+	var next sql.NullTime
+	err := s.DB.QueryRowContext(ctx,
+		"SELECT MIN(t) FROM ("+
+			"SELECT start_time AS t FROM time_ranges WHERE server = ? AND start_time > NOW() "+
+			"UNION ALL SELECT end_time AS t FROM time_ranges WHERE server = ? AND end_time > NOW()"+
+			") AS changes",
+		server, server,
+	).Scan(&next)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return next.Time, nil
+}
+
 func (s *DropInfo) GetItemDropSetByStageIdAndRangeId(ctx context.Context, server string, stageId int, rangeId int) ([]int, error) {
 	var results []int
 	err := s.DB.NewSelect().
@@ -133,7 +150,14 @@ func (s *DropInfo) GetForCurrentTimeRangeWithDropTypes(ctx context.Context, quer
 	if err != nil {
 		return nil, nil, err
 	}
-	for _, dropInfo := range allDropInfos {
+	itemDropInfos, typeDropInfos = SplitDropInfosByDropType(allDropInfos)
+	return itemDropInfos, typeDropInfos, nil
+}
+
+// SplitDropInfosByDropType splits dropInfos into drop infos of items and drop infos of drop types, leaving out
+// drop infos of recognition only items.
+func SplitDropInfosByDropType(dropInfos []*model.DropInfo) (itemDropInfos, typeDropInfos []*model.DropInfo) {
+	for _, dropInfo := range dropInfos {
 		if dropInfo.DropType != constant.DropTypeRecognitionOnly {
 			if dropInfo.ItemID.Valid {
 				itemDropInfos = append(itemDropInfos, dropInfo)
@@ -142,8 +166,7 @@ func (s *DropInfo) GetForCurrentTimeRangeWithDropTypes(ctx context.Context, quer
 			}
 		}
 	}
-
-	return itemDropInfos, typeDropInfos, nil
+	return itemDropInfos, typeDropInfos
 }
 
 func (s *DropInfo) GetDropInfosWithFilters(ctx context.Context, server string, timeRanges []*model.TimeRange, stageIdFilter []int, itemIdFilter []int) ([]*model.DropInfo, error) {
@@ -8,14 +8,16 @@ import (
 	"github.com/uptrace/bun"
 
 	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbrouter"
 )
 
 type DropMatrixElement struct {
-	db *bun.DB
+	db     *bun.DB
+	router *dbrouter.Router
 }
 
-func NewDropMatrixElement(db *bun.DB) *DropMatrixElement {
-	return &DropMatrixElement{db: db}
+func NewDropMatrixElement(db *bun.DB, router *dbrouter.Router) *DropMatrixElement {
+	return &DropMatrixElement{db: db, router: router}
 }
 
 func (s *DropMatrixElement) BatchSaveElements(ctx context.Context, elements []*model.DropMatrixElement, server string) error {
@@ -33,11 +35,42 @@ func (s *DropMatrixElement) BatchSaveElements(ctx context.Context, elements []*m
 	return nil
 }
 
+// ReplaceElementsForStages replaces elements of stageIds in sourceCategories within rangeId of server with elements,
+// leaving elements of other stages, source categories and time ranges untouched. Elements calculated under a lock
+// carried by ctx are refused with dlock.ErrFenced once the lock has been acquired by another holder who has replaced
+// elements since.
+func (s *DropMatrixElement) ReplaceElementsForStages(
+	ctx context.Context, elements []*model.DropMatrixElement, server string, rangeId int, stageIds []int, sourceCategories []string,
+) error {
+	return s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := fenceByLock(ctx, tx); err != nil {
+			return err
+		}
+		_, err := tx.NewDelete().
+			Model((*model.DropMatrixElement)(nil)).
+			Where("server = ?", server).
+			Where("range_id = ?", rangeId).
+			Where("stage_id IN (?)", bun.In(stageIds)).
+			Where("source_category IN (?)", bun.In(sourceCategories)).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if len(elements) == 0 {
+			return nil
+		}
+		_, err = tx.NewInsert().Model(&elements).Exec(ctx)
+		return err
+	})
+}
+
 func (s *DropMatrixElement) DeleteByServer(ctx context.Context, server string) error {
 	_, err := s.db.NewDelete().Model((*model.DropMatrixElement)(nil)).Where("server = ?", server).Exec(ctx)
 	return err
 }
 
+// GetElementsByServerAndSourceCategory returns elements of server in sourceCategory. Elements are read from the
+// primary, as they are read to refill caches right after being replaced, which the replica might lag behind.
 func (s *DropMatrixElement) GetElementsByServerAndSourceCategory(ctx context.Context, server string, sourceCategory string) ([]*model.DropMatrixElement, error) {
 	var elements []*model.DropMatrixElement
 	err := s.db.NewSelect().Model(&elements).Where("server = ?", server).Where("source_category = ?", sourceCategory).Scan(ctx)
@@ -48,3 +81,20 @@ func (s *DropMatrixElement) GetElementsByServerAndSourceCategory(ctx context.Con
 	}
 	return elements, nil
 }
+
+// GetElementsByStageAndItem returns elements of stageId and itemId of all servers in sourceCategory.
+func (s *DropMatrixElement) GetElementsByStageAndItem(ctx context.Context, stageId int, itemId int, sourceCategory string) ([]*model.DropMatrixElement, error) {
+	var elements []*model.DropMatrixElement
+	err := s.router.Read(ctx).NewSelect().
+		Model(&elements).
+		Where("stage_id = ?", stageId).
+		Where("item_id = ?", itemId).
+		Where("source_category = ?", sourceCategory).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return elements, nil
+}
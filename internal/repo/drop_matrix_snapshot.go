@@ -0,0 +1,88 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type DropMatrixSnapshot struct {
+	DB *bun.DB
+}
+
+func NewDropMatrixSnapshot(db *bun.DB) *DropMatrixSnapshot {
+	return &DropMatrixSnapshot{DB: db}
+}
+
+// SaveDropMatrixSnapshot saves snapshot, replacing the snapshot of the same server and date if any.
+func (r *DropMatrixSnapshot) SaveDropMatrixSnapshot(ctx context.Context, snapshot *model.DropMatrixSnapshot) error {
+	_, err := r.DB.NewInsert().
+		Model(snapshot).
+		On("CONFLICT (server, date) DO UPDATE").
+		Set("elements = EXCLUDED.elements").
+		Set("payload = EXCLUDED.payload").
+		Set("created_at = EXCLUDED.created_at").
+		Returning("snapshot_id").
+		Exec(ctx)
+
+	return err
+}
+
+// GetLatestDropMatrixSnapshot returns the latest snapshot of server taken on or before date, without its payload.
+func (r *DropMatrixSnapshot) GetLatestDropMatrixSnapshot(ctx context.Context, server string, date time.Time) (*model.DropMatrixSnapshot, error) {
+	var snapshot model.DropMatrixSnapshot
+	err := r.DB.NewSelect().
+		Model(&snapshot).
+		ExcludeColumn("payload").
+		Where("server = ?", server).
+		Where("date <= ?", date).
+		Order("date DESC").
+		Limit(1).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+// GetDropMatrixSnapshotPayload returns the payload of the snapshot of snapshotId.
+func (r *DropMatrixSnapshot) GetDropMatrixSnapshotPayload(ctx context.Context, snapshotId int) ([]byte, error) {
+	var payload []byte
+	err := r.DB.NewSelect().
+		Model((*model.DropMatrixSnapshot)(nil)).
+		Column("payload").
+		Where("snapshot_id = ?", snapshotId).
+		Scan(ctx, &payload)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// DeleteDropMatrixSnapshotsBefore deletes snapshots of all servers taken before date, and returns the number of
+// snapshots deleted.
+func (r *DropMatrixSnapshot) DeleteDropMatrixSnapshotsBefore(ctx context.Context, date time.Time) (int64, error) {
+	res, err := r.DB.NewDelete().
+		Model((*model.DropMatrixSnapshot)(nil)).
+		Where("date < ?", date).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
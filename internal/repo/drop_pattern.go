@@ -3,17 +3,11 @@ package repo
 import (
 	"context"
 	"database/sql"
-	"fmt"
-	"sort"
-	"strconv"
-	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
-	"github.com/zeebo/xxh3"
 
 	"github.com/penguin-statistics/backend-next/internal/model"
-	"github.com/penguin-statistics/backend-next/internal/model/types"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 )
 
@@ -72,43 +66,83 @@ func (s *DropPattern) GetDropPatternByHash(ctx context.Context, hash string) (*m
 	return &dropPattern, nil
 }
 
-func (s *DropPattern) GetOrCreateDropPatternFromDrops(ctx context.Context, tx bun.Tx, drops []*types.Drop) (*model.DropPattern, bool, error) {
-	originalFingerprint, hash := s.calculateDropPatternHash(drops)
+// GetOrCreateDropPattern returns the pattern of hash, which is created within tx if it does not exist yet, and
+// whether it has been created. The pattern is inserted optimistically, so that consumers creating the same pattern
+// concurrently neither fail on the unique index of hash nor create duplicates: inserts of all but the first one wait
+// for it to commit and then do nothing, and the pattern committed is selected instead.
+func (s *DropPattern) GetOrCreateDropPattern(ctx context.Context, tx bun.Tx, hash, originalFingerprint string) (*model.DropPattern, bool, error) {
 	dropPattern := &model.DropPattern{
 		Hash:                hash,
 		OriginalFingerprint: originalFingerprint,
 	}
-	err := tx.NewSelect().
+	res, err := tx.NewInsert().
 		Model(dropPattern).
-		Where("hash = ?", hash).
-		Scan(ctx)
-
-	if err == nil {
-		return dropPattern, false, nil
-	} else if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		On("CONFLICT (hash) DO NOTHING").
+		Returning("pattern_id").
+		Exec(ctx)
+	if err != nil {
 		return nil, false, err
 	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, false, err
+	}
+	if affected > 0 {
+		return dropPattern, true, nil
+	}
 
-	_, err = tx.NewInsert().
+	err = tx.NewSelect().
 		Model(dropPattern).
-		Exec(ctx)
-	if err != nil {
+		Where("hash = ?", hash).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		// the conflicting pattern has been deleted since, e.g. by the pattern dedup job, and reports of it are
+		// persisted once redelivered
+		return nil, false, errors.Errorf("drop pattern %s conflicted on insert but could not be found", hash)
+	} else if err != nil {
 		return nil, false, err
 	}
 
-	return dropPattern, true, nil
+	return dropPattern, false, nil
 }
 
-func (s *DropPattern) calculateDropPatternHash(drops []*types.Drop) (originalFingerprint, hexHash string) {
-	segments := make([]string, len(drops))
-
-	for i, drop := range drops {
-		segments[i] = fmt.Sprintf("%d:%d", drop.ItemID, drop.Quantity)
+// MergeDropPatterns points drop reports of patterns of duplicateIds to the pattern of survivorId, and deletes the
+// duplicate patterns along with their elements. It returns the number of drop reports rewritten.
+func (s *DropPattern) MergeDropPatterns(ctx context.Context, tx bun.Tx, survivorId int, duplicateIds []int) (int64, error) {
+	res, err := tx.NewUpdate().
+		Table("drop_reports").
+		Set("pattern_id = ?", survivorId).
+		Where("pattern_id IN (?)", bun.In(duplicateIds)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rewritten, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
 	}
 
-	sort.Strings(segments)
+	if _, err := tx.NewDelete().
+		Model((*model.DropPatternElement)(nil)).
+		Where("drop_pattern_id IN (?)", bun.In(duplicateIds)).
+		Exec(ctx); err != nil {
+		return 0, err
+	}
+	if _, err := tx.NewDelete().
+		Model((*model.DropPattern)(nil)).
+		Where("pattern_id IN (?)", bun.In(duplicateIds)).
+		Exec(ctx); err != nil {
+		return 0, err
+	}
+	return rewritten, nil
+}
 
-	originalFingerprint = strings.Join(segments, "|")
-	hash := xxh3.HashStringSeed(originalFingerprint, 0)
-	return originalFingerprint, strconv.FormatUint(hash, 16)
+// UpdateDropPatternHash updates the hash and the original fingerprint of dropPattern.
+func (s *DropPattern) UpdateDropPatternHash(ctx context.Context, tx bun.Tx, dropPattern *model.DropPattern) error {
+	_, err := tx.NewUpdate().
+		Model(dropPattern).
+		Column("hash", "original_fingerprint").
+		WherePK().
+		Exec(ctx)
+	return err
 }
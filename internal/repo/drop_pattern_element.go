@@ -107,3 +107,11 @@ func (r *DropPatternElement) GetDropPatternElementsByPatternId(ctx context.Conte
 	}
 	return elements, nil
 }
+
+func (r *DropPatternElement) DeleteDropPatternElementsByPatternId(ctx context.Context, tx bun.Tx, patternId int) error {
+	_, err := tx.NewDelete().
+		Model((*model.DropPatternElement)(nil)).
+		Where("drop_pattern_id = ?", patternId).
+		Exec(ctx)
+	return err
+}
@@ -2,28 +2,37 @@ package repo
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cursor"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbrouter"
 	"github.com/penguin-statistics/backend-next/internal/pkg/gameday"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgqry"
 )
 
 type DropReport struct {
-	DB *bun.DB
+	DB     *bun.DB
+	Router *dbrouter.Router
+	Views  *AggregateView
 }
 
-func NewDropReport(db *bun.DB) *DropReport {
+func NewDropReport(db *bun.DB, router *dbrouter.Router, views *AggregateView) *DropReport {
 	return &DropReport{
-		DB: db,
+		DB:     db,
+		Router: router,
+		Views:  views,
 	}
 }
 
@@ -34,15 +43,214 @@ func (s *DropReport) CreateDropReport(ctx context.Context, tx bun.Tx, dropReport
 	return err
 }
 
-func (s *DropReport) DeleteDropReport(ctx context.Context, reportId int) error {
-	_, err := s.DB.NewUpdate().
+// CreateDropReports inserts dropReports with a single statement, and populates their ReportID.
+func (s *DropReport) CreateDropReports(ctx context.Context, tx bun.Tx, dropReports []*model.DropReport) error {
+	if len(dropReports) == 0 {
+		return nil
+	}
+	_, err := tx.NewInsert().
+		Model(&dropReports).
+		Exec(ctx)
+	return err
+}
+
+func (s *DropReport) GetDropReportById(ctx context.Context, reportId int) (*model.DropReport, error) {
+	var dropReport model.DropReport
+	err := s.DB.NewSelect().
+		Model(&dropReport).
+		Where("report_id = ?", reportId).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &dropReport, nil
+}
+
+// GetDropReportByIdSince returns the report reportId only if it has been created since `since`. Bounding created_at
+// allows partitions of older months to be skipped when drop_reports is partitioned.
+func (s *DropReport) GetDropReportByIdSince(ctx context.Context, reportId int, since time.Time) (*model.DropReport, error) {
+	var dropReport model.DropReport
+	err := s.DB.NewSelect().
+		Model(&dropReport).
+		Where("report_id = ?", reportId).
+		Where("created_at >= ?", since).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &dropReport, nil
+}
+
+// DeleteDropReport soft-deletes reportId as recalled, keeping its row and pattern so that the recall could be
+// reversed with RestoreDropReport.
+func (s *DropReport) DeleteDropReport(ctx context.Context, tx bun.Tx, reportId int) error {
+	_, err := tx.NewUpdate().
 		Model((*model.DropReport)(nil)).
-		Set("reliability = ?", -1).
+		Set("reliability_before_deletion = reliability").
+		Set("reliability = ?", constant.ReliabilityRecalled).
+		Set("deleted_at = NOW()").
 		Where("report_id = ?", reportId).
+		Where("deleted_at IS NULL").
 		Exec(ctx)
 	return err
 }
 
+// SupersedeDropReport soft-deletes reportId within tx as replaced by a correction, and reports whether it has been
+// superseded. Reports which have been deleted already are left as they are.
+func (s *DropReport) SupersedeDropReport(ctx context.Context, tx bun.Tx, reportId int) (bool, error) {
+	res, err := tx.NewUpdate().
+		Model((*model.DropReport)(nil)).
+		Set("reliability_before_deletion = reliability").
+		Set("reliability = ?", constant.ReliabilityReplaced).
+		Set("deleted_at = NOW()").
+		Where("report_id = ?", reportId).
+		Where("deleted_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// RestoreDropReport reverses the recall or the purge of reportId within tx, restoring its reliability before the
+// deletion, and returns the report restored. pgerr.ErrNotFound is returned if the report has not been deleted, or
+// has been superseded by a correction, which would otherwise be counted twice.
+func (s *DropReport) RestoreDropReport(ctx context.Context, tx bun.Tx, reportId int) (*model.DropReport, error) {
+	var dropReport model.DropReport
+	_, err := tx.NewUpdate().
+		Model(&dropReport).
+		Set("reliability = reliability_before_deletion").
+		Set("reliability_before_deletion = NULL").
+		Set("deleted_at = NULL").
+		Where("report_id = ?", reportId).
+		Where("deleted_at IS NOT NULL").
+		Where("reliability <> ?", constant.ReliabilityReplaced).
+		Returning("*").
+		Exec(ctx, &dropReport)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	if dropReport.ReportID == 0 {
+		return nil, pgerr.ErrNotFound
+	}
+
+	return &dropReport, nil
+}
+
+// MarkDropReportsUnreliable sets reliability of reliable reports matching filter within tx, and returns the number
+// and the created_at range of affected reports per stage.
+func (s *DropReport) MarkDropReportsUnreliable(ctx context.Context, tx bun.Tx, filter *model.DropReportFilter, reliability int) ([]*model.PurgedStage, error) {
+	update := tx.NewUpdate().
+		TableExpr("drop_reports AS dr").
+		Set("reliability_before_deletion = dr.reliability").
+		Set("reliability = ?", reliability).
+		Set("deleted_at = NOW()").
+		Where("dr.report_id IN (?)", selectReliableDropReports(tx, filter).ColumnExpr("dr.report_id")).
+		Where("dr.reliability = 0").
+		Returning("dr.stage_id, dr.created_at")
+	return summarizePurgedStages(ctx, tx, update)
+}
+
+// CountReliableDropReports returns the number and the created_at range of reliable reports matching filter per
+// stage, i.e. what MarkDropReportsUnreliable would affect.
+func (s *DropReport) CountReliableDropReports(ctx context.Context, filter *model.DropReportFilter) ([]*model.PurgedStage, error) {
+	return summarizePurgedStages(ctx, s.DB, selectReliableDropReports(s.DB, filter).ColumnExpr("dr.stage_id, dr.created_at"))
+}
+
+// selectReliableDropReports selects reliable reports matching filter, without any columns selected.
+func selectReliableDropReports(db bun.IDB, filter *model.DropReportFilter) *bun.SelectQuery {
+	query := db.NewSelect().
+		TableExpr("drop_reports AS dr").
+		Where("dr.reliability = 0").
+		Where("dr.server = ?", filter.Server).
+		Where("dr.created_at >= ?", filter.StartTime).
+		Where("dr.created_at < ?", filter.EndTime)
+	if filter.AccountID.Valid {
+		query = query.Where("dr.account_id = ?", filter.AccountID.Int64)
+	}
+	if filter.StageID.Valid {
+		query = query.Where("dr.stage_id = ?", filter.StageID.Int64)
+	}
+	if filter.IPRange.Valid || filter.SourceName.Valid || filter.Version.Valid {
+		query = query.Join("JOIN drop_report_extras AS dre ON dre.report_id = dr.report_id")
+		if filter.IPRange.Valid {
+			query = query.Where("inet(dre.ip) <<= inet(?)", filter.IPRange.String)
+		}
+		if filter.SourceName.Valid {
+			query = query.Where("dre.source_name = ?", filter.SourceName.String)
+		}
+		if filter.Version.Valid {
+			query = query.Where("dre.version = ?", filter.Version.String)
+		}
+	}
+	return query
+}
+
+// summarizePurgedStages groups reports of reports, a query of their stage_id and created_at, by stage.
+func summarizePurgedStages(ctx context.Context, db bun.IDB, reports bun.Query) ([]*model.PurgedStage, error) {
+	results := make([]*model.PurgedStage, 0)
+	err := db.NewSelect().
+		With("purged", reports).
+		TableExpr("purged").
+		Column("stage_id").
+		ColumnExpr("COUNT(*) AS reports").
+		ColumnExpr("MIN(created_at) AS min_created_at").
+		ColumnExpr("MAX(created_at) AS max_created_at").
+		Group("stage_id").
+		Scan(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetMaxReportId returns the id of the latest drop report, or 0 if there is none.
+func (s *DropReport) GetMaxReportId(ctx context.Context) (int, error) {
+	var reportId int
+	err := s.DB.NewSelect().
+		TableExpr("drop_reports").
+		ColumnExpr("COALESCE(MAX(report_id), 0)").
+		Scan(ctx, &reportId)
+	return reportId, err
+}
+
+// GetTouchedStages returns the stages of server which have reports with an id in (afterReportId, untilReportId],
+// including reports recalled or rejected.
+func (s *DropReport) GetTouchedStages(ctx context.Context, server string, afterReportId int, untilReportId int) ([]*model.TouchedStage, error) {
+	stages := make([]*model.TouchedStage, 0)
+	err := s.DB.NewSelect().
+		TableExpr("drop_reports AS dr").
+		Column("dr.stage_id").
+		ColumnExpr("MIN(dr.created_at) AS min_created_at").
+		ColumnExpr("MAX(dr.created_at) AS max_created_at").
+		Where("dr.report_id > ?", afterReportId).
+		Where("dr.report_id <= ?", untilReportId).
+		Where("dr.created_at IS NOT NULL").
+		Where("dr.server = ?", server).
+		Group("dr.stage_id").
+		Scan(ctx, &stages)
+	if err != nil {
+		return nil, err
+	}
+	return stages, nil
+}
+
 func (s *DropReport) CalcTotalQuantityForDropMatrix(
 	ctx context.Context, server string, timeRange *model.TimeRange, stageIdItemIdMap map[int][]int, accountId null.Int, sourceCategory string,
 ) ([]*model.TotalQuantityResultForDropMatrix, error) {
@@ -50,6 +258,9 @@ func (s *DropReport) CalcTotalQuantityForDropMatrix(
 	if len(stageIdItemIdMap) == 0 {
 		return results, nil
 	}
+	if s.materialized(timeRange, accountId) {
+		return s.Views.CalcTotalQuantityForDropMatrix(ctx, server, timeRange.RangeID, stageIdItemIdMap, sourceCategory)
+	}
 
 	subq1 := s.DB.NewSelect().
 		TableExpr("drop_reports AS dr").
@@ -60,7 +271,7 @@ func (s *DropReport) CalcTotalQuantityForDropMatrix(
 	s.handleServer(subq1, server)
 	s.handleStagesAndItems(subq1, stageIdItemIdMap)
 
-	mainq := s.DB.NewSelect().
+	mainq := s.aggregateDB(ctx, accountId).NewSelect().
 		TableExpr("(?) AS a", subq1).
 		Column("stage_id", "item_id").
 		ColumnExpr("SUM(quantity) AS total_quantity").
@@ -75,6 +286,68 @@ func (s *DropReport) CalcTotalQuantityForDropMatrix(
 	return results, nil
 }
 
+// recencyWeightExpr is the weight of a report, decaying exponentially by half every halfLife seconds before now.
+func recencyWeightExpr(now time.Time, halfLife time.Duration) string {
+	return fmt.Sprintf("POWER(0.5, EXTRACT(EPOCH FROM (to_timestamp(%d) - dr.created_at)) / %f)", now.Unix(), halfLife.Seconds())
+}
+
+// CalcWeightedTimesForRecentDropMatrix returns the times of stageIds in reports of server created since `since`,
+// with each report weighted by recencyWeightExpr.
+func (s *DropReport) CalcWeightedTimesForRecentDropMatrix(
+	ctx context.Context, server string, stageIds []int, since time.Time, now time.Time, halfLife time.Duration,
+) ([]*model.WeightedTimesResult, error) {
+	results := make([]*model.WeightedTimesResult, 0)
+	if len(stageIds) == 0 {
+		return results, nil
+	}
+
+	query := s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_reports AS dr").
+		Column("dr.stage_id").
+		ColumnExpr("SUM(dr.times * " + recencyWeightExpr(now, halfLife) + ") AS weighted_times")
+	s.handleAccountAndReliability(query, null.NewInt(0, false))
+	s.handleCreatedAtWithTime(query, since, now)
+	s.handleServer(query, server)
+	s.handleStages(query, stageIds)
+
+	if err := query.
+		Group("dr.stage_id").
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CalcWeightedQuantityForRecentDropMatrix returns the quantity of items dropped from stages in stageIdItemIdMap
+// in reports of server created since `since`, with each report weighted by recencyWeightExpr.
+func (s *DropReport) CalcWeightedQuantityForRecentDropMatrix(
+	ctx context.Context, server string, stageIdItemIdMap map[int][]int, since time.Time, now time.Time, halfLife time.Duration,
+) ([]*model.WeightedQuantityResult, error) {
+	results := make([]*model.WeightedQuantityResult, 0)
+	if len(stageIdItemIdMap) == 0 {
+		return results, nil
+	}
+
+	weight := recencyWeightExpr(now, halfLife)
+	query := s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_reports AS dr").
+		Column("dr.stage_id", "dpe.item_id").
+		ColumnExpr("SUM(dpe.quantity * " + weight + ") AS weighted_quantity").
+		ColumnExpr("SUM(dpe.quantity * dpe.quantity * " + weight + ") AS weighted_quantity_squared").
+		Join("JOIN drop_pattern_elements AS dpe ON dpe.drop_pattern_id = dr.pattern_id")
+	s.handleAccountAndReliability(query, null.NewInt(0, false))
+	s.handleCreatedAtWithTime(query, since, now)
+	s.handleServer(query, server)
+	s.handleStagesAndItems(query, stageIdItemIdMap)
+
+	if err := query.
+		Group("dr.stage_id", "dpe.item_id").
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 func (s *DropReport) CalcTotalQuantityForPatternMatrix(
 	ctx context.Context, server string, timeRange *model.TimeRange, stageIds []int, accountId null.Int, sourceCategory string,
 ) ([]*model.TotalQuantityResultForPatternMatrix, error) {
@@ -82,6 +355,9 @@ func (s *DropReport) CalcTotalQuantityForPatternMatrix(
 	if len(stageIds) == 0 {
 		return results, nil
 	}
+	if s.materialized(timeRange, accountId) {
+		return s.Views.CalcTotalQuantityForPatternMatrix(ctx, server, timeRange.RangeID, stageIds, sourceCategory)
+	}
 
 	subq1 := s.DB.NewSelect().
 		TableExpr("drop_reports AS dr").
@@ -92,7 +368,7 @@ func (s *DropReport) CalcTotalQuantityForPatternMatrix(
 	s.handleStages(subq1, stageIds)
 	s.handleTimes(subq1, 1)
 
-	mainq := s.DB.NewSelect().
+	mainq := s.aggregateDB(ctx, accountId).NewSelect().
 		TableExpr("(?) AS a", subq1).
 		Column("stage_id", "pattern_id").
 		ColumnExpr("COUNT(*) AS total_quantity").
@@ -114,6 +390,9 @@ func (s *DropReport) CalcTotalTimes(
 	if len(stageIds) == 0 {
 		return results, nil
 	}
+	if s.materialized(timeRange, accountId) {
+		return s.Views.CalcTotalTimes(ctx, server, timeRange.RangeID, stageIds, excludeNonOneTimes, sourceCategory)
+	}
 
 	subq1 := s.DB.NewSelect().
 		TableExpr("drop_reports AS dr").
@@ -126,7 +405,7 @@ func (s *DropReport) CalcTotalTimes(
 	s.handleServer(subq1, server)
 	s.handleStages(subq1, stageIds)
 
-	mainq := s.DB.NewSelect().
+	mainq := s.aggregateDB(ctx, accountId).NewSelect().
 		TableExpr("(?) AS a", subq1).
 		Column("stage_id").
 		ColumnExpr("SUM(times) AS total_times").
@@ -148,6 +427,9 @@ func (s *DropReport) CalcQuantityUniqCount(
 	if len(stageIdItemIdMap) == 0 {
 		return results, nil
 	}
+	if s.materialized(timeRange, accountId) {
+		return s.Views.CalcQuantityUniqCount(ctx, server, timeRange.RangeID, stageIdItemIdMap, sourceCategory)
+	}
 
 	subq1 := s.DB.NewSelect().
 		TableExpr("drop_reports AS dr").
@@ -158,7 +440,7 @@ func (s *DropReport) CalcQuantityUniqCount(
 	s.handleServer(subq1, server)
 	s.handleStagesAndItems(subq1, stageIdItemIdMap)
 
-	mainq := s.DB.NewSelect().
+	mainq := s.aggregateDB(ctx, accountId).NewSelect().
 		TableExpr("(?) AS a", subq1).
 		Column("stage_id", "item_id", "quantity").
 		ColumnExpr("COUNT(*) AS count").
@@ -196,7 +478,7 @@ func (s *DropReport) CalcTotalQuantityForTrend(
 	s.handleServer(subq1, server)
 	s.handleStagesAndItems(subq1, stageIdItemIdMap)
 
-	mainq := s.DB.NewSelect().
+	mainq := s.aggregateDB(ctx, accountId).NewSelect().
 		TableExpr("(?) AS a", subq1).
 		Column("group_id", "interval_start", "interval_end", "stage_id", "item_id").
 		ColumnExpr("SUM(quantity) AS total_quantity").
@@ -233,7 +515,7 @@ func (s *DropReport) CalcTotalTimesForTrend(
 	s.handleServer(subq1, server)
 	s.handleStages(subq1, stageIds)
 
-	mainq := s.DB.NewSelect().
+	mainq := s.aggregateDB(ctx, accountId).NewSelect().
 		TableExpr("(?) AS a", subq1).
 		Column("group_id", "interval_start", "interval_end", "stage_id").
 		ColumnExpr("SUM(times) AS total_times").
@@ -248,9 +530,68 @@ func (s *DropReport) CalcTotalTimesForTrend(
 	return results, nil
 }
 
+// CalcPersonalTimesByInterval aggregates times of reports of accountId per stage, within each of intervalNum
+// intervals of intervalLength since the game day of startTime. Only intervals with reports are returned.
+func (s *DropReport) CalcPersonalTimesByInterval(
+	ctx context.Context, server string, accountId int, startTime time.Time, intervalLength time.Duration, intervalNum int,
+) ([]*model.TotalTimesResultForTrend, error) {
+	results := make([]*model.TotalTimesResultForTrend, 0)
+
+	gameDayStart := gameday.StartTime(server, startTime)
+	lastDayEnd := gameDayStart.Add(intervalLength * time.Duration(intervalNum))
+
+	query := s.DB.NewSelect().
+		With("intervals", s.genSubQueryForTrendSegments(gameDayStart, intervalLength, intervalNum-1)).
+		TableExpr("drop_reports AS dr").
+		Column("sub.group_id", "sub.interval_start", "sub.interval_end", "dr.stage_id").
+		ColumnExpr("SUM(dr.times) AS total_times").
+		Join("JOIN intervals AS sub").
+		JoinOn("dr.created_at >= sub.interval_start AND dr.created_at < sub.interval_end")
+	s.handleAccountAndReliability(query, null.IntFrom(int64(accountId)))
+	s.handleCreatedAtWithTime(query, gameDayStart, lastDayEnd)
+	s.handleServer(query, server)
+
+	if err := query.
+		Group("sub.group_id", "sub.interval_start", "sub.interval_end", "dr.stage_id").
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CalcPersonalQuantityByInterval aggregates quantity of drops of reports of accountId per stage and item, within
+// each of intervalNum intervals of intervalLength since the game day of startTime.
+func (s *DropReport) CalcPersonalQuantityByInterval(
+	ctx context.Context, server string, accountId int, startTime time.Time, intervalLength time.Duration, intervalNum int,
+) ([]*model.TotalQuantityResultForTrend, error) {
+	results := make([]*model.TotalQuantityResultForTrend, 0)
+
+	gameDayStart := gameday.StartTime(server, startTime)
+	lastDayEnd := gameDayStart.Add(intervalLength * time.Duration(intervalNum))
+
+	query := s.DB.NewSelect().
+		With("intervals", s.genSubQueryForTrendSegments(gameDayStart, intervalLength, intervalNum-1)).
+		TableExpr("drop_reports AS dr").
+		Column("sub.group_id", "sub.interval_start", "sub.interval_end", "dr.stage_id", "dpe.item_id").
+		ColumnExpr("SUM(dpe.quantity) AS total_quantity").
+		Join("JOIN drop_pattern_elements AS dpe ON dpe.drop_pattern_id = dr.pattern_id").
+		Join("JOIN intervals AS sub").
+		JoinOn("dr.created_at >= sub.interval_start AND dr.created_at < sub.interval_end")
+	s.handleAccountAndReliability(query, null.IntFrom(int64(accountId)))
+	s.handleCreatedAtWithTime(query, gameDayStart, lastDayEnd)
+	s.handleServer(query, server)
+
+	if err := query.
+		Group("sub.group_id", "sub.interval_start", "sub.interval_end", "dr.stage_id", "dpe.item_id").
+		Scan(ctx, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
 func (s *DropReport) CalcTotalSanityCostForShimSiteStats(ctx context.Context, server string) (sanity int, err error) {
 	err = pgqry.New(
-		s.DB.NewSelect().
+		s.Router.Read(ctx).NewSelect().
 			TableExpr("drop_reports AS dr").
 			ColumnExpr("SUM(st.sanity * dr.times)").
 			Where("dr.reliability = 0 AND dr.server = ?", server),
@@ -264,7 +605,7 @@ func (s *DropReport) CalcTotalStageQuantityForShimSiteStats(ctx context.Context,
 	results := make([]*modelv2.TotalStageTime, 0)
 
 	err := pgqry.New(
-		s.DB.NewSelect().
+		s.Router.Read(ctx).NewSelect().
 			TableExpr("drop_reports AS dr").
 			Column("st.ark_stage_id").
 			ColumnExpr("SUM(dr.times) AS total_times").
@@ -286,12 +627,59 @@ func (s *DropReport) CalcTotalStageQuantityForShimSiteStats(ctx context.Context,
 	return results, nil
 }
 
+// CalcUniqueReportersForSiteStats returns the number of distinct accounts submitting reliable reports on server
+// since the given time.
+func (s *DropReport) CalcUniqueReportersForSiteStats(ctx context.Context, server string, since time.Time) (count int, err error) {
+	err = s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_reports AS dr").
+		ColumnExpr("COUNT(DISTINCT dr.account_id)").
+		Where("dr.reliability = 0 AND dr.server = ?", server).
+		Where("dr.created_at >= ?", since).
+		Scan(ctx, &count)
+	return count, err
+}
+
+// CalcTotalSourceSubmissionsForSiteStats returns the number of reliable reports and their times on server since the
+// given time, per source name.
+func (s *DropReport) CalcTotalSourceSubmissionsForSiteStats(ctx context.Context, server string, since time.Time) ([]*model.TotalSourceSubmissionsResult, error) {
+	results := make([]*model.TotalSourceSubmissionsResult, 0)
+
+	err := s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_reports AS dr").
+		Join("JOIN drop_report_extras AS dre ON dre.report_id = dr.report_id").
+		Column("dre.source_name").
+		ColumnExpr("COUNT(*) AS total_reports").
+		ColumnExpr("SUM(dr.times) AS total_times").
+		Where("dr.reliability = 0 AND dr.server = ?", server).
+		Where("dr.created_at >= ?", since).
+		Group("dre.source_name").
+		Scan(ctx, &results)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CalcRejectionsSince returns the number of reports submitted on server since the given time, and the number of
+// those rejected by verifiers. Reports recalled or replaced by their submitters, or purged by admins, are not counted
+// as rejected.
+func (s *DropReport) CalcRejectionsSince(ctx context.Context, server string, since time.Time) (total int, rejected int, err error) {
+	err = s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_reports AS dr").
+		ColumnExpr("COUNT(*)").
+		ColumnExpr("COUNT(*) FILTER (WHERE dr.reliability > 0 AND dr.reliability <> ?)", constant.ViolationReliabilityPurged).
+		Where("dr.reliability >= 0 AND dr.server = ?", server).
+		Where("dr.created_at >= ?", since).
+		Scan(ctx, &total, &rejected)
+	return total, rejected, err
+}
+
 func (s *DropReport) CalcTotalItemQuantityForShimSiteStats(ctx context.Context, server string) ([]*modelv2.TotalItemQuantity, error) {
 	results := make([]*modelv2.TotalItemQuantity, 0)
 
 	types := []string{constant.ItemTypeMaterial, constant.ItemTypeFurniture, constant.ItemTypeChip}
 	err := pgqry.New(
-		s.DB.NewSelect().
+		s.Router.Read(ctx).NewSelect().
 			TableExpr("drop_reports AS dr").
 			Column("it.ark_item_id").
 			ColumnExpr("SUM(dpe.quantity) AS total_quantity").
@@ -307,6 +695,244 @@ func (s *DropReport) CalcTotalItemQuantityForShimSiteStats(ctx context.Context,
 	return results, nil
 }
 
+// StreamDailyDropAggregates calls fn with the times and the quantity of drops of reliable reports of server per
+// stage, item and game day, ordered by day, stage and item, without holding all aggregates in memory.
+func (s *DropReport) StreamDailyDropAggregates(ctx context.Context, server string, fn func(aggregate *model.DailyDropAggregate) error) error {
+	// reports are shifted so that game days start at midnight, and then truncated to dates
+	_, offset := time.Now().In(gameday.Location(server)).Zone()
+	shift := offset - constant.GameDayStartHour*int(time.Hour/time.Second)
+	dayExpr := "to_char(dr.created_at AT TIME ZONE 'UTC' + ? * interval '1 second', 'YYYY-MM-DD')"
+
+	times := s.DB.NewSelect().
+		TableExpr("drop_reports AS dr").
+		Column("dr.stage_id").
+		ColumnExpr(dayExpr+" AS day", shift).
+		ColumnExpr("SUM(dr.times) AS times").
+		Where("dr.reliability = 0 AND dr.server = ?", server).
+		GroupExpr("dr.stage_id, day")
+	quantities := s.DB.NewSelect().
+		TableExpr("drop_reports AS dr").
+		Join("JOIN drop_pattern_elements AS dpe ON dpe.drop_pattern_id = dr.pattern_id").
+		Column("dr.stage_id", "dpe.item_id").
+		ColumnExpr(dayExpr+" AS day", shift).
+		ColumnExpr("SUM(dpe.quantity) AS quantity").
+		Where("dr.reliability = 0 AND dr.server = ?", server).
+		GroupExpr("dr.stage_id, dpe.item_id, day")
+
+	rows, err := pgqry.New(
+		s.DB.NewSelect().
+			With("t", times).
+			With("q", quantities).
+			TableExpr("t").
+			Join("LEFT JOIN q ON q.stage_id = t.stage_id AND q.day = t.day").
+			ColumnExpr("t.day, st.ark_stage_id, it.ark_item_id, t.times, COALESCE(q.quantity, 0) AS quantity").
+			OrderExpr("t.day, st.ark_stage_id, it.ark_item_id"),
+	).
+		UseStageById("t.stage_id").
+		UseItemById("q.item_id").
+		Q.Rows(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var aggregate model.DailyDropAggregate
+		if err := s.DB.ScanRow(ctx, rows, &aggregate); err != nil {
+			return err
+		}
+		if err := fn(&aggregate); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// DropReportListQuery filters drop reports listed. Reports are not filtered on fields left zero.
+type DropReportListQuery struct {
+	AccountID      null.Int
+	Server         string
+	StageID        null.Int
+	Reliability    null.Int
+	MinReliability null.Int
+	// IPRange is an IP range in CIDR notation.
+	IPRange    null.String
+	SourceName null.String
+	// StartTime and EndTime limit reports to those created within [StartTime, EndTime).
+	StartTime time.Time
+	EndTime   time.Time
+	// Cursor lists reports after the cursor, or from the latest report if nil.
+	Cursor *cursor.Cursor
+	Limit  int
+}
+
+// ListDropReports returns up to query.Limit drop reports matching query along with their drops, ordered by creation
+// time and then report id, both descending.
+func (s *DropReport) ListDropReports(ctx context.Context, query *DropReportListQuery) ([]*model.ListedDropReport, error) {
+	reports := make([]*model.ListedDropReport, 0)
+	q := pgqry.New(
+		s.DB.NewSelect().
+			TableExpr("drop_reports AS dr").
+			Column("dr.report_id", "st.ark_stage_id", "dr.pattern_id", "dr.times", "dr.created_at", "dr.server", "dr.reliability", "dr.account_id", "dre.source_name", "dre.version", "dr.deleted_at").
+			Join("LEFT JOIN drop_report_extras AS dre ON dre.report_id = dr.report_id"),
+	).
+		UseStageById("dr.stage_id").
+		Q
+	if query.AccountID.Valid {
+		q = q.Where("dr.account_id = ?", query.AccountID.Int64)
+	}
+	if query.Server != "" {
+		s.handleServer(q, query.Server)
+	}
+	if query.StageID.Valid {
+		q = q.Where("dr.stage_id = ?", query.StageID.Int64)
+	}
+	if query.Reliability.Valid {
+		q = q.Where("dr.reliability = ?", query.Reliability.Int64)
+	}
+	if query.MinReliability.Valid {
+		q = q.Where("dr.reliability >= ?", query.MinReliability.Int64)
+	}
+	if query.IPRange.Valid {
+		q = q.Where("inet(dre.ip) <<= inet(?)", query.IPRange.String)
+	}
+	if query.SourceName.Valid {
+		q = q.Where("dre.source_name = ?", query.SourceName.String)
+	}
+	if !query.StartTime.IsZero() {
+		q = q.Where("dr.created_at >= ?", query.StartTime)
+	}
+	if !query.EndTime.IsZero() {
+		q = q.Where("dr.created_at < ?", query.EndTime)
+	}
+	if query.Cursor != nil {
+		q = q.Where("(dr.created_at, dr.report_id) < (?, ?)", query.Cursor.CreatedAt, query.Cursor.ReportID)
+	}
+	err := q.
+		OrderExpr("dr.created_at DESC, dr.report_id DESC").
+		Limit(query.Limit).
+		Scan(ctx, &reports)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return reports, nil
+	}
+
+	patternIds := make([]int, 0, len(reports))
+	for _, report := range reports {
+		patternIds = append(patternIds, report.PatternID)
+	}
+	drops := make([]*model.ListedDrop, 0)
+	err = pgqry.New(
+		s.DB.NewSelect().
+			TableExpr("drop_pattern_elements AS dpe").
+			Column("dpe.drop_pattern_id", "it.ark_item_id", "dpe.quantity").
+			Where("dpe.drop_pattern_id IN (?)", bun.In(patternIds)),
+	).
+		UseItemById("dpe.item_id").
+		Q.
+		OrderExpr("dpe.drop_pattern_id, it.sort_id").
+		Scan(ctx, &drops)
+	if err != nil {
+		return nil, err
+	}
+
+	dropsByPatternId := make(map[int][]*model.ListedDrop)
+	for _, drop := range drops {
+		dropsByPatternId[drop.DropPatternID] = append(dropsByPatternId[drop.DropPatternID], drop)
+	}
+	for _, report := range reports {
+		report.Drops = dropsByPatternId[report.PatternID]
+		if report.Drops == nil {
+			report.Drops = make([]*model.ListedDrop, 0)
+		}
+	}
+	return reports, nil
+}
+
+// ItemBoundsImpactQuery selects reliable reports of an item created within [Start, End) to be checked against the
+// Current and Proposed bounds of the item.
+type ItemBoundsImpactQuery struct {
+	Server  string
+	StageID int
+	ItemID  int
+	Start   time.Time
+	End     time.Time
+	// ScaleWithTimes is whether bounds scale with times of reports, which is not the case for gachabox stages.
+	ScaleWithTimes bool
+	Current        *model.Bounds
+	Proposed       *model.Bounds
+}
+
+// CalcItemBoundsImpact counts reports selected by query, and those of them having a quantity of the item out of the
+// proposed bounds, or newly out of them as they are within the current bounds. Reports not having the item dropped
+// are considered to have a quantity of 0.
+func (s *DropReport) CalcItemBoundsImpact(ctx context.Context, query *ItemBoundsImpactQuery) (*model.BoundsImpact, error) {
+	runsExpr := "1"
+	if query.ScaleWithTimes {
+		runsExpr = "GREATEST(dr.times, 1)"
+	}
+
+	subq := s.Router.Read(ctx).NewSelect().
+		TableExpr("drop_reports AS dr").
+		Column("dr.report_id").
+		ColumnExpr(runsExpr+" AS runs").
+		ColumnExpr("COALESCE(SUM(dpe.quantity), 0) AS quantity").
+		Join("LEFT JOIN drop_pattern_elements AS dpe ON dpe.drop_pattern_id = dr.pattern_id AND dpe.item_id = ?", query.ItemID).
+		Where("dr.reliability = 0").
+		Where("dr.stage_id = ?", query.StageID).
+		Group("dr.report_id", "dr.times")
+	s.handleServer(subq, query.Server)
+	s.handleCreatedAtWithTime(subq, query.Start, query.End)
+
+	current := withinBoundsExpr(query.Current)
+	proposed := withinBoundsExpr(query.Proposed)
+
+	var impact model.BoundsImpact
+	err := s.Router.Read(ctx).NewSelect().
+		TableExpr("(?) AS a", subq).
+		ColumnExpr("COUNT(*) AS checked").
+		ColumnExpr("COUNT(*) FILTER (WHERE NOT ("+proposed+")) AS out_of_bounds").
+		ColumnExpr("COUNT(*) FILTER (WHERE NOT ("+proposed+") AND ("+current+")) AS newly_out_of_bounds").
+		Scan(ctx, &impact)
+	if err != nil {
+		return nil, err
+	}
+	return &impact, nil
+}
+
+// withinBoundsExpr returns the condition of `quantity` of a report of `runs` being within bounds, the same as the
+// drop verifier judges drops of an item by. Exceptions are not applicable to reports of multiple runs.
+func withinBoundsExpr(bounds *model.Bounds) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "quantity BETWEEN %d * runs AND %d * runs", bounds.Lower, bounds.Upper)
+	if len(bounds.Exceptions) > 0 {
+		exceptions := make([]string, 0, len(bounds.Exceptions))
+		for _, exception := range bounds.Exceptions {
+			exceptions = append(exceptions, strconv.Itoa(exception))
+		}
+		fmt.Fprintf(&b, " AND NOT (runs = 1 AND quantity IN (%s))", strings.Join(exceptions, ","))
+	}
+	return b.String()
+}
+
+// materialized returns whether aggregates within timeRange of accountId shall be read from s.Views: only global
+// aggregates within a time range from the database are materialized, and only when the views are enabled.
+func (s *DropReport) materialized(timeRange *model.TimeRange, accountId null.Int) bool {
+	return s.Views.Enabled && !accountId.Valid && timeRange.RangeID != 0
+}
+
+// aggregateDB returns the database aggregate queries of accountId shall be executed against. Personal aggregates
+// are cached right after the account submits or recalls a report, so they are never read from the replica, which
+// might lag behind.
+func (s *DropReport) aggregateDB(ctx context.Context, accountId null.Int) *bun.DB {
+	if accountId.Valid {
+		return s.DB
+	}
+	return s.Router.Read(ctx)
+}
+
 func (s *DropReport) handleStagesAndItems(query *bun.SelectQuery, stageIdItemIdMap map[int][]int) {
 	stageConditions := make([]string, 0)
 	for stageId, itemIds := range stageIdItemIdMap {
@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type DropReportCorrection struct {
+	DB *bun.DB
+}
+
+func NewDropReportCorrection(db *bun.DB) *DropReportCorrection {
+	return &DropReportCorrection{DB: db}
+}
+
+func (c *DropReportCorrection) CreateDropReportCorrections(ctx context.Context, tx bun.Tx, corrections []*model.DropReportCorrection) error {
+	_, err := tx.NewInsert().
+		Model(&corrections).
+		Exec(ctx)
+
+	return err
+}
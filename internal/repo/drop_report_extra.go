@@ -7,6 +7,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/uptrace/bun"
 
+	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 )
@@ -36,12 +37,16 @@ func (c *DropReportExtra) GetDropReportExtraById(ctx context.Context, id int) (*
 	return &dropReportExtra, nil
 }
 
+// IsDropReportExtraMD5Exist returns whether a report of md5 has been persisted and not recalled. It reads from the
+// primary, as duplicates submitted in a row would be missed by the replica lagging behind.
 func (c *DropReportExtra) IsDropReportExtraMD5Exist(ctx context.Context, md5 string) bool {
 	var dropReportExtra model.DropReportExtra
 
 	count, err := c.DB.NewSelect().
 		Model(&dropReportExtra).
-		Where("md5 = ?", md5).
+		Join("JOIN drop_reports AS dr ON dr.report_id = dre.report_id").
+		Where("dre.md5 = ?", md5).
+		Where("dr.reliability != ?", constant.ReliabilityRecalled).
 		Count(ctx)
 	if err != nil {
 		return false
@@ -57,3 +62,15 @@ func (c *DropReportExtra) CreateDropReportExtra(ctx context.Context, tx bun.Tx,
 
 	return err
 }
+
+// CreateDropReportExtras inserts extras with a single statement. ReportID of each extra must have been set.
+func (c *DropReportExtra) CreateDropReportExtras(ctx context.Context, tx bun.Tx, extras []*model.DropReportExtra) error {
+	if len(extras) == 0 {
+		return nil
+	}
+	_, err := tx.NewInsert().
+		Model(&extras).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+)
+
+const (
+	dropReportsTable       = "drop_reports"
+	dropReportsLegacyTable = "drop_reports_legacy"
+	// dropReportsDefaultPartition holds reports whose created_at is not covered by any monthly partition
+	dropReportsDefaultPartition = "drop_reports_default"
+)
+
+// DropReportPartition manages the monthly range partitions of drop_reports, partitioned by created_at.
+type DropReportPartition struct {
+	DB *bun.DB
+}
+
+func NewDropReportPartition(db *bun.DB) *DropReportPartition {
+	return &DropReportPartition{DB: db}
+}
+
+// DropReportPartitionName returns the name of the partition holding reports created within the month of t.
+func DropReportPartitionName(t time.Time) string {
+	t = t.UTC()
+	return fmt.Sprintf("%s_y%04dm%02d", dropReportsTable, t.Year(), int(t.Month()))
+}
+
+// DropReportPartitionRange returns the range [start, end) of created_at covered by the partition of the month of t.
+func DropReportPartitionRange(t time.Time) (start time.Time, end time.Time) {
+	t = t.UTC()
+	start = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+func (r *DropReportPartition) IsPartitioned(ctx context.Context) (bool, error) {
+	return r.DB.NewSelect().
+		TableExpr("pg_partitioned_table").
+		Where("partrelid = to_regclass(?)", dropReportsTable).
+		Exists(ctx)
+}
+
+// GetPartitionNames returns the names of all partitions of drop_reports, including the default partition.
+func (r *DropReportPartition) GetPartitionNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := r.DB.NewSelect().
+		TableExpr("pg_inherits AS i").
+		Join("JOIN pg_class AS c ON c.oid = i.inhrelid").
+		ColumnExpr("c.relname").
+		Where("i.inhparent = to_regclass(?)", dropReportsTable).
+		OrderExpr("c.relname").
+		Scan(ctx, &names)
+	if err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// CreateMonthlyPartition creates the partition of the month of t if it does not exist yet. Note that creation fails
+// if the default partition already holds reports within the month.
+func (r *DropReportPartition) CreateMonthlyPartition(ctx context.Context, db bun.IDB, t time.Time) error {
+	start, end := DropReportPartitionRange(t)
+	_, err := db.ExecContext(ctx,
+		"CREATE TABLE IF NOT EXISTS ? PARTITION OF ? FOR VALUES FROM (?) TO (?)",
+		bun.Ident(DropReportPartitionName(t)), bun.Ident(dropReportsTable), start, end,
+	)
+	return err
+}
+
+// MigrateToPartitioned replaces drop_reports with a table partitioned by month of created_at, within tx.
+// The original table is renamed to drop_reports_legacy and kept for verification, and its rows are copied over.
+func (r *DropReportPartition) MigrateToPartitioned(ctx context.Context, tx bun.Tx) error {
+	var minCreatedAt, maxCreatedAt sql.NullTime
+	err := tx.QueryRowContext(ctx, "SELECT MIN(created_at), MAX(created_at) FROM ?", bun.Ident(dropReportsTable)).
+		Scan(&minCreatedAt, &maxCreatedAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to get created_at range of drop reports")
+	}
+
+	// the primary key of the partitioned table includes created_at, which therefore could not be null
+	var hasNullCreatedAt bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM ? WHERE created_at IS NULL)", bun.Ident(dropReportsTable)).
+		Scan(&hasNullCreatedAt)
+	if err != nil {
+		return errors.Wrap(err, "failed to check created_at of drop reports")
+	}
+	if hasNullCreatedAt {
+		return errors.New("drop reports without created_at could not be partitioned; fill in their created_at first")
+	}
+
+	var sequence sql.NullString
+	err = tx.QueryRowContext(ctx, "SELECT pg_get_serial_sequence(?, 'report_id')", dropReportsTable).
+		Scan(&sequence)
+	if err != nil {
+		return errors.Wrap(err, "failed to get report_id sequence")
+	}
+
+	type statement struct {
+		query string
+		args  []interface{}
+	}
+	statements := []statement{
+		{"ALTER TABLE ? RENAME TO ?", []interface{}{bun.Ident(dropReportsTable), bun.Ident(dropReportsLegacyTable)}},
+		{"CREATE TABLE ? (LIKE ? INCLUDING DEFAULTS INCLUDING CONSTRAINTS) PARTITION BY RANGE (created_at)",
+			[]interface{}{bun.Ident(dropReportsTable), bun.Ident(dropReportsLegacyTable)}},
+		// a primary key of a partitioned table must include the partition key, so created_at is appended to report_id,
+		// which still identifies reports as report_id is drawn from a sequence
+		{"ALTER TABLE ? ADD PRIMARY KEY (report_id, created_at)", []interface{}{bun.Ident(dropReportsTable)}},
+		{"CREATE INDEX ON ? (stage_id, created_at)", []interface{}{bun.Ident(dropReportsTable)}},
+		{"CREATE TABLE ? PARTITION OF ? DEFAULT", []interface{}{bun.Ident(dropReportsDefaultPartition), bun.Ident(dropReportsTable)}},
+	}
+	if sequence.Valid {
+		// the sequence would otherwise be dropped together with the legacy table
+		statements = append(statements, statement{"ALTER SEQUENCE ? OWNED BY ?.report_id",
+			[]interface{}{bun.Safe(sequence.String), bun.Ident(dropReportsTable)}})
+	}
+	for _, s := range statements {
+		if _, err := tx.ExecContext(ctx, s.query, s.args...); err != nil {
+			return err
+		}
+	}
+
+	if minCreatedAt.Valid && maxCreatedAt.Valid {
+		for month, _ := DropReportPartitionRange(minCreatedAt.Time); !month.After(maxCreatedAt.Time); month = month.AddDate(0, 1, 0) {
+			if err := r.CreateMonthlyPartition(ctx, tx, month); err != nil {
+				return errors.Wrapf(err, "failed to create partition %s", DropReportPartitionName(month))
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO ? SELECT * FROM ?", bun.Ident(dropReportsTable), bun.Ident(dropReportsLegacyTable))
+	return errors.Wrap(err, "failed to copy drop reports")
+}
@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type DropReportRecall struct {
+	DB *bun.DB
+}
+
+func NewDropReportRecall(db *bun.DB) *DropReportRecall {
+	return &DropReportRecall{DB: db}
+}
+
+func (c *DropReportRecall) CreateDropReportRecall(ctx context.Context, tx bun.Tx, recall *model.DropReportRecall) error {
+	_, err := tx.NewInsert().
+		Model(recall).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type DropType struct {
+	DB *bun.DB
+}
+
+func NewDropType(db *bun.DB) *DropType {
+	return &DropType{DB: db}
+}
+
+func (r *DropType) GetDropTypes(ctx context.Context) ([]*model.DropType, error) {
+	dropTypes := make([]*model.DropType, 0)
+	err := r.DB.NewSelect().
+		Model(&dropTypes).
+		Order("drop_type").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return dropTypes, nil
+}
+
+// SaveDropType creates dropType, or replaces the drop type of the same name.
+func (r *DropType) SaveDropType(ctx context.Context, dropType *model.DropType) error {
+	_, err := r.DB.NewInsert().
+		Model(dropType).
+		On("CONFLICT (drop_type) DO UPDATE").
+		Set("api_name = EXCLUDED.api_name").
+		Set("aliases = EXCLUDED.aliases").
+		Set("reportable = EXCLUDED.reportable").
+		Set("in_pattern = EXCLUDED.in_pattern").
+		Set("description = EXCLUDED.description").
+		Set("updated_at = current_timestamp").
+		Returning("updated_at").
+		Exec(ctx)
+
+	return err
+}
+
+// DeleteDropType deletes the drop type of name, and returns it, or nil if it does not exist.
+func (r *DropType) DeleteDropType(ctx context.Context, name string) (*model.DropType, error) {
+	dropTypes := make([]*model.DropType, 0, 1)
+	_, err := r.DB.NewDelete().
+		Model(&dropTypes).
+		Where("drop_type = ?", name).
+		Returning("*").
+		Exec(ctx)
+	if err != nil || len(dropTypes) == 0 {
+		return nil, err
+	}
+
+	return dropTypes[0], nil
+}
@@ -0,0 +1,117 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type GameDataChange struct {
+	DB *bun.DB
+}
+
+func NewGameDataChange(db *bun.DB) *GameDataChange {
+	return &GameDataChange{DB: db}
+}
+
+// GetGameDataChanges returns the latest limit changes of status, latest first.
+func (r *GameDataChange) GetGameDataChanges(ctx context.Context, status string, limit int) ([]*model.GameDataChange, error) {
+	changes := make([]*model.GameDataChange, 0)
+	err := r.DB.NewSelect().
+		Model(&changes).
+		Where("status = ?", status).
+		Order("change_id DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (r *GameDataChange) GetGameDataChangeById(ctx context.Context, changeId int) (*model.GameDataChange, error) {
+	var change model.GameDataChange
+	err := r.DB.NewSelect().
+		Model(&change).
+		Where("change_id = ?", changeId).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &change, nil
+}
+
+// GetPendingGameDataChanges returns all pending changes within tx, locking them until tx ends.
+func (r *GameDataChange) GetPendingGameDataChanges(ctx context.Context, tx bun.Tx) ([]*model.GameDataChange, error) {
+	changes := make([]*model.GameDataChange, 0)
+	err := tx.NewSelect().
+		Model(&changes).
+		Where("status = ?", constant.GameDataChangeStatusPending).
+		For("UPDATE").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (r *GameDataChange) CreateGameDataChanges(ctx context.Context, tx bun.Tx, changes []*model.GameDataChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewInsert().
+		Model(&changes).
+		Exec(ctx)
+
+	return err
+}
+
+func (r *GameDataChange) SupersedeGameDataChanges(ctx context.Context, tx bun.Tx, changeIds []int) error {
+	if len(changeIds) == 0 {
+		return nil
+	}
+
+	_, err := tx.NewUpdate().
+		Model((*model.GameDataChange)(nil)).
+		Set("status = ?", constant.GameDataChangeStatusSuperseded).
+		Where("change_id IN (?)", bun.In(changeIds)).
+		Where("status = ?", constant.GameDataChangeStatusPending).
+		Exec(ctx)
+
+	return err
+}
+
+// TransitGameDataChange changes the status of the change of changeId from status from to status to, and reports
+// whether the change was of status from. reviewedAt is recorded along, and cleared if nil.
+func (r *GameDataChange) TransitGameDataChange(ctx context.Context, changeId int, from string, to string, reviewedAt *time.Time) (bool, error) {
+	res, err := r.DB.NewUpdate().
+		Model((*model.GameDataChange)(nil)).
+		Set("status = ?", to).
+		Set("reviewed_at = ?", reviewedAt).
+		Where("change_id = ?", changeId).
+		Where("status = ?", from).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
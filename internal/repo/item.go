@@ -12,6 +12,8 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 )
 
+// Item reads items from the primary rather than the replica, as they are read to refill caches, which are dropped
+// right after items are updated, and which the replica might lag behind.
 type Item struct {
 	DB *bun.DB
 }
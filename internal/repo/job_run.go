@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type JobRun struct {
+	DB *bun.DB
+}
+
+func NewJobRun(db *bun.DB) *JobRun {
+	return &JobRun{DB: db}
+}
+
+func (r *JobRun) CreateJobRun(ctx context.Context, run *model.JobRun) error {
+	_, err := r.DB.NewInsert().
+		Model(run).
+		Returning("run_id").
+		Exec(ctx)
+
+	return err
+}
+
+// FinishJobRun records the duration, result and error of run.
+func (r *JobRun) FinishJobRun(ctx context.Context, run *model.JobRun) error {
+	_, err := r.DB.NewUpdate().
+		Model(run).
+		Column("duration_ms", "result", "error").
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+// GetJobRuns returns the latest limit runs of job, or of all jobs if job is empty, latest first.
+func (r *JobRun) GetJobRuns(ctx context.Context, job string, limit int) ([]*model.JobRun, error) {
+	runs := make([]*model.JobRun, 0)
+	query := r.DB.NewSelect().
+		Model(&runs).
+		Order("run_id DESC").
+		Limit(limit)
+	if job != "" {
+		query = query.Where("job = ?", job)
+	}
+	if err := query.Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	return runs, nil
+}
@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
+)
+
+// fenceByLock refuses the write of tx with dlock.ErrFenced if it is made under a lock, carried by ctx, which has been
+// acquired by another holder with a greater fencing token, and that holder has written under it. Otherwise, the
+// token of the lock is recorded within tx, so that writes of holders of smaller tokens are refused from then on.
+// Writes not made under any lock are let through.
+func fenceByLock(ctx context.Context, tx bun.Tx) error {
+	lock := dlock.FromContext(ctx)
+	if lock == nil {
+		return nil
+	}
+
+	result, err := tx.NewInsert().
+		Model(&model.LockFence{Name: lock.Name(), Token: lock.Token()}).
+		On("CONFLICT (name) DO UPDATE").
+		Set("token = EXCLUDED.token").
+		Where("lf.token <= EXCLUDED.token").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return dlock.ErrFenced
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type MatrixWatermark struct {
+	DB *bun.DB
+}
+
+func NewMatrixWatermark(db *bun.DB) *MatrixWatermark {
+	return &MatrixWatermark{DB: db}
+}
+
+func (r *MatrixWatermark) GetMatrixWatermark(ctx context.Context, matrix string, server string) (*model.MatrixWatermark, error) {
+	var watermark model.MatrixWatermark
+	err := r.DB.NewSelect().
+		Model(&watermark).
+		Where("matrix = ?", matrix).
+		Where("server = ?", server).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &watermark, nil
+}
+
+func (r *MatrixWatermark) UpsertMatrixWatermark(ctx context.Context, watermark *model.MatrixWatermark) error {
+	_, err := r.DB.NewInsert().
+		Model(watermark).
+		On("CONFLICT (matrix, server) DO UPDATE").
+		Set("report_id = EXCLUDED.report_id").
+		Set("full_refreshed_at = EXCLUDED.full_refreshed_at").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+
+	return err
+}
@@ -38,6 +38,8 @@ func (s *PatternMatrixElement) DeleteByServer(ctx context.Context, server string
 	return err
 }
 
+// GetElementsByServerAndSourceCategory returns elements of server in sourceCategory. Elements are read from the
+// primary, as they are read to refill caches right after being replaced, which the replica might lag behind.
 func (s *PatternMatrixElement) GetElementsByServerAndSourceCategory(ctx context.Context, server string, sourceCategory string) ([]*model.PatternMatrixElement, error) {
 	var elements []*model.PatternMatrixElement
 	err := s.db.NewSelect().Model(&elements).Where("server = ?", server).Where("source_category = ?", sourceCategory).Scan(ctx)
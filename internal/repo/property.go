@@ -49,3 +49,25 @@ func (c *Property) GetPropertyByKey(ctx context.Context, key string) (*model.Pro
 
 	return &property, nil
 }
+
+// UpsertProperty sets the value of the property of key, creating the property if it does not exist yet.
+func (c *Property) UpsertProperty(ctx context.Context, key string, value string) error {
+	return c.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().
+			Model((*model.Property)(nil)).
+			Set("value = ?", value).
+			Where("key = ?", key).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if affected, err := res.RowsAffected(); err != nil || affected > 0 {
+			return err
+		}
+
+		_, err = tx.NewInsert().
+			Model(&model.Property{Key: key, Value: value}).
+			Exec(ctx)
+		return err
+	})
+}
@@ -0,0 +1,128 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type Recognition struct {
+	DB *bun.DB
+}
+
+func NewRecognition(db *bun.DB) *Recognition {
+	return &Recognition{DB: db}
+}
+
+// GetBundles returns recognition bundles without their content, newest first.
+func (r *Recognition) GetBundles(ctx context.Context) ([]*model.RecognitionBundle, error) {
+	bundles := make([]*model.RecognitionBundle, 0)
+	err := r.DB.NewSelect().
+		Model(&bundles).
+		ExcludeColumn("content").
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return bundles, nil
+}
+
+// GetBundleByHash returns the bundle of hash without its content.
+func (r *Recognition) GetBundleByHash(ctx context.Context, hash string) (*model.RecognitionBundle, error) {
+	var bundle model.RecognitionBundle
+	err := r.DB.NewSelect().
+		Model(&bundle).
+		ExcludeColumn("content").
+		Where("hash = ?", hash).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &bundle, nil
+}
+
+func (r *Recognition) GetBundleContentByHash(ctx context.Context, hash string) (*model.RecognitionBundleContent, error) {
+	var content model.RecognitionBundleContent
+	err := r.DB.NewSelect().
+		Model((*model.RecognitionBundle)(nil)).
+		Column("content_type", "content").
+		Where("hash = ?", hash).
+		Scan(ctx, &content.ContentType, &content.Content)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &content, nil
+}
+
+// CreateBundle creates bundle, and reports whether it has been created. As bundles are addressed by the hash of
+// their content, a bundle already uploaded is left as is.
+func (r *Recognition) CreateBundle(ctx context.Context, bundle *model.RecognitionBundle) (bool, error) {
+	res, err := r.DB.NewInsert().
+		Model(bundle).
+		On("CONFLICT (hash) DO NOTHING").
+		Returning("created_at").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+func (r *Recognition) GetReleases(ctx context.Context) ([]*model.RecognitionRelease, error) {
+	releases := make([]*model.RecognitionRelease, 0)
+	err := r.DB.NewSelect().
+		Model(&releases).
+		Order("server").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+func (r *Recognition) GetReleaseByServer(ctx context.Context, server string) (*model.RecognitionRelease, error) {
+	var release model.RecognitionRelease
+	err := r.DB.NewSelect().
+		Model(&release).
+		Where("server = ?", server).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &release, nil
+}
+
+// SaveRelease points the server of release to the bundle of it.
+func (r *Recognition) SaveRelease(ctx context.Context, release *model.RecognitionRelease) error {
+	_, err := r.DB.NewInsert().
+		Model(release).
+		On("CONFLICT (server) DO UPDATE").
+		Set("hash = EXCLUDED.hash").
+		Set("updated_at = current_timestamp").
+		Returning("updated_at").
+		Exec(ctx)
+
+	return err
+}
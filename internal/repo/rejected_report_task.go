@@ -0,0 +1,69 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type RejectedReportTask struct {
+	DB *bun.DB
+}
+
+func NewRejectedReportTask(db *bun.DB) *RejectedReportTask {
+	return &RejectedReportTask{DB: db}
+}
+
+func (c *RejectedReportTask) GetRejectedReportTasks(ctx context.Context) ([]*model.RejectedReportTask, error) {
+	var tasks []*model.RejectedReportTask
+	err := c.DB.NewSelect().
+		Model(&tasks).
+		Order("rejected_task_id DESC").
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (c *RejectedReportTask) GetRejectedReportTaskById(ctx context.Context, id int) (*model.RejectedReportTask, error) {
+	var task model.RejectedReportTask
+	err := c.DB.NewSelect().
+		Model(&task).
+		Where("rejected_task_id = ?", id).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+func (c *RejectedReportTask) CreateRejectedReportTask(ctx context.Context, task *model.RejectedReportTask) error {
+	_, err := c.DB.NewInsert().
+		Model(task).
+		Exec(ctx)
+
+	return err
+}
+
+func (c *RejectedReportTask) DeleteRejectedReportTask(ctx context.Context, id int) error {
+	_, err := c.DB.NewDelete().
+		Model((*model.RejectedReportTask)(nil)).
+		Where("rejected_task_id = ?", id).
+		Exec(ctx)
+
+	return err
+}
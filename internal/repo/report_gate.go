@@ -0,0 +1,47 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type ReportGate struct {
+	DB *bun.DB
+}
+
+func NewReportGate(db *bun.DB) *ReportGate {
+	return &ReportGate{DB: db}
+}
+
+func (c *ReportGate) GetReportGates(ctx context.Context) ([]*model.ReportGate, error) {
+	gates := make([]*model.ReportGate, 0)
+	err := c.DB.NewSelect().
+		Model(&gates).
+		Order("gate_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return gates, nil
+}
+
+func (c *ReportGate) CreateReportGate(ctx context.Context, gate *model.ReportGate) error {
+	_, err := c.DB.NewInsert().
+		Model(gate).
+		Exec(ctx)
+
+	return err
+}
+
+func (c *ReportGate) DeleteReportGate(ctx context.Context, id int) error {
+	_, err := c.DB.NewDelete().
+		Model((*model.ReportGate)(nil)).
+		Where("gate_id = ?", id).
+		Exec(ctx)
+
+	return err
+}
@@ -0,0 +1,40 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type ReportPurge struct {
+	DB *bun.DB
+}
+
+func NewReportPurge(db *bun.DB) *ReportPurge {
+	return &ReportPurge{DB: db}
+}
+
+func (r *ReportPurge) CreateReportPurge(ctx context.Context, tx bun.Tx, purge *model.ReportPurge) error {
+	_, err := tx.NewInsert().
+		Model(purge).
+		Exec(ctx)
+
+	return err
+}
+
+// GetReportPurges returns the latest limit purges, latest first.
+func (r *ReportPurge) GetReportPurges(ctx context.Context, limit int) ([]*model.ReportPurge, error) {
+	purges := make([]*model.ReportPurge, 0)
+	err := r.DB.NewSelect().
+		Model(&purges).
+		Order("purge_id DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return purges, nil
+}
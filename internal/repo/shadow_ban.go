@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type ShadowBan struct {
+	DB *bun.DB
+}
+
+func NewShadowBan(db *bun.DB) *ShadowBan {
+	return &ShadowBan{DB: db}
+}
+
+// GetActiveShadowBans returns all bans which have not expired yet.
+func (c *ShadowBan) GetActiveShadowBans(ctx context.Context) ([]*model.ShadowBan, error) {
+	bans := make([]*model.ShadowBan, 0)
+	err := c.DB.NewSelect().
+		Model(&bans).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("ban_id DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return bans, nil
+}
+
+func (c *ShadowBan) CreateShadowBan(ctx context.Context, ban *model.ShadowBan) error {
+	_, err := c.DB.NewInsert().
+		Model(ban).
+		Exec(ctx)
+
+	return err
+}
+
+// DeleteShadowBan deletes the ban of id, and returns it, or nil if it does not exist.
+func (c *ShadowBan) DeleteShadowBan(ctx context.Context, id int) (*model.ShadowBan, error) {
+	bans := make([]*model.ShadowBan, 0, 1)
+	_, err := c.DB.NewDelete().
+		Model(&bans).
+		Where("ban_id = ?", id).
+		Returning("*").
+		Exec(ctx)
+	if err != nil || len(bans) == 0 {
+		return nil, err
+	}
+
+	return bans[0], nil
+}
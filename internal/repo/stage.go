@@ -16,6 +16,8 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 )
 
+// Stage reads stages from the primary rather than the replica, as they are read to refill caches, which are dropped
+// right after stages are updated, and which the replica might lag behind.
 type Stage struct {
 	db *bun.DB
 }
@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type StageRewriteRule struct {
+	DB *bun.DB
+}
+
+func NewStageRewriteRule(db *bun.DB) *StageRewriteRule {
+	return &StageRewriteRule{DB: db}
+}
+
+// GetStageRewriteRules returns all rules, in the order they are evaluated.
+func (c *StageRewriteRule) GetStageRewriteRules(ctx context.Context) ([]*model.StageRewriteRule, error) {
+	rules := make([]*model.StageRewriteRule, 0)
+	err := c.DB.NewSelect().
+		Model(&rules).
+		Order("rule_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func (c *StageRewriteRule) CreateStageRewriteRule(ctx context.Context, rule *model.StageRewriteRule) error {
+	_, err := c.DB.NewInsert().
+		Model(rule).
+		Exec(ctx)
+
+	return err
+}
+
+func (c *StageRewriteRule) DeleteStageRewriteRule(ctx context.Context, id int) error {
+	_, err := c.DB.NewDelete().
+		Model((*model.StageRewriteRule)(nil)).
+		Where("rule_id = ?", id).
+		Exec(ctx)
+
+	return err
+}
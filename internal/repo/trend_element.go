@@ -18,12 +18,19 @@ func NewTrendElement(db *bun.DB) *TrendElement {
 	return &TrendElement{db: db}
 }
 
-func (s *TrendElement) BatchSaveElements(ctx context.Context, elements []*model.TrendElement, server string) error {
+// BatchSaveElements replaces trend elements of server in granularity with elements.
+func (s *TrendElement) BatchSaveElements(ctx context.Context, elements []*model.TrendElement, server string, granularity string) error {
 	err := s.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
-		_, err := tx.NewDelete().Model((*model.TrendElement)(nil)).Where("server = ?", server).Exec(ctx)
+		_, err := tx.NewDelete().Model((*model.TrendElement)(nil)).
+			Where("server = ?", server).
+			Where("granularity = ?", granularity).
+			Exec(ctx)
 		if err != nil {
 			return err
 		}
+		if len(elements) == 0 {
+			return nil
+		}
 		_, err = tx.NewInsert().Model(&elements).Exec(ctx)
 		return err
 	})
@@ -38,9 +45,13 @@ func (s *TrendElement) DeleteByServer(ctx context.Context, server string) error
 	return err
 }
 
-func (s *TrendElement) GetElementsByServerAndSourceCategory(ctx context.Context, server string, sourceCategory string) ([]*model.TrendElement, error) {
+func (s *TrendElement) GetElementsByServerAndSourceCategory(ctx context.Context, server string, sourceCategory string, granularity string) ([]*model.TrendElement, error) {
 	var elements []*model.TrendElement
-	err := s.db.NewSelect().Model(&elements).Where("server = ?", server).Where("source_category = ?", sourceCategory).Scan(ctx)
+	err := s.db.NewSelect().Model(&elements).
+		Where("server = ?", server).
+		Where("source_category = ?", sourceCategory).
+		Where("granularity = ?", granularity).
+		Scan(ctx)
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	} else if err != nil {
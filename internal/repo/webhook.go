@@ -0,0 +1,209 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type Webhook struct {
+	DB *bun.DB
+}
+
+func NewWebhook(db *bun.DB) *Webhook {
+	return &Webhook{DB: db}
+}
+
+func (r *Webhook) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	webhooks := make([]*model.Webhook, 0)
+	err := r.DB.NewSelect().
+		Model(&webhooks).
+		Order("webhook_id").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *Webhook) GetWebhookById(ctx context.Context, id int) (*model.Webhook, error) {
+	var webhook model.Webhook
+	err := r.DB.NewSelect().
+		Model(&webhook).
+		Where("webhook_id = ?", id).
+		Scan(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// GetEnabledWebhooksByEvent returns enabled webhooks subscribing to event.
+func (r *Webhook) GetEnabledWebhooksByEvent(ctx context.Context, event string) ([]*model.Webhook, error) {
+	webhooks := make([]*model.Webhook, 0)
+	err := r.DB.NewSelect().
+		Model(&webhooks).
+		Where("enabled").
+		Where("? = ANY(events)", event).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *Webhook) CreateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	_, err := r.DB.NewInsert().
+		Model(webhook).
+		Returning("webhook_id, created_at, updated_at").
+		Exec(ctx)
+
+	return err
+}
+
+// UpdateWebhook updates the URL, events, description and whether it is enabled of webhook.
+func (r *Webhook) UpdateWebhook(ctx context.Context, webhook *model.Webhook) error {
+	res, err := r.DB.NewUpdate().
+		Model(webhook).
+		Column("url", "events", "description", "enabled").
+		Set("updated_at = now()").
+		WherePK().
+		Returning("*").
+		Exec(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return pgerr.ErrNotFound
+	} else if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return pgerr.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteWebhook deletes the webhook of id along with its deliveries.
+func (r *Webhook) DeleteWebhook(ctx context.Context, id int) error {
+	return r.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewDelete().
+			Model((*model.WebhookDelivery)(nil)).
+			Where("webhook_id = ?", id).
+			Exec(ctx); err != nil {
+			return err
+		}
+
+		res, err := tx.NewDelete().
+			Model((*model.Webhook)(nil)).
+			Where("webhook_id = ?", id).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return pgerr.ErrNotFound
+		}
+		return nil
+	})
+}
+
+func (r *Webhook) CreateDeliveries(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	_, err := r.DB.NewInsert().
+		Model(&deliveries).
+		Returning("delivery_id").
+		Exec(ctx)
+
+	return err
+}
+
+// ClaimDueDeliveries claims up to limit pending deliveries due by now, of enabled webhooks, by postponing their next
+// attempts by lease, so that the same delivery is not attempted by more than one instance at a time. Deliveries
+// claimed are attempted again once lease has passed, in case the instance claiming them has gone away.
+func (r *Webhook) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]*model.WebhookDelivery, error) {
+	now := time.Now()
+	due := r.DB.NewSelect().
+		Model((*model.WebhookDelivery)(nil)).
+		Column("delivery_id").
+		Where("status = ?", constant.WebhookDeliveryStatusPending).
+		Where("next_attempt_at <= ?", now).
+		Where("EXISTS (SELECT 1 FROM webhooks AS wh WHERE wh.webhook_id = whd.webhook_id AND wh.enabled)").
+		Order("next_attempt_at").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+	_, err := r.DB.NewUpdate().
+		Model(&deliveries).
+		Set("next_attempt_at = ?", now.Add(lease)).
+		Where("delivery_id IN (?)", due).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// FinishDeliveryAttempt records the result of an attempt of delivery.
+func (r *Webhook) FinishDeliveryAttempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	_, err := r.DB.NewUpdate().
+		Model(delivery).
+		Column("status", "attempts", "response_status", "last_error", "next_attempt_at", "delivered_at").
+		WherePK().
+		Exec(ctx)
+
+	return err
+}
+
+// GetDeliveriesByWebhookId returns the latest limit deliveries to the webhook of webhookId, latest first.
+func (r *Webhook) GetDeliveriesByWebhookId(ctx context.Context, webhookId int, limit int) ([]*model.WebhookDelivery, error) {
+	deliveries := make([]*model.WebhookDelivery, 0)
+	err := r.DB.NewSelect().
+		Model(&deliveries).
+		Where("webhook_id = ?", webhookId).
+		Order("delivery_id DESC").
+		Limit(limit).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ResetDelivery makes the delivery of id pending again, to be attempted right away with its attempts reset.
+func (r *Webhook) ResetDelivery(ctx context.Context, id int) (*model.WebhookDelivery, error) {
+	deliveries := make([]*model.WebhookDelivery, 0, 1)
+	_, err := r.DB.NewUpdate().
+		Model(&deliveries).
+		Set("status = ?", constant.WebhookDeliveryStatusPending).
+		Set("attempts = 0").
+		Set("next_attempt_at = ?", time.Now()).
+		Where("delivery_id = ?", id).
+		Returning("*").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, pgerr.ErrNotFound
+	}
+
+	return deliveries[0], nil
+}
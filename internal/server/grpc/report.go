@@ -0,0 +1,224 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"go.uber.org/fx"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/protos"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/service"
+	"github.com/penguin-statistics/backend-next/internal/util"
+	"github.com/penguin-statistics/backend-next/internal/util/i18n"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+)
+
+type Report struct {
+	fx.In
+
+	Config        *config.Config
+	ReportService *service.Report
+}
+
+type reportServer struct {
+	protos.UnimplementedReportServiceServer
+	Report
+}
+
+func RegisterReport(server *grpclib.Server, c Report) {
+	protos.RegisterReportServiceServer(server, &reportServer{Report: c})
+}
+
+func (c *reportServer) SubmitSingularReport(ctx context.Context, in *protos.SingularReportRequest) (*protos.ReportResponse, error) {
+	req := singularReportRequestFromProto(in)
+	if err := rekuest.ValidStructTranslated(translator(ctx), req); err != nil {
+		return nil, c.ReportService.WithCorrectionSuggestion(ctx, req, err)
+	}
+
+	taskId, err := c.ReportService.QueueSingularReport(ctx, c.unarySubmitter(ctx), req)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.ReportResponse{TaskId: taskId}, nil
+}
+
+func (c *reportServer) SubmitBatchReport(ctx context.Context, in *protos.BatchReportRequest) (*protos.ReportResponse, error) {
+	taskId, err := c.submitBatchReport(ctx, c.unarySubmitter(ctx), in)
+	if err != nil {
+		return nil, err
+	}
+	return &protos.ReportResponse{TaskId: taskId, Sequence: in.Sequence}, nil
+}
+
+func (c *reportServer) SubmitBatchReportStream(stream protos.ReportService_SubmitBatchReportStreamServer) error {
+	ctx := stream.Context()
+	submitter := c.reportSubmitter(ctx, func(penguinId string) {
+		md := metadata.Pairs(constant.PenguinIDSetHeader, penguinId)
+		// headers could have been sent along with a previous response, hence the trailer as well
+		_ = stream.SetHeader(md)
+		stream.SetTrailer(md)
+	})
+
+	for {
+		in, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		resp := &protos.ReportResponse{Sequence: in.Sequence}
+		taskId, err := c.submitBatchReport(ctx, submitter, in)
+		if err != nil {
+			var perr *pgerr.PenguinError
+			if !errors.As(err, &perr) {
+				return statusOf("SubmitBatchReportStream", err)
+			}
+			resp.Error = &protos.ReportError{Code: perr.ErrorCode, Message: perr.Message}
+		} else {
+			resp.TaskId = taskId
+		}
+
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *reportServer) submitBatchReport(ctx context.Context, submitter *service.ReportSubmitter, in *protos.BatchReportRequest) (string, error) {
+	req := batchReportRequestFromProto(in)
+	if err := rekuest.ValidStructTranslated(translator(ctx), req); err != nil {
+		return "", err
+	}
+
+	return c.ReportService.QueueBatchReport(ctx, submitter, req)
+}
+
+// unarySubmitter returns the submitter of a unary call, whose newly created PenguinID is sent in header metadata.
+func (c *reportServer) unarySubmitter(ctx context.Context) *service.ReportSubmitter {
+	return c.reportSubmitter(ctx, func(penguinId string) {
+		_ = grpclib.SetHeader(ctx, metadata.Pairs(constant.PenguinIDSetHeader, penguinId))
+	})
+}
+
+func (c *reportServer) reportSubmitter(ctx context.Context, onAccountCreated func(penguinId string)) *service.ReportSubmitter {
+	submitter := &service.ReportSubmitter{
+		PenguinID: strings.TrimSpace(strings.TrimPrefix(firstMetadata(ctx, "authorization"), constant.PenguinIDAuthorizationRealm)),
+		IP:        peerIP(ctx, firstMetadata(ctx, "x-forwarded-for"), c.Config.TrustedProxies),
+		UserAgent: firstMetadata(ctx, "user-agent"),
+		RequestID: xid.New().String(),
+	}
+	submitter.OnAccountCreated = func(penguinId string) {
+		// later submissions over the same stream are made with the account created
+		submitter.PenguinID = penguinId
+		onAccountCreated(penguinId)
+	}
+	return submitter
+}
+
+// firstMetadata returns the first value of key in the incoming metadata of ctx, or an empty string if there is none.
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// translator returns the translator for the languages accepted by the client, as the HTTP server does.
+func translator(ctx context.Context) ut.Translator {
+	return i18n.TranslatorFor(firstMetadata(ctx, "accept-language"))
+}
+
+// peerIP returns the IP of the peer. Like the HTTP server, X-Forwarded-For is only honored when the peer is one of
+// trustedProxies.
+func peerIP(ctx context.Context, forwardedFor string, trustedProxies []string) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+
+	if forwardedFor != "" && util.IsTrustedProxy(host, trustedProxies) {
+		return strings.TrimSpace(strings.SplitN(forwardedFor, ",", 2)[0])
+	}
+	return host
+}
+
+func dropsFromProto(in []*protos.ReportDrop) []types.ArkDrop {
+	drops := make([]types.ArkDrop, 0, len(in))
+	for _, drop := range in {
+		drops = append(drops, types.ArkDrop{
+			DropType: drop.DropType,
+			ItemID:   drop.ItemId,
+			Quantity: int(drop.Quantity),
+		})
+	}
+	return drops
+}
+
+func metadataFromProto(in *protos.ReportMetadata) *types.ReportRequestMetadata {
+	if in == nil {
+		return nil
+	}
+	return &types.ReportRequestMetadata{
+		Fingerprint:             in.Fingerprint,
+		MD5:                     in.Md5,
+		FileName:                in.FileName,
+		LastModified:            int(in.LastModified),
+		RecognizerVersion:       in.RecognizerVersion,
+		RecognizerAssetsVersion: in.RecognizerAssetsVersion,
+		ItemCount:               int(in.ItemCount),
+	}
+}
+
+func singularReportRequestFromProto(in *protos.SingularReportRequest) *types.SingleReportRequest {
+	return &types.SingleReportRequest{
+		FragmentStageID: types.FragmentStageID{StageID: in.StageId},
+		FragmentReportCommon: types.FragmentReportCommon{
+			Server:  in.Server,
+			Source:  in.Source,
+			Version: in.Version,
+		},
+		Drops:    dropsFromProto(in.Drops),
+		Times:    int(in.Times),
+		Metadata: metadataFromProto(in.Metadata),
+	}
+}
+
+func batchReportRequestFromProto(in *protos.BatchReportRequest) *types.BatchReportRequest {
+	req := &types.BatchReportRequest{
+		FragmentReportCommon: types.FragmentReportCommon{
+			Server:  in.Server,
+			Source:  in.Source,
+			Version: in.Version,
+		},
+		BatchDrops: make([]types.BatchReportDrop, 0, len(in.BatchDrops)),
+	}
+	for _, drop := range in.BatchDrops {
+		batchDrop := types.BatchReportDrop{
+			FragmentStageID: types.FragmentStageID{StageID: drop.StageId},
+			Drops:           dropsFromProto(drop.Drops),
+		}
+		if metadata := metadataFromProto(drop.Metadata); metadata != nil {
+			batchDrop.Metadata = *metadata
+		}
+		req.BatchDrops = append(req.BatchDrops, batchDrop)
+	}
+	return req
+}
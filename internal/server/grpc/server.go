@@ -0,0 +1,106 @@
+package grpc
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// retryAfterHeader is the header metadata carrying how many seconds the client shall wait before retrying,
+// mirroring the Retry-After header of the HTTP API.
+const retryAfterHeader = "retry-after"
+
+func Create() *grpclib.Server {
+	return grpclib.NewServer(
+		grpclib.ChainUnaryInterceptor(recoverUnary, errorUnary),
+		grpclib.ChainStreamInterceptor(recoverStream),
+	)
+}
+
+// recoverUnary recovers from panics in unary handlers, which would otherwise crash the whole process.
+func recoverUnary(ctx context.Context, req any, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(info.FullMethod, r)
+			err = status.Error(codes.Internal, pgerr.ErrInternalErrorImmutable.Message)
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func recoverStream(srv any, ss grpclib.ServerStream, info *grpclib.StreamServerInfo, handler grpclib.StreamHandler) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logPanic(info.FullMethod, r)
+			err = status.Error(codes.Internal, pgerr.ErrInternalErrorImmutable.Message)
+		}
+	}()
+	return handler(srv, ss)
+}
+
+func logPanic(method string, r any) {
+	buf := make([]byte, 4096)
+	buf = buf[:runtime.Stack(buf, false)]
+	log.Error().Str("method", method).Msgf("panic: %v\n%s\n", r, buf)
+}
+
+// errorUnary converts errors returned by unary handlers into gRPC statuses.
+func errorUnary(ctx context.Context, req any, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (any, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		if retryAfter, ok := retryAfterOf(err); ok {
+			if err := grpclib.SetHeader(ctx, metadata.Pairs(retryAfterHeader, retryAfter)); err != nil {
+				log.Warn().Err(err).Msg("failed to set retry-after header")
+			}
+		}
+		return nil, statusOf(info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+// statusOf converts err into a gRPC status. pgerr.PenguinError is mapped by its HTTP status code, while any
+// other error is considered an internal error.
+func statusOf(method string, err error) error {
+	var perr *pgerr.PenguinError
+	if !errors.As(err, &perr) {
+		log.Error().Err(err).Str("method", method).Msg("Internal Server Error")
+		return status.Error(codes.Internal, pgerr.ErrInternalErrorImmutable.Message)
+	}
+
+	log.Warn().Err(perr).Str("method", method).Msg(perr.Message)
+	return status.Error(codeOf(perr), perr.Message)
+}
+
+func codeOf(perr *pgerr.PenguinError) codes.Code {
+	switch perr.StatusCode {
+	case fiber.StatusBadRequest:
+		return codes.InvalidArgument
+	case fiber.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case fiber.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	default:
+		return codes.Internal
+	}
+}
+
+func retryAfterOf(err error) (string, bool) {
+	var perr *pgerr.PenguinError
+	if !errors.As(err, &perr) || perr.Extras == nil {
+		return "", false
+	}
+	if retryAfter, ok := (*perr.Extras)["retryAfter"].(int); ok {
+		return strconv.Itoa(retryAfter), true
+	}
+	return "", false
+}
@@ -34,6 +34,14 @@ func HandleCustomError(ctx *fiber.Ctx, e *pgerr.PenguinError) error {
 		for k, v := range *e.Extras {
 			body[k] = v
 		}
+
+		if retryAfter, ok := (*e.Extras)["retryAfter"].(int); ok {
+			ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+		}
+	}
+
+	if e.Reason != nil {
+		body["reason"] = e.Reason
 	}
 
 	return ctx.Status(e.StatusCode).JSON(body)
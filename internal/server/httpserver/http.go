@@ -22,6 +22,7 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
@@ -31,8 +32,12 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/pkg/middlewares"
 	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/util/tabular"
 )
 
+// metricsPath is the path Prometheus metrics are exposed at
+const metricsPath = "/metrics"
+
 var registerPromOnce sync.Once
 
 func Create(conf *config.Config) *fiber.App {
@@ -48,12 +53,9 @@ func Create(conf *config.Config) *fiber.App {
 		IdleTimeout:             conf.HTTPServerShutdownTimeout,
 		ProxyHeader:             fiber.HeaderXForwardedFor,
 		EnableTrustedProxyCheck: true,
-		TrustedProxies: []string{
-			"::1",
-			"127.0.0.1",
-		},
-		ErrorHandler: ErrorHandler,
-		Immutable:    true,
+		TrustedProxies:          conf.TrustedProxies,
+		ErrorHandler:            ErrorHandler,
+		Immutable:               true,
 	})
 
 	app.Use(favicon.New())
@@ -63,9 +65,9 @@ func Create(conf *config.Config) *fiber.App {
 	}))
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "*",
-		AllowMethods:     "GET, POST, DELETE, OPTIONS",
-		AllowHeaders:     "Content-Type, Authorization, X-Requested-With, X-Penguin-Variant, sentry-trace",
-		ExposeHeaders:    "Content-Type, X-Penguin-Set-PenguinID, X-Penguin-Upgrade, X-Penguin-Compatible, X-Penguin-Request-ID",
+		AllowMethods:     "GET, POST, PATCH, DELETE, OPTIONS",
+		AllowHeaders:     "Content-Type, Authorization, X-Requested-With, X-Penguin-Variant, If-None-Match, X-Penguin-Schema, sentry-trace",
+		ExposeHeaders:    "Content-Type, X-Penguin-Set-PenguinID, X-Penguin-Upgrade, X-Penguin-Compatible, X-Penguin-Request-ID, RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, Retry-After, ETag, X-Penguin-Schema, Deprecation, Sunset, Link",
 		AllowCredentials: true,
 	}))
 	// requestid is used by report service to identify requests and generate taskId there afterwards
@@ -88,6 +90,7 @@ func Create(conf *config.Config) *fiber.App {
 		}
 		return err
 	})
+	app.Use(middlewares.Metrics(metricsPath))
 
 	app.Use(helmet.New(helmet.Config{
 		HSTSMaxAge:         31356000,
@@ -106,7 +109,7 @@ func Create(conf *config.Config) *fiber.App {
 	}))
 	registerPromOnce.Do(func() {
 		fiberprom := fiberprometheus.New(observability.ServiceName)
-		fiberprom.RegisterAt(app, "/metrics")
+		fiberprom.RegisterAt(app, metricsPath)
 	})
 
 	if conf.TracingEnabled {
@@ -123,9 +126,11 @@ func Create(conf *config.Config) *fiber.App {
 			)),
 		)
 		otel.SetTracerProvider(tracerProvider)
+		// trace context is propagated to report tasks published to NATS, see observability.InjectTraceContext
+		otel.SetTextMapPropagator(propagation.TraceContext{})
 
 		app.Use(fiberotel.New(fiberotel.Config{
-			Tracer:   tracerProvider.Tracer("backendv3"),
+			Tracer:   tracerProvider.Tracer(observability.TracerName),
 			SpanName: "HTTP {{ .Method }} {{ .Path }}",
 		}))
 	}
@@ -175,7 +180,12 @@ func Create(conf *config.Config) *fiber.App {
 			CacheControl: true,
 			Expiration:   time.Minute * 5,
 			KeyGenerator: func(c *fiber.Ctx) string {
-				return utils.CopyString(c.OriginalURL())
+				// results are responded in the format negotiated, which could be told by the Accept header alone
+				format, err := tabular.Negotiate(c)
+				if err != nil {
+					format = "invalid"
+				}
+				return utils.CopyString(c.OriginalURL()) + "|" + format
 			},
 		}))
 	}
@@ -9,7 +9,9 @@ import (
 
 	"github.com/penguin-statistics/backend-next/internal/config"
 	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/middlewares"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/service"
 )
 
 type V2 struct {
@@ -28,7 +30,7 @@ type Meta struct {
 	fiber.Router
 }
 
-func CreateEndpointGroups(app *fiber.App, conf *config.Config) (*V2, *V3, *Admin, *Meta) {
+func CreateEndpointGroups(app *fiber.App, conf *config.Config, apiKeyService *service.APIKey, rateLimitService *service.RateLimit, auditService *service.Audit) (*V2, *V3, *Admin, *Meta) {
 	v2 := app.Group("/PenguinStats/api/v2", func(c *fiber.Ctx) error {
 		// add compatibility versioning header for v2 shims
 		c.Set(constant.ShimCompatibilityHeaderKey, constant.ShimCompatibilityHeaderValue)
@@ -45,21 +47,56 @@ func CreateEndpointGroups(app *fiber.App, conf *config.Config) (*V2, *V3, *Admin
 		return c.Next()
 	})
 
+	adminKeys := adminKeysByActor(conf)
 	admin := app.Group("/api/admin", func(c *fiber.Ctx) error {
-		if len(conf.AdminKey) < 64 {
+		if len(adminKeys) == 0 {
 			log.Error().Msg("admin key is not set or is too short (at least should be 64 chars long), and a request has reached")
 			return c.SendStatus(fiber.StatusInternalServerError)
 		}
 		key := strings.TrimSpace(strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer"))
 
-		// use constant time comparison to prevent timing attacks
-		if subtle.ConstantTimeCompare([]byte(key), []byte(conf.AdminKey)) != 1 {
+		// use constant time comparison to prevent timing attacks, and compare against all keys so that the time
+		// taken does not tell which key has matched
+		actor := ""
+		for candidate, candidateKey := range adminKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(candidateKey)) == 1 {
+				actor = candidate
+			}
+		}
+		if actor == "" {
 			return c.SendStatus(fiber.StatusUnauthorized)
 		}
+		c.Locals(constant.ContextKeyAdminActor, actor)
 		return c.Next()
 	})
 
 	meta := app.Group("/api/_")
 
+	// automated reporters could authenticate with API keys instead of PenguinIDs
+	v2.Use(middlewares.AuthenticateAPIKey(apiKeyService.Authenticate))
+	v3.Use(middlewares.AuthenticateAPIKey(apiKeyService.Authenticate))
+	// rate limits are applied after authentication, so that requests are counted towards their API key or account
+	v2.Use(middlewares.RateLimit(rateLimitService.Take))
+	v3.Use(middlewares.RateLimit(rateLimitService.Take))
+	// admin mutations are recorded after authentication, so that only those authorized are recorded
+	admin.Use(middlewares.Audit(auditService.Record))
+
 	return &V2{Router: v2}, &V3{Router: v3}, &Admin{Router: admin}, &Meta{Router: meta}
 }
+
+// adminKeysByActor returns keys which could authenticate the admin API, keyed by the actor they identify. Keys
+// shorter than 64 characters are left out.
+func adminKeysByActor(conf *config.Config) map[string]string {
+	keys := make(map[string]string, len(conf.AdminActorKeys)+1)
+	for actor, key := range conf.AdminActorKeys {
+		if len(key) < 64 {
+			log.Warn().Str("actor", actor).Msg("admin key of actor is too short (at least should be 64 chars long), ignoring it")
+			continue
+		}
+		keys[actor] = key
+	}
+	if len(conf.AdminKey) >= 64 {
+		keys[constant.AuditDefaultActor] = conf.AdminKey
+	}
+	return keys
+}
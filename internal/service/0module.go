@@ -11,22 +11,59 @@ func Module() fx.Option {
 		NewStage,
 		NewGeoIP,
 		NewTrend,
+		NewAdvancedQuery,
 		NewAdmin,
+		NewGameDataSync,
+		NewAnomaly,
 		NewHealth,
+		NewScheduler,
+		NewRuntimeConfig,
+		NewFeatureFlag,
+		NewAudit,
+		NewWebhook,
+		NewRecognition,
+		NewRecognitionValidation,
+		NewNotifier,
+		NewRateLimit,
 		NewNotice,
 		NewReport,
+		NewReportPurge,
+		AsReportStage(NewReportScoring),
+		NewReportBrowse,
 		NewAccount,
+		NewAccountOAuth,
+		NewAPIKey,
+		NewAccountTrust,
 		NewFormula,
 		NewActivity,
+		NewCalendar,
+		NewCacheVersion,
 		NewDropInfo,
+		NewDropType,
 		NewShortURL,
+		NewShadowBan,
+		NewReportGate,
+		NewStageRewrite,
 		NewTimeRange,
 		NewSiteStats,
+		NewDatasetSnapshot,
+		NewDropMatrixHistory,
+		NewEfficiency,
+		NewPlanner,
+		NewPublicAggregate,
+		NewPrecompressed,
+		NewAggregateView,
 		NewDropMatrix,
+		NewMatrixRefresh,
+		NewPersonalHistory,
 		NewDropReport,
+		NewDropReportPartition,
+		NewMigration,
 		NewTrendElement,
 		NewPatternMatrix,
+		NewPatternDedup,
 		NewDropMatrixElement,
+		NewDropPattern,
 		NewDropPatternElement,
 		NewPatternMatrixElement,
 	))
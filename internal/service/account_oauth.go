@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/oauth"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var (
+	ErrOAuthProviderNotFound = pgerr.ErrInvalidReq.Msg("invalid request: identity provider not supported")
+	ErrOAuthStateInvalid     = pgerr.ErrInvalidReq.Msg("invalid request: state is invalid or expired")
+	ErrOAuthIdentityTaken    = pgerr.ErrInvalidReq.Msg("invalid request: identity has been attached to another PenguinID")
+)
+
+// AccountOAuth attaches identities of external identity providers to accounts, and logs in with them, so that
+// contributors could use the same PenguinID across devices without copying it around.
+type AccountOAuth struct {
+	AccountService      *Account
+	AccountRepo         *repo.Account
+	AccountIdentityRepo *repo.AccountIdentity
+	Redis               *redis.Client
+
+	// RedirectURL is the URL identity providers redirect to after authorization.
+	RedirectURL string
+	// Providers are enabled identity providers, by name.
+	Providers map[string]*oauth.Provider
+}
+
+func NewAccountOAuth(accountService *Account, accountRepo *repo.Account, accountIdentityRepo *repo.AccountIdentity, redisClient *redis.Client, conf *config.Config) *AccountOAuth {
+	service := &AccountOAuth{
+		AccountService:      accountService,
+		AccountRepo:         accountRepo,
+		AccountIdentityRepo: accountIdentityRepo,
+		Redis:               redisClient,
+		RedirectURL:         conf.OAuthRedirectURL,
+		Providers:           make(map[string]*oauth.Provider),
+	}
+	if conf.OAuthRedirectURL == "" {
+		return service
+	}
+	if conf.OAuthGitHubClientID != "" {
+		service.Providers[oauth.ProviderGitHub] = oauth.GitHub(conf.OAuthGitHubClientID, conf.OAuthGitHubClientSecret)
+	}
+	if conf.OAuthGoogleClientID != "" {
+		service.Providers[oauth.ProviderGoogle] = oauth.Google(conf.OAuthGoogleClientID, conf.OAuthGoogleClientSecret)
+	}
+	return service
+}
+
+// oauthState is the pending authorization saved under its state until the callback.
+type oauthState struct {
+	Provider string `json:"provider"`
+	// AccountID is the account to attach the identity to. Zero when logging in.
+	AccountID int `json:"accountId"`
+}
+
+// GetAuthorizeURL returns the URL of the provider to redirect the user to, along with the state of the authorization,
+// which shall be bound to the browser of the user. The identity authorized is attached to account if not nil, or is
+// logged in with otherwise.
+func (s *AccountOAuth) GetAuthorizeURL(ctx context.Context, providerName string, account *model.Account) (url string, stateKey string, err error) {
+	provider, ok := s.Providers[providerName]
+	if !ok {
+		return "", "", ErrOAuthProviderNotFound
+	}
+
+	state := oauthState{Provider: providerName}
+	if account != nil {
+		state.AccountID = account.AccountID
+	}
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return "", "", err
+	}
+
+	stateKey = uniuri.NewLen(32)
+	if err := s.Redis.Set(ctx, constant.OAuthStateKeyPrefix+stateKey, encoded, time.Second*constant.OAuthStateTTLSec).Err(); err != nil {
+		return "", "", err
+	}
+	return provider.AuthCodeURL(stateKey, s.RedirectURL), stateKey, nil
+}
+
+// HandleCallback completes the authorization of stateKey with code, and returns the account the identity is
+// attached to. An account is created for identities logged in with for the first time. boundStateKey is the state
+// bound to the browser completing the authorization, which must be stateKey, so that a victim could not be made to
+// complete an authorization started by an attacker.
+func (s *AccountOAuth) HandleCallback(ctx context.Context, code string, stateKey string, boundStateKey string) (*model.Account, error) {
+	if boundStateKey == "" || subtle.ConstantTimeCompare([]byte(stateKey), []byte(boundStateKey)) != 1 {
+		return nil, ErrOAuthStateInvalid
+	}
+
+	encoded, err := s.Redis.GetDel(ctx, constant.OAuthStateKeyPrefix+stateKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrOAuthStateInvalid
+	} else if err != nil {
+		return nil, err
+	}
+	var state oauthState
+	if err := json.Unmarshal(encoded, &state); err != nil {
+		return nil, ErrOAuthStateInvalid
+	}
+	provider, ok := s.Providers[state.Provider]
+	if !ok {
+		return nil, ErrOAuthProviderNotFound
+	}
+
+	accessToken, err := provider.Exchange(ctx, code, s.RedirectURL)
+	if err != nil {
+		return nil, pgerr.ErrInvalidReq.Msg("invalid request: failed to authorize with %s", provider.Name)
+	}
+	identity, err := provider.FetchIdentity(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	attached, err := s.AccountIdentityRepo.GetIdentity(ctx, provider.Name, identity.Subject)
+	if err == nil {
+		if state.AccountID != 0 && state.AccountID != attached.AccountID {
+			return nil, ErrOAuthIdentityTaken
+		}
+		return s.AccountRepo.GetAccountById(ctx, strconv.Itoa(attached.AccountID))
+	} else if !errors.Is(err, pgerr.ErrNotFound) {
+		return nil, err
+	}
+
+	var account *model.Account
+	if state.AccountID != 0 {
+		account, err = s.AccountRepo.GetAccountById(ctx, strconv.Itoa(state.AccountID))
+	} else {
+		account, err = s.AccountService.CreateAccountWithRandomPenguinId(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.AccountIdentityRepo.CreateIdentity(ctx, &model.AccountIdentity{
+		AccountID: account.AccountID,
+		Provider:  provider.Name,
+		Subject:   identity.Subject,
+		Name:      identity.Name,
+	}); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+func (s *AccountOAuth) GetIdentities(ctx context.Context, accountId int) ([]*model.AccountIdentity, error) {
+	return s.AccountIdentityRepo.GetIdentitiesByAccountId(ctx, accountId)
+}
+
+func (s *AccountOAuth) DetachIdentity(ctx context.Context, accountId int, providerName string) error {
+	return s.AccountIdentityRepo.DeleteIdentity(ctx, accountId, providerName)
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+const (
+	// accountTrustPrior is the trust score of accounts without any report.
+	accountTrustPrior = 0.5
+	// accountTrustPriorWeight is how many reports the prior weighs as, so that scores of accounts with few reports
+	// stay close to the prior.
+	accountTrustPriorWeight = 10
+	// accountTrustRefreshBatchSize is the number of accounts popped from the dirty set at a time.
+	accountTrustRefreshBatchSize = 500
+)
+
+// accountTrustAnomalyReliabilities are the reliabilities whose violation indicates an abusive submitter, rather
+// than a defective client.
+var accountTrustAnomalyReliabilities = []int{
+	constant.ViolationReliabilityQuantitySanity,
+	constant.ViolationReliabilityVelocity,
+}
+
+type AccountTrust struct {
+	Redis                 *redis.Client
+	AccountTrustScoreRepo *repo.AccountTrustScore
+}
+
+func NewAccountTrust(redisClient *redis.Client, accountTrustScoreRepo *repo.AccountTrustScore) *AccountTrust {
+	return &AccountTrust{
+		Redis:                 redisClient,
+		AccountTrustScoreRepo: accountTrustScoreRepo,
+	}
+}
+
+func (s *AccountTrust) GetAccountTrustScore(ctx context.Context, accountId int) (*model.AccountTrustScore, error) {
+	return s.AccountTrustScoreRepo.GetAccountTrustScoreByAccountId(ctx, accountId)
+}
+
+func (s *AccountTrust) GetLowestAccountTrustScores(ctx context.Context, limit int) ([]*model.AccountTrustScore, error) {
+	return s.AccountTrustScoreRepo.GetLowestAccountTrustScores(ctx, limit)
+}
+
+// RecalculateAccountTrustScore recalculates the trust score of an account from all of its reports.
+func (s *AccountTrust) RecalculateAccountTrustScore(ctx context.Context, accountId int) (*model.AccountTrustScore, error) {
+	stats, err := s.AccountTrustScoreRepo.CalcAccountReportStats(ctx, accountId, accountTrustAnomalyReliabilities)
+	if err != nil {
+		return nil, err
+	}
+
+	// recalled and anomalous reports weigh as additional rejections on top of being counted as reports
+	accepted := stats.Reports - stats.Rejected
+	weighted := stats.Reports + stats.Recalled + stats.Anomalies
+	score := (float64(accepted) + accountTrustPrior*accountTrustPriorWeight) / float64(weighted+accountTrustPriorWeight)
+
+	now := time.Now()
+	trustScore := &model.AccountTrustScore{
+		AccountID: accountId,
+		Score:     score,
+		Reports:   stats.Reports,
+		Rejected:  stats.Rejected,
+		Recalled:  stats.Recalled,
+		Anomalies: stats.Anomalies,
+		UpdatedAt: &now,
+	}
+	if err := s.AccountTrustScoreRepo.UpsertAccountTrustScore(ctx, trustScore); err != nil {
+		return nil, err
+	}
+	return trustScore, nil
+}
+
+// RefreshDirtyAccountTrustScores recalculates trust scores of all accounts marked dirty since the last refresh,
+// and returns the number of accounts refreshed.
+func (s *AccountTrust) RefreshDirtyAccountTrustScores(ctx context.Context) (int, error) {
+	refreshed := 0
+	for {
+		accountIds, err := s.Redis.SPopN(ctx, constant.AccountTrustDirtyKey, accountTrustRefreshBatchSize).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return refreshed, err
+		}
+		if len(accountIds) == 0 {
+			return refreshed, nil
+		}
+
+		for _, accountId := range accountIds {
+			id, err := strconv.Atoi(accountId)
+			if err != nil {
+				continue
+			}
+			if _, err := s.RecalculateAccountTrustScore(ctx, id); err != nil {
+				// put the account back so that it will be retried in the next refresh
+				markAccountTrustDirty(ctx, s.Redis, id)
+				return refreshed, errors.Wrapf(err, "failed to recalculate trust score of account %d", id)
+			}
+			refreshed++
+		}
+	}
+}
+
+// markAccountTrustDirty marks the trust score of an account to be recalculated in the next refresh.
+func markAccountTrustDirty(ctx context.Context, redisClient *redis.Client, accountId int) {
+	if accountId == 0 {
+		return
+	}
+	if err := redisClient.SAdd(ctx, constant.AccountTrustDirtyKey, accountId).Err(); err != nil {
+		log.Warn().Err(err).Int("accountId", accountId).Msg("failed to mark account trust score dirty")
+	}
+}
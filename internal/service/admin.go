@@ -8,20 +8,27 @@ import (
 
 	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model"
-	"github.com/penguin-statistics/backend-next/internal/model/cache"
 	"github.com/penguin-statistics/backend-next/internal/model/gamedata"
 	"github.com/penguin-statistics/backend-next/internal/repo"
 )
 
 type Admin struct {
-	DB        *bun.DB
-	AdminRepo *repo.Admin
+	DB                  *bun.DB
+	AdminRepo           *repo.Admin
+	DropReportRepo      *repo.DropReport
+	CacheVersionService *CacheVersion
+	WebhookService      *Webhook
+	DropTypeService     *DropType
 }
 
-func NewAdmin(db *bun.DB, adminRepo *repo.Admin) *Admin {
+func NewAdmin(db *bun.DB, adminRepo *repo.Admin, dropReportRepo *repo.DropReport, cacheVersionService *CacheVersion, webhookService *Webhook, dropTypeService *DropType) *Admin {
 	return &Admin{
-		DB:        db,
-		AdminRepo: adminRepo,
+		DB:                  db,
+		AdminRepo:           adminRepo,
+		DropReportRepo:      dropReportRepo,
+		CacheVersionService: cacheVersionService,
+		WebhookService:      webhookService,
+		DropTypeService:     dropTypeService,
 	}
 }
 
@@ -81,6 +88,10 @@ func (s *Admin) SaveRenderedObjects(ctx context.Context, objects *gamedata.Rende
 				for _, dropInfo := range dropInfos {
 					dropInfo.StageID = stageId
 					dropInfo.RangeID = rangeId
+					if err := s.validateDropInfo(ctx, tx, dropInfo); err != nil {
+						innerErr = err
+						return err
+					}
 					dropInfosToSave = append(dropInfosToSave, dropInfo)
 				}
 			}
@@ -98,33 +109,16 @@ func (s *Admin) SaveRenderedObjects(ctx context.Context, objects *gamedata.Rende
 
 	// if no error, purge cache
 	if innerErr == nil {
-		// zone
-		if objects.Zone != nil {
-			cache.Zones.Delete()
-			cache.ShimZones.Delete()
+		arkStageIds := make([]string, 0, len(objects.Stages)+len(objects.DropInfosMap))
+		for _, stage := range objects.Stages {
+			arkStageIds = append(arkStageIds, stage.ArkStageID)
 		}
-
-		// activity
-		if objects.Activity != nil {
-			cache.Activities.Delete()
-			cache.ShimActivities.Delete()
-		}
-
-		// timerange
-		if objects.TimeRange != nil {
-			cache.TimeRanges.Delete(objects.TimeRange.Server)
-			cache.TimeRangesMap.Delete(objects.TimeRange.Server)
-			cache.MaxAccumulableTimeRanges.Delete(objects.TimeRange.Server)
+		for arkStageId := range objects.DropInfosMap {
+			arkStageIds = append(arkStageIds, arkStageId)
 		}
-
-		// stage
-		if len(objects.Stages) > 0 {
-			cache.Stages.Delete()
-			cache.StagesMapByID.Delete()
-			cache.StagesMapByArkID.Delete()
-			for _, server := range constant.Servers {
-				cache.ShimStages.Delete(server)
-			}
+		s.invalidateGameData(ctx, nil, arkStageIds)
+		if objects.Activity != nil {
+			s.WebhookService.Publish(constant.WebhookEventActivityPublished, objects.Activity)
 		}
 	}
 
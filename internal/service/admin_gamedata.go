@@ -0,0 +1,600 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// SaveItems creates items, or updates them if items of the same ark item ids already exist.
+func (s *Admin) SaveItems(ctx context.Context, items []*model.Item) error {
+	arkItemIds := make([]string, 0, len(items))
+	for _, item := range items {
+		if err := validateItem(item); err != nil {
+			return err
+		}
+		if lo.Contains(arkItemIds, item.ArkItemID) {
+			return pgerr.ErrInvalidReq.Msg("item `%s` is given more than once", item.ArkItemID)
+		}
+		arkItemIds = append(arkItemIds, item.ArkItemID)
+	}
+
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return s.AdminRepo.SaveItems(ctx, tx, &items)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateGameData(ctx, arkItemIds, nil)
+	return nil
+}
+
+// SaveStages creates stages, or updates them if stages of the same ark stage ids already exist.
+func (s *Admin) SaveStages(ctx context.Context, stages []*model.Stage) error {
+	arkStageIds := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		if lo.Contains(arkStageIds, stage.ArkStageID) {
+			return pgerr.ErrInvalidReq.Msg("stage `%s` is given more than once", stage.ArkStageID)
+		}
+		arkStageIds = append(arkStageIds, stage.ArkStageID)
+	}
+
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, stage := range stages {
+			if err := s.validateStage(ctx, tx, stage); err != nil {
+				return err
+			}
+		}
+		return s.AdminRepo.SaveStages(ctx, tx, &stages)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.invalidateGameData(ctx, nil, arkStageIds)
+	return nil
+}
+
+// PatchItem updates fields of the item of arkItemId present in patch, which is a partial item in JSON.
+func (s *Admin) PatchItem(ctx context.Context, arkItemId string, patch []byte) (*model.Item, error) {
+	var item model.Item
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.AdminRepo.GetForUpdate(ctx, tx, &item, "ark_item_id = ?", arkItemId); err != nil {
+			return err
+		}
+		audit.Before(ctx, &item)
+
+		itemId := item.ItemID
+		if err := applyGameDataPatch(patch, &item); err != nil {
+			return err
+		}
+		item.ItemID = itemId
+		if item.ArkItemID != arkItemId {
+			return pgerr.ErrInvalidReq.Msg("itemId could not be changed")
+		}
+		if err := validateItem(&item); err != nil {
+			return err
+		}
+
+		return s.AdminRepo.UpdateItem(ctx, tx, &item)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGameData(ctx, []string{arkItemId}, nil)
+	audit.After(ctx, &item)
+	return &item, nil
+}
+
+// PatchStage updates fields of the stage of arkStageId present in patch, which is a partial stage in JSON.
+func (s *Admin) PatchStage(ctx context.Context, arkStageId string, patch []byte) (*model.Stage, error) {
+	var stage model.Stage
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.AdminRepo.GetForUpdate(ctx, tx, &stage, "ark_stage_id = ?", arkStageId); err != nil {
+			return err
+		}
+		audit.Before(ctx, &stage)
+
+		stageId := stage.StageID
+		if err := applyGameDataPatch(patch, &stage); err != nil {
+			return err
+		}
+		stage.StageID = stageId
+		if stage.ArkStageID != arkStageId {
+			return pgerr.ErrInvalidReq.Msg("stageId could not be changed")
+		}
+		if err := s.validateStage(ctx, tx, &stage); err != nil {
+			return err
+		}
+
+		return s.AdminRepo.UpdateStage(ctx, tx, &stage)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGameData(ctx, nil, []string{arkStageId})
+	audit.After(ctx, &stage)
+	return &stage, nil
+}
+
+// PatchZone updates fields of the zone of arkZoneId present in patch, which is a partial zone in JSON.
+func (s *Admin) PatchZone(ctx context.Context, arkZoneId string, patch []byte) (*model.Zone, error) {
+	var zone model.Zone
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.AdminRepo.GetForUpdate(ctx, tx, &zone, "ark_zone_id = ?", arkZoneId); err != nil {
+			return err
+		}
+		audit.Before(ctx, &zone)
+
+		zoneId := zone.ZoneID
+		if err := applyGameDataPatch(patch, &zone); err != nil {
+			return err
+		}
+		zone.ZoneID = zoneId
+		if zone.ArkZoneID != arkZoneId {
+			return pgerr.ErrInvalidReq.Msg("zoneId could not be changed")
+		}
+		if err := validateZone(&zone); err != nil {
+			return err
+		}
+
+		return s.AdminRepo.UpdateZone(ctx, tx, &zone)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGameData(ctx, nil, nil)
+	audit.After(ctx, &zone)
+	return &zone, nil
+}
+
+// PatchActivity updates fields of the activity of activityId present in patch, which is a partial activity in JSON.
+func (s *Admin) PatchActivity(ctx context.Context, activityId int, patch []byte) (*model.Activity, error) {
+	var activity model.Activity
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.AdminRepo.GetForUpdate(ctx, tx, &activity, "activity_id = ?", activityId); err != nil {
+			return err
+		}
+		audit.Before(ctx, &activity)
+
+		if err := applyGameDataPatch(patch, &activity); err != nil {
+			return err
+		}
+		activity.ActivityID = activityId
+		if err := validateActivity(&activity); err != nil {
+			return err
+		}
+
+		return s.AdminRepo.UpdateActivity(ctx, tx, &activity)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGameData(ctx, nil, nil)
+	audit.After(ctx, &activity)
+	s.WebhookService.Publish(constant.WebhookEventActivityPublished, &activity)
+	return &activity, nil
+}
+
+// PatchDropInfo updates fields of the drop info of dropId present in patch, which is a partial drop info in JSON.
+func (s *Admin) PatchDropInfo(ctx context.Context, dropId int, patch []byte) (*model.DropInfo, error) {
+	var (
+		dropInfo    model.DropInfo
+		arkStageIds []string
+	)
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.AdminRepo.GetForUpdate(ctx, tx, &dropInfo, "drop_id = ?", dropId); err != nil {
+			return err
+		}
+		audit.Before(ctx, &dropInfo)
+		previousStageId := dropInfo.StageID
+
+		if err := applyGameDataPatch(patch, &dropInfo); err != nil {
+			return err
+		}
+		dropInfo.DropID = dropId
+		if err := s.validateDropInfo(ctx, tx, &dropInfo); err != nil {
+			return err
+		}
+		if err := s.AdminRepo.UpdateDropInfo(ctx, tx, &dropInfo); err != nil {
+			return err
+		}
+
+		// drop infos are cached by stage, so both the stage it was of and the stage it is of are invalidated
+		var err error
+		arkStageIds, err = s.AdminRepo.GetArkStageIdsByStageIds(ctx, tx, []int{previousStageId, dropInfo.StageID})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGameData(ctx, nil, arkStageIds)
+	audit.After(ctx, &dropInfo)
+	return &dropInfo, nil
+}
+
+// SaveDropInfoBounds previews bounds of proposed, an item drop info, against reliable reports of the item created
+// within lookback and the time range of proposed, and commits them if commit is set. Bounds of the drop info of the
+// same server, stage, item, drop type and time range are updated, or proposed is created if there is none.
+//
+// Reports are checked against bounds of the item summed across its drop types, as reports are not told apart by
+// drop types once stored, which could only be looser than what the drop verifier judges new reports by.
+func (s *Admin) SaveDropInfoBounds(ctx context.Context, proposed *model.DropInfo, lookback time.Duration, commit bool) (*model.DropInfoBoundsPreview, error) {
+	if !proposed.ItemID.Valid {
+		return nil, pgerr.ErrInvalidReq.Msg("only bounds of item drop infos could be previewed against reports")
+	}
+
+	var (
+		preview model.DropInfoBoundsPreview
+		stage   model.Stage
+	)
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		dropInfos, err := s.AdminRepo.GetItemDropInfosForUpdate(ctx, tx, proposed.Server, proposed.StageID, int(proposed.ItemID.Int64), proposed.RangeID)
+		if err != nil {
+			return err
+		}
+
+		preview.CurrentBounds = itemBounds(dropInfos)
+
+		dropInfo := proposed
+		siblings := make([]*model.DropInfo, 0, len(dropInfos))
+		for _, existing := range dropInfos {
+			if existing.DropType == proposed.DropType {
+				dropInfo = existing
+				dropInfo.Bounds = proposed.Bounds
+				continue
+			}
+			siblings = append(siblings, existing)
+		}
+		if err := s.validateDropInfo(ctx, tx, dropInfo); err != nil {
+			return err
+		}
+		preview.DropInfo = dropInfo
+		preview.ProposedBounds = itemBounds(append(siblings, dropInfo))
+
+		var timeRange model.TimeRange
+		if err := s.AdminRepo.Get(ctx, tx, &stage, "stage_id = ?", dropInfo.StageID); err != nil {
+			return err
+		}
+		if err := s.AdminRepo.Get(ctx, tx, &timeRange, "range_id = ?", dropInfo.RangeID); err != nil {
+			return err
+		}
+
+		preview.Until = time.Now()
+		if timeRange.EndTime != nil && timeRange.EndTime.Before(preview.Until) {
+			preview.Until = *timeRange.EndTime
+		}
+		preview.Since = preview.Until.Add(-lookback)
+		if timeRange.StartTime != nil && timeRange.StartTime.After(preview.Since) {
+			preview.Since = *timeRange.StartTime
+		}
+
+		preview.BoundsImpact = &model.BoundsImpact{}
+		if preview.Since.Before(preview.Until) {
+			preview.BoundsImpact, err = s.DropReportRepo.CalcItemBoundsImpact(ctx, &repo.ItemBoundsImpactQuery{
+				Server:         dropInfo.Server,
+				StageID:        dropInfo.StageID,
+				ItemID:         int(dropInfo.ItemID.Int64),
+				Start:          preview.Since,
+				End:            preview.Until,
+				ScaleWithTimes: stage.ExtraProcessType.ValueOrZero() != constant.ExtraProcessTypeGachaBox,
+				Current:        preview.CurrentBounds,
+				Proposed:       preview.ProposedBounds,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if !commit {
+			return nil
+		}
+		preview.Committed = true
+		if dropInfo.DropID != 0 {
+			return s.AdminRepo.UpdateDropInfo(ctx, tx, dropInfo)
+		}
+		return s.AdminRepo.SaveDropInfos(ctx, tx, &[]*model.DropInfo{dropInfo})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if preview.Committed {
+		s.invalidateGameData(ctx, nil, []string{stage.ArkStageID})
+	}
+	return &preview, nil
+}
+
+// itemBounds returns bounds of an item across its drop infos of different drop types. Exceptions are only kept
+// when the item is of a single drop type, as they could not be told apart otherwise. An item without drop infos
+// is not expected to be dropped at all.
+func itemBounds(dropInfos []*model.DropInfo) *model.Bounds {
+	bounds := &model.Bounds{}
+	for _, dropInfo := range dropInfos {
+		bounds.Lower += dropInfo.Bounds.Lower
+		bounds.Upper += dropInfo.Bounds.Upper
+	}
+	if len(dropInfos) == 1 {
+		bounds.Exceptions = dropInfos[0].Bounds.Exceptions
+	}
+	return bounds
+}
+
+// SplitTimeRange splits time range rangeId of the stage of arkStageId at at: the time range of drop infos of the
+// stage is closed at at, and their copies are of a new time range opened at at. Time ranges shared with other
+// stages are left intact, while the stage is moved to a copy of the time range closed at at instead.
+func (s *Admin) SplitTimeRange(ctx context.Context, server string, arkStageId string, rangeId int, at time.Time) (*model.TimeRangeSplit, error) {
+	var (
+		split model.TimeRangeSplit
+		stage model.Stage
+	)
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if err := s.AdminRepo.Get(ctx, tx, &stage, "ark_stage_id = ?", arkStageId); err != nil {
+			return err
+		}
+
+		var timeRange model.TimeRange
+		if err := s.AdminRepo.GetForUpdate(ctx, tx, &timeRange, "range_id = ?", rangeId); err != nil {
+			return err
+		}
+		if timeRange.Server != server {
+			return pgerr.ErrInvalidReq.Msg("time range %d is not of server `%s`", rangeId, server)
+		}
+		if timeRange.StartTime == nil || timeRange.EndTime == nil || !at.After(*timeRange.StartTime) || !at.Before(*timeRange.EndTime) {
+			return pgerr.ErrInvalidReq.Msg("time range %d could only be split within it", rangeId)
+		}
+
+		dropInfos, err := s.AdminRepo.GetStageDropInfosForUpdate(ctx, tx, server, stage.StageID, rangeId)
+		if err != nil {
+			return err
+		}
+		if len(dropInfos) == 0 {
+			return pgerr.ErrInvalidReq.Msg("stage `%s` has no drop infos within time range %d", arkStageId, rangeId)
+		}
+
+		shared, err := s.AdminRepo.Exists(ctx, tx, (*model.DropInfo)(nil), "range_id = ? AND stage_id <> ?", rangeId, stage.StageID)
+		if err != nil {
+			return err
+		}
+
+		before := timeRange
+		if shared {
+			before.RangeID = 0
+			before.Comment = null.StringFrom(fmt.Sprintf("split from time range %d", rangeId))
+		}
+		before.EndTime = &at
+		after := timeRange
+		after.RangeID = 0
+		after.StartTime = &at
+		after.Comment = null.StringFrom(fmt.Sprintf("split from time range %d", rangeId))
+
+		timeRanges := []*model.TimeRange{&before, &after}
+		if err := s.AdminRepo.SaveTimeRanges(ctx, tx, &timeRanges); err != nil {
+			return err
+		}
+
+		copies := make([]*model.DropInfo, 0, len(dropInfos))
+		for _, dropInfo := range dropInfos {
+			dropInfoCopy := *dropInfo
+			dropInfoCopy.DropID = 0
+			dropInfoCopy.RangeID = after.RangeID
+			copies = append(copies, &dropInfoCopy)
+
+			if shared {
+				dropInfo.RangeID = before.RangeID
+				if err := s.AdminRepo.UpdateDropInfo(ctx, tx, dropInfo); err != nil {
+					return err
+				}
+			}
+		}
+		if err := s.AdminRepo.SaveDropInfos(ctx, tx, &copies); err != nil {
+			return err
+		}
+
+		split.Before = &before
+		split.After = &after
+		if shared {
+			split.VacatedRangeID = rangeId
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGameData(ctx, nil, []string{arkStageId})
+	return &split, nil
+}
+
+// invalidateGameData drops game data cached by all instances after it has been updated. Hot lookups of arkItemIds
+// and arkStageIds cached in Redis are deleted before in-process copies of all instances are flushed, so that
+// instances could not refill their in-process tier with stale values from Redis.
+func (s *Admin) invalidateGameData(ctx context.Context, arkItemIds []string, arkStageIds []string) {
+	if len(arkItemIds) > 0 {
+		if err := cache.ItemByArkID.Delete(ctx, arkItemIds...); err != nil {
+			log.Warn().Err(err).Msg("failed to delete cached items")
+		}
+	}
+
+	if len(arkStageIds) > 0 {
+		dropInfoKeys := make([]string, 0, len(arkStageIds)*len(constant.Servers))
+		for _, server := range constant.Servers {
+			for _, arkStageId := range arkStageIds {
+				dropInfoKeys = append(dropInfoKeys, server+constant.CacheSep+arkStageId)
+			}
+		}
+		if err := cache.StageExtraProcessTypeByArkID.Delete(ctx, arkStageIds...); err != nil {
+			log.Warn().Err(err).Msg("failed to delete cached stage extra process types")
+		}
+		if err := cache.CurrentDropInfosByArkStageID.Delete(ctx, dropInfoKeys...); err != nil {
+			log.Warn().Err(err).Msg("failed to delete cached current drop infos")
+		}
+	}
+
+	if err := s.CacheVersionService.Bump(ctx, constant.CacheVersionGameData, ""); err != nil {
+		// other instances catch up once their copies expire, while this instance is not left behind
+		log.Warn().Err(err).Msg("failed to invalidate cached game data")
+		cache.FlushLocalGameData()
+	}
+}
+
+// applyGameDataPatch overwrites fields of dest present in patch. Fields unknown to dest are rejected, so that typos
+// in field names are not silently ignored.
+func applyGameDataPatch(patch []byte, dest any) error {
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dest); err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid patch: %s", err)
+	}
+	return nil
+}
+
+// isJSONObject reports whether raw is a JSON object, e.g. a map of names by language.
+func isJSONObject(raw json.RawMessage) bool {
+	var m map[string]any
+	return json.Unmarshal(raw, &m) == nil && m != nil
+}
+
+func validateItem(item *model.Item) error {
+	if item.ArkItemID == "" {
+		return pgerr.ErrInvalidReq.Msg("item: itemId is required")
+	}
+	if !isJSONObject(item.Name) {
+		return pgerr.ErrInvalidReq.Msg("item `%s`: name must be an object", item.ArkItemID)
+	}
+	if !isJSONObject(item.Existence) {
+		return pgerr.ErrInvalidReq.Msg("item `%s`: existence must be an object", item.ArkItemID)
+	}
+	if item.Rarity < 0 {
+		return pgerr.ErrInvalidReq.Msg("item `%s`: rarity must not be negative", item.ArkItemID)
+	}
+	return nil
+}
+
+func (s *Admin) validateStage(ctx context.Context, tx bun.Tx, stage *model.Stage) error {
+	if stage.ArkStageID == "" {
+		return pgerr.ErrInvalidReq.Msg("stage: stageId is required")
+	}
+	if stage.StageType == "" {
+		return pgerr.ErrInvalidReq.Msg("stage `%s`: stageType is required", stage.ArkStageID)
+	}
+	if stage.ExtraProcessType.Valid && stage.ExtraProcessType.String != constant.ExtraProcessTypeGachaBox {
+		return pgerr.ErrInvalidReq.Msg("stage `%s`: unknown extraProcessType `%s`", stage.ArkStageID, stage.ExtraProcessType.String)
+	}
+	if !isJSONObject(stage.Code) {
+		return pgerr.ErrInvalidReq.Msg("stage `%s`: code must be an object", stage.ArkStageID)
+	}
+	if !isJSONObject(stage.Existence) {
+		return pgerr.ErrInvalidReq.Msg("stage `%s`: existence must be an object", stage.ArkStageID)
+	}
+	if stage.Sanity.Valid && stage.Sanity.Int64 < 0 {
+		return pgerr.ErrInvalidReq.Msg("stage `%s`: sanity must not be negative", stage.ArkStageID)
+	}
+
+	exists, err := s.AdminRepo.Exists(ctx, tx, (*model.Zone)(nil), "zone_id = ?", stage.ZoneID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return pgerr.ErrInvalidReq.Msg("stage `%s`: zone %d not found", stage.ArkStageID, stage.ZoneID)
+	}
+	return nil
+}
+
+func validateZone(zone *model.Zone) error {
+	if zone.ArkZoneID == "" {
+		return pgerr.ErrInvalidReq.Msg("zone: zoneId is required")
+	}
+	if zone.Category == "" {
+		return pgerr.ErrInvalidReq.Msg("zone `%s`: category is required", zone.ArkZoneID)
+	}
+	if !isJSONObject(zone.Name) {
+		return pgerr.ErrInvalidReq.Msg("zone `%s`: name must be an object", zone.ArkZoneID)
+	}
+	if !isJSONObject(zone.Existence) {
+		return pgerr.ErrInvalidReq.Msg("zone `%s`: existence must be an object", zone.ArkZoneID)
+	}
+	return nil
+}
+
+func validateActivity(activity *model.Activity) error {
+	if activity.StartTime != nil && activity.EndTime != nil && !activity.EndTime.After(*activity.StartTime) {
+		return pgerr.ErrInvalidReq.Msg("activity %d: endTime must be after startTime", activity.ActivityID)
+	}
+	if !isJSONObject(activity.Name) {
+		return pgerr.ErrInvalidReq.Msg("activity %d: name must be an object", activity.ActivityID)
+	}
+	if !isJSONObject(activity.Existence) {
+		return pgerr.ErrInvalidReq.Msg("activity %d: existence must be an object", activity.ActivityID)
+	}
+	return nil
+}
+
+func (s *Admin) validateDropInfo(ctx context.Context, tx bun.Tx, dropInfo *model.DropInfo) error {
+	// drop infos to be created have no id yet
+	label := fmt.Sprintf("drop info %d", dropInfo.DropID)
+	if dropInfo.DropID == 0 {
+		label = fmt.Sprintf("drop info of stage %d and item %v", dropInfo.StageID, dropInfo.ItemID.ValueOrZero())
+	}
+
+	if !lo.Contains(constant.Servers, dropInfo.Server) {
+		return pgerr.ErrInvalidReq.Msg("%s: unknown server `%s`", label, dropInfo.Server)
+	}
+	if _, err := s.DropTypeService.GetDropTypeByName(ctx, dropInfo.DropType); errors.Is(err, ErrDropTypeNotFound) {
+		return pgerr.ErrInvalidReq.Msg("%s: unknown dropType `%s`", label, dropInfo.DropType)
+	} else if err != nil {
+		return err
+	}
+	if dropInfo.Bounds == nil {
+		return pgerr.ErrInvalidReq.Msg("%s: bounds is required", label)
+	}
+	if dropInfo.Bounds.Lower < 0 || dropInfo.Bounds.Lower > dropInfo.Bounds.Upper {
+		return pgerr.ErrInvalidReq.Msg("%s: bounds must satisfy 0 <= lower <= upper", label)
+	}
+
+	references := []struct {
+		name  string
+		model any
+		where string
+		id    int64
+		check bool
+	}{
+		{"stage", (*model.Stage)(nil), "stage_id = ?", int64(dropInfo.StageID), true},
+		{"time range", (*model.TimeRange)(nil), "range_id = ?", int64(dropInfo.RangeID), true},
+		{"item", (*model.Item)(nil), "item_id = ?", dropInfo.ItemID.Int64, dropInfo.ItemID.Valid},
+	}
+	for _, reference := range references {
+		if !reference.check {
+			continue
+		}
+		exists, err := s.AdminRepo.Exists(ctx, tx, reference.model, reference.where, reference.id)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return pgerr.ErrInvalidReq.Msg("%s: %s %d not found", label, reference.name, reference.id)
+		}
+	}
+	return nil
+}
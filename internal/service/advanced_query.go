@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	modelv3 "github.com/penguin-statistics/backend-next/internal/model/v3"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+var ErrAdvancedQueryIntervalTooSmall = pgerr.ErrInvalidReq.Msg("interval length must be greater than 1 hour")
+
+type AdvancedQuery struct {
+	DropMatrixService *DropMatrix
+	TrendService      *Trend
+	StageService      *Stage
+	ItemService       *Item
+}
+
+func NewAdvancedQuery(dropMatrixService *DropMatrix, trendService *Trend, stageService *Stage, itemService *Item) *AdvancedQuery {
+	return &AdvancedQuery{
+		DropMatrixService: dropMatrixService,
+		TrendService:      trendService,
+		StageService:      stageService,
+		ItemService:       itemService,
+	}
+}
+
+// advancedQueryUnit is an advanced query on one of its servers, resolved to stage and item ids.
+type advancedQueryUnit struct {
+	id             string
+	server         string
+	start          time.Time
+	end            time.Time
+	intervalLength time.Duration
+	intervalNum    int
+	accountId      null.Int
+
+	stageIds    []int
+	itemIds     []int
+	arkStageIds map[string]struct{}
+	// arkItemIds is empty when all items are queried
+	arkItemIds map[string]struct{}
+}
+
+// sharedKey identifies the calculation the unit shares with other units of the same server, time range, interval
+// and account.
+func (u *advancedQueryUnit) sharedKey() string {
+	return strings.Join([]string{
+		u.server,
+		strconv.FormatInt(u.start.UnixMilli(), 10),
+		strconv.FormatInt(u.end.UnixMilli(), 10),
+		u.intervalLength.String(),
+		accountIdKey(u.accountId),
+	}, constant.CacheSep)
+}
+
+// advancedQueryGroup is a calculation shared by units, over the union of their stages and items.
+type advancedQueryGroup struct {
+	units    []*advancedQueryUnit
+	stageIds []int
+	// itemIds is nil when any of the units queries all items
+	itemIds []int
+
+	matrix *modelv2.DropMatrixQueryResult
+	trend  *modelv2.TrendQueryResult
+}
+
+// Execute runs queries, with accountId as the account of personal queries, and returns a result per query per
+// server. Queries are grouped by server, time range, interval and account, and each group is calculated once over
+// the union of the stages and items of its queries, so that a batch of queries costs about as much as its distinct
+// time ranges instead of its size.
+func (s *AdvancedQuery) Execute(ctx context.Context, queries []*types.AdvancedQueryV3, accountId null.Int) (*modelv3.AdvancedQueryResult, error) {
+	// queries without an end share the same end, so that they could share the same calculation
+	now := time.Now()
+	units := make([]*advancedQueryUnit, 0, len(queries))
+	for _, query := range queries {
+		queryUnits, err := s.resolve(ctx, query, accountId, now)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, queryUnits...)
+	}
+
+	groups := make(map[string]*advancedQueryGroup)
+	groupOrder := make([]string, 0)
+	for _, unit := range units {
+		key := unit.sharedKey()
+		group, ok := groups[key]
+		if !ok {
+			group = &advancedQueryGroup{itemIds: make([]int, 0)}
+			groups[key] = group
+			groupOrder = append(groupOrder, key)
+		}
+		group.units = append(group.units, unit)
+		group.stageIds = appendUniqueInts(group.stageIds, unit.stageIds...)
+		if len(unit.itemIds) == 0 {
+			group.itemIds = nil
+		} else if group.itemIds != nil {
+			group.itemIds = appendUniqueInts(group.itemIds, unit.itemIds...)
+		}
+	}
+
+	for _, key := range groupOrder {
+		if err := s.calc(ctx, groups[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &modelv3.AdvancedQueryResult{
+		Results: make([]*modelv3.AdvancedQueryResultEntry, 0, len(units)),
+	}
+	for _, unit := range units {
+		result.Results = append(result.Results, groups[unit.sharedKey()].entryOf(unit))
+	}
+	return result, nil
+}
+
+// resolve resolves query into a unit per server of the query.
+func (s *AdvancedQuery) resolve(ctx context.Context, query *types.AdvancedQueryV3, accountId null.Int, now time.Time) ([]*advancedQueryUnit, error) {
+	if !query.IsPersonal {
+		accountId = null.NewInt(0, false)
+	}
+
+	stageIds := make([]int, 0, len(query.StageIDs))
+	arkStageIds := make(map[string]struct{}, len(query.StageIDs))
+	for _, arkStageId := range query.StageIDs {
+		stage, err := s.StageService.GetStageByArkId(ctx, arkStageId)
+		if err != nil {
+			return nil, err
+		}
+		stageIds = appendUniqueInts(stageIds, stage.StageID)
+		arkStageIds[stage.ArkStageID] = struct{}{}
+	}
+
+	itemIds := make([]int, 0, len(query.ItemIDs))
+	arkItemIds := make(map[string]struct{}, len(query.ItemIDs))
+	for _, arkItemId := range query.ItemIDs {
+		item, err := s.ItemService.GetItemByArkId(ctx, arkItemId)
+		if err != nil {
+			return nil, err
+		}
+		itemIds = appendUniqueInts(itemIds, item.ItemID)
+		arkItemIds[item.ArkItemID] = struct{}{}
+	}
+
+	units := make([]*advancedQueryUnit, 0, len(query.Servers))
+	for _, server := range query.Servers {
+		unit := &advancedQueryUnit{
+			id:          query.ID,
+			server:      server,
+			start:       time.UnixMilli(constant.ServerStartTimeMapMillis[server]),
+			end:         now,
+			accountId:   accountId,
+			stageIds:    stageIds,
+			itemIds:     itemIds,
+			arkStageIds: arkStageIds,
+			arkItemIds:  arkItemIds,
+		}
+		if query.StartTime.Valid {
+			unit.start = time.UnixMilli(query.StartTime.Int64)
+		}
+		if query.EndTime.Valid {
+			unit.end = time.UnixMilli(query.EndTime.Int64)
+		}
+		if !unit.end.After(unit.start) {
+			return nil, pgerr.ErrInvalidReq.Msg("end must be after start")
+		}
+
+		if query.Interval.Valid {
+			// interval is in milliseconds
+			unit.intervalLength = time.Duration(query.Interval.Int64 * 1e6).Round(time.Hour)
+			if unit.intervalLength.Hours() < 1 {
+				return nil, ErrAdvancedQueryIntervalTooSmall
+			}
+			unit.intervalNum = int(unit.end.Sub(unit.start).Hours()) / int(unit.intervalLength.Hours())
+			if unit.intervalNum > constant.MaxIntervalNum {
+				return nil, pgerr.ErrInvalidReq.Msg("too many sections: interval number is %d sections, which is larger than %d sections", unit.intervalNum, constant.MaxIntervalNum)
+			}
+		}
+		units = append(units, unit)
+	}
+	return units, nil
+}
+
+// calc calculates the drop matrix or the trend of group, over the union of stages and items of its units.
+func (s *AdvancedQuery) calc(ctx context.Context, group *advancedQueryGroup) error {
+	unit := group.units[0]
+	itemIds := group.itemIds
+	if itemIds == nil {
+		itemIds = []int{}
+	}
+
+	if unit.intervalLength == 0 {
+		timeRange := &model.TimeRange{
+			StartTime: &unit.start,
+			EndTime:   &unit.end,
+		}
+		matrix, err := s.DropMatrixService.GetShimCustomizedDropMatrixResults(ctx, unit.server, timeRange, group.stageIds, itemIds, unit.accountId)
+		if err != nil {
+			return err
+		}
+		group.matrix = s.DropMatrixService.GateLowSample(matrix, "")
+		return nil
+	}
+
+	trend, err := s.TrendService.GetShimCustomizedTrendResults(ctx, unit.server, &unit.start, unit.intervalLength, unit.intervalNum, group.stageIds, itemIds, unit.accountId)
+	if err != nil {
+		return err
+	}
+	group.trend = trend
+	return nil
+}
+
+// entryOf returns the part of the result of group that unit has queried.
+func (g *advancedQueryGroup) entryOf(unit *advancedQueryUnit) *modelv3.AdvancedQueryResultEntry {
+	entry := &modelv3.AdvancedQueryResultEntry{
+		ID:     unit.id,
+		Server: unit.server,
+	}
+	if g.matrix != nil {
+		entry.Matrix = make([]*modelv2.OneDropMatrixElement, 0)
+		for _, el := range g.matrix.Matrix {
+			if unit.queries(el.StageID, el.ItemID) {
+				entry.Matrix = append(entry.Matrix, el)
+			}
+		}
+	}
+	if g.trend != nil {
+		entry.Trend = make(map[string]*modelv2.StageTrend)
+		for arkStageId, stageTrend := range g.trend.Trend {
+			if _, ok := unit.arkStageIds[arkStageId]; !ok {
+				continue
+			}
+			results := make(map[string]*modelv2.OneItemTrend)
+			for arkItemId, itemTrend := range stageTrend.Results {
+				if unit.queries(arkStageId, arkItemId) {
+					results[arkItemId] = itemTrend
+				}
+			}
+			entry.Trend[arkStageId] = &modelv2.StageTrend{
+				Results:   results,
+				StartTime: stageTrend.StartTime,
+			}
+		}
+	}
+	return entry
+}
+
+func (u *advancedQueryUnit) queries(arkStageId string, arkItemId string) bool {
+	if _, ok := u.arkStageIds[arkStageId]; !ok {
+		return false
+	}
+	if len(u.arkItemIds) == 0 {
+		return true
+	}
+	_, ok := u.arkItemIds[arkItemId]
+	return ok
+}
+
+func appendUniqueInts(s []int, values ...int) []int {
+	for _, v := range values {
+		if !lo.Contains(s, v) {
+			s = append(s, v)
+		}
+	}
+	return s
+}
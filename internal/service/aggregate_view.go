@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// AggregateView manages materialized views the global drop matrix and pattern matrix are calculated from when
+// enabled.
+type AggregateView struct {
+	AggregateViewRepo *repo.AggregateView
+	Locker            *dlock.Locker
+}
+
+func NewAggregateView(aggregateViewRepo *repo.AggregateView, locker *dlock.Locker) *AggregateView {
+	return &AggregateView{
+		AggregateViewRepo: aggregateViewRepo,
+		Locker:            locker,
+	}
+}
+
+// Enabled returns whether the global drop matrix and pattern matrix are calculated from the views.
+func (s *AggregateView) Enabled() bool {
+	return s.AggregateViewRepo.Enabled
+}
+
+// RefreshViews refreshes the views to include reports submitted since their last refresh. The views could be
+// refreshed even when they are disabled, so that they are ready before being enabled. Refreshes never run
+// concurrently across instances.
+func (s *AggregateView) RefreshViews(ctx context.Context) error {
+	return s.Locker.Do(ctx, constant.LockAggregateViews, func(ctx context.Context) error {
+		start := time.Now()
+		if err := s.AggregateViewRepo.RefreshViews(ctx); err != nil {
+			return err
+		}
+		log.Info().Dur("duration", time.Since(start)).Msg("aggregate views refreshed")
+		return nil
+	})
+}
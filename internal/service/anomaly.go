@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+// Anomaly detects drop rates of recent reports deviating significantly from their historical baselines.
+type Anomaly struct {
+	AnomalyRepo       *repo.Anomaly
+	DropMatrixService *DropMatrix
+	StageService      *Stage
+	ItemService       *Item
+	WebhookService    *Webhook
+	Notifier          *Notifier
+	// RuntimeConfig provides the thresholds anomalies are flagged by in effect.
+	RuntimeConfig *RuntimeConfig
+
+	// RecentWindow is the duration of the window of recent reports to check.
+	RecentWindow time.Duration
+	// BaselineWindow is the duration of the window right before RecentWindow the recent drop rates compare against.
+	BaselineWindow time.Duration
+}
+
+func NewAnomaly(anomalyRepo *repo.Anomaly, dropMatrixService *DropMatrix, stageService *Stage, itemService *Item, webhookService *Webhook, notifier *Notifier, runtimeConfig *RuntimeConfig, conf *config.Config) *Anomaly {
+	return &Anomaly{
+		AnomalyRepo:       anomalyRepo,
+		DropMatrixService: dropMatrixService,
+		StageService:      stageService,
+		ItemService:       itemService,
+		WebhookService:    webhookService,
+		Notifier:          notifier,
+		RuntimeConfig:     runtimeConfig,
+		RecentWindow:      conf.AnomalyRecentWindow,
+		BaselineWindow:    conf.AnomalyBaselineWindow,
+	}
+}
+
+type anomalyNotification struct {
+	Server       string    `json:"server"`
+	StageID      string    `json:"stageId"`
+	ItemID       string    `json:"itemId"`
+	WindowStart  time.Time `json:"windowStart"`
+	WindowEnd    time.Time `json:"windowEnd"`
+	BaselineRate float64   `json:"baselineRate"`
+	RecentRate   float64   `json:"recentRate"`
+	RecentTimes  int       `json:"recentTimes"`
+	ZScore       float64   `json:"zScore"`
+}
+
+// DetectAnomalies compares drop rates of reports of server within the recent window against the baseline window
+// right before it, records deviations not yet flagged within the recent window, and notifies moderators of them.
+// It returns the newly flagged anomalies.
+func (s *Anomaly) DetectAnomalies(ctx context.Context, server string) ([]*model.Anomaly, error) {
+	conf := s.RuntimeConfig.Current()
+	windowEnd := time.Now()
+	windowStart := windowEnd.Add(-s.RecentWindow)
+	baselineStart := windowStart.Add(-s.BaselineWindow)
+
+	recentElements, err := s.DropMatrixService.calcDropMatrixForTimeRanges(ctx, server,
+		[]*model.TimeRange{{StartTime: &windowStart, EndTime: &windowEnd}}, nil, nil, null.Int{}, constant.SourceCategoryAll)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate drop matrix of recent window")
+	}
+	baselineElements, err := s.DropMatrixService.calcDropMatrixForTimeRanges(ctx, server,
+		[]*model.TimeRange{{StartTime: &baselineStart, EndTime: &windowStart}}, nil, nil, null.Int{}, constant.SourceCategoryAll)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate drop matrix of baseline window")
+	}
+
+	type cell struct {
+		stageId int
+		itemId  int
+	}
+	baselineMap := make(map[cell]*model.DropMatrixElement, len(baselineElements))
+	for _, el := range baselineElements {
+		baselineMap[cell{stageId: el.StageID, itemId: el.ItemID}] = el
+	}
+
+	// anomalies already flagged within the recent window are not flagged again
+	flagged, err := s.AnomalyRepo.GetAnomaliesSince(ctx, server, windowStart)
+	if err != nil {
+		return nil, err
+	}
+	flaggedSet := make(map[cell]struct{}, len(flagged))
+	for _, anomaly := range flagged {
+		flaggedSet[cell{stageId: anomaly.StageID, itemId: anomaly.ItemID}] = struct{}{}
+	}
+
+	anomalies := make([]*model.Anomaly, 0)
+	for _, recent := range recentElements {
+		key := cell{stageId: recent.StageID, itemId: recent.ItemID}
+		baseline, ok := baselineMap[key]
+		if !ok || recent.Times < conf.AnomalyMinTimes || baseline.Times < conf.AnomalyMinTimes {
+			continue
+		}
+		if _, ok := flaggedSet[key]; ok {
+			continue
+		}
+
+		recentRate := float64(recent.Quantity) / float64(recent.Times)
+		baselineRate := float64(baseline.Quantity) / float64(baseline.Times)
+		recentStdDev := util.CalcStdDevFromQuantityBuckets(recent.QuantityBuckets, recent.Times)
+		baselineStdDev := util.CalcStdDevFromQuantityBuckets(baseline.QuantityBuckets, baseline.Times)
+		stdErr := math.Sqrt(recentStdDev*recentStdDev/float64(recent.Times) + baselineStdDev*baselineStdDev/float64(baseline.Times))
+		if stdErr == 0 {
+			// deterministic drops have no variance to compare against
+			continue
+		}
+
+		zScore := (recentRate - baselineRate) / stdErr
+		if math.Abs(zScore) < conf.AnomalyZThreshold {
+			continue
+		}
+		anomalies = append(anomalies, &model.Anomaly{
+			Server:        server,
+			StageID:       recent.StageID,
+			ItemID:        recent.ItemID,
+			WindowStart:   windowStart,
+			WindowEnd:     windowEnd,
+			BaselineTimes: baseline.Times,
+			BaselineRate:  util.RoundFloat64(baselineRate, constant.StdDevDigits),
+			RecentTimes:   recent.Times,
+			RecentRate:    util.RoundFloat64(recentRate, constant.StdDevDigits),
+			ZScore:        util.RoundFloat64(zScore, constant.StdDevDigits),
+		})
+	}
+
+	if err := s.AnomalyRepo.CreateAnomalies(ctx, anomalies); err != nil {
+		return nil, errors.Wrap(err, "failed to save anomalies")
+	}
+	if err := s.notify(ctx, anomalies); err != nil {
+		// anomalies have been recorded anyway, so failing to notify does not fail the detection
+		log.Error().Err(err).Str("server", server).Int("anomalies", len(anomalies)).Msg("failed to notify anomalies")
+	}
+	return anomalies, nil
+}
+
+// notify sends anomalies, with ark stage and item ids, to webhooks subscribing to
+// constant.WebhookEventAnomalyDetected and alert channels.
+func (s *Anomaly) notify(ctx context.Context, anomalies []*model.Anomaly) error {
+	if len(anomalies) == 0 {
+		return nil
+	}
+
+	stagesMapById, err := s.StageService.GetStagesMapById(ctx)
+	if err != nil {
+		return err
+	}
+	itemsMapById, err := s.ItemService.GetItemsMapById(ctx)
+	if err != nil {
+		return err
+	}
+
+	notifications := make([]*anomalyNotification, 0, len(anomalies))
+	for _, anomaly := range anomalies {
+		notification := &anomalyNotification{
+			Server:       anomaly.Server,
+			WindowStart:  anomaly.WindowStart,
+			WindowEnd:    anomaly.WindowEnd,
+			BaselineRate: anomaly.BaselineRate,
+			RecentRate:   anomaly.RecentRate,
+			RecentTimes:  anomaly.RecentTimes,
+			ZScore:       anomaly.ZScore,
+		}
+		if stage, ok := stagesMapById[anomaly.StageID]; ok {
+			notification.StageID = stage.ArkStageID
+		}
+		if item, ok := itemsMapById[anomaly.ItemID]; ok {
+			notification.ItemID = item.ArkItemID
+		}
+		notifications = append(notifications, notification)
+	}
+
+	s.WebhookService.Publish(constant.WebhookEventAnomalyDetected, notifications)
+	s.Notifier.Notify(ctx, anomalyAlert(notifications))
+	return nil
+}
+
+// anomalyAlertMaxLines is the maximum number of anomalies listed in an alert.
+const anomalyAlertMaxLines = 10
+
+// anomalyAlert formats notifications of anomalies of the same server as an alert.
+func anomalyAlert(notifications []*anomalyNotification) *Alert {
+	lines := make([]string, 0, anomalyAlertMaxLines+1)
+	for i, n := range notifications {
+		if i == anomalyAlertMaxLines {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(notifications)-anomalyAlertMaxLines))
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s / %s: %.4f -> %.4f (z=%.1f, n=%d)",
+			n.StageID, n.ItemID, n.BaselineRate, n.RecentRate, n.ZScore, n.RecentTimes))
+	}
+
+	return &Alert{
+		Kind:     constant.AlertAnomaly,
+		Severity: constant.AlertSeverityWarning,
+		Server:   notifications[0].Server,
+		Title:    fmt.Sprintf("%d drop rate anomalies flagged", len(notifications)),
+		Message:  strings.Join(lines, "\n"),
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// apiKeyCacheTTL bounds how long a revoked API key could still be used on instances other than the one which
+// revoked it.
+const apiKeyCacheTTL = time.Minute
+
+var ErrAPIKeyLimitReached = pgerr.ErrInvalidReq.Msg("invalid request: maximum number of API keys reached")
+
+// APIKey manages API keys, which automated reporters authenticate with to act on behalf of an account.
+type APIKey struct {
+	APIKeyRepo     *repo.APIKey
+	AccountService *Account
+}
+
+func NewAPIKey(apiKeyRepo *repo.APIKey, accountService *Account) *APIKey {
+	return &APIKey{
+		APIKeyRepo:     apiKeyRepo,
+		AccountService: accountService,
+	}
+}
+
+// CreateAPIKey creates an API key named name for accountId, and returns it along with the key itself, which
+// is not retrievable afterwards.
+func (s *APIKey) CreateAPIKey(ctx context.Context, accountId int, name string) (*model.APIKey, string, error) {
+	plain := constant.APIKeyPrefix + uniuri.NewLen(40)
+	key := &model.APIKey{
+		AccountID: accountId,
+		Name:      name,
+		Prefix:    plain[:len(constant.APIKeyPrefix)+6],
+		KeyHash:   hashAPIKey(plain),
+	}
+	created, err := s.APIKeyRepo.CreateAPIKey(ctx, key, constant.APIKeyMaxPerAccount)
+	if err != nil {
+		return nil, "", err
+	} else if !created {
+		return nil, "", ErrAPIKeyLimitReached
+	}
+	return key, plain, nil
+}
+
+func (s *APIKey) GetAPIKeys(ctx context.Context, accountId int) ([]*model.APIKey, error) {
+	return s.APIKeyRepo.GetActiveAPIKeysByAccountId(ctx, accountId)
+}
+
+func (s *APIKey) RevokeAPIKey(ctx context.Context, accountId int, keyId int) error {
+	key, err := s.APIKeyRepo.RevokeAPIKey(ctx, accountId, keyId)
+	if err != nil {
+		return err
+	}
+	return cache.APIKeyByHash.Delete(key.KeyHash)
+}
+
+// Authenticate returns the PenguinID of the account of plain, and the id of the key.
+// pgerr.ErrUnauthorized is returned if plain is not an active API key.
+//
+// Cache: apiKey#keyHash:{keyHash}, 1 min
+func (s *APIKey) Authenticate(ctx context.Context, plain string) (penguinId string, keyId int, err error) {
+	if !strings.HasPrefix(plain, constant.APIKeyPrefix) {
+		return "", 0, pgerr.ErrUnauthorized
+	}
+
+	keyHash := hashAPIKey(plain)
+	var key model.APIKey
+	if err := cache.APIKeyByHash.Get(keyHash, &key); err != nil {
+		dbKey, err := s.APIKeyRepo.GetActiveAPIKeyByHash(ctx, keyHash)
+		if errors.Is(err, pgerr.ErrNotFound) {
+			return "", 0, pgerr.ErrUnauthorized
+		} else if err != nil {
+			return "", 0, err
+		}
+		key = *dbKey
+		cache.APIKeyByHash.Set(keyHash, key, apiKeyCacheTTL)
+
+		// last usage is tracked at the granularity of the cache TTL, to avoid a write per request
+		if err := s.APIKeyRepo.TouchAPIKey(ctx, key.KeyID, time.Now()); err != nil {
+			log.Warn().Err(err).Int("keyId", key.KeyID).Msg("failed to update last usage of api key")
+		}
+	}
+
+	account, err := s.AccountService.GetAccountById(ctx, strconv.Itoa(key.AccountID))
+	if err != nil {
+		return "", 0, err
+	}
+	return account.PenguinID, key.KeyID, nil
+}
+
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/pkg/flog"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// Audit keeps the audit log of mutations made through the admin API, recording who has made each of them, from
+// where, and what has been changed.
+type Audit struct {
+	AuditLogRepo *repo.AuditLog
+}
+
+func NewAudit(auditLogRepo *repo.AuditLog) *Audit {
+	return &Audit{
+		AuditLogRepo: auditLogRepo,
+	}
+}
+
+// Record records the audit log of the request of ctx, which has been handled with err, along with snapshots in
+// trail. Failed requests are recorded as well, so that attempts could be reviewed. Failing to record is logged
+// instead of failing the request, as the mutation has been made already.
+func (s *Audit) Record(ctx *fiber.Ctx, trail *audit.Trail, err error) {
+	actor, ok := ctx.Locals(constant.ContextKeyAdminActor).(string)
+	if !ok {
+		actor = constant.AuditDefaultActor
+	}
+
+	auditLog := &model.AuditLog{
+		Actor:      actor,
+		Action:     ctx.Method() + " " + ctx.Route().Path,
+		Path:       ctx.OriginalURL(),
+		IP:         ctx.IP(),
+		StatusCode: auditStatusCode(ctx, err),
+	}
+	if requestId, ok := ctx.Locals(constant.ContextKeyRequestID).(string); ok {
+		auditLog.RequestID = null.StringFrom(requestId)
+	}
+	if body := ctx.Body(); len(body) > 0 && json.Valid(body) {
+		// Redact copies the body, which is only valid until the request is responded
+		auditLog.Request = audit.Redact(body, isSecretAuditField)
+	}
+
+	auditLog.Before, auditLog.After = trail.Snapshots()
+	if diff := audit.Diff(auditLog.Before, auditLog.After); diff != nil {
+		// a map of raw messages could never fail to be marshalled
+		auditLog.Diff, _ = json.Marshal(diff)
+	}
+
+	// the request context is not used, as it is recycled once the request is responded
+	recordCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := s.AuditLogRepo.CreateAuditLog(recordCtx, auditLog); err != nil {
+		flog.ErrorFrom(ctx).Err(err).Str("action", auditLog.Action).Msg("failed to record audit log")
+	}
+}
+
+// secretAuditFields are names of fields of request bodies, in lower case, whose values are secrets.
+var secretAuditFields = map[string]struct{}{
+	"secret":   {},
+	"password": {},
+	"token":    {},
+	"apikey":   {},
+}
+
+// isSecretAuditField returns whether values of the field of name in request bodies are secrets, including overrides
+// of secret fields of config.Config.
+func isSecretAuditField(name string) bool {
+	if _, ok := secretAuditFields[strings.ToLower(name)]; ok {
+		return true
+	}
+	return config.IsSecretField(name)
+}
+
+// RecordOperation records auditLog of an operation made without the admin API, e.g. by penguinctl in maintenance
+// mode, which has been made with req as its request body and has ended with err.
+func (s *Audit) RecordOperation(ctx context.Context, auditLog *model.AuditLog, req any, err error) {
+	if req != nil {
+		if body, marshalErr := json.Marshal(req); marshalErr == nil {
+			auditLog.Request = audit.Redact(body, isSecretAuditField)
+		}
+	}
+	auditLog.StatusCode = errorStatusCode(err)
+
+	// the operation could have been cancelled, while it shall be recorded anyway
+	recordCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := s.AuditLogRepo.CreateAuditLog(recordCtx, auditLog); err != nil {
+		log.Error().Err(err).Str("action", auditLog.Action).Msg("failed to record audit log")
+	}
+}
+
+// auditStatusCode returns the status code the request of ctx is responded with. Requests failed with err are
+// responded by the error handler after all middlewares have returned, so the status code is taken from err.
+func auditStatusCode(ctx *fiber.Ctx, err error) int {
+	if err == nil {
+		return ctx.Response().StatusCode()
+	}
+	return errorStatusCode(err)
+}
+
+// errorStatusCode returns the status code a request failed with err is responded with, or 200 if err is nil.
+func errorStatusCode(err error) int {
+	if err == nil {
+		return fiber.StatusOK
+	}
+
+	var pe *pgerr.PenguinError
+	if errors.As(err, &pe) {
+		return pe.StatusCode
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return fe.Code
+	}
+	return fiber.StatusInternalServerError
+}
+
+// GetAuditLogs returns audit logs matching req, latest first.
+func (s *Audit) GetAuditLogs(ctx context.Context, req *types.AuditLogQuery) ([]*model.AuditLog, error) {
+	query := &repo.AuditLogQuery{
+		Actor:    req.Actor,
+		Action:   req.Action,
+		BeforeID: req.Before,
+		Limit:    req.Limit,
+	}
+	if query.Limit == 0 {
+		query.Limit = 100
+	}
+	if req.StartTime != 0 {
+		query.StartTime = time.UnixMilli(req.StartTime)
+	}
+	if req.EndTime != 0 {
+		query.EndTime = time.UnixMilli(req.EndTime)
+	}
+
+	return s.AuditLogRepo.GetAuditLogs(ctx, query)
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+// cacheVersionLocalTTL bounds how long an instance trusts its own copy of a version. Versions are kept up to date
+// by invalidations published on NATS, so the TTL only matters when an invalidation is missed.
+const cacheVersionLocalTTL = time.Minute * 10
+
+// cacheInvalidators drop in-process copies of a cached aggregate of a name, given the key of the aggregate.
+// Aggregates cached in Redis are keyed by their version instead, and need no invalidator.
+var cacheInvalidators = map[string]func(key string){
+	constant.CacheVersionDropMatrix: func(server string) {
+		_ = cache.ShimMaxAccumulableDropMatrixResults.Delete(server + constant.CacheSep + "true")
+		_ = cache.ShimMaxAccumulableDropMatrixResults.Delete(server + constant.CacheSep + "false")
+	},
+	constant.CacheVersionRecentDropMatrix: func(server string) {
+		_ = cache.RecentDropMatrixResults.Delete(server)
+	},
+	constant.CacheVersionPatternMatrix: func(server string) {
+		_ = cache.ShimLatestPatternMatrixResults.Delete(server)
+	},
+	constant.CacheVersionTrend: func(key string) {
+		_ = cache.ShimSavedTrendResults.Delete(key)
+	},
+	constant.CacheVersionSiteStats: func(server string) {
+		_ = cache.ShimSiteStats.Delete(server)
+	},
+	constant.CacheVersionGameData: func(_ string) {
+		cache.FlushLocalGameData()
+	},
+	constant.CacheVersionRecognitionRelease: func(server string) {
+		_ = cache.RecognitionReleaseByServer.Delete(server)
+	},
+	constant.CacheVersionDropPattern: func(patternId string) {
+		_ = cache.DropPatternElementsByPatternID.Delete(patternId)
+		// hashes of a pattern are not known by its id, and the lookup by hash is refilled as reports are persisted
+		_ = cache.DropPatternIDByHash.Flush()
+	},
+}
+
+// CacheVersion keeps versions of cached aggregates in Redis, shared by all instances. Bumping a version drops
+// copies of the aggregate cached by every instance, through invalidations published on NATS.
+type CacheVersion struct {
+	Redis    *redis.Client
+	NatsConn *nats.Conn
+}
+
+func NewCacheVersion(redisClient *redis.Client, natsConn *nats.Conn) *CacheVersion {
+	return &CacheVersion{
+		Redis:    redisClient,
+		NatsConn: natsConn,
+	}
+}
+
+// ListenCacheInvalidations subscribes to invalidations published by all instances, including this one, for as long
+// as the application runs.
+func ListenCacheInvalidations(s *CacheVersion, lc fx.Lifecycle) {
+	var sub *nats.Subscription
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			var err error
+			sub, err = s.NatsConn.Subscribe(constant.CacheInvalidationSubject, s.handleInvalidation)
+			return err
+		},
+		OnStop: func(_ context.Context) error {
+			return sub.Unsubscribe()
+		},
+	})
+}
+
+// Version returns the current version of the aggregate of name with key. Aggregates never bumped are of version 0.
+//
+// Cache: cacheVersion#name|key:{name}|{key}, 10 mins
+func (s *CacheVersion) Version(ctx context.Context, name string, key string) (int64, error) {
+	var version int64
+	if err := cache.CacheVersions.Get(name+constant.CacheSep+key, &version); err == nil {
+		return version, nil
+	}
+
+	version, err := s.Redis.Get(ctx, cacheVersionKey(name, key)).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, err
+	}
+	cache.CacheVersions.Set(name+constant.CacheSep+key, version, cacheVersionLocalTTL)
+	return version, nil
+}
+
+// ETagVersion returns the current version of the aggregate of name with key, formatted for entity tags, or false if
+// the version could not be read, in which case the response shall be served without an entity tag rather than failed.
+func (s *CacheVersion) ETagVersion(ctx context.Context, name string, key string) (string, bool) {
+	version, err := s.Version(ctx, name, key)
+	if err != nil {
+		log.Warn().Err(err).Str("name", name).Str("key", key).Msg("failed to read cache version; serving without ETag")
+		return "", false
+	}
+	return strconv.FormatInt(version, 10), true
+}
+
+// Bump increments the version of the aggregate of name with key, after its underlying data has changed, and
+// drops copies of it cached by every instance.
+func (s *CacheVersion) Bump(ctx context.Context, name string, key string) error {
+	version, err := s.Redis.Incr(ctx, cacheVersionKey(name, key)).Result()
+	if err != nil {
+		return errors.Wrapf(err, "failed to bump cache version of %s %s", name, key)
+	}
+
+	invalidation := &types.CacheInvalidation{Name: name, Key: key, Version: version}
+	s.applyInvalidation(invalidation)
+
+	data, err := json.Marshal(invalidation)
+	if err != nil {
+		return err
+	}
+	if err := s.NatsConn.Publish(constant.CacheInvalidationSubject, data); err != nil {
+		// other instances catch up once their copy of the version expires
+		log.Warn().Err(err).Str("name", name).Str("key", key).Msg("failed to publish cache invalidation")
+	}
+	return nil
+}
+
+func (s *CacheVersion) handleInvalidation(msg *nats.Msg) {
+	var invalidation types.CacheInvalidation
+	if err := json.Unmarshal(msg.Data, &invalidation); err != nil {
+		log.Warn().Err(err).Msg("failed to unmarshal cache invalidation")
+		return
+	}
+
+	var current int64
+	if err := cache.CacheVersions.Get(invalidation.Name+constant.CacheSep+invalidation.Key, &current); err == nil && current >= invalidation.Version {
+		// already applied, e.g. by Bump of this instance
+		return
+	}
+	s.applyInvalidation(&invalidation)
+}
+
+func (s *CacheVersion) applyInvalidation(invalidation *types.CacheInvalidation) {
+	cache.CacheVersions.Set(invalidation.Name+constant.CacheSep+invalidation.Key, invalidation.Version, cacheVersionLocalTTL)
+	if invalidate, ok := cacheInvalidators[invalidation.Name]; ok {
+		invalidate(invalidation.Key)
+	}
+}
+
+func cacheVersionKey(name string, key string) string {
+	return constant.CacheVersionKeyPrefix + name + ":" + key
+}
+
+// invalidatePersonalCaches bumps the version of personal caches of accountId, so that cached personal drop matrices
+// and personal histories of all servers are left behind to expire.
+func (s *CacheVersion) invalidatePersonalCaches(ctx context.Context, accountId int) {
+	if accountId == 0 {
+		return
+	}
+	if err := s.Bump(ctx, constant.CacheVersionPersonal, strconv.Itoa(accountId)); err != nil {
+		log.Warn().Err(err).Int("accountId", accountId).Msg("failed to invalidate personal caches")
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/gameday"
+)
+
+// Calendar answers questions about time on game servers, such as when a game day or a game week starts on a server
+// and which activities are open at some point of time, so that reports are bucketed by the day boundaries of the
+// server they are reported on.
+type Calendar struct {
+	ActivityService *Activity
+}
+
+func NewCalendar(activityService *Activity) *Calendar {
+	return &Calendar{
+		ActivityService: activityService,
+	}
+}
+
+// Location returns the time zone of server.
+func (s *Calendar) Location(server string) *time.Location {
+	return gameday.Location(server)
+}
+
+// DayStart returns the start of the game day containing t on server, which is the daily reset of the server.
+func (s *Calendar) DayStart(server string, t time.Time) time.Time {
+	return gameday.StartTime(server, t)
+}
+
+// DayEnd returns the end of the game day containing t on server, which is the start of the next game day.
+func (s *Calendar) DayEnd(server string, t time.Time) time.Time {
+	return gameday.EndTime(server, t)
+}
+
+// IsDayStart returns whether t is exactly the daily reset of server.
+func (s *Calendar) IsDayStart(server string, t time.Time) bool {
+	return gameday.IsStartTime(server, t)
+}
+
+// WeekStart returns the start of the game week containing t on server, which is the daily reset on Monday.
+func (s *Calendar) WeekStart(server string, t time.Time) time.Time {
+	return gameday.WeekStartTime(server, t)
+}
+
+// WeekEnd returns the end of the game week containing t on server, which is the start of the next game week.
+func (s *Calendar) WeekEnd(server string, t time.Time) time.Time {
+	return gameday.WeekEndTime(server, t)
+}
+
+// OpenActivities returns activities open on server at t.
+func (s *Calendar) OpenActivities(ctx context.Context, server string, t time.Time) ([]*model.Activity, error) {
+	activities, err := s.ActivityService.GetActivities(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	open := make([]*model.Activity, 0)
+	for _, activity := range activities {
+		if activity.StartTime != nil && t.Before(*activity.StartTime) {
+			continue
+		}
+		if activity.EndTime != nil && !t.Before(*activity.EndTime) {
+			continue
+		}
+		if !activityExistsOn(activity, server) {
+			continue
+		}
+		open = append(open, activity)
+	}
+	return open, nil
+}
+
+func activityExistsOn(activity *model.Activity, server string) bool {
+	var existence map[string]*model.ServerExistence
+	if err := json.Unmarshal(activity.Existence, &existence); err != nil {
+		return false
+	}
+	serverExistence, ok := existence[strings.ToUpper(server)]
+	return ok && serverExistence != nil && serverExistence.Exist
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/objstore"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var ErrDatasetSnapshotsDisabled = pgerr.ErrInvalidReq.Msg("dataset snapshots are not available on this instance")
+
+// DatasetSnapshot generates anonymized snapshots of the whole dataset, which are drop reports aggregated per stage,
+// item and game day, as gzip'd ND-JSON files uploaded to S3-compatible object storage.
+type DatasetSnapshot struct {
+	DropReportRepo *repo.DropReport
+	ObjectStore    *objstore.Client
+	// RuntimeConfig provides how long signed download URLs of snapshots are valid for, and how long snapshots are
+	// kept for.
+	RuntimeConfig *RuntimeConfig
+}
+
+func NewDatasetSnapshot(dropReportRepo *repo.DropReport, objectStore *objstore.Client, runtimeConfig *RuntimeConfig) *DatasetSnapshot {
+	return &DatasetSnapshot{
+		DropReportRepo: dropReportRepo,
+		ObjectStore:    objectStore,
+		RuntimeConfig:  runtimeConfig,
+	}
+}
+
+// Enabled returns whether object storage for dataset snapshots has been configured.
+func (s *DatasetSnapshot) Enabled() bool {
+	return s.ObjectStore != nil
+}
+
+// GenerateSnapshot generates a snapshot of server and uploads it. The snapshot is written to a temporary file
+// first, so that it is never held in memory as a whole.
+func (s *DatasetSnapshot) GenerateSnapshot(ctx context.Context, server string) error {
+	if !s.Enabled() {
+		return ErrDatasetSnapshotsDisabled
+	}
+
+	file, err := os.CreateTemp("", "penguin-snapshot-*.ndjson.gz")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary snapshot file")
+	}
+	defer func() {
+		file.Close()
+		if err := os.Remove(file.Name()); err != nil {
+			log.Warn().Err(err).Str("file", file.Name()).Msg("failed to remove temporary snapshot file")
+		}
+	}()
+
+	buffered := bufio.NewWriter(file)
+	gz := gzip.NewWriter(buffered)
+	encoder := json.NewEncoder(gz)
+	rows := 0
+	err = s.DropReportRepo.StreamDailyDropAggregates(ctx, server, func(aggregate *model.DailyDropAggregate) error {
+		rows++
+		return encoder.Encode(aggregate)
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	key := datasetSnapshotKey(server, time.Now())
+	if err := s.ObjectStore.PutObject(ctx, key, file, size, constant.DatasetSnapshotContentType); err != nil {
+		return err
+	}
+	log.Info().Str("server", server).Str("key", key).Int("rows", rows).Int64("size", size).Msg("dataset snapshot uploaded")
+	return nil
+}
+
+// ListSnapshots returns snapshots of server, newest first, with signed download URLs.
+func (s *DatasetSnapshot) ListSnapshots(ctx context.Context, server string) ([]*modelv2.DatasetSnapshot, error) {
+	if !s.Enabled() {
+		return nil, ErrDatasetSnapshotsDisabled
+	}
+
+	objects, err := s.ObjectStore.ListObjects(ctx, constant.DatasetSnapshotKeyPrefix+server+"/")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].LastModified.After(objects[j].LastModified)
+	})
+
+	ttl := s.RuntimeConfig.Current().SnapshotDownloadURLTTL
+	snapshots := make([]*modelv2.DatasetSnapshot, 0, len(objects))
+	for _, object := range objects {
+		snapshots = append(snapshots, &modelv2.DatasetSnapshot{
+			Server:    server,
+			Name:      path.Base(object.Key),
+			Size:      object.Size,
+			CreatedAt: object.LastModified.UnixMilli(),
+			URL:       s.ObjectStore.PresignGetObject(object.Key, ttl),
+		})
+	}
+	return snapshots, nil
+}
+
+// PruneSnapshots deletes snapshots of server older than config.Config SnapshotRetention, except for the latest one.
+func (s *DatasetSnapshot) PruneSnapshots(ctx context.Context, server string) error {
+	if !s.Enabled() {
+		return ErrDatasetSnapshotsDisabled
+	}
+	retention := s.RuntimeConfig.Current().SnapshotRetention
+	if retention <= 0 {
+		return nil
+	}
+
+	objects, err := s.ObjectStore.ListObjects(ctx, constant.DatasetSnapshotKeyPrefix+server+"/")
+	if err != nil {
+		return err
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].LastModified.After(objects[j].LastModified)
+	})
+
+	expiry := time.Now().Add(-retention)
+	deleted := 0
+	for i, object := range objects {
+		if i == 0 || object.LastModified.After(expiry) {
+			continue
+		}
+		if err := s.ObjectStore.DeleteObject(ctx, object.Key); err != nil {
+			return err
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		log.Info().Str("server", server).Int("snapshots", deleted).Dur("retention", retention).Msg("expired dataset snapshots deleted")
+	}
+	return nil
+}
+
+func datasetSnapshotKey(server string, t time.Time) string {
+	return constant.DatasetSnapshotKeyPrefix + server + "/drop-reports-daily-" + t.UTC().Format("20060102T150405Z") + ".ndjson.gz"
+}
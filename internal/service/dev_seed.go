@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/xid"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/devseed"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/gamedata"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/bininfo"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+const (
+	// devSeedTimeRangeName names time ranges created by DevSeed, which tell whether fixtures have been loaded into
+	// a server.
+	devSeedTimeRangeName = "dev-seed"
+	// devSeedSource is the source of reports queued by DevSeed.
+	devSeedSource = "dev-seed"
+)
+
+// devSeedStartTime is the start time of time ranges created by DevSeed, which end at constant.FakeEndTimeMilli.
+var devSeedStartTime = time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+
+// DevSeed loads the fixture set of package devseed into a database for local development, and queues its reports.
+type DevSeed struct {
+	DB            *bun.DB
+	AdminRepo     *repo.Admin
+	AdminService  *Admin
+	ReportService *Report
+}
+
+func NewDevSeed(db *bun.DB, adminRepo *repo.Admin, adminService *Admin, reportService *Report) *DevSeed {
+	return &DevSeed{
+		DB:            db,
+		AdminRepo:     adminRepo,
+		AdminService:  adminService,
+		ReportService: reportService,
+	}
+}
+
+// LoadFixtures creates the items, zone, stages and drop infos of fixtures, and returns servers they have been
+// loaded into. Servers the fixtures have already been loaded into are skipped, so that it could be run repeatedly.
+// Items, the zone and stages are upserted by their ark ids, which resets those modified since.
+func (s *DevSeed) LoadFixtures(ctx context.Context, fixtures *devseed.FixtureSet) ([]string, error) {
+	servers := make([]string, 0, len(constant.Servers))
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		for _, server := range constant.Servers {
+			loaded, err := s.AdminRepo.Exists(ctx, tx, (*model.TimeRange)(nil), "name = ? AND server = ?", devSeedTimeRangeName, server)
+			if err != nil {
+				return err
+			}
+			if !loaded {
+				servers = append(servers, server)
+			}
+		}
+		return nil
+	})
+	if err != nil || len(servers) == 0 {
+		return nil, err
+	}
+
+	if err := s.AdminService.SaveItems(ctx, fixtures.Items); err != nil {
+		return nil, errors.Wrap(err, "failed to save items")
+	}
+	itemIds := make(map[string]int, len(fixtures.Items))
+	for _, item := range fixtures.Items {
+		itemIds[item.ArkItemID] = item.ItemID
+	}
+
+	stages := make([]*model.Stage, 0, len(fixtures.Stages))
+	for _, stage := range fixtures.Stages {
+		stages = append(stages, stage.Stage)
+	}
+	endTime := time.UnixMilli(constant.FakeEndTimeMilli)
+
+	for _, server := range servers {
+		dropInfosMap := make(map[string][]*model.DropInfo, len(fixtures.Stages))
+		for _, stage := range fixtures.Stages {
+			for _, fixture := range stage.DropInfos {
+				dropInfo := &model.DropInfo{
+					Server:      server,
+					DropType:    fixture.DropType,
+					Accumulable: fixture.Accumulable,
+					Bounds:      fixture.Bounds,
+				}
+				if fixture.ArkItemID != "" {
+					itemId, ok := itemIds[fixture.ArkItemID]
+					if !ok {
+						return nil, errors.Errorf("stage %s: unknown item %s", stage.ArkStageID, fixture.ArkItemID)
+					}
+					dropInfo.ItemID = null.IntFrom(int64(itemId))
+				}
+				dropInfosMap[stage.ArkStageID] = append(dropInfosMap[stage.ArkStageID], dropInfo)
+			}
+		}
+
+		objects := &gamedata.RenderedObjects{
+			Zone:         fixtures.Zone,
+			Stages:       stages,
+			DropInfosMap: dropInfosMap,
+			TimeRange: &model.TimeRange{
+				Name:      null.StringFrom(devSeedTimeRangeName),
+				StartTime: &devSeedStartTime,
+				EndTime:   &endTime,
+				Comment:   null.StringFrom("created by --dev-seed"),
+				Server:    server,
+			},
+		}
+		if err := s.AdminService.SaveRenderedObjects(ctx, objects); err != nil {
+			return nil, errors.Wrapf(err, "failed to save game data of server %s", server)
+		}
+		log.Info().Str("server", server).Int("stages", len(stages)).Msg("dev seed fixtures loaded")
+	}
+	return servers, nil
+}
+
+// QueueReports queues the reports of fixtures on servers, as submitted by a single account created for them, and
+// returns ids of the report tasks queued.
+func (s *DevSeed) QueueReports(ctx context.Context, fixtures *devseed.FixtureSet, servers []string) ([]string, error) {
+	submitter := &ReportSubmitter{
+		IP:        "127.0.0.1",
+		UserAgent: devSeedSource,
+	}
+	submitter.OnAccountCreated = func(penguinId string) {
+		// later reports are submitted with the account created
+		submitter.PenguinID = penguinId
+	}
+
+	taskIds := make([]string, 0, len(servers)*len(fixtures.Reports))
+	for _, server := range servers {
+		for _, report := range fixtures.Reports {
+			submitter.RequestID = xid.New().String()
+			taskId, err := s.ReportService.QueueSingularReport(ctx, submitter, &types.SingleReportRequest{
+				FragmentStageID: report.FragmentStageID,
+				FragmentReportCommon: types.FragmentReportCommon{
+					Server:  server,
+					Source:  devSeedSource,
+					Version: bininfo.Version,
+				},
+				Drops: report.Drops,
+				Times: report.Times,
+			})
+			if err != nil {
+				return taskIds, errors.Wrapf(err, "failed to queue report of stage %s on server %s", report.StageID, server)
+			}
+			taskIds = append(taskIds, taskId)
+		}
+	}
+	return taskIds, nil
+}
@@ -2,20 +2,26 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/ahmetb/go-linq/v3"
+	"github.com/go-redis/redis/v8"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/guregu/null.v3"
 
+	"github.com/penguin-statistics/backend-next/internal/config"
 	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model"
 	"github.com/penguin-statistics/backend-next/internal/model/cache"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
 	"github.com/penguin-statistics/backend-next/internal/pkg/async"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
 	"github.com/penguin-statistics/backend-next/internal/util"
 )
 
@@ -28,7 +34,7 @@ This service has four functions:
 		c. apply shim for v2 (optional)
 
 	2. Get Personal Drop Matrix
-		a. calcDropMatrixForTimeRanges() to calc elements
+		a. calcDropMatrixForTimeRanges() to calc elements, cached by accountId until the account reports or recalls
 		b. convertDropMatrixElementsToMaxAccumulableDropMatrixQueryResult() to combine elements based max accumulable timeranges and convert to DropMatrixQueryResult
 		c. apply shim for v2 (optional)
 
@@ -42,6 +48,9 @@ This service has four functions:
 		b. save elements into DB
 */
 
+// dropMatrixWatermark is the name of the drop matrix in matrix watermarks
+const dropMatrixWatermark = "drop"
+
 type DropMatrix struct {
 	TimeRangeService         *TimeRange
 	DropReportService        *DropReport
@@ -49,6 +58,23 @@ type DropMatrix struct {
 	DropMatrixElementService *DropMatrixElement
 	StageService             *Stage
 	ItemService              *Item
+	MatrixWatermarkRepo      *repo.MatrixWatermark
+	CacheVersionService      *CacheVersion
+	Redis                    *redis.Client
+	Locker                   *dlock.Locker
+	// RuntimeConfig provides the low-sample threshold and mode in effect.
+	RuntimeConfig *RuntimeConfig
+
+	// RecentHalfLife and RecentWindow are the half-life of report weights and the duration of reports the recent
+	// drop matrix is calculated with.
+	RecentHalfLife time.Duration
+	RecentWindow   time.Duration
+	// SourceCategories are the source categories elements are calculated in by the matrix worker, which on-demand
+	// refreshes recalculate as well.
+	SourceCategories []string
+
+	// flight coalesces identical drop matrix calculations running concurrently
+	flight async.Flight[*model.DropMatrixQueryResult]
 }
 
 func NewDropMatrix(
@@ -58,6 +84,12 @@ func NewDropMatrix(
 	dropMatrixElementService *DropMatrixElement,
 	stageService *Stage,
 	itemService *Item,
+	matrixWatermarkRepo *repo.MatrixWatermark,
+	cacheVersionService *CacheVersion,
+	redisClient *redis.Client,
+	locker *dlock.Locker,
+	runtimeConfig *RuntimeConfig,
+	conf *config.Config,
 ) *DropMatrix {
 	return &DropMatrix{
 		TimeRangeService:         timeRangeService,
@@ -66,6 +98,14 @@ func NewDropMatrix(
 		DropMatrixElementService: dropMatrixElementService,
 		StageService:             stageService,
 		ItemService:              itemService,
+		MatrixWatermarkRepo:      matrixWatermarkRepo,
+		CacheVersionService:      cacheVersionService,
+		Redis:                    redisClient,
+		Locker:                   locker,
+		RuntimeConfig:            runtimeConfig,
+		RecentHalfLife:           conf.RecentMatrixHalfLife,
+		RecentWindow:             conf.RecentMatrixWindow,
+		SourceCategories:         conf.MatrixWorkerSourceCategories,
 	}
 }
 
@@ -73,8 +113,14 @@ func NewDropMatrix(
 func (s *DropMatrix) GetMaxAccumulableDropMatrixResults(
 	ctx context.Context, server string, stageFilterStr string, itemFilterStr string, accountId null.Int,
 ) (*modelv2.DropMatrixQueryResult, error) {
-	valueFunc := func() (*modelv2.DropMatrixQueryResult, error) {
-		savedDropMatrixResults, err := s.getMaxAccumulableDropMatrixResults(ctx, server, accountId, constant.SourceCategoryAll)
+	valueFunc := func(ctx context.Context) (*modelv2.DropMatrixQueryResult, error) {
+		var savedDropMatrixResults *model.DropMatrixQueryResult
+		var err error
+		if accountId.Valid {
+			savedDropMatrixResults, err = s.getPersonalMaxAccumulableDropMatrixResults(ctx, server, int(accountId.Int64), constant.SourceCategoryAll)
+		} else {
+			savedDropMatrixResults, err = s.getMaxAccumulableDropMatrixResults(ctx, server, accountId, constant.SourceCategoryAll)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -88,7 +134,7 @@ func (s *DropMatrix) GetMaxAccumulableDropMatrixResults(
 	var results modelv2.DropMatrixQueryResult
 	if !accountId.Valid && stageFilterStr == "" && itemFilterStr == "" {
 		key := server
-		calculated, err := cache.ShimMaxAccumulableDropMatrixResults.MutexGetSet(key, &results, valueFunc, 24*time.Hour)
+		calculated, err := cache.ShimMaxAccumulableDropMatrixResults.MutexGetSet(ctx, key, &results, valueFunc, 24*time.Hour)
 		if err != nil {
 			return nil, err
 		} else if calculated {
@@ -96,7 +142,7 @@ func (s *DropMatrix) GetMaxAccumulableDropMatrixResults(
 		}
 		return &results, nil
 	} else {
-		return valueFunc()
+		return valueFunc(ctx)
 	}
 }
 
@@ -104,8 +150,14 @@ func (s *DropMatrix) GetMaxAccumulableDropMatrixResults(
 func (s *DropMatrix) GetShimMaxAccumulableDropMatrixResults(
 	ctx context.Context, server string, showClosedZones bool, stageFilterStr string, itemFilterStr string, accountId null.Int,
 ) (*modelv2.DropMatrixQueryResult, error) {
-	valueFunc := func() (*modelv2.DropMatrixQueryResult, error) {
-		savedDropMatrixResults, err := s.getMaxAccumulableDropMatrixResults(ctx, server, accountId, constant.SourceCategoryAll)
+	valueFunc := func(ctx context.Context) (*modelv2.DropMatrixQueryResult, error) {
+		var savedDropMatrixResults *model.DropMatrixQueryResult
+		var err error
+		if accountId.Valid {
+			savedDropMatrixResults, err = s.getPersonalMaxAccumulableDropMatrixResults(ctx, server, int(accountId.Int64), constant.SourceCategoryAll)
+		} else {
+			savedDropMatrixResults, err = s.getMaxAccumulableDropMatrixResults(ctx, server, accountId, constant.SourceCategoryAll)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -119,7 +171,7 @@ func (s *DropMatrix) GetShimMaxAccumulableDropMatrixResults(
 	var results modelv2.DropMatrixQueryResult
 	if !accountId.Valid && stageFilterStr == "" && itemFilterStr == "" {
 		key := server + constant.CacheSep + strconv.FormatBool(showClosedZones)
-		calculated, err := cache.ShimMaxAccumulableDropMatrixResults.MutexGetSet(key, &results, valueFunc, 24*time.Hour)
+		calculated, err := cache.ShimMaxAccumulableDropMatrixResults.MutexGetSet(ctx, key, &results, valueFunc, 24*time.Hour)
 		if err != nil {
 			return nil, err
 		} else if calculated {
@@ -127,7 +179,7 @@ func (s *DropMatrix) GetShimMaxAccumulableDropMatrixResults(
 		}
 		return &results, nil
 	} else {
-		return valueFunc()
+		return valueFunc(ctx)
 	}
 }
 
@@ -141,7 +193,15 @@ func (s *DropMatrix) GetShimCustomizedDropMatrixResults(
 	return s.applyShimForDropMatrixQuery(ctx, server, true, "", "", customizedDropMatrixQueryResult)
 }
 
+// RefreshAllDropMatrixElements recalculates the whole drop matrix of server. Refreshes of the drop matrix of the
+// same server never run concurrently across instances.
 func (s *DropMatrix) RefreshAllDropMatrixElements(ctx context.Context, server string, sourceCategories []string) error {
+	return s.Locker.Do(ctx, constant.LockDropMatrix+server, func(ctx context.Context) error {
+		return s.refreshAllDropMatrixElements(ctx, server, sourceCategories)
+	})
+}
+
+func (s *DropMatrix) refreshAllDropMatrixElements(ctx context.Context, server string, sourceCategories []string) error {
 	allTimeRanges, err := s.TimeRangeService.GetTimeRangesByServer(ctx, server)
 	if err != nil {
 		return err
@@ -164,33 +224,233 @@ func (s *DropMatrix) RefreshAllDropMatrixElements(ctx context.Context, server st
 	if err := s.DropMatrixElementService.BatchSaveElements(ctx, elements, server); err != nil {
 		return err
 	}
-	if err := cache.ShimMaxAccumulableDropMatrixResults.Delete(server + constant.CacheSep + "true"); err != nil {
+	return s.CacheVersionService.Bump(ctx, constant.CacheVersionDropMatrix, server)
+}
+
+// RefreshDropMatrixElements refreshes the drop matrix of server incrementally: only elements of stages within time
+// ranges touched by reports submitted since the last refresh are recalculated. The whole matrix is recalculated
+// instead if it has never been, or if the nightly full refresh at fullRefreshHour (in UTC) is due, so that changes
+// not tracked by the watermark, such as recalls and drop info updates, are eventually included as well.
+// It returns whether any element has been recalculated.
+func (s *DropMatrix) RefreshDropMatrixElements(ctx context.Context, server string, sourceCategories []string, fullRefreshHour int) (refreshed bool, err error) {
+	err = s.Locker.Do(ctx, constant.LockDropMatrix+server, func(ctx context.Context) error {
+		refreshed, err = s.refreshDropMatrixElements(ctx, server, sourceCategories, fullRefreshHour)
 		return err
+	})
+	return refreshed, err
+}
+
+func (s *DropMatrix) refreshDropMatrixElements(ctx context.Context, server string, sourceCategories []string, fullRefreshHour int) (bool, error) {
+	// reports committed out of the order of their ids could be missed by the watermark, until the next full refresh
+	maxReportId, err := s.DropReportService.GetMaxReportId(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	watermark, err := s.MatrixWatermarkRepo.GetMatrixWatermark(ctx, dropMatrixWatermark, server)
+	if errors.Is(err, pgerr.ErrNotFound) || (err == nil && isFullRefreshDue(watermark.FullRefreshedAt, now, fullRefreshHour)) {
+		log.Info().Str("server", server).Msg("fully refreshing drop matrix")
+		if err := s.refreshAllDropMatrixElements(ctx, server, sourceCategories); err != nil {
+			return false, err
+		}
+		return true, s.MatrixWatermarkRepo.UpsertMatrixWatermark(ctx, &model.MatrixWatermark{
+			Matrix:          dropMatrixWatermark,
+			Server:          server,
+			ReportID:        maxReportId,
+			FullRefreshedAt: &now,
+			UpdatedAt:       &now,
+		})
+	} else if err != nil {
+		return false, err
+	}
+
+	touchedStages, err := s.DropReportService.GetTouchedStages(ctx, server, watermark.ReportID, maxReportId)
+	if err != nil {
+		return false, err
 	}
-	if err := cache.ShimMaxAccumulableDropMatrixResults.Delete(server + constant.CacheSep + "false"); err != nil {
+	refreshed := 0
+	if len(touchedStages) > 0 {
+		refreshed, err = s.refreshDropMatrixElementsForStages(ctx, server, sourceCategories, touchedStages, nil)
+		if err != nil {
+			return false, err
+		}
+		log.Info().
+			Str("server", server).
+			Int("stages", len(touchedStages)).
+			Int("timeRanges", refreshed).
+			Msg("incrementally refreshed drop matrix")
+	}
+
+	watermark.ReportID = maxReportId
+	watermark.UpdatedAt = &now
+	return refreshed > 0, s.MatrixWatermarkRepo.UpsertMatrixWatermark(ctx, watermark)
+}
+
+// RefreshDropMatrixElementsForStage recalculates elements of stageId within time ranges of server overlapping
+// [start, end), and reports progress with onProgress after each time range, if not nil.
+func (s *DropMatrix) RefreshDropMatrixElementsForStage(
+	ctx context.Context, server string, stageId int, start, end time.Time, sourceCategories []string, onProgress func(done, total int),
+) error {
+	return s.Locker.Do(ctx, constant.LockDropMatrix+server, func(ctx context.Context) error {
+		_, err := s.refreshDropMatrixElementsForStages(ctx, server, sourceCategories, []*model.TouchedStage{{
+			StageID:      stageId,
+			MinCreatedAt: start,
+			MaxCreatedAt: end.Add(-time.Nanosecond),
+		}}, onProgress)
 		return err
+	})
+}
+
+// refreshDropMatrixElementsForStages recalculates elements of touchedStages within time ranges their reports fall in,
+// and returns the number of time ranges refreshed. onProgress, if not nil, is called after each time range.
+func (s *DropMatrix) refreshDropMatrixElementsForStages(
+	ctx context.Context, server string, sourceCategories []string, touchedStages []*model.TouchedStage, onProgress func(done, total int),
+) (int, error) {
+	timeRanges, err := s.TimeRangeService.GetTimeRangesByServer(ctx, server)
+	if err != nil {
+		return 0, err
+	}
+
+	// stage ids touched within each time range, in the order of timeRanges
+	type touchedTimeRange struct {
+		timeRange *model.TimeRange
+		stageIds  []int
+	}
+	touchedTimeRanges := make([]touchedTimeRange, 0)
+	for _, timeRange := range timeRanges {
+		stageIds := make([]int, 0)
+		for _, stage := range touchedStages {
+			if stage.MinCreatedAt.Before(*timeRange.EndTime) && !stage.MaxCreatedAt.Before(*timeRange.StartTime) {
+				stageIds = append(stageIds, stage.StageID)
+			}
+		}
+		if len(stageIds) > 0 {
+			touchedTimeRanges = append(touchedTimeRanges, touchedTimeRange{timeRange: timeRange, stageIds: stageIds})
+		}
+	}
+
+	for i, touched := range touchedTimeRanges {
+		if err := s.replaceElementsForStages(ctx, server, touched.timeRange, touched.stageIds, sourceCategories); err != nil {
+			return 0, err
+		}
+		if onProgress != nil {
+			onProgress(i+1, len(touchedTimeRanges))
+		}
+	}
+	if len(touchedTimeRanges) == 0 {
+		return 0, nil
+	}
+	return len(touchedTimeRanges), s.CacheVersionService.Bump(ctx, constant.CacheVersionDropMatrix, server)
+}
+
+// RefreshDropMatrixElementsForSplit recalculates elements of stageId within both time ranges of split, as saved
+// rather than as cached, so that the refresh does not race invalidations of cached time ranges. Elements of the stage
+// left behind in the time range vacated by the split are deleted.
+func (s *DropMatrix) RefreshDropMatrixElementsForSplit(
+	ctx context.Context, server string, stageId int, split *model.TimeRangeSplit, sourceCategories []string, onProgress func(done, total int),
+) error {
+	return s.Locker.Do(ctx, constant.LockDropMatrix+server, func(ctx context.Context) error {
+		// copies, as calculations cap end times of time ranges at the time being
+		timeRanges := []model.TimeRange{*split.Before, *split.After}
+		for i := range timeRanges {
+			if err := s.replaceElementsForStages(ctx, server, &timeRanges[i], []int{stageId}, sourceCategories); err != nil {
+				return err
+			}
+			if onProgress != nil {
+				onProgress(i+1, len(timeRanges))
+			}
+		}
+		if split.VacatedRangeID != 0 {
+			if err := s.DropMatrixElementService.ReplaceElementsForStages(ctx, nil, server, split.VacatedRangeID, []int{stageId}, sourceCategories); err != nil {
+				return err
+			}
+		}
+		return s.CacheVersionService.Bump(ctx, constant.CacheVersionDropMatrix, server)
+	})
+}
+
+// replaceElementsForStages recalculates elements of stageIds in sourceCategories within timeRange, and replaces
+// elements saved with them.
+func (s *DropMatrix) replaceElementsForStages(ctx context.Context, server string, timeRange *model.TimeRange, stageIds []int, sourceCategories []string) error {
+	elements := make([]*model.DropMatrixElement, 0)
+	for _, sourceCategory := range sourceCategories {
+		results, err := s.calcDropMatrixForTimeRanges(ctx, server, []*model.TimeRange{timeRange}, stageIds, nil, null.NewInt(0, false), sourceCategory)
+		if err != nil {
+			return err
+		}
+		elements = append(elements, results...)
 	}
-	return nil
+	return s.DropMatrixElementService.ReplaceElementsForStages(ctx, elements, server, timeRange.RangeID, stageIds, sourceCategories)
 }
 
-// calc DropMatrixQueryResult for customized conditions
+// isFullRefreshDue returns whether fullRefreshHour (in UTC) has come since lastFullRefresh.
+func isFullRefreshDue(lastFullRefresh *time.Time, now time.Time, fullRefreshHour int) bool {
+	if lastFullRefresh == nil {
+		return true
+	}
+	last := lastFullRefresh.UTC()
+	next := time.Date(last.Year(), last.Month(), last.Day(), fullRefreshHour, 0, 0, 0, time.UTC)
+	if !next.After(last) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return !now.Before(next)
+}
+
+// calc DropMatrixQueryResult for customized conditions, coalescing identical queries running concurrently
 func (s *DropMatrix) QueryDropMatrix(
 	ctx context.Context, server string, timeRanges []*model.TimeRange, stageIdFilter []int, itemIdFilter []int, accountId null.Int, sourceCategory string,
 ) (*model.DropMatrixQueryResult, error) {
-	dropMatrixElements, err := s.calcDropMatrixForTimeRanges(ctx, server, timeRanges, stageIdFilter, itemIdFilter, accountId, sourceCategory)
-	if err != nil {
-		return nil, err
+	timeRangeStrs := make([]string, 0, len(timeRanges))
+	for _, timeRange := range timeRanges {
+		timeRangeStrs = append(timeRangeStrs, timeRange.String())
 	}
-	return s.convertDropMatrixElementsToDropMatrixQueryResult(ctx, dropMatrixElements)
+	key := strings.Join([]string{
+		"query", server, strings.Join(timeRangeStrs, ","), fmt.Sprint(stageIdFilter), fmt.Sprint(itemIdFilter), accountIdKey(accountId), sourceCategory,
+	}, constant.CacheSep)
+	return s.flight.Do(key, func() (*model.DropMatrixQueryResult, error) {
+		dropMatrixElements, err := s.calcDropMatrixForTimeRanges(ctx, server, timeRanges, stageIdFilter, itemIdFilter, accountId, sourceCategory)
+		if err != nil {
+			return nil, err
+		}
+		return s.convertDropMatrixElementsToDropMatrixQueryResult(ctx, dropMatrixElements)
+	})
 }
 
-// calc DropMatrixQueryResult for max accumulable timeranges
+// calc DropMatrixQueryResult for max accumulable timeranges, coalescing identical calculations running concurrently
 func (s *DropMatrix) getMaxAccumulableDropMatrixResults(ctx context.Context, server string, accountId null.Int, sourceCategory string) (*model.DropMatrixQueryResult, error) {
-	dropMatrixElements, err := s.getDropMatrixElements(ctx, server, accountId, sourceCategory)
+	key := strings.Join([]string{"maxAccumulable", server, accountIdKey(accountId), sourceCategory}, constant.CacheSep)
+	return s.flight.Do(key, func() (*model.DropMatrixQueryResult, error) {
+		dropMatrixElements, err := s.getDropMatrixElements(ctx, server, accountId, sourceCategory)
+		if err != nil {
+			return nil, err
+		}
+		return s.convertDropMatrixElementsToMaxAccumulableDropMatrixQueryResult(ctx, server, dropMatrixElements)
+	})
+}
+
+// getPersonalMaxAccumulableDropMatrixResults returns the personal drop matrix of accountId, cached until the account
+// submits or recalls a report.
+//
+// Cache: (tiered) personalDropMatrixResults#accountId|version|server:{accountId}|{version}|{server}, 10 mins
+// in-process, 1 hr in Redis
+func (s *DropMatrix) getPersonalMaxAccumulableDropMatrixResults(ctx context.Context, server string, accountId int, sourceCategory string) (*model.DropMatrixQueryResult, error) {
+	version, err := s.CacheVersionService.Version(ctx, constant.CacheVersionPersonal, strconv.Itoa(accountId))
+	if err != nil {
+		return nil, err
+	}
+	key := strings.Join([]string{strconv.Itoa(accountId), strconv.FormatInt(version, 10), server}, constant.CacheSep)
+	results, err := cache.PersonalDropMatrixResults.GetSet(ctx, key, func(ctx context.Context) (model.DropMatrixQueryResult, error) {
+		results, err := s.getMaxAccumulableDropMatrixResults(ctx, server, null.IntFrom(int64(accountId)), sourceCategory)
+		if err != nil {
+			return model.DropMatrixQueryResult{}, err
+		}
+		return *results, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return s.convertDropMatrixElementsToMaxAccumulableDropMatrixQueryResult(ctx, server, dropMatrixElements)
+	return &results, nil
 }
 
 // For global, get elements from DB; For personal, calc elements
@@ -259,6 +519,9 @@ func (s *DropMatrix) calcDropMatrixForTimeRanges(
 		combinedResults = append(combinedResults, oneBatch...)
 	}
 
+	// fixed-rate drops are deterministic, so their quantity is derived from times instead of reported quantities
+	fixedQuantityMap := util.GetFixedQuantityMapFromDropInfos(dropInfos)
+
 	// save stage times for later use
 	stageTimesMap := map[int]int{}
 
@@ -314,6 +577,10 @@ func (s *DropMatrix) calcDropMatrixForTimeRanges(
 				quantity := el3.(*model.CombinedResultForDropMatrix).Quantity
 				times := el3.(*model.CombinedResultForDropMatrix).Times
 				quantityBuckets := el3.(*model.CombinedResultForDropMatrix).QuantityBuckets
+				if fixedQuantity, ok := fixedQuantityMap[stageId][itemId]; ok {
+					quantity = fixedQuantity * times
+					quantityBuckets = map[int]int{fixedQuantity: times}
+				}
 				dropMatrixElement := model.DropMatrixElement{
 					StageID:         stageId,
 					ItemID:          itemId,
@@ -334,12 +601,17 @@ func (s *DropMatrix) calcDropMatrixForTimeRanges(
 			// add those items which do not show up in the matrix (quantity is 0)
 			for itemId := range dropSet {
 				times := stageTimesMap[stageId]
+				quantity, quantityBuckets := 0, map[int]int{0: times}
+				if fixedQuantity, ok := fixedQuantityMap[stageId][itemId]; ok {
+					quantity = fixedQuantity * times
+					quantityBuckets = map[int]int{fixedQuantity: times}
+				}
 				dropMatrixElementWithZeroQuantity := model.DropMatrixElement{
 					StageID:         stageId,
 					ItemID:          itemId,
 					RangeID:         rangeId,
-					Quantity:        0,
-					QuantityBuckets: map[int]int{0: times},
+					Quantity:        quantity,
+					QuantityBuckets: quantityBuckets,
 					Times:           times,
 					Server:          server,
 					SourceCategory:  sourceCategory,
@@ -647,6 +919,50 @@ func (s *DropMatrix) applyShimForDropMatrixQuery(ctx context.Context, server str
 	return results, nil
 }
 
+// LowSampleKey returns the key of how GateLowSample treats elements by mode, which depends on config.Config
+// MatrixLowSampleThreshold and MatrixLowSampleMode at runtime, for keys of responses gated by GateLowSample.
+func (s *DropMatrix) LowSampleKey(mode string) string {
+	conf := s.RuntimeConfig.Current()
+	if conf.MatrixLowSampleThreshold <= 0 {
+		return "off"
+	}
+	if mode == "" {
+		mode = conf.MatrixLowSampleMode
+	}
+	return mode + "@" + strconv.Itoa(conf.MatrixLowSampleThreshold)
+}
+
+// GateLowSample returns result with elements sampled fewer than config.Config MatrixLowSampleThreshold times treated
+// by mode, which defaults to config.Config MatrixLowSampleMode when empty. result is left as is, as it could be shared
+// by the cache.
+func (s *DropMatrix) GateLowSample(result *modelv2.DropMatrixQueryResult, mode string) *modelv2.DropMatrixQueryResult {
+	conf := s.RuntimeConfig.Current()
+	threshold := conf.MatrixLowSampleThreshold
+	if threshold <= 0 {
+		return result
+	}
+	if mode == "" {
+		mode = conf.MatrixLowSampleMode
+	}
+
+	gated := &modelv2.DropMatrixQueryResult{
+		Matrix: make([]*modelv2.OneDropMatrixElement, 0, len(result.Matrix)),
+	}
+	for _, el := range result.Matrix {
+		if el.Times >= threshold {
+			gated.Matrix = append(gated.Matrix, el)
+			continue
+		}
+		if mode == constant.LowSampleModeExclude {
+			continue
+		}
+		flagged := *el
+		flagged.LowSample = true
+		gated.Matrix = append(gated.Matrix, &flagged)
+	}
+	return gated
+}
+
 func (s *DropMatrix) convertOneDropMatrixElementToStatsBundle(el *model.OneDropMatrixElement) (*util.StatsBundle, error) {
 	if el.Times == 0 {
 		return nil, errors.New("times should not be 0")
@@ -665,3 +981,11 @@ func (s *DropMatrix) validateQuantityBucketsAndTimes(quantityBuckets map[int]int
 	}
 	return sum <= times
 }
+
+// accountIdKey returns accountId as a part of keys of coalesced calculations, which is empty for global ones.
+func accountIdKey(accountId null.Int) string {
+	if !accountId.Valid {
+		return ""
+	}
+	return strconv.FormatInt(accountId.Int64, 10)
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+)
+
+// CompareServers returns the max accumulable drop matrix element of arkStageId and arkItemId on each server, from
+// the drop matrix elements of all servers fetched at once. Servers the item has never been reported to drop from the
+// stage on are left out.
+func (s *DropMatrix) CompareServers(ctx context.Context, arkStageId string, arkItemId string) (*modelv2.ServerComparison, error) {
+	stage, err := s.StageService.GetStageByArkId(ctx, arkStageId)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.ItemService.GetItemByArkId(ctx, arkItemId)
+	if err != nil {
+		return nil, err
+	}
+
+	elements, err := s.DropMatrixElementService.GetElementsByStageAndItem(ctx, stage.StageID, item.ItemID, constant.SourceCategoryAll)
+	if err != nil {
+		return nil, err
+	}
+	elementsByServer := make(map[string][]*model.DropMatrixElement)
+	for _, element := range elements {
+		elementsByServer[element.Server] = append(elementsByServer[element.Server], element)
+	}
+
+	comparison := &modelv2.ServerComparison{
+		StageID: stage.ArkStageID,
+		ItemID:  item.ArkItemID,
+		Servers: make([]*modelv2.ServerComparisonElement, 0, len(constant.Servers)),
+	}
+	for _, server := range constant.Servers {
+		serverElements, ok := elementsByServer[server]
+		if !ok {
+			continue
+		}
+		// time ranges of the element are combined just like in the drop matrix of the server
+		result, err := s.convertDropMatrixElementsToMaxAccumulableDropMatrixQueryResult(ctx, server, serverElements)
+		if err != nil {
+			return nil, err
+		}
+		for _, el := range result.Matrix {
+			if el.StageID != stage.StageID || el.ItemID != item.ItemID || el.Times == 0 {
+				continue
+			}
+			comparison.Servers = append(comparison.Servers, newServerComparisonElement(server, el))
+		}
+	}
+	return comparison, nil
+}
+
+// newServerComparisonElement converts el of server, with the confidence interval of its rate approximated by the
+// normal distribution of the mean quantity per run.
+func newServerComparisonElement(server string, el *model.OneDropMatrixElement) *modelv2.ServerComparisonElement {
+	rate, lower, upper := normalRateInterval(el.Quantity, el.Times, el.StdDev)
+	comparisonElement := &modelv2.ServerComparisonElement{
+		Server:    server,
+		Times:     el.Times,
+		Quantity:  el.Quantity,
+		Rate:      rate,
+		Lower:     lower,
+		Upper:     upper,
+		StartTime: el.TimeRange.StartTime.UnixMilli(),
+		EndTime:   null.NewInt(el.TimeRange.EndTime.UnixMilli(), true),
+	}
+	if comparisonElement.EndTime.Int64 == constant.FakeEndTimeMilli {
+		comparisonElement.EndTime = null.NewInt(0, false)
+	}
+	return comparisonElement
+}
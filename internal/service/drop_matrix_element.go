@@ -21,6 +21,10 @@ func (s *DropMatrixElement) BatchSaveElements(ctx context.Context, elements []*m
 	return s.DropMatrixElementRepo.BatchSaveElements(ctx, elements, server)
 }
 
+func (s *DropMatrixElement) ReplaceElementsForStages(ctx context.Context, elements []*model.DropMatrixElement, server string, rangeId int, stageIds []int, sourceCategories []string) error {
+	return s.DropMatrixElementRepo.ReplaceElementsForStages(ctx, elements, server, rangeId, stageIds, sourceCategories)
+}
+
 func (s *DropMatrixElement) DeleteByServer(ctx context.Context, server string) error {
 	return s.DropMatrixElementRepo.DeleteByServer(ctx, server)
 }
@@ -28,3 +32,7 @@ func (s *DropMatrixElement) DeleteByServer(ctx context.Context, server string) e
 func (s *DropMatrixElement) GetElementsByServerAndSourceCategory(ctx context.Context, server string, sourceCategory string) ([]*model.DropMatrixElement, error) {
 	return s.DropMatrixElementRepo.GetElementsByServerAndSourceCategory(ctx, server, sourceCategory)
 }
+
+func (s *DropMatrixElement) GetElementsByStageAndItem(ctx context.Context, stageId int, itemId int, sourceCategory string) ([]*model.DropMatrixElement, error) {
+	return s.DropMatrixElementRepo.GetElementsByStageAndItem(ctx, stageId, itemId, sourceCategory)
+}
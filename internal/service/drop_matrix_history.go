@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// DropMatrixHistory keeps daily snapshots of the drop matrix of every server, so that drop rates could be looked up
+// as they have been on a past date.
+type DropMatrixHistory struct {
+	DropMatrixService      *DropMatrix
+	DropMatrixSnapshotRepo *repo.DropMatrixSnapshot
+	RuntimeConfig          *RuntimeConfig
+}
+
+func NewDropMatrixHistory(dropMatrixService *DropMatrix, dropMatrixSnapshotRepo *repo.DropMatrixSnapshot, runtimeConfig *RuntimeConfig) *DropMatrixHistory {
+	return &DropMatrixHistory{
+		DropMatrixService:      dropMatrixService,
+		DropMatrixSnapshotRepo: dropMatrixSnapshotRepo,
+		RuntimeConfig:          runtimeConfig,
+	}
+}
+
+// TakeSnapshot snapshots the drop matrix of server, including closed zones, as it is served at the moment. Taking
+// a snapshot again on the same UTC date replaces the former one.
+func (s *DropMatrixHistory) TakeSnapshot(ctx context.Context, server string) error {
+	result, err := s.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx, server, true, "", "", null.NewInt(0, false))
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(result.Matrix); err != nil {
+		return errors.Wrap(err, "failed to encode drop matrix snapshot")
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	snapshot := &model.DropMatrixSnapshot{
+		Server:    server,
+		Date:      now.Truncate(time.Hour * 24),
+		Elements:  len(result.Matrix),
+		Payload:   buf.Bytes(),
+		CreatedAt: &now,
+	}
+	if err := s.DropMatrixSnapshotRepo.SaveDropMatrixSnapshot(ctx, snapshot); err != nil {
+		return err
+	}
+	log.Info().
+		Str("server", server).
+		Int("elements", snapshot.Elements).
+		Int("size", len(snapshot.Payload)).
+		Msg("drop matrix snapshot saved")
+	return nil
+}
+
+// PruneSnapshots deletes snapshots older than config.Config MatrixSnapshotRetention.
+func (s *DropMatrixHistory) PruneSnapshots(ctx context.Context) error {
+	retention := s.RuntimeConfig.Current().MatrixSnapshotRetention
+	if retention <= 0 {
+		return nil
+	}
+
+	deleted, err := s.DropMatrixSnapshotRepo.DeleteDropMatrixSnapshotsBefore(ctx, time.Now().UTC().Add(-retention).Truncate(time.Hour*24))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Info().Int64("snapshots", deleted).Dur("retention", retention).Msg("expired drop matrix snapshots deleted")
+	}
+	return nil
+}
+
+// GetDropMatrixHistory returns the drop matrix of server of the latest snapshot taken on or before date, which is
+// a UTC date. The date is resolved to its snapshot on every call, so that a snapshot taken since is responded right
+// away, while the matrix of the snapshot is cached.
+//
+// Cache: dropMatrixHistoryResults#snapshotId|createdAt:{snapshotId}|{createdAt}, 24 hrs, as snapshots are only
+// replaced by snapshots taken later on the same date, which are created at a different time
+func (s *DropMatrixHistory) GetDropMatrixHistory(ctx context.Context, server string, date time.Time) (*modelv2.DropMatrixHistoryResult, error) {
+	snapshot, err := s.DropMatrixSnapshotRepo.GetLatestDropMatrixSnapshot(ctx, server, date)
+	if err != nil {
+		return nil, err
+	}
+
+	valueFunc := func(ctx context.Context) (*modelv2.DropMatrixHistoryResult, error) {
+		payload, err := s.DropMatrixSnapshotRepo.GetDropMatrixSnapshotPayload(ctx, snapshot.SnapshotID)
+		if err != nil {
+			return nil, err
+		}
+
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid payload of drop matrix snapshot %d", snapshot.SnapshotID)
+		}
+		defer gz.Close()
+
+		result := &modelv2.DropMatrixHistoryResult{
+			Server: server,
+			Date:   snapshot.Date.Format(constant.DateLayout),
+		}
+		if snapshot.CreatedAt != nil {
+			result.SnapshotAt = snapshot.CreatedAt.UnixMilli()
+		}
+		if err := json.NewDecoder(gz).Decode(&result.Matrix); err != nil {
+			return nil, errors.Wrapf(err, "invalid payload of drop matrix snapshot %d", snapshot.SnapshotID)
+		}
+		return result, nil
+	}
+
+	var createdAt int64
+	if snapshot.CreatedAt != nil {
+		createdAt = snapshot.CreatedAt.UnixMilli()
+	}
+	var result modelv2.DropMatrixHistoryResult
+	key := strconv.Itoa(snapshot.SnapshotID) + constant.CacheSep + strconv.FormatInt(createdAt, 10)
+	if _, err := cache.DropMatrixHistoryResults.MutexGetSet(ctx, key, &result, valueFunc, time.Hour*24); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+// GetItemDropStages returns the stages the item drops from on server, with their drop rates and sanity costs, as
+// they are in the drop matrix of server. Elements sampled too few times are treated by lowSample, just like in the
+// drop matrix.
+func (s *DropMatrix) GetItemDropStages(ctx context.Context, server string, arkItemId string, showClosedZones bool, lowSample string) (*modelv2.ItemDropStages, error) {
+	item, err := s.ItemService.GetItemByArkId(ctx, arkItemId)
+	if err != nil {
+		return nil, err
+	}
+	// the whole drop matrix is cached, so it is filtered here rather than queried with an item filter
+	result, err := s.GetShimMaxAccumulableDropMatrixResults(ctx, server, showClosedZones, "", "", null.NewInt(0, false))
+	if err != nil {
+		return nil, err
+	}
+	result = s.GateLowSample(result, lowSample)
+	return s.itemDropStagesOf(ctx, server, item.ArkItemID, result)
+}
+
+// itemDropStagesOf returns the stages the item of arkItemId drops from according to result, which is a drop matrix
+// of server, ordered by stage ids.
+func (s *DropMatrix) itemDropStagesOf(ctx context.Context, server string, arkItemId string, result *modelv2.DropMatrixQueryResult) (*modelv2.ItemDropStages, error) {
+	stagesMap, err := s.StageService.GetStagesMapByArkId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	itemDropStages := &modelv2.ItemDropStages{
+		ItemID: arkItemId,
+		Server: server,
+		Stages: make([]*modelv2.ItemDropStage, 0),
+	}
+	for _, el := range result.Matrix {
+		if el.ItemID != arkItemId || el.Times == 0 {
+			continue
+		}
+		itemDropStage := newItemDropStage(el)
+		if stage, ok := stagesMap[el.StageID]; ok && stage.Sanity.Valid {
+			itemDropStage.ApCost = stage.Sanity
+		}
+		if itemDropStage.ApCost.Valid && el.Quantity > 0 {
+			itemDropStage.ApPerItem = null.FloatFrom(util.RoundFloat64(float64(itemDropStage.ApCost.Int64)*float64(el.Times)/float64(el.Quantity), constant.StdDevDigits))
+		}
+		itemDropStages.Stages = append(itemDropStages.Stages, itemDropStage)
+	}
+	sort.SliceStable(itemDropStages.Stages, func(i, j int) bool {
+		return itemDropStages.Stages[i].StageID < itemDropStages.Stages[j].StageID
+	})
+	return itemDropStages, nil
+}
+
+// newItemDropStage converts el, with the confidence interval of its rate approximated by the normal distribution of
+// the mean quantity per run.
+func newItemDropStage(el *modelv2.OneDropMatrixElement) *modelv2.ItemDropStage {
+	rate, lower, upper := normalRateInterval(el.Quantity, el.Times, el.StdDev)
+	return &modelv2.ItemDropStage{
+		StageID:   el.StageID,
+		Times:     el.Times,
+		Quantity:  el.Quantity,
+		Rate:      rate,
+		Lower:     lower,
+		Upper:     upper,
+		StartTime: el.StartTime,
+		EndTime:   el.EndTime,
+		LowSample: el.LowSample,
+	}
+}
+
+// normalRateInterval returns the rate of quantity dropped out of times runs, along with its confidence interval
+// approximated by the normal distribution of the mean quantity per run of standard deviation stdDev, all rounded for
+// responses. times must be positive.
+func normalRateInterval(quantity, times int, stdDev float64) (rate, lower, upper float64) {
+	rate = float64(quantity) / float64(times)
+	halfWidth := constant.ConfidenceZ * stdDev / math.Sqrt(float64(times))
+	return util.RoundFloat64(rate, constant.StdDevDigits),
+		util.RoundFloat64(math.Max(0, rate-halfWidth), constant.ConfidenceBoundDigits),
+		util.RoundFloat64(rate+halfWidth, constant.ConfidenceBoundDigits)
+}
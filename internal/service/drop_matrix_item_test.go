@@ -0,0 +1,23 @@
+package service
+
+import "testing"
+
+func TestNormalRateInterval(t *testing.T) {
+	tests := []struct {
+		quantity, times    int
+		stdDev             float64
+		rate, lower, upper float64
+	}{
+		{50, 100, 0.5, 0.5, 0.402, 0.598},
+		{0, 10, 0, 0, 0, 0},
+		{1, 4, 0.5, 0.25, 0, 0.74},
+		{300, 100, 1, 3, 2.804, 3.196},
+	}
+	for _, test := range tests {
+		rate, lower, upper := normalRateInterval(test.quantity, test.times, test.stdDev)
+		if rate != test.rate || lower != test.lower || upper != test.upper {
+			t.Errorf("normalRateInterval(%d, %d, %v): expected %v [%v, %v], got %v [%v, %v]",
+				test.quantity, test.times, test.stdDev, test.rate, test.lower, test.upper, rate, lower, upper)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+/*
+The recent drop matrix weights each report by its age, halving the weight every RecentHalfLife, so that drop rates
+follow changes of drop rates shortly after they happen instead of being diluted by the whole time range. Only items
+currently dropping from stages currently open are calculated, and `times` and `quantity` of its elements are the
+effective, weighted counts rounded to integers.
+*/
+
+// GetShimRecentDropMatrixResults returns the recent drop matrix of server.
+func (s *DropMatrix) GetShimRecentDropMatrixResults(ctx context.Context, server string, stageFilterStr string, itemFilterStr string) (*modelv2.DropMatrixQueryResult, error) {
+	results, err := s.getRecentDropMatrixResults(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	return s.applyShimForDropMatrixQuery(ctx, server, true, stageFilterStr, itemFilterStr, results)
+}
+
+// RefreshRecentDropMatrix recalculates the recent drop matrix of server and saves it to Redis, dropping the recent
+// drop matrix of server cached by every instance, which then load the saved one instead of recalculating it.
+func (s *DropMatrix) RefreshRecentDropMatrix(ctx context.Context, server string) error {
+	return s.Locker.Do(ctx, constant.LockRecentDropMatrix+server, func(ctx context.Context) error {
+		results, err := s.calcRecentDropMatrix(ctx, server, time.Now())
+		if err != nil {
+			return err
+		}
+		resultsJSON, err := json.Marshal(results)
+		if err != nil {
+			return err
+		}
+		if err := s.Redis.Set(ctx, constant.RecentDropMatrixKeyPrefix+server, resultsJSON, 0).Err(); err != nil {
+			return errors.Wrapf(err, "failed to save recent drop matrix of %s", server)
+		}
+		return s.CacheVersionService.Bump(ctx, constant.CacheVersionRecentDropMatrix, server)
+	})
+}
+
+// getRecentDropMatrixResults returns the recent drop matrix of server saved by RefreshRecentDropMatrix, or an empty
+// matrix if it has never been calculated.
+//
+// Cache: recentDropMatrixResults#server:{server}, 24 hrs, records last modified time
+func (s *DropMatrix) getRecentDropMatrixResults(ctx context.Context, server string) (*model.DropMatrixQueryResult, error) {
+	valueFunc := func(ctx context.Context) (*model.DropMatrixQueryResult, error) {
+		results := &model.DropMatrixQueryResult{
+			Matrix: make([]*model.OneDropMatrixElement, 0),
+		}
+		resultsJSON, err := s.Redis.Get(ctx, constant.RecentDropMatrixKeyPrefix+server).Bytes()
+		if errors.Is(err, redis.Nil) {
+			return results, nil
+		} else if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(resultsJSON, results); err != nil {
+			return nil, errors.Wrapf(err, "invalid recent drop matrix saved of %s", server)
+		}
+		return results, nil
+	}
+
+	var results model.DropMatrixQueryResult
+	calculated, err := cache.RecentDropMatrixResults.MutexGetSet(ctx, server, &results, valueFunc, 24*time.Hour)
+	if err != nil {
+		return nil, err
+	} else if calculated {
+		cache.LastModifiedTime.Set("[recentDropMatrixResults#server:"+server+"]", time.Now(), 0)
+	}
+	return &results, nil
+}
+
+func (s *DropMatrix) calcRecentDropMatrix(ctx context.Context, server string, now time.Time) (*model.DropMatrixQueryResult, error) {
+	since := now.Add(-s.RecentWindow)
+
+	currentDropInfos, err := s.DropInfoService.GetCurrentDropInfosByServer(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	stageIdItemIdMap := make(map[int][]int)
+	for _, dropInfo := range currentDropInfos {
+		if !dropInfo.ItemID.Valid {
+			continue
+		}
+		stageIdItemIdMap[dropInfo.StageID] = append(stageIdItemIdMap[dropInfo.StageID], int(dropInfo.ItemID.Int64))
+	}
+	stageIds := make([]int, 0, len(stageIdItemIdMap))
+	for stageId := range stageIdItemIdMap {
+		stageIds = append(stageIds, stageId)
+	}
+
+	timesResults, err := s.DropReportService.CalcWeightedTimesForRecentDropMatrix(ctx, server, stageIds, since, now, s.RecentHalfLife)
+	if err != nil {
+		return nil, err
+	}
+	quantityResults, err := s.DropReportService.CalcWeightedQuantityForRecentDropMatrix(ctx, server, stageIdItemIdMap, since, now, s.RecentHalfLife)
+	if err != nil {
+		return nil, err
+	}
+
+	quantityResultsMap := make(map[int]map[int]*model.WeightedQuantityResult)
+	for _, el := range quantityResults {
+		if _, ok := quantityResultsMap[el.StageID]; !ok {
+			quantityResultsMap[el.StageID] = make(map[int]*model.WeightedQuantityResult)
+		}
+		quantityResultsMap[el.StageID][el.ItemID] = el
+	}
+
+	endTime := time.UnixMilli(constant.FakeEndTimeMilli)
+	timeRange := &model.TimeRange{StartTime: &since, EndTime: &endTime}
+	results := &model.DropMatrixQueryResult{
+		Matrix: make([]*model.OneDropMatrixElement, 0),
+	}
+	for _, timesResult := range timesResults {
+		// stages with barely any recent reports would have no effective times at all
+		if math.Round(timesResult.WeightedTimes) < 1 {
+			continue
+		}
+		// items are deduplicated, as an item could be dropped as multiple drop types
+		seen := make(map[int]struct{})
+		for _, itemId := range stageIdItemIdMap[timesResult.StageID] {
+			if _, ok := seen[itemId]; ok {
+				continue
+			}
+			seen[itemId] = struct{}{}
+
+			var quantity, quantitySquared float64
+			if el, ok := quantityResultsMap[timesResult.StageID][itemId]; ok {
+				quantity, quantitySquared = el.WeightedQuantity, el.WeightedQuantitySquared
+			}
+			mean := quantity / timesResult.WeightedTimes
+			variance := quantitySquared/timesResult.WeightedTimes - mean*mean
+			results.Matrix = append(results.Matrix, &model.OneDropMatrixElement{
+				StageID:   timesResult.StageID,
+				ItemID:    itemId,
+				Times:     int(math.Round(timesResult.WeightedTimes)),
+				Quantity:  int(math.Round(quantity)),
+				StdDev:    util.RoundFloat64(math.Sqrt(math.Max(variance, 0)), constant.StdDevDigits),
+				TimeRange: timeRange,
+			})
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsFullRefreshDue(t *testing.T) {
+	at := func(day, hour, minute int) *time.Time {
+		t := time.Date(2022, 6, day, hour, minute, 0, 0, time.UTC)
+		return &t
+	}
+	const fullRefreshHour = 4
+	tests := []struct {
+		lastFullRefresh *time.Time
+		now             time.Time
+		expected        bool
+	}{
+		{nil, *at(15, 10, 0), true},
+		// refreshed before the hour of the same day, which has not come yet
+		{at(15, 1, 0), *at(15, 3, 59), false},
+		{at(15, 1, 0), *at(15, 4, 0), true},
+		// refreshed at or after the hour, which comes again on the next day
+		{at(15, 4, 0), *at(15, 23, 0), false},
+		{at(15, 4, 0), *at(16, 4, 0), true},
+		{at(15, 10, 0), *at(16, 3, 0), false},
+		{at(15, 10, 0), *at(17, 0, 0), true},
+	}
+	for _, test := range tests {
+		if due := isFullRefreshDue(test.lastFullRefresh, test.now, fullRefreshHour); due != test.expected {
+			t.Errorf("isFullRefreshDue(%v, %v, %d): expected %v, got %v", test.lastFullRefresh, test.now, fullRefreshHour, test.expected, due)
+		}
+	}
+
+	// times are compared in UTC regardless of their locations
+	cst := time.FixedZone("UTC+8", 8*60*60)
+	last := time.Date(2022, 6, 15, 11, 0, 0, 0, cst) // 03:00 UTC
+	if !isFullRefreshDue(&last, time.Date(2022, 6, 15, 12, 0, 0, 0, cst), fullRefreshHour) {
+		t.Errorf("Expected full refresh due at 04:00 UTC, got not due")
+	}
+}
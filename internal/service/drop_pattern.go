@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/util/reportutil"
+)
+
+type DropPattern struct {
+	DropPatternRepo        *repo.DropPattern
+	DropPatternElementRepo *repo.DropPatternElement
+}
+
+func NewDropPattern(dropPatternRepo *repo.DropPattern, dropPatternElementRepo *repo.DropPatternElement) *DropPattern {
+	return &DropPattern{
+		DropPatternRepo:        dropPatternRepo,
+		DropPatternElementRepo: dropPatternElementRepo,
+	}
+}
+
+// GetOrCreateDropPattern returns the pattern of drops, which have been merged by item ids. Patterns not known to this
+// instance are looked up within tx, and created along with their elements if they do not exist yet.
+//
+// Cache: dropPatternId#hash:{hash}, indefinitely, as patterns of a hash never change. Entries are only added by
+// RememberDropPatterns and PreloadDropPatterns, once patterns are known to have been committed.
+func (s *DropPattern) GetOrCreateDropPattern(ctx context.Context, tx bun.Tx, drops []*types.Drop) (*model.DropPattern, error) {
+	originalFingerprint, hash := reportutil.CanonicalPatternFromDrops(drops)
+
+	var patternId int
+	if err := cache.DropPatternIDByHash.Get(hash, &patternId); err == nil {
+		return &model.DropPattern{
+			PatternID:           patternId,
+			Hash:                hash,
+			OriginalFingerprint: originalFingerprint,
+		}, nil
+	}
+
+	dropPattern, created, err := s.DropPatternRepo.GetOrCreateDropPattern(ctx, tx, hash, originalFingerprint)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get or create drop pattern")
+	}
+	if created {
+		if _, err := s.DropPatternElementRepo.CreateDropPatternElements(ctx, tx, dropPattern.PatternID, drops); err != nil {
+			return nil, errors.Wrap(err, "failed to create drop pattern elements")
+		}
+	}
+	return dropPattern, nil
+}
+
+// RememberDropPatterns adds dropPatterns to the lookup by hash. Patterns created within a transaction shall only be
+// remembered after the transaction has been committed.
+func (s *DropPattern) RememberDropPatterns(dropPatterns []*model.DropPattern) {
+	for _, dropPattern := range dropPatterns {
+		cache.DropPatternIDByHash.Set(dropPattern.Hash, dropPattern.PatternID, 0)
+	}
+}
+
+// PreloadDropPatterns adds all drop patterns to the lookup by hash, so that reports of known patterns are persisted
+// without looking up their patterns in the database.
+func (s *DropPattern) PreloadDropPatterns(ctx context.Context) error {
+	start := time.Now()
+	dropPatterns, err := s.DropPatternRepo.GetDropPatterns(ctx)
+	if err != nil {
+		return err
+	}
+	s.RememberDropPatterns(dropPatterns)
+	log.Info().
+		Int("patterns", len(dropPatterns)).
+		Dur("duration", time.Since(start)).
+		Msg("drop patterns preloaded")
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"gopkg.in/guregu/null.v3"
 
 	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cursor"
 	"github.com/penguin-statistics/backend-next/internal/repo"
 )
 
@@ -20,6 +21,55 @@ func NewDropReport(dropReportRepo *repo.DropReport) *DropReport {
 	}
 }
 
+// ListDropReports returns the page of drop reports matching query, whose Cursor and Limit select the page.
+func (s *DropReport) ListDropReports(ctx context.Context, query repo.DropReportListQuery) (*model.DropReportPage, error) {
+	limit := query.Limit
+	// a report more than the page is listed to tell whether there is a next page
+	query.Limit++
+	reports, err := s.DropReportRepo.ListDropReports(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.DropReportPage{Reports: reports}
+	if len(reports) > limit {
+		page.Reports = reports[:limit]
+		last := page.Reports[limit-1]
+		page.NextCursor = (&cursor.Cursor{CreatedAt: last.CreatedAt, ReportID: last.ReportID}).Encode()
+	}
+	return page, nil
+}
+
+// ListPersonalDropReports returns the page of drop reports of server submitted by accountId, leaving out reports
+// recalled. How reports are judged is not disclosed to the account.
+func (s *DropReport) ListPersonalDropReports(ctx context.Context, server string, accountId int, c *cursor.Cursor, limit int) (*model.DropReportPage, error) {
+	page, err := s.ListDropReports(ctx, repo.DropReportListQuery{
+		AccountID:      null.IntFrom(int64(accountId)),
+		Server:         server,
+		MinReliability: null.IntFrom(0),
+		Cursor:         c,
+		Limit:          limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, report := range page.Reports {
+		report.Reliability = nil
+		report.AccountID = nil
+		report.DeletedAt = nil
+	}
+	return page, nil
+}
+
+func (s *DropReport) GetMaxReportId(ctx context.Context) (int, error) {
+	return s.DropReportRepo.GetMaxReportId(ctx)
+}
+
+func (s *DropReport) GetTouchedStages(ctx context.Context, server string, afterReportId int, untilReportId int) ([]*model.TouchedStage, error) {
+	return s.DropReportRepo.GetTouchedStages(ctx, server, afterReportId, untilReportId)
+}
+
 func (s *DropReport) CalcTotalQuantityForDropMatrix(
 	ctx context.Context, server string, timeRange *model.TimeRange, stageIdItemIdMap map[int][]int, accountId null.Int, sourceCategory string,
 ) ([]*model.TotalQuantityResultForDropMatrix, error) {
@@ -44,6 +94,18 @@ func (s *DropReport) CalcTotalTimesForPatternMatrix(
 	return s.DropReportRepo.CalcTotalTimes(ctx, server, timeRange, stageIds, accountId, true, sourceCategory)
 }
 
+func (s *DropReport) CalcWeightedTimesForRecentDropMatrix(
+	ctx context.Context, server string, stageIds []int, since time.Time, now time.Time, halfLife time.Duration,
+) ([]*model.WeightedTimesResult, error) {
+	return s.DropReportRepo.CalcWeightedTimesForRecentDropMatrix(ctx, server, stageIds, since, now, halfLife)
+}
+
+func (s *DropReport) CalcWeightedQuantityForRecentDropMatrix(
+	ctx context.Context, server string, stageIdItemIdMap map[int][]int, since time.Time, now time.Time, halfLife time.Duration,
+) ([]*model.WeightedQuantityResult, error) {
+	return s.DropReportRepo.CalcWeightedQuantityForRecentDropMatrix(ctx, server, stageIdItemIdMap, since, now, halfLife)
+}
+
 func (s *DropReport) CalcTotalQuantityForTrend(
 	ctx context.Context, server string, startTime *time.Time, intervalLength time.Duration, intervalNum int, stageIdItemIdMap map[int][]int, accountId null.Int, sourceCategory string,
 ) ([]*model.TotalQuantityResultForTrend, error) {
@@ -61,3 +123,15 @@ func (s *DropReport) CalcQuantityUniqCount(
 ) ([]*model.QuantityUniqCountResultForDropMatrix, error) {
 	return s.DropReportRepo.CalcQuantityUniqCount(ctx, server, timeRange, stageIdItemIdMap, accountId, sourceCategory)
 }
+
+func (s *DropReport) CalcPersonalTimesByInterval(
+	ctx context.Context, server string, accountId int, startTime time.Time, intervalLength time.Duration, intervalNum int,
+) ([]*model.TotalTimesResultForTrend, error) {
+	return s.DropReportRepo.CalcPersonalTimesByInterval(ctx, server, accountId, startTime, intervalLength, intervalNum)
+}
+
+func (s *DropReport) CalcPersonalQuantityByInterval(
+	ctx context.Context, server string, accountId int, startTime time.Time, intervalLength time.Duration, intervalNum int,
+) ([]*model.TotalQuantityResultForTrend, error) {
+	return s.DropReportRepo.CalcPersonalQuantityByInterval(ctx, server, accountId, startTime, intervalLength, intervalNum)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+type DropReportPartition struct {
+	DB                      *bun.DB
+	DropReportPartitionRepo *repo.DropReportPartition
+}
+
+func NewDropReportPartition(db *bun.DB, dropReportPartitionRepo *repo.DropReportPartition) *DropReportPartition {
+	return &DropReportPartition{
+		DB:                      db,
+		DropReportPartitionRepo: dropReportPartitionRepo,
+	}
+}
+
+// EnsurePartitions creates the partitions of drop_reports for the current month and the following `ahead` months,
+// so that new reports never land in the default partition. It is a no-op if drop_reports is not partitioned.
+func (s *DropReportPartition) EnsurePartitions(ctx context.Context, ahead int) error {
+	partitioned, err := s.DropReportPartitionRepo.IsPartitioned(ctx)
+	if err != nil {
+		return err
+	}
+	if !partitioned {
+		return nil
+	}
+
+	month, _ := repo.DropReportPartitionRange(time.Now())
+	for i := 0; i <= ahead; i++ {
+		if err := s.DropReportPartitionRepo.CreateMonthlyPartition(ctx, s.DB, month.AddDate(0, i, 0)); err != nil {
+			return errors.Wrapf(err, "failed to create partition %s", repo.DropReportPartitionName(month.AddDate(0, i, 0)))
+		}
+	}
+	return nil
+}
+
+// MigrateToPartitioned converts drop_reports into a table partitioned by month, in a single transaction which locks
+// drop_reports until the migration completes. It is a no-op if drop_reports is already partitioned.
+func (s *DropReportPartition) MigrateToPartitioned(ctx context.Context, ahead int) error {
+	partitioned, err := s.DropReportPartitionRepo.IsPartitioned(ctx)
+	if err != nil {
+		return err
+	}
+	if partitioned {
+		log.Info().Msg("drop_reports is already partitioned")
+		return nil
+	}
+
+	start := time.Now()
+	err = s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return s.DropReportPartitionRepo.MigrateToPartitioned(ctx, tx)
+	})
+	if err != nil {
+		return err
+	}
+	log.Info().Dur("duration", time.Since(start)).Msg("drop_reports has been partitioned")
+
+	return s.EnsurePartitions(ctx, ahead)
+}
+
+func (s *DropReportPartition) GetPartitionNames(ctx context.Context) ([]string, error) {
+	return s.DropReportPartitionRepo.GetPartitionNames(ctx)
+}
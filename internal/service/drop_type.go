@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var ErrDropTypeNotFound = pgerr.ErrNotFound.Msg("drop type not found")
+
+// builtinDropTypes returns drop types registered even when not stored in the database, which drop types stored of
+// the same names override.
+func builtinDropTypes() []*model.DropType {
+	return []*model.DropType{
+		{Name: constant.DropTypeRegular, APIName: "NORMAL_DROP", Aliases: []string{"REGULAR_DROP"}, Reportable: true, InPattern: true, Builtin: true},
+		{Name: constant.DropTypeSpecial, APIName: "SPECIAL_DROP", Aliases: []string{}, Reportable: true, InPattern: true, Builtin: true},
+		{Name: constant.DropTypeExtra, APIName: "EXTRA_DROP", Aliases: []string{}, Reportable: true, InPattern: true, Builtin: true},
+		{Name: constant.DropTypeFurniture, APIName: "FURNITURE", Aliases: []string{}, Reportable: true, InPattern: true, Builtin: true},
+		{Name: constant.DropTypeRecognitionOnly, APIName: "RECOGNITION_ONLY", Aliases: []string{}, Builtin: true},
+	}
+}
+
+// DropType is the registry of drop types, which maps drop types between their API and database forms, and decides
+// whether drops of each type are accepted from reports and counted in drop patterns.
+type DropType struct {
+	DropTypeRepo        *repo.DropType
+	CacheVersionService *CacheVersion
+}
+
+func NewDropType(dropTypeRepo *repo.DropType, cacheVersionService *CacheVersion) *DropType {
+	return &DropType{
+		DropTypeRepo:        dropTypeRepo,
+		CacheVersionService: cacheVersionService,
+	}
+}
+
+// GetDropTypes returns builtin drop types, overridden by and along with those stored, ordered by name.
+//
+// Cache: (singular) dropTypes, 1 hr
+func (s *DropType) GetDropTypes(ctx context.Context) ([]*model.DropType, error) {
+	var dropTypes []*model.DropType
+	err := cache.DropTypes.MutexGetSet(&dropTypes, func() ([]*model.DropType, error) {
+		stored, err := s.DropTypeRepo.GetDropTypes(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		registry := lo.KeyBy(builtinDropTypes(), func(dropType *model.DropType) string {
+			return dropType.Name
+		})
+		for _, dropType := range stored {
+			registry[dropType.Name] = dropType
+		}
+
+		dropTypes := lo.Values(registry)
+		sort.Slice(dropTypes, func(i, j int) bool {
+			return dropTypes[i].Name < dropTypes[j].Name
+		})
+		return dropTypes, nil
+	}, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return dropTypes, nil
+}
+
+func (s *DropType) GetDropTypeByName(ctx context.Context, name string) (*model.DropType, error) {
+	dropTypes, err := s.GetDropTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dropType, ok := lo.Find(dropTypes, func(dropType *model.DropType) bool {
+		return dropType.Name == name
+	})
+	if !ok {
+		return nil, ErrDropTypeNotFound
+	}
+	return dropType, nil
+}
+
+// ResolveReported returns the drop type drops submitted with apiName in reports are of. ErrDropTypeNotFound is
+// returned if apiName is neither the API name nor an alias of any drop type accepted from reports.
+func (s *DropType) ResolveReported(ctx context.Context, apiName string) (*model.DropType, error) {
+	dropTypes, err := s.GetDropTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dropType, ok := lo.Find(dropTypes, func(dropType *model.DropType) bool {
+		return dropType.Reportable && (dropType.APIName == apiName || lo.Contains(dropType.Aliases, apiName))
+	})
+	if !ok {
+		return nil, ErrDropTypeNotFound
+	}
+	return dropType, nil
+}
+
+// GetAPINames returns API names of drop types, keyed by their names.
+func (s *DropType) GetAPINames(ctx context.Context) (map[string]string, error) {
+	dropTypes, err := s.GetDropTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiNames := make(map[string]string, len(dropTypes))
+	for _, dropType := range dropTypes {
+		apiNames[dropType.Name] = dropType.APIName
+	}
+	return apiNames, nil
+}
+
+// FilterPatternDrops returns drops of drop types counted in drop patterns. Drops of drop types not registered are
+// counted, so that they are never silently lost.
+func (s *DropType) FilterPatternDrops(ctx context.Context, drops []*types.Drop) ([]*types.Drop, error) {
+	dropTypes, err := s.GetDropTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := lo.Filter(dropTypes, func(dropType *model.DropType, _ int) bool {
+		return !dropType.InPattern
+	})
+	return lo.Filter(drops, func(drop *types.Drop, _ int) bool {
+		return !lo.ContainsBy(excluded, func(dropType *model.DropType) bool {
+			return dropType.Name == drop.DropType
+		})
+	}), nil
+}
+
+// SaveDropType registers the drop type of name, or replaces the registered one. The API name and aliases shall not be
+// taken by any other drop type, so that drops of reports are never ambiguous.
+func (s *DropType) SaveDropType(ctx context.Context, name string, req *types.DropTypeRequest) (*model.DropType, error) {
+	dropTypes, err := s.GetDropTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	apiNames := append([]string{req.APIName}, req.Aliases...)
+	if len(lo.Uniq(apiNames)) != len(apiNames) {
+		return nil, pgerr.ErrInvalidReq.Msg("apiName and aliases shall not contain duplicates")
+	}
+	for _, other := range dropTypes {
+		if other.Name == name {
+			audit.Before(ctx, other)
+			continue
+		}
+		taken := append([]string{other.APIName}, other.Aliases...)
+		if conflicts := lo.Intersect(taken, apiNames); len(conflicts) > 0 {
+			return nil, pgerr.ErrInvalidReq.Msg("`%s` is already taken by drop type `%s`", conflicts[0], other.Name)
+		}
+	}
+
+	dropType := &model.DropType{
+		Name:        name,
+		APIName:     req.APIName,
+		Aliases:     req.Aliases,
+		Reportable:  req.Reportable,
+		InPattern:   req.InPattern,
+		Description: req.Description,
+	}
+	if dropType.Aliases == nil {
+		dropType.Aliases = []string{}
+	}
+	if err := s.DropTypeRepo.SaveDropType(ctx, dropType); err != nil {
+		return nil, err
+	}
+	audit.After(ctx, dropType)
+
+	s.invalidate(ctx)
+	return dropType, nil
+}
+
+// DeleteDropType deletes the stored drop type of name. Builtin drop types are reverted to their defaults instead.
+func (s *DropType) DeleteDropType(ctx context.Context, name string) error {
+	dropType, err := s.DropTypeRepo.DeleteDropType(ctx, name)
+	if err != nil {
+		return err
+	}
+	if dropType == nil {
+		return ErrDropTypeNotFound
+	}
+	audit.Before(ctx, dropType)
+
+	s.invalidate(ctx)
+	return nil
+}
+
+// invalidate drops drop types cached by every instance, along with game data serialized with them, such as drop
+// infos of stages.
+func (s *DropType) invalidate(ctx context.Context) {
+	if err := s.CacheVersionService.Bump(ctx, constant.CacheVersionGameData, ""); err != nil {
+		log.Warn().Err(err).Msg("failed to invalidate cached drop types")
+		cache.FlushLocalGameData()
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+// efficiencyDefaultLimit is the number of stages ranked when not specified.
+const efficiencyDefaultLimit = 10
+
+// Efficiency ranks stages by the sanity spent per item dropped, joining sanity costs of stages with drop rates.
+type Efficiency struct {
+	DropMatrixService *DropMatrix
+	ItemService       *Item
+	RuntimeConfig     *RuntimeConfig
+}
+
+func NewEfficiency(dropMatrixService *DropMatrix, itemService *Item, runtimeConfig *RuntimeConfig) *Efficiency {
+	return &Efficiency{
+		DropMatrixService: dropMatrixService,
+		ItemService:       itemService,
+		RuntimeConfig:     runtimeConfig,
+	}
+}
+
+// RankStagesForItem ranks the stages the item of query drops from, from the one spending the least sanity per item
+// dropped. Stages of unknown sanity costs, or sampled fewer times than query MinTimes, are left out.
+func (s *Efficiency) RankStagesForItem(ctx context.Context, query *types.StageEfficiencyQuery) (*modelv2.StageEfficiencyRanking, error) {
+	item, err := s.ItemService.GetItemByArkId(ctx, query.ItemID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranking := &modelv2.StageEfficiencyRanking{
+		ItemID: item.ArkItemID,
+		Server: query.Server,
+	}
+	var result *modelv2.DropMatrixQueryResult
+	if query.StartTime == 0 && query.EndTime == 0 {
+		result, err = s.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx, query.Server, query.ShowClosedZones, "", "", null.NewInt(0, false))
+	} else {
+		timeRange := efficiencyTimeRange(query)
+		ranking.StartTime = null.IntFrom(timeRange.StartTime.UnixMilli())
+		ranking.EndTime = null.IntFrom(timeRange.EndTime.UnixMilli())
+		result, err = s.DropMatrixService.GetShimCustomizedDropMatrixResults(ctx, query.Server, timeRange, nil, []int{item.ItemID}, null.NewInt(0, false))
+	}
+	if err != nil {
+		return nil, err
+	}
+	itemDropStages, err := s.DropMatrixService.itemDropStagesOf(ctx, query.Server, item.ArkItemID, result)
+	if err != nil {
+		return nil, err
+	}
+
+	minTimes := query.MinTimes
+	if minTimes == 0 {
+		minTimes = s.RuntimeConfig.Current().MatrixLowSampleThreshold
+	}
+	ranking.Stages = make([]*modelv2.StageEfficiency, 0, len(itemDropStages.Stages))
+	for _, itemDropStage := range itemDropStages.Stages {
+		if !itemDropStage.ApPerItem.Valid || itemDropStage.Times < minTimes {
+			continue
+		}
+		ranking.Stages = append(ranking.Stages, &modelv2.StageEfficiency{
+			ItemDropStage: itemDropStage,
+			ItemsPerAp:    util.RoundFloat64(float64(itemDropStage.Quantity)/float64(itemDropStage.Times)/float64(itemDropStage.ApCost.Int64), constant.StdDevDigits),
+		})
+	}
+	// ties are broken by the number of times sampled, as the more sampled rate is the more reliable one
+	sort.SliceStable(ranking.Stages, func(i, j int) bool {
+		a, b := ranking.Stages[i], ranking.Stages[j]
+		if a.ApPerItem.Float64 != b.ApPerItem.Float64 {
+			return a.ApPerItem.Float64 < b.ApPerItem.Float64
+		}
+		return a.Times > b.Times
+	})
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = efficiencyDefaultLimit
+	}
+	if len(ranking.Stages) > limit {
+		ranking.Stages = ranking.Stages[:limit]
+	}
+	for i, stage := range ranking.Stages {
+		stage.Rank = i + 1
+	}
+	return ranking, nil
+}
+
+// efficiencyTimeRange returns the time range of query, defaulting to the start of its server and now.
+func efficiencyTimeRange(query *types.StageEfficiencyQuery) *model.TimeRange {
+	startTime := time.UnixMilli(constant.ServerStartTimeMapMillis[query.Server])
+	if query.StartTime != 0 {
+		startTime = time.UnixMilli(query.StartTime)
+	}
+	endTime := time.Now()
+	if query.EndTime != 0 {
+		endTime = time.UnixMilli(query.EndTime)
+	}
+	return &model.TimeRange{
+		StartTime: &startTime,
+		EndTime:   &endTime,
+	}
+}
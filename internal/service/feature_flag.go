@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/pkg/featureflag"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgid"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+var (
+	ErrFeatureFlagNotFound = pgerr.ErrNotFound.Msg("feature flag not found")
+	ErrFeatureDisabled     = pgerr.New(fiber.StatusNotFound, "FEATURE_DISABLED", "this feature is not available yet")
+)
+
+// FeatureFlag manages feature flags, which gate new behaviors per server and for a percentage of users.
+type FeatureFlag struct {
+	Store *featureflag.Store
+}
+
+func NewFeatureFlag(store *featureflag.Store) *FeatureFlag {
+	return &FeatureFlag{
+		Store: store,
+	}
+}
+
+// EnabledForRequest returns whether the flag of key is enabled for the user of the request of ctx on server, or
+// fallback if the flag does not exist. Users are identified by their PenguinID, or by their IP without one.
+func (s *FeatureFlag) EnabledForRequest(ctx *fiber.Ctx, key string, server string, fallback bool) bool {
+	id := "ip:" + util.ExtractIP(ctx)
+	if penguinId := pgid.Extract(ctx); penguinId != "" {
+		id = "pgid:" + penguinId
+	}
+	return s.Store.Enabled(ctx.Context(), key, featureflag.Subject{Server: server, ID: id}, fallback)
+}
+
+func (s *FeatureFlag) GetFeatureFlags(ctx context.Context) ([]*featureflag.Flag, error) {
+	return s.Store.List(ctx)
+}
+
+func (s *FeatureFlag) SetFeatureFlag(ctx context.Context, key string, req *types.FeatureFlagRequest) (*featureflag.Flag, error) {
+	flag := &featureflag.Flag{
+		Key:         key,
+		Enabled:     req.Enabled,
+		Servers:     req.Servers,
+		Percentage:  req.Percentage,
+		Description: req.Description,
+	}
+	if flag.Servers == nil {
+		flag.Servers = []string{}
+	}
+	if err := s.snapshotFeatureFlag(ctx, key); err != nil {
+		return nil, err
+	}
+	if err := s.Store.Set(ctx, flag); err != nil {
+		return nil, err
+	}
+	audit.After(ctx, flag)
+	return flag, nil
+}
+
+func (s *FeatureFlag) DeleteFeatureFlag(ctx context.Context, key string) error {
+	if err := s.snapshotFeatureFlag(ctx, key); err != nil {
+		return err
+	}
+	deleted, err := s.Store.Delete(ctx, key)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrFeatureFlagNotFound
+	}
+	return nil
+}
+
+// snapshotFeatureFlag records the flag of key before being changed to the audit log, if it exists.
+func (s *FeatureFlag) snapshotFeatureFlag(ctx context.Context, key string) error {
+	flag, err := s.Store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if flag != nil {
+		audit.Before(ctx, flag)
+	}
+	return nil
+}
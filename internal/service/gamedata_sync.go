@@ -0,0 +1,519 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var (
+	ErrGameDataSyncInProgress = pgerr.New(fiber.StatusConflict, "GAMEDATA_SYNC_IN_PROGRESS", "game data is being synced by another instance")
+	ErrGameDataChangeConflict = pgerr.New(fiber.StatusConflict, "GAMEDATA_CHANGE_CONFLICT", "record has been changed since the change was staged; the change has been superseded, and the next sync stages it again if still applicable")
+)
+
+var gameDataSyncClient = &http.Client{
+	Timeout: time.Minute,
+}
+
+// syncedItemTypes are types of items in the gamedata repository created when missing. Items of other types, e.g.
+// furniture, are only updated if already created.
+var syncedItemTypes = []string{
+	constant.ItemTypeMaterial,
+	constant.ItemTypeCardExp,
+	constant.ItemTypeChip,
+	constant.ItemTypeActivity,
+}
+
+// syncedStageTypes are types of stages in the gamedata repository synced, leaving out e.g. tutorials.
+var syncedStageTypes = []string{"MAIN", "SUB", "ACTIVITY", "DAILY"}
+
+// externalItem is an item of item_table.json in the gamedata repository.
+type externalItem struct {
+	ItemID   string `json:"itemId"`
+	Name     string `json:"name"`
+	ItemType string `json:"itemType"`
+	// Rarity is either an integer starting from 0, or "TIER_1" to "TIER_6" in later versions.
+	Rarity json.RawMessage `json:"rarity"`
+}
+
+func (i *externalItem) rarity() (int, error) {
+	var rarity int
+	if err := json.Unmarshal(i.Rarity, &rarity); err == nil {
+		return rarity, nil
+	}
+	var tier string
+	if err := json.Unmarshal(i.Rarity, &tier); err != nil {
+		return 0, err
+	}
+	rarity, err := strconv.Atoi(strings.TrimPrefix(tier, "TIER_"))
+	if err != nil {
+		return 0, err
+	}
+	return rarity - 1, nil
+}
+
+// externalStage is a stage of stage_table.json in the gamedata repository.
+type externalStage struct {
+	StageID   string `json:"stageId"`
+	ZoneID    string `json:"zoneId"`
+	StageType string `json:"stageType"`
+	Code      string `json:"code"`
+	APCost    int    `json:"apCost"`
+}
+
+// GameDataSync stages changes to items and stages found in the external gamedata repository, for moderators to
+// approve before they are applied.
+type GameDataSync struct {
+	Locker             *dlock.Locker
+	DB                 *bun.DB
+	ItemRepo           *repo.Item
+	StageRepo          *repo.Stage
+	ZoneRepo           *repo.Zone
+	GameDataChangeRepo *repo.GameDataChange
+	AdminService       *Admin
+
+	// BaseURL is the URL of the directory item_table.json and stage_table.json are fetched from.
+	BaseURL string
+}
+
+func NewGameDataSync(db *bun.DB, locker *dlock.Locker, itemRepo *repo.Item, stageRepo *repo.Stage, zoneRepo *repo.Zone, gameDataChangeRepo *repo.GameDataChange, adminService *Admin, conf *config.Config) *GameDataSync {
+	return &GameDataSync{
+		Locker:             locker,
+		DB:                 db,
+		ItemRepo:           itemRepo,
+		StageRepo:          stageRepo,
+		ZoneRepo:           zoneRepo,
+		GameDataChangeRepo: gameDataChangeRepo,
+		AdminService:       adminService,
+		BaseURL:            strings.TrimSuffix(conf.GameDataSyncURL, "/"),
+	}
+}
+
+// Sync fetches items and stages from the gamedata repository, and stages changes to them for approval. A pending
+// change is kept if staged again, and superseded if a different change to the same record is staged or the record
+// no longer differs. Sync returns the number of changes newly staged.
+func (s *GameDataSync) Sync(ctx context.Context) (int, error) {
+	lock, err := s.Locker.TryLock(ctx, constant.LockGameDataSync)
+	if errors.Is(err, dlock.ErrNotAcquired) {
+		return 0, ErrGameDataSyncInProgress
+	} else if err != nil {
+		return 0, err
+	}
+	defer lock.Unlock()
+	ctx = lock.Context()
+
+	itemChanges, err := s.diffItems(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to diff items")
+	}
+	stageChanges, err := s.diffStages(ctx)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to diff stages")
+	}
+
+	return s.stageChanges(ctx, append(itemChanges, stageChanges...))
+}
+
+func (s *GameDataSync) diffItems(ctx context.Context) ([]*model.GameDataChange, error) {
+	var table struct {
+		Items map[string]*externalItem `json:"items"`
+	}
+	source := s.BaseURL + "/item_table.json"
+	if err := fetchGameData(ctx, source, &table); err != nil {
+		return nil, err
+	}
+
+	items, err := s.ItemRepo.GetItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	itemsByArkId := lo.KeyBy(items, func(item *model.Item) string { return item.ArkItemID })
+
+	changes := make([]*model.GameDataChange, 0)
+	for _, external := range table.Items {
+		rarity, err := external.rarity()
+		if err != nil {
+			log.Warn().Err(err).Str("itemId", external.ItemID).Msg("skipped syncing item of unknown rarity")
+			continue
+		}
+
+		item, ok := itemsByArkId[external.ItemID]
+		if !ok {
+			if !lo.Contains(syncedItemTypes, external.ItemType) {
+				continue
+			}
+			change, err := newGameDataChange(constant.GameDataChangeKindItem, external.ItemID, source, nil, map[string]any{
+				"itemId":    external.ItemID,
+				"name":      map[string]string{constant.GameDataSyncLanguage: external.Name},
+				"existence": syncedExistence(),
+				"rarity":    rarity,
+			})
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+			continue
+		}
+
+		before, after := map[string]any{}, map[string]any{}
+		name, changed, err := patchLocalized(item.Name, external.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "item %s", item.ArkItemID)
+		}
+		if changed {
+			before["name"], after["name"] = item.Name, name
+		}
+		if item.Rarity != rarity {
+			before["rarity"], after["rarity"] = item.Rarity, rarity
+		}
+		if len(after) == 0 {
+			continue
+		}
+		change, err := newGameDataChange(constant.GameDataChangeKindItem, item.ArkItemID, source, before, after)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+func (s *GameDataSync) diffStages(ctx context.Context) ([]*model.GameDataChange, error) {
+	var table struct {
+		Stages map[string]*externalStage `json:"stages"`
+	}
+	source := s.BaseURL + "/stage_table.json"
+	if err := fetchGameData(ctx, source, &table); err != nil {
+		return nil, err
+	}
+
+	stages, err := s.StageRepo.GetStages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stagesByArkId := lo.KeyBy(stages, func(stage *model.Stage) string { return stage.ArkStageID })
+
+	zones, err := s.ZoneRepo.GetZones(ctx)
+	if err != nil && !errors.Is(err, pgerr.ErrNotFound) {
+		return nil, err
+	}
+	zonesByArkId := lo.KeyBy(zones, func(zone *model.Zone) string { return zone.ArkZoneID })
+
+	changes := make([]*model.GameDataChange, 0)
+	for _, external := range table.Stages {
+		if !lo.Contains(syncedStageTypes, external.StageType) {
+			continue
+		}
+
+		stage, ok := stagesByArkId[external.StageID]
+		if !ok {
+			zone, ok := zonesByArkId[external.ZoneID]
+			if !ok {
+				// zones are curated by moderators, so stages of zones not created yet wait for them
+				log.Debug().Str("stageId", external.StageID).Str("zoneId", external.ZoneID).Msg("skipped syncing stage of unknown zone")
+				continue
+			}
+			change, err := newGameDataChange(constant.GameDataChangeKindStage, external.StageID, source, nil, map[string]any{
+				"stageId":   external.StageID,
+				"zoneId":    zone.ZoneID,
+				"stageType": external.StageType,
+				"code":      map[string]string{constant.GameDataSyncLanguage: external.Code},
+				"sanity":    external.APCost,
+				"existence": syncedExistence(),
+			})
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, change)
+			continue
+		}
+
+		before, after := map[string]any{}, map[string]any{}
+		code, changed, err := patchLocalized(stage.Code, external.Code)
+		if err != nil {
+			return nil, errors.Wrapf(err, "stage %s", stage.ArkStageID)
+		}
+		if changed {
+			before["code"], after["code"] = stage.Code, code
+		}
+		if !stage.Sanity.Valid || stage.Sanity.Int64 != int64(external.APCost) {
+			before["sanity"], after["sanity"] = stage.Sanity, external.APCost
+		}
+		if len(after) == 0 {
+			continue
+		}
+		change, err := newGameDataChange(constant.GameDataChangeKindStage, stage.ArkStageID, source, before, after)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// stageChanges persists changes as pending, keeping pending changes staged again and superseding the others.
+func (s *GameDataSync) stageChanges(ctx context.Context, changes []*model.GameDataChange) (int, error) {
+	var staged int
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		pending, err := s.GameDataChangeRepo.GetPendingGameDataChanges(ctx, tx)
+		if err != nil {
+			return err
+		}
+		pendingByRecord := lo.KeyBy(pending, func(change *model.GameDataChange) string {
+			return change.Kind + constant.CacheSep + change.ArkID
+		})
+
+		toCreate := make([]*model.GameDataChange, 0, len(changes))
+		supersededIds := make([]int, 0)
+		for _, change := range changes {
+			record := change.Kind + constant.CacheSep + change.ArkID
+			if existing, ok := pendingByRecord[record]; ok {
+				delete(pendingByRecord, record)
+				if jsonEqual(existing.After, change.After) {
+					continue
+				}
+				supersededIds = append(supersededIds, existing.ChangeID)
+			}
+			toCreate = append(toCreate, change)
+		}
+		// records left no longer differ from the gamedata repository
+		for _, existing := range pendingByRecord {
+			supersededIds = append(supersededIds, existing.ChangeID)
+		}
+
+		if err := s.GameDataChangeRepo.SupersedeGameDataChanges(ctx, tx, supersededIds); err != nil {
+			return err
+		}
+		staged = len(toCreate)
+		return s.GameDataChangeRepo.CreateGameDataChanges(ctx, tx, toCreate)
+	})
+	return staged, err
+}
+
+func (s *GameDataSync) GetGameDataChanges(ctx context.Context, status string, limit int) ([]*model.GameDataChange, error) {
+	return s.GameDataChangeRepo.GetGameDataChanges(ctx, status, limit)
+}
+
+// ApproveGameDataChange applies the pending change of changeId. The change is superseded instead if the record it
+// changes has been changed since the change was staged.
+func (s *GameDataSync) ApproveGameDataChange(ctx context.Context, changeId int) (*model.GameDataChange, error) {
+	change, err := s.GameDataChangeRepo.GetGameDataChangeById(ctx, changeId)
+	if err != nil {
+		return nil, err
+	}
+	if change.Status != constant.GameDataChangeStatusPending {
+		return nil, pgerr.ErrInvalidReq.Msg("change is already %s", change.Status)
+	}
+
+	if err := s.checkGameDataChangeConflict(ctx, change); err != nil {
+		if errors.Is(err, ErrGameDataChangeConflict) {
+			_, _ = s.GameDataChangeRepo.TransitGameDataChange(ctx, changeId,
+				constant.GameDataChangeStatusPending, constant.GameDataChangeStatusSuperseded, nil)
+		}
+		return nil, err
+	}
+
+	// the change is claimed before being applied, so that concurrent approvals apply it only once
+	reviewedAt := time.Now()
+	claimed, err := s.GameDataChangeRepo.TransitGameDataChange(ctx, changeId,
+		constant.GameDataChangeStatusPending, constant.GameDataChangeStatusApproved, &reviewedAt)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, pgerr.ErrInvalidReq.Msg("change is no longer pending")
+	}
+
+	if err := s.applyGameDataChange(ctx, change); err != nil {
+		if _, revertErr := s.GameDataChangeRepo.TransitGameDataChange(ctx, changeId,
+			constant.GameDataChangeStatusApproved, constant.GameDataChangeStatusPending, nil); revertErr != nil {
+			log.Error().Err(revertErr).Int("changeId", changeId).Msg("failed to revert game data change to pending")
+		}
+		return nil, err
+	}
+
+	change.Status = constant.GameDataChangeStatusApproved
+	change.ReviewedAt = &reviewedAt
+	return change, nil
+}
+
+func (s *GameDataSync) RejectGameDataChange(ctx context.Context, changeId int) error {
+	reviewedAt := time.Now()
+	rejected, err := s.GameDataChangeRepo.TransitGameDataChange(ctx, changeId,
+		constant.GameDataChangeStatusPending, constant.GameDataChangeStatusRejected, &reviewedAt)
+	if err != nil {
+		return err
+	}
+	if !rejected {
+		return pgerr.ErrInvalidReq.Msg("change is not pending")
+	}
+	return nil
+}
+
+// checkGameDataChangeConflict returns ErrGameDataChangeConflict if the record change creates has been created, or
+// fields of the record change updates differ from change.Before.
+func (s *GameDataSync) checkGameDataChangeConflict(ctx context.Context, change *model.GameDataChange) error {
+	var (
+		current any
+		err     error
+	)
+	switch change.Kind {
+	case constant.GameDataChangeKindItem:
+		current, err = s.ItemRepo.GetItemByArkId(ctx, change.ArkID)
+	case constant.GameDataChangeKindStage:
+		current, err = s.StageRepo.GetStageByArkId(ctx, change.ArkID)
+	default:
+		return pgerr.ErrInvalidReq.Msg("unknown kind of change `%s`", change.Kind)
+	}
+	notFound := errors.Is(err, pgerr.ErrNotFound)
+	if err != nil && !notFound {
+		return err
+	}
+
+	if change.Action == constant.GameDataChangeActionCreate {
+		if !notFound {
+			return ErrGameDataChangeConflict
+		}
+		return nil
+	}
+	if notFound {
+		return ErrGameDataChangeConflict
+	}
+
+	var before, currentFields map[string]json.RawMessage
+	if err := json.Unmarshal(change.Before, &before); err != nil {
+		return err
+	}
+	encoded, err := json.Marshal(current)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(encoded, &currentFields); err != nil {
+		return err
+	}
+	for field, value := range before {
+		if !jsonEqual(value, currentFields[field]) {
+			return ErrGameDataChangeConflict
+		}
+	}
+	return nil
+}
+
+func (s *GameDataSync) applyGameDataChange(ctx context.Context, change *model.GameDataChange) error {
+	switch change.Kind + constant.CacheSep + change.Action {
+	case constant.GameDataChangeKindItem + constant.CacheSep + constant.GameDataChangeActionCreate:
+		var item model.Item
+		if err := json.Unmarshal(change.After, &item); err != nil {
+			return err
+		}
+		return s.AdminService.SaveItems(ctx, []*model.Item{&item})
+	case constant.GameDataChangeKindItem + constant.CacheSep + constant.GameDataChangeActionUpdate:
+		_, err := s.AdminService.PatchItem(ctx, change.ArkID, change.After)
+		return err
+	case constant.GameDataChangeKindStage + constant.CacheSep + constant.GameDataChangeActionCreate:
+		var stage model.Stage
+		if err := json.Unmarshal(change.After, &stage); err != nil {
+			return err
+		}
+		return s.AdminService.SaveStages(ctx, []*model.Stage{&stage})
+	case constant.GameDataChangeKindStage + constant.CacheSep + constant.GameDataChangeActionUpdate:
+		_, err := s.AdminService.PatchStage(ctx, change.ArkID, change.After)
+		return err
+	default:
+		return pgerr.ErrInvalidReq.Msg("unknown change `%s` of `%s`", change.Action, change.Kind)
+	}
+}
+
+// newGameDataChange creates a pending change to the record of kind and arkId. The change creates the record if
+// before is nil, and updates it otherwise.
+func newGameDataChange(kind string, arkId string, source string, before map[string]any, after map[string]any) (*model.GameDataChange, error) {
+	change := &model.GameDataChange{
+		Kind:   kind,
+		ArkID:  arkId,
+		Action: constant.GameDataChangeActionCreate,
+		Status: constant.GameDataChangeStatusPending,
+		Source: source,
+	}
+
+	var err error
+	if before != nil {
+		change.Action = constant.GameDataChangeActionUpdate
+		if change.Before, err = json.Marshal(before); err != nil {
+			return nil, err
+		}
+	}
+	if change.After, err = json.Marshal(after); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// patchLocalized returns localized, a map with language code as key, with the value of constant.GameDataSyncLanguage
+// replaced by value, and whether the value has changed.
+func patchLocalized(localized json.RawMessage, value string) (map[string]any, bool, error) {
+	m := map[string]any{}
+	if len(localized) > 0 {
+		if err := json.Unmarshal(localized, &m); err != nil {
+			return nil, false, err
+		}
+		if m == nil {
+			m = map[string]any{}
+		}
+	}
+	if current, ok := m[constant.GameDataSyncLanguage].(string); ok && current == value {
+		return m, false, nil
+	}
+	m[constant.GameDataSyncLanguage] = value
+	return m, true, nil
+}
+
+// syncedExistence is the existence of records created by syncs, which exist on constant.GameDataSyncServer only.
+func syncedExistence() map[string]any {
+	return map[string]any{
+		constant.GameDataSyncServer: map[string]bool{"exist": true},
+	}
+}
+
+// jsonEqual reports whether a and b are equal JSON values, regardless of formatting and order of keys.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func fetchGameData(ctx context.Context, url string, dest any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := gameDataSyncClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("failed to fetch %s: unexpected status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(dest)
+}
@@ -56,20 +56,19 @@ func (s *Item) GetItemById(ctx context.Context, itemId int) (*model.Item, error)
 	return item, nil
 }
 
-// Cache: item#arkItemId:{arkItemId}, 1 hr
+// Cache: (tiered) item#arkItemId:{arkItemId}, 10 mins in-process, 1 hr in Redis
 func (s *Item) GetItemByArkId(ctx context.Context, arkItemId string) (*model.Item, error) {
-	var item model.Item
-	err := cache.ItemByArkID.Get(arkItemId, &item)
-	if err == nil {
-		return &item, nil
-	}
-
-	dbItem, err := s.ItemRepo.GetItemByArkId(ctx, arkItemId)
+	item, err := cache.ItemByArkID.GetSet(ctx, arkItemId, func(ctx context.Context) (model.Item, error) {
+		dbItem, err := s.ItemRepo.GetItemByArkId(ctx, arkItemId)
+		if err != nil {
+			return model.Item{}, err
+		}
+		return *dbItem, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	go cache.ItemByArkID.Set(arkItemId, *dbItem, time.Hour)
-	return dbItem, nil
+	return &item, nil
 }
 
 func (s *Item) SearchItemByName(ctx context.Context, name string) (*model.Item, error) {
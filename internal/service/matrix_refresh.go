@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/nats-io/nats.go"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbguard"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbrouter"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// matrixRefreshTimeout is the timeout of a single on-demand matrix refresh.
+const matrixRefreshTimeout = time.Minute * 10
+
+// MatrixRefresh refreshes matrices on demand, e.g. after bad reports have been purged, without waiting for the
+// next run of the matrix worker.
+type MatrixRefresh struct {
+	NatsConn          *nats.Conn
+	DropMatrixService *DropMatrix
+	StageService      *Stage
+	WebhookService    *Webhook
+
+	// wg tracks refreshes running in background
+	wg sync.WaitGroup
+}
+
+func NewMatrixRefresh(natsConn *nats.Conn, dropMatrixService *DropMatrix, stageService *Stage, webhookService *Webhook) *MatrixRefresh {
+	return &MatrixRefresh{
+		NatsConn:          natsConn,
+		DropMatrixService: dropMatrixService,
+		StageService:      stageService,
+		WebhookService:    webhookService,
+	}
+}
+
+// RefreshStageDropMatrix starts refreshing the drop matrix of a stage in background, and returns the refresh id.
+// Progress is published to the NATS subject constant.MatrixRefreshSubjectPrefix + refresh id.
+func (s *MatrixRefresh) RefreshStageDropMatrix(ctx context.Context, req *types.MatrixRefreshRequest) (string, error) {
+	stage, err := s.StageService.GetStageByArkId(ctx, req.StageID)
+	if err != nil {
+		return "", pgerr.ErrInvalidReq.Msg("stage `%s` not found", req.StageID)
+	}
+
+	start := time.UnixMilli(req.StartTime)
+	end := time.Now()
+	if req.EndTime != 0 {
+		end = time.UnixMilli(req.EndTime)
+	}
+
+	return s.startRefresh(req.Server, req.StageID, func(ctx context.Context, onProgress func(done, total int)) ([]string, error) {
+		return []string{req.StageID}, s.DropMatrixService.RefreshDropMatrixElementsForStage(ctx, req.Server, stage.StageID, start, end,
+			s.DropMatrixService.SourceCategories, onProgress)
+	}), nil
+}
+
+// RefreshSplitTimeRange starts refreshing the drop matrix of the stage of arkStageId within time ranges of split in
+// background, and returns the refresh id.
+func (s *MatrixRefresh) RefreshSplitTimeRange(ctx context.Context, server string, arkStageId string, split *model.TimeRangeSplit) (string, error) {
+	stage, err := s.StageService.GetStageByArkId(ctx, arkStageId)
+	if err != nil {
+		return "", pgerr.ErrInvalidReq.Msg("stage `%s` not found", arkStageId)
+	}
+
+	return s.startRefresh(server, arkStageId, func(ctx context.Context, onProgress func(done, total int)) ([]string, error) {
+		return []string{arkStageId}, s.DropMatrixService.RefreshDropMatrixElementsForSplit(ctx, server, stage.StageID, split,
+			s.DropMatrixService.SourceCategories, onProgress)
+	}), nil
+}
+
+// RefreshTouchedStages starts refreshing the drop matrix of touchedStages of server in background, within time
+// ranges their reports fall in, and returns the refresh id. The refresh holds the drop matrix lock of server, as
+// the matrix worker does.
+func (s *MatrixRefresh) RefreshTouchedStages(server string, touchedStages []*model.TouchedStage) string {
+	return s.startRefresh(server, "", func(ctx context.Context, onProgress func(done, total int)) ([]string, error) {
+		err := s.DropMatrixService.Locker.Do(ctx, constant.LockDropMatrix+server, func(ctx context.Context) error {
+			_, err := s.DropMatrixService.refreshDropMatrixElementsForStages(ctx, server,
+				s.DropMatrixService.SourceCategories, touchedStages, onProgress)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		stagesMapById, err := s.StageService.GetStagesMapById(ctx)
+		if err != nil {
+			return nil, err
+		}
+		arkStageIds := make([]string, 0, len(touchedStages))
+		for _, touched := range touchedStages {
+			if stage, ok := stagesMapById[touched.StageID]; ok {
+				arkStageIds = append(arkStageIds, stage.ArkStageID)
+			}
+		}
+		return arkStageIds, nil
+	})
+}
+
+// startRefresh runs refresh in background, publishing its progress, and returns the refresh id. refresh returns ark
+// stage ids of stages refreshed, which webhooks are notified of once it has finished.
+func (s *MatrixRefresh) startRefresh(server string, stageId string, refresh func(ctx context.Context, onProgress func(done, total int)) ([]string, error)) string {
+	refreshId := uniuri.NewLen(16)
+	progress := &types.MatrixRefreshProgress{
+		RefreshID: refreshId,
+		Server:    server,
+		StageID:   stageId,
+		State:     constant.MatrixRefreshStateStarted,
+	}
+	s.publishProgress(progress)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		// refreshes follow changes made just before them, such as purges, which the replica might lag behind
+		refreshCtx, cancel := context.WithTimeout(dbguard.WithQueryTimeout(dbrouter.WithPrimary(context.Background()), 0), matrixRefreshTimeout)
+		defer cancel()
+
+		arkStageIds, err := refresh(refreshCtx, func(done, total int) {
+			progress.State = constant.MatrixRefreshStateProgress
+			progress.Done, progress.Total = done, total
+			s.publishProgress(progress)
+		})
+		if err != nil {
+			log.Error().Err(err).Str("refreshId", refreshId).Msg("failed to refresh drop matrix")
+			progress.State = constant.MatrixRefreshStateFailed
+			progress.Error = err.Error()
+		} else {
+			progress.State = constant.MatrixRefreshStateFinished
+			if len(arkStageIds) > 0 {
+				s.WebhookService.Publish(constant.WebhookEventMatrixRefreshed, &model.WebhookMatrixRefreshed{
+					Server:   server,
+					StageIDs: arkStageIds,
+				})
+			}
+		}
+		s.publishProgress(progress)
+	}()
+
+	return refreshId
+}
+
+// Wait waits for refreshes running in background to finish, e.g. before a short-lived process such as penguinctl
+// exits, as refreshes are abandoned otherwise.
+func (s *MatrixRefresh) Wait() {
+	s.wg.Wait()
+}
+
+func (s *MatrixRefresh) publishProgress(progress *types.MatrixRefreshProgress) {
+	data, err := json.Marshal(progress)
+	if err != nil {
+		log.Error().Err(err).Str("refreshId", progress.RefreshID).Msg("failed to marshal matrix refresh progress")
+		return
+	}
+	// progress is informative only, so it is published without JetStream persistence
+	if err := s.NatsConn.Publish(constant.MatrixRefreshSubjectPrefix+progress.RefreshID, data); err != nil {
+		log.Error().Err(err).Str("refreshId", progress.RefreshID).Msg("failed to publish matrix refresh progress")
+	}
+}
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/migrations"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbguard"
+)
+
+// Tables bun records applied migrations and the lock of runs in.
+const (
+	migrationsTable     = "bun_migrations"
+	migrationLocksTable = "bun_migration_locks"
+)
+
+var (
+	// ErrMigrationsPending is returned when the service starts while migrations are pending.
+	ErrMigrationsPending = errors.New("migrations of the database schema are pending; apply them with `--migrate` first, or set PENGUIN_V3_MIGRATIONS_PENDING_ALLOWED to start anyway")
+	// ErrMigrationsUnknown is returned when migrations are applied or rolled back while the database has been
+	// migrated by a newer binary, as runs of both binaries would be mixed up.
+	ErrMigrationsUnknown = errors.New("the database has been migrated by a newer binary; run migrations with that binary instead")
+	// ErrMigrationLocked is returned when migrations are applied or rolled back while another run holds the lock.
+	ErrMigrationLocked = errors.New("migrations are locked by another run; if no run is in progress, e.g. a run has crashed, release the lock with `--unlock`")
+	// ErrMigrationIrreversible is returned when the latest run contains a migration without a down migration.
+	ErrMigrationIrreversible = errors.New("the latest run contains irreversible migrations")
+)
+
+// Migration applies and rolls back migrations of the database schema in package migrations. Migrations are applied
+// in runs, and rolled back by runs in reverse.
+type Migration struct {
+	DB       *bun.DB
+	Migrator *migrate.Migrator
+}
+
+func NewMigration(db *bun.DB) *Migration {
+	return &Migration{
+		DB: db,
+		Migrator: migrate.NewMigrator(db, migrations.Migrations,
+			migrate.WithTableName(migrationsTable), migrate.WithLocksTableName(migrationLocksTable)),
+	}
+}
+
+// GetMigrationStatus returns the status of all migrations known to the binary, and those applied but unknown. It is
+// read-only, and works before any migration has ever been applied.
+func (s *Migration) GetMigrationStatus(ctx context.Context) (*model.MigrationStatus, error) {
+	set, err := s.migrationsWithStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.MigrationStatus{
+		Migrations:  make([]*model.MigrationState, 0, len(set.known)),
+		Unknown:     make([]string, 0),
+		LastGroupID: set.applied.LastGroupID(),
+	}
+	for i := range set.known {
+		migration := &set.known[i]
+		state := &model.MigrationState{
+			Name:       migration.Name,
+			Applied:    migration.IsApplied(),
+			Reversible: migration.Down != nil,
+		}
+		if state.Applied {
+			migratedAt := migration.MigratedAt
+			state.GroupID, state.MigratedAt = migration.GroupID, &migratedAt
+		} else {
+			status.Pending++
+		}
+		status.Migrations = append(status.Migrations, state)
+	}
+	for _, migration := range set.unknown {
+		status.Unknown = append(status.Unknown, migration.Name)
+	}
+
+	status.Locked, err = s.isLocked(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Migrate applies all pending migrations in a new run, and returns the run. Migrations are applied until one fails,
+// in which case the run is still recorded, including the failed migration, so that it could be rolled back.
+func (s *Migration) Migrate(ctx context.Context) (*migrate.MigrationGroup, error) {
+	if err := s.checkRunnable(ctx); err != nil {
+		return nil, err
+	}
+
+	// migrations are only bound by ctx, as they could take long on large tables
+	group, err := s.Migrator.Migrate(dbguard.WithQueryTimeout(ctx, 0))
+	if err != nil {
+		if group != nil && len(group.Migrations) > 0 {
+			failed := group.Migrations[len(group.Migrations)-1]
+			return group, errors.Wrapf(err, "migration %s of run %d failed; the run has been recorded as applied, so that it could be rolled back with `--rollback`", failed.Name, group.ID)
+		}
+		return group, err
+	}
+	return group, nil
+}
+
+// GetLastGroup returns the latest run, which is the one rolled back next. The run is zero if no migration has ever
+// been applied.
+func (s *Migration) GetLastGroup(ctx context.Context) (*migrate.MigrationGroup, error) {
+	set, err := s.migrationsWithStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return set.known.LastGroup(), nil
+}
+
+// Rollback rolls back the migrations of the latest run in reverse, and returns the run. Runs containing
+// irreversible migrations are never rolled back, as their migrations would be marked as not applied while their
+// changes are left as they are.
+func (s *Migration) Rollback(ctx context.Context) (*migrate.MigrationGroup, error) {
+	if err := s.checkRunnable(ctx); err != nil {
+		return nil, err
+	}
+
+	group, err := s.GetLastGroup(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var irreversible []string
+	for _, migration := range group.Migrations {
+		if migration.Down == nil {
+			irreversible = append(irreversible, migration.Name)
+		}
+	}
+	if len(irreversible) > 0 {
+		return nil, errors.Wrapf(ErrMigrationIrreversible, "run %d: %s", group.ID, strings.Join(irreversible, ", "))
+	}
+
+	return s.Migrator.Rollback(dbguard.WithQueryTimeout(ctx, 0))
+}
+
+// Unlock releases the lock of runs, e.g. when a run has crashed while holding it.
+func (s *Migration) Unlock(ctx context.Context) error {
+	if err := s.Migrator.Init(ctx); err != nil {
+		return err
+	}
+	return s.Migrator.Unlock(ctx)
+}
+
+// checkRunnable checks that migrations could be applied or rolled back by the binary right now.
+func (s *Migration) checkRunnable(ctx context.Context) error {
+	if err := s.Migrator.Init(ctx); err != nil {
+		return errors.Wrap(err, "failed to create migration tables")
+	}
+
+	locked, err := s.isLocked(ctx)
+	if err != nil {
+		return err
+	}
+	if locked {
+		return ErrMigrationLocked
+	}
+
+	set, err := s.migrationsWithStatus(ctx)
+	if err != nil {
+		return err
+	}
+	if len(set.unknown) > 0 {
+		return errors.Wrapf(ErrMigrationsUnknown, "unknown migrations: %s", set.unknown)
+	}
+	return nil
+}
+
+type migrationSet struct {
+	// known are migrations known to the binary in ascending order, with those applied having their run recorded
+	known migrate.MigrationSlice
+	// applied are migrations applied to the database, known to the binary or not
+	applied migrate.MigrationSlice
+	// unknown are migrations applied to the database but unknown to the binary
+	unknown migrate.MigrationSlice
+}
+
+// migrationsWithStatus is migrate.Migrator.MigrationsWithStatus, but also returns migrations unknown to the binary,
+// and does not require the migration tables to exist.
+func (s *Migration) migrationsWithStatus(ctx context.Context) (*migrationSet, error) {
+	result := &migrationSet{known: migrations.Migrations.Sorted()}
+
+	exists, err := s.tableExists(ctx, migrationsTable)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if err := s.DB.NewSelect().
+			ColumnExpr("*").
+			Model(&result.applied).
+			ModelTableExpr(migrationsTable).
+			Scan(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to get applied migrations")
+		}
+	}
+
+	appliedByName := make(map[string]*migrate.Migration, len(result.applied))
+	for i := range result.applied {
+		appliedByName[result.applied[i].Name] = &result.applied[i]
+	}
+	for i := range result.known {
+		migration := &result.known[i]
+		if applied, ok := appliedByName[migration.Name]; ok {
+			migration.ID, migration.GroupID, migration.MigratedAt = applied.ID, applied.GroupID, applied.MigratedAt
+			delete(appliedByName, migration.Name)
+		}
+	}
+	for i := range result.applied {
+		if _, ok := appliedByName[result.applied[i].Name]; ok {
+			result.unknown = append(result.unknown, result.applied[i])
+		}
+	}
+	return result, nil
+}
+
+func (s *Migration) isLocked(ctx context.Context) (bool, error) {
+	exists, err := s.tableExists(ctx, migrationLocksTable)
+	if err != nil || !exists {
+		return false, err
+	}
+	return s.DB.NewSelect().TableExpr(migrationLocksTable).Exists(ctx)
+}
+
+func (s *Migration) tableExists(ctx context.Context, table string) (bool, error) {
+	var exists bool
+	err := s.DB.QueryRowContext(ctx, "SELECT to_regclass(?) IS NOT NULL", table).Scan(&exists)
+	return exists, err
+}
+
+// CheckMigrations refuses to start the service while migrations are pending, unless allowed by
+// config.Config.MigrationsPendingAllowed. Migrations unknown to the binary are only warned about, as they are
+// expected while a newer binary is being rolled out.
+func CheckMigrations(s *Migration, conf *config.Config) error {
+	status, err := s.GetMigrationStatus(context.Background())
+	if err != nil {
+		return errors.Wrap(err, "failed to check migrations")
+	}
+
+	if len(status.Unknown) > 0 {
+		log.Warn().Strs("unknown", status.Unknown).Msg("the database has been migrated by a newer binary")
+	}
+	if status.Pending > 0 {
+		if !conf.MigrationsPendingAllowed {
+			return errors.Wrapf(ErrMigrationsPending, "%d pending", status.Pending)
+		}
+		log.Warn().Int("pending", status.Pending).Msg("migrations of the database schema are pending")
+	}
+	return nil
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// alertTimeout is the timeout of pushing an alert to a single channel.
+const alertTimeout = time.Second * 10
+
+var ErrAlertChannelNotFound = pgerr.ErrNotFound.Msg("alert channel not found")
+
+// alertColors are colors of Discord embeds of each severity.
+var alertColors = map[string]int{
+	constant.AlertSeverityInfo:     0x3498db,
+	constant.AlertSeverityWarning:  0xf1c40f,
+	constant.AlertSeverityCritical: 0xe74c3c,
+}
+
+// Alert is an ops alert pushed to alert channels.
+type Alert struct {
+	// Kind is one of constant.Alert*, by which the alert is routed to channels.
+	Kind string
+	// Severity is one of constant.AlertSeverity*.
+	Severity string
+	// Server is the server the alert is of, if any.
+	Server  string
+	Title   string
+	Message string
+	Fields  []AlertField
+	// DedupKey identifies the condition alerted, so that the same condition is not alerted again within
+	// config.Config AlertCooldown. Alerts without DedupKey are always pushed.
+	DedupKey string
+}
+
+type AlertField struct {
+	Name  string
+	Value string
+}
+
+// Notifier pushes formatted ops alerts to Discord and Telegram channels, routed by rules of config.Config
+// AlertChannels in effect, and checks ops conditions alerts are pushed for.
+type Notifier struct {
+	Redis          *redis.Client
+	DropReportRepo *repo.DropReport
+	RuntimeConfig  *RuntimeConfig
+}
+
+func NewNotifier(redisClient *redis.Client, dropReportRepo *repo.DropReport, runtimeConfig *RuntimeConfig) *Notifier {
+	return &Notifier{
+		Redis:          redisClient,
+		DropReportRepo: dropReportRepo,
+		RuntimeConfig:  runtimeConfig,
+	}
+}
+
+// Notify pushes alert to all channels it is routed to, unless the same alert has been pushed within
+// config.Config AlertCooldown. Failing to push is logged, as alerts are best-effort.
+func (s *Notifier) Notify(ctx context.Context, alert *Alert) {
+	conf := s.RuntimeConfig.Current()
+	channels := lo.Filter(conf.AlertChannels, func(channel config.AlertChannel, _ int) bool {
+		return alertRouted(&channel, alert)
+	})
+	if len(channels) == 0 {
+		return
+	}
+
+	cooldownKey := constant.AlertCooldownKeyPrefix + alert.DedupKey
+	if alert.DedupKey != "" && conf.AlertCooldown > 0 {
+		cooling, err := s.Redis.Exists(ctx, cooldownKey).Result()
+		if err != nil {
+			// alerts are rather pushed more than once than not at all
+			log.Warn().Err(err).Str("alert", alert.Kind).Msg("failed to check alert cooldown")
+		} else if cooling > 0 {
+			return
+		}
+	}
+
+	// the cooldown only starts once the alert has been pushed, so that alerts failed to push are pushed again
+	if !s.push(ctx, channels, alert) || alert.DedupKey == "" || conf.AlertCooldown <= 0 {
+		return
+	}
+	if err := s.Redis.Set(ctx, cooldownKey, time.Now().Unix(), conf.AlertCooldown).Err(); err != nil {
+		log.Warn().Err(err).Str("alert", alert.Kind).Msg("failed to start alert cooldown")
+	}
+}
+
+// NotifyChannel pushes a test alert to the channel named name right away, regardless of its routing rules.
+func (s *Notifier) NotifyChannel(ctx context.Context, name string) error {
+	channel, ok := lo.Find(s.RuntimeConfig.Current().AlertChannels, func(channel config.AlertChannel) bool {
+		return channel.Name == name
+	})
+	if !ok {
+		return ErrAlertChannelNotFound
+	}
+
+	return s.send(ctx, &channel, &Alert{
+		Kind:     constant.AlertTest,
+		Severity: constant.AlertSeverityInfo,
+		Title:    "Test alert",
+		Message:  "Alerts routed to channel `" + name + "` will be pushed here.",
+	})
+}
+
+// push pushes alert to channels concurrently, and returns whether it has been pushed to any of them.
+func (s *Notifier) push(ctx context.Context, channels []config.AlertChannel, alert *Alert) bool {
+	var (
+		wg     sync.WaitGroup
+		pushed int32
+	)
+	for i := range channels {
+		channel := &channels[i]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.send(ctx, channel, alert); err != nil {
+				log.Error().Err(err).Str("channel", channel.Name).Str("alert", alert.Kind).Msg("failed to push alert")
+				return
+			}
+			atomic.StoreInt32(&pushed, 1)
+		}()
+	}
+	wg.Wait()
+	return atomic.LoadInt32(&pushed) == 1
+}
+
+func (s *Notifier) send(ctx context.Context, channel *config.AlertChannel, alert *Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, alertTimeout)
+	defer cancel()
+
+	var (
+		url  string
+		body any
+	)
+	switch channel.Kind {
+	case config.AlertChannelDiscord:
+		url, body = channel.URL, discordAlert(alert)
+	case config.AlertChannelTelegram:
+		url = "https://api.telegram.org/bot" + channel.BotToken + "/sendMessage"
+		body = map[string]any{
+			"chat_id":                  channel.ChatID,
+			"text":                     telegramAlert(alert),
+			"parse_mode":               "HTML",
+			"disable_web_page_preview": true,
+		}
+	default:
+		return errors.Errorf("unknown alert channel kind %q", channel.Kind)
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// the error could contain the URL, which contains the secret of the channel
+		return errors.Errorf("failed to reach %s channel", channel.Kind)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errors.Errorf("%s channel responded with status %d", channel.Kind, resp.StatusCode)
+	}
+	return nil
+}
+
+// alertRouted returns whether alert is routed to channel by its rules.
+func alertRouted(channel *config.AlertChannel, alert *Alert) bool {
+	if len(channel.Alerts) > 0 && !lo.Contains(channel.Alerts, alert.Kind) {
+		return false
+	}
+	if len(channel.Servers) > 0 && alert.Server != "" && !lo.Contains(channel.Servers, alert.Server) {
+		return false
+	}
+	return constant.AlertSeverityRanks[alert.Severity] >= constant.AlertSeverityRanks[channel.MinSeverity]
+}
+
+// discordAlert formats alert as the body of a Discord webhook execution, with alert in an embed.
+func discordAlert(alert *Alert) map[string]any {
+	fields := make([]map[string]any, 0, len(alert.Fields)+1)
+	if alert.Server != "" {
+		fields = append(fields, map[string]any{"name": "Server", "value": alert.Server, "inline": true})
+	}
+	for _, field := range alert.Fields {
+		fields = append(fields, map[string]any{"name": field.Name, "value": field.Value, "inline": true})
+	}
+	return map[string]any{
+		"username": "Penguin Statistics",
+		"embeds": []map[string]any{{
+			"title":       "[" + strings.ToUpper(alert.Severity) + "] " + alert.Title,
+			"description": alert.Message,
+			"color":       alertColors[alert.Severity],
+			"fields":      fields,
+			"footer":      map[string]any{"text": alert.Kind},
+			"timestamp":   time.Now().UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+// telegramAlert formats alert as the HTML text of a Telegram message.
+func telegramAlert(alert *Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<b>[%s] %s</b>\n", strings.ToUpper(alert.Severity), html.EscapeString(alert.Title))
+	if alert.Message != "" {
+		b.WriteString(html.EscapeString(alert.Message) + "\n")
+	}
+	if alert.Server != "" {
+		fmt.Fprintf(&b, "\n<b>Server</b>: %s", html.EscapeString(alert.Server))
+	}
+	for _, field := range alert.Fields {
+		fmt.Fprintf(&b, "\n<b>%s</b>: %s", html.EscapeString(field.Name), html.EscapeString(field.Value))
+	}
+	fmt.Fprintf(&b, "\n\n<i>%s</i>", html.EscapeString(alert.Kind))
+	return b.String()
+}
+
+// CheckReportBacklog alerts if pending report tasks exceed config.Config AlertReportBacklogThreshold.
+func (s *Notifier) CheckReportBacklog(ctx context.Context, pending int) {
+	threshold := s.RuntimeConfig.Current().AlertReportBacklogThreshold
+	if threshold <= 0 || pending <= threshold {
+		return
+	}
+
+	severity := constant.AlertSeverityWarning
+	if pending > threshold*5 {
+		severity = constant.AlertSeverityCritical
+	}
+	s.Notify(ctx, &Alert{
+		Kind:     constant.AlertReportBacklog,
+		Severity: severity,
+		Title:    "Report backlog building up",
+		Message:  "Report tasks are submitted faster than workers consume them.",
+		Fields: []AlertField{
+			{Name: "Pending", Value: strconv.Itoa(pending)},
+			{Name: "Threshold", Value: strconv.Itoa(threshold)},
+		},
+		DedupKey: constant.AlertReportBacklog + ":" + severity,
+	})
+}
+
+// CheckRejectionSpikes alerts servers whose ratio of reports rejected by verifiers within config.Config
+// AlertRejectionWindow exceeds config.Config AlertRejectionSpikeRatio.
+func (s *Notifier) CheckRejectionSpikes(ctx context.Context) error {
+	conf := s.RuntimeConfig.Current()
+	if conf.AlertRejectionSpikeRatio <= 0 {
+		return nil
+	}
+
+	since := time.Now().Add(-conf.AlertRejectionWindow)
+	for _, server := range constant.Servers {
+		total, rejected, err := s.DropReportRepo.CalcRejectionsSince(ctx, server, since)
+		if err != nil {
+			return err
+		}
+		if total < conf.AlertRejectionMinReports {
+			continue
+		}
+		ratio := float64(rejected) / float64(total)
+		if ratio <= conf.AlertRejectionSpikeRatio {
+			continue
+		}
+
+		s.Notify(ctx, &Alert{
+			Kind:     constant.AlertRejectionSpike,
+			Severity: constant.AlertSeverityWarning,
+			Server:   server,
+			Title:    "Spike of rejected reports",
+			Message:  fmt.Sprintf("%.1f%% of reports within the last %s have been rejected by verifiers.", ratio*100, conf.AlertRejectionWindow),
+			Fields: []AlertField{
+				{Name: "Rejected", Value: strconv.Itoa(rejected)},
+				{Name: "Total", Value: strconv.Itoa(total)},
+			},
+			DedupKey: constant.AlertRejectionSpike + ":" + server,
+		})
+	}
+	return nil
+}
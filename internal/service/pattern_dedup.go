@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/util/reportutil"
+)
+
+// PatternDedup merges drop patterns of the same drops, which have been created as drops of the same item used to
+// be hashed separately, into one pattern of the canonical hash of the drops.
+type PatternDedup struct {
+	DB                     *bun.DB
+	DropPatternRepo        *repo.DropPattern
+	DropPatternElementRepo *repo.DropPatternElement
+	AggregateViewService   *AggregateView
+	PatternMatrixService   *PatternMatrix
+	CacheVersionService    *CacheVersion
+}
+
+func NewPatternDedup(db *bun.DB, dropPatternRepo *repo.DropPattern, dropPatternElementRepo *repo.DropPatternElement, aggregateViewService *AggregateView, patternMatrixService *PatternMatrix, cacheVersionService *CacheVersion) *PatternDedup {
+	return &PatternDedup{
+		DB:                     db,
+		DropPatternRepo:        dropPatternRepo,
+		DropPatternElementRepo: dropPatternElementRepo,
+		AggregateViewService:   aggregateViewService,
+		PatternMatrixService:   patternMatrixService,
+		CacheVersionService:    cacheVersionService,
+	}
+}
+
+// patternGroup is a group of drop patterns of the same drops, and whether any of them is not canonical.
+type patternGroup struct {
+	*types.PatternDuplicate
+	quantities map[int]int
+	survivor   *model.DropPattern
+	// elementsStale is whether elements of the survivor have to be rewritten to the canonical ones
+	elementsStale bool
+}
+
+// FindDuplicates returns groups of drop patterns of the same drops, and patterns whose hashes or elements are not
+// canonical, ordered by survivor ids.
+func (s *PatternDedup) FindDuplicates(ctx context.Context) ([]*types.PatternDuplicate, error) {
+	groups, err := s.findGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	duplicates := make([]*types.PatternDuplicate, 0, len(groups))
+	for _, group := range groups {
+		duplicates = append(duplicates, group.PatternDuplicate)
+	}
+	return duplicates, nil
+}
+
+// Dedup merges duplicate patterns into their survivors and canonicalizes survivors, one group at a time, so that it
+// could be run again after failing halfway. Pattern matrices of all servers are then recalculated for
+// sourceCategories.
+func (s *PatternDedup) Dedup(ctx context.Context, sourceCategories []string) (*types.PatternDedupResult, error) {
+	groups, err := s.findGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.PatternDedupResult{
+		Duplicates: make([]*types.PatternDuplicate, 0, len(groups)),
+	}
+	for _, group := range groups {
+		rewrittenHash := group.survivor.Hash != group.Hash
+		rewritten, err := s.merge(ctx, group)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to merge duplicates of pattern %d", group.SurvivorID)
+		}
+		result.Duplicates = append(result.Duplicates, group.PatternDuplicate)
+		result.MergedPatterns += len(group.DuplicateIDs)
+		result.RewrittenReports += rewritten
+
+		if group.elementsStale || len(group.DuplicateIDs) > 0 || rewrittenHash {
+			if err := s.CacheVersionService.Bump(ctx, constant.CacheVersionDropPattern, strconv.Itoa(group.SurvivorID)); err != nil {
+				log.Warn().Err(err).Int("patternId", group.SurvivorID).Msg("failed to invalidate cached drop pattern")
+			}
+		}
+	}
+	log.Info().
+		Int("groups", len(groups)).
+		Int("mergedPatterns", result.MergedPatterns).
+		Int64("rewrittenReports", result.RewrittenReports).
+		Msg("drop patterns deduplicated")
+
+	if result.RewrittenReports == 0 {
+		return result, nil
+	}
+	if s.AggregateViewService.Enabled() {
+		if err := s.AggregateViewService.RefreshViews(ctx); err != nil {
+			return nil, errors.Wrap(err, "failed to refresh aggregate views")
+		}
+	}
+	for _, server := range constant.Servers {
+		if err := s.PatternMatrixService.RefreshAllPatternMatrixElements(ctx, server, sourceCategories); err != nil {
+			return nil, errors.Wrapf(err, "failed to refresh pattern matrix of server %s", server)
+		}
+	}
+	return result, nil
+}
+
+// findGroups groups drop patterns by the canonical hash of their elements, or of their fingerprints for patterns
+// without elements, and returns groups which have duplicates or whose survivors are not canonical.
+func (s *PatternDedup) findGroups(ctx context.Context) ([]*patternGroup, error) {
+	patterns, err := s.DropPatternRepo.GetDropPatterns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	elements, err := s.DropPatternElementRepo.GetDropPatternElements(ctx)
+	if err != nil {
+		return nil, err
+	}
+	elementsByPatternId := make(map[int][]*model.DropPatternElement, len(patterns))
+	for _, element := range elements {
+		elementsByPatternId[element.DropPatternID] = append(elementsByPatternId[element.DropPatternID], element)
+	}
+
+	groupsByHash := make(map[string]*patternGroup)
+	patternsByHash := make(map[string][]*model.DropPattern)
+	staleByPatternId := make(map[int]bool)
+	for _, pattern := range patterns {
+		patternElements := elementsByPatternId[pattern.PatternID]
+		quantities := reportutil.PatternQuantities(patternElements)
+		if len(patternElements) == 0 {
+			quantities, err = reportutil.ParsePatternFingerprint(pattern.OriginalFingerprint)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to parse fingerprint of pattern %d", pattern.PatternID)
+			}
+		}
+
+		fingerprint, hash := reportutil.CanonicalPattern(quantities)
+		if _, ok := groupsByHash[hash]; !ok {
+			groupsByHash[hash] = &patternGroup{
+				PatternDuplicate: &types.PatternDuplicate{
+					Hash:         hash,
+					Fingerprint:  fingerprint,
+					DuplicateIDs: make([]int, 0),
+				},
+				quantities: quantities,
+			}
+		}
+		patternsByHash[hash] = append(patternsByHash[hash], pattern)
+		staleByPatternId[pattern.PatternID] = len(patternElements) != len(quantities)
+	}
+
+	groups := make([]*patternGroup, 0)
+	for hash, group := range groupsByHash {
+		members := patternsByHash[hash]
+		sort.Slice(members, func(i, j int) bool { return members[i].PatternID < members[j].PatternID })
+
+		// the pattern holding the canonical hash already is kept, as new reports are attached to it
+		group.survivor = members[0]
+		for _, member := range members {
+			if member.Hash == hash {
+				group.survivor = member
+				break
+			}
+		}
+		group.SurvivorID = group.survivor.PatternID
+		for _, member := range members {
+			if member != group.survivor {
+				group.DuplicateIDs = append(group.DuplicateIDs, member.PatternID)
+			}
+		}
+		group.elementsStale = staleByPatternId[group.SurvivorID]
+
+		canonical := group.survivor.Hash == group.Hash && group.survivor.OriginalFingerprint == group.Fingerprint
+		if len(group.DuplicateIDs) > 0 || !canonical || group.elementsStale {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].SurvivorID < groups[j].SurvivorID })
+	return groups, nil
+}
+
+// merge merges duplicates of group into its survivor and canonicalizes the survivor in a transaction, and returns
+// the number of drop reports rewritten.
+//
+// When no pattern held the canonical hash as groups were found, consumers could be creating the pattern of the
+// canonical hash concurrently. Rather than rewriting the hash of the survivor, which would then conflict on the
+// unique index of hash, the pattern of the canonical hash is created the same way consumers do, or selected if it
+// has been created since, and becomes the survivor, which the previous one is merged into along with duplicates.
+func (s *PatternDedup) merge(ctx context.Context, group *patternGroup) (rewritten int64, err error) {
+	err = s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if group.survivor.Hash != group.Hash {
+			canonical, created, err := s.DropPatternRepo.GetOrCreateDropPattern(ctx, tx, group.Hash, group.Fingerprint)
+			if err != nil {
+				return err
+			}
+			group.DuplicateIDs = append(group.DuplicateIDs, group.SurvivorID)
+			group.survivor = canonical
+			group.SurvivorID = canonical.PatternID
+			// elements of a pattern created by consumers are canonical already
+			group.elementsStale = created
+		}
+
+		if len(group.DuplicateIDs) > 0 {
+			rewritten, err = s.DropPatternRepo.MergeDropPatterns(ctx, tx, group.SurvivorID, group.DuplicateIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		if group.survivor.OriginalFingerprint != group.Fingerprint {
+			group.survivor.OriginalFingerprint = group.Fingerprint
+			if err := s.DropPatternRepo.UpdateDropPatternHash(ctx, tx, group.survivor); err != nil {
+				return err
+			}
+		}
+
+		if group.elementsStale {
+			if err := s.DropPatternElementRepo.DeleteDropPatternElementsByPatternId(ctx, tx, group.SurvivorID); err != nil {
+				return err
+			}
+			if len(group.quantities) == 0 {
+				return nil
+			}
+			drops := make([]*types.Drop, 0, len(group.quantities))
+			for itemId, quantity := range group.quantities {
+				drops = append(drops, &types.Drop{ItemID: itemId, Quantity: quantity})
+			}
+			if _, err := s.DropPatternElementRepo.CreateDropPatternElements(ctx, tx, group.SurvivorID, drops); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return rewritten, err
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/model/cache"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
 	"github.com/penguin-statistics/backend-next/internal/pkg/async"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
 	"github.com/penguin-statistics/backend-next/internal/pkg/wrap"
 	"github.com/penguin-statistics/backend-next/internal/util"
 )
@@ -25,6 +26,8 @@ type PatternMatrix struct {
 	DropPatternElementService   *DropPatternElement
 	StageService                *Stage
 	ItemService                 *Item
+	CacheVersionService         *CacheVersion
+	Locker                      *dlock.Locker
 }
 
 func NewPatternMatrix(
@@ -35,6 +38,8 @@ func NewPatternMatrix(
 	dropPatternElementService *DropPatternElement,
 	stageService *Stage,
 	itemService *Item,
+	cacheVersionService *CacheVersion,
+	locker *dlock.Locker,
 ) *PatternMatrix {
 	return &PatternMatrix{
 		TimeRangeService:            timeRangeService,
@@ -44,12 +49,14 @@ func NewPatternMatrix(
 		DropPatternElementService:   dropPatternElementService,
 		StageService:                stageService,
 		ItemService:                 itemService,
+		CacheVersionService:         cacheVersionService,
+		Locker:                      locker,
 	}
 }
 
 // Cache: shimLatestPatternMatrixResults#server:{server}, 24hrs, records last modified time
 func (s *PatternMatrix) GetShimLatestPatternMatrixResults(ctx context.Context, server string, accountId null.Int) (*modelv2.PatternMatrixQueryResult, error) {
-	valueFunc := func() (*modelv2.PatternMatrixQueryResult, error) {
+	valueFunc := func(ctx context.Context) (*modelv2.PatternMatrixQueryResult, error) {
 		queryResult, err := s.getLatestPatternMatrixResults(ctx, server, accountId, constant.SourceCategoryAll)
 		if err != nil {
 			return nil, err
@@ -63,7 +70,7 @@ func (s *PatternMatrix) GetShimLatestPatternMatrixResults(ctx context.Context, s
 
 	var results modelv2.PatternMatrixQueryResult
 	if !accountId.Valid {
-		calculated, err := cache.ShimLatestPatternMatrixResults.MutexGetSet(server, &results, valueFunc, 24*time.Hour)
+		calculated, err := cache.ShimLatestPatternMatrixResults.MutexGetSet(ctx, server, &results, valueFunc, 24*time.Hour)
 		if err != nil {
 			return nil, err
 		} else if calculated {
@@ -71,11 +78,19 @@ func (s *PatternMatrix) GetShimLatestPatternMatrixResults(ctx context.Context, s
 		}
 		return &results, nil
 	} else {
-		return valueFunc()
+		return valueFunc(ctx)
 	}
 }
 
+// RefreshAllPatternMatrixElements recalculates the whole pattern matrix of server. Refreshes of the pattern matrix of
+// the same server never run concurrently across instances.
 func (s *PatternMatrix) RefreshAllPatternMatrixElements(ctx context.Context, server string, sourceCategories []string) error {
+	return s.Locker.Do(ctx, constant.LockPatternMatrix+server, func(ctx context.Context) error {
+		return s.refreshAllPatternMatrixElements(ctx, server, sourceCategories)
+	})
+}
+
+func (s *PatternMatrix) refreshAllPatternMatrixElements(ctx context.Context, server string, sourceCategories []string) error {
 	timeRangesMap, err := s.TimeRangeService.GetTimeRangesMap(ctx, server)
 	if err != nil {
 		return err
@@ -105,7 +120,7 @@ func (s *PatternMatrix) RefreshAllPatternMatrixElements(ctx context.Context, ser
 	if err := s.PatternMatrixElementService.BatchSaveElements(ctx, elements, server); err != nil {
 		return err
 	}
-	return cache.ShimLatestPatternMatrixResults.Delete(server)
+	return s.CacheVersionService.Bump(ctx, constant.CacheVersionPatternMatrix, server)
 }
 
 func (s *PatternMatrix) getLatestPatternMatrixResults(ctx context.Context, server string, accountId null.Int, sourceCategory string) (*model.PatternMatrixQueryResult, error) {
@@ -191,12 +206,15 @@ func (s *PatternMatrix) calcPatternMatrixForTimeRanges(
 		}
 		combinedResults := s.combineQuantityAndTimesResults(quantityResults, timesResults)
 		for _, result := range combinedResults {
+			lower, upper := calcPatternConfidenceInterval(result.Quantity, result.Times)
 			results = append(results, &model.PatternMatrixElement{
 				StageID:        result.StageID,
 				PatternID:      result.PatternID,
 				RangeID:        timeRange.RangeID,
 				Quantity:       result.Quantity,
 				Times:          result.Times,
+				Lower:          lower,
+				Upper:          upper,
 				Server:         server,
 				SourceCategory: sourceCategory,
 			})
@@ -276,11 +294,18 @@ func (s *PatternMatrix) convertPatternMatrixElementsToDropPatternQueryResult(
 	}
 	for _, patternMatrixElement := range patternMatrixElements {
 		timeRange := timeRangesMap[patternMatrixElement.RangeID]
+		lower, upper := patternMatrixElement.Lower, patternMatrixElement.Upper
+		if upper == 0 {
+			// elements saved before intervals were introduced have no bounds until the next refresh
+			lower, upper = calcPatternConfidenceInterval(patternMatrixElement.Quantity, patternMatrixElement.Times)
+		}
 		result.PatternMatrix = append(result.PatternMatrix, &model.OnePatternMatrixElement{
 			StageID:   patternMatrixElement.StageID,
 			PatternID: patternMatrixElement.PatternID,
 			Quantity:  patternMatrixElement.Quantity,
 			Times:     patternMatrixElement.Times,
+			Lower:     lower,
+			Upper:     upper,
 			TimeRange: timeRange,
 		})
 	}
@@ -338,6 +363,8 @@ func (s *PatternMatrix) applyShimForPatternMatrixQuery(ctx context.Context, quer
 				StageID:   stage.ArkStageID,
 				Times:     oneDropPattern.Times,
 				Quantity:  oneDropPattern.Quantity,
+				Lower:     oneDropPattern.Lower,
+				Upper:     oneDropPattern.Upper,
 				StartTime: oneDropPattern.TimeRange.StartTime.UnixMilli(),
 				EndTime:   endTime,
 				Pattern:   &pattern,
@@ -350,3 +377,10 @@ func (s *PatternMatrix) applyShimForPatternMatrixQuery(ctx context.Context, quer
 	}
 	return results, nil
 }
+
+// calcPatternConfidenceInterval returns the rounded bounds of the Wilson score interval of the proportion of a
+// pattern that appeared quantity times out of times reports.
+func calcPatternConfidenceInterval(quantity int, times int) (lower float64, upper float64) {
+	lower, upper = util.CalcWilsonScoreInterval(quantity, times, constant.ConfidenceZ)
+	return util.RoundFloat64(lower, constant.ConfidenceBoundDigits), util.RoundFloat64(upper, constant.ConfidenceBoundDigits)
+}
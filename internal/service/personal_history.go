@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/gameday"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+type personalHistoryInterval struct {
+	length time.Duration
+	count  int
+	// start returns the start of the bucket containing t on server
+	start func(calendar *Calendar, server string, t time.Time) time.Time
+}
+
+// personalHistoryIntervals defines the bucket length, the number of buckets and the bucket boundaries of each
+// supported interval.
+var personalHistoryIntervals = map[string]personalHistoryInterval{
+	constant.PersonalHistoryIntervalDay:  {length: gameday.Day, count: 30, start: (*Calendar).DayStart},
+	constant.PersonalHistoryIntervalWeek: {length: gameday.Week, count: 12, start: (*Calendar).WeekStart},
+}
+
+// PersonalHistory aggregates reports of an account into time buckets, for rendering a personal farming history.
+type PersonalHistory struct {
+	DropReportService   *DropReport
+	StageService        *Stage
+	ItemService         *Item
+	CalendarService     *Calendar
+	CacheVersionService *CacheVersion
+}
+
+func NewPersonalHistory(dropReportService *DropReport, stageService *Stage, itemService *Item, calendarService *Calendar, cacheVersionService *CacheVersion) *PersonalHistory {
+	return &PersonalHistory{
+		DropReportService:   dropReportService,
+		StageService:        stageService,
+		ItemService:         itemService,
+		CalendarService:     calendarService,
+		CacheVersionService: cacheVersionService,
+	}
+}
+
+// GetPersonalHistory returns times and quantities of reports of accountId per stage and item, bucketed by interval,
+// which is either constant.PersonalHistoryIntervalDay or constant.PersonalHistoryIntervalWeek.
+// Buckets without any report are omitted.
+//
+// Like personal drop matrices, personal histories are cached until the account submits or recalls a report, and
+// otherwise only expire to roll the buckets forward.
+//
+// Cache: (tiered) personalHistoryResults#accountId|version|server|interval:{accountId}|{version}|{server}|{interval},
+// 10 mins in-process, 1 hr in Redis
+func (s *PersonalHistory) GetPersonalHistory(ctx context.Context, server string, accountId int, interval string) (*modelv2.PersonalHistoryQueryResult, error) {
+	spec, ok := personalHistoryIntervals[interval]
+	if !ok {
+		return nil, pgerr.ErrInvalidReq.Msg("invalid interval `%s`", interval)
+	}
+
+	version, err := s.CacheVersionService.Version(ctx, constant.CacheVersionPersonal, strconv.Itoa(accountId))
+	if err != nil {
+		return nil, err
+	}
+	key := strings.Join([]string{strconv.Itoa(accountId), strconv.FormatInt(version, 10), server, interval}, constant.CacheSep)
+	result, err := cache.PersonalHistoryResults.GetSet(ctx, key, func(ctx context.Context) (modelv2.PersonalHistoryQueryResult, error) {
+		result, err := s.calcPersonalHistory(ctx, server, accountId, interval, spec)
+		if err != nil {
+			return modelv2.PersonalHistoryQueryResult{}, err
+		}
+		return *result, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *PersonalHistory) calcPersonalHistory(
+	ctx context.Context, server string, accountId int, interval string, spec personalHistoryInterval,
+) (*modelv2.PersonalHistoryQueryResult, error) {
+	// the last bucket is the one containing now
+	startTime := spec.start(s.CalendarService, server, time.Now().Add(-spec.length*time.Duration(spec.count-1)))
+
+	timesResults, err := s.DropReportService.CalcPersonalTimesByInterval(ctx, server, accountId, startTime, spec.length, spec.count)
+	if err != nil {
+		return nil, err
+	}
+	quantityResults, err := s.DropReportService.CalcPersonalQuantityByInterval(ctx, server, accountId, startTime, spec.length, spec.count)
+	if err != nil {
+		return nil, err
+	}
+
+	stagesMapById, err := s.StageService.GetStagesMapById(ctx)
+	if err != nil {
+		return nil, err
+	}
+	itemsMapById, err := s.ItemService.GetItemsMapById(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type stageKey struct {
+		groupId int
+		stageId int
+	}
+	buckets := make(map[int]*modelv2.PersonalHistoryBucket)
+	stages := make(map[stageKey]*modelv2.PersonalHistoryStage)
+	result := &modelv2.PersonalHistoryQueryResult{
+		Interval: interval,
+		Buckets:  make([]*modelv2.PersonalHistoryBucket, 0),
+	}
+	for _, el := range timesResults {
+		stage, ok := stagesMapById[el.StageID]
+		if !ok {
+			continue
+		}
+		bucket, ok := buckets[el.GroupID]
+		if !ok {
+			bucket = &modelv2.PersonalHistoryBucket{
+				StartTime: el.IntervalStart.UnixMilli(),
+				EndTime:   el.IntervalEnd.UnixMilli(),
+				Stages:    make([]*modelv2.PersonalHistoryStage, 0),
+			}
+			buckets[el.GroupID] = bucket
+		}
+		oneStage := &modelv2.PersonalHistoryStage{
+			StageID: stage.ArkStageID,
+			Times:   el.TotalTimes,
+			Items:   make([]*modelv2.PersonalHistoryItem, 0),
+		}
+		bucket.Stages = append(bucket.Stages, oneStage)
+		stages[stageKey{groupId: el.GroupID, stageId: el.StageID}] = oneStage
+	}
+
+	for _, el := range quantityResults {
+		oneStage, ok := stages[stageKey{groupId: el.GroupID, stageId: el.StageID}]
+		if !ok || oneStage.Times == 0 {
+			continue
+		}
+		item, ok := itemsMapById[el.ItemID]
+		if !ok {
+			continue
+		}
+		oneStage.Items = append(oneStage.Items, &modelv2.PersonalHistoryItem{
+			ItemID:   item.ArkItemID,
+			Quantity: el.TotalQuantity,
+			Rate:     float64(el.TotalQuantity) / float64(oneStage.Times),
+		})
+	}
+
+	for groupId := 0; groupId < spec.count; groupId++ {
+		if bucket, ok := buckets[groupId]; ok {
+			result.Buckets = append(result.Buckets, bucket)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/lp"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+const (
+	// planDigits is the number of digits runs, quantities and sanity of plans are rounded to.
+	planDigits = 2
+	// planMinRuns is the number of runs below which a stage is considered not farmed by a plan, as solutions carry
+	// rounding errors.
+	planMinRuns = 1e-6
+)
+
+// Planner plans farming with the least sanity expected to obtain items required, by solving a linear program over
+// drop rates of the drop matrix and sanity costs of stages.
+type Planner struct {
+	DropMatrixService *DropMatrix
+	StageService      *Stage
+	ItemService       *Item
+	RuntimeConfig     *RuntimeConfig
+}
+
+func NewPlanner(dropMatrixService *DropMatrix, stageService *Stage, itemService *Item, runtimeConfig *RuntimeConfig) *Planner {
+	return &Planner{
+		DropMatrixService: dropMatrixService,
+		StageService:      stageService,
+		ItemService:       itemService,
+		RuntimeConfig:     runtimeConfig,
+	}
+}
+
+// Plan returns the plan of req. Only stages currently open, of known sanity costs and sampled at least as many times
+// as the low sample threshold of drop matrices are farmed.
+//
+// Farming is planned as the linear program of minimizing the sanity spent, i.e. the sum of runs of each stage times
+// its sanity cost, subject to the expected drops of each item required being at least the quantity required. The
+// program is solved as its dual, which is always feasible, and the runs are then the shadow prices of the dual.
+func (s *Planner) Plan(ctx context.Context, req *types.PlanRequest) (*modelv2.Plan, error) {
+	// quantities of the same item required more than once are summed up
+	quantities := make(map[string]int, len(req.Requirements))
+	for _, requirement := range req.Requirements {
+		if _, err := s.ItemService.GetItemByArkId(ctx, requirement.ItemID); err != nil {
+			return nil, err
+		}
+		quantities[requirement.ItemID] += requirement.Quantity
+	}
+	itemIds := lo.Keys(quantities)
+	sort.Strings(itemIds)
+	itemIndices := make(map[string]int, len(itemIds))
+	for i, itemId := range itemIds {
+		itemIndices[itemId] = i
+	}
+
+	result, err := s.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx, req.Server, false, "", "", null.NewInt(0, false))
+	if err != nil {
+		return nil, err
+	}
+	stagesMap, err := s.StageService.GetStagesMapByArkId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := make(map[string]bool, len(req.ExcludeStages))
+	for _, stageId := range req.ExcludeStages {
+		excluded[stageId] = true
+	}
+	threshold := s.RuntimeConfig.Current().MatrixLowSampleThreshold
+	ratesByStageId := make(map[string][]float64)
+	for _, el := range result.Matrix {
+		index, required := itemIndices[el.ItemID]
+		if !required || excluded[el.StageID] || el.Times == 0 || el.Times < threshold || el.Quantity == 0 {
+			continue
+		}
+		if stage, ok := stagesMap[el.StageID]; !ok || !stage.Sanity.Valid {
+			continue
+		}
+		if _, ok := ratesByStageId[el.StageID]; !ok {
+			ratesByStageId[el.StageID] = make([]float64, len(itemIds))
+		}
+		ratesByStageId[el.StageID][index] = float64(el.Quantity) / float64(el.Times)
+	}
+
+	unobtainable := lo.Filter(itemIds, func(itemId string, i int) bool {
+		return !lo.ContainsBy(lo.Values(ratesByStageId), func(rates []float64) bool { return rates[i] > 0 })
+	})
+	if len(unobtainable) > 0 {
+		return nil, pgerr.ErrInvalidReq.Msg("items `%s` are not dropped by any stage available", strings.Join(unobtainable, "`, `"))
+	}
+
+	stageIds := lo.Keys(ratesByStageId)
+	sort.Strings(stageIds)
+	rates := make([][]float64, 0, len(stageIds))
+	apCosts := make([]float64, 0, len(stageIds))
+	for _, stageId := range stageIds {
+		rates = append(rates, ratesByStageId[stageId])
+		apCosts = append(apCosts, float64(stagesMap[stageId].Sanity.Int64))
+	}
+	required := make([]float64, 0, len(itemIds))
+	for _, itemId := range itemIds {
+		required = append(required, float64(quantities[itemId]))
+	}
+
+	solution, err := lp.Maximize(required, rates, apCosts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to solve farming plan")
+	}
+
+	plan := &modelv2.Plan{
+		Server:  req.Server,
+		TotalAp: util.RoundFloat64(solution.Value, planDigits),
+		Stages:  make([]*modelv2.PlanStage, 0),
+	}
+	for i, runs := range solution.Duals {
+		if runs < planMinRuns {
+			continue
+		}
+		planStage := &modelv2.PlanStage{
+			StageID: stageIds[i],
+			Runs:    util.RoundFloat64(runs, planDigits),
+			ApCost:  int(apCosts[i]),
+			Items:   make([]*modelv2.PlanItem, 0),
+		}
+		for j, rate := range rates[i] {
+			if rate > 0 {
+				planStage.Items = append(planStage.Items, &modelv2.PlanItem{
+					ItemID:   itemIds[j],
+					Quantity: util.RoundFloat64(runs*rate, planDigits),
+				})
+			}
+		}
+		plan.Stages = append(plan.Stages, planStage)
+	}
+	sort.SliceStable(plan.Stages, func(i, j int) bool {
+		return plan.Stages[i].Runs*float64(plan.Stages[i].ApCost) > plan.Stages[j].Runs*float64(plan.Stages[j].ApCost)
+	})
+	return plan, nil
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/async"
+	"github.com/penguin-statistics/backend-next/internal/pkg/precompress"
+)
+
+// Precompressed keeps responses of cached aggregates compressed ahead in Redis, in each encoding of config.Config
+// PrecompressEncodings, so that traffic spikes on those aggregates are served without compressing the same body
+// over and over on every instance.
+type Precompressed struct {
+	Redis         *redis.Client
+	RuntimeConfig *RuntimeConfig
+
+	// flight coalesces compressions of the same response, as compressing at the best levels is expensive
+	flight async.Flight[*precompress.Entry]
+}
+
+func NewPrecompressed(redisClient *redis.Client, runtimeConfig *RuntimeConfig) *Precompressed {
+	return &Precompressed{
+		Redis:         redisClient,
+		RuntimeConfig: runtimeConfig,
+	}
+}
+
+// Encodings returns encodings responses are currently compressed in.
+func (s *Precompressed) Encodings() []string {
+	return s.RuntimeConfig.Current().PrecompressEncodings
+}
+
+// Compress compresses body in each encoding responses are currently compressed in. body is kept as is when no
+// encoding is enabled.
+func (s *Precompressed) Compress(body []byte) (*precompress.Entry, error) {
+	encodings := s.Encodings()
+	if len(encodings) == 0 {
+		return &precompress.Entry{Bodies: map[string][]byte{precompress.EncodingIdentity: body}}, nil
+	}
+	return precompress.Compress(body, encodings)
+}
+
+// Get returns the precompressed response of key in encoding, which is EncodingIdentity for the uncompressed
+// response. On a miss, the response is compressed in encoding only, from the uncompressed response kept along, which
+// is rendered by render and encoded in JSON if it is missing as well. Responses are kept for config.Config
+// PrecompressTTL. Keys shall carry the version of their aggregate, and anything else the response depends on, so
+// that responses are never served after the aggregate has changed.
+func (s *Precompressed) Get(ctx context.Context, key string, encoding string, render func() (any, error)) (*precompress.Entry, error) {
+	redisKey := constant.PrecompressedKeyPrefix + key
+	values, err := s.Redis.HMGet(ctx, redisKey, encoding, precompress.EncodingIdentity).Result()
+	if err != nil {
+		return nil, err
+	}
+	if body, ok := values[0].(string); ok {
+		return &precompress.Entry{Bodies: map[string][]byte{encoding: []byte(body)}}, nil
+	}
+
+	return s.flight.Do(key+constant.CacheSep+encoding, func() (*precompress.Entry, error) {
+		fields := make(map[string]any, 2)
+		identity, ok := values[1].(string)
+		body := []byte(identity)
+		if !ok {
+			v, err := render()
+			if err != nil {
+				return nil, err
+			}
+			body, err = json.Marshal(v)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to encode precompressed response")
+			}
+			fields[precompress.EncodingIdentity] = body
+		}
+
+		entry := &precompress.Entry{Bodies: map[string][]byte{precompress.EncodingIdentity: body}}
+		if encoding != precompress.EncodingIdentity {
+			compressed, err := precompress.CompressIn(body, encoding)
+			if err != nil {
+				return nil, err
+			}
+			entry.Bodies[encoding] = compressed
+			fields[encoding] = compressed
+		}
+
+		_, err = s.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, redisKey, fields)
+			pipe.Expire(ctx, redisKey, s.RuntimeConfig.Current().PrecompressTTL)
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to save precompressed response")
+		}
+		return entry, nil
+	})
+}
+
+// precompressedFieldsOf returns the fields of a Redis hash holding the bodies of entry, keyed by their encodings.
+func precompressedFieldsOf(entry *precompress.Entry) map[string]any {
+	fields := make(map[string]any, len(entry.Bodies))
+	for encoding, body := range entry.Bodies {
+		fields[encoding] = body
+	}
+	return fields
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"github.com/zeebo/xxh3"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/async"
+	"github.com/penguin-statistics/backend-next/internal/pkg/precompress"
+)
+
+// publicAggregateTTL is how long a rendered public aggregate is served for without being rendered again, so that an
+// aggregate is not served for long after its worker has stopped.
+const publicAggregateTTL = time.Hour * 24
+
+// PublicAggregateMeta describes a rendered public aggregate, which is kept apart from its bodies so that requests
+// revalidated are responded without reading any body.
+type PublicAggregateMeta struct {
+	GeneratedAt time.Time
+	// ETag is the hash of the uncompressed JSON of the aggregate.
+	ETag string
+	// Encodings are the encodings the aggregate is held in.
+	Encodings []string
+}
+
+// PublicAggregateBlob is a rendered public aggregate.
+type PublicAggregateBlob struct {
+	PublicAggregateMeta
+	// Entry is the JSON of the aggregate, compressed in each encoding of config.Config PrecompressEncodings.
+	Entry *precompress.Entry
+}
+
+// PublicAggregate renders modelv2.PublicAggregate of every server into a single precompressed JSON blob held in
+// Redis, so that third-party tools are served without calculating anything, and without compressing responses per
+// request.
+type PublicAggregate struct {
+	DropMatrixService    *DropMatrix
+	StageService         *Stage
+	ItemService          *Item
+	PrecompressedService *Precompressed
+	Redis                *redis.Client
+
+	// flight coalesces renders of the same server on instances the aggregate has not been rendered for yet
+	flight async.Flight[*PublicAggregateBlob]
+}
+
+func NewPublicAggregate(dropMatrixService *DropMatrix, stageService *Stage, itemService *Item, precompressedService *Precompressed, redisClient *redis.Client) *PublicAggregate {
+	return &PublicAggregate{
+		DropMatrixService:    dropMatrixService,
+		StageService:         stageService,
+		ItemService:          itemService,
+		PrecompressedService: precompressedService,
+		Redis:                redisClient,
+	}
+}
+
+// Render renders the public aggregate of server and saves it to Redis, replacing the former one.
+func (s *PublicAggregate) Render(ctx context.Context, server string) (*PublicAggregateBlob, error) {
+	matrix, err := s.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx, server, true, "", "", null.NewInt(0, false))
+	if err != nil {
+		return nil, err
+	}
+	matrix = s.DropMatrixService.GateLowSample(matrix, "")
+	stages, err := s.StageService.GetShimStages(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.ItemService.GetShimItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	generatedAt := time.Now()
+	body, err := json.Marshal(&modelv2.PublicAggregate{
+		Server:      server,
+		GeneratedAt: generatedAt.UnixMilli(),
+		Matrix:      matrix.Matrix,
+		Stages:      stages,
+		Items:       items,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode public aggregate")
+	}
+	entry, err := s.PrecompressedService.Compress(body)
+	if err != nil {
+		return nil, err
+	}
+
+	blob := &PublicAggregateBlob{
+		PublicAggregateMeta: PublicAggregateMeta{
+			GeneratedAt: generatedAt,
+			ETag:        strconv.FormatUint(xxh3.Hash(body), 16),
+			Encodings:   lo.Keys(entry.Bodies),
+		},
+		Entry: entry,
+	}
+	sort.Strings(blob.Encodings)
+	bodyKey := constant.PublicAggregateKeyPrefix + server
+	metaKey := constant.PublicAggregateMetaKeyPrefix + server
+	_, err = s.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		// bodies of encodings no longer enabled are dropped along with the former aggregate
+		pipe.Del(ctx, bodyKey)
+		pipe.HSet(ctx, bodyKey, precompressedFieldsOf(entry))
+		pipe.Expire(ctx, bodyKey, publicAggregateTTL)
+		pipe.HSet(ctx, metaKey,
+			constant.PublicAggregateFieldGeneratedAt, generatedAt.UnixMilli(),
+			constant.PublicAggregateFieldETag, blob.ETag,
+			constant.PublicAggregateFieldEncodings, strings.Join(blob.Encodings, ","),
+		)
+		pipe.Expire(ctx, metaKey, publicAggregateTTL)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to save public aggregate")
+	}
+	log.Info().
+		Str("server", server).
+		Int("elements", len(matrix.Matrix)).
+		Int("size", len(body)).
+		Msg("public aggregate rendered")
+	return blob, nil
+}
+
+// GetPublicAggregateMeta returns the description of the rendered public aggregate of server, which is rendered right
+// away if it has not been rendered yet.
+func (s *PublicAggregate) GetPublicAggregateMeta(ctx context.Context, server string) (*PublicAggregateMeta, error) {
+	fields, err := s.Redis.HGetAll(ctx, constant.PublicAggregateMetaKeyPrefix+server).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		blob, err := s.render(ctx, server)
+		if err != nil {
+			return nil, err
+		}
+		return &blob.PublicAggregateMeta, nil
+	}
+
+	generatedAt, err := strconv.ParseInt(fields[constant.PublicAggregateFieldGeneratedAt], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid generation time of public aggregate")
+	}
+	return &PublicAggregateMeta{
+		GeneratedAt: time.UnixMilli(generatedAt),
+		ETag:        fields[constant.PublicAggregateFieldETag],
+		Encodings:   strings.Split(fields[constant.PublicAggregateFieldEncodings], ","),
+	}, nil
+}
+
+// GetPublicAggregateEntry returns the entry of the rendered public aggregate of server described by meta, holding
+// only its body in encoding, or in any encoding meta holds if not in encoding. The aggregate is rendered right away
+// if it has expired since meta was read.
+func (s *PublicAggregate) GetPublicAggregateEntry(ctx context.Context, server string, meta *PublicAggregateMeta, encoding string) (*precompress.Entry, error) {
+	if !lo.Contains(meta.Encodings, encoding) {
+		encoding = meta.Encodings[0]
+	}
+	body, err := s.Redis.HGet(ctx, constant.PublicAggregateKeyPrefix+server, encoding).Bytes()
+	if errors.Is(err, redis.Nil) {
+		blob, err := s.render(ctx, server)
+		if err != nil {
+			return nil, err
+		}
+		return blob.Entry, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &precompress.Entry{Bodies: map[string][]byte{encoding: body}}, nil
+}
+
+// render renders the public aggregate of server on behalf of requests, coalescing renders of the same server.
+func (s *PublicAggregate) render(ctx context.Context, server string) (*PublicAggregateBlob, error) {
+	return s.flight.Do(server, func() (*PublicAggregateBlob, error) {
+		return s.Render(ctx, server)
+	})
+}
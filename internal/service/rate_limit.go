@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"math"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgid"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+// tokenBucketScript takes a token from the bucket KEYS[1] of capacity ARGV[1], refilled by ARGV[2] tokens per
+// millisecond, and returns whether the token has been taken along with the tokens left. Time is taken from Redis,
+// so that instances with skewed clocks share the same buckets consistently.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(bucket[1]) or capacity
+local ts = tonumber(bucket[2]) or now
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", now)
+redis.call("PEXPIRE", KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// RateLimit limits requests with token buckets in Redis, per API key, or per account and per IP. Requests with an
+// API key are only limited by the bucket of the key, while the others are limited by the bucket of their IP, and
+// the bucket of their account as well if they have one.
+// Tiers are read from RuntimeConfig on each request, so that they could be tuned without restarting.
+type RateLimit struct {
+	Redis          *redis.Client
+	AccountService *Account
+	RuntimeConfig  *RuntimeConfig
+}
+
+func NewRateLimit(redisClient *redis.Client, accountService *Account, runtimeConfig *RuntimeConfig) *RateLimit {
+	return &RateLimit{
+		Redis:          redisClient,
+		AccountService: accountService,
+		RuntimeConfig:  runtimeConfig,
+	}
+}
+
+// rateLimitBucket is a token bucket a request is counted towards.
+type rateLimitBucket struct {
+	key  string
+	tier config.RateLimitTier
+}
+
+// Take counts the request of ctx towards its buckets, and returns the state of the most restrictive one: the first
+// bucket rejecting the request, or the one with the fewest requests remaining. Buckets after the one rejecting the
+// request are not counted. Nil is returned if the tiers of all buckets of the request are disabled.
+func (s *RateLimit) Take(ctx *fiber.Ctx) (*types.RateLimitStatus, error) {
+	var result *types.RateLimitStatus
+	for _, bucket := range s.buckets(ctx) {
+		if !bucket.tier.Enabled() {
+			continue
+		}
+		status, err := s.take(ctx.Context(), bucket.key, bucket.tier)
+		if err != nil {
+			return nil, err
+		}
+		if !status.Allowed {
+			return status, nil
+		}
+		if result == nil || status.Remaining < result.Remaining {
+			result = status
+		}
+	}
+	return result, nil
+}
+
+// buckets returns the buckets the request of ctx is counted towards, along with the tiers they are limited by.
+// PenguinIDs which do not belong to any account are ignored.
+func (s *RateLimit) buckets(ctx *fiber.Ctx) []rateLimitBucket {
+	conf := s.RuntimeConfig.Current()
+	if keyId, ok := ctx.Locals(constant.ContextKeyAPIKeyID).(int); ok {
+		return []rateLimitBucket{{key: constant.RateLimitKeyPrefix + "apikey:" + strconv.Itoa(keyId), tier: conf.RateLimitAPIKey}}
+	}
+
+	buckets := []rateLimitBucket{{key: constant.RateLimitKeyPrefix + "ip:" + util.ExtractIP(ctx), tier: conf.RateLimitIP}}
+	if penguinId := pgid.Extract(ctx); penguinId != "" {
+		if account, err := s.AccountService.GetAccountByPenguinId(ctx.Context(), penguinId); err == nil {
+			buckets = append(buckets, rateLimitBucket{key: constant.RateLimitKeyPrefix + "account:" + strconv.Itoa(account.AccountID), tier: conf.RateLimitAccount})
+		}
+	}
+	return buckets
+}
+
+func (s *RateLimit) take(ctx context.Context, key string, tier config.RateLimitTier) (*types.RateLimitStatus, error) {
+	// tokens refilled per millisecond
+	rate := float64(tier.Requests) / float64(tier.Period.Milliseconds())
+
+	result, err := tokenBucketScript.Run(ctx, s.Redis, []string{key}, tier.Requests, rate).Slice()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to take rate limit token")
+	}
+	if len(result) != 2 {
+		return nil, errors.Errorf("unexpected rate limit script result: %v", result)
+	}
+	allowed, _ := result[0].(int64)
+	tokensStr, _ := result[1].(string)
+	tokens, err := strconv.ParseFloat(tokensStr, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse rate limit tokens")
+	}
+
+	status := &types.RateLimitStatus{
+		Limit:     tier.Requests,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     int(math.Ceil((float64(tier.Requests) - tokens) / rate / 1000)),
+		Allowed:   allowed == 1,
+	}
+	if !status.Allowed {
+		status.RetryAfter = int(math.Ceil((1 - tokens) / rate / 1000))
+	}
+	return status, nil
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var (
+	ErrRecognitionBundleNotFound  = pgerr.ErrNotFound.Msg("recognition bundle not found")
+	ErrRecognitionReleaseNotFound = pgerr.ErrNotFound.Msg("no recognition bundle has been released for this server")
+)
+
+// Recognition serves bundles of item recognition definitions, which are addressed by the hash of their content, so
+// that recognizers always fetch a consistent set of definitions. Each server points to the latest bundle of it,
+// which admins release after uploading the bundle.
+type Recognition struct {
+	RecognitionRepo     *repo.Recognition
+	CacheVersionService *CacheVersion
+}
+
+func NewRecognition(recognitionRepo *repo.Recognition, cacheVersionService *CacheVersion) *Recognition {
+	return &Recognition{
+		RecognitionRepo:     recognitionRepo,
+		CacheVersionService: cacheVersionService,
+	}
+}
+
+func (s *Recognition) GetBundles(ctx context.Context) ([]*model.RecognitionBundle, error) {
+	return s.RecognitionRepo.GetBundles(ctx)
+}
+
+// UploadBundle stores content as a bundle of version, addressed by its SHA-256 hash. Uploading the same content
+// again returns the bundle already stored, which keeps its version.
+func (s *Recognition) UploadBundle(ctx context.Context, version string, contentType string, content []byte) (*model.RecognitionBundle, error) {
+	if len(content) == 0 {
+		return nil, pgerr.ErrInvalidReq.Msg("recognition bundle is empty")
+	}
+	if contentType == "" {
+		contentType = constant.RecognitionBundleDefaultContentType
+	}
+
+	sum := sha256.Sum256(content)
+	bundle := &model.RecognitionBundle{
+		Hash:        hex.EncodeToString(sum[:]),
+		Version:     version,
+		ContentType: contentType,
+		Size:        int64(len(content)),
+		Content:     content,
+	}
+	created, err := s.RecognitionRepo.CreateBundle(ctx, bundle)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return s.RecognitionRepo.GetBundleByHash(ctx, bundle.Hash)
+	}
+
+	audit.After(ctx, bundle)
+	log.Info().Str("hash", bundle.Hash).Str("version", version).Int64("size", bundle.Size).Msg("recognition bundle uploaded")
+	return bundle, nil
+}
+
+// GetBundleContent returns the content of the bundle of hash, which is cached as bundles never change.
+func (s *Recognition) GetBundleContent(ctx context.Context, hash string) (*model.RecognitionBundleContent, error) {
+	content, err := cache.RecognitionBundleContentByHash.GetSet(ctx, hash, func(ctx context.Context) (model.RecognitionBundleContent, error) {
+		content, err := s.RecognitionRepo.GetBundleContentByHash(ctx, hash)
+		if err != nil {
+			return model.RecognitionBundleContent{}, err
+		}
+		return *content, nil
+	})
+	if errors.Is(err, pgerr.ErrNotFound) {
+		return nil, ErrRecognitionBundleNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return &content, nil
+}
+
+func (s *Recognition) GetReleases(ctx context.Context) ([]*model.RecognitionRelease, error) {
+	return s.RecognitionRepo.GetReleases(ctx)
+}
+
+// Release makes the bundle of hash the latest one of server. Releasing a bundle released before rolls the server
+// back to it.
+func (s *Recognition) Release(ctx context.Context, server string, hash string) (*model.RecognitionRelease, error) {
+	if _, err := s.RecognitionRepo.GetBundleByHash(ctx, hash); err != nil {
+		if errors.Is(err, pgerr.ErrNotFound) {
+			return nil, ErrRecognitionBundleNotFound
+		}
+		return nil, err
+	}
+
+	previous, err := s.RecognitionRepo.GetReleaseByServer(ctx, server)
+	if err == nil {
+		audit.Before(ctx, previous)
+	} else if !errors.Is(err, pgerr.ErrNotFound) {
+		return nil, err
+	}
+
+	release := &model.RecognitionRelease{
+		Server: server,
+		Hash:   hash,
+	}
+	if err := s.RecognitionRepo.SaveRelease(ctx, release); err != nil {
+		return nil, err
+	}
+	audit.After(ctx, release)
+
+	if err := s.CacheVersionService.Bump(ctx, constant.CacheVersionRecognitionRelease, server); err != nil {
+		log.Warn().Err(err).Str("server", server).Msg("failed to invalidate cached recognition release")
+	}
+	log.Info().Str("server", server).Str("hash", hash).Msg("recognition bundle released")
+	return release, nil
+}
+
+// GetLatestRelease returns the latest bundle of server.
+//
+// Cache: recognitionRelease#server:{server}, 24 hrs; invalidated on release
+func (s *Recognition) GetLatestRelease(ctx context.Context, server string) (*modelv2.RecognitionRelease, error) {
+	valueFunc := func(ctx context.Context) (*modelv2.RecognitionRelease, error) {
+		release, err := s.RecognitionRepo.GetReleaseByServer(ctx, server)
+		if errors.Is(err, pgerr.ErrNotFound) {
+			return nil, ErrRecognitionReleaseNotFound
+		} else if err != nil {
+			return nil, err
+		}
+
+		bundle, err := s.RecognitionRepo.GetBundleByHash(ctx, release.Hash)
+		if err != nil {
+			return nil, err
+		}
+
+		return &modelv2.RecognitionRelease{
+			Server:      server,
+			Hash:        bundle.Hash,
+			Version:     bundle.Version,
+			Size:        bundle.Size,
+			ContentType: bundle.ContentType,
+			ReleasedAt:  release.UpdatedAt.UnixMilli(),
+			URL:         constant.RecognitionBundleURLPrefix + bundle.Hash,
+		}, nil
+	}
+
+	var release modelv2.RecognitionRelease
+	if _, err := cache.RecognitionReleaseByServer.MutexGetSet(ctx, server, &release, valueFunc, 24*time.Hour); err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
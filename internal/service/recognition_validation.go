@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+// RecognitionValidation cross-checks the raw output of recognizers submitted along with reports, so that whether a
+// recognized report is trusted is decided by the server instead of the recognizer. Thresholds are read from
+// RuntimeConfig.
+type RecognitionValidation struct {
+	RuntimeConfig   *RuntimeConfig
+	DropTypeService *DropType
+}
+
+func NewRecognitionValidation(runtimeConfig *RuntimeConfig, dropTypeService *DropType) *RecognitionValidation {
+	return &RecognitionValidation{
+		RuntimeConfig:   runtimeConfig,
+		DropTypeService: dropTypeService,
+	}
+}
+
+// Validate cross-checks the recognition output of metadata against drops of a report from source. It returns why
+// the report shall be refused if the output contradicts the drops, has overlapping items, or has items of confidence
+// below RecognitionRejectConfidence. Otherwise it returns why the report shall be flagged if any confidence is below
+// RecognitionFlagConfidence, or if the output is omitted while source is one of RecognitionRequiredSources. Both are
+// empty for other reports without recognition output.
+func (s *RecognitionValidation) Validate(ctx context.Context, source string, drops []types.ArkDrop, metadata *types.ReportRequestMetadata) (rejection string, flag string, err error) {
+	conf := s.RuntimeConfig.Current()
+	if metadata == nil || metadata.Recognition == nil {
+		if lo.Contains(conf.RecognitionRequiredSources, source) {
+			return "", fmt.Sprintf("recognition output is omitted by %s, which is expected to submit it", source), nil
+		}
+		return "", "", nil
+	}
+	items := metadata.Recognition.Items
+
+	mismatch, err := s.dropsMismatch(ctx, drops, items)
+	if err != nil {
+		return "", "", err
+	}
+	if mismatch != "" {
+		return "recognition output does not match drops: " + mismatch, "", nil
+	}
+
+	if conf.RecognitionMaxOverlap > 0 {
+		for i := range items {
+			for j := i + 1; j < len(items); j++ {
+				if overlap := intersectionOverUnion(items[i].Box, items[j].Box); overlap > conf.RecognitionMaxOverlap {
+					return fmt.Sprintf("items %d and %d recognized overlap by %.2f", i, j, overlap), "", nil
+				}
+			}
+		}
+	}
+
+	for i, item := range items {
+		confidence, of := item.Confidence, "item"
+		if item.QuantityConfidence < confidence {
+			confidence, of = item.QuantityConfidence, "quantity"
+		}
+
+		if confidence < conf.RecognitionRejectConfidence {
+			return fmt.Sprintf("%s of item %d `%s` is recognized with confidence %.2f", of, i, item.ItemID, confidence), "", nil
+		}
+		if flag == "" && confidence < conf.RecognitionFlagConfidence {
+			flag = fmt.Sprintf("%s of item %d `%s` is recognized with low confidence %.2f", of, i, item.ItemID, confidence)
+		}
+	}
+
+	return "", flag, nil
+}
+
+// dropsMismatch returns how the quantities of items recognized differ from those of drops, per drop type and item,
+// or an empty string if they are the same. Drop types are compared by the registered drop types they resolve to, so
+// that aliases of the same drop type match.
+func (s *RecognitionValidation) dropsMismatch(ctx context.Context, drops []types.ArkDrop, items []*types.RecognizedItem) (string, error) {
+	resolve := func(apiName string) (string, error) {
+		dropType, err := s.DropTypeService.ResolveReported(ctx, apiName)
+		if errors.Is(err, ErrDropTypeNotFound) {
+			return apiName, nil
+		} else if err != nil {
+			return "", err
+		}
+		return dropType.Name, nil
+	}
+
+	quantities := make(map[string]int)
+	for _, drop := range drops {
+		dropType, err := resolve(drop.DropType)
+		if err != nil {
+			return "", err
+		}
+		quantities[dropType+" "+drop.ItemID] += drop.Quantity
+	}
+	for _, item := range items {
+		dropType, err := resolve(item.DropType)
+		if err != nil {
+			return "", err
+		}
+		quantities[dropType+" "+item.ItemID] -= item.Quantity
+	}
+
+	mismatches := make([]string, 0)
+	for key, diff := range quantities {
+		if diff != 0 {
+			mismatches = append(mismatches, fmt.Sprintf("%s (%+d in drops)", key, diff))
+		}
+	}
+	sort.Strings(mismatches)
+	return strings.Join(mismatches, ", "), nil
+}
+
+func intersectionOverUnion(a, b types.RecognitionBox) float64 {
+	width := lo.Min([]int{a.X + a.Width, b.X + b.Width}) - lo.Max([]int{a.X, b.X})
+	height := lo.Min([]int{a.Y + a.Height, b.Y + b.Height}) - lo.Max([]int{a.Y, b.Y})
+	if width <= 0 || height <= 0 {
+		return 0
+	}
+
+	intersection := float64(width * height)
+	union := float64(a.Width*a.Height+b.Width*b.Height) - intersection
+	return intersection / union
+}
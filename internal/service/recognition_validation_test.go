@@ -0,0 +1,32 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+func TestIntersectionOverUnion(t *testing.T) {
+	tests := []struct {
+		a, b     types.RecognitionBox
+		expected float64
+	}{
+		{types.RecognitionBox{X: 0, Y: 0, Width: 10, Height: 10}, types.RecognitionBox{X: 0, Y: 0, Width: 10, Height: 10}, 1},
+		{types.RecognitionBox{X: 0, Y: 0, Width: 10, Height: 10}, types.RecognitionBox{X: 5, Y: 0, Width: 10, Height: 10}, 50.0 / 150},
+		{types.RecognitionBox{X: 0, Y: 0, Width: 10, Height: 10}, types.RecognitionBox{X: 5, Y: 5, Width: 10, Height: 10}, 25.0 / 175},
+		{types.RecognitionBox{X: 0, Y: 0, Width: 10, Height: 10}, types.RecognitionBox{X: 2, Y: 2, Width: 5, Height: 5}, 25.0 / 100},
+		// boxes sharing only an edge do not overlap
+		{types.RecognitionBox{X: 0, Y: 0, Width: 10, Height: 10}, types.RecognitionBox{X: 10, Y: 0, Width: 10, Height: 10}, 0},
+		{types.RecognitionBox{X: 0, Y: 0, Width: 10, Height: 10}, types.RecognitionBox{X: 20, Y: 20, Width: 10, Height: 10}, 0},
+	}
+	for _, test := range tests {
+		iou := intersectionOverUnion(test.a, test.b)
+		if math.Abs(iou-test.expected) > 1e-9 {
+			t.Errorf("intersectionOverUnion(%+v, %+v): expected %v, got %v", test.a, test.b, test.expected, iou)
+		}
+		if reversed := intersectionOverUnion(test.b, test.a); math.Abs(reversed-iou) > 1e-9 {
+			t.Errorf("intersectionOverUnion(%+v, %+v): expected to be symmetric, got %v and %v", test.a, test.b, iou, reversed)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
@@ -13,20 +14,39 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/guregu/null.v3"
 
+	"github.com/penguin-statistics/backend-next/internal/config"
 	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
 	"github.com/penguin-statistics/backend-next/internal/model/types"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/breaker"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
 	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
-	"github.com/penguin-statistics/backend-next/internal/pkg/pgid"
+	"github.com/penguin-statistics/backend-next/internal/pkg/spool"
 	"github.com/penguin-statistics/backend-next/internal/repo"
 	"github.com/penguin-statistics/backend-next/internal/util"
 	"github.com/penguin-statistics/backend-next/internal/util/reportutil"
 	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
 )
 
+// syncReportTimeout is the timeout of verifying and persisting a report in synchronous mode.
+const syncReportTimeout = time.Second * 5
+
 var (
-	ErrReportNotFound = pgerr.ErrInvalidReq.Msg("report not existed or has already been recalled")
+	ErrReportNotFound = pgerr.ErrInvalidReq.Msg("report not existed or has already been recalled").WithReason(pgerr.ReasonReportNotFound)
 	ErrNatsTimeout    = errors.New("timeout waiting for NATS response")
+
+	ErrSyncReportTimeout          = pgerr.New(fiber.StatusGatewayTimeout, "SYNC_REPORT_TIMEOUT", "report could not be processed in time; retry without `sync` to submit it asynchronously").WithReason(pgerr.ReasonSyncReportTimeout)
+	ErrReportRecallWindowExceeded = pgerr.ErrInvalidReq.Msg("report could no longer be recalled as the recall window has passed").WithReason(pgerr.ReasonRecallWindowExceeded)
+
+	ErrGachaboxTimes     = pgerr.ErrInvalidReq.Msg("invalid request: times is not supported for gachabox stages").WithReason(pgerr.ReasonGachaboxTimes)
+	ErrReportCorrectable = pgerr.ErrInvalidReq.Msg("invalid request: report could be corrected by applying `suggestion`").WithReason(pgerr.ReasonReportCorrectable)
+	ErrReportDuplicated  = pgerr.ErrInvalidReq.Msg("invalid request: screenshot has already been reported as task `taskId`").WithReason(pgerr.ReasonReportDuplicated)
 )
 
 type Report struct {
@@ -35,54 +55,104 @@ type Report struct {
 	NatsJS                 nats.JetStreamContext
 	ItemService            *Item
 	StageService           *Stage
+	DropTypeService        *DropType
 	AccountService         *Account
 	StageRepo              *repo.Stage
 	DropInfoRepo           *repo.DropInfo
 	DropReportRepo         *repo.DropReport
-	DropPatternRepo        *repo.DropPattern
+	DropPatternService     *DropPattern
 	DropReportExtraRepo    *repo.DropReportExtra
-	DropPatternElementRepo *repo.DropPatternElement
+	DropReportRecallRepo   *repo.DropReportRecall
+	RejectedReportTaskRepo *repo.RejectedReportTask
 	ReportVerifier         *reportverifs.ReportVerifiers
+	ReportGateVerifier     *reportverifs.ReportGateVerifier
+	StageRewriteService    *StageRewrite
+	CacheVersionService    *CacheVersion
+	// RuntimeConfig provides the screenshot dedup window and report quotas in effect.
+	RuntimeConfig *RuntimeConfig
+
+	// DropReportCorrectionRepo links reports superseded by corrections to the corrected reports.
+	DropReportCorrectionRepo *repo.DropReportCorrection
+
+	// RecognitionValidationService decides whether reports submitted with recognition output are trusted.
+	RecognitionValidationService *RecognitionValidation
+
+	// RecallWindow is the duration after a report has been submitted, within which the report could be recalled.
+	RecallWindow  time.Duration
+	PublishPolicy *ReportPublishPolicy
+	// Pipeline runs report tasks consumed through stages from decoding to notifying.
+	Pipeline *ReportPipeline
+	// Batcher persists report tasks in batches. Report tasks are persisted one by one if nil.
+	Batcher *ReportBatcher
+	// Spool keeps report tasks which could not be published until NATS recovers. Such reports are rejected if nil.
+	Spool *spool.Spool
 }
 
-func NewReport(db *bun.DB, redisClient *redis.Client, natsJs nats.JetStreamContext, itemService *Item, stageService *Stage, stageRepo *repo.Stage, dropInfoRepo *repo.DropInfo, dropReportRepo *repo.DropReport, dropReportExtraRepo *repo.DropReportExtra, dropPatternRepo *repo.DropPattern, dropPatternElementRepo *repo.DropPatternElement, accountService *Account, reportVerifier *reportverifs.ReportVerifiers) *Report {
+func NewReport(db *bun.DB, redisClient *redis.Client, natsJs nats.JetStreamContext, itemService *Item, stageService *Stage, dropTypeService *DropType, stageRepo *repo.Stage, dropInfoRepo *repo.DropInfo, dropReportRepo *repo.DropReport, dropReportExtraRepo *repo.DropReportExtra, dropPatternService *DropPattern, dropReportRecallRepo *repo.DropReportRecall, dropReportCorrectionRepo *repo.DropReportCorrection, rejectedReportTaskRepo *repo.RejectedReportTask, accountService *Account, reportVerifier *reportverifs.ReportVerifiers, reportGateVerifier *reportverifs.ReportGateVerifier, stageRewriteService *StageRewrite, cacheVersionService *CacheVersion, recognitionValidationService *RecognitionValidation, runtimeConfig *RuntimeConfig, reportSpool *spool.Spool, conf *config.Config) *Report {
 	service := &Report{
 		DB:                     db,
 		Redis:                  redisClient,
 		NatsJS:                 natsJs,
 		ItemService:            itemService,
 		StageService:           stageService,
+		DropTypeService:        dropTypeService,
 		AccountService:         accountService,
 		StageRepo:              stageRepo,
 		DropInfoRepo:           dropInfoRepo,
 		DropReportRepo:         dropReportRepo,
-		DropPatternRepo:        dropPatternRepo,
+		DropPatternService:     dropPatternService,
 		DropReportExtraRepo:    dropReportExtraRepo,
-		DropPatternElementRepo: dropPatternElementRepo,
+		DropReportRecallRepo:   dropReportRecallRepo,
+		RejectedReportTaskRepo: rejectedReportTaskRepo,
 		ReportVerifier:         reportVerifier,
-	}
-	return service
-}
+		ReportGateVerifier:     reportGateVerifier,
+		StageRewriteService:    stageRewriteService,
+		CacheVersionService:    cacheVersionService,
+		RuntimeConfig:          runtimeConfig,
+		Spool:                  reportSpool,
+		RecallWindow:           conf.ReportRecallWindow,
+		PublishPolicy: &ReportPublishPolicy{
+			Timeout: conf.ReportPublishTimeout,
+			Retries: conf.ReportPublishRetries,
+			Backoff: conf.ReportPublishRetryBackoff,
+		},
+		DropReportCorrectionRepo: dropReportCorrectionRepo,
 
-func (s *Report) pipelineAccount(ctx *fiber.Ctx) (accountId int, err error) {
-	account, err := s.AccountService.GetAccountFromRequest(ctx)
-	if err != nil {
-		createdAccount, err := s.AccountService.CreateAccountWithRandomPenguinId(ctx.Context())
-		if err != nil {
-			return 0, err
+		RecognitionValidationService: recognitionValidationService,
+	}
+	if conf.ReportPublishBreakerThreshold > 0 {
+		service.PublishPolicy.Breaker = breaker.New(conf.ReportPublishBreakerThreshold, conf.ReportPublishBreakerCooldown, func(open bool) {
+			if open {
+				log.Error().Msg("publishing report tasks has failed repeatedly, rejecting reports until NATS recovers")
+				observability.ReportPublishBreakerOpen.Set(1)
+			} else {
+				log.Info().Msg("publishing report tasks has recovered")
+				observability.ReportPublishBreakerOpen.Set(0)
+			}
+		})
+	}
+	service.Pipeline = newReportPipeline(service.builtinReportStages()...)
+	if conf.ReportBatchMaxRows > 0 {
+		service.Batcher = &ReportBatcher{
+			MaxRows:     conf.ReportBatchMaxRows,
+			Window:      conf.ReportBatchWindow,
+			persistFunc: service.persistReportTasks,
 		}
-		accountId = createdAccount.AccountID
-		pgid.Inject(ctx, createdAccount.PenguinID)
-	} else {
-		accountId = account.AccountID
 	}
-
-	return accountId, nil
+	return service
 }
 
 func (s *Report) pipelineMergeDropsAndMapDropTypes(ctx context.Context, drops []types.ArkDrop) ([]*types.Drop, error) {
 	convertedDrops := make([]*types.Drop, 0, len(drops))
-	for _, drop := range drops {
+	for i, drop := range drops {
+		// maps API drop type to DB drop type
+		dropType, err := s.DropTypeService.ResolveReported(ctx, drop.DropType)
+		if errors.Is(err, ErrDropTypeNotFound) {
+			return nil, pgerr.ErrInvalidReq.Msg("invalid request: unknown dropType `%s` of drops[%d]", drop.DropType, i)
+		} else if err != nil {
+			return nil, err
+		}
+
 		item, err := s.ItemService.GetItemByArkId(ctx, drop.ItemID)
 		if err != nil {
 			if !errors.Is(err, pgerr.ErrNotFound) {
@@ -94,8 +164,7 @@ func (s *Report) pipelineMergeDropsAndMapDropTypes(ctx context.Context, drops []
 		}
 
 		convertedDrops = append(convertedDrops, &types.Drop{
-			// maps DropType to DB DropType
-			DropType: constant.DropTypeMap[drop.DropType],
+			DropType: dropType.Name,
 			ItemID:   item.ItemID,
 			Quantity: drop.Quantity,
 		})
@@ -106,90 +175,309 @@ func (s *Report) pipelineMergeDropsAndMapDropTypes(ctx context.Context, drops []
 	return convertedDrops, nil
 }
 
-func (s *Report) pipelineTaskId(ctx *fiber.Ctx) string {
-	return ctx.Locals(constant.ContextKeyRequestID).(string) + "-" + uniuri.NewLen(16)
+func (s *Report) pipelineTaskId(submitter *ReportSubmitter) string {
+	return submitter.RequestID + "-" + uniuri.NewLen(16)
 }
 
-func (s *Report) pipelineAggregateGachaboxDrops(ctx context.Context, singleReport *types.ReportTaskSingleReport) error {
+func (s *Report) pipelineAggregateGachaboxDrops(ctx context.Context, singleReport *types.ReportTaskSingleReport, runs int) error {
 	// for gachabox drop, we need to aggregate `times` according to `quantity` for report.Drops
 	category, err := s.StageService.GetStageExtraProcessTypeByArkId(ctx, singleReport.StageID)
 	if err != nil {
 		return err
 	}
 	if category.Valid && category.String == constant.ExtraProcessTypeGachaBox {
+		// `times` of gachabox reports is derived from drops, so it could not be specified as runs
+		if runs > 1 {
+			return ErrGachaboxTimes
+		}
 		reportutil.AggregateGachaBoxDrops(singleReport)
 	}
 
 	return nil
 }
 
-// FIXME: temporary compensation for reports from MaaAssistant, where stageId passed for act18d3 is currently ambiguous
-// this function will mutate req with the correct stageId, if detected that such request matches the following criteria:
-// 1. report time < 1654718400000
-// 2. is from MeoAssistant
-// 3. stageId is in form `act18d3_0$_perm` where $ represents integers [1-9]
-func (s *Report) pipelineMaaAct18d3TemporaryMitigation(ctx *fiber.Ctx, req *types.SingleReportRequest) {
-	if time.Now().UnixMilli() < 1654718400000 && req.Source == "MeoAssistant" {
-		if strings.HasPrefix(req.StageID, "act18d3_") && strings.HasSuffix(req.StageID, "_perm") {
-			req.StageID = strings.Replace(req.StageID, "_perm", "_rep", 1)
+// pipelineStageRewrite returns stageId with the correct stageId, if detected that a report from source with version
+// is affected by a known client-side defect matched by a stage rewrite rule.
+func (s *Report) pipelineStageRewrite(ctx context.Context, submitter *ReportSubmitter, source, version, stageId string) string {
+	rewritten, rule := s.StageRewriteService.Apply(ctx, source, version, stageId, time.Now())
+	if rule != nil {
+		log.Debug().
+			Str("requestId", submitter.RequestID).
+			Str("rule", rule.Name).
+			Str("stageId", stageId).
+			Str("rewrittenStageId", rewritten).
+			Msg("report stageId rewritten by stage rewrite rule")
+	}
+	return rewritten
+}
+
+// PreviewMitigation returns how the report pipeline would rewrite the stageId reported by source with version at t,
+// without submitting anything.
+func (s *Report) PreviewMitigation(ctx context.Context, source, version, stageId string, t time.Time) *modelv2.MitigationPreviewResponse {
+	rewritten, rule := s.StageRewriteService.Apply(ctx, source, version, stageId, t)
+	resp := &modelv2.MitigationPreviewResponse{
+		StageID:          stageId,
+		RewrittenStageID: rewritten,
+		Rewritten:        rule != nil,
+	}
+	if rule != nil {
+		resp.MatchedRule = null.StringFrom(rule.Name)
+	}
+	return resp
+}
+
+// SuggestCorrections checks req for fixable mistakes, including non-canonical stageId, outdated version
+// and incorrect drop types, and returns a suggestion to correct them. nil is returned if nothing is fixable.
+func (s *Report) SuggestCorrections(ctx context.Context, req *types.SingleReportRequest) (*types.ReportCorrectionSuggestion, error) {
+	suggestion := &types.ReportCorrectionSuggestion{}
+	fixable := false
+
+	if req.StageID != "" {
+		canonical, err := s.StageService.ResolveArkStageId(ctx, req.StageID)
+		if err != nil && !errors.Is(err, pgerr.ErrNotFound) {
+			return nil, err
+		}
+		if err == nil && canonical != req.StageID {
+			suggestion.StageID = canonical
+			fixable = true
 		}
 	}
+
+	// versions rejected by gates are fixable by upgrading, if a minimum version is known
+	if action, _ := s.ReportGateVerifier.Check(ctx, req.Source, req.Version); action == constant.ReportGateActionReject {
+		if minimum := s.ReportGateVerifier.MinimumVersion(ctx, req.Source); minimum != "" {
+			suggestion.MinimumVersion = minimum
+			fixable = true
+		}
+	}
+
+	for i, drop := range req.Drops {
+		dropType, err := s.suggestDropType(ctx, drop.DropType)
+		if err != nil {
+			return nil, err
+		}
+		if dropType != "" {
+			suggestion.DropTypes = append(suggestion.DropTypes, &types.DropTypeSuggestion{
+				Index:    i,
+				DropType: dropType,
+			})
+			fixable = true
+		}
+	}
+
+	if !fixable {
+		return nil, nil
+	}
+	return suggestion, nil
 }
 
-func (s *Report) commitReportTask(ctx *fiber.Ctx, subject string, task *types.ReportTask) (taskId string, err error) {
-	taskId = s.pipelineTaskId(ctx)
-	task.TaskID = taskId
+// suggestDropType returns the API name of the drop type apiName is likely meant to be, when apiName is not accepted
+// from reports while its upper-cased form, or the drop type it is commonly mistaken for, is. An empty string is
+// returned if apiName is accepted or there is nothing to suggest.
+func (s *Report) suggestDropType(ctx context.Context, apiName string) (string, error) {
+	_, err := s.DropTypeService.ResolveReported(ctx, apiName)
+	if err == nil {
+		return "", nil
+	} else if !errors.Is(err, ErrDropTypeNotFound) {
+		return "", err
+	}
 
-	reportTaskJSON, err := json.Marshal(task)
+	normalized := strings.ToUpper(strings.TrimSpace(apiName))
+	if alias, ok := constant.DropTypeAliasMap[normalized]; ok {
+		normalized = alias
+	}
+	dropType, err := s.DropTypeService.ResolveReported(ctx, normalized)
+	if errors.Is(err, ErrDropTypeNotFound) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return dropType.APIName, nil
+}
+
+// WithCorrectionSuggestion attaches the correction suggestion for req, if any, to rejection err as
+// the `suggestion` extra of the error response.
+func (s *Report) WithCorrectionSuggestion(ctx context.Context, req *types.SingleReportRequest, rejection error) error {
+	var perr *pgerr.PenguinError
+	if !errors.As(rejection, &perr) {
+		return rejection
+	}
+
+	suggestion, err := s.SuggestCorrections(ctx, req)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to suggest corrections for rejected report")
+		return rejection
+	}
+	if suggestion == nil {
+		return rejection
+	}
+
+	extras := pgerr.Extras{}
+	if perr.Extras != nil {
+		for k, v := range *perr.Extras {
+			extras[k] = v
+		}
+	}
+	extras["suggestion"] = suggestion
+	return perr.WithExtras(extras)
+}
+
+// pipelineReportGate rejects reports from source with version if they match a rejecting report gate.
+func (s *Report) pipelineReportGate(ctx context.Context, source, version string) error {
+	action, gate := s.ReportGateVerifier.Check(ctx, source, version)
+	if action != constant.ReportGateActionReject {
+		return nil
+	}
+
+	extras := pgerr.Extras{}
+	if minimum := s.ReportGateVerifier.MinimumVersion(ctx, source); minimum != "" {
+		extras["minimumVersion"] = minimum
+	}
+	return pgerr.ErrInvalidReq.Msg("invalid request: reports from %s %s are not accepted: %s", source, version, gate.Reason).
+		WithReason(pgerr.ReasonReportGated).
+		WithExtras(extras)
+}
+
+// pipelineRejectCorrectable rejects req early if it has fixable mistakes, so that clients
+// could correct them by the suggestion in the response and retry.
+func (s *Report) pipelineRejectCorrectable(ctx context.Context, req *types.SingleReportRequest) error {
+	suggestion, err := s.SuggestCorrections(ctx, req)
+	if err != nil {
+		return err
+	}
+	if suggestion != nil {
+		return ErrReportCorrectable.WithExtras(pgerr.Extras{
+			"suggestion": suggestion,
+		})
+	}
+	return nil
+}
+
+// pipelineRecognition cross-checks the recognition output of a report from source against its drops, and returns
+// why the report shall be flagged, if any. index is the index of the report in a batch, or -1 for a singular report.
+func (s *Report) pipelineRecognition(ctx context.Context, source string, drops []types.ArkDrop, metadata *types.ReportRequestMetadata, index int) (flag string, err error) {
+	rejection, flag, err := s.RecognitionValidationService.Validate(ctx, source, drops, metadata)
 	if err != nil {
 		return "", err
 	}
+	if rejection == "" {
+		return flag, nil
+	}
 
-	pub, err := s.NatsJS.PublishAsync(subject, reportTaskJSON)
+	if index >= 0 {
+		rejection = fmt.Sprintf("batchDrops[%d]: %s", index, rejection)
+	}
+	return "", pgerr.ErrInvalidReq.Msg("invalid request: recognition output is not trusted: %s", rejection).
+		WithReason(pgerr.ReasonRecognitionRejected)
+}
+
+func (s *Report) commitReportTask(ctx context.Context, submitter *ReportSubmitter, subject string, task *types.ReportTask) (taskId string, err error) {
+	taskId = s.pipelineTaskId(submitter)
+	task.TaskID = taskId
+
+	// a resubmitted screenshot is silently merged into the task first submitted it
+	duplicates, claims, err := s.pipelineDedupScreenshots(ctx, task, taskId)
 	if err != nil {
 		return "", err
 	}
+	if len(task.Reports) == 0 {
+		return firstDuplicate(duplicates), nil
+	}
 
-	select {
-	case err := <-pub.Err():
+	if err = s.queueReportTask(ctx, subject, task); err != nil {
+		s.releaseScreenshotClaims(ctx, claims)
+		s.refundQuota(ctx, submitter, task)
 		return "", err
-	case <-pub.Ok():
-		return taskId, nil
-	case <-ctx.Context().Done():
-		return "", ctx.Context().Err()
-	case <-time.After(time.Second * 10):
-		return "", ErrNatsTimeout
 	}
+	return taskId, nil
 }
 
-// returns taskID and error, if any
-func (s *Report) PreprocessAndQueueSingularReport(ctx *fiber.Ctx, req *types.SingleReportRequest) (taskId string, err error) {
+// queueReportTask marks task as queued, and publishes it to subject, or spools it if it could not be published.
+// The task is marked before it is published, as the worker could consume it, and mark it as persisted, before
+// publishing returns.
+func (s *Report) queueReportTask(ctx context.Context, subject string, task *types.ReportTask) error {
+	s.setReportTaskStatus(ctx, task.TaskID, constant.ReportTaskStateQueued, nil)
+	if err := s.publishReportTask(ctx, subject, task); err != nil {
+		if spoolErr := s.spoolReportTask(ctx, subject, task, err); spoolErr != nil {
+			s.clearReportTaskStatus(ctx, task.TaskID)
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Report) publishReportTask(ctx context.Context, subject string, task *types.ReportTask) error {
+	ctx, span := observability.Tracer().Start(ctx, "NATS publish "+subject,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("nats"),
+			semconv.MessagingDestinationKey.String(subject),
+			semconv.MessagingMessageIDKey.String(task.TaskID),
+		),
+	)
+	defer span.End()
+
+	task.TraceContext = observability.InjectTraceContext(ctx)
+	reportTaskJSON, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	// task id doubles as the message id, so that JetStream drops the task if it is published more than once
+	if err := s.publish(ctx, subject, reportTaskJSON, task.TaskID); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	return nil
+}
+
+func (s *Report) preprocessSingularReport(ctx context.Context, submitter *ReportSubmitter, req *types.SingleReportRequest) (*types.ReportTask, error) {
+	if err := s.pipelineReportGate(ctx, req.Source, req.Version); err != nil {
+		return nil, s.WithCorrectionSuggestion(ctx, req, err)
+	}
+
 	// if account is not found, create new account
-	accountId, err := s.pipelineAccount(ctx)
+	accountId, err := s.pipelineAccount(ctx, submitter)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// merge drops with same (dropType, itemId) pair
-	drops, err := s.pipelineMergeDropsAndMapDropTypes(ctx.Context(), req.Drops)
+	drops, err := s.pipelineMergeDropsAndMapDropTypes(ctx, req.Drops)
 	if err != nil {
-		return "", err
+		return nil, s.WithCorrectionSuggestion(ctx, req, err)
+	}
+
+	req.StageID = s.pipelineStageRewrite(ctx, submitter, req.Source, req.Version, req.StageID)
+
+	// reject fixable reports with a suggestion, so that clients could correct and retry
+	if err = s.pipelineRejectCorrectable(ctx, req); err != nil {
+		return nil, err
+	}
+
+	recognitionFlag, err := s.pipelineRecognition(ctx, req.Source, req.Drops, req.Metadata, -1)
+	if err != nil {
+		return nil, err
 	}
 
-	s.pipelineMaaAct18d3TemporaryMitigation(ctx, req)
+	times := req.Times
+	if times == 0 {
+		times = 1
+	}
 
 	singleReport := &types.ReportTaskSingleReport{
 		FragmentStageID: req.FragmentStageID,
 		Drops:           drops,
-		// for now, we do not support multiple report by specifying `times`
-		Times:    1,
-		Metadata: req.Metadata,
+		Times:           times,
+		Metadata:        req.Metadata,
+		RecognitionFlag: recognitionFlag,
 	}
 
 	// for gachabox drop, we need to aggregate `times` according to `quantity` for report.Drops
-	err = s.pipelineAggregateGachaboxDrops(ctx.Context(), singleReport)
+	err = s.pipelineAggregateGachaboxDrops(ctx, singleReport, times)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// construct ReportContext
@@ -202,40 +490,90 @@ func (s *Report) PreprocessAndQueueSingularReport(ctx *fiber.Ctx, req *types.Sin
 		},
 		Reports:   []*types.ReportTaskSingleReport{singleReport},
 		AccountID: accountId,
-		IP:        util.ExtractIP(ctx),
+		IP:        submitter.IP,
+		APIKeyID:  submitter.APIKeyID,
 	}
 
-	return s.commitReportTask(ctx, "REPORT.SINGLE", reportTask)
+	if err = s.pipelineQuota(ctx, submitter, reportTask); err != nil {
+		return nil, err
+	}
+
+	return reportTask, nil
 }
 
-func (s *Report) PreprocessAndQueueBatchReport(ctx *fiber.Ctx, req *types.BatchReportRequest) (taskId string, err error) {
-	// if account is not found, create new account
-	accountId, err := s.pipelineAccount(ctx)
+// returns taskID and error, if any
+func (s *Report) PreprocessAndQueueSingularReport(ctx *fiber.Ctx, req *types.SingleReportRequest) (taskId string, err error) {
+	return s.QueueSingularReport(observability.FiberContext(ctx), fiberReportSubmitter(ctx), req)
+}
+
+// QueueSingularReport works like PreprocessAndQueueSingularReport, for reports submitted by submitter over
+// protocols other than HTTP.
+func (s *Report) QueueSingularReport(ctx context.Context, submitter *ReportSubmitter, req *types.SingleReportRequest) (taskId string, err error) {
+	reportTask, err := s.preprocessSingularReport(ctx, submitter, req)
 	if err != nil {
 		return "", err
 	}
 
+	return s.commitReportTask(ctx, submitter, "REPORT.SINGLE", reportTask)
+}
+
+// PreprocessAndConsumeSingularReport works like PreprocessAndQueueSingularReport, but verifies and persists the
+// report inline instead of queueing it, and returns the verdict of the report.
+func (s *Report) PreprocessAndConsumeSingularReport(ctx *fiber.Ctx, req *types.SingleReportRequest) (taskId string, verdict *modelv2.ReportVerdict, err error) {
+	submitter := fiberReportSubmitter(ctx)
+	reportTask, err := s.preprocessSingularReport(ctx.Context(), submitter, req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	taskId, verdicts, err := s.consumeReportTaskInline(ctx.Context(), submitter, reportTask)
+	if err != nil {
+		return "", nil, err
+	}
+	return taskId, verdicts[0], nil
+}
+
+func (s *Report) preprocessBatchReport(ctx context.Context, submitter *ReportSubmitter, req *types.BatchReportRequest) (*types.ReportTask, error) {
+	if err := s.pipelineReportGate(ctx, req.Source, req.Version); err != nil {
+		// gates match the source and version common to the whole batch, which are all a suggestion is made from here
+		return nil, s.WithCorrectionSuggestion(ctx, &types.SingleReportRequest{FragmentReportCommon: req.FragmentReportCommon}, err)
+	}
+
+	// if account is not found, create new account
+	accountId, err := s.pipelineAccount(ctx, submitter)
+	if err != nil {
+		return nil, err
+	}
+
 	reports := make([]*types.ReportTaskSingleReport, len(req.BatchDrops))
 
 	for i, drop := range req.BatchDrops {
+		recognitionFlag, err := s.pipelineRecognition(ctx, req.Source, drop.Drops, &drop.Metadata, i)
+		if err != nil {
+			return nil, err
+		}
+
 		// merge drops with same (dropType, itemId) pair
-		drops, err := s.pipelineMergeDropsAndMapDropTypes(ctx.Context(), drop.Drops)
+		drops, err := s.pipelineMergeDropsAndMapDropTypes(ctx, drop.Drops)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		// catch the variable
 		metadata := drop.Metadata
+		stageId := drop.FragmentStageID
+		stageId.StageID = s.pipelineStageRewrite(ctx, submitter, req.Source, req.Version, stageId.StageID)
 		report := &types.ReportTaskSingleReport{
-			FragmentStageID: drop.FragmentStageID,
+			FragmentStageID: stageId,
 			Drops:           drops,
 			Times:           1,
 			Metadata:        &metadata,
+			RecognitionFlag: recognitionFlag,
 		}
 
-		err = s.pipelineAggregateGachaboxDrops(ctx.Context(), report)
+		err = s.pipelineAggregateGachaboxDrops(ctx, report, 1)
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		reports[i] = report
@@ -250,33 +588,229 @@ func (s *Report) PreprocessAndQueueBatchReport(ctx *fiber.Ctx, req *types.BatchR
 		},
 		Reports:   reports,
 		AccountID: accountId,
-		IP:        util.ExtractIP(ctx),
+		IP:        submitter.IP,
+		APIKeyID:  submitter.APIKeyID,
+	}
+
+	if err = s.pipelineQuota(ctx, submitter, reportTask); err != nil {
+		return nil, err
+	}
+
+	return reportTask, nil
+}
+
+func (s *Report) PreprocessAndQueueBatchReport(ctx *fiber.Ctx, req *types.BatchReportRequest) (taskId string, err error) {
+	return s.QueueBatchReport(observability.FiberContext(ctx), fiberReportSubmitter(ctx), req)
+}
+
+// QueueBatchReport works like PreprocessAndQueueBatchReport, for reports submitted by submitter over
+// protocols other than HTTP.
+func (s *Report) QueueBatchReport(ctx context.Context, submitter *ReportSubmitter, req *types.BatchReportRequest) (taskId string, err error) {
+	reportTask, err := s.preprocessBatchReport(ctx, submitter, req)
+	if err != nil {
+		return "", err
+	}
+
+	return s.commitReportTask(ctx, submitter, "REPORT.BATCH", reportTask)
+}
+
+// PreprocessAndConsumeBatchReport works like PreprocessAndQueueBatchReport, but verifies and persists the
+// reports inline instead of queueing them, and returns the verdict of each report.
+func (s *Report) PreprocessAndConsumeBatchReport(ctx *fiber.Ctx, req *types.BatchReportRequest) (taskId string, verdicts []*modelv2.ReportVerdict, err error) {
+	submitter := fiberReportSubmitter(ctx)
+	reportTask, err := s.preprocessBatchReport(ctx.Context(), submitter, req)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return s.consumeReportTaskInline(ctx.Context(), submitter, reportTask)
+}
+
+// consumeReportTaskInline verifies and persists task within syncReportTimeout, and returns the verdict of each report.
+func (s *Report) consumeReportTaskInline(ctx context.Context, submitter *ReportSubmitter, task *types.ReportTask) (taskId string, verdicts []*modelv2.ReportVerdict, err error) {
+	taskId = s.pipelineTaskId(submitter)
+	task.TaskID = taskId
+
+	// verdicts of the task first submitted the screenshot are not available inline, hence duplicates are rejected
+	duplicates, claims, err := s.pipelineDedupScreenshots(ctx, task, taskId)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(task.Reports) == 0 {
+		return "", nil, ErrReportDuplicated.WithExtras(pgerr.Extras{
+			"taskId": firstDuplicate(duplicates),
+		})
 	}
 
-	return s.commitReportTask(ctx, "REPORT.BATCH", reportTask)
+	taskCtx, cancel := context.WithTimeout(ctx, syncReportTimeout)
+	defer cancel()
+
+	violations, err := s.ConsumeReportTask(taskCtx, task)
+	if err != nil {
+		s.releaseScreenshotClaims(ctx, claims)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "", nil, ErrSyncReportTimeout
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	violations = violations.Disclosable()
+	verdicts = make([]*modelv2.ReportVerdict, 0, len(task.Reports)+len(duplicates))
+	// duplicates have been removed from the task, and are placed back at their original indices
+	appendDuplicates := func() {
+		for claimedBy, ok := duplicates[len(verdicts)]; ok; claimedBy, ok = duplicates[len(verdicts)] {
+			message := "screenshot has already been reported as task " + claimedBy
+			verdicts = append(verdicts, &modelv2.ReportVerdict{
+				Accepted: false,
+				Message:  message,
+				Reason:   pgerr.NewReason(pgerr.ReasonReportDuplicated, message),
+			})
+		}
+	}
+	for i := range task.Reports {
+		appendDuplicates()
+
+		verdict := &modelv2.ReportVerdict{
+			Reliability: violations.Reliability(i),
+			Accepted:    true,
+		}
+		if violation, ok := violations[i]; ok {
+			verdict.Accepted = false
+			verdict.Verifier = violation.Name
+			verdict.Message = violation.Message
+			verdict.Reason = pgerr.NewVerifierReason(violation.Name, violation.Message)
+		}
+		verdicts = append(verdicts, verdict)
+	}
+	appendDuplicates()
+	return taskId, verdicts, nil
 }
 
-func (s *Report) RecallSingularReport(ctx context.Context, req *types.SingleReportRecallRequest) error {
-	var reportId int
-	r := s.Redis.Get(ctx, req.ReportHash)
+// resolveRecallableReport returns the report id of reportHash, if the report is still recallable.
+func (s *Report) resolveRecallableReport(ctx context.Context, reportHash string) (int, error) {
+	r := s.Redis.Get(ctx, reportHash)
 
 	if errors.Is(r.Err(), redis.Nil) {
-		return ErrReportNotFound
+		return 0, ErrReportNotFound
 	} else if r.Err() != nil {
-		return r.Err()
+		return 0, r.Err()
 	}
 
 	reportId, err := r.Int()
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = s.DropReportRepo.GetDropReportByIdSince(ctx, reportId, time.Now().Add(-s.RecallWindow))
+	if errors.Is(err, pgerr.ErrNotFound) {
+		// the report is either not existed, or created before the recall window
+		if _, err := s.DropReportRepo.GetDropReportById(ctx, reportId); err == nil {
+			return 0, ErrReportRecallWindowExceeded
+		}
+		return 0, ErrReportNotFound
+	} else if err != nil {
+		return 0, err
+	}
+
+	return reportId, nil
+}
+
+// recallReports recalls reportIds in a single transaction, auditing each recall with the requester of ctx.
+func (s *Report) recallReports(ctx *fiber.Ctx, reportIds []int, reason string) error {
+	// the recall is audited with the account who requested it, if any
+	var accountId null.Int
+	if account, err := s.AccountService.GetAccountFromRequest(ctx); err == nil {
+		accountId = null.IntFrom(int64(account.AccountID))
+	}
+	ip := util.ExtractIP(ctx)
+
+	err := s.DB.RunInTx(ctx.Context(), nil, func(c context.Context, tx bun.Tx) error {
+		for _, reportId := range reportIds {
+			if err := s.DropReportRepo.DeleteDropReport(c, tx, reportId); err != nil {
+				return err
+			}
+			if err := s.DropReportRecallRepo.CreateDropReportRecall(c, tx, &model.DropReportRecall{
+				ReportID:  reportId,
+				AccountID: accountId,
+				IP:        ip,
+				Reason:    null.NewString(reason, reason != ""),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	err = s.DropReportRepo.DeleteDropReport(ctx, reportId)
+	// recalls count towards the trust score of the account who submitted the report, and change its personal matrix
+	for _, reportId := range reportIds {
+		if dropReport, err := s.DropReportRepo.GetDropReportById(ctx.Context(), reportId); err == nil {
+			markAccountTrustDirty(ctx.Context(), s.Redis, dropReport.AccountID)
+			s.CacheVersionService.invalidatePersonalCaches(ctx.Context(), dropReport.AccountID)
+		}
+	}
+	s.releaseRecalledScreenshots(ctx.Context(), reportIds)
+	return nil
+}
+
+func (s *Report) RecallSingularReport(ctx *fiber.Ctx, req *types.SingleReportRecallRequest) error {
+	reportId, err := s.resolveRecallableReport(ctx.Context(), req.ReportHash)
 	if err != nil {
 		return err
 	}
 
-	s.Redis.Del(ctx, req.ReportHash)
+	if err = s.recallReports(ctx, []int{reportId}, req.Reason); err != nil {
+		return err
+	}
+
+	s.Redis.Del(ctx.Context(), req.ReportHash)
 
 	return nil
 }
+
+// RecallBatchReports recalls all recallable reports among req.ReportHashes in a single transaction, and returns
+// the result for each report hash. Report hashes that are not recallable do not prevent others from being recalled.
+func (s *Report) RecallBatchReports(ctx *fiber.Ctx, req *types.BatchReportRecallRequest) (*modelv2.BatchRecallResponse, error) {
+	results := make([]*modelv2.BatchRecallResult, 0, len(req.ReportHashes))
+	reportIds := make([]int, 0, len(req.ReportHashes))
+	recallableHashes := make([]string, 0, len(req.ReportHashes))
+	seen := make(map[int]struct{}, len(req.ReportHashes))
+
+	for _, reportHash := range req.ReportHashes {
+		result := &modelv2.BatchRecallResult{ReportHash: reportHash}
+		results = append(results, result)
+
+		reportId, err := s.resolveRecallableReport(ctx.Context(), reportHash)
+		if err != nil {
+			var perr *pgerr.PenguinError
+			if !errors.As(err, &perr) {
+				return nil, err
+			}
+			result.Reason = perr.Message
+			result.ReasonDetail = pgerr.ReasonOf(perr)
+			continue
+		}
+		if _, ok := seen[reportId]; ok {
+			result.Reason = ErrReportNotFound.Message
+			result.ReasonDetail = ErrReportNotFound.Reason
+			continue
+		}
+		seen[reportId] = struct{}{}
+
+		result.Recalled = true
+		reportIds = append(reportIds, reportId)
+		recallableHashes = append(recallableHashes, reportHash)
+	}
+
+	if len(reportIds) > 0 {
+		if err := s.recallReports(ctx, reportIds, req.Reason); err != nil {
+			return nil, err
+		}
+		s.Redis.Del(ctx.Context(), recallableHashes...)
+	}
+
+	return &modelv2.BatchRecallResponse{Results: results}, nil
+}
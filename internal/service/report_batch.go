@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// reportBatchFlushTimeout is the timeout of persisting a single batch of report tasks.
+const reportBatchFlushTimeout = time.Second * 10
+
+// ReportBatcher persists verified report tasks from concurrent consumers together. A batch is persisted once it holds
+// MaxRows reports or Window has passed since its first task arrived, whichever comes first. With a zero Window, a task
+// is never held back waiting for others: it is persisted right away when no batch is being persisted, and otherwise
+// together with all the tasks verified in the meantime, up to MaxRows reports.
+//
+// A batch failed to be persisted is retried task by task, so that only the tasks failing on their own are failed,
+// instead of a single bad task failing every task of the batch over and over as they are redelivered together.
+type ReportBatcher struct {
+	MaxRows int
+	Window  time.Duration
+
+	persistFunc func(ctx context.Context, tasks []*consumedReportTask) error
+	pending     chan *batchedReportTask
+	once        sync.Once
+}
+
+type batchedReportTask struct {
+	consumedReportTask
+
+	// ctx is the context of the caller. Tasks whose caller has given up by the time of flushing are not persisted,
+	// so that they could be safely redelivered.
+	ctx  context.Context
+	done chan error
+}
+
+// persist queues reportTask to be persisted with the next batch, and blocks until the batch has been persisted.
+// The caller is always replied to, even if ctx is done, so that it never mistakes a persisted task for a failed one.
+func (b *ReportBatcher) persist(ctx context.Context, reportTask *types.ReportTask, violations reportverifs.Violations) error {
+	b.once.Do(func() {
+		b.pending = make(chan *batchedReportTask)
+		go b.run()
+	})
+
+	task := &batchedReportTask{
+		consumedReportTask: consumedReportTask{task: reportTask, violations: violations},
+		ctx:                ctx,
+		done:               make(chan error, 1),
+	}
+	select {
+	case b.pending <- task:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-task.done
+}
+
+func (b *ReportBatcher) run() {
+	for {
+		// block until the first task of the batch arrives, then take the tasks arriving within the window, or only
+		// those already waiting without blocking if there is no window
+		batch := []*batchedReportTask{<-b.pending}
+		rows := len(batch[0].task.Reports)
+		var timer *time.Timer
+		if b.Window > 0 {
+			timer = time.NewTimer(b.Window)
+		}
+	collect:
+		for rows < b.MaxRows {
+			var task *batchedReportTask
+			if timer != nil {
+				select {
+				case task = <-b.pending:
+				case <-timer.C:
+					break collect
+				}
+			} else {
+				select {
+				case task = <-b.pending:
+				default:
+					break collect
+				}
+			}
+			batch = append(batch, task)
+			rows += len(task.task.Reports)
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+
+		b.flush(batch)
+	}
+}
+
+func (b *ReportBatcher) flush(batch []*batchedReportTask) {
+	live := make([]*batchedReportTask, 0, len(batch))
+	for _, task := range batch {
+		if err := task.ctx.Err(); err != nil {
+			task.done <- err
+			continue
+		}
+		live = append(live, task)
+	}
+	if len(live) == 0 {
+		return
+	}
+
+	start := time.Now()
+	err := b.persistBatch(live)
+	if err == nil {
+		log.Debug().Int("tasks", len(live)).Dur("duration", time.Since(start)).Msg("report task batch persisted")
+		for _, task := range live {
+			task.done <- nil
+		}
+		return
+	}
+	if len(live) == 1 {
+		live[0].done <- err
+		return
+	}
+
+	log.Warn().Err(err).Int("tasks", len(live)).Msg("failed to persist report task batch; retrying task by task")
+	for _, task := range live {
+		task.done <- b.persistBatch([]*batchedReportTask{task})
+	}
+}
+
+// persistBatch persists tasks within a single transaction.
+func (b *ReportBatcher) persistBatch(batch []*batchedReportTask) error {
+	tasks := make([]*consumedReportTask, 0, len(batch))
+	for _, task := range batch {
+		tasks = append(tasks, &task.consumedReportTask)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), reportBatchFlushTimeout)
+	defer cancel()
+
+	err := b.persistFunc(ctx, tasks)
+	if err != nil && len(tasks) == 1 {
+		log.Error().Err(err).Str("taskId", tasks[0].task.TaskID).Msg("failed to persist report task")
+	}
+	return err
+}
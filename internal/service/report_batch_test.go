@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+func TestReportBatcherWindow(t *testing.T) {
+	tests := []struct {
+		maxRows  int
+		window   time.Duration
+		tasks    int
+		expected []int
+	}{
+		// tasks arriving one by one within the window are persisted together
+		{100, time.Second, 3, []int{3}},
+		// a full batch is persisted without waiting for the window to pass
+		{2, time.Hour, 4, []int{2, 2}},
+		// without a window, a task arriving after the previous one has been persisted is persisted on its own
+		{100, 0, 3, []int{1, 1, 1}},
+	}
+	for _, test := range tests {
+		var mu sync.Mutex
+		var batches []int
+		b := &ReportBatcher{
+			MaxRows: test.maxRows,
+			Window:  test.window,
+			persistFunc: func(ctx context.Context, tasks []*consumedReportTask) error {
+				mu.Lock()
+				defer mu.Unlock()
+				batches = append(batches, len(tasks))
+				return nil
+			},
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < test.tasks; i++ {
+			task := &types.ReportTask{
+				TaskID:  strconv.Itoa(i),
+				Reports: []*types.ReportTaskSingleReport{{}},
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := b.persist(context.Background(), task, nil); err != nil {
+					t.Error(err)
+				}
+			}()
+			if test.window == 0 {
+				// let the task be persisted before the next one arrives
+				wg.Wait()
+			} else {
+				time.Sleep(time.Millisecond * 10)
+			}
+		}
+		wg.Wait()
+
+		if len(batches) != len(test.expected) {
+			t.Errorf("ReportBatcher{MaxRows: %d, Window: %s}: expected batches %v, got %v", test.maxRows, test.window, test.expected, batches)
+			continue
+		}
+		for i := range batches {
+			if batches[i] != test.expected[i] {
+				t.Errorf("ReportBatcher{MaxRows: %d, Window: %s}: expected batches %v, got %v", test.maxRows, test.window, test.expected, batches)
+				break
+			}
+		}
+	}
+}
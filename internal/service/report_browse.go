@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cursor"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// ReportBrowse searches persisted drop reports for moderators, to support abuse investigations without direct
+// access to the database.
+type ReportBrowse struct {
+	DropReportService *DropReport
+	AccountService    *Account
+	StageService      *Stage
+}
+
+func NewReportBrowse(dropReportService *DropReport, accountService *Account, stageService *Stage) *ReportBrowse {
+	return &ReportBrowse{
+		DropReportService: dropReportService,
+		AccountService:    accountService,
+		StageService:      stageService,
+	}
+}
+
+// BrowseReports returns the page of drop reports matching req, along with their drops, latest first.
+func (s *ReportBrowse) BrowseReports(ctx context.Context, req *types.ReportBrowseQuery, c *cursor.Cursor, limit int) (*model.DropReportPage, error) {
+	query := repo.DropReportListQuery{
+		Server:     req.Server,
+		AccountID:  null.NewInt(int64(req.AccountID), req.AccountID != 0),
+		SourceName: null.NewString(req.Source, req.Source != ""),
+		Cursor:     c,
+		Limit:      limit,
+	}
+	if req.PenguinID != "" {
+		account, err := s.AccountService.GetAccountByPenguinId(ctx, req.PenguinID)
+		if errors.Is(err, pgerr.ErrNotFound) {
+			return nil, pgerr.ErrInvalidReq.Msg("account of penguinId `%s` not found", req.PenguinID)
+		} else if err != nil {
+			return nil, err
+		}
+		query.AccountID = null.IntFrom(int64(account.AccountID))
+	}
+	if req.IP != "" {
+		ipRange, err := normalizeIPRange(req.IP)
+		if err != nil {
+			return nil, pgerr.ErrInvalidReq.Msg("invalid ip `%s`", req.IP)
+		}
+		query.IPRange = null.StringFrom(ipRange)
+	}
+	if req.StageID != "" {
+		stage, err := s.StageService.GetStageByArkId(ctx, req.StageID)
+		if err != nil {
+			return nil, pgerr.ErrInvalidReq.Msg("stage `%s` not found", req.StageID)
+		}
+		query.StageID = null.IntFrom(int64(stage.StageID))
+	}
+	if req.Reliability != "" {
+		// validated to be a number already
+		reliability, _ := strconv.Atoi(req.Reliability)
+		query.Reliability = null.IntFrom(int64(reliability))
+	}
+	if req.StartTime != 0 {
+		query.StartTime = time.UnixMilli(req.StartTime)
+	}
+	if req.EndTime != 0 {
+		query.EndTime = time.UnixMilli(req.EndTime)
+	}
+
+	return s.DropReportService.ListDropReports(ctx, query)
+}
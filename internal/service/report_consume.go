@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// ConsumeReportTask runs reportTask through the report pipeline, and returns the violations found by verifiers.
+// Reports with violations are still persisted, only with a reliability describing the violation.
+func (s *Report) ConsumeReportTask(ctx context.Context, reportTask *types.ReportTask) (reportverifs.Violations, error) {
+	process := &ReportProcess{Task: reportTask}
+	if err := s.Pipeline.Process(ctx, process); err != nil {
+		return nil, err
+	}
+	return process.Violations, nil
+}
+
+// consumedReportTask is a report task that has been verified and is pending to be persisted.
+type consumedReportTask struct {
+	task       *types.ReportTask
+	violations reportverifs.Violations
+}
+
+// persistReportTasks persists the reports of tasks within a single transaction, inserting drop reports and their
+// extras with one statement each.
+func (s *Report) persistReportTasks(ctx context.Context, tasks []*consumedReportTask) error {
+	dropReports := make([]*model.DropReport, 0, len(tasks))
+	dropPatterns := make([]*model.DropPattern, 0, len(tasks))
+	extras := make([]*model.DropReportExtra, 0, len(tasks))
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	intendedCommit := false
+	defer func() {
+		if !intendedCommit {
+			log.Warn().Int("tasks", len(tasks)).Msg("rolling back transaction due to error")
+			if err := tx.Rollback(); err != nil {
+				log.Error().Err(err).Msg("failed to rollback transaction")
+			}
+		}
+	}()
+
+	for _, consumed := range tasks {
+		reportTask := consumed.task
+
+		// reportTask.CreatedAt is in microseconds
+		var taskCreatedAt time.Time
+		if reportTask.CreatedAt != 0 {
+			taskCreatedAt = time.UnixMicro(reportTask.CreatedAt)
+		} else {
+			taskCreatedAt = time.Now()
+		}
+
+		if reportTask.IP == "" {
+			// FIXME: temporary hack; find why ip is empty
+			reportTask.IP = "127.0.0.1"
+		}
+
+		// calculate drop pattern hash for each report, whose drops have been merged by the enrich stage
+		for idx, report := range reportTask.Reports {
+			dropPattern, err := s.DropPatternService.GetOrCreateDropPattern(ctx, tx, report.Drops)
+			if err != nil {
+				return err
+			}
+			dropPatterns = append(dropPatterns, dropPattern)
+
+			stage, err := s.StageRepo.GetStageByArkId(ctx, report.StageID)
+			if err != nil {
+				return errors.Wrap(err, "failed to get stage")
+			}
+
+			dropReports = append(dropReports, &model.DropReport{
+				StageID:     stage.StageID,
+				PatternID:   dropPattern.PatternID,
+				Times:       report.Times,
+				CreatedAt:   &taskCreatedAt,
+				Reliability: consumed.violations.Reliability(idx),
+				Server:      reportTask.Server,
+				AccountID:   reportTask.AccountID,
+			})
+
+			md5 := ""
+			if report.Metadata != nil && report.Metadata.MD5 != "" {
+				md5 = report.Metadata.MD5
+			}
+			extras = append(extras, &model.DropReportExtra{
+				IP:         reportTask.IP,
+				Source:     reportTask.Source,
+				Version:    reportTask.Version,
+				Metadata:   report.Metadata,
+				MD5:        null.NewString(md5, md5 != ""),
+				APIKeyID:   null.NewInt(int64(reportTask.APIKeyID), reportTask.APIKeyID != 0),
+				FraudScore: null.FloatFromPtr(report.FraudScore),
+			})
+		}
+	}
+
+	if err = s.DropReportRepo.CreateDropReports(ctx, tx, dropReports); err != nil {
+		return errors.Wrap(err, "failed to create drop reports")
+	}
+	// extras share the id of their drop report, which is only known after the drop reports are inserted
+	for i, extra := range extras {
+		extra.ReportID = dropReports[i].ReportID
+	}
+	if err = s.DropReportExtraRepo.CreateDropReportExtras(ctx, tx, extras); err != nil {
+		return errors.Wrap(err, "failed to create drop report extras")
+	}
+
+	// corrections supersede the reports they correct within the same transaction, so that only one of them counts.
+	// Corrections found unreliable leave the reports they correct as they are, so that a correction could never
+	// take a reliable report out of the drop matrix by itself.
+	corrections := make([]*model.DropReportCorrection, 0)
+	offset := 0
+	for _, consumed := range tasks {
+		for idx, report := range consumed.task.Reports {
+			if report.Supersedes == 0 {
+				continue
+			}
+			if reliability := dropReports[offset+idx].Reliability; reliability != 0 {
+				log.Info().
+					Int("reportId", report.Supersedes).
+					Int("reliability", reliability).
+					Str("taskId", consumed.task.TaskID).
+					Msg("correction is unreliable; the report corrected is kept")
+				continue
+			}
+			superseded, err := s.DropReportRepo.SupersedeDropReport(ctx, tx, report.Supersedes)
+			if err != nil {
+				return errors.Wrap(err, "failed to supersede corrected drop report")
+			}
+			if !superseded {
+				log.Warn().
+					Int("reportId", report.Supersedes).
+					Str("taskId", consumed.task.TaskID).
+					Msg("report corrected has been deleted before the correction is persisted")
+			}
+			corrections = append(corrections, &model.DropReportCorrection{
+				ReportID:          report.Supersedes,
+				CorrectedReportID: dropReports[offset+idx].ReportID,
+				IP:                consumed.task.IP,
+				Reason:            null.NewString(report.CorrectionReason, report.CorrectionReason != ""),
+			})
+		}
+		offset += len(consumed.task.Reports)
+	}
+	if len(corrections) > 0 {
+		if err = s.DropReportCorrectionRepo.CreateDropReportCorrections(ctx, tx, corrections); err != nil {
+			return errors.Wrap(err, "failed to create drop report corrections")
+		}
+	}
+
+	pipe := s.Redis.Pipeline()
+	offset = 0
+	for _, consumed := range tasks {
+		offset += len(consumed.task.Reports)
+		// tasks without reports have nothing to recall, and the report before them belongs to another task
+		if len(consumed.task.Reports) == 0 {
+			continue
+		}
+		// the task is recalled by its last report, as it has always been
+		pipe.Set(ctx, consumed.task.TaskID, dropReports[offset-1].ReportID, s.RecallWindow)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return errors.Wrap(err, "failed to set report id in redis")
+	}
+
+	intendedCommit = true
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	// patterns created are only looked up by hash once committed, as they are gone if the transaction is rolled back
+	s.DropPatternService.RememberDropPatterns(dropPatterns)
+	return nil
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+)
+
+// CorrectSingularReport queues req as the correction of the report of reportHash, which is still recallable, and
+// returns the task id of the corrected report. The corrected report inherits the stage, server and source of the
+// report, and goes through the report gate and quota, and the verifiers, just like a new report. It supersedes the
+// report in the same transaction it is persisted in, only if persisted as reliable, so that only one of them is
+// counted at any time. The report could not be recalled or corrected again once the correction has been queued.
+func (s *Report) CorrectSingularReport(ctx *fiber.Ctx, reportHash string, req *types.ReportCorrectionRequest) (taskId string, err error) {
+	c := observability.FiberContext(ctx)
+	submitter := fiberReportSubmitter(ctx)
+
+	reportId, err := s.resolveRecallableReport(c, reportHash)
+	if err != nil {
+		return "", err
+	}
+	dropReport, err := s.DropReportRepo.GetDropReportById(c, reportId)
+	if err != nil {
+		return "", err
+	}
+	extra, err := s.DropReportExtraRepo.GetDropReportExtraById(c, reportId)
+	if err != nil {
+		return "", err
+	}
+	stage, err := s.StageService.GetStageById(c, dropReport.StageID)
+	if err != nil {
+		return "", err
+	}
+
+	correction := &types.SingleReportRequest{
+		FragmentStageID: types.FragmentStageID{StageID: stage.ArkStageID},
+		FragmentReportCommon: types.FragmentReportCommon{
+			Server:  dropReport.Server,
+			Source:  extra.Source,
+			Version: extra.Version,
+		},
+		Drops:    req.Drops,
+		Times:    req.Times,
+		Metadata: extra.Metadata,
+	}
+
+	if err = s.pipelineReportGate(c, correction.Source, correction.Version); err != nil {
+		return "", err
+	}
+
+	// the corrected drops are validated just like those of a new report
+	drops, err := s.pipelineMergeDropsAndMapDropTypes(c, correction.Drops)
+	if err != nil {
+		return "", err
+	}
+	if err = s.pipelineRejectCorrectable(c, correction); err != nil {
+		return "", err
+	}
+
+	times := correction.Times
+	if times == 0 {
+		times = 1
+	}
+	singleReport := &types.ReportTaskSingleReport{
+		FragmentStageID:  correction.FragmentStageID,
+		Drops:            drops,
+		Times:            times,
+		Metadata:         correction.Metadata,
+		Supersedes:       reportId,
+		CorrectionReason: req.Reason,
+	}
+	if err = s.pipelineAggregateGachaboxDrops(c, singleReport, times); err != nil {
+		return "", err
+	}
+
+	reportTask := &types.ReportTask{
+		CreatedAt:            time.Now().UnixMicro(),
+		FragmentReportCommon: correction.FragmentReportCommon,
+		Reports:              []*types.ReportTaskSingleReport{singleReport},
+		AccountID:            dropReport.AccountID,
+		IP:                   submitter.IP,
+		APIKeyID:             submitter.APIKeyID,
+	}
+	if err = s.pipelineQuota(c, submitter, reportTask); err != nil {
+		return "", err
+	}
+
+	// claim the report hash, so that the report could only be corrected, or recalled, once
+	claimed, err := s.Redis.Del(c, reportHash).Result()
+	if err != nil {
+		s.refundQuota(c, submitter, reportTask)
+		return "", err
+	}
+	if claimed == 0 {
+		s.refundQuota(c, submitter, reportTask)
+		return "", ErrReportNotFound
+	}
+
+	// the screenshot of the report is claimed by the report itself, hence the correction is not deduplicated
+	taskId = s.pipelineTaskId(submitter)
+	reportTask.TaskID = taskId
+	if err = s.queueReportTask(c, "REPORT.SINGLE", reportTask); err != nil {
+		// hand the report hash back for the rest of the recall window, so that the correction could be retried
+		if ttl := time.Until(dropReport.CreatedAt.Add(s.RecallWindow)); ttl > 0 {
+			if setErr := s.Redis.Set(c, reportHash, reportId, ttl).Err(); setErr != nil {
+				log.Warn().Err(setErr).Int("reportId", reportId).Msg("failed to release report hash after correction failed")
+			}
+		}
+		s.refundQuota(c, submitter, reportTask)
+		return "", err
+	}
+
+	return taskId, nil
+}
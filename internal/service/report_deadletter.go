@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+)
+
+// DeadLetterReportTask publishes the report task data, originally published to subject as the message of stream
+// sequence, to the dead-letter subject after it could not be consumed for attempts times, with consumeErr being the
+// error of the last attempt.
+func (s *Report) DeadLetterReportTask(ctx context.Context, subject string, sequence uint64, data []byte, consumeErr error, attempts int) error {
+	deadLetterJSON, err := json.Marshal(&types.DeadLetterReportTask{
+		Subject:  subject,
+		Error:    consumeErr.Error(),
+		Attempts: attempts,
+		Task:     data,
+	})
+	if err != nil {
+		return err
+	}
+
+	// the stream sequence identifies the delivery being dead-lettered, as a task requeued is published anew
+	if err = s.publish(ctx, constant.ReportSubjectDeadLetter, deadLetterJSON, fmt.Sprintf("dead-letter:%s:%d", subject, sequence)); err != nil {
+		return err
+	}
+
+	if taskId := gjson.GetBytes(data, "taskId").String(); taskId != "" {
+		s.setReportTaskStatus(ctx, taskId, constant.ReportTaskStateFailed, func(status *modelv2.ReportTaskStatus) {
+			status.Error = consumeErr.Error()
+		})
+	}
+	return nil
+}
+
+// PersistDeadLetterReportTask saves a dead-lettered report task so that it could be inspected by admins.
+func (s *Report) PersistDeadLetterReportTask(ctx context.Context, deadLetter *types.DeadLetterReportTask) error {
+	return s.RejectedReportTaskRepo.CreateRejectedReportTask(ctx, &model.RejectedReportTask{
+		TaskID:   gjson.GetBytes(deadLetter.Task, "taskId").String(),
+		Subject:  deadLetter.Subject,
+		Task:     deadLetter.Task,
+		Error:    deadLetter.Error,
+		Attempts: deadLetter.Attempts,
+	})
+}
+
+func (s *Report) GetRejectedReportTasks(ctx context.Context) ([]*model.RejectedReportTask, error) {
+	return s.RejectedReportTaskRepo.GetRejectedReportTasks(ctx)
+}
+
+// RequeueRejectedReportTask publishes the rejected report task back to its original subject, and removes it
+// from rejected report tasks.
+func (s *Report) RequeueRejectedReportTask(ctx context.Context, id int) error {
+	task, err := s.RejectedReportTaskRepo.GetRejectedReportTaskById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	// marked before it is published, as the worker could have consumed it before publishing returns
+	s.setReportTaskStatus(ctx, task.TaskID, constant.ReportTaskStateQueued, nil)
+	if err := s.publish(ctx, task.Subject, task.Task, fmt.Sprintf("%s:requeue:%d", task.TaskID, task.RejectedTaskID)); err != nil {
+		s.clearReportTaskStatus(ctx, task.TaskID)
+		return err
+	}
+
+	return s.RejectedReportTaskRepo.DeleteRejectedReportTask(ctx, id)
+}
+
+func (s *Report) DiscardRejectedReportTask(ctx context.Context, id int) error {
+	if _, err := s.RejectedReportTaskRepo.GetRejectedReportTaskById(ctx, id); err != nil {
+		return err
+	}
+
+	return s.RejectedReportTaskRepo.DeleteRejectedReportTask(ctx, id)
+}
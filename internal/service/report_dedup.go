@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+// screenshotClaims is the set of screenshot md5 keys claimed by a report task, which shall be released if
+// the task failed to be committed.
+type screenshotClaims []string
+
+// pipelineDedupScreenshots claims the screenshot md5 of each report in task for taskId, and removes reports whose
+// screenshot has already been claimed by another task within config.Config ReportScreenshotDedupWindow. The removed reports are
+// returned as duplicates, keyed by their original index in task and valued by the id of the task which claimed
+// their screenshot.
+func (s *Report) pipelineDedupScreenshots(ctx context.Context, task *types.ReportTask, taskId string) (duplicates map[int]string, claims screenshotClaims, err error) {
+	duplicates = map[int]string{}
+	window := s.RuntimeConfig.Current().ReportScreenshotDedupWindow
+	if window <= 0 {
+		return duplicates, nil, nil
+	}
+
+	reports := make([]*types.ReportTaskSingleReport, 0, len(task.Reports))
+	for index, report := range task.Reports {
+		if report.Metadata == nil || report.Metadata.MD5 == "" {
+			reports = append(reports, report)
+			continue
+		}
+
+		key := constant.ReportScreenshotKeyPrefix + strings.ToLower(report.Metadata.MD5)
+		claimed, err := s.Redis.SetNX(ctx, key, taskId, window).Result()
+		if err != nil {
+			s.releaseScreenshotClaims(ctx, claims)
+			return nil, nil, errors.Wrap(err, "failed to claim screenshot")
+		}
+		if claimed {
+			claims = append(claims, key)
+			reports = append(reports, report)
+			continue
+		}
+
+		claimedBy, err := s.Redis.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			// the claim has just expired; simply take the report as is
+			reports = append(reports, report)
+			continue
+		} else if err != nil {
+			s.releaseScreenshotClaims(ctx, claims)
+			return nil, nil, errors.Wrap(err, "failed to resolve screenshot claim")
+		}
+
+		log.Info().
+			Str("taskId", taskId).
+			Str("claimedBy", claimedBy).
+			Str("md5", report.Metadata.MD5).
+			Msg("merged report with duplicated screenshot")
+		duplicates[index] = claimedBy
+	}
+
+	task.Reports = reports
+	return duplicates, claims, nil
+}
+
+// firstDuplicate returns the id of the task which claimed the screenshot of the first duplicated report.
+func firstDuplicate(duplicates map[int]string) string {
+	first := -1
+	for index := range duplicates {
+		if first == -1 || index < first {
+			first = index
+		}
+	}
+	return duplicates[first]
+}
+
+func (s *Report) releaseScreenshotClaims(ctx context.Context, claims screenshotClaims) {
+	if len(claims) == 0 {
+		return
+	}
+	if err := s.Redis.Del(ctx, claims...).Err(); err != nil {
+		log.Warn().Err(err).Strs("keys", claims).Msg("failed to release screenshot claims")
+	}
+}
+
+// releaseRecalledScreenshots releases the screenshot claims of recalled reports, so that their screenshots could be
+// submitted again once the mistaken reports are recalled.
+func (s *Report) releaseRecalledScreenshots(ctx context.Context, reportIds []int) {
+	claims := make(screenshotClaims, 0, len(reportIds))
+	for _, reportId := range reportIds {
+		extra, err := s.DropReportExtraRepo.GetDropReportExtraById(ctx, reportId)
+		if err != nil || !extra.MD5.Valid || extra.MD5.String == "" {
+			continue
+		}
+		claims = append(claims, constant.ReportScreenshotKeyPrefix+strings.ToLower(extra.MD5.String))
+	}
+	s.releaseScreenshotClaims(ctx, claims)
+}
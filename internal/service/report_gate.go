@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/snapshot"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// ReportGate manages report gates, which allow, reject or down-weight reports from known-broken client versions.
+type ReportGate struct {
+	Redis              *redis.Client
+	ReportGateRepo     *repo.ReportGate
+	ReportGateVerifier *reportverifs.ReportGateVerifier
+}
+
+func NewReportGate(redisClient *redis.Client, reportGateRepo *repo.ReportGate, reportGateVerifier *reportverifs.ReportGateVerifier) *ReportGate {
+	return &ReportGate{
+		Redis:              redisClient,
+		ReportGateRepo:     reportGateRepo,
+		ReportGateVerifier: reportGateVerifier,
+	}
+}
+
+func (s *ReportGate) GetReportGates(ctx context.Context) ([]*model.ReportGate, error) {
+	return s.ReportGateRepo.GetReportGates(ctx)
+}
+
+func (s *ReportGate) CreateReportGate(ctx context.Context, req *types.ReportGateRequest) (*model.ReportGate, error) {
+	gate := &model.ReportGate{
+		Source: req.Source,
+		Action: req.Action,
+		Reason: req.Reason,
+	}
+	if req.VersionFrom != "" {
+		gate.VersionFrom = null.StringFrom(prefixVersion(req.VersionFrom))
+	}
+	if req.VersionBefore != "" {
+		gate.VersionBefore = null.StringFrom(prefixVersion(req.VersionBefore))
+	}
+
+	if err := s.ReportGateRepo.CreateReportGate(ctx, gate); err != nil {
+		return nil, err
+	}
+	return gate, s.SyncReportGates(ctx)
+}
+
+func (s *ReportGate) DeleteReportGate(ctx context.Context, id int) error {
+	if err := s.ReportGateRepo.DeleteReportGate(ctx, id); err != nil {
+		return err
+	}
+	return s.SyncReportGates(ctx)
+}
+
+// SyncReportGates publishes report gates to Redis, from where the report gate verifier of every instance reloads
+// them.
+func (s *ReportGate) SyncReportGates(ctx context.Context) error {
+	gates, err := s.ReportGateRepo.GetReportGates(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshot.Publish(ctx, s.Redis, constant.ReportGatesKey, gates); err != nil {
+		return errors.Wrap(err, "failed to sync report gates")
+	}
+
+	// take effect on this instance right away
+	s.ReportGateVerifier.SetGates(gates)
+	return nil
+}
+
+// prefixVersion returns version prefixed with `v`, in the form accepted by semver.
+func prefixVersion(version string) string {
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+	return "v" + version
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/util/rekuest"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// reportImportCheckpointTTL is how long the checkpoint of an import is kept after the import has last run.
+const reportImportCheckpointTTL = time.Hour * 24 * 7
+
+// reportImportMaxLineSize is the maximum size of a single record line.
+const reportImportMaxLineSize = 1 << 20
+
+type ReportImportOptions struct {
+	// ImportID identifies the import. Running an import again with the same ImportID resumes from its checkpoint.
+	ImportID string
+	// Concurrency is the number of records processed concurrently.
+	Concurrency int
+	// DryRun processes and verifies records without queueing them or recording the checkpoint.
+	DryRun bool
+}
+
+type ReportImportSummary struct {
+	Total     int64 `json:"total"`
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	// Skipped is the number of records already processed according to the checkpoint.
+	Skipped int64 `json:"skipped"`
+}
+
+type reportImportLine struct {
+	index int
+	line  []byte
+}
+
+// ImportReports reads historical reports from r, one types.ReportImportRecord per line, and queues them through
+// the singular report pipeline. Progress is checkpointed to Redis so an interrupted import can be resumed without
+// reprocessing, and every failed record is written to errW as a types.BatchReportError JSON line.
+func (s *Report) ImportReports(ctx context.Context, r io.Reader, errW io.Writer, opts *ReportImportOptions) (*ReportImportSummary, error) {
+	if opts.ImportID == "" {
+		return nil, errors.New("import id is required")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	checkpointKey := constant.ReportImportCheckpointKeyPrefix + opts.ImportID
+	checkpoint, err := s.Redis.Get(ctx, checkpointKey).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, errors.Wrap(err, "failed to load import checkpoint")
+	}
+
+	summary := &ReportImportSummary{}
+	var errWMu sync.Mutex
+	writeError := func(index int, reason string) {
+		atomic.AddInt64(&summary.Failed, 1)
+
+		errWMu.Lock()
+		defer errWMu.Unlock()
+		if err := json.NewEncoder(errW).Encode(types.BatchReportError{Index: index, Reason: reason}); err != nil {
+			log.Error().Err(err).Int("index", index).Msg("failed to write report import error")
+		}
+	}
+
+	lines := make(chan reportImportLine)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for l := range lines {
+				if err := s.importReport(ctx, l.index, l.line, checkpointKey, opts); err != nil {
+					writeError(l.index, err.Error())
+					continue
+				}
+				atomic.AddInt64(&summary.Succeeded, 1)
+			}
+		}()
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), reportImportMaxLineSize)
+	index := 0
+	for ; scanner.Scan(); index++ {
+		summary.Total++
+		if isReportImportCheckpointed(checkpoint, index) {
+			summary.Skipped++
+			continue
+		}
+
+		// scanner reuses its buffer, so the line has to be copied before handing over to workers
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+
+		select {
+		case lines <- reportImportLine{index: index, line: line}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(lines)
+	wg.Wait()
+
+	if !opts.DryRun {
+		s.Redis.Expire(ctx, checkpointKey, reportImportCheckpointTTL)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return summary, errors.Wrapf(err, "failed to read record at line %d", index+1)
+	}
+	return summary, ctx.Err()
+}
+
+func (s *Report) importReport(ctx context.Context, index int, line []byte, checkpointKey string, opts *ReportImportOptions) error {
+	var record types.ReportImportRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		return errors.Wrap(err, "invalid record")
+	}
+	if err := rekuest.Validate.Struct(&record); err != nil {
+		return errors.Wrap(err, "invalid record")
+	}
+
+	createdAt := time.UnixMilli(record.CreatedAt)
+	record.StageID, _ = s.StageRewriteService.Apply(ctx, record.Source, record.Version, record.StageID, createdAt)
+
+	drops, err := s.pipelineMergeDropsAndMapDropTypes(ctx, record.Drops)
+	if err != nil {
+		return err
+	}
+
+	singleReport := &types.ReportTaskSingleReport{
+		FragmentStageID: record.FragmentStageID,
+		Drops:           drops,
+		Times:           1,
+		Metadata:        record.Metadata,
+	}
+	if err = s.pipelineAggregateGachaboxDrops(ctx, singleReport, 1); err != nil {
+		return err
+	}
+
+	reportTask := &types.ReportTask{
+		// task id is deterministic so that a record re-queued after a crash could be traced back
+		TaskID:               opts.ImportID + "-" + strconv.Itoa(index),
+		CreatedAt:            createdAt.UnixMicro(),
+		FragmentReportCommon: record.FragmentReportCommon,
+		Reports:              []*types.ReportTaskSingleReport{singleReport},
+		AccountID:            record.AccountID,
+		IP:                   record.IP,
+	}
+
+	if opts.DryRun {
+		violations, err := s.ReportVerifier.Verify(reportverifs.WithDryRun(ctx), reportTask)
+		if err != nil {
+			return err
+		}
+		if violation, ok := violations[0]; ok {
+			return errors.Errorf("would be rejected by verifier `%s`: %s", violation.Name, violation.Message)
+		}
+		return nil
+	}
+
+	// the checkpoint is recorded ahead of publishing, so that a record queued is never queued again on resumption,
+	// even if the import is interrupted right after publishing; it is cleared again if publishing fails
+	if err := s.Redis.SetBit(ctx, checkpointKey, int64(index), 1).Err(); err != nil {
+		return errors.Wrap(err, "failed to record import checkpoint")
+	}
+	if err := s.publishReportTask(ctx, "REPORT.SINGLE", reportTask); err != nil {
+		if err := s.Redis.SetBit(ctx, checkpointKey, int64(index), 0).Err(); err != nil {
+			log.Error().Err(err).Int("index", index).Msg("failed to clear report import checkpoint")
+		}
+		return err
+	}
+	return nil
+}
+
+// isReportImportCheckpointed reports whether the record at index is marked as processed in the checkpoint bitmap,
+// which follows the bit order of Redis SETBIT.
+func isReportImportCheckpointed(checkpoint []byte, index int) bool {
+	if index/8 >= len(checkpoint) {
+		return false
+	}
+	return checkpoint[index/8]&(0x80>>(index%8)) != 0
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+	"github.com/penguin-statistics/backend-next/internal/util/reportutil"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// Orders of built-in stages of the report pipeline. Stages injected by AsReportStage are placed in-between by their
+// orders, e.g. a scoring stage ordered 250 runs on verified reports, before drops are enriched.
+const (
+	ReportStageOrderDecode  = 100
+	ReportStageOrderVerify  = 200
+	ReportStageOrderEnrich  = 300
+	ReportStageOrderPersist = 400
+	ReportStageOrderNotify  = 500
+)
+
+// ReportProcess is a report task going through the report pipeline.
+type ReportProcess struct {
+	// Subject and Data are of the message the task is decoded from. Data is empty for tasks consumed inline, which
+	// have been decoded already.
+	Subject string
+	Data    []byte
+
+	Task *types.ReportTask
+	// Violations are found by verifiers, keyed by indices of reports of Task
+	Violations reportverifs.Violations
+
+	// DryRun runs the task through the pipeline without persisting its reports, e.g. for replays previewing how
+	// archived tasks would be verified. Stages with side effects shall skip them for dry runs.
+	DryRun bool
+}
+
+// ReportNext hands a report task over to the stages after the calling one.
+type ReportNext func(ctx context.Context) error
+
+// ReportStage is a stage of the report pipeline. Stages are middlewares: each of them calls next to hand the task
+// over to the stages after it, and could act on the task before and after them. A stage stops the task from going
+// further by returning without calling next, or by returning an error, which fails the task.
+type ReportStage interface {
+	// Name labels metrics of the stage.
+	Name() string
+	// Order is the position of the stage in the pipeline. Stages of lower orders run first.
+	Order() int
+	Process(ctx context.Context, process *ReportProcess, next ReportNext) error
+}
+
+// AsReportStage annotates constructor of a ReportStage, so that the stage is added to the report pipeline once
+// provided to the application.
+func AsReportStage(constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(ReportStage)),
+		fx.ResultTags(`group:"reportStages"`),
+	)
+}
+
+// ReportPipeline runs report tasks consumed through its stages, in order: decode, verify, enrich, persist and
+// notify, along with stages injected in-between.
+type ReportPipeline struct {
+	stages []ReportStage
+}
+
+func newReportPipeline(stages ...ReportStage) *ReportPipeline {
+	pipeline := &ReportPipeline{}
+	pipeline.Add(stages...)
+	return pipeline
+}
+
+// Add adds stages to the pipeline. Stages shall only be added before the application starts.
+func (p *ReportPipeline) Add(stages ...ReportStage) {
+	p.stages = append(p.stages, stages...)
+	sort.SliceStable(p.stages, func(i, j int) bool { return p.stages[i].Order() < p.stages[j].Order() })
+}
+
+// Stages returns names of stages of the pipeline, in the order they run.
+func (p *ReportPipeline) Stages() []string {
+	names := make([]string, 0, len(p.stages))
+	for _, stage := range p.stages {
+		names = append(names, stage.Name())
+	}
+	return names
+}
+
+// Process runs process through all stages of the pipeline.
+func (p *ReportPipeline) Process(ctx context.Context, process *ReportProcess) error {
+	return p.run(ctx, process, 0)
+}
+
+// run runs process through the stage of index and stages after it. Durations and failures are recorded to each
+// stage without those of the stages after it.
+func (p *ReportPipeline) run(ctx context.Context, process *ReportProcess, index int) error {
+	if index >= len(p.stages) {
+		return nil
+	}
+	stage := p.stages[index]
+
+	var (
+		downstream    time.Duration
+		downstreamErr error
+	)
+	start := time.Now()
+	err := stage.Process(ctx, process, func(ctx context.Context) error {
+		nextStart := time.Now()
+		downstreamErr = p.run(ctx, process, index+1)
+		downstream += time.Since(nextStart)
+		return downstreamErr
+	})
+	observability.ReportStageDuration.
+		WithLabelValues(stage.Name()).
+		Observe((time.Since(start) - downstream).Seconds())
+	if err != nil && !errors.Is(err, downstreamErr) {
+		observability.ReportStageFailures.WithLabelValues(stage.Name()).Inc()
+	}
+	return err
+}
+
+type ReportStagesDeps struct {
+	fx.In
+
+	Stages []ReportStage `group:"reportStages"`
+}
+
+// InjectReportStages adds stages provided by AsReportStage to the report pipeline of s.
+func InjectReportStages(s *Report, deps ReportStagesDeps) {
+	s.Pipeline.Add(deps.Stages...)
+	log.Debug().Strs("stages", s.Pipeline.Stages()).Msg("report pipeline assembled")
+}
+
+// builtinReportStages returns built-in stages of the report pipeline of s.
+func (s *Report) builtinReportStages() []ReportStage {
+	return []ReportStage{
+		&reportDecodeStage{},
+		&reportVerifyStage{s},
+		&reportEnrichStage{s},
+		&reportPersistStage{s},
+		&reportNotifyStage{s},
+	}
+}
+
+// reportDecodeStage decodes tasks consumed from NATS, and continues the trace of their submission.
+type reportDecodeStage struct{}
+
+func (*reportDecodeStage) Name() string { return "decode" }
+func (*reportDecodeStage) Order() int   { return ReportStageOrderDecode }
+
+func (*reportDecodeStage) Process(ctx context.Context, process *ReportProcess, next ReportNext) (err error) {
+	if process.Task != nil {
+		return next(ctx)
+	}
+
+	reportTask := &types.ReportTask{}
+	if err := json.Unmarshal(process.Data, reportTask); err != nil {
+		return errors.Wrap(err, "failed to decode report task")
+	}
+	process.Task = reportTask
+
+	ctx, span := observability.Tracer().Start(
+		observability.ExtractTraceContext(ctx, reportTask.TraceContext),
+		"NATS consume "+process.Subject,
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(
+			semconv.MessagingSystemKey.String("nats"),
+			semconv.MessagingDestinationKey.String(process.Subject),
+			semconv.MessagingMessageIDKey.String(reportTask.TaskID),
+			semconv.MessagingOperationProcess,
+		),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	return next(ctx)
+}
+
+// reportVerifyStage runs verifiers on reports. Reports with violations still go further, only with a reliability
+// describing the violation.
+type reportVerifyStage struct {
+	*Report
+}
+
+func (*reportVerifyStage) Name() string { return "verify" }
+func (*reportVerifyStage) Order() int   { return ReportStageOrderVerify }
+
+func (s *reportVerifyStage) Process(ctx context.Context, process *ReportProcess, next ReportNext) error {
+	L := log.With().
+		Interface("task", process.Task).
+		Logger()
+
+	L.Info().Msg("now processing new report task")
+
+	verifyCtx := ctx
+	if process.DryRun {
+		verifyCtx = reportverifs.WithDryRun(ctx)
+	}
+	violations, err := s.ReportVerifier.Verify(verifyCtx, process.Task)
+	if err != nil {
+		return err
+	}
+	process.Violations = violations
+	if len(process.Violations) > 0 {
+		L.Warn().
+			Interface("violations", process.Violations).
+			Msg("report task verification failed on some or all reports")
+	}
+	if !process.DryRun {
+		s.setReportTaskStatus(ctx, process.Task.TaskID, constant.ReportTaskStateVerified, nil)
+	}
+	return next(ctx)
+}
+
+// reportEnrichStage leaves out drops of types not counted in patterns, which have been verified, and merges drops of
+// the same item, as they are persisted.
+type reportEnrichStage struct {
+	*Report
+}
+
+func (*reportEnrichStage) Name() string { return "enrich" }
+func (*reportEnrichStage) Order() int   { return ReportStageOrderEnrich }
+
+func (s *reportEnrichStage) Process(ctx context.Context, process *ReportProcess, next ReportNext) error {
+	for _, report := range process.Task.Reports {
+		patternDrops, err := s.DropTypeService.FilterPatternDrops(ctx, report.Drops)
+		if err != nil {
+			return errors.Wrap(err, "failed to filter drops by drop type")
+		}
+		report.Drops = reportutil.MergeDropsByItemID(patternDrops)
+	}
+	return next(ctx)
+}
+
+// reportPersistStage persists reports, in batches if batching is enabled.
+type reportPersistStage struct {
+	*Report
+}
+
+func (*reportPersistStage) Name() string { return "persist" }
+func (*reportPersistStage) Order() int   { return ReportStageOrderPersist }
+
+func (s *reportPersistStage) Process(ctx context.Context, process *ReportProcess, next ReportNext) error {
+	if process.DryRun {
+		return next(ctx)
+	}
+
+	var err error
+	if s.Batcher != nil {
+		err = s.Batcher.persist(ctx, process.Task, process.Violations)
+	} else {
+		err = s.persistReportTasks(ctx, []*consumedReportTask{{task: process.Task, violations: process.Violations}})
+	}
+	if err != nil {
+		return err
+	}
+	return next(ctx)
+}
+
+// reportNotifyStage publishes the verdict of persisted reports to their submitter, and invalidates what their
+// submitter has been counted for.
+type reportNotifyStage struct {
+	*Report
+}
+
+func (*reportNotifyStage) Name() string { return "notify" }
+func (*reportNotifyStage) Order() int   { return ReportStageOrderNotify }
+
+func (s *reportNotifyStage) Process(ctx context.Context, process *ReportProcess, next ReportNext) error {
+	if process.DryRun {
+		return next(ctx)
+	}
+
+	reportTask := process.Task
+	for idx := range reportTask.Reports {
+		observability.ReportReliability.
+			WithLabelValues(strconv.Itoa(process.Violations.Reliability(idx)), reportTask.Source).
+			Inc()
+	}
+
+	s.setReportTaskConsumed(ctx, reportTask.TaskID, process.Violations)
+	markAccountTrustDirty(ctx, s.Redis, reportTask.AccountID)
+	s.CacheVersionService.invalidatePersonalCaches(ctx, reportTask.AccountID)
+	return next(ctx)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/breaker"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+var ErrReportQueueUnavailable = pgerr.New(fiber.StatusServiceUnavailable, "REPORT_QUEUE_UNAVAILABLE", "reports could not be queued as the message queue is unavailable; retry later").WithReason(pgerr.ReasonReportQueueUnavailable)
+
+// ReportPublishPolicy decides how publishing report tasks to NATS is retried, and when to stop trying altogether.
+type ReportPublishPolicy struct {
+	// Timeout is the timeout of a single attempt to publish.
+	Timeout time.Duration
+	// Retries is the number of retries after the first attempt has failed.
+	Retries int
+	// Backoff is the base of the exponential backoff in-between retries, which is fully jittered.
+	Backoff time.Duration
+	// Breaker rejects publishing with ErrReportQueueUnavailable once publishing has failed repeatedly.
+	// Publishing is never rejected if nil.
+	Breaker *breaker.Breaker
+}
+
+// backoff returns the duration to wait for before the retry-th retry, drawn from [0, Backoff * 2^(retry-1)).
+func (p *ReportPublishPolicy) backoff(retry int) time.Duration {
+	ceiling := p.Backoff << (retry - 1)
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// publish publishes data to subject as the message of msgId, retrying by s.PublishPolicy. An attempt timed out could
+// have been published nonetheless, hence msgId is required for JetStream to drop messages published more than once.
+func (s *Report) publish(ctx context.Context, subject string, data []byte, msgId string) error {
+	policy := s.PublishPolicy
+	if !policy.Breaker.Allow() {
+		observability.ReportPublishFailures.WithLabelValues(subject, "circuit_open").Inc()
+		return ErrReportQueueUnavailable
+	}
+
+	var err error
+	for attempt := 0; attempt <= policy.Retries; attempt++ {
+		if attempt > 0 {
+			observability.ReportPublishRetries.WithLabelValues(subject).Inc()
+			select {
+			case <-time.After(policy.backoff(attempt)):
+			case <-ctx.Done():
+				// the caller has gone away, which says nothing about the health of NATS
+				policy.Breaker.Abandon()
+				return ctx.Err()
+			}
+		}
+
+		err = s.publishOnce(ctx, subject, data, nats.MsgId(msgId))
+		if err == nil {
+			policy.Breaker.Success()
+			return nil
+		}
+		if ctx.Err() != nil {
+			policy.Breaker.Abandon()
+			return err
+		}
+
+		reason := "error"
+		if errors.Is(err, ErrNatsTimeout) {
+			reason = "timeout"
+		}
+		observability.ReportPublishFailures.WithLabelValues(subject, reason).Inc()
+		log.Warn().Err(err).Str("subject", subject).Int("attempt", attempt+1).Msg("failed to publish report task")
+	}
+
+	policy.Breaker.Failure()
+	return errors.Wrapf(err, "failed to publish to %s after %d attempts", subject, policy.Retries+1)
+}
+
+func (s *Report) publishOnce(ctx context.Context, subject string, data []byte, opts ...nats.PubOpt) error {
+	pub, err := s.NatsJS.PublishAsync(subject, data, opts...)
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(s.PublishPolicy.Timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-pub.Err():
+		return err
+	case <-pub.Ok():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return ErrNatsTimeout
+	}
+}
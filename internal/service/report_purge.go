@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// ReportPurge marks persisted drop reports matching a filter unreliable in bulk, e.g. after a batch of fake reports
+// has been identified, and refreshes the drop matrix of the stages affected.
+type ReportPurge struct {
+	DB                   *bun.DB
+	Redis                *redis.Client
+	DropReportRepo       *repo.DropReport
+	ReportPurgeRepo      *repo.ReportPurge
+	StageService         *Stage
+	MatrixRefreshService *MatrixRefresh
+	CacheVersionService  *CacheVersion
+}
+
+func NewReportPurge(db *bun.DB, redisClient *redis.Client, dropReportRepo *repo.DropReport, reportPurgeRepo *repo.ReportPurge, stageService *Stage, matrixRefreshService *MatrixRefresh, cacheVersionService *CacheVersion) *ReportPurge {
+	return &ReportPurge{
+		DB:                   db,
+		Redis:                redisClient,
+		DropReportRepo:       dropReportRepo,
+		ReportPurgeRepo:      reportPurgeRepo,
+		StageService:         stageService,
+		MatrixRefreshService: matrixRefreshService,
+		CacheVersionService:  cacheVersionService,
+	}
+}
+
+func (s *ReportPurge) GetReportPurges(ctx context.Context, limit int) ([]*model.ReportPurge, error) {
+	return s.ReportPurgeRepo.GetReportPurges(ctx, limit)
+}
+
+// PurgeReports marks reliable reports matching req.Filter unreliable and records the purge with the IP of the admin
+// requesting it. The drop matrix of the stages affected is then refreshed in background.
+// Dry runs only count the reports which would be purged.
+func (s *ReportPurge) PurgeReports(ctx context.Context, req *types.ReportPurgeRequest, ip string) (*types.ReportPurgeResponse, error) {
+	filter, err := s.convertFilter(ctx, &req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var purgedStages []*model.PurgedStage
+	purge := &model.ReportPurge{
+		Filter: &req.Filter,
+		Reason: req.Reason,
+		IP:     ip,
+	}
+	if req.DryRun {
+		purgedStages, err = s.DropReportRepo.CountReliableDropReports(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, stage := range purgedStages {
+			purge.AffectedReports += stage.Reports
+		}
+	} else {
+		err = s.DB.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+			purgedStages, err = s.DropReportRepo.MarkDropReportsUnreliable(ctx, tx, filter, constant.ViolationReliabilityPurged)
+			if err != nil {
+				return err
+			}
+			for _, stage := range purgedStages {
+				purge.AffectedReports += stage.Reports
+			}
+			return s.ReportPurgeRepo.CreateReportPurge(ctx, tx, purge)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stagesMapById, err := s.StageService.GetStagesMapById(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := &types.ReportPurgeResponse{
+		AffectedReports: purge.AffectedReports,
+		Stages:          make([]string, 0, len(purgedStages)),
+	}
+	touchedStages := make([]*model.TouchedStage, 0, len(purgedStages))
+	for _, stage := range purgedStages {
+		if arkStage, ok := stagesMapById[stage.StageID]; ok {
+			resp.Stages = append(resp.Stages, arkStage.ArkStageID)
+		}
+		touchedStages = append(touchedStages, &model.TouchedStage{
+			StageID:      stage.StageID,
+			MinCreatedAt: stage.MinCreatedAt,
+			MaxCreatedAt: stage.MaxCreatedAt,
+		})
+	}
+	if req.DryRun {
+		return resp, nil
+	}
+
+	resp.PurgeID = purge.PurgeID
+	if len(touchedStages) > 0 {
+		resp.RefreshID = s.MatrixRefreshService.RefreshTouchedStages(filter.Server, touchedStages)
+		resp.Subject = constant.MatrixRefreshSubjectPrefix + resp.RefreshID
+		// the purge has been committed already, so failing to record the refresh id only loses a reference
+		if _, err := s.DB.NewUpdate().
+			Model(purge).
+			Set("refresh_id = ?", resp.RefreshID).
+			WherePK().
+			Exec(ctx); err != nil {
+			log.Warn().Err(err).Int("purgeId", purge.PurgeID).Msg("failed to record refresh id of report purge")
+		}
+	}
+	return resp, nil
+}
+
+// RestoreDropReport reverses the recall or the purge of reportId, e.g. when it has been recalled by accident, and
+// refreshes the drop matrix of its stage in background.
+func (s *ReportPurge) RestoreDropReport(ctx context.Context, reportId int) (*types.ReportRestoreResponse, error) {
+	var dropReport *model.DropReport
+	err := s.DB.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) (err error) {
+		dropReport, err = s.DropReportRepo.RestoreDropReport(ctx, tx, reportId)
+		return err
+	})
+	if errors.Is(err, pgerr.ErrNotFound) {
+		return nil, pgerr.ErrInvalidReq.Msg("report %d not found or not deleted", reportId)
+	} else if err != nil {
+		return nil, err
+	}
+
+	// the report counts towards the trust score and the personal matrix of its submitter again
+	markAccountTrustDirty(ctx, s.Redis, dropReport.AccountID)
+	s.CacheVersionService.invalidatePersonalCaches(ctx, dropReport.AccountID)
+
+	refreshId := s.MatrixRefreshService.RefreshTouchedStages(dropReport.Server, []*model.TouchedStage{{
+		StageID:      dropReport.StageID,
+		MinCreatedAt: *dropReport.CreatedAt,
+		MaxCreatedAt: *dropReport.CreatedAt,
+	}})
+	return &types.ReportRestoreResponse{
+		ReportID:    dropReport.ReportID,
+		Reliability: dropReport.Reliability,
+		RefreshID:   refreshId,
+		Subject:     constant.MatrixRefreshSubjectPrefix + refreshId,
+	}, nil
+}
+
+func (s *ReportPurge) convertFilter(ctx context.Context, req *types.ReportPurgeFilter) (*model.DropReportFilter, error) {
+	if req.AccountID == 0 && req.IPRange == "" && req.Source == "" && req.StageID == "" {
+		return nil, pgerr.ErrInvalidReq.Msg("at least one of accountId, ipRange, source and stageId is required")
+	}
+
+	filter := &model.DropReportFilter{
+		Server:     req.Server,
+		StartTime:  time.UnixMilli(req.StartTime),
+		EndTime:    time.Now(),
+		AccountID:  null.NewInt(int64(req.AccountID), req.AccountID != 0),
+		SourceName: null.NewString(req.Source, req.Source != ""),
+		Version:    null.NewString(req.Version, req.Version != ""),
+	}
+	if req.EndTime != 0 {
+		filter.EndTime = time.UnixMilli(req.EndTime)
+	}
+	if req.IPRange != "" {
+		ipRange, err := normalizeIPRange(req.IPRange)
+		if err != nil {
+			return nil, pgerr.ErrInvalidReq.Msg("invalid ip range `%s`", req.IPRange)
+		}
+		filter.IPRange = null.StringFrom(ipRange)
+	}
+	if req.StageID != "" {
+		stage, err := s.StageService.GetStageByArkId(ctx, req.StageID)
+		if err != nil {
+			return nil, pgerr.ErrInvalidReq.Msg("stage `%s` not found", req.StageID)
+		}
+		filter.StageID = null.IntFrom(int64(stage.StageID))
+	}
+	return filter, nil
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// reportQuotaPeriod is the period report quotas are counted in.
+const reportQuotaPeriod = time.Hour
+
+// reportQuotaLimit returns the quota of conf applied to reports submitted by submitter. Quotas limit how many reports
+// an account could submit per stage per hour. Submitters authenticated with an API key are trusted, while the source
+// and the User-Agent declared by submitters are never taken into account, as anyone could declare them.
+func reportQuotaLimit(conf *config.Config, submitter *ReportSubmitter) int {
+	if submitter.APIKeyID != 0 {
+		return conf.ReportQuotaTrusted
+	}
+	return conf.ReportQuotaUnknown
+}
+
+// pipelineQuota counts reports of task towards the quota of the account, and rejects the task with
+// pgerr.ErrRateLimited if any stage would exceed the quota. Rejected tasks are not counted, and tasks failed to be
+// queued afterwards shall be refunded with refundQuota.
+func (s *Report) pipelineQuota(ctx context.Context, submitter *ReportSubmitter, task *types.ReportTask) error {
+	limit := reportQuotaLimit(s.RuntimeConfig.Current(), submitter)
+	if limit <= 0 {
+		return nil
+	}
+
+	now := time.Now()
+	period := now.Truncate(reportQuotaPeriod)
+	retryAfter := int(period.Add(reportQuotaPeriod).Sub(now).Seconds()) + 1
+
+	keys, counts := reportQuotaKeys(task, period)
+	used, err := s.incrQuota(ctx, keys, counts, 1)
+	if err != nil {
+		// quota is best-effort, and shall never block reports from being submitted
+		log.Warn().Err(err).Int("accountId", task.AccountID).Msg("failed to count report quota")
+		return nil
+	}
+
+	for stageId, count := range used {
+		if count > int64(limit) {
+			if _, err := s.incrQuota(ctx, keys, counts, -1); err != nil {
+				log.Warn().Err(err).Int("accountId", task.AccountID).Msg("failed to revert report quota")
+			}
+			return pgerr.ErrRateLimited.Msg("too many requests: at most %d reports per hour are accepted for stage `%s`", limit, stageId).
+				WithReason(pgerr.ReasonReportQuotaExceeded).
+				WithExtras(pgerr.Extras{
+					"stageId":    stageId,
+					"limit":      limit,
+					"retryAfter": retryAfter,
+				})
+		}
+	}
+	return nil
+}
+
+// refundQuota returns reports of task, counted by pipelineQuota on submission, to the quota of the account, after
+// the task has failed to be queued.
+func (s *Report) refundQuota(ctx context.Context, submitter *ReportSubmitter, task *types.ReportTask) {
+	if reportQuotaLimit(s.RuntimeConfig.Current(), submitter) <= 0 {
+		return
+	}
+
+	submittedAt := time.Now()
+	if task.CreatedAt != 0 {
+		submittedAt = time.UnixMicro(task.CreatedAt)
+	}
+	keys, counts := reportQuotaKeys(task, submittedAt.Truncate(reportQuotaPeriod))
+	if _, err := s.incrQuota(ctx, keys, counts, -1); err != nil {
+		log.Warn().Err(err).Int("accountId", task.AccountID).Msg("failed to refund report quota")
+	}
+}
+
+// reportQuotaKeys returns the quota counter of each stage reported in task within the period starting at period,
+// along with the number of runs reported of each stage.
+func reportQuotaKeys(task *types.ReportTask, period time.Time) (keys map[string]string, counts map[string]int64) {
+	counts = map[string]int64{}
+	for _, report := range task.Reports {
+		runs := report.Times
+		if runs <= 0 {
+			runs = 1
+		}
+		counts[report.StageID] += int64(runs)
+	}
+
+	keys = make(map[string]string, len(counts))
+	for stageId := range counts {
+		keys[stageId] = constant.ReportQuotaKeyPrefix + strconv.Itoa(task.AccountID) + ":" + stageId + ":" + strconv.FormatInt(period.Unix(), 10)
+	}
+	return keys, counts
+}
+
+// incrQuota increments the quota counter of each stage by sign * counts, and returns the counters after increment.
+func (s *Report) incrQuota(ctx context.Context, keys map[string]string, counts map[string]int64, sign int64) (map[string]int64, error) {
+	pipe := s.Redis.TxPipeline()
+	cmds := make(map[string]*redis.IntCmd, len(keys))
+	for stageId, key := range keys {
+		cmds[stageId] = pipe.IncrBy(ctx, key, sign*counts[stageId])
+		pipe.Expire(ctx, key, reportQuotaPeriod)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to increment report quota")
+	}
+
+	used := make(map[string]int64, len(cmds))
+	for stageId, cmd := range cmds {
+		used[stageId] = cmd.Val()
+	}
+	return used, nil
+}
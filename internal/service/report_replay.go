@@ -0,0 +1,274 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+const (
+	// reportReplayConcurrency is the number of tasks replayed concurrently for replays requested by admins.
+	reportReplayConcurrency = 8
+	// reportReplayGuardTTL is how long a task replayed stays claimed, against being replayed again.
+	reportReplayGuardTTL = time.Hour * 24 * 90
+)
+
+type ReportReplayOptions struct {
+	// Concurrency is the number of tasks replayed concurrently.
+	Concurrency int
+	// DryRun verifies tasks without persisting their reports.
+	DryRun bool
+}
+
+// reportReplayItem is an archived report task pending to be replayed.
+type reportReplayItem struct {
+	index int
+	data  []byte
+	// rejectedId is the id of the rejected report task the item is of, or 0 for items not replayed from rejected
+	// report tasks.
+	rejectedId int
+}
+
+// ReplayReportTasks reads archived report tasks from r, one per line, and replays them through the current report
+// pipeline, e.g. to reprocess tasks dead-lettered because of a bug of verifiers after it has been fixed. Each line is
+// a types.ReportTask, or a types.DeadLetterReportTask or rejected report task wrapping one, and the result of every
+// line is written to w as a types.ReportReplayResult JSON line.
+//
+// Reports are persisted as new reports, hence tasks are skipped, unless for DryRun, if their reports have been
+// persisted within the recall window, or they have been replayed within reportReplayGuardTTL.
+func (s *Report) ReplayReportTasks(ctx context.Context, r io.Reader, w io.Writer, opts *ReportReplayOptions) (*types.ReportReplaySummary, error) {
+	var wMu sync.Mutex
+	emit := func(result *types.ReportReplayResult) {
+		wMu.Lock()
+		defer wMu.Unlock()
+		if err := json.NewEncoder(w).Encode(result); err != nil {
+			log.Error().Err(err).Int("index", result.Index).Msg("failed to write report replay result")
+		}
+	}
+
+	items := make(chan reportReplayItem)
+	var (
+		summary *types.ReportReplaySummary
+		done    = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		summary = s.replayReportTasks(ctx, items, opts, emit)
+	}()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), reportImportMaxLineSize)
+	index := 0
+	for ; scanner.Scan(); index++ {
+		// scanner reuses its buffer, so the line has to be copied before handing over to workers
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+
+		select {
+		case items <- reportReplayItem{index: index, data: line}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(items)
+	<-done
+
+	if err := scanner.Err(); err != nil {
+		return summary, errors.Wrapf(err, "failed to read task at line %d", index+1)
+	}
+	return summary, ctx.Err()
+}
+
+// ReplayRequestedReportTasks replays report tasks of req through the current report pipeline. Rejected report tasks
+// replayed are removed from rejected report tasks, unless the replay is a dry run.
+func (s *Report) ReplayRequestedReportTasks(ctx context.Context, req *types.ReportReplayRequest) (*types.ReportReplayResponse, error) {
+	pending := make([]reportReplayItem, 0, len(req.RejectedIDs)+len(req.Tasks))
+	for _, id := range req.RejectedIDs {
+		task, err := s.RejectedReportTaskRepo.GetRejectedReportTaskById(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, reportReplayItem{index: len(pending), data: task.Task, rejectedId: id})
+	}
+	for _, task := range req.Tasks {
+		pending = append(pending, reportReplayItem{index: len(pending), data: task})
+	}
+
+	items := make(chan reportReplayItem, len(pending))
+	for _, item := range pending {
+		items <- item
+	}
+	close(items)
+
+	results := make([]*types.ReportReplayResult, len(pending))
+	summary := s.replayReportTasks(ctx, items, &ReportReplayOptions{
+		Concurrency: reportReplayConcurrency,
+		DryRun:      req.DryRun,
+	}, func(result *types.ReportReplayResult) {
+		// each result is of a distinct index, so results could be set concurrently
+		results[result.Index] = result
+	})
+	return &types.ReportReplayResponse{
+		Summary: summary,
+		Results: results,
+	}, ctx.Err()
+}
+
+// replayReportTasks replays items until items is closed, and hands the result of every item over to emit.
+func (s *Report) replayReportTasks(ctx context.Context, items <-chan reportReplayItem, opts *ReportReplayOptions, emit func(*types.ReportReplayResult)) *types.ReportReplaySummary {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	summary := &types.ReportReplaySummary{}
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range items {
+				atomic.AddInt64(&summary.Total, 1)
+				if ctx.Err() != nil {
+					atomic.AddInt64(&summary.Failed, 1)
+					emit(&types.ReportReplayResult{Index: item.index, RejectedID: item.rejectedId, Error: ctx.Err().Error()})
+					continue
+				}
+
+				result := s.replayReportTask(ctx, item, opts.DryRun)
+				if result.Skipped {
+					atomic.AddInt64(&summary.Skipped, 1)
+				} else if result.Error != "" {
+					atomic.AddInt64(&summary.Failed, 1)
+				} else {
+					atomic.AddInt64(&summary.Replayed, 1)
+					for _, verdict := range result.Verdicts {
+						if verdict.Verifier != "" {
+							atomic.AddInt64(&summary.Rejected, 1)
+						}
+					}
+				}
+				emit(result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	log.Info().
+		Int64("total", summary.Total).
+		Int64("replayed", summary.Replayed).
+		Int64("failed", summary.Failed).
+		Int64("skipped", summary.Skipped).
+		Int64("rejected", summary.Rejected).
+		Bool("dryRun", opts.DryRun).
+		Msg("report tasks replayed")
+	return summary
+}
+
+func (s *Report) replayReportTask(ctx context.Context, item reportReplayItem, dryRun bool) *types.ReportReplayResult {
+	result := &types.ReportReplayResult{
+		Index:      item.index,
+		RejectedID: item.rejectedId,
+	}
+
+	reportTask, err := decodeArchivedReportTask(item.data)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.TaskID = reportTask.TaskID
+
+	if !dryRun {
+		skip, err := s.claimReportReplay(ctx, reportTask.TaskID)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if skip != "" {
+			result.Skipped = true
+			result.Error = skip
+			return result
+		}
+	}
+
+	process := &ReportProcess{Task: reportTask, DryRun: dryRun}
+	if err := s.Pipeline.Process(ctx, process); err != nil {
+		if !dryRun {
+			// the task is released, so that it could be replayed again once the failure is resolved
+			if delErr := s.Redis.Del(ctx, constant.ReportReplayKeyPrefix+reportTask.TaskID).Err(); delErr != nil {
+				log.Warn().Err(delErr).Str("taskId", reportTask.TaskID).Msg("failed to release report replay claim")
+			}
+		}
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Verdicts = make([]*types.ReportReplayVerdict, 0, len(reportTask.Reports))
+	for i := range reportTask.Reports {
+		verdict := &types.ReportReplayVerdict{Reliability: process.Violations.Reliability(i)}
+		if violation, ok := process.Violations[i]; ok {
+			verdict.Verifier = violation.Name
+			verdict.Message = violation.Message
+		}
+		result.Verdicts = append(result.Verdicts, verdict)
+	}
+
+	if item.rejectedId != 0 && !dryRun {
+		if err := s.RejectedReportTaskRepo.DeleteRejectedReportTask(ctx, item.rejectedId); err != nil {
+			log.Error().Err(err).Int("id", item.rejectedId).Msg("failed to remove replayed rejected report task")
+		}
+	}
+	return result
+}
+
+// claimReportReplay claims task taskId for a replay persisting its reports, and returns why the task shall be
+// skipped instead, if it has been persisted or replayed already.
+func (s *Report) claimReportReplay(ctx context.Context, taskId string) (skip string, err error) {
+	// tasks persisted are recallable by their id within the recall window
+	persisted, err := s.Redis.Exists(ctx, taskId).Result()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to check whether the task has been persisted")
+	}
+	if persisted > 0 {
+		return "reports of the task have been persisted already", nil
+	}
+
+	claimed, err := s.Redis.SetNX(ctx, constant.ReportReplayKeyPrefix+taskId, time.Now().Unix(), reportReplayGuardTTL).Result()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to claim the task for replay")
+	}
+	if !claimed {
+		return "the task has been replayed already", nil
+	}
+	return "", nil
+}
+
+// decodeArchivedReportTask decodes a report task archived as either itself, or wrapped as `task` of a dead-lettered
+// or rejected report task.
+func decodeArchivedReportTask(data []byte) (*types.ReportTask, error) {
+	if wrapped := gjson.GetBytes(data, "task"); wrapped.IsObject() {
+		data = []byte(wrapped.Raw)
+	}
+
+	reportTask := &types.ReportTask{}
+	if err := json.Unmarshal(data, reportTask); err != nil {
+		return nil, errors.Wrap(err, "invalid report task")
+	}
+	if reportTask.TaskID == "" || len(reportTask.Reports) == 0 {
+		return nil, errors.New("invalid report task: missing task id or reports")
+	}
+	return reportTask, nil
+}
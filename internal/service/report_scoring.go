@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/breaker"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+)
+
+// reportScoringOrder places scoring right after verification, so that reports are scored as they have been submitted.
+const reportScoringOrder = ReportStageOrderVerify + 50
+
+// reportScoringResponseLimit is the maximum number of bytes of the response of the scoring service read.
+const reportScoringResponseLimit = 64 * 1024
+
+var reportScoringClient = &http.Client{}
+
+// ReportScoring is a stage of the report pipeline scoring reports with the external scoring service configured by
+// config.Config ReportScoringURL, for how likely they are fake. Scoring never fails report tasks: reports are
+// persisted without scores if the service fails, times out, or has failed repeatedly and is skipped.
+type ReportScoring struct {
+	RuntimeConfig *RuntimeConfig
+	Breaker       *breaker.Breaker
+}
+
+func NewReportScoring(runtimeConfig *RuntimeConfig, conf *config.Config) *ReportScoring {
+	s := &ReportScoring{
+		RuntimeConfig: runtimeConfig,
+	}
+	if conf.ReportScoringBreakerThreshold > 0 {
+		s.Breaker = breaker.New(conf.ReportScoringBreakerThreshold, conf.ReportScoringBreakerCooldown, func(open bool) {
+			if open {
+				log.Warn().Msg("scoring reports has failed repeatedly, skipping it until the scoring service recovers")
+			} else {
+				log.Info().Msg("scoring reports has recovered")
+			}
+		})
+	}
+	return s
+}
+
+func (*ReportScoring) Name() string { return "score" }
+func (*ReportScoring) Order() int   { return reportScoringOrder }
+
+func (s *ReportScoring) Process(ctx context.Context, process *ReportProcess, next ReportNext) error {
+	// scores are only persisted, hence dry runs are not scored, leaving the scoring service and its breaker alone
+	if !process.DryRun {
+		s.score(ctx, process.Task)
+	}
+	return next(ctx)
+}
+
+func (s *ReportScoring) score(ctx context.Context, reportTask *types.ReportTask) {
+	conf := s.RuntimeConfig.Current()
+	if conf.ReportScoringURL == "" || len(reportTask.Reports) == 0 {
+		return
+	}
+	if !s.Breaker.Allow() {
+		observability.ReportScoringFailures.WithLabelValues("circuit_open").Inc()
+		return
+	}
+
+	scores, err := s.request(ctx, conf, reportTask)
+	if err != nil {
+		s.Breaker.Failure()
+		reason := "error"
+		if errors.Is(err, context.DeadlineExceeded) {
+			reason = "timeout"
+		}
+		observability.ReportScoringFailures.WithLabelValues(reason).Inc()
+		log.Warn().Err(err).Str("taskId", reportTask.TaskID).Msg("failed to score report task, persisting it unscored")
+		return
+	}
+	invalid := lo.ContainsBy(scores, func(score float64) bool { return !(score >= 0 && score <= 1) })
+	if len(scores) != len(reportTask.Reports) || invalid {
+		// the service is reachable, so the breaker is not tripped by what is likely a bug of either side
+		s.Breaker.Success()
+		observability.ReportScoringFailures.WithLabelValues("invalid").Inc()
+		log.Warn().
+			Str("taskId", reportTask.TaskID).
+			Int("reports", len(reportTask.Reports)).
+			Floats64("scores", scores).
+			Msg("scoring service responded with invalid scores, persisting reports unscored")
+		return
+	}
+	s.Breaker.Success()
+
+	for i, report := range reportTask.Reports {
+		score := scores[i]
+		report.FraudScore = &score
+		observability.ReportFraudScore.WithLabelValues(reportTask.Source).Observe(score)
+	}
+}
+
+func (s *ReportScoring) request(ctx context.Context, conf *config.Config, reportTask *types.ReportTask) ([]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, conf.ReportScoringTimeout)
+	defer cancel()
+
+	body, err := json.Marshal(&types.ReportScoringRequest{
+		TaskID:               reportTask.TaskID,
+		FragmentReportCommon: reportTask.FragmentReportCommon,
+		AccountID:            reportTask.AccountID,
+		Reports:              reportTask.Reports,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, conf.ReportScoringURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PenguinStats-Scoring/1.0")
+
+	resp, err := reportScoringClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, errors.Errorf("scoring service responded with status %d", resp.StatusCode)
+	}
+	var scoring types.ReportScoringResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, reportScoringResponseLimit)).Decode(&scoring); err != nil {
+		return nil, errors.Wrap(err, "invalid response of scoring service")
+	}
+	return scoring.Scores, nil
+}
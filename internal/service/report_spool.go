@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+	"github.com/penguin-statistics/backend-next/internal/pkg/spool"
+)
+
+var errReportSpoolDisabled = errors.New("report spool is disabled")
+
+// spoolReportTask writes task to s.Spool after it has failed to be published with publishErr, so that it is
+// replayed once NATS recovers. Tasks are not spooled if the caller has gone away, as whether the task has been
+// published is unknown to the caller.
+func (s *Report) spoolReportTask(ctx context.Context, subject string, task *types.ReportTask, publishErr error) error {
+	if s.Spool == nil {
+		return errReportSpoolDisabled
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	err = s.Spool.Put(&spool.Message{
+		Subject: subject,
+		MsgID:   task.TaskID,
+		Data:    data,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("taskId", task.TaskID).Msg("failed to spool report task")
+		return err
+	}
+
+	observability.ReportSpooled.WithLabelValues(subject).Inc()
+	observability.ReportSpoolPending.Inc()
+	log.Warn().Err(publishErr).Str("taskId", task.TaskID).Str("subject", subject).
+		Msg("report task could not be published and has been spooled to be replayed later")
+	return nil
+}
+
+// ReplayReportSpool replays report tasks in the spool to NATS every conf.ReportSpoolReplayInterval, for as long as
+// the application runs. Nothing is replayed if the spool is disabled.
+func ReplayReportSpool(s *Report, conf *config.Config, lc fx.Lifecycle) {
+	if s.Spool == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			go func() {
+				defer close(done)
+				ticker := time.NewTicker(conf.ReportSpoolReplayInterval)
+				defer ticker.Stop()
+
+				s.replaySpool(ctx)
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						s.replaySpool(ctx)
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// replaySpool publishes spooled report tasks in the order they were spooled, until the spool is empty or
+// publishing fails, in which case the rest are left for the next replay.
+func (s *Report) replaySpool(ctx context.Context) {
+	replayed, err := s.Spool.Drain(func(msg *spool.Message) error {
+		if err := s.publish(ctx, msg.Subject, msg.Data, msg.MsgID); err != nil {
+			return err
+		}
+		observability.ReportSpoolReplayed.WithLabelValues(msg.Subject).Inc()
+		return nil
+	})
+
+	pending, lenErr := s.Spool.Len()
+	if lenErr == nil {
+		observability.ReportSpoolPending.Set(float64(pending))
+	}
+
+	if replayed > 0 {
+		log.Info().Int("replayed", replayed).Int("pending", pending).Msg("replayed spooled report tasks")
+	}
+	if err != nil && ctx.Err() == nil {
+		log.Warn().Err(err).Int("pending", pending).Msg("failed to replay spooled report tasks, retrying later")
+	}
+}
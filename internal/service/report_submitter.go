@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgid"
+	"github.com/penguin-statistics/backend-next/internal/util"
+)
+
+// ReportSubmitter describes who submits a report, independent of the protocol the report is submitted over.
+type ReportSubmitter struct {
+	// PenguinID is the PenguinID the submitter has authenticated with. Empty when not authenticated.
+	PenguinID string
+	// APIKeyID is the id of the API key the submitter has authenticated with. Zero when not authenticated with
+	// an API key.
+	APIKeyID  int
+	IP        string
+	UserAgent string
+	// RequestID identifies the request, and prefixes the id of the report task.
+	RequestID string
+	// OnAccountCreated is called with the PenguinID of the account created for a submitter not authenticated,
+	// so that it could be handed back to the submitter.
+	OnAccountCreated func(penguinId string)
+}
+
+// fiberReportSubmitter returns the submitter of the HTTP request of ctx.
+func fiberReportSubmitter(ctx *fiber.Ctx) *ReportSubmitter {
+	apiKeyId, _ := ctx.Locals(constant.ContextKeyAPIKeyID).(int)
+	return &ReportSubmitter{
+		PenguinID: pgid.Extract(ctx),
+		APIKeyID:  apiKeyId,
+		IP:        util.ExtractIP(ctx),
+		UserAgent: ctx.Get(fiber.HeaderUserAgent),
+		RequestID: ctx.Locals(constant.ContextKeyRequestID).(string),
+		OnAccountCreated: func(penguinId string) {
+			pgid.Inject(ctx, penguinId)
+		},
+	}
+}
+
+// pipelineAccount returns the account of submitter. An account is created if the submitter is not authenticated,
+// or has authenticated with an invalid PenguinID.
+func (s *Report) pipelineAccount(ctx context.Context, submitter *ReportSubmitter) (accountId int, err error) {
+	if submitter.PenguinID != "" {
+		account, err := s.AccountService.GetAccountByPenguinId(ctx, submitter.PenguinID)
+		if err == nil {
+			return account.AccountID, nil
+		}
+		log.Warn().
+			Err(err).
+			Str("penguinIdProvided", submitter.PenguinID).
+			Msg("failed to get account from request")
+	}
+
+	createdAccount, err := s.AccountService.CreateAccountWithRandomPenguinId(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if submitter.OnAccountCreated != nil {
+		submitter.OnAccountCreated(createdAccount.PenguinID)
+	}
+	return createdAccount.AccountID, nil
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/util/reportverifs"
+)
+
+// setReportTaskStatus records the processing state of task taskId. Failures are only logged, as the status is
+// informational and shall never fail the processing of the task itself.
+func (s *Report) setReportTaskStatus(ctx context.Context, taskId string, state string, mutate func(status *modelv2.ReportTaskStatus)) {
+	status := &modelv2.ReportTaskStatus{
+		TaskID:    taskId,
+		State:     state,
+		UpdatedAt: time.Now().UnixMilli(),
+	}
+	if mutate != nil {
+		mutate(status)
+	}
+
+	statusJSON, err := json.Marshal(status)
+	if err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("failed to marshal report task status")
+		return
+	}
+	if err := s.Redis.Set(ctx, constant.ReportTaskStatusKeyPrefix+taskId, statusJSON, s.RecallWindow).Err(); err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("failed to set report task status")
+	}
+	if err := s.Redis.Publish(ctx, constant.ReportTaskStatusChannelPrefix+taskId, statusJSON).Err(); err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("failed to publish report task status")
+	}
+}
+
+// clearReportTaskStatus removes the status of task taskId, which has been marked as queued but could not be queued.
+func (s *Report) clearReportTaskStatus(ctx context.Context, taskId string) {
+	if err := s.Redis.Del(ctx, constant.ReportTaskStatusKeyPrefix+taskId).Err(); err != nil {
+		log.Error().Err(err).Str("taskId", taskId).Msg("failed to clear report task status")
+	}
+}
+
+func (s *Report) setReportTaskConsumed(ctx context.Context, taskId string, violations reportverifs.Violations) {
+	violations = violations.Disclosable()
+	if len(violations) == 0 {
+		s.setReportTaskStatus(ctx, taskId, constant.ReportTaskStatePersisted, func(status *modelv2.ReportTaskStatus) {
+			status.ReportHash = taskId
+		})
+		return
+	}
+
+	s.setReportTaskStatus(ctx, taskId, constant.ReportTaskStateRejected, func(status *modelv2.ReportTaskStatus) {
+		status.ReportHash = taskId
+		for index, violation := range violations {
+			status.Rejections = append(status.Rejections, &modelv2.ReportTaskRejection{
+				Index:       index,
+				Verifier:    violation.Name,
+				Reliability: violation.Reliability,
+				Message:     violation.Message,
+				Reason:      pgerr.NewVerifierReason(violation.Name, violation.Message),
+			})
+		}
+		sort.Slice(status.Rejections, func(i, j int) bool {
+			return status.Rejections[i].Index < status.Rejections[j].Index
+		})
+	})
+}
+
+// GetReportTaskStatus returns the processing state of task taskId. Tasks whose status has expired but could still
+// be recalled are resolved from the persisted report.
+func (s *Report) GetReportTaskStatus(ctx context.Context, taskId string) (*modelv2.ReportTaskStatus, error) {
+	statusJSON, err := s.Redis.Get(ctx, constant.ReportTaskStatusKeyPrefix+taskId).Bytes()
+	if err == nil {
+		var status modelv2.ReportTaskStatus
+		if err := json.Unmarshal(statusJSON, &status); err != nil {
+			return nil, err
+		}
+		return &status, nil
+	} else if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	reportId, err := s.Redis.Get(ctx, taskId).Int()
+	if errors.Is(err, redis.Nil) {
+		return nil, pgerr.ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	// the task status is only resolved while the report is recallable
+	dropReport, err := s.DropReportRepo.GetDropReportByIdSince(ctx, reportId, time.Now().Add(-s.RecallWindow))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &modelv2.ReportTaskStatus{
+		TaskID:     taskId,
+		State:      constant.ReportTaskStatePersisted,
+		ReportHash: taskId,
+	}
+	if dropReport.CreatedAt != nil {
+		status.UpdatedAt = dropReport.CreatedAt.UnixMilli()
+	}
+	if dropReport.Reliability > 0 && dropReport.Reliability != constant.ViolationReliabilityShadowBan {
+		status.State = constant.ReportTaskStateRejected
+		status.Rejections = []*modelv2.ReportTaskRejection{{
+			Index:       0,
+			Reliability: dropReport.Reliability,
+		}}
+	}
+	return status, nil
+}
+
+// isReportTaskSettled reports whether state is final, after which the status of the task would not change anymore.
+func isReportTaskSettled(state string) bool {
+	return state == constant.ReportTaskStatePersisted ||
+		state == constant.ReportTaskStateRejected ||
+		state == constant.ReportTaskStateFailed
+}
+
+// WatchReportTaskStatus calls onStatus with the current status of task taskId, and then with every update of
+// the status, until the task is settled, ctx is done, or onStatus returns an error.
+func (s *Report) WatchReportTaskStatus(ctx context.Context, taskId string, onStatus func(status *modelv2.ReportTaskStatus) error) error {
+	// subscribe before getting the current status, so that no update in-between would be missed
+	sub := s.Redis.Subscribe(ctx, constant.ReportTaskStatusChannelPrefix+taskId)
+	defer sub.Close()
+	if _, err := sub.Receive(ctx); err != nil {
+		return errors.Wrap(err, "failed to subscribe to report task status")
+	}
+
+	status, err := s.GetReportTaskStatus(ctx, taskId)
+	if err != nil {
+		return err
+	}
+	if err := onStatus(status); err != nil {
+		return err
+	}
+	if isReportTaskSettled(status.State) {
+		return nil
+	}
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			var status modelv2.ReportTaskStatus
+			if err := json.Unmarshal([]byte(msg.Payload), &status); err != nil {
+				return err
+			}
+			if err := onStatus(&status); err != nil {
+				return err
+			}
+			if isReportTaskSettled(status.State) {
+				return nil
+			}
+		}
+	}
+}
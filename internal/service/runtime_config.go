@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// redactedConfigValue is disclosed in place of values of secret fields of config.Config.
+const redactedConfigValue = "[redacted]"
+
+// RuntimeConfig holds the config in effect, which is the config parsed from the environment on start, overridden by
+// overrides from the file at config.Config ConfigOverridesPath and then by those of the `config_overrides` property.
+// Overrides are reloaded without restarting, once the file or the property changes, and only fields of config.Config
+// returned by config.ReloadableFields could be overridden. Tunables shall be read from Current each time they are
+// used, instead of being copied on start.
+type RuntimeConfig struct {
+	PropertyRepo *repo.Property
+	NatsConn     *nats.Conn
+
+	// base is the config parsed from the environment, which overrides apply to
+	base *config.Config
+
+	// current is the *config.Config in effect
+	current atomic.Value
+
+	// mu serializes reloads, and guards the fields below
+	mu          sync.Mutex
+	subscribers []func(conf *config.Config, changed []string)
+	overrides   map[string]string
+}
+
+func NewRuntimeConfig(propertyRepo *repo.Property, natsConn *nats.Conn, conf *config.Config) *RuntimeConfig {
+	s := &RuntimeConfig{
+		PropertyRepo: propertyRepo,
+		NatsConn:     natsConn,
+		base:         conf,
+		overrides:    map[string]string{},
+	}
+	s.current.Store(conf)
+	return s
+}
+
+// Current returns the config in effect. The returned config shall not be modified.
+func (s *RuntimeConfig) Current() *config.Config {
+	return s.current.Load().(*config.Config)
+}
+
+// OnChange registers fn to be called with the new config and the names of changed fields after each reload which
+// changes any field.
+func (s *RuntimeConfig) OnChange(fn func(conf *config.Config, changed []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Reload reads overrides from the file and the property again, and applies them on top of the config parsed from
+// the environment. The config in effect is left as is if overrides could not be read or applied.
+func (s *RuntimeConfig) Reload(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	overrides, err := s.readOverrides(ctx)
+	if err != nil {
+		return err
+	}
+	conf, err := s.base.Override(overrides)
+	if err != nil {
+		return err
+	}
+	s.overrides = overrides
+
+	changed := s.Current().Changed(conf)
+	if len(changed) == 0 {
+		return nil
+	}
+	s.current.Store(conf)
+	log.Info().Strs("changed", changed).Msg("config reloaded")
+	for _, fn := range s.subscribers {
+		fn(conf, changed)
+	}
+	return nil
+}
+
+// readOverrides returns overrides from the file, overridden by those of the property.
+func (s *RuntimeConfig) readOverrides(ctx context.Context) (map[string]string, error) {
+	overrides := map[string]string{}
+
+	if path := s.base.ConfigOverridesPath; path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Wrap(err, "failed to read config overrides file")
+		}
+		if len(data) > 0 {
+			if err := decodeConfigOverrides(data, overrides); err != nil {
+				return nil, errors.Wrap(err, "failed to parse config overrides file")
+			}
+		}
+	}
+
+	property, err := s.PropertyRepo.GetPropertyByKey(ctx, constant.ConfigOverridesPropertyKey)
+	if err != nil && !errors.Is(err, pgerr.ErrNotFound) {
+		return nil, err
+	}
+	if property != nil && property.Value != "" {
+		if err := decodeConfigOverrides([]byte(property.Value), overrides); err != nil {
+			return nil, errors.Wrap(err, "failed to parse config overrides property")
+		}
+	}
+	return overrides, nil
+}
+
+// decodeConfigOverrides decodes a JSON object of overrides into overrides. JSON strings are taken as is, while other
+// values, such as numbers and booleans, are taken as their JSON text.
+func decodeConfigOverrides(data []byte, overrides map[string]string) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	for name, value := range raw {
+		var str string
+		if err := json.Unmarshal(value, &str); err == nil {
+			overrides[name] = str
+		} else {
+			overrides[name] = strings.TrimSpace(string(value))
+		}
+	}
+	return nil
+}
+
+// GetConfigStatus returns the value in effect of each field which could be overridden, and the overrides in effect.
+// Values and overrides of secret fields are redacted. See config.IsSecretField.
+func (s *RuntimeConfig) GetConfigStatus() map[string]any {
+	s.mu.Lock()
+	overrides := make(map[string]string, len(s.overrides))
+	for name, value := range s.overrides {
+		if config.IsSecretField(name) {
+			value = redactedConfigValue
+		}
+		overrides[name] = value
+	}
+	s.mu.Unlock()
+
+	conf := s.Current()
+	values := make(map[string]any)
+	for _, name := range config.ReloadableFields() {
+		values[name] = configFieldValue(conf, name)
+	}
+	return map[string]any{
+		"values":    values,
+		"overrides": overrides,
+	}
+}
+
+// SetOverrides replaces overrides of the property with overrides, after validating them, reloads the config of this
+// instance, and notifies all other instances to reload theirs.
+func (s *RuntimeConfig) SetOverrides(ctx context.Context, overrides map[string]json.RawMessage) error {
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+	decoded := map[string]string{}
+	if err := decodeConfigOverrides(data, decoded); err != nil {
+		return pgerr.ErrInvalidReq.Msg("invalid config overrides: %s", err)
+	}
+	if _, err := s.base.Override(decoded); err != nil {
+		return pgerr.ErrInvalidReq.Msg("%s", err)
+	}
+
+	previous, err := s.PropertyRepo.GetPropertyByKey(ctx, constant.ConfigOverridesPropertyKey)
+	if err != nil && !errors.Is(err, pgerr.ErrNotFound) {
+		return err
+	}
+	if previous != nil && json.Valid([]byte(previous.Value)) {
+		audit.Before(ctx, redactConfigOverrides([]byte(previous.Value)))
+	}
+
+	if err := s.PropertyRepo.UpsertProperty(ctx, constant.ConfigOverridesPropertyKey, string(data)); err != nil {
+		return err
+	}
+	audit.After(ctx, redactConfigOverrides(data))
+	if err := s.Reload(ctx); err != nil {
+		return err
+	}
+	if err := s.NatsConn.Publish(constant.ConfigChangedSubject, nil); err != nil {
+		log.Warn().Err(err).Msg("failed to broadcast config change, other instances reload on their next check")
+	}
+	return nil
+}
+
+// RunConfigReload reloads the config on start, on every config change broadcast by any instance, and every
+// config.Config ConfigReloadInterval, for as long as the application runs.
+func RunConfigReload(s *RuntimeConfig, lc fx.Lifecycle) {
+	var sub *nats.Subscription
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	reload := func() {
+		reloadCtx, cancelReload := context.WithTimeout(ctx, time.Second*10)
+		defer cancelReload()
+		if err := s.Reload(reloadCtx); err != nil {
+			log.Error().Err(err).Msg("failed to reload config, keeping the config in effect")
+		}
+	}
+
+	lc.Append(fx.Hook{
+		OnStart: func(startCtx context.Context) error {
+			if err := s.Reload(startCtx); err != nil {
+				log.Error().Err(err).Msg("failed to load config overrides, starting with the config from the environment")
+			}
+
+			var err error
+			sub, err = s.NatsConn.Subscribe(constant.ConfigChangedSubject, func(_ *nats.Msg) {
+				reload()
+			})
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				defer close(done)
+				if s.base.ConfigReloadInterval <= 0 {
+					<-ctx.Done()
+					return
+				}
+				ticker := time.NewTicker(s.base.ConfigReloadInterval)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						reload()
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(_ context.Context) error {
+			cancel()
+			<-done
+			return sub.Unsubscribe()
+		},
+	})
+}
+
+// redactConfigOverrides returns the JSON object of overrides data with overrides of secret fields redacted.
+func redactConfigOverrides(data []byte) json.RawMessage {
+	var overrides map[string]json.RawMessage
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return json.RawMessage(data)
+	}
+	for name := range overrides {
+		if config.IsSecretField(name) {
+			overrides[name] = json.RawMessage(`"` + redactedConfigValue + `"`)
+		}
+	}
+	redacted, err := json.Marshal(overrides)
+	if err != nil {
+		return json.RawMessage(data)
+	}
+	return redacted
+}
+
+// configFieldValue returns the value of the field of name of conf, as disclosed by GetConfigStatus. Secret values
+// are redacted, except for alert channels, whose names and kinds are disclosed without their URLs and tokens.
+func configFieldValue(conf *config.Config, name string) any {
+	value := config.FieldValue(conf, name)
+	switch v := value.(type) {
+	case time.Duration:
+		return v.String()
+	case config.RateLimitTier:
+		if !v.Enabled() {
+			return "0"
+		}
+		return v.String()
+	case config.AlertChannels:
+		return v.String()
+	case []string:
+		return strings.Join(v, ",")
+	}
+	if config.IsSecretField(name) && !reflect.ValueOf(value).IsZero() {
+		return redactedConfigValue
+	}
+	return value
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/cron"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
+	"github.com/penguin-statistics/backend-next/internal/pkg/leader"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var (
+	ErrJobNotFound = pgerr.ErrNotFound.Msg("job not found")
+	ErrJobRunning  = pgerr.ErrInvalidReq.Msg("job is running")
+)
+
+// Job is a job run by the Scheduler on its schedule.
+type Job struct {
+	Name string
+	// Spec returns the default cron spec of the job in conf, which could be overridden by config.Config
+	// SchedulerJobSpecs. See cron.Parse for the format. The job is rescheduled once the spec changes as the config
+	// is reloaded. Jobs whose specs are empty are never run on schedule, but only when triggered.
+	Spec func(conf *config.Config) string
+	// Timeout bounds a single run of the job. No timeout when 0.
+	Timeout time.Duration
+	// LeaderOnly is whether the job is only run on schedule by the instance elected by leader.Elector. Runs of such
+	// jobs, including those triggered on other instances, hold the lock of the job, so that they never overlap
+	// across instances.
+	LeaderOnly bool
+	// RunOnStart is whether the job is also run as soon as the scheduler starts.
+	RunOnStart bool
+	Run        func(ctx context.Context) error
+}
+
+type scheduledJob struct {
+	*Job
+	// spec and schedule are guarded by Scheduler.mu, as they change once the job is rescheduled. schedule is nil when
+	// spec is empty
+	spec     string
+	schedule cron.Schedule
+	enabled  bool
+	// rescheduled is signalled once the schedule of the job has changed
+	rescheduled chan struct{}
+
+	// running is 1 while the job is running, so that runs of the same job never overlap
+	running int32
+	// lock is the lock held by the current run of a LeaderOnly job, only accessed by the run holding running
+	lock *dlock.Lock
+	// nextRunAt is the UnixNano of when the job is run next on schedule, 0 if not scheduled
+	nextRunAt int64
+}
+
+// Scheduler runs jobs registered to it on their cron schedules, and records every run of them, so that runs could
+// be reviewed and jobs could be triggered manually from the admin API.
+type Scheduler struct {
+	JobRunRepo    *repo.JobRun
+	Elector       *leader.Elector
+	Locker        *dlock.Locker
+	RuntimeConfig *RuntimeConfig
+
+	disabled map[string]bool
+	instance string
+
+	mu   sync.RWMutex
+	jobs map[string]*scheduledJob
+
+	// ctx is the context runs are started in, cancelled once the scheduler stops
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewScheduler(jobRunRepo *repo.JobRun, elector *leader.Elector, locker *dlock.Locker, runtimeConfig *RuntimeConfig, conf *config.Config) (*Scheduler, error) {
+	if _, err := parseJobSpecs(conf.SchedulerJobSpecs); err != nil {
+		return nil, err
+	}
+	disabled := make(map[string]bool, len(conf.SchedulerDisabledJobs))
+	for _, name := range conf.SchedulerDisabledJobs {
+		disabled[strings.TrimSpace(name)] = true
+	}
+	instance, _ := os.Hostname()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		JobRunRepo:    jobRunRepo,
+		Elector:       elector,
+		Locker:        locker,
+		RuntimeConfig: runtimeConfig,
+		disabled:      disabled,
+		instance:      instance,
+		jobs:          make(map[string]*scheduledJob),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+	runtimeConfig.OnChange(func(conf *config.Config, _ []string) {
+		s.reschedule(conf)
+	})
+	return s, nil
+}
+
+// parseJobSpecs parses semicolon separated `job=spec` pairs.
+func parseJobSpecs(s string) (map[string]string, error) {
+	specs := make(map[string]string)
+	for _, pair := range strings.Split(s, ";") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, errors.Errorf("invalid job spec %q: expected `job=spec`", pair)
+		}
+		specs[strings.TrimSpace(name)] = strings.TrimSpace(spec)
+	}
+	return specs, nil
+}
+
+// Register registers job to be run on its schedule once the scheduler starts. Jobs shall be registered before
+// the application starts.
+func (s *Scheduler) Register(job *Job) error {
+	spec, schedule, err := jobSchedule(job, s.RuntimeConfig.Current())
+	if err != nil {
+		return errors.Wrapf(err, "failed to register job %s", job.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.Name]; ok {
+		return errors.Errorf("job %s has been registered already", job.Name)
+	}
+	s.jobs[job.Name] = &scheduledJob{
+		Job:         job,
+		spec:        spec,
+		schedule:    schedule,
+		enabled:     !s.disabled[job.Name],
+		rescheduled: make(chan struct{}, 1),
+	}
+	return nil
+}
+
+// jobSchedule returns the spec of job in conf, and the schedule parsed from it, which is nil if the spec is empty.
+func jobSchedule(job *Job, conf *config.Config) (string, cron.Schedule, error) {
+	specs, err := parseJobSpecs(conf.SchedulerJobSpecs)
+	if err != nil {
+		return "", nil, err
+	}
+	spec, ok := specs[job.Name]
+	if !ok {
+		spec = job.Spec(conf)
+	}
+	if spec == "" {
+		return "", nil, nil
+	}
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return "", nil, err
+	}
+	return spec, schedule, nil
+}
+
+// reschedule reschedules jobs whose specs in conf have changed. Jobs whose new specs are invalid are kept on their
+// current schedules.
+func (s *Scheduler) reschedule(conf *config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range s.jobs {
+		spec, schedule, err := jobSchedule(job.Job, conf)
+		if err != nil {
+			log.Error().Err(err).Str("job", job.Name).Msg("failed to reschedule job, keeping its current schedule")
+			continue
+		}
+		if spec == job.spec {
+			continue
+		}
+		log.Info().Str("job", job.Name).Str("from", job.spec).Str("to", spec).Msg("job rescheduled")
+		job.spec, job.schedule = spec, schedule
+		select {
+		case job.rescheduled <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// RunScheduler starts running jobs registered to s on their schedules once the application starts, and waits for
+// running jobs to finish when it stops.
+func RunScheduler(s *Scheduler, lc fx.Lifecycle) {
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			s.mu.RLock()
+			defer s.mu.RUnlock()
+			for _, job := range s.jobs {
+				if !job.enabled {
+					log.Info().Str("job", job.Name).Msg("job is disabled by configuration")
+					continue
+				}
+				s.wg.Add(1)
+				go s.loop(job)
+			}
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			s.cancel()
+			done := make(chan struct{})
+			go func() {
+				s.wg.Wait()
+				close(done)
+			}()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+func (s *Scheduler) loop(job *scheduledJob) {
+	defer s.wg.Done()
+	defer atomic.StoreInt64(&job.nextRunAt, 0)
+
+	if job.RunOnStart {
+		s.runScheduled(job)
+	}
+	for {
+		s.mu.RLock()
+		spec, schedule := job.spec, job.schedule
+		s.mu.RUnlock()
+		var next time.Time
+		if schedule != nil {
+			next = schedule.Next(time.Now())
+		}
+		if next.IsZero() {
+			if spec != "" {
+				log.Warn().Str("job", job.Name).Str("spec", spec).Msg("job is not run on its schedule until rescheduled")
+			}
+			atomic.StoreInt64(&job.nextRunAt, 0)
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-job.rescheduled:
+				continue
+			}
+		}
+		atomic.StoreInt64(&job.nextRunAt, next.UnixNano())
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-job.rescheduled:
+			timer.Stop()
+			continue
+		case <-timer.C:
+		}
+		s.runScheduled(job)
+	}
+}
+
+func (s *Scheduler) runScheduled(job *scheduledJob) {
+	if job.LeaderOnly && !s.Elector.IsLeader() {
+		log.Debug().Str("job", job.Name).Msg("job skipped as this instance is not the leader")
+		return
+	}
+	run, err := s.start(job, constant.JobTriggerSchedule)
+	if errors.Is(err, ErrJobRunning) {
+		log.Info().Str("job", job.Name).Msg("job skipped as it is still running")
+		return
+	} else if err != nil {
+		log.Error().Err(err).Str("job", job.Name).Msg("job skipped as it could not be started")
+		return
+	}
+	s.execute(job, run)
+}
+
+// Trigger starts running the job of name in background right away, regardless of its schedule, and returns the
+// record of the run. LeaderOnly jobs could be triggered on any instance, but not while running on another.
+func (s *Scheduler) Trigger(name string) (*model.JobRun, error) {
+	s.mu.RLock()
+	job, ok := s.jobs[name]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+
+	run, err := s.start(job, constant.JobTriggerManual)
+	if err != nil {
+		return nil, err
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.execute(job, run)
+	}()
+	return run, nil
+}
+
+// start marks job as running, acquiring the lock of the job if it is LeaderOnly, and records the start of the run.
+// The run is still executed if it could not be recorded.
+func (s *Scheduler) start(job *scheduledJob, trigger string) (*model.JobRun, error) {
+	if !atomic.CompareAndSwapInt32(&job.running, 0, 1) {
+		return nil, ErrJobRunning
+	}
+	if job.LeaderOnly {
+		lock, err := s.Locker.TryLock(s.ctx, constant.LockJob+job.Name)
+		if err != nil {
+			atomic.StoreInt32(&job.running, 0)
+			if errors.Is(err, dlock.ErrNotAcquired) {
+				return nil, ErrJobRunning
+			}
+			return nil, errors.Wrapf(err, "failed to acquire lock of job %s", job.Name)
+		}
+		job.lock = lock
+	}
+
+	now := time.Now()
+	run := &model.JobRun{
+		Job:       job.Name,
+		Trigger:   trigger,
+		Instance:  s.instance,
+		StartedAt: &now,
+		Result:    constant.JobResultRunning,
+	}
+	if err := s.JobRunRepo.CreateJobRun(s.ctx, run); err != nil {
+		log.Warn().Err(err).Str("job", job.Name).Msg("failed to record job run")
+	}
+	return run, nil
+}
+
+// execute runs job, records the result of run, and marks job as not running, releasing the lock of the job if held.
+func (s *Scheduler) execute(job *scheduledJob, run *model.JobRun) {
+	defer atomic.StoreInt32(&job.running, 0)
+
+	logger := log.With().Str("job", job.Name).Str("trigger", run.Trigger).Logger()
+	logger.Info().Msg("job started")
+
+	ctx := s.ctx
+	if lock := job.lock; lock != nil {
+		// the run is cancelled once the lock is lost, as another instance could have started running the job
+		ctx = lock.Context()
+		defer func() {
+			job.lock = nil
+			lock.Unlock()
+		}()
+	}
+	ctx = logger.WithContext(ctx)
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	err := job.Run(ctx)
+	duration := time.Since(*run.StartedAt)
+	run.DurationMs = null.IntFrom(duration.Milliseconds())
+	if err != nil {
+		run.Result = constant.JobResultFailed
+		run.Error = null.StringFrom(err.Error())
+		logger.Error().Err(err).Dur("duration", duration).Msg("job failed")
+	} else {
+		run.Result = constant.JobResultSucceeded
+		logger.Info().Dur("duration", duration).Msg("job finished")
+	}
+
+	if run.RunID == 0 {
+		return
+	}
+	// the result is recorded even when the scheduler is stopping
+	recordCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := s.JobRunRepo.FinishJobRun(recordCtx, run); err != nil {
+		logger.Warn().Err(err).Msg("failed to record job result")
+	}
+}
+
+// GetJobs returns the status of jobs registered to the scheduler of this instance, ordered by name.
+func (s *Scheduler) GetJobs() []*model.JobStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]*model.JobStatus, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		status := &model.JobStatus{
+			Name:       job.Name,
+			Spec:       job.spec,
+			Enabled:    job.enabled,
+			LeaderOnly: job.LeaderOnly,
+			Running:    atomic.LoadInt32(&job.running) == 1,
+		}
+		if nextRunAt := atomic.LoadInt64(&job.nextRunAt); nextRunAt != 0 {
+			t := time.Unix(0, nextRunAt)
+			status.NextRunAt = &t
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// GetJobRuns returns the latest limit runs of the job of name, or of all jobs if name is empty, across instances.
+func (s *Scheduler) GetJobRuns(ctx context.Context, name string, limit int) ([]*model.JobRun, error) {
+	return s.JobRunRepo.GetJobRuns(ctx, name, limit)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+type ShadowBan struct {
+	Redis         *redis.Client
+	ShadowBanRepo *repo.ShadowBan
+}
+
+func NewShadowBan(redisClient *redis.Client, shadowBanRepo *repo.ShadowBan) *ShadowBan {
+	return &ShadowBan{
+		Redis:         redisClient,
+		ShadowBanRepo: shadowBanRepo,
+	}
+}
+
+func (s *ShadowBan) GetActiveShadowBans(ctx context.Context) ([]*model.ShadowBan, error) {
+	return s.ShadowBanRepo.GetActiveShadowBans(ctx)
+}
+
+func (s *ShadowBan) CreateShadowBan(ctx context.Context, req *types.ShadowBanRequest) (*model.ShadowBan, error) {
+	ban := &model.ShadowBan{
+		Reason: req.Reason,
+	}
+	if req.AccountID != 0 {
+		ban.AccountID = null.IntFrom(int64(req.AccountID))
+	}
+	if req.IPRange != "" {
+		ipRange, err := normalizeIPRange(req.IPRange)
+		if err != nil {
+			return nil, pgerr.ErrInvalidReq.Msg("invalid ip range")
+		}
+		ban.IPRange = null.StringFrom(ipRange)
+	}
+	if req.ExpiresAt != 0 {
+		expiresAt := time.UnixMilli(req.ExpiresAt)
+		ban.ExpiresAt = &expiresAt
+	}
+
+	if err := s.ShadowBanRepo.CreateShadowBan(ctx, ban); err != nil {
+		return nil, err
+	}
+	audit.After(ctx, ban)
+	return ban, s.SyncShadowBans(ctx)
+}
+
+func (s *ShadowBan) DeleteShadowBan(ctx context.Context, id int) error {
+	ban, err := s.ShadowBanRepo.DeleteShadowBan(ctx, id)
+	if err != nil {
+		return err
+	}
+	if ban != nil {
+		audit.Before(ctx, ban)
+	}
+	return s.SyncShadowBans(ctx)
+}
+
+// SyncShadowBans publishes active bans to Redis, from where the shadow ban verifier consults them.
+func (s *ShadowBan) SyncShadowBans(ctx context.Context) error {
+	bans, err := s.ShadowBanRepo.GetActiveShadowBans(ctx)
+	if err != nil {
+		return err
+	}
+
+	bansJSON, err := json.Marshal(bans)
+	if err != nil {
+		return err
+	}
+	if err := s.Redis.Set(ctx, constant.ShadowBansKey, bansJSON, 0).Err(); err != nil {
+		return errors.Wrap(err, "failed to sync shadow bans")
+	}
+	return nil
+}
+
+// normalizeIPRange returns ipRange in CIDR notation. A single IP is treated as a range of itself only.
+func normalizeIPRange(ipRange string) (string, error) {
+	if ip := net.ParseIP(ipRange); ip != nil {
+		if ip.To4() != nil {
+			return ip.String() + "/32", nil
+		}
+		return ip.String() + "/128", nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(ipRange)
+	if err != nil {
+		return "", err
+	}
+	return ipNet.String(), nil
+}
@@ -2,36 +2,78 @@ package service
 
 import (
 	"context"
+	"strconv"
 	"time"
 
+	"github.com/samber/lo"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
 	"github.com/penguin-statistics/backend-next/internal/model/cache"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
 	"github.com/penguin-statistics/backend-next/internal/repo"
 )
 
 type SiteStats struct {
-	DropReportRepo *repo.DropReport
+	DropReportRepo      *repo.DropReport
+	CacheVersionService *CacheVersion
+	Locker              *dlock.Locker
 }
 
-func NewSiteStats(dropReportRepo *repo.DropReport) *SiteStats {
+func NewSiteStats(dropReportRepo *repo.DropReport, cacheVersionService *CacheVersion, locker *dlock.Locker) *SiteStats {
 	return &SiteStats{
-		DropReportRepo: dropReportRepo,
+		DropReportRepo:      dropReportRepo,
+		CacheVersionService: cacheVersionService,
+		Locker:              locker,
 	}
 }
 
-// Cache: shimSiteStats#server:{server}, 24hrs
+// Cache: shimSiteStats#server:{server}, 24hrs; reporter stats are cached separately, see getSiteReporterStats
 func (s *SiteStats) GetShimSiteStats(ctx context.Context, server string) (*modelv2.SiteStats, error) {
-	var results modelv2.SiteStats
-	err := cache.ShimSiteStats.Get(server, &results)
-	if err == nil {
-		return &results, nil
+	results, err := s.getShimSiteStats(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+
+	reporterStats, err := s.getSiteReporterStats(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	results.SiteReporterStats = *reporterStats
+	return results, nil
+}
+
+// RefreshShimSiteStats recalculates site stats of server, dropping site stats of server cached by every instance.
+// Refreshes of site stats of the same server never run concurrently across instances.
+func (s *SiteStats) RefreshShimSiteStats(ctx context.Context, server string) (results *modelv2.SiteStats, err error) {
+	err = s.Locker.Do(ctx, constant.LockSiteStats+server, func(ctx context.Context) error {
+		results, err = s.refreshShimSiteStats(ctx, server)
+		return err
+	})
+	return results, err
+}
+
+func (s *SiteStats) refreshShimSiteStats(ctx context.Context, server string) (*modelv2.SiteStats, error) {
+	if err := s.CacheVersionService.Bump(ctx, constant.CacheVersionSiteStats, server); err != nil {
+		return nil, err
+	}
+
+	results, err := s.getShimSiteStats(ctx, server)
+	if err != nil {
+		return nil, err
 	}
 
-	return s.RefreshShimSiteStats(ctx, server)
+	reporterStats, err := s.getSiteReporterStats(ctx, server)
+	if err != nil {
+		return nil, err
+	}
+	results.SiteReporterStats = *reporterStats
+	return results, nil
 }
 
-func (s *SiteStats) RefreshShimSiteStats(ctx context.Context, server string) (*modelv2.SiteStats, error) {
-	valueFunc := func() (*modelv2.SiteStats, error) {
+func (s *SiteStats) getShimSiteStats(ctx context.Context, server string) (*modelv2.SiteStats, error) {
+	valueFunc := func(ctx context.Context) (*modelv2.SiteStats, error) {
 		stageTimes, err := s.DropReportRepo.CalcTotalStageQuantityForShimSiteStats(ctx, server, false)
 		if err != nil {
 			return nil, err
@@ -61,11 +103,84 @@ func (s *SiteStats) RefreshShimSiteStats(ctx context.Context, server string) (*m
 	}
 
 	var results modelv2.SiteStats
-	cache.ShimSiteStats.Delete(server)
-	_, err := cache.ShimSiteStats.MutexGetSet(server, &results, valueFunc, 24*time.Hour)
+	calculated, err := cache.ShimSiteStats.MutexGetSet(ctx, server, &results, valueFunc, 24*time.Hour)
 	if err != nil {
 		return nil, err
+	} else if calculated {
+		cache.LastModifiedTime.Set("[shimSiteStats#server:"+server+"]", time.Now(), 0)
 	}
-	cache.LastModifiedTime.Set("[shimSiteStats#server:"+server+"]", time.Now(), 0)
 	return &results, nil
 }
+
+// getSiteReporterStats returns unique reporters and the source breakdown of submissions of server in the last 24
+// hours and 7 days. They are keyed by the version of site stats of server, so that they are recalculated once by
+// RefreshShimSiteStats and then shared by all instances.
+//
+// Cache: (tiered) siteReporterStats#server|version:{server}|{version}, 10 mins in-process, 1 hr in Redis
+func (s *SiteStats) getSiteReporterStats(ctx context.Context, server string) (*modelv2.SiteReporterStats, error) {
+	version, err := s.CacheVersionService.Version(ctx, constant.CacheVersionSiteStats, server)
+	if err != nil {
+		return nil, err
+	}
+	key := server + constant.CacheSep + strconv.FormatInt(version, 10)
+	stats, err := cache.SiteReporterStats.GetSet(ctx, key, func(ctx context.Context) (modelv2.SiteReporterStats, error) {
+		return s.calcSiteReporterStats(ctx, server)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+func (s *SiteStats) calcSiteReporterStats(ctx context.Context, server string) (modelv2.SiteReporterStats, error) {
+	now := time.Now()
+	since24h, since7d := now.Add(-time.Hour*24), now.Add(-time.Hour*24*7)
+
+	uniqueReporters24h, err := s.DropReportRepo.CalcUniqueReportersForSiteStats(ctx, server, since24h)
+	if err != nil {
+		return modelv2.SiteReporterStats{}, err
+	}
+	uniqueReporters7d, err := s.DropReportRepo.CalcUniqueReportersForSiteStats(ctx, server, since7d)
+	if err != nil {
+		return modelv2.SiteReporterStats{}, err
+	}
+	submissions24h, err := s.DropReportRepo.CalcTotalSourceSubmissionsForSiteStats(ctx, server, since24h)
+	if err != nil {
+		return modelv2.SiteReporterStats{}, err
+	}
+	submissions7d, err := s.DropReportRepo.CalcTotalSourceSubmissionsForSiteStats(ctx, server, since7d)
+	if err != nil {
+		return modelv2.SiteReporterStats{}, err
+	}
+
+	return modelv2.SiteReporterStats{
+		UniqueReporters24H: uniqueReporters24h,
+		UniqueReporters7D:  uniqueReporters7d,
+		SourceBreakdown24H: breakdownSourceSubmissions(submissions24h),
+		SourceBreakdown7D:  breakdownSourceSubmissions(submissions7d),
+	}, nil
+}
+
+// breakdownSourceSubmissions sums up submissions per source name into the source groups of site stats.
+func breakdownSourceSubmissions(results []*model.TotalSourceSubmissionsResult) []*modelv2.SourceSubmissions {
+	groups := map[string]*modelv2.SourceSubmissions{
+		constant.SiteStatsSourceMAA:      {Source: constant.SiteStatsSourceMAA},
+		constant.SiteStatsSourceFrontend: {Source: constant.SiteStatsSourceFrontend},
+		constant.SiteStatsSourceOthers:   {Source: constant.SiteStatsSourceOthers},
+	}
+	for _, result := range results {
+		group := groups[constant.SiteStatsSourceOthers]
+		if result.SourceName == constant.MeoAssistant {
+			group = groups[constant.SiteStatsSourceMAA]
+		} else if lo.Contains(constant.ManualSources, result.SourceName) {
+			group = groups[constant.SiteStatsSourceFrontend]
+		}
+		group.Reports += result.TotalReports
+		group.Times += result.TotalTimes
+	}
+	return []*modelv2.SourceSubmissions{
+		groups[constant.SiteStatsSourceMAA],
+		groups[constant.SiteStatsSourceFrontend],
+		groups[constant.SiteStatsSourceOthers],
+	}
+}
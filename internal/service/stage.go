@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/ahmetb/go-linq/v3"
@@ -17,12 +18,14 @@ import (
 )
 
 type Stage struct {
-	StageRepo *repo.Stage
+	StageRepo       *repo.Stage
+	DropTypeService *DropType
 }
 
-func NewStage(stageRepo *repo.Stage) *Stage {
+func NewStage(stageRepo *repo.Stage, dropTypeService *DropType) *Stage {
 	return &Stage{
-		StageRepo: stageRepo,
+		StageRepo:       stageRepo,
+		DropTypeService: dropTypeService,
 	}
 }
 
@@ -67,6 +70,25 @@ func (s *Stage) GetStageByArkId(ctx context.Context, arkStageId string) (*model.
 	return dbStage, nil
 }
 
+// ResolveArkStageId resolves arkStageId, which might be mistyped in letter case or surrounded by spaces,
+// to the canonical ark stage id. pgerr.ErrNotFound is returned when no stage could be resolved.
+func (s *Stage) ResolveArkStageId(ctx context.Context, arkStageId string) (string, error) {
+	stagesMapByArkId, err := s.GetStagesMapByArkId(ctx)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := stagesMapByArkId[arkStageId]; ok {
+		return arkStageId, nil
+	}
+	normalized := strings.TrimSpace(arkStageId)
+	for canonical := range stagesMapByArkId {
+		if strings.EqualFold(canonical, normalized) {
+			return canonical, nil
+		}
+	}
+	return "", pgerr.ErrNotFound
+}
+
 func (s *Stage) SearchStageByCode(ctx context.Context, code string) (*model.Stage, error) {
 	return s.StageRepo.SearchStageByCode(ctx, code)
 }
@@ -83,8 +105,12 @@ func (s *Stage) GetShimStages(ctx context.Context, server string) ([]*modelv2.St
 	if err != nil {
 		return nil, err
 	}
+	dropTypeAPINames, err := s.DropTypeService.GetAPINames(ctx)
+	if err != nil {
+		return nil, err
+	}
 	for _, i := range stages {
-		s.applyShim(i)
+		s.applyShim(i, dropTypeAPINames)
 	}
 	cache.ShimStages.Set(server, stages, time.Hour)
 	cache.LastModifiedTime.Set("[shimStages#server:"+server+"]", time.Now(), 0)
@@ -103,13 +129,20 @@ func (s *Stage) GetShimStageByArkId(ctx context.Context, arkStageId string, serv
 	if err != nil {
 		return nil, err
 	}
-	s.applyShim(dbStage)
+	dropTypeAPINames, err := s.DropTypeService.GetAPINames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.applyShim(dbStage, dropTypeAPINames)
 	go cache.ShimStageByArkID.Set(arkStageId, *dbStage, time.Hour)
 	return dbStage, nil
 }
 
+// Cache: (tiered) stageExtraProcessType#arkStageId:{arkStageId}, 10 mins in-process, 1 hr in Redis
 func (s *Stage) GetStageExtraProcessTypeByArkId(ctx context.Context, arkStageId string) (null.String, error) {
-	return s.StageRepo.GetStageExtraProcessTypeByArkId(ctx, arkStageId)
+	return cache.StageExtraProcessTypeByArkID.GetSet(ctx, arkStageId, func(ctx context.Context) (null.String, error) {
+		return s.StageRepo.GetStageExtraProcessTypeByArkId(ctx, arkStageId)
+	})
 }
 
 // Cache: (singular) stagesMapById, 1 hr
@@ -162,7 +195,9 @@ func (s *Stage) GetGachaBoxStages(ctx context.Context) ([]*model.Stage, error) {
 	return stages, nil
 }
 
-func (s *Stage) applyShim(stage *modelv2.Stage) {
+// applyShim converts stage to its v2 form, where drop types of drop infos are mapped to their API names by
+// dropTypeAPINames.
+func (s *Stage) applyShim(stage *modelv2.Stage, dropTypeAPINames map[string]string) {
 	codeI18n := gjson.ParseBytes(stage.CodeI18n)
 	stage.Code = codeI18n.Map()["zh"].String()
 
@@ -196,6 +231,8 @@ func (s *Stage) applyShim(stage *modelv2.Stage) {
 		if i.Stage != nil {
 			i.ArkStageID = i.Stage.ArkStageID
 		}
-		i.DropType = constant.DropTypeReversedMap[i.DropType]
+		if apiName, ok := dropTypeAPINames[i.DropType]; ok {
+			i.DropType = apiName
+		}
 	}
 }
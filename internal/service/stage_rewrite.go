@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/pkg/snapshot"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/util/reportutil"
+)
+
+// stageRewriteRulesRefreshInterval is how often stage rewrite rules are reloaded.
+const stageRewriteRulesRefreshInterval = time.Second * 10
+
+// StageRewrite rewrites stageIds of reports affected by known client-side defects, by rules stored in the
+// database and published to Redis. Rules are loaded from the database when they are missing from Redis or Redis
+// fails. See snapshot.LoadPublished.
+type StageRewrite struct {
+	Redis                *redis.Client
+	StageRewriteRuleRepo *repo.StageRewriteRule
+
+	rules *snapshot.Snapshot[reportutil.StageRewriteRules]
+}
+
+func NewStageRewrite(redisClient *redis.Client, stageRewriteRuleRepo *repo.StageRewriteRule) *StageRewrite {
+	s := &StageRewrite{
+		Redis:                redisClient,
+		StageRewriteRuleRepo: stageRewriteRuleRepo,
+	}
+	s.rules = snapshot.New("stage_rewrite_rules", stageRewriteRulesRefreshInterval, s.loadRules)
+	return s
+}
+
+// Apply returns stageId reported by source with version at t, after rewritten by the first matching rule,
+// together with the matched rule. If no rule matches, stageId is returned as-is with a nil rule.
+func (s *StageRewrite) Apply(ctx context.Context, source, version, stageId string, t time.Time) (string, *model.StageRewriteRule) {
+	return s.rules.Get().Apply(source, version, stageId, t)
+}
+
+func (s *StageRewrite) GetStageRewriteRules(ctx context.Context) ([]*model.StageRewriteRule, error) {
+	return s.StageRewriteRuleRepo.GetStageRewriteRules(ctx)
+}
+
+func (s *StageRewrite) CreateStageRewriteRule(ctx context.Context, req *types.StageRewriteRuleRequest) (*model.StageRewriteRule, error) {
+	rule := &model.StageRewriteRule{
+		Name:           req.Name,
+		Source:         null.NewString(req.Source, req.Source != ""),
+		StageIDPattern: req.StageIDPattern,
+		Rewrite:        req.Rewrite,
+	}
+	if req.VersionFrom != "" {
+		rule.VersionFrom = null.StringFrom(prefixVersion(req.VersionFrom))
+	}
+	if req.VersionBefore != "" {
+		rule.VersionBefore = null.StringFrom(prefixVersion(req.VersionBefore))
+	}
+	if req.ActiveFrom != 0 {
+		activeFrom := time.UnixMilli(req.ActiveFrom)
+		rule.ActiveFrom = &activeFrom
+	}
+	if req.ActiveUntil != 0 {
+		activeUntil := time.UnixMilli(req.ActiveUntil)
+		rule.ActiveUntil = &activeUntil
+	}
+	if _, err := reportutil.CompileStageRewriteRule(rule); err != nil {
+		return nil, pgerr.ErrInvalidReq.Msg("invalid stageIdPattern: %s", err)
+	}
+
+	if err := s.StageRewriteRuleRepo.CreateStageRewriteRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, s.SyncStageRewriteRules(ctx)
+}
+
+func (s *StageRewrite) DeleteStageRewriteRule(ctx context.Context, id int) error {
+	if err := s.StageRewriteRuleRepo.DeleteStageRewriteRule(ctx, id); err != nil {
+		return err
+	}
+	return s.SyncStageRewriteRules(ctx)
+}
+
+// SyncStageRewriteRules publishes stage rewrite rules to Redis, from where every instance reloads them.
+func (s *StageRewrite) SyncStageRewriteRules(ctx context.Context) error {
+	rules, err := s.StageRewriteRuleRepo.GetStageRewriteRules(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := snapshot.Publish(ctx, s.Redis, constant.StageRewriteRulesKey, rules); err != nil {
+		return errors.Wrap(err, "failed to sync stage rewrite rules")
+	}
+
+	// take effect on this instance right away
+	s.rules.Set(reportutil.CompileStageRewriteRules(rules))
+	return nil
+}
+
+func (s *StageRewrite) loadRules(ctx context.Context) (reportutil.StageRewriteRules, error) {
+	rules, err := snapshot.LoadPublished(ctx, s.Redis, constant.StageRewriteRulesKey, s.StageRewriteRuleRepo.GetStageRewriteRules)
+	if err != nil {
+		return nil, err
+	}
+	return reportutil.CompileStageRewriteRules(rules), nil
+}
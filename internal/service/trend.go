@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/ahmetb/go-linq/v3"
@@ -14,10 +17,47 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/model/cache"
 	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
 	"github.com/penguin-statistics/backend-next/internal/pkg/async"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dlock"
 	"github.com/penguin-statistics/backend-next/internal/pkg/gameday"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
 	"github.com/penguin-statistics/backend-next/internal/util"
 )
 
+type trendGranularity struct {
+	length time.Duration
+	// count is the maximum number of intervals of saved trends. Counts of granularities shorter than a game day
+	// are multiples of a game day, so that saved trends always start at a daily reset.
+	count int
+	// start and end return the boundaries of the period containing t on server which saved trends are aligned to
+	start func(calendar *Calendar, server string, t time.Time) time.Time
+	end   func(calendar *Calendar, server string, t time.Time) time.Time
+}
+
+// trendGranularities defines the interval length, the number of intervals and the alignment of saved trends of each
+// supported granularity.
+var trendGranularities = map[string]trendGranularity{
+	constant.TrendGranularityHour: {length: time.Hour, count: 72, start: (*Calendar).DayStart, end: (*Calendar).DayEnd},
+	constant.TrendGranularityDay:  {length: gameday.Day, count: constant.DefaultIntervalNum, start: (*Calendar).DayStart, end: (*Calendar).DayEnd},
+	constant.TrendGranularityWeek: {length: gameday.Week, count: 26, start: (*Calendar).WeekStart, end: (*Calendar).WeekEnd},
+}
+
+func getTrendGranularity(granularity string) (trendGranularity, error) {
+	spec, ok := trendGranularities[granularity]
+	if !ok {
+		return trendGranularity{}, pgerr.ErrInvalidReq.Msg("invalid granularity `%s`", granularity)
+	}
+	return spec, nil
+}
+
+// TrendGranularityLength returns the length of each interval of saved trends in granularity.
+func TrendGranularityLength(granularity string) (time.Duration, error) {
+	spec, err := getTrendGranularity(granularity)
+	if err != nil {
+		return 0, err
+	}
+	return spec.length, nil
+}
+
 type Trend struct {
 	TimeRangeService            *TimeRange
 	DropReportService           *DropReport
@@ -27,6 +67,12 @@ type Trend struct {
 	TrendElementService         *TrendElement
 	StageService                *Stage
 	ItemService                 *Item
+	CalendarService             *Calendar
+	CacheVersionService         *CacheVersion
+	Locker                      *dlock.Locker
+
+	// flight coalesces identical customized trend queries running concurrently
+	flight async.Flight[*model.TrendQueryResult]
 }
 
 func NewTrend(
@@ -38,6 +84,9 @@ func NewTrend(
 	trendElementService *TrendElement,
 	stageService *Stage,
 	itemService *Item,
+	calendarService *Calendar,
+	cacheVersionService *CacheVersion,
+	locker *dlock.Locker,
 ) *Trend {
 	return &Trend{
 		TimeRangeService:            timeRangeService,
@@ -48,17 +97,28 @@ func NewTrend(
 		TrendElementService:         trendElementService,
 		StageService:                stageService,
 		ItemService:                 itemService,
+		CalendarService:             calendarService,
+		CacheVersionService:         cacheVersionService,
+		Locker:                      locker,
 	}
 }
 
-// Cache: shimSavedTrendResults#server:{server}, 24hrs, records last modified time
-func (s *Trend) GetShimSavedTrendResults(ctx context.Context, server string) (*modelv2.TrendQueryResult, error) {
-	valueFunc := func() (*modelv2.TrendQueryResult, error) {
-		queryResult, err := s.getSavedTrendResults(ctx, server, constant.SourceCategoryAll)
+// GetShimSavedTrendResults returns saved trends of server in granularity, which is one of
+// constant.TrendGranularities.
+//
+// Cache: shimSavedTrendResults#server|granularity:{server}|{granularity}, 24hrs, records last modified time
+func (s *Trend) GetShimSavedTrendResults(ctx context.Context, server string, granularity string) (*modelv2.TrendQueryResult, error) {
+	spec, err := getTrendGranularity(granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	valueFunc := func(ctx context.Context) (*modelv2.TrendQueryResult, error) {
+		queryResult, err := s.getSavedTrendResults(ctx, server, constant.SourceCategoryAll, granularity)
 		if err != nil {
 			return nil, err
 		}
-		slowShimResult, err := s.applyShimForSavedTrendQuery(ctx, server, queryResult)
+		slowShimResult, err := s.applyShimForSavedTrendQuery(ctx, server, spec, queryResult)
 		if err != nil {
 			return nil, err
 		}
@@ -66,15 +126,21 @@ func (s *Trend) GetShimSavedTrendResults(ctx context.Context, server string) (*m
 	}
 
 	var shimResult modelv2.TrendQueryResult
-	calculated, err := cache.ShimSavedTrendResults.MutexGetSet(server, &shimResult, valueFunc, 24*time.Hour)
+	key := ShimSavedTrendResultsKey(server, granularity)
+	calculated, err := cache.ShimSavedTrendResults.MutexGetSet(ctx, key, &shimResult, valueFunc, 24*time.Hour)
 	if err != nil {
 		return nil, err
 	} else if calculated {
-		cache.LastModifiedTime.Set("[shimSavedTrendResults#server:"+server+"]", time.Now(), 0)
+		cache.LastModifiedTime.Set("[shimSavedTrendResults#server|granularity:"+key+"]", time.Now(), 0)
 	}
 	return &shimResult, nil
 }
 
+// ShimSavedTrendResultsKey returns the key of saved trends of server in granularity in cache.ShimSavedTrendResults.
+func ShimSavedTrendResultsKey(server string, granularity string) string {
+	return server + constant.CacheSep + granularity
+}
+
 func (s *Trend) GetShimCustomizedTrendResults(
 	ctx context.Context, server string, startTime *time.Time, intervalLength time.Duration, intervalNum int, stageIds []int, itemIds []int, accountId null.Int,
 ) (*modelv2.TrendQueryResult, error) {
@@ -85,17 +151,37 @@ func (s *Trend) GetShimCustomizedTrendResults(
 	return s.applyShimForCustomizedTrendQuery(ctx, trendQueryResult, startTime)
 }
 
+// QueryTrend calculates trends for customized conditions, coalescing identical queries running concurrently.
 func (s *Trend) QueryTrend(
 	ctx context.Context, server string, startTime *time.Time, intervalLength time.Duration, intervalNum int, stageIdFilter []int, itemIdFilter []int, accountId null.Int, sourceCategory string,
 ) (*model.TrendQueryResult, error) {
-	trendElements, err := s.calcTrend(ctx, server, startTime, intervalLength, intervalNum, stageIdFilter, itemIdFilter, accountId, sourceCategory)
+	key := strings.Join([]string{
+		server, strconv.FormatInt(startTime.UnixMilli(), 10), intervalLength.String(), strconv.Itoa(intervalNum),
+		fmt.Sprint(stageIdFilter), fmt.Sprint(itemIdFilter), accountIdKey(accountId), sourceCategory,
+	}, constant.CacheSep)
+	return s.flight.Do(key, func() (*model.TrendQueryResult, error) {
+		trendElements, err := s.calcTrend(ctx, server, startTime, intervalLength, intervalNum, stageIdFilter, itemIdFilter, accountId, sourceCategory)
+		if err != nil {
+			return nil, err
+		}
+		return s.convertTrendElementsToTrendQueryResult(trendElements)
+	})
+}
+
+// RefreshTrendElements recalculates saved trends of server in granularity. Refreshes of saved trends of the same
+// server and granularity never run concurrently across instances.
+func (s *Trend) RefreshTrendElements(ctx context.Context, server string, granularity string, sourceCategories []string) error {
+	return s.Locker.Do(ctx, constant.LockTrend+server+constant.CacheSep+granularity, func(ctx context.Context) error {
+		return s.refreshTrendElements(ctx, server, granularity, sourceCategories)
+	})
+}
+
+func (s *Trend) refreshTrendElements(ctx context.Context, server string, granularity string, sourceCategories []string) error {
+	spec, err := getTrendGranularity(granularity)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	return s.convertTrendElementsToTrendQueryResult(trendElements)
-}
 
-func (s *Trend) RefreshTrendElements(ctx context.Context, server string, sourceCategories []string) error {
 	maxAccumulableTimeRanges, err := s.TimeRangeService.GetMaxAccumulableTimeRangesByServer(ctx, server)
 	if err != nil {
 		return err
@@ -134,23 +220,17 @@ func (s *Trend) RefreshTrendElements(ctx context.Context, server string, sourceC
 				endTime = time.Now()
 			}
 
-			startTime = gameday.StartTime(server, startTime)
-			if !gameday.IsStartTime(server, endTime) {
-				endTime = gameday.EndTime(server, endTime)
+			startTime = spec.start(s.CalendarService, server, startTime)
+			if !spec.start(s.CalendarService, server, endTime).Equal(endTime) {
+				endTime = spec.end(s.CalendarService, server, endTime)
 			} else {
-				loc := constant.LocMap[server]
-				endTime = endTime.In(loc)
-			}
-
-			diff := int(endTime.Sub(startTime).Hours())
-			intervalNum := diff / 24
-			if diff%24 != 0 { // shouldn't happen actually
-				intervalNum++
+				endTime = endTime.In(s.CalendarService.Location(server))
 			}
 
-			if intervalNum > constant.DefaultIntervalNum {
-				intervalNum = constant.DefaultIntervalNum
-				startTime = endTime.Add(time.Hour * time.Duration((-1)*24*intervalNum))
+			intervalNum := int((endTime.Sub(startTime) + spec.length - 1) / spec.length)
+			if intervalNum > spec.count {
+				intervalNum = spec.count
+				startTime = endTime.Add(-spec.length * time.Duration(intervalNum))
 			}
 
 			calcq = append(calcq, map[string]any{
@@ -170,10 +250,13 @@ func (s *Trend) RefreshTrendElements(ctx context.Context, server string, sourceC
 
 		currentBatch := make([]*model.TrendElement, 0)
 		for _, sourceCategory := range sourceCategories {
-			results, err := s.calcTrend(ctx, server, &startTime, time.Hour*24, intervalNum, []int{stageId}, itemIds, null.NewInt(0, false), sourceCategory)
+			results, err := s.calcTrend(ctx, server, &startTime, spec.length, intervalNum, []int{stageId}, itemIds, null.NewInt(0, false), sourceCategory)
 			if err != nil {
 				return nil, err
 			}
+			for _, result := range results {
+				result.Granularity = granularity
+			}
 			currentBatch = append(currentBatch, results...)
 		}
 		return currentBatch, nil
@@ -182,14 +265,14 @@ func (s *Trend) RefreshTrendElements(ctx context.Context, server string, sourceC
 		return errors.Wrap(err, "failed to refresh trend elements")
 	}
 
-	if err := s.TrendElementService.BatchSaveElements(ctx, elements, server); err != nil {
+	if err := s.TrendElementService.BatchSaveElements(ctx, elements, server, granularity); err != nil {
 		return err
 	}
-	return cache.ShimSavedTrendResults.Delete(server)
+	return s.CacheVersionService.Bump(ctx, constant.CacheVersionTrend, ShimSavedTrendResultsKey(server, granularity))
 }
 
-func (s *Trend) getSavedTrendResults(ctx context.Context, server string, sourceCategory string) (*model.TrendQueryResult, error) {
-	trendElements, err := s.TrendElementService.GetElementsByServerAndSourceCategory(ctx, server, sourceCategory)
+func (s *Trend) getSavedTrendResults(ctx context.Context, server string, sourceCategory string, granularity string) (*model.TrendQueryResult, error) {
+	trendElements, err := s.TrendElementService.GetElementsByServerAndSourceCategory(ctx, server, sourceCategory, granularity)
 	if err != nil {
 		return nil, err
 	}
@@ -432,9 +515,9 @@ func (s *Trend) applyShimForCustomizedTrendQuery(ctx context.Context, queryResul
 	return results, nil
 }
 
-func (s *Trend) applyShimForSavedTrendQuery(ctx context.Context, server string, queryResult *model.TrendQueryResult) (*modelv2.TrendQueryResult, error) {
-	shimMinStartTime := gameday.EndTime(server, time.Now()).Add(-1 * constant.DefaultIntervalNum * 24 * time.Hour)
-	currentGameDayEndTime := gameday.EndTime(server, time.Now())
+func (s *Trend) applyShimForSavedTrendQuery(ctx context.Context, server string, spec trendGranularity, queryResult *model.TrendQueryResult) (*modelv2.TrendQueryResult, error) {
+	currentPeriodEndTime := spec.end(s.CalendarService, server, time.Now())
+	shimMinStartTime := currentPeriodEndTime.Add(-spec.length * time.Duration(spec.count))
 
 	itemsMapById, err := s.ItemService.GetItemsMapById(ctx)
 	if err != nil {
@@ -458,10 +541,10 @@ func (s *Trend) applyShimForSavedTrendQuery(ctx context.Context, server string,
 
 		// calc stage trend start time
 		for _, itemTrend := range stageTrend.Results {
-			itemStartTime := itemTrend.StartTime.Add((-1) * time.Duration(itemTrend.MinGroupID) * 24 * time.Hour)
-			// if the end time of this item is before the global trend start time (now - 60d), then we don't show it
-			dayNum := len(itemTrend.Quantity)
-			itemEndTime := itemStartTime.Add(time.Duration(dayNum) * 24 * time.Hour)
+			itemStartTime := itemTrend.StartTime.Add(-spec.length * time.Duration(itemTrend.MinGroupID))
+			// if the end time of this item is before the global trend start time (end of the current period - count intervals), then we don't show it
+			intervalNum := len(itemTrend.Quantity)
+			itemEndTime := itemStartTime.Add(spec.length * time.Duration(intervalNum))
 			if itemEndTime.Before(shimMinStartTime) {
 				continue
 			}
@@ -478,22 +561,22 @@ func (s *Trend) applyShimForSavedTrendQuery(ctx context.Context, server string,
 		for _, itemTrend := range stageTrend.Results {
 			item := itemsMapById[itemTrend.ItemID]
 
-			itemStartTime := itemTrend.StartTime.Add((-1) * time.Duration(itemTrend.MinGroupID) * 24 * time.Hour)
-			dayNum := len(itemTrend.Quantity)
-			itemEndTime := itemStartTime.Add(time.Duration(dayNum) * 24 * time.Hour)
+			itemStartTime := itemTrend.StartTime.Add(-spec.length * time.Duration(itemTrend.MinGroupID))
+			intervalNum := len(itemTrend.Quantity)
+			itemEndTime := itemStartTime.Add(spec.length * time.Duration(intervalNum))
 			if itemEndTime.Before(shimMinStartTime) {
 				continue
 			}
 
 			// add 0s to the head of quantity and times arrays according to itemStartTime
-			headZeroNum := int(itemStartTime.Sub(*stageTrendStartTime).Hours() / 24)
+			headZeroNum := int(itemStartTime.Sub(*stageTrendStartTime) / spec.length)
 			if headZeroNum > 0 {
 				itemTrend.Quantity = append(make([]int, headZeroNum), itemTrend.Quantity...)
 				itemTrend.Times = append(make([]int, headZeroNum), itemTrend.Times...)
 			}
 
 			// add 0s to the tail of quantity and times arrays according to itemEndTime
-			tailZeroNum := int(currentGameDayEndTime.Sub(itemEndTime).Hours() / 24)
+			tailZeroNum := int(currentPeriodEndTime.Sub(itemEndTime) / spec.length)
 			if tailZeroNum > 0 {
 				itemTrend.Quantity = append(itemTrend.Quantity, make([]int, tailZeroNum)...)
 				itemTrend.Times = append(itemTrend.Times, make([]int, tailZeroNum)...)
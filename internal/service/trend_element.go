@@ -17,14 +17,14 @@ func NewTrendElement(trendElementRepo *repo.TrendElement) *TrendElement {
 	}
 }
 
-func (s *TrendElement) BatchSaveElements(ctx context.Context, elements []*model.TrendElement, server string) error {
-	return s.TrendElementRepo.BatchSaveElements(ctx, elements, server)
+func (s *TrendElement) BatchSaveElements(ctx context.Context, elements []*model.TrendElement, server string, granularity string) error {
+	return s.TrendElementRepo.BatchSaveElements(ctx, elements, server, granularity)
 }
 
 func (s *TrendElement) DeleteByServer(ctx context.Context, server string) error {
 	return s.TrendElementRepo.DeleteByServer(ctx, server)
 }
 
-func (s *TrendElement) GetElementsByServerAndSourceCategory(ctx context.Context, server string, sourceCategory string) ([]*model.TrendElement, error) {
-	return s.TrendElementRepo.GetElementsByServerAndSourceCategory(ctx, server, sourceCategory)
+func (s *TrendElement) GetElementsByServerAndSourceCategory(ctx context.Context, server string, sourceCategory string, granularity string) ([]*model.TrendElement, error) {
+	return s.TrendElementRepo.GetElementsByServerAndSourceCategory(ctx, server, sourceCategory, granularity)
 }
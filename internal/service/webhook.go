@@ -0,0 +1,333 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dchest/uniuri"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/audit"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// webhookPublishTimeout bounds creating deliveries of an event, which is done in background of whatever sends it.
+const webhookPublishTimeout = time.Second * 10
+
+// webhookResponseBodyLimit is the maximum number of bytes of the response body of a failed delivery kept as its
+// error.
+const webhookResponseBodyLimit = 256
+
+var webhookClient = &http.Client{
+	// webhooks shall respond to deliveries themselves, instead of redirecting them elsewhere
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// Webhook delivers events to registered webhooks. Events are persisted as deliveries, one per webhook subscribing
+// to them, which are POSTed signed with the secret of each webhook, and retried with exponential backoff until
+// they succeed or config.Config WebhookMaxAttempts is reached.
+type Webhook struct {
+	WebhookRepo   *repo.Webhook
+	RuntimeConfig *RuntimeConfig
+}
+
+func NewWebhook(webhookRepo *repo.Webhook, runtimeConfig *RuntimeConfig) *Webhook {
+	return &Webhook{
+		WebhookRepo:   webhookRepo,
+		RuntimeConfig: runtimeConfig,
+	}
+}
+
+func (s *Webhook) GetWebhooks(ctx context.Context) ([]*model.Webhook, error) {
+	return s.WebhookRepo.GetWebhooks(ctx)
+}
+
+// CreateWebhook creates a webhook, and returns it along with its secret, which is not retrievable afterwards.
+func (s *Webhook) CreateWebhook(ctx context.Context, req *types.WebhookRequest) (*model.CreatedWebhook, error) {
+	webhook := &model.Webhook{
+		URL:         req.URL,
+		Secret:      constant.WebhookSecretPrefix + uniuri.NewLen(32),
+		Events:      lo.Uniq(req.Events),
+		Description: req.Description,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+	}
+	if err := s.WebhookRepo.CreateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	audit.After(ctx, webhook)
+	return &model.CreatedWebhook{Webhook: webhook, Secret: webhook.Secret}, nil
+}
+
+// UpdateWebhook replaces the URL, events, description and whether it is enabled of the webhook of id. The secret is
+// kept as is.
+func (s *Webhook) UpdateWebhook(ctx context.Context, id int, req *types.WebhookRequest) (*model.Webhook, error) {
+	webhook, err := s.WebhookRepo.GetWebhookById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	audit.Before(ctx, webhook)
+
+	webhook.URL = req.URL
+	webhook.Events = lo.Uniq(req.Events)
+	webhook.Description = req.Description
+	if req.Enabled != nil {
+		webhook.Enabled = *req.Enabled
+	}
+	if err := s.WebhookRepo.UpdateWebhook(ctx, webhook); err != nil {
+		return nil, err
+	}
+	audit.After(ctx, webhook)
+	return webhook, nil
+}
+
+func (s *Webhook) DeleteWebhook(ctx context.Context, id int) error {
+	webhook, err := s.WebhookRepo.GetWebhookById(ctx, id)
+	if err != nil {
+		return err
+	}
+	audit.Before(ctx, webhook)
+
+	return s.WebhookRepo.DeleteWebhook(ctx, id)
+}
+
+// GetWebhookDeliveries returns the latest limit deliveries to the webhook of id, latest first.
+func (s *Webhook) GetWebhookDeliveries(ctx context.Context, id int, limit int) ([]*model.WebhookDelivery, error) {
+	if _, err := s.WebhookRepo.GetWebhookById(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.WebhookRepo.GetDeliveriesByWebhookId(ctx, id, limit)
+}
+
+// Ping delivers a ping event to the webhook of id right away, regardless of events it subscribes to, and returns
+// the delivery after it has been attempted.
+func (s *Webhook) Ping(ctx context.Context, id int) (*model.WebhookDelivery, error) {
+	webhook, err := s.WebhookRepo.GetWebhookById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// the delivery is created as claimed, as it is attempted right here
+	deliveries, err := s.createDeliveries(ctx, []*model.Webhook{webhook}, constant.WebhookEventPing, map[string]any{
+		"webhookId": webhook.WebhookID,
+	}, time.Now().Add(constant.WebhookDeliveryLease))
+	if err != nil {
+		return nil, err
+	}
+	delivery := deliveries[0]
+	s.attempt(ctx, webhook, delivery)
+	return delivery, nil
+}
+
+// Redeliver makes the delivery of id pending again with its attempts reset, e.g. after it has been given up while
+// the webhook was down, and attempts it in background.
+func (s *Webhook) Redeliver(ctx context.Context, id int) (*model.WebhookDelivery, error) {
+	delivery, err := s.WebhookRepo.ResetDelivery(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	go s.deliverInBackground()
+	return delivery, nil
+}
+
+// Publish sends event with data to all enabled webhooks subscribing to it, in background, so that senders are
+// neither blocked nor failed by webhooks. data is marshalled right away, so it could be modified afterwards.
+func (s *Webhook) Publish(event string, data any) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		log.Error().Err(err).Str("event", event).Msg("failed to marshal webhook event")
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookPublishTimeout)
+		defer cancel()
+
+		webhooks, err := s.WebhookRepo.GetEnabledWebhooksByEvent(ctx, event)
+		if err != nil {
+			log.Error().Err(err).Str("event", event).Msg("failed to get webhooks of event")
+			return
+		}
+		if len(webhooks) == 0 {
+			return
+		}
+		if _, err := s.createDeliveries(ctx, webhooks, event, json.RawMessage(dataJSON), time.Now()); err != nil {
+			log.Error().Err(err).Str("event", event).Msg("failed to create webhook deliveries")
+			return
+		}
+		s.deliverInBackground()
+	}()
+}
+
+// createDeliveries creates pending deliveries of event with data to webhooks, which share the same event id, to be
+// attempted at nextAttemptAt.
+func (s *Webhook) createDeliveries(ctx context.Context, webhooks []*model.Webhook, event string, data any, nextAttemptAt time.Time) ([]*model.WebhookDelivery, error) {
+	payload, err := json.Marshal(&model.WebhookPayload{
+		EventID:   uniuri.NewLen(16),
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]*model.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			WebhookID:     webhook.WebhookID,
+			Event:         event,
+			Payload:       payload,
+			Status:        constant.WebhookDeliveryStatusPending,
+			NextAttemptAt: &nextAttemptAt,
+		})
+	}
+	if err := s.WebhookRepo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// deliverInBackground attempts deliveries due right away, instead of waiting for the next run of the worker.
+func (s *Webhook) deliverInBackground() {
+	ctx, cancel := context.WithTimeout(context.Background(), constant.WebhookDeliveryLease)
+	defer cancel()
+	if _, err := s.DeliverDue(ctx); err != nil {
+		log.Error().Err(err).Msg("failed to deliver webhook events")
+	}
+}
+
+// DeliverDue attempts pending deliveries due by now, and returns the number of deliveries claimed. Deliveries
+// are claimed before attempted, so that it could be run by all instances at the same time.
+func (s *Webhook) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.WebhookRepo.ClaimDueDeliveries(ctx, constant.WebhookDeliveryBatchSize, constant.WebhookDeliveryLease)
+	if err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	webhooks, err := s.WebhookRepo.GetWebhooks(ctx)
+	if err != nil {
+		return 0, err
+	}
+	webhooksById := lo.KeyBy(webhooks, func(webhook *model.Webhook) int { return webhook.WebhookID })
+
+	for _, delivery := range deliveries {
+		if ctx.Err() != nil {
+			// deliveries left are attempted again once their claims have expired
+			break
+		}
+		webhook, ok := webhooksById[delivery.WebhookID]
+		if !ok {
+			// the webhook has been deleted since the delivery was claimed
+			continue
+		}
+		s.attempt(ctx, webhook, delivery)
+	}
+	return len(deliveries), nil
+}
+
+// attempt POSTs delivery to webhook once, and records the result. Failed deliveries are retried with exponential
+// backoff, and given up once config.Config WebhookMaxAttempts is reached.
+func (s *Webhook) attempt(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) {
+	conf := s.RuntimeConfig.Current()
+	delivery.Attempts++
+
+	statusCode, err := s.post(ctx, webhook, delivery, conf.WebhookTimeout)
+	delivery.ResponseStatus = null.NewInt(int64(statusCode), statusCode != 0)
+	now := time.Now()
+	if err == nil {
+		delivery.Status = constant.WebhookDeliveryStatusSucceeded
+		delivery.LastError = null.String{}
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = null.StringFrom(err.Error())
+		if delivery.Attempts >= conf.WebhookMaxAttempts {
+			delivery.Status = constant.WebhookDeliveryStatusFailed
+			log.Warn().Err(err).Int("deliveryId", delivery.DeliveryID).Int("webhookId", webhook.WebhookID).
+				Int("attempts", delivery.Attempts).Msg("webhook delivery given up")
+		} else {
+			nextAttemptAt := now.Add(webhookRetryDelay(delivery.Attempts))
+			delivery.NextAttemptAt = &nextAttemptAt
+		}
+	}
+
+	// the result is recorded even if ctx has been cancelled, so that the delivery is not attempted again needlessly
+	finishCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	if err := s.WebhookRepo.FinishDeliveryAttempt(finishCtx, delivery); err != nil {
+		log.Error().Err(err).Int("deliveryId", delivery.DeliveryID).Msg("failed to record webhook delivery attempt")
+	}
+}
+
+// post POSTs the payload of delivery to webhook, and returns the status code it has responded with. Deliveries
+// are considered succeeded only if responded with a 2xx status code.
+func (s *Webhook) post(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "PenguinStats-Webhook/1.0")
+	req.Header.Set(constant.WebhookEventHeader, delivery.Event)
+	req.Header.Set(constant.WebhookDeliveryHeader, strconv.Itoa(delivery.DeliveryID))
+	req.Header.Set(constant.WebhookSignatureHeader, signWebhookPayload(webhook.Secret, time.Now(), delivery.Payload))
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodyLimit))
+		return resp.StatusCode, errors.Errorf("webhook responded with status %d: %s", resp.StatusCode, body)
+	}
+	// the body is drained so that the connection could be reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return resp.StatusCode, nil
+}
+
+// signWebhookPayload returns the signature of payload sent at t, in the form described by
+// constant.WebhookSignatureHeader.
+func signWebhookPayload(secret string, t time.Time, payload []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// webhookRetryDelay returns the delay before retrying a delivery which has failed attempts times.
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := constant.WebhookRetryBaseDelay
+	for i := 1; i < attempts && delay < constant.WebhookRetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > constant.WebhookRetryMaxDelay {
+		delay = constant.WebhookRetryMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSignWebhookPayload(t *testing.T) {
+	sentAt := time.Unix(1654041600, 0)
+	tests := []struct {
+		secret   string
+		payload  string
+		expected string
+	}{
+		{"whsec_test", `{"event":"matrix.refreshed"}`, "t=1654041600,v1=151092559485ed37b918f0f1869cf4ff6d109573cf241a2a8b7ec49c6ae6255a"},
+		{"whsec_test", ``, "t=1654041600,v1=10deae9f7467ceae75ba764aa50d3d5c4d7670edc32bbb25137bd4256b76da95"},
+	}
+	for _, test := range tests {
+		if signature := signWebhookPayload(test.secret, sentAt, []byte(test.payload)); signature != test.expected {
+			t.Errorf("signWebhookPayload(%q, %q): expected %s, got %s", test.secret, test.payload, test.expected, signature)
+		}
+	}
+
+	if signWebhookPayload("other", sentAt, nil) == tests[1].expected {
+		t.Errorf("Expected signatures to differ by secrets, got the same")
+	}
+}
+
+func TestWebhookRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		expected time.Duration
+	}{
+		{0, time.Second * 30},
+		{1, time.Second * 30},
+		{2, time.Minute},
+		{3, time.Minute * 2},
+		{10, time.Second * 30 * 512},
+		{11, time.Hour * 6},
+		{100, time.Hour * 6},
+	}
+	for _, test := range tests {
+		if delay := webhookRetryDelay(test.attempts); delay != test.expected {
+			t.Errorf("webhookRetryDelay(%d): expected %v, got %v", test.attempts, test.expected, delay)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package util
 
 import (
 	"github.com/ahmetb/go-linq/v3"
+	"github.com/tidwall/gjson"
 
 	"github.com/penguin-statistics/backend-next/internal/model"
 )
@@ -31,3 +32,34 @@ func GetStageIdsFromDropInfos(dropInfos []*model.DropInfo) []int {
 	linq.From(dropInfos).SelectT(func(dropInfo *model.DropInfo) int { return dropInfo.StageID }).Distinct().ToSlice(&stageIds)
 	return stageIds
 }
+
+// GetFixedQuantityFromDropInfo returns the quantity of the item guaranteed to drop per clear, if the drop info
+// is marked as fixed-rate by having `fixedQuantity` in its extras. Such drops are deterministic and are therefore
+// not estimated statistically.
+func GetFixedQuantityFromDropInfo(dropInfo *model.DropInfo) (int, bool) {
+	if !dropInfo.ItemID.Valid || len(dropInfo.Extras) == 0 {
+		return 0, false
+	}
+	fixedQuantity := gjson.GetBytes(dropInfo.Extras, "fixedQuantity")
+	if fixedQuantity.Type != gjson.Number {
+		return 0, false
+	}
+	return int(fixedQuantity.Int()), true
+}
+
+// GetFixedQuantityMapFromDropInfos returns a map whose key is stage id, and value is a sub map whose key is
+// item id and value is the fixed quantity per clear, for all fixed-rate drop infos.
+func GetFixedQuantityMapFromDropInfos(dropInfos []*model.DropInfo) map[int]map[int]int {
+	fixedQuantityMap := make(map[int]map[int]int)
+	for _, dropInfo := range dropInfos {
+		fixedQuantity, ok := GetFixedQuantityFromDropInfo(dropInfo)
+		if !ok {
+			continue
+		}
+		if _, ok := fixedQuantityMap[dropInfo.StageID]; !ok {
+			fixedQuantityMap[dropInfo.StageID] = make(map[int]int)
+		}
+		fixedQuantityMap[dropInfo.StageID][int(dropInfo.ItemID.Int64)] = fixedQuantity
+	}
+	return fixedQuantityMap
+}
@@ -0,0 +1,101 @@
+package fieldset
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Parse returns fields requested by the `fields` query of ctx, which is a comma separated list of fields records in
+// the response shall be limited to, in the manner of sparse fieldsets of JSON:API. nil is returned if the query is
+// omitted or empty.
+func Parse(ctx *fiber.Ctx) []string {
+	var fields []string
+	for _, field := range strings.Split(ctx.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// Key returns the form of fields to be part of cache keys and ETags of responses limited to fields.
+func Key(fields []string) string {
+	return strings.Join(fields, ",")
+}
+
+// Send responds v in JSON with its records limited to fields, or as is if fields is nil. Records are elements of v
+// if v is an array, or v itself if v is an object and key is empty. Otherwise, records are elements of the member
+// key of v, while other members of v are left as is.
+func Send(ctx *fiber.Ctx, v any, key string, fields []string) error {
+	if fields == nil {
+		return ctx.JSON(v)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	data, err = Select(data, key, fields)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return ctx.Send(data)
+}
+
+// Select limits records of data, which is in JSON, to fields. Records are located by key the same way as Send.
+func Select(data []byte, key string, fields []string) ([]byte, error) {
+	if key != "" {
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err != nil || members == nil {
+			return data, err
+		}
+		records, ok := members[key]
+		if !ok {
+			return data, nil
+		}
+		records, err := Select(records, "", fields)
+		if err != nil {
+			return nil, err
+		}
+		members[key] = records
+		return json.Marshal(members)
+	}
+
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var records []map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &records); err != nil {
+			return nil, err
+		}
+		for i, record := range records {
+			records[i] = pick(record, fields)
+		}
+		return json.Marshal(records)
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		var record map[string]json.RawMessage
+		if err := json.Unmarshal(trimmed, &record); err != nil {
+			return nil, err
+		}
+		return json.Marshal(pick(record, fields))
+	default:
+		return data, nil
+	}
+}
+
+func pick(record map[string]json.RawMessage, fields []string) map[string]json.RawMessage {
+	if record == nil {
+		return nil
+	}
+	picked := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := record[field]; ok {
+			picked[field] = value
+		}
+	}
+	return picked
+}
@@ -0,0 +1,40 @@
+package fieldset
+
+import (
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		data     string
+		key      string
+		fields   []string
+		expected string
+	}{
+		{`[{"a":1,"b":2,"c":3},{"a":4}]`, "", []string{"a", "c"}, `[{"a":1,"c":3},{"a":4}]`},
+		{`{"a":1,"b":2}`, "", []string{"b", "missing"}, `{"b":2}`},
+		{`{"matrix":[{"a":1,"b":2}],"total":1}`, "matrix", []string{"a"}, `{"matrix":[{"a":1}],"total":1}`},
+		{`{"total":1}`, "matrix", []string{"a"}, `{"total":1}`},
+		{`[null]`, "", []string{"a"}, `[null]`},
+		{`"scalar"`, "", []string{"a"}, `"scalar"`},
+	}
+	for _, test := range tests {
+		selected, err := Select([]byte(test.data), test.key, test.fields)
+		if err != nil {
+			t.Errorf("Select(%s, %q, %v): expected no error, got %v", test.data, test.key, test.fields, err)
+			continue
+		}
+		if string(selected) != test.expected {
+			t.Errorf("Select(%s, %q, %v): expected %s, got %s", test.data, test.key, test.fields, test.expected, selected)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	if key := Key([]string{"stageId", "itemId"}); key != "stageId,itemId" {
+		t.Errorf("Expected key 'stageId,itemId', got '%s'", key)
+	}
+	if key := Key(nil); key != "" {
+		t.Errorf("Expected empty key, got '%s'", key)
+	}
+}
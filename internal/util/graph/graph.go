@@ -0,0 +1,160 @@
+// Package graph implements the read-only GraphQL schema of result data, resolved with the same cached shim results
+// backing the v2 API.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"sync"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/samber/lo"
+	"gopkg.in/guregu/null.v3"
+
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+//go:embed schema.graphql
+var schemaString string
+
+const (
+	// maxDepth limits the nesting of queries, e.g. stage { zone { stages { ... } } }
+	maxDepth = 8
+	// maxParallelism limits the number of fields resolved concurrently per request
+	maxParallelism = 16
+)
+
+// Services are the services resolvers fetch result data from.
+type Services struct {
+	ItemService          *service.Item
+	StageService         *service.Stage
+	ZoneService          *service.Zone
+	DropMatrixService    *service.DropMatrix
+	PatternMatrixService *service.PatternMatrix
+	TrendService         *service.Trend
+}
+
+// NewSchema parses the schema and binds it to the root resolver over services.
+func NewSchema(services Services) (*graphql.Schema, error) {
+	return graphql.ParseSchema(schemaString, &Query{services: services},
+		graphql.MaxDepth(maxDepth),
+		graphql.MaxParallelism(maxParallelism),
+	)
+}
+
+// lookup resolves items, stages and zones referenced by other objects within a single request, by their ark ids.
+// Each list is fetched at most once per request, from the cached shim lists.
+type lookup struct {
+	services Services
+
+	mu     sync.Mutex
+	items  map[string]*modelv2.Item
+	zones  map[string]*modelv2.Zone
+	stages map[string]map[string]*modelv2.Stage
+}
+
+func newLookup(services Services) *lookup {
+	return &lookup{
+		services: services,
+		stages:   map[string]map[string]*modelv2.Stage{},
+	}
+}
+
+func (l *lookup) item(ctx context.Context, arkItemId string) (*Item, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.items == nil {
+		items, err := l.services.ItemService.GetShimItems(ctx)
+		if err != nil {
+			return nil, err
+		}
+		l.items = lo.KeyBy(items, func(item *modelv2.Item) string { return item.ArkItemID })
+	}
+	item, ok := l.items[arkItemId]
+	if !ok {
+		return nil, nil
+	}
+	return &Item{item}, nil
+}
+
+func (l *lookup) stage(ctx context.Context, arkStageId string, server string) (*Stage, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stages, ok := l.stages[server]
+	if !ok {
+		shimStages, err := l.services.StageService.GetShimStages(ctx, server)
+		if err != nil {
+			return nil, err
+		}
+		stages = lo.KeyBy(shimStages, func(stage *modelv2.Stage) string { return stage.ArkStageID })
+		l.stages[server] = stages
+	}
+	stage, ok := stages[arkStageId]
+	if !ok {
+		return nil, nil
+	}
+	return &Stage{stage: stage, server: server, lookup: l}, nil
+}
+
+func (l *lookup) zone(ctx context.Context, arkZoneId string) (*Zone, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.zones == nil {
+		zones, err := l.services.ZoneService.GetShimZones(ctx)
+		if err != nil {
+			return nil, err
+		}
+		l.zones = lo.KeyBy(zones, func(zone *modelv2.Zone) string { return zone.ArkZoneID })
+	}
+	zone, ok := l.zones[arkZoneId]
+	if !ok {
+		return nil, nil
+	}
+	return &Zone{zone: zone, lookup: l}, nil
+}
+
+// idFilter returns a predicate of whether an id is within ids, which accepts every id when ids is nil.
+func idFilter(ids *[]string) func(id string) bool {
+	if ids == nil {
+		return func(string) bool { return true }
+	}
+	set := make(map[string]struct{}, len(*ids))
+	for _, id := range *ids {
+		set[id] = struct{}{}
+	}
+	return func(id string) bool {
+		_, ok := set[id]
+		return ok
+	}
+}
+
+func nullString(s null.String) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+func nullInt(i null.Int) *int32 {
+	if !i.Valid {
+		return nil
+	}
+	v := int32(i.Int64)
+	return &v
+}
+
+func nullTimestamp(i null.Int) *Timestamp {
+	if !i.Valid {
+		return nil
+	}
+	t := Timestamp(i.Int64)
+	return &t
+}
+
+func int32s(s []int) []int32 {
+	return lo.Map(s, func(v int, _ int) int32 { return int32(v) })
+}
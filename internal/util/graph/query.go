@@ -0,0 +1,130 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	"github.com/samber/lo"
+	"gopkg.in/guregu/null.v3"
+)
+
+// Query is the root resolver.
+type Query struct {
+	services Services
+}
+
+func (q *Query) Items(ctx context.Context) ([]*Item, error) {
+	items, err := q.services.ItemService.GetShimItems(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*Item, 0, len(items))
+	for _, item := range items {
+		resolvers = append(resolvers, &Item{item})
+	}
+	return resolvers, nil
+}
+
+func (q *Query) Item(ctx context.Context, args struct{ ItemId string }) (*Item, error) {
+	return newLookup(q.services).item(ctx, args.ItemId)
+}
+
+func (q *Query) Stages(ctx context.Context, args struct{ Server string }) ([]*Stage, error) {
+	stages, err := q.services.StageService.GetShimStages(ctx, args.Server)
+	if err != nil {
+		return nil, err
+	}
+	l := newLookup(q.services)
+	resolvers := make([]*Stage, 0, len(stages))
+	for _, stage := range stages {
+		resolvers = append(resolvers, &Stage{stage: stage, server: args.Server, lookup: l})
+	}
+	return resolvers, nil
+}
+
+func (q *Query) Stage(ctx context.Context, args struct {
+	StageId string
+	Server  string
+}) (*Stage, error) {
+	return newLookup(q.services).stage(ctx, args.StageId, args.Server)
+}
+
+func (q *Query) Zones(ctx context.Context) ([]*Zone, error) {
+	zones, err := q.services.ZoneService.GetShimZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	l := newLookup(q.services)
+	resolvers := make([]*Zone, 0, len(zones))
+	for _, zone := range zones {
+		resolvers = append(resolvers, &Zone{zone: zone, lookup: l})
+	}
+	return resolvers, nil
+}
+
+func (q *Query) Zone(ctx context.Context, args struct{ ZoneId string }) (*Zone, error) {
+	return newLookup(q.services).zone(ctx, args.ZoneId)
+}
+
+func (q *Query) Matrix(ctx context.Context, args struct {
+	Server          string
+	StageIds        *[]string
+	ItemIds         *[]string
+	ShowClosedZones bool
+}) ([]*MatrixCell, error) {
+	// filters are applied here rather than by the service, so that the cached matrix is always used
+	result, err := q.services.DropMatrixService.GetShimMaxAccumulableDropMatrixResults(ctx, args.Server, args.ShowClosedZones, "", "", null.NewInt(0, false))
+	if err != nil {
+		return nil, err
+	}
+	stageFilter, itemFilter := idFilter(args.StageIds), idFilter(args.ItemIds)
+	l := newLookup(q.services)
+	resolvers := make([]*MatrixCell, 0, len(result.Matrix))
+	for _, element := range result.Matrix {
+		if stageFilter(element.StageID) && itemFilter(element.ItemID) {
+			resolvers = append(resolvers, &MatrixCell{element: element, server: args.Server, lookup: l})
+		}
+	}
+	return resolvers, nil
+}
+
+func (q *Query) Patterns(ctx context.Context, args struct {
+	Server   string
+	StageIds *[]string
+}) ([]*PatternCell, error) {
+	result, err := q.services.PatternMatrixService.GetShimLatestPatternMatrixResults(ctx, args.Server, null.NewInt(0, false))
+	if err != nil {
+		return nil, err
+	}
+	stageFilter := idFilter(args.StageIds)
+	l := newLookup(q.services)
+	resolvers := make([]*PatternCell, 0, len(result.PatternMatrix))
+	for _, element := range result.PatternMatrix {
+		if stageFilter(element.StageID) {
+			resolvers = append(resolvers, &PatternCell{element: element, server: args.Server, lookup: l})
+		}
+	}
+	return resolvers, nil
+}
+
+func (q *Query) Trends(ctx context.Context, args struct {
+	Server      string
+	Granularity string
+	StageIds    *[]string
+}) ([]*StageTrend, error) {
+	result, err := q.services.TrendService.GetShimSavedTrendResults(ctx, args.Server, args.Granularity)
+	if err != nil {
+		return nil, err
+	}
+	stageFilter := idFilter(args.StageIds)
+	l := newLookup(q.services)
+	stageIds := lo.Keys(result.Trend)
+	sort.Strings(stageIds)
+	resolvers := make([]*StageTrend, 0, len(stageIds))
+	for _, stageId := range stageIds {
+		if stageFilter(stageId) {
+			resolvers = append(resolvers, &StageTrend{stageId: stageId, trend: result.Trend[stageId], server: args.Server, lookup: l})
+		}
+	}
+	return resolvers, nil
+}
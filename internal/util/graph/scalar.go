@@ -0,0 +1,65 @@
+package graph
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+)
+
+// JSON is the JSON scalar, passing raw JSON values, e.g. localized names, through as is.
+type JSON json.RawMessage
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input any) error {
+	b, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	*j = b
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+// newJSON returns the JSON scalar of raw, or nil if raw is empty.
+func newJSON(raw json.RawMessage) *JSON {
+	if len(raw) == 0 {
+		return nil
+	}
+	j := JSON(raw)
+	return &j
+}
+
+// Timestamp is the Timestamp scalar, in milliseconds since the Unix epoch. Timestamps do not fit in the 32-bit
+// Int scalar of GraphQL, hence the separate scalar.
+type Timestamp int64
+
+func (Timestamp) ImplementsGraphQLType(name string) bool {
+	return name == "Timestamp"
+}
+
+func (t *Timestamp) UnmarshalGraphQL(input any) error {
+	switch v := input.(type) {
+	case int32:
+		*t = Timestamp(v)
+	case int64:
+		*t = Timestamp(v)
+	case float64:
+		*t = Timestamp(v)
+	default:
+		return errors.New("wrong type for Timestamp")
+	}
+	return nil
+}
+
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(t), 10), nil
+}
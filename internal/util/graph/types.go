@@ -0,0 +1,212 @@
+package graph
+
+import (
+	"context"
+	"sort"
+
+	"github.com/samber/lo"
+
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+)
+
+type Item struct {
+	item *modelv2.Item
+}
+
+func (r *Item) ItemId() string   { return r.item.ArkItemID }
+func (r *Item) Name() string     { return r.item.Name }
+func (r *Item) NameI18n() *JSON  { return newJSON(r.item.NameI18n) }
+func (r *Item) Existence() *JSON { return newJSON(r.item.Existence) }
+func (r *Item) ItemType() string { return r.item.ItemType }
+func (r *Item) SortId() int32    { return int32(r.item.SortID) }
+func (r *Item) Rarity() int32    { return int32(r.item.Rarity) }
+func (r *Item) GroupId() *string { return nullString(r.item.Group) }
+func (r *Item) Alias() *JSON     { return newJSON(r.item.AliasMap) }
+func (r *Item) Pron() *JSON      { return newJSON(r.item.PronMap) }
+func (r *Item) SpriteCoord() *[]int32 {
+	if r.item.SpriteCoord == nil {
+		return nil
+	}
+	coord := int32s(*r.item.SpriteCoord)
+	return &coord
+}
+
+type Stage struct {
+	stage  *modelv2.Stage
+	server string
+	lookup *lookup
+}
+
+func (r *Stage) StageId() string      { return r.stage.ArkStageID }
+func (r *Stage) ZoneId() string       { return r.stage.ArkZoneID }
+func (r *Stage) StageType() string    { return r.stage.StageType }
+func (r *Stage) Code() string         { return r.stage.Code }
+func (r *Stage) CodeI18n() *JSON      { return newJSON(r.stage.CodeI18n) }
+func (r *Stage) ApCost() *int32       { return nullInt(r.stage.Sanity) }
+func (r *Stage) Existence() *JSON     { return newJSON(r.stage.Existence) }
+func (r *Stage) MinClearTime() *int32 { return nullInt(r.stage.MinClearTime) }
+
+func (r *Stage) Zone(ctx context.Context) (*Zone, error) {
+	return r.lookup.zone(ctx, r.stage.ArkZoneID)
+}
+
+func (r *Stage) DropInfos() []*DropInfo {
+	resolvers := make([]*DropInfo, 0, len(r.stage.DropInfos))
+	for _, dropInfo := range r.stage.DropInfos {
+		resolvers = append(resolvers, &DropInfo{dropInfo: dropInfo, lookup: r.lookup})
+	}
+	return resolvers
+}
+
+type DropInfo struct {
+	dropInfo *modelv2.DropInfo
+	lookup   *lookup
+}
+
+func (r *DropInfo) DropType() string { return r.dropInfo.DropType }
+func (r *DropInfo) Bounds() *JSON    { return newJSON(r.dropInfo.Bounds) }
+
+func (r *DropInfo) ItemId() *string {
+	if r.dropInfo.ArkItemID == "" {
+		return nil
+	}
+	return &r.dropInfo.ArkItemID
+}
+
+func (r *DropInfo) Item(ctx context.Context) (*Item, error) {
+	if r.dropInfo.ArkItemID == "" {
+		return nil, nil
+	}
+	return r.lookup.item(ctx, r.dropInfo.ArkItemID)
+}
+
+type Zone struct {
+	zone   *modelv2.Zone
+	lookup *lookup
+}
+
+func (r *Zone) ZoneId() string      { return r.zone.ArkZoneID }
+func (r *Zone) ZoneIndex() int32    { return int32(r.zone.Index) }
+func (r *Zone) Type() string        { return r.zone.Category }
+func (r *Zone) SubType() *string    { return nullString(r.zone.Type) }
+func (r *Zone) ZoneName() string    { return r.zone.ZoneName }
+func (r *Zone) ZoneNameI18n() *JSON { return newJSON(r.zone.ZoneNameI18n) }
+func (r *Zone) Existence() *JSON    { return newJSON(r.zone.Existence) }
+func (r *Zone) Background() *string { return nullString(r.zone.Background) }
+func (r *Zone) StageIds() []string  { return r.zone.StageIds }
+
+// Stages returns stages of the zone open in server. Stages not in server are left out.
+func (r *Zone) Stages(ctx context.Context, args struct{ Server string }) ([]*Stage, error) {
+	resolvers := make([]*Stage, 0, len(r.zone.StageIds))
+	for _, stageId := range r.zone.StageIds {
+		stage, err := r.lookup.stage(ctx, stageId, args.Server)
+		if err != nil {
+			return nil, err
+		}
+		if stage != nil {
+			resolvers = append(resolvers, stage)
+		}
+	}
+	return resolvers, nil
+}
+
+type MatrixCell struct {
+	element *modelv2.OneDropMatrixElement
+	server  string
+	lookup  *lookup
+}
+
+func (r *MatrixCell) StageId() string  { return r.element.StageID }
+func (r *MatrixCell) ItemId() string   { return r.element.ItemID }
+func (r *MatrixCell) Times() int32     { return int32(r.element.Times) }
+func (r *MatrixCell) Quantity() int32  { return int32(r.element.Quantity) }
+func (r *MatrixCell) StdDev() float64  { return r.element.StdDev }
+func (r *MatrixCell) Start() Timestamp { return Timestamp(r.element.StartTime) }
+func (r *MatrixCell) End() *Timestamp  { return nullTimestamp(r.element.EndTime) }
+
+func (r *MatrixCell) Stage(ctx context.Context) (*Stage, error) {
+	return r.lookup.stage(ctx, r.element.StageID, r.server)
+}
+
+func (r *MatrixCell) Item(ctx context.Context) (*Item, error) {
+	return r.lookup.item(ctx, r.element.ItemID)
+}
+
+type PatternCell struct {
+	element *modelv2.OnePatternMatrixElement
+	server  string
+	lookup  *lookup
+}
+
+func (r *PatternCell) StageId() string  { return r.element.StageID }
+func (r *PatternCell) Times() int32     { return int32(r.element.Times) }
+func (r *PatternCell) Quantity() int32  { return int32(r.element.Quantity) }
+func (r *PatternCell) Lower() float64   { return r.element.Lower }
+func (r *PatternCell) Upper() float64   { return r.element.Upper }
+func (r *PatternCell) Start() Timestamp { return Timestamp(r.element.StartTime) }
+func (r *PatternCell) End() *Timestamp  { return nullTimestamp(r.element.EndTime) }
+
+func (r *PatternCell) Stage(ctx context.Context) (*Stage, error) {
+	return r.lookup.stage(ctx, r.element.StageID, r.server)
+}
+
+func (r *PatternCell) Drops() []*PatternDrop {
+	if r.element.Pattern == nil {
+		return []*PatternDrop{}
+	}
+	resolvers := make([]*PatternDrop, 0, len(r.element.Pattern.Drops))
+	for _, drop := range r.element.Pattern.Drops {
+		resolvers = append(resolvers, &PatternDrop{drop: drop, lookup: r.lookup})
+	}
+	return resolvers
+}
+
+type PatternDrop struct {
+	drop   *modelv2.OneDrop
+	lookup *lookup
+}
+
+func (r *PatternDrop) ItemId() string  { return r.drop.ItemID }
+func (r *PatternDrop) Quantity() int32 { return int32(r.drop.Quantity) }
+
+func (r *PatternDrop) Item(ctx context.Context) (*Item, error) {
+	return r.lookup.item(ctx, r.drop.ItemID)
+}
+
+type StageTrend struct {
+	stageId string
+	trend   *modelv2.StageTrend
+	server  string
+	lookup  *lookup
+}
+
+func (r *StageTrend) StageId() string      { return r.stageId }
+func (r *StageTrend) StartTime() Timestamp { return Timestamp(r.trend.StartTime) }
+
+func (r *StageTrend) Stage(ctx context.Context) (*Stage, error) {
+	return r.lookup.stage(ctx, r.stageId, r.server)
+}
+
+func (r *StageTrend) Results() []*ItemTrend {
+	itemIds := lo.Keys(r.trend.Results)
+	sort.Strings(itemIds)
+	resolvers := make([]*ItemTrend, 0, len(itemIds))
+	for _, itemId := range itemIds {
+		resolvers = append(resolvers, &ItemTrend{itemId: itemId, trend: r.trend.Results[itemId], lookup: r.lookup})
+	}
+	return resolvers
+}
+
+type ItemTrend struct {
+	itemId string
+	trend  *modelv2.OneItemTrend
+	lookup *lookup
+}
+
+func (r *ItemTrend) ItemId() string    { return r.itemId }
+func (r *ItemTrend) Times() []int32    { return int32s(r.trend.Times) }
+func (r *ItemTrend) Quantity() []int32 { return int32s(r.trend.Quantity) }
+
+func (r *ItemTrend) Item(ctx context.Context) (*Item, error) {
+	return r.lookup.item(ctx, r.itemId)
+}
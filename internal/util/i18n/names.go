@@ -0,0 +1,27 @@
+package i18n
+
+import (
+	"encoding/json"
+)
+
+// NameLanguages are languages names of game data are in, as keys of their i18n JSON objects, e.g. `name_i18n`.
+var NameLanguages = []string{"zh", "en", "ja", "ko"}
+
+// FilterNames returns names, an i18n JSON object, with only the name in lang kept, or an empty object if there is no
+// name in lang. names is returned as is if it is not an object.
+func FilterNames(names json.RawMessage, lang string) json.RawMessage {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(names, &m); err != nil || m == nil {
+		return names
+	}
+
+	filtered := make(map[string]json.RawMessage, 1)
+	if name, ok := m[lang]; ok {
+		filtered[lang] = name
+	}
+	b, err := json.Marshal(filtered)
+	if err != nil {
+		return names
+	}
+	return b
+}
@@ -1,7 +1,10 @@
 package i18n
 
 import (
+	"strings"
+
 	ut "github.com/go-playground/universal-translator"
+	"golang.org/x/text/language"
 
 	"github.com/go-playground/locales/en"
 	"github.com/go-playground/locales/ja"
@@ -10,3 +13,22 @@ import (
 )
 
 var UT = ut.New(en.New(), zh_Hant_TW.New(), zh.New(), ja.New())
+
+// TranslatorFor returns the translator for the most preferred language of acceptLanguage, an Accept-Language header,
+// or the fallback translator if none of the languages is supported.
+func TranslatorFor(acceptLanguage string) ut.Translator {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil {
+		return UT.GetFallback()
+	}
+
+	var langs []string
+
+	for _, tag := range tags {
+		langs = append(langs, strings.ReplaceAll(strings.ToLower(tag.String()), "-", "_"))
+	}
+
+	trans, _ := UT.FindTranslator(langs...)
+
+	return trans
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"net"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,3 +16,22 @@ func ExtractIP(ctx *fiber.Ctx) string {
 		return ""
 	}
 }
+
+// IsTrustedProxy returns whether ip is one of trustedProxies, which are IPs or CIDRs, in the same manner as
+// fiber.Config.TrustedProxies.
+func IsTrustedProxy(ip string, trustedProxies []string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if strings.Contains(proxy, "/") {
+			if _, network, err := net.ParseCIDR(proxy); err == nil && network.Contains(parsed) {
+				return true
+			}
+		} else if proxyIP := net.ParseIP(proxy); proxyIP != nil && proxyIP.Equal(parsed) {
+			return true
+		}
+	}
+	return false
+}
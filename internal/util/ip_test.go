@@ -0,0 +1,24 @@
+package util
+
+import "testing"
+
+func TestIsTrustedProxy(t *testing.T) {
+	trustedProxies := []string{"::1", "127.0.0.1", "10.0.0.0/8"}
+	tests := []struct {
+		ip       string
+		expected bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"127.0.0.2", false},
+		{"11.0.0.1", false},
+		{"not an ip", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := IsTrustedProxy(test.ip, trustedProxies); got != test.expected {
+			t.Errorf("IsTrustedProxy(%q): expected %v, got %v", test.ip, test.expected, got)
+		}
+	}
+}
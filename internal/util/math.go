@@ -55,6 +55,19 @@ func CombineTwoBundles(bundle1, bundle2 *StatsBundle) *StatsBundle {
 	}
 }
 
+// CalcWilsonScoreInterval returns the Wilson score interval of the proportion of successes out of n trials,
+// at the confidence level of the z-score z. The interval is [0, 1] when n is 0.
+func CalcWilsonScoreInterval(successes int, n int, z float64) (lower float64, upper float64) {
+	if n <= 0 {
+		return 0, 1
+	}
+	p := float64(successes) / float64(n)
+	z2n := z * z / float64(n)
+	center := (p + z2n/2) / (1 + z2n)
+	halfWidth := z / (1 + z2n) * math.Sqrt(p*(1-p)/float64(n)+z2n/float64(n)/4)
+	return math.Max(0, center-halfWidth), math.Min(1, center+halfWidth)
+}
+
 func RoundFloat64(f float64, n int) float64 {
 	pow10_n := math.Pow10(n)
 	return math.Round(f*pow10_n) / pow10_n
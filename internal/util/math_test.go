@@ -0,0 +1,29 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCalcWilsonScoreInterval(t *testing.T) {
+	tests := []struct {
+		successes int
+		n         int
+		z         float64
+		lower     float64
+		upper     float64
+	}{
+		{0, 0, 1.96, 0, 1},
+		{50, 100, 1.96, 0.403830, 0.596170},
+		{0, 10, 1.96, 0, 0.277540},
+		{10, 10, 1.96, 0.722460, 1},
+		{1, 3, 1, 0.135643, 0.614357},
+	}
+	for _, test := range tests {
+		lower, upper := CalcWilsonScoreInterval(test.successes, test.n, test.z)
+		if math.Abs(lower-test.lower) > 1e-6 || math.Abs(upper-test.upper) > 1e-6 {
+			t.Errorf("CalcWilsonScoreInterval(%d, %d, %v): expected [%v, %v], got [%v, %v]",
+				test.successes, test.n, test.z, test.lower, test.upper, lower, upper)
+		}
+	}
+}
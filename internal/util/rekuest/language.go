@@ -0,0 +1,43 @@
+package rekuest
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/samber/lo"
+	"golang.org/x/text/language"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/util/i18n"
+)
+
+// LanguageAuto is the value of query `lang` to negotiate the language of names by the Accept-Language header.
+const LanguageAuto = "auto"
+
+var nameLanguageMatcher = language.NewMatcher(lo.Map(i18n.NameLanguages, func(lang string, _ int) language.Tag {
+	return language.Make(lang)
+}))
+
+// NameLanguage returns the language names of game data in the response shall be filtered to, as requested by query
+// `lang`, which is one of i18n.NameLanguages, or LanguageAuto to negotiate one by the Accept-Language header.
+// An empty string is returned if query `lang` is omitted, in which case names in all languages shall be responded,
+// as they always have been.
+func NameLanguage(ctx *fiber.Ctx) (string, error) {
+	lang := ctx.Query("lang")
+	if lang == "" {
+		return "", nil
+	}
+
+	if lang == LanguageAuto {
+		ctx.Vary(fiber.HeaderAcceptLanguage)
+		tags, _, err := language.ParseAcceptLanguage(ctx.Get(fiber.HeaderAcceptLanguage))
+		if err != nil || len(tags) == 0 {
+			return i18n.NameLanguages[0], nil
+		}
+		_, index, _ := nameLanguageMatcher.Match(tags...)
+		return i18n.NameLanguages[index], nil
+	}
+
+	if !lo.Contains(i18n.NameLanguages, lang) {
+		return "", pgerr.ErrInvalidReq.Msg("invalid lang: expected one of %v or `%s`", i18n.NameLanguages, LanguageAuto)
+	}
+	return lang, nil
+}
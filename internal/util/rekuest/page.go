@@ -0,0 +1,28 @@
+package rekuest
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/cursor"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+// Page returns the page of a listing requested by query `cursor`, which is opaque and given by the previous page,
+// and query `limit`, which defaults to defaultLimit and could not exceed maxLimit.
+func Page(ctx *fiber.Ctx, defaultLimit int, maxLimit int) (*cursor.Cursor, int, error) {
+	c, err := cursor.Decode(ctx.Query("cursor"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit := defaultLimit
+	if ctx.Query("limit") != "" {
+		if limit, err = strconv.Atoi(ctx.Query("limit")); err != nil || limit <= 0 || limit > maxLimit {
+			return nil, 0, pgerr.ErrInvalidReq.Msg("invalid limit: must be within 1 and %d", maxLimit)
+		}
+	}
+
+	return c, limit, nil
+}
@@ -103,7 +103,10 @@ func validateVar(ctx *fiber.Ctx, s any, tag string) []*ErrorResponse {
 }
 
 func validateStruct(ctx *fiber.Ctx, s any) []*ErrorResponse {
-	tr := TranslatorFromCtx(ctx)
+	return validateStructTranslated(TranslatorFromCtx(ctx), s)
+}
+
+func validateStructTranslated(tr ut.Translator, s any) []*ErrorResponse {
 	err := Validate.Struct(s)
 	if err != nil {
 		errs, ok := err.(validator.ValidationErrors)
@@ -139,6 +142,16 @@ func ValidStruct(ctx *fiber.Ctx, dest any) error {
 	return nil
 }
 
+// ValidStructTranslated validates dest like ValidStruct, with violations translated by tr, for requests which are
+// not served by fiber.
+func ValidStructTranslated(tr ut.Translator, dest any) error {
+	if err := validateStructTranslated(tr, dest); err != nil {
+		return pgerr.NewInvalidViolations(err)
+	}
+
+	return nil
+}
+
 func ValidVar(ctx *fiber.Ctx, field any, tag string) error {
 	if err := validateVar(ctx, field, tag); err != nil {
 		return pgerr.NewInvalidViolations(err)
@@ -0,0 +1,77 @@
+package reportutil
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zeebo/xxh3"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+// CanonicalPattern returns the canonical fingerprint of the drop pattern of quantities keyed by item ids, and its
+// hash. Fingerprints are `itemId:quantity` segments sorted as strings and joined by `|`, so that the same drops hash
+// the same regardless of their order, and hashes are the hex of the xxh3 hash of fingerprints.
+func CanonicalPattern(quantities map[int]int) (fingerprint, hexHash string) {
+	segments := make([]string, 0, len(quantities))
+	for itemId, quantity := range quantities {
+		segments = append(segments, strconv.Itoa(itemId)+":"+strconv.Itoa(quantity))
+	}
+	sort.Strings(segments)
+
+	fingerprint = strings.Join(segments, "|")
+	return fingerprint, strconv.FormatUint(xxh3.HashStringSeed(fingerprint, 0), 16)
+}
+
+// CanonicalPatternFromDrops returns the canonical fingerprint and hash of drops, with quantities of the same item
+// summed up.
+func CanonicalPatternFromDrops(drops []*types.Drop) (fingerprint, hexHash string) {
+	quantities := make(map[int]int, len(drops))
+	for _, drop := range drops {
+		quantities[drop.ItemID] += drop.Quantity
+	}
+	return CanonicalPattern(quantities)
+}
+
+// CanonicalPatternFromElements returns the canonical fingerprint and hash of the drop pattern of elements, with
+// quantities of the same item summed up.
+func CanonicalPatternFromElements(elements []*model.DropPatternElement) (fingerprint, hexHash string) {
+	return CanonicalPattern(PatternQuantities(elements))
+}
+
+// PatternQuantities sums up quantities of elements by item ids.
+func PatternQuantities(elements []*model.DropPatternElement) map[int]int {
+	quantities := make(map[int]int, len(elements))
+	for _, element := range elements {
+		quantities[element.ItemID] += element.Quantity
+	}
+	return quantities
+}
+
+// ParsePatternFingerprint parses quantities keyed by item ids from fingerprint, with quantities of the same item
+// summed up.
+func ParsePatternFingerprint(fingerprint string) (map[int]int, error) {
+	quantities := make(map[int]int)
+	if fingerprint == "" {
+		return quantities, nil
+	}
+	for _, segment := range strings.Split(fingerprint, "|") {
+		itemId, quantity, ok := strings.Cut(segment, ":")
+		if !ok {
+			return nil, errors.Errorf("invalid segment `%s` of pattern fingerprint", segment)
+		}
+		i, err := strconv.Atoi(itemId)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid item id of segment `%s` of pattern fingerprint", segment)
+		}
+		q, err := strconv.Atoi(quantity)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid quantity of segment `%s` of pattern fingerprint", segment)
+		}
+		quantities[i] += q
+	}
+	return quantities, nil
+}
@@ -0,0 +1,67 @@
+package reportutil
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+func TestCanonicalPattern(t *testing.T) {
+	tests := []struct {
+		quantities  map[int]int
+		fingerprint string
+	}{
+		{map[int]int{}, ""},
+		{map[int]int{1: 2}, "1:2"},
+		{map[int]int{2: 1, 10: 3, 1: 2}, "10:3|1:2|2:1"},
+	}
+	for _, test := range tests {
+		fingerprint, hash := CanonicalPattern(test.quantities)
+		if fingerprint != test.fingerprint {
+			t.Errorf("CanonicalPattern(%v): expected fingerprint %q, got %q", test.quantities, test.fingerprint, fingerprint)
+		}
+		if _, again := CanonicalPattern(test.quantities); hash != again {
+			t.Errorf("CanonicalPattern(%v): expected a stable hash, got %q and %q", test.quantities, hash, again)
+		}
+	}
+}
+
+func TestCanonicalPatternIgnoresOrder(t *testing.T) {
+	drops := []*types.Drop{{ItemID: 2, Quantity: 1}, {ItemID: 1, Quantity: 1}, {ItemID: 1, Quantity: 2}}
+	elements := []*model.DropPatternElement{{ItemID: 1, Quantity: 3}, {ItemID: 2, Quantity: 1}}
+
+	dropsFingerprint, dropsHash := CanonicalPatternFromDrops(drops)
+	elementsFingerprint, elementsHash := CanonicalPatternFromElements(elements)
+	if dropsFingerprint != "1:3|2:1" || dropsFingerprint != elementsFingerprint || dropsHash != elementsHash {
+		t.Errorf("Expected drops and elements to share fingerprint 1:3|2:1 and its hash, got %q (%s) and %q (%s)",
+			dropsFingerprint, dropsHash, elementsFingerprint, elementsHash)
+	}
+}
+
+func TestParsePatternFingerprint(t *testing.T) {
+	tests := []struct {
+		fingerprint string
+		expected    map[int]int
+		wantErr     bool
+	}{
+		{"", map[int]int{}, false},
+		{"1:2", map[int]int{1: 2}, false},
+		{"10:3|1:2|2:1", map[int]int{1: 2, 2: 1, 10: 3}, false},
+		{"1:2|1:3", map[int]int{1: 5}, false},
+		{"1", nil, true},
+		{"a:1", nil, true},
+		{"1:b", nil, true},
+	}
+	for _, test := range tests {
+		quantities, err := ParsePatternFingerprint(test.fingerprint)
+		if (err != nil) != test.wantErr {
+			t.Errorf("ParsePatternFingerprint(%q): expected error %v, got %v", test.fingerprint, test.wantErr, err)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(quantities, test.expected) {
+			t.Errorf("ParsePatternFingerprint(%q): expected %v, got %v", test.fingerprint, test.expected, quantities)
+		}
+	}
+}
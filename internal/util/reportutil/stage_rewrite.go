@@ -0,0 +1,66 @@
+package reportutil
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+type compiledStageRewriteRule struct {
+	*model.StageRewriteRule
+	pattern *regexp.Regexp
+}
+
+// StageRewriteRules are compiled stage rewrite rules, evaluated in order; only the first matching one is applied.
+type StageRewriteRules []*compiledStageRewriteRule
+
+// CompileStageRewriteRule compiles the stageId pattern of rule.
+func CompileStageRewriteRule(rule *model.StageRewriteRule) (*regexp.Regexp, error) {
+	pattern, err := regexp.Compile(rule.StageIDPattern)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid stageId pattern of stage rewrite rule %s", rule.Name)
+	}
+	return pattern, nil
+}
+
+// CompileStageRewriteRules compiles rules in order. Rules with invalid stageId patterns are skipped.
+func CompileStageRewriteRules(rules []*model.StageRewriteRule) StageRewriteRules {
+	compiled := make(StageRewriteRules, 0, len(rules))
+	for _, rule := range rules {
+		pattern, err := CompileStageRewriteRule(rule)
+		if err != nil {
+			log.Error().Err(err).Int("ruleId", rule.RuleID).Msg("skipping stage rewrite rule")
+			continue
+		}
+		compiled = append(compiled, &compiledStageRewriteRule{StageRewriteRule: rule, pattern: pattern})
+	}
+	return compiled
+}
+
+func (r *compiledStageRewriteRule) matches(source, version, stageId string, t time.Time) bool {
+	if r.Source.Valid && r.Source.String != source {
+		return false
+	}
+	if r.ActiveFrom != nil && t.Before(*r.ActiveFrom) {
+		return false
+	}
+	if r.ActiveUntil != nil && !t.Before(*r.ActiveUntil) {
+		return false
+	}
+	return VersionInRange(version, r.VersionFrom, r.VersionBefore) && r.pattern.MatchString(stageId)
+}
+
+// Apply returns stageId reported by source with version at t, after rewritten by the first matching rule,
+// together with the matched rule. If no rule matches, stageId is returned as-is with a nil rule.
+func (rules StageRewriteRules) Apply(source, version, stageId string, t time.Time) (string, *model.StageRewriteRule) {
+	for _, rule := range rules {
+		if rule.matches(source, version, stageId, t) {
+			return rule.pattern.ReplaceAllString(stageId, rule.Rewrite), rule.StageRewriteRule
+		}
+	}
+	return stageId, nil
+}
@@ -0,0 +1,100 @@
+package reportutil
+
+import (
+	"strings"
+
+	"golang.org/x/mod/semver"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+)
+
+// canonicalVersion returns version prefixed with `v` as required by semver, and whether it is a valid SemVer.
+func canonicalVersion(version string) (string, bool) {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version, semver.IsValid(version)
+}
+
+// VersionInRange reports whether version is within [from, before), where a null bound is unbounded. Versions not
+// following SemVer are only within the range unbounded on both sides.
+func VersionInRange(version string, from, before null.String) bool {
+	if !from.Valid && !before.Valid {
+		return true
+	}
+
+	version, ok := canonicalVersion(version)
+	if !ok {
+		return false
+	}
+	if from.Valid {
+		if from, ok := canonicalVersion(from.String); ok && semver.Compare(version, from) < 0 {
+			return false
+		}
+	}
+	if before.Valid {
+		if before, ok := canonicalVersion(before.String); ok && semver.Compare(version, before) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// GateMatches reports whether gate matches a report from source with version.
+func GateMatches(gate *model.ReportGate, source, version string) bool {
+	return gate.Source == source && VersionInRange(version, gate.VersionFrom, gate.VersionBefore)
+}
+
+// CheckGates returns the action taken on a report from source with version, and the gate taking it. Rejecting
+// gates take precedence over down-weighting ones. When allowlistOnly is true, reports not matching any allowing
+// gate are down-weighted with a nil gate. Otherwise, constant.ReportGateActionAllow is returned with a nil gate
+// if no gate matches.
+func CheckGates(gates []*model.ReportGate, source, version string, allowlistOnly bool) (action string, gate *model.ReportGate) {
+	var downweighting *model.ReportGate
+	allowed := !allowlistOnly
+	for _, g := range gates {
+		if !GateMatches(g, source, version) {
+			continue
+		}
+		switch g.Action {
+		case constant.ReportGateActionReject:
+			return constant.ReportGateActionReject, g
+		case constant.ReportGateActionDownweight:
+			if downweighting == nil {
+				downweighting = g
+			}
+		case constant.ReportGateActionAllow:
+			allowed = true
+		}
+	}
+
+	if downweighting != nil {
+		return constant.ReportGateActionDownweight, downweighting
+	}
+	if !allowed {
+		return constant.ReportGateActionDownweight, nil
+	}
+	return constant.ReportGateActionAllow, nil
+}
+
+// MinimumVersion returns the lowest version of source not rejected by gates rejecting every version below it,
+// i.e. rejecting gates without VersionFrom. An empty string is returned if there is no such gate.
+func MinimumVersion(gates []*model.ReportGate, source string) string {
+	minimum := ""
+	for _, gate := range gates {
+		if gate.Source != source || gate.Action != constant.ReportGateActionReject ||
+			gate.VersionFrom.Valid || !gate.VersionBefore.Valid {
+			continue
+		}
+		before, ok := canonicalVersion(gate.VersionBefore.String)
+		if !ok {
+			continue
+		}
+		if minimum == "" || semver.Compare(before, minimum) > 0 {
+			minimum = before
+		}
+	}
+	return minimum
+}
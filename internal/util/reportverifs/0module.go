@@ -2,12 +2,30 @@ package reportverifs
 
 import "go.uber.org/fx"
 
+// asVerifier registers constructor of a Verifier into the verifier registry.
+func asVerifier(constructor any) any {
+	return fx.Annotate(
+		constructor,
+		fx.As(new(Verifier)),
+		fx.ResultTags(`group:"verifiers"`),
+	)
+}
+
 func Module() fx.Option {
 	return fx.Module("reportverifs", fx.Provide(
-		NewMD5Verifier,
-		NewUserVerifier,
-		NewDropVerifier,
+		asVerifier(NewMD5Verifier),
+		asVerifier(NewRecognitionVerifier),
+		asVerifier(NewShadowBanVerifier),
+		asVerifier(NewUserVerifier),
+		asVerifier(NewVelocityVerifier),
+		asVerifier(NewDropVerifier),
+		asVerifier(NewQuantitySanityVerifier),
+		asVerifier(NewGameModeCompatVerifier),
+		asVerifier(NewActivityWindowVerifier),
+		asVerifier(NewRejectRuleVerifier),
+		// the report gate verifier is also consulted by the report service directly
+		NewReportGateVerifier,
+		asVerifier(func(v *ReportGateVerifier) *ReportGateVerifier { return v }),
 		NewReportVerifier,
-		NewRejectRuleVerifier,
 	))
 }
@@ -0,0 +1,18 @@
+package reportverifs
+
+import "context"
+
+type dryRunContextKey struct{}
+
+// WithDryRun marks verifiers running with ctx as running for a dry run, e.g. of a replay previewing how archived
+// tasks would be verified. Verifiers shall only read what they record for a dry run, so that the verdict of the
+// task, once it is actually submitted or replayed, is not affected.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunContextKey{}, true)
+}
+
+// IsDryRun returns whether verifiers running with ctx are running for a dry run.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunContextKey{}).(bool)
+	return dryRun
+}
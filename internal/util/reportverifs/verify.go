@@ -2,44 +2,175 @@ package reportverifs
 
 import (
 	"context"
+	"encoding/json"
+	"sort"
+	"sync"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/featureflag"
 	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
 )
 
+// DefaultOrder is the order verifiers run in, when not overridden at runtime. Registered verifiers not listed
+// here run afterwards, ordered by name.
+var DefaultOrder = []string{
+	"shadow_ban",
+	"report_gate",
+	"user",
+	"activity_window",
+	"md5",
+	"recognition",
+	"velocity",
+	"quantity_sanity",
+	"drop",
+	"game_mode_compat",
+	"reject_rule",
+}
+
+// pipelineRefreshInterval is how often the runtime configuration of verifiers is reloaded.
+const pipelineRefreshInterval = time.Minute
+
 type Verifier interface {
 	Name() string
 	Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection
 }
 
-type ReportVerifiers []Verifier
+// PipelineConfig is the runtime configuration of verifiers, stored as the property
+// constant.ReportVerifiersPropertyKey.
+type PipelineConfig struct {
+	// Order overrides DefaultOrder. Registered verifiers not listed run afterwards, in DefaultOrder.
+	Order []string `json:"order,omitempty"`
+	// Disabled lists verifiers which shall not run, in addition to those disabled by environment.
+	Disabled []string `json:"disabled,omitempty"`
+}
+
+type ReportVerifiersDeps struct {
+	fx.In
+
+	Verifiers    []Verifier `group:"verifiers"`
+	PropertyRepo *repo.Property
+	FeatureFlags *featureflag.Store
+	Config       *config.Config
+}
+
+// ReportVerifiers is the registry of all verifiers, which runs enabled verifiers in the configured order.
+type ReportVerifiers struct {
+	registry     map[string]Verifier
+	propertyRepo *repo.Property
+	featureFlags *featureflag.Store
+	envDisabled  []string
+
+	mu          sync.Mutex
+	pipeline    []Verifier
+	refreshedAt time.Time
+}
+
+func NewReportVerifier(deps ReportVerifiersDeps) *ReportVerifiers {
+	registry := make(map[string]Verifier, len(deps.Verifiers))
+	for _, verifier := range deps.Verifiers {
+		registry[verifier.Name()] = verifier
+	}
 
-func NewReportVerifier(userVerifier *UserVerifier, dropVerifier *DropVerifier, md5Verifier *MD5Verifier, rejectRuleVerifier *RejectRuleVerifier) *ReportVerifiers {
 	return &ReportVerifiers{
-		userVerifier,
-		md5Verifier,
-		dropVerifier,
-		rejectRuleVerifier,
+		registry:     registry,
+		propertyRepo: deps.PropertyRepo,
+		featureFlags: deps.FeatureFlags,
+		envDisabled:  deps.Config.ReportVerifiersDisabled,
+	}
+}
+
+// Pipeline returns the enabled verifiers, in the order they run.
+func (verifiers *ReportVerifiers) Pipeline(ctx context.Context) []Verifier {
+	verifiers.mu.Lock()
+	defer verifiers.mu.Unlock()
+
+	if verifiers.pipeline != nil && time.Since(verifiers.refreshedAt) < pipelineRefreshInterval {
+		return verifiers.pipeline
+	}
+
+	pipelineConfig, err := verifiers.loadPipelineConfig(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to load verifiers configuration, falling back to the default one")
+		pipelineConfig = &PipelineConfig{}
+	}
+
+	verifiers.pipeline = verifiers.buildPipeline(pipelineConfig)
+	verifiers.refreshedAt = time.Now()
+	return verifiers.pipeline
+}
+
+func (verifiers *ReportVerifiers) loadPipelineConfig(ctx context.Context) (*PipelineConfig, error) {
+	property, err := verifiers.propertyRepo.GetPropertyByKey(ctx, constant.ReportVerifiersPropertyKey)
+	if errors.Is(err, pgerr.ErrNotFound) {
+		return &PipelineConfig{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var pipelineConfig PipelineConfig
+	if err := json.Unmarshal([]byte(property.Value), &pipelineConfig); err != nil {
+		return nil, errors.Wrap(err, "invalid verifiers property")
+	}
+	return &pipelineConfig, nil
+}
+
+func (verifiers *ReportVerifiers) buildPipeline(pipelineConfig *PipelineConfig) []Verifier {
+	remaining := lo.Keys(verifiers.registry)
+	sort.Strings(remaining)
+	order := lo.Uniq(append(append(append([]string{}, pipelineConfig.Order...), DefaultOrder...), remaining...))
+
+	pipeline := make([]Verifier, 0, len(verifiers.registry))
+	for _, name := range order {
+		verifier, ok := verifiers.registry[name]
+		if !ok || lo.Contains(verifiers.envDisabled, name) || lo.Contains(pipelineConfig.Disabled, name) {
+			continue
+		}
+		pipeline = append(pipeline, verifier)
 	}
+	return pipeline
 }
 
-func (verifiers ReportVerifiers) Verify(ctx context.Context, reportTask *types.ReportTask) (violations Violations) {
+// Verify runs the pipeline on each report of reportTask, and returns the violation of each report rejected by any
+// verifier. Verifiers gated by a feature flag of constant.FeatureFlagVerifierPrefix are skipped for reports the flag
+// is not enabled for. An error is returned when any report could not be verified for a transient reason, so that the
+// report task could be retried.
+func (verifiers *ReportVerifiers) Verify(ctx context.Context, reportTask *types.ReportTask) (violations Violations, err error) {
 	violations = map[int]*Violation{}
+	subject := featureflag.Subject{Server: reportTask.Server, ID: featureflag.AccountSubjectID(reportTask.AccountID)}
+	pipeline := lo.Filter(verifiers.Pipeline(ctx), func(verifier Verifier, _ int) bool {
+		return verifiers.featureFlags.Enabled(ctx, constant.FeatureFlagVerifierPrefix+verifier.Name(), subject, true)
+	})
 
 	for reportIndex, report := range reportTask.Reports {
-		for _, pipe := range verifiers {
+		for _, pipe := range pipeline {
 			start := time.Now()
 
 			name := pipe.Name()
 			rejection := pipe.Verify(ctx, report, reportTask)
 
+			if rejection != nil && rejection.Err != nil {
+				return nil, errors.Wrapf(rejection.Err, "verifier `%s` could not verify report %d", name, reportIndex)
+			}
 			if rejection != nil {
 				violations[reportIndex] = &Violation{
 					Name:      name,
 					Rejection: *rejection,
 				}
 
+				observability.ReportVerifyRejections.
+					WithLabelValues(name).
+					Inc()
+
 				break
 			}
 
@@ -49,5 +180,5 @@ func (verifiers ReportVerifiers) Verify(ctx context.Context, reportTask *types.R
 		}
 	}
 
-	return violations
+	return violations, nil
 }
@@ -0,0 +1,119 @@
+package reportverifs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+// activityWindowGracePeriod is how long after a stage closes its reports are still accepted, as runs started
+// right before closing could be reported a bit later, and clocks of clients could be slightly off.
+const activityWindowGracePeriod = time.Minute * 30
+
+var ErrStageNotOpen = errors.New("stage is not open on the server")
+
+type ActivityWindowVerifier struct {
+	StageRepo *repo.Stage
+	ZoneRepo  *repo.Zone
+}
+
+// ensure ActivityWindowVerifier conforms to Verifier
+var _ Verifier = (*ActivityWindowVerifier)(nil)
+
+func NewActivityWindowVerifier(stageRepo *repo.Stage, zoneRepo *repo.Zone) *ActivityWindowVerifier {
+	return &ActivityWindowVerifier{
+		StageRepo: stageRepo,
+		ZoneRepo:  zoneRepo,
+	}
+}
+
+func (v *ActivityWindowVerifier) Name() string {
+	return "activity_window"
+}
+
+// Verify rejects reports of stages which are not open on the reported server at the time the report is submitted,
+// by the existence of the stage, or of its zone when the stage itself has no time range. Reports from clients with
+// skewed clocks or stale stage lists are caught this way.
+func (v *ActivityWindowVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	stage, err := v.StageRepo.GetStageByArkId(ctx, report.StageID)
+	if err != nil {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityActivityWindow,
+			Message:     err.Error(),
+		}
+	}
+	server := strings.ToUpper(reportTask.Server)
+
+	existence, err := parseServerExistence(stage.Existence, server)
+	if err != nil {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityActivityWindow,
+			Message:     err.Error(),
+		}
+	}
+	if existence.Exist && existence.StartTime == nil && existence.EndTime == nil {
+		// stages of activities usually leave the time range to their zones
+		zone, err := v.ZoneRepo.GetZoneById(ctx, stage.ZoneID)
+		if err != nil {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityActivityWindow,
+				Message:     err.Error(),
+			}
+		}
+		if existence, err = parseServerExistence(zone.Existence, server); err != nil {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityActivityWindow,
+				Message:     err.Error(),
+			}
+		}
+	}
+
+	submittedAt := time.Now()
+	if reportTask.CreatedAt != 0 {
+		submittedAt = time.UnixMicro(reportTask.CreatedAt)
+	}
+	if err := checkServerExistence(existence, submittedAt); err != nil {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityActivityWindow,
+			Message:     fmt.Sprintf("stage %s on server %s: %s", report.StageID, server, err),
+		}
+	}
+	return nil
+}
+
+// parseServerExistence returns the existence of a stage or a zone on server, out of existenceJSON of all servers.
+func parseServerExistence(existenceJSON json.RawMessage, server string) (*model.ServerExistence, error) {
+	var existences map[string]*model.ServerExistence
+	if err := json.Unmarshal(existenceJSON, &existences); err != nil {
+		return nil, errors.Wrap(err, "invalid existence")
+	}
+	existence, ok := existences[server]
+	if !ok || existence == nil {
+		return &model.ServerExistence{}, nil
+	}
+	return existence, nil
+}
+
+// checkServerExistence returns ErrStageNotOpen if existence is not open at t, or closed longer than
+// activityWindowGracePeriod ago.
+func checkServerExistence(existence *model.ServerExistence, t time.Time) error {
+	if !existence.Exist {
+		return ErrStageNotOpen
+	}
+	if existence.StartTime != nil && t.Before(time.UnixMilli(int64(*existence.StartTime))) {
+		return errors.Wrapf(ErrStageNotOpen, "opens at %s", time.UnixMilli(int64(*existence.StartTime)).UTC().Format(time.RFC3339))
+	}
+	if existence.EndTime != nil && t.After(time.UnixMilli(int64(*existence.EndTime)).Add(activityWindowGracePeriod)) {
+		return errors.Wrapf(ErrStageNotOpen, "closed at %s", time.UnixMilli(int64(*existence.EndTime)).UTC().Format(time.RFC3339))
+	}
+	return nil
+}
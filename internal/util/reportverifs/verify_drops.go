@@ -3,6 +3,7 @@ package reportverifs
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/samber/lo"
@@ -10,7 +11,9 @@ import (
 	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model"
 	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
 	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/util"
 )
 
 var (
@@ -22,14 +25,16 @@ var (
 
 type DropVerifier struct {
 	DropInfoRepo *repo.DropInfo
+	StageRepo    *repo.Stage
 }
 
 // ensure DropVerifier conforms to Verifier
 var _ Verifier = (*DropVerifier)(nil)
 
-func NewDropVerifier(dropInfoRepo *repo.DropInfo) *DropVerifier {
+func NewDropVerifier(dropInfoRepo *repo.DropInfo, stageRepo *repo.Stage) *DropVerifier {
 	return &DropVerifier{
 		DropInfoRepo: dropInfoRepo,
+		StageRepo:    stageRepo,
 	}
 }
 
@@ -38,10 +43,15 @@ func (d *DropVerifier) Name() string {
 }
 
 func (d *DropVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
-	itemDropInfos, typeDropInfos, err := d.DropInfoRepo.GetForCurrentTimeRangeWithDropTypes(ctx, &repo.DropInfoQuery{
-		Server:     reportTask.Server,
-		ArkStageId: report.StageID,
-	})
+	itemDropInfos, typeDropInfos, err := currentDropInfos(ctx, d.DropInfoRepo, reportTask.Server, report.StageID)
+	if err != nil {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityDrop,
+			Message:     err.Error(),
+		}
+	}
+
+	runs, err := reportRuns(ctx, d.StageRepo, report)
 	if err != nil {
 		return &Rejection{
 			Reliability: constant.ViolationReliabilityDrop,
@@ -51,11 +61,11 @@ func (d *DropVerifier) Verify(ctx context.Context, report *types.ReportTaskSingl
 
 	var errs []error
 
-	if innerErrs := d.verifyDropType(report, typeDropInfos); innerErrs != nil {
+	if innerErrs := d.verifyDropType(report, typeDropInfos, runs); innerErrs != nil {
 		errs = append(errs, innerErrs...)
 	}
 
-	if innerErrs := d.verifyDropItem(report, itemDropInfos); innerErrs != nil {
+	if innerErrs := d.verifyDropItem(report, itemDropInfos, runs); innerErrs != nil {
 		errs = append(errs, innerErrs...)
 	}
 
@@ -69,7 +79,9 @@ func (d *DropVerifier) Verify(ctx context.Context, report *types.ReportTaskSingl
 	return nil
 }
 
-func (d *DropVerifier) verifyDropType(report *types.ReportTaskSingleReport, dropInfos []*model.DropInfo) (errs []error) {
+// verifyDropType verifies the amount of kinds of items dropped under each drop type. When drops are aggregated
+// from multiple runs, the upper bound scales with runs while exceptions are not applicable.
+func (d *DropVerifier) verifyDropType(report *types.ReportTaskSingleReport, dropInfos []*model.DropInfo, runs int) (errs []error) {
 	grouped := lo.GroupBy(report.Drops, func(drop *types.Drop) string {
 		return drop.DropType
 	})
@@ -83,9 +95,9 @@ func (d *DropVerifier) verifyDropType(report *types.ReportTaskSingleReport, drop
 		count := dropTypeAmountMap[dropInfo.DropType]
 		if dropInfo.Bounds.Lower > count {
 			errs = append(errs, errors.Wrap(ErrInvalidDropType, fmt.Sprintf("drop type `%s`: expected at least %d, but got %d", dropInfo.DropType, dropInfo.Bounds.Lower, count)))
-		} else if dropInfo.Bounds.Upper < count {
-			errs = append(errs, errors.Wrap(ErrInvalidDropType, fmt.Sprintf("drop type `%s`: expected at most %d, but got %d", dropInfo.DropType, dropInfo.Bounds.Upper, count)))
-		} else if dropInfo.Bounds.Exceptions != nil {
+		} else if upper := dropInfo.Bounds.Upper * runs; upper < count {
+			errs = append(errs, errors.Wrap(ErrInvalidDropType, fmt.Sprintf("drop type `%s`: expected at most %d, but got %d", dropInfo.DropType, upper, count)))
+		} else if runs == 1 && dropInfo.Bounds.Exceptions != nil {
 			if lo.Contains(dropInfo.Bounds.Exceptions, count) {
 				errs = append(errs, errors.Wrap(ErrInvalidDropType, fmt.Sprintf("drop type `%s`: expected not to have (%v), but got %d", dropInfo.DropType, dropInfo.Bounds.Exceptions, count)))
 			}
@@ -98,9 +110,9 @@ func (d *DropVerifier) verifyDropType(report *types.ReportTaskSingleReport, drop
 /**
  * Verify drop item quantity
  * Check 1: iterate drops, check if any item is not in dropInfos
- * Check 2: iterate dropInfos, check if quantity is within bounds
+ * Check 2: iterate dropInfos, check if quantity is within bounds, which scale with runs
  */
-func (d *DropVerifier) verifyDropItem(report *types.ReportTaskSingleReport, dropInfos []*model.DropInfo) (errs []error) {
+func (d *DropVerifier) verifyDropItem(report *types.ReportTaskSingleReport, dropInfos []*model.DropInfo, runs int) (errs []error) {
 	itemIdSetFromDropInfos := make(map[int]struct{})
 	for _, dropInfo := range dropInfos {
 		itemIdSetFromDropInfos[int(dropInfo.ItemID.Int64)] = struct{}{}
@@ -126,11 +138,15 @@ func (d *DropVerifier) verifyDropItem(report *types.ReportTaskSingleReport, drop
 		if quantityMap, ok := dropItemQuantityMap[itemId]; ok {
 			count = quantityMap[dropInfo.DropType]
 		}
-		if dropInfo.Bounds.Lower > count {
-			errs = append(errs, errors.Wrap(ErrInvalidDropItem, fmt.Sprintf("item %d in drop type `%s`: expected at least %d, but got %d", itemId, dropInfo.DropType, dropInfo.Bounds.Lower, count)))
-		} else if dropInfo.Bounds.Upper < count {
-			errs = append(errs, errors.Wrap(ErrInvalidDropItem, fmt.Sprintf("item %d in drop type `%s`: expected at most %d, but got %d", itemId, dropInfo.DropType, dropInfo.Bounds.Upper, count)))
-		} else if dropInfo.Bounds.Exceptions != nil {
+		if fixedQuantity, ok := util.GetFixedQuantityFromDropInfo(dropInfo); ok && count != fixedQuantity*report.Times {
+			// fixed-rate drops are flagged rather than rejected, as bounds below still take care of the rejection
+			observability.ReportFixedDropContradiction.WithLabelValues(report.StageID, strconv.Itoa(itemId)).Inc()
+		}
+		if lower := dropInfo.Bounds.Lower * runs; lower > count {
+			errs = append(errs, errors.Wrap(ErrInvalidDropItem, fmt.Sprintf("item %d in drop type `%s`: expected at least %d, but got %d", itemId, dropInfo.DropType, lower, count)))
+		} else if upper := dropInfo.Bounds.Upper * runs; upper < count {
+			errs = append(errs, errors.Wrap(ErrInvalidDropItem, fmt.Sprintf("item %d in drop type `%s`: expected at most %d, but got %d", itemId, dropInfo.DropType, upper, count)))
+		} else if runs == 1 && dropInfo.Bounds.Exceptions != nil {
 			if lo.Contains(dropInfo.Bounds.Exceptions, count) {
 				errs = append(errs, errors.Wrap(ErrInvalidDropItem, fmt.Sprintf("item %d in drop type `%s`: expected not to have (%v), but got %d", itemId, dropInfo.DropType, dropInfo.Bounds.Exceptions, count)))
 			}
@@ -0,0 +1,167 @@
+package reportverifs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/samber/lo"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var ErrCrossGameModeDrop = errors.New("item is not expected to drop in the game mode of the stage")
+
+type GameModeCompatVerifier struct {
+	PropertyRepo *repo.Property
+	ItemRepo     *repo.Item
+	StageRepo    *repo.Stage
+	ZoneRepo     *repo.Zone
+}
+
+// ensure GameModeCompatVerifier conforms to Verifier
+var _ Verifier = (*GameModeCompatVerifier)(nil)
+
+func NewGameModeCompatVerifier(propertyRepo *repo.Property, itemRepo *repo.Item, stageRepo *repo.Stage, zoneRepo *repo.Zone) *GameModeCompatVerifier {
+	return &GameModeCompatVerifier{
+		PropertyRepo: propertyRepo,
+		ItemRepo:     itemRepo,
+		StageRepo:    stageRepo,
+		ZoneRepo:     zoneRepo,
+	}
+}
+
+func (g *GameModeCompatVerifier) Name() string {
+	return "game_mode_compat"
+}
+
+func (g *GameModeCompatVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	itemGameModes, err := g.getItemGameModes(ctx)
+	if err != nil {
+		return unverifiable(err)
+	}
+	if len(itemGameModes) == 0 {
+		return nil
+	}
+
+	var stage model.Stage
+	if _, err := cache.StageByArkID.MutexGetSet(ctx, report.StageID, &stage, func(ctx context.Context) (*model.Stage, error) {
+		return g.StageRepo.GetStageByArkId(ctx, report.StageID)
+	}, time.Hour); errors.Is(err, pgerr.ErrNotFound) {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityGameModeCompat,
+			Message:     fmt.Sprintf("stage `%s` not found", report.StageID),
+		}
+	} else if err != nil {
+		return unverifiable(err)
+	}
+	zoneCategory, err := g.getZoneCategory(ctx, stage.ZoneID)
+	if err != nil {
+		return unverifiable(err)
+	}
+	itemsMapById, err := g.getItemsMapById(ctx)
+	if err != nil {
+		return unverifiable(err)
+	}
+
+	var errs []error
+	for _, drop := range report.Drops {
+		item, ok := itemsMapById[drop.ItemID]
+		if !ok {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityGameModeCompat,
+				Message:     fmt.Sprintf("item %d not found", drop.ItemID),
+			}
+		}
+
+		gameModes, ok := itemGameModes[item.ArkItemID]
+		if !ok || lo.Contains(gameModes, zoneCategory) {
+			continue
+		}
+		errs = append(errs, errors.Wrap(ErrCrossGameModeDrop, fmt.Sprintf("item `%s`: expected to drop in (%v), but stage `%s` is in `%s`", item.ArkItemID, gameModes, stage.ArkStageID, zoneCategory)))
+	}
+
+	if len(errs) > 0 {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityGameModeCompat,
+			Message:     fmt.Sprintf("%v", errs),
+		}
+	}
+
+	return nil
+}
+
+// getItemGameModes returns a map with ark item id as key and the zone categories the item could drop in as value.
+// An empty map is returned when the property is not configured or is invalid.
+//
+// Cache: (singular) itemGameModes, 10 mins
+func (g *GameModeCompatVerifier) getItemGameModes(ctx context.Context) (map[string][]string, error) {
+	var itemGameModes map[string][]string
+	err := cache.ItemGameModes.MutexGetSet(&itemGameModes, func() (map[string][]string, error) {
+		property, err := g.PropertyRepo.GetPropertyByKey(ctx, constant.ItemGameModesPropertyKey)
+		if errors.Is(err, pgerr.ErrNotFound) {
+			return map[string][]string{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		var itemGameModes map[string][]string
+		if err := json.Unmarshal([]byte(property.Value), &itemGameModes); err != nil {
+			// reports are not to blame for a misconfigured property, hence they are not verified against it
+			log.Warn().Err(err).Msg("invalid item game modes property, ignoring it")
+			return map[string][]string{}, nil
+		}
+		return itemGameModes, nil
+	}, time.Minute*10)
+	if err != nil {
+		return nil, err
+	}
+	return itemGameModes, nil
+}
+
+// getZoneCategory returns the category of zone zoneId.
+//
+// Cache: (singular) zones, 1 hr
+func (g *GameModeCompatVerifier) getZoneCategory(ctx context.Context, zoneId int) (string, error) {
+	var zones []*model.Zone
+	err := cache.Zones.MutexGetSet(&zones, func() ([]*model.Zone, error) {
+		return g.ZoneRepo.GetZones(ctx)
+	}, time.Hour)
+	if err != nil {
+		return "", err
+	}
+
+	zone, ok := lo.Find(zones, func(zone *model.Zone) bool {
+		return zone.ZoneID == zoneId
+	})
+	if !ok {
+		return "", errors.Errorf("zone %d not found", zoneId)
+	}
+	return zone.Category, nil
+}
+
+// Cache: (singular) itemsMapById, 1 hr
+func (g *GameModeCompatVerifier) getItemsMapById(ctx context.Context) (map[int]*model.Item, error) {
+	var itemsMapById map[int]*model.Item
+	err := cache.ItemsMapById.MutexGetSet(&itemsMapById, func() (map[int]*model.Item, error) {
+		items, err := g.ItemRepo.GetItems(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return lo.KeyBy(items, func(item *model.Item) int {
+			return item.ItemID
+		}), nil
+	}, time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return itemsMapById, nil
+}
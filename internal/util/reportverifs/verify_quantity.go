@@ -0,0 +1,146 @@
+package reportverifs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v3"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/cache"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var ErrQuantityExceedsMaximum = errors.New("item quantity exceeds the theoretical maximum of the stage")
+
+type QuantitySanityVerifier struct {
+	DropInfoRepo *repo.DropInfo
+	StageRepo    *repo.Stage
+}
+
+// ensure QuantitySanityVerifier conforms to Verifier
+var _ Verifier = (*QuantitySanityVerifier)(nil)
+
+func NewQuantitySanityVerifier(dropInfoRepo *repo.DropInfo, stageRepo *repo.Stage) *QuantitySanityVerifier {
+	return &QuantitySanityVerifier{
+		DropInfoRepo: dropInfoRepo,
+		StageRepo:    stageRepo,
+	}
+}
+
+func (q *QuantitySanityVerifier) Name() string {
+	return "quantity_sanity"
+}
+
+// Verify rejects reports having any item dropped more than the theoretical maximum of the stage, which is the sum
+// of upper bounds of all drop infos of the item across drop types, scaled by runs.
+func (q *QuantitySanityVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	itemDropInfos, _, err := currentDropInfos(ctx, q.DropInfoRepo, reportTask.Server, report.StageID)
+	if err != nil {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityQuantitySanity,
+			Message:     err.Error(),
+		}
+	}
+
+	runs, err := reportRuns(ctx, q.StageRepo, report)
+	if err != nil {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityQuantitySanity,
+			Message:     err.Error(),
+		}
+	}
+
+	// maxQuantityMap: key is item id, value is the theoretical maximum quantity of a single run
+	maxQuantityMap := make(map[int]int)
+	for _, dropInfo := range itemDropInfos {
+		maxQuantityMap[int(dropInfo.ItemID.Int64)] += dropInfo.Bounds.Upper
+	}
+
+	quantityMap := make(map[int]int)
+	for _, drop := range report.Drops {
+		quantityMap[drop.ItemID] += drop.Quantity
+	}
+
+	var errs []error
+	for itemId, quantity := range quantityMap {
+		maxQuantity, ok := maxQuantityMap[itemId]
+		if !ok {
+			// unknown items are left for the drop verifier to reject
+			continue
+		}
+		if maximum := maxQuantity * runs; quantity > maximum {
+			errs = append(errs, errors.Wrap(ErrQuantityExceedsMaximum, fmt.Sprintf("item %d: expected at most %d, but got %d", itemId, maximum, quantity)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityQuantitySanity,
+			Message:     fmt.Sprintf("%v", errs),
+		}
+	}
+
+	return nil
+}
+
+// reportRuns returns how many runs the drops of report are aggregated from. For gachabox stages, `times` is derived
+// from the quantity of drops instead of runs, and their bounds are already described for the aggregated drops.
+func reportRuns(ctx context.Context, stageRepo *repo.Stage, report *types.ReportTaskSingleReport) (int, error) {
+	if report.Times <= 1 {
+		return 1, nil
+	}
+	category, err := cache.StageExtraProcessTypeByArkID.GetSet(ctx, report.StageID, func(ctx context.Context) (null.String, error) {
+		return stageRepo.GetStageExtraProcessTypeByArkId(ctx, report.StageID)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if category.Valid && category.String == constant.ExtraProcessTypeGachaBox {
+		return 1, nil
+	}
+	return report.Times, nil
+}
+
+// currentDropInfos returns drop infos of stage arkStageId within the current time range of server, split by
+// repo.SplitDropInfosByDropType. Drop infos cached are loaded again once a time range of server has started or ended
+// since they were loaded.
+//
+// Cache: (tiered) currentDropInfos#server|arkStageId:{server}|{arkStageId}, 30 secs in-process, 2 mins in Redis
+func currentDropInfos(ctx context.Context, dropInfoRepo *repo.DropInfo, server string, arkStageId string) (itemDropInfos, typeDropInfos []*model.DropInfo, err error) {
+	key := server + constant.CacheSep + arkStageId
+	load := func(ctx context.Context) (model.CurrentDropInfos, error) {
+		// read ahead of drop infos, so that drop infos are never taken as current for longer than they are
+		validUntil, err := dropInfoRepo.GetNextTimeRangeChange(ctx, server)
+		if err != nil {
+			return model.CurrentDropInfos{}, err
+		}
+		dropInfos, err := dropInfoRepo.GetForCurrentTimeRange(ctx, &repo.DropInfoQuery{
+			Server:     server,
+			ArkStageId: arkStageId,
+		})
+		if err != nil {
+			return model.CurrentDropInfos{}, err
+		}
+		return model.CurrentDropInfos{DropInfos: dropInfos, ValidUntil: validUntil}, nil
+	}
+
+	current, err := cache.CurrentDropInfosByArkStageID.GetSet(ctx, key, load)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !current.ValidUntil.IsZero() && !time.Now().Before(current.ValidUntil) {
+		if err := cache.CurrentDropInfosByArkStageID.Delete(ctx, key); err != nil {
+			return nil, nil, err
+		}
+		if current, err = cache.CurrentDropInfosByArkStageID.GetSet(ctx, key, load); err != nil {
+			return nil, nil, err
+		}
+	}
+	itemDropInfos, typeDropInfos = repo.SplitDropInfosByDropType(current.DropInfos)
+	return itemDropInfos, typeDropInfos, nil
+}
@@ -0,0 +1,128 @@
+package reportverifs
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+var (
+	ErrRecognitionMD5Malformed      = errors.New("md5 is not a valid hex-encoded md5 digest")
+	ErrRecognitionVersionIncomplete = errors.New("recognizer version and recognizer assets version shall be reported together")
+	ErrRecognitionItemCountMismatch = errors.New("item count recognized does not match the drops")
+	ErrRecognitionMD5Duplicated     = errors.New("md5 has already been submitted recently")
+)
+
+type RecognitionVerifier struct {
+	Redis *redis.Client
+}
+
+// ensure RecognitionVerifier conforms to Verifier
+var _ Verifier = (*RecognitionVerifier)(nil)
+
+func NewRecognitionVerifier(redisClient *redis.Client) *RecognitionVerifier {
+	return &RecognitionVerifier{
+		Redis: redisClient,
+	}
+}
+
+func (r *RecognitionVerifier) Name() string {
+	return "recognition"
+}
+
+// Verify rejects reports flagged for recognition output of low confidence on submission, reports whose recognition
+// metadata is internally inconsistent, or whose md5 duplicates another report within the same task or is claimed
+// by another task within the screenshot deduplication window.
+func (r *RecognitionVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	if report.RecognitionFlag != "" {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityRecognition,
+			Message:     report.RecognitionFlag,
+		}
+	}
+
+	metadata := report.Metadata
+	if metadata == nil || (metadata.MD5 == "" && metadata.RecognizerVersion == "" && metadata.RecognizerAssetsVersion == "") {
+		// not a report from recognition
+		return nil
+	}
+
+	if metadata.MD5 != "" {
+		if digest, err := hex.DecodeString(metadata.MD5); err != nil || len(digest) != 16 {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityRecognition,
+				Message:     ErrRecognitionMD5Malformed.Error(),
+			}
+		}
+	}
+
+	if (metadata.RecognizerVersion == "") != (metadata.RecognizerAssetsVersion == "") {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityRecognition,
+			Message:     ErrRecognitionVersionIncomplete.Error(),
+		}
+	}
+
+	if metadata.ItemCount != 0 {
+		quantity := 0
+		for _, drop := range report.Drops {
+			quantity += drop.Quantity
+		}
+		if quantity != metadata.ItemCount {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityRecognition,
+				Message:     errors.Wrap(ErrRecognitionItemCountMismatch, fmt.Sprintf("recognized %d items, but got %d in drops", metadata.ItemCount, quantity)).Error(),
+			}
+		}
+	}
+
+	if metadata.MD5 != "" {
+		if rejection := r.verifyMD5Unique(ctx, report, reportTask); rejection != nil {
+			return rejection
+		}
+	}
+
+	return nil
+}
+
+func (r *RecognitionVerifier) verifyMD5Unique(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	md5 := strings.ToLower(report.Metadata.MD5)
+
+	// reports of the same task are not persisted yet, hence only the first one with the md5 is accepted
+	for _, other := range reportTask.Reports {
+		if other == report {
+			break
+		}
+		if other.Metadata != nil && strings.ToLower(other.Metadata.MD5) == md5 {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityRecognition,
+				Message:     errors.Wrap(ErrRecognitionMD5Duplicated, "within the same task").Error(),
+			}
+		}
+	}
+
+	// the md5 is claimed on submission by the task which first submitted it, see service.Report; only another
+	// task holding the claim makes the report a duplicate, so that a redelivered task is not rejected by its own claim
+	key := constant.ReportScreenshotKeyPrefix + md5
+	claimedBy, err := r.Redis.Get(ctx, key).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityRecognition,
+			Message:     err.Error(),
+		}
+	}
+	if claimedBy != "" && claimedBy != reportTask.TaskID {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityRecognition,
+			Message:     ErrRecognitionMD5Duplicated.Error(),
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package reportverifs
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/snapshot"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+	"github.com/penguin-statistics/backend-next/internal/util/reportutil"
+)
+
+// reportGatesRefreshInterval is how often report gates are reloaded.
+const reportGatesRefreshInterval = time.Second * 10
+
+// ReportGateVerifier down-weights reports from sources and versions gated by report gates. It is also consulted
+// by the report service to refuse reports matching rejecting gates on submission.
+//
+// Gates are stored in the database, and published to Redis, from where every instance reloads them. Gates are
+// loaded from the database, and published again, when they are missing from Redis or Redis fails, so that gates
+// are never lifted by losing Redis.
+type ReportGateVerifier struct {
+	Redis          *redis.Client
+	ReportGateRepo *repo.ReportGate
+	// AllowlistOnly down-weights reports from sources and versions not matching any allowing gate.
+	AllowlistOnly bool
+
+	gates *snapshot.Snapshot[[]*model.ReportGate]
+}
+
+// ensure ReportGateVerifier conforms to Verifier
+var _ Verifier = (*ReportGateVerifier)(nil)
+
+func NewReportGateVerifier(redisClient *redis.Client, reportGateRepo *repo.ReportGate, conf *config.Config) *ReportGateVerifier {
+	v := &ReportGateVerifier{
+		Redis:          redisClient,
+		ReportGateRepo: reportGateRepo,
+		AllowlistOnly:  conf.ReportGateAllowlistOnly,
+	}
+	v.gates = snapshot.New("report_gates", reportGatesRefreshInterval, v.loadGates)
+	return v
+}
+
+func (v *ReportGateVerifier) Name() string {
+	return "report_gate"
+}
+
+// Verify down-weights the report if it matches any gate. Reports matching rejecting gates are down-weighted
+// as well, as they could only reach here if the gate has been created after submission.
+func (v *ReportGateVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	action, gate := v.Check(ctx, reportTask.Source, reportTask.Version)
+	if action == constant.ReportGateActionAllow {
+		return nil
+	}
+
+	message := "source or version is not allowed"
+	if gate != nil {
+		message = gate.Reason
+	}
+	return &Rejection{
+		Reliability: constant.ViolationReliabilityReportGate,
+		Message:     message,
+	}
+}
+
+// Check returns the action taken on reports from source with version, and the gate taking it.
+// See reportutil.CheckGates.
+func (v *ReportGateVerifier) Check(ctx context.Context, source, version string) (action string, gate *model.ReportGate) {
+	return reportutil.CheckGates(v.gates.Get(), source, version, v.AllowlistOnly)
+}
+
+// MinimumVersion returns the minimum version of source accepted. See reportutil.MinimumVersion.
+func (v *ReportGateVerifier) MinimumVersion(ctx context.Context, source string) string {
+	return reportutil.MinimumVersion(v.gates.Get(), source)
+}
+
+// SetGates makes gates take effect on this instance right away, e.g. after gates have been changed by it.
+func (v *ReportGateVerifier) SetGates(gates []*model.ReportGate) {
+	v.gates.Set(gates)
+}
+
+func (v *ReportGateVerifier) loadGates(ctx context.Context) ([]*model.ReportGate, error) {
+	return snapshot.LoadPublished(ctx, v.Redis, constant.ReportGatesKey, v.ReportGateRepo.GetReportGates)
+}
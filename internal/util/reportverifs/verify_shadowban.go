@@ -0,0 +1,127 @@
+package reportverifs
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+)
+
+// shadowBansRefreshInterval is how often the ban list is reloaded from Redis.
+const shadowBansRefreshInterval = time.Second * 10
+
+type shadowBanList struct {
+	accountIds map[int]struct{}
+	ipNets     []*net.IPNet
+}
+
+type ShadowBanVerifier struct {
+	Redis *redis.Client
+
+	mu          sync.Mutex
+	bans        *shadowBanList
+	refreshedAt time.Time
+}
+
+// ensure ShadowBanVerifier conforms to Verifier
+var _ Verifier = (*ShadowBanVerifier)(nil)
+
+func NewShadowBanVerifier(redisClient *redis.Client) *ShadowBanVerifier {
+	return &ShadowBanVerifier{
+		Redis: redisClient,
+	}
+}
+
+func (s *ShadowBanVerifier) Name() string {
+	return "shadow_ban"
+}
+
+// Verify marks reports from shadow banned accounts or IP ranges. The rejection is never disclosed to the
+// submitter; see Violations.Disclosable.
+func (s *ShadowBanVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	bans := s.getBans(ctx)
+
+	if _, ok := bans.accountIds[reportTask.AccountID]; ok && reportTask.AccountID != 0 {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityShadowBan,
+			Message:     "account is shadow banned",
+		}
+	}
+	if ip := net.ParseIP(reportTask.IP); ip != nil {
+		for _, ipNet := range bans.ipNets {
+			if ipNet.Contains(ip) {
+				return &Rejection{
+					Reliability: constant.ViolationReliabilityShadowBan,
+					Message:     "ip is shadow banned by range " + ipNet.String(),
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// getBans returns the cached ban list, which is reloaded every shadowBansRefreshInterval. The stale list is kept
+// when reloading fails.
+func (s *ShadowBanVerifier) getBans(ctx context.Context) *shadowBanList {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.bans != nil && time.Since(s.refreshedAt) < shadowBansRefreshInterval {
+		return s.bans
+	}
+
+	bans, err := s.loadBans(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("failed to load shadow bans")
+		if s.bans == nil {
+			return &shadowBanList{}
+		}
+		return s.bans
+	}
+
+	s.bans = bans
+	s.refreshedAt = time.Now()
+	return s.bans
+}
+
+func (s *ShadowBanVerifier) loadBans(ctx context.Context) (*shadowBanList, error) {
+	list := &shadowBanList{accountIds: map[int]struct{}{}}
+
+	bansJSON, err := s.Redis.Get(ctx, constant.ShadowBansKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return list, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var bans []*model.ShadowBan
+	if err := json.Unmarshal(bansJSON, &bans); err != nil {
+		return nil, errors.Wrap(err, "invalid shadow bans")
+	}
+
+	now := time.Now()
+	for _, ban := range bans {
+		if ban.ExpiresAt != nil && ban.ExpiresAt.Before(now) {
+			continue
+		}
+		if ban.AccountID.Valid {
+			list.accountIds[int(ban.AccountID.Int64)] = struct{}{}
+		}
+		if ban.IPRange.Valid {
+			if _, ipNet, err := net.ParseCIDR(ban.IPRange.String); err == nil {
+				list.ipNets = append(list.ipNets, ipNet)
+			}
+		}
+	}
+	return list, nil
+}
@@ -0,0 +1,195 @@
+package reportverifs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/pkg/errors"
+
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+	"github.com/penguin-statistics/backend-next/internal/repo"
+)
+
+var ErrSubmissionTooFrequent = errors.New("reports are submitted too frequently")
+
+// VelocityThreshold limits how many report tasks could be submitted within a sliding window. Zero limits are
+// not enforced.
+type VelocityThreshold struct {
+	WindowSeconds int `json:"windowSeconds"`
+	MaxPerIP      int `json:"maxPerIp"`
+	MaxPerAccount int `json:"maxPerAccount"`
+}
+
+// DefaultVelocityThresholds is used when constant.ReportVelocityPropertyKey is not configured. Keys are report
+// sources, and thresholds under key "default" apply to sources not listed.
+var DefaultVelocityThresholds = map[string]*VelocityThreshold{
+	// manual sources are expected to be slow, as reports are typed in by hand
+	constant.FrontendV2:         {WindowSeconds: 60, MaxPerIP: 30, MaxPerAccount: 15},
+	constant.FrontendV1:         {WindowSeconds: 60, MaxPerIP: 30, MaxPerAccount: 15},
+	constant.FrontendV1Internal: {WindowSeconds: 60, MaxPerIP: 30, MaxPerAccount: 15},
+	// MeoAssistant submits a report after every run, which takes at least several seconds even for the
+	// shortest stages
+	"MeoAssistant": {WindowSeconds: 60, MaxPerIP: 120, MaxPerAccount: 20},
+	"default":      {WindowSeconds: 60, MaxPerIP: 120, MaxPerAccount: 30},
+}
+
+// velocityTrustedScore is the trust score above which accounts are fast-tracked, with thresholds scaled up by
+// velocityTrustedWeight instead. They are still limited, so that a trusted account taken over could not flood reports.
+const (
+	velocityTrustedScore  = 0.95
+	velocityTrustedWeight = 5.0
+)
+
+type VelocityVerifier struct {
+	Redis                 *redis.Client
+	PropertyRepo          *repo.Property
+	AccountTrustScoreRepo *repo.AccountTrustScore
+}
+
+// ensure VelocityVerifier conforms to Verifier
+var _ Verifier = (*VelocityVerifier)(nil)
+
+func NewVelocityVerifier(redisClient *redis.Client, propertyRepo *repo.Property, accountTrustScoreRepo *repo.AccountTrustScore) *VelocityVerifier {
+	return &VelocityVerifier{
+		Redis:                 redisClient,
+		PropertyRepo:          propertyRepo,
+		AccountTrustScoreRepo: accountTrustScoreRepo,
+	}
+}
+
+func (v *VelocityVerifier) Name() string {
+	return "velocity"
+}
+
+// Verify records the report task into the per-IP and per-account sliding windows, and lowers the reliability of
+// reports whose submitter has exceeded the threshold of the source within the window. Thresholds are scaled up
+// by the trust score of the account, see velocityWeight.
+func (v *VelocityVerifier) Verify(ctx context.Context, report *types.ReportTaskSingleReport, reportTask *types.ReportTask) *Rejection {
+	weight := 1.0
+	if trustScore, err := v.AccountTrustScoreRepo.GetAccountTrustScoreByAccountId(ctx, reportTask.AccountID); err == nil {
+		weight = velocityWeight(trustScore.Score)
+	}
+
+	threshold, err := v.getThreshold(ctx, reportTask.Source)
+	if err != nil {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityVelocity,
+			Message:     err.Error(),
+		}
+	}
+	if threshold == nil || threshold.WindowSeconds <= 0 {
+		return nil
+	}
+	window := time.Duration(threshold.WindowSeconds) * time.Second
+
+	var errs []error
+	if threshold.MaxPerIP > 0 && reportTask.IP != "" {
+		count, err := v.record(ctx, constant.ReportVelocityIPKeyPrefix+reportTask.IP, reportTask, window)
+		if err != nil {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityVelocity,
+				Message:     err.Error(),
+			}
+		}
+		if maximum := int64(float64(threshold.MaxPerIP) * weight); count > maximum {
+			errs = append(errs, errors.Wrap(ErrSubmissionTooFrequent, fmt.Sprintf("ip: expected at most %d within %s, but got %d", maximum, window, count)))
+		}
+	}
+	if threshold.MaxPerAccount > 0 && reportTask.AccountID != 0 {
+		count, err := v.record(ctx, constant.ReportVelocityAccountKeyPrefix+strconv.Itoa(reportTask.AccountID), reportTask, window)
+		if err != nil {
+			return &Rejection{
+				Reliability: constant.ViolationReliabilityVelocity,
+				Message:     err.Error(),
+			}
+		}
+		if maximum := int64(float64(threshold.MaxPerAccount) * weight); count > maximum {
+			errs = append(errs, errors.Wrap(ErrSubmissionTooFrequent, fmt.Sprintf("account: expected at most %d within %s, but got %d", maximum, window, count)))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &Rejection{
+			Reliability: constant.ViolationReliabilityVelocity,
+			Message:     fmt.Sprintf("%v", errs),
+		}
+	}
+
+	return nil
+}
+
+// velocityWeight returns the factor thresholds are scaled up by for an account of trustScore: 1 plus the trust
+// score, or velocityTrustedWeight for accounts trusted enough.
+func velocityWeight(trustScore float64) float64 {
+	if trustScore >= velocityTrustedScore {
+		return velocityTrustedWeight
+	}
+	return 1 + trustScore
+}
+
+// record adds the report task into the sliding window stored at key, and returns the number of report tasks
+// within the window. Tasks are keyed by their id, so that reports of the same task, or a task redelivered, are
+// only counted once. Dry runs count the task without adding it.
+func (v *VelocityVerifier) record(ctx context.Context, key string, reportTask *types.ReportTask, window time.Duration) (int64, error) {
+	createdAt := time.UnixMicro(reportTask.CreatedAt)
+	if reportTask.CreatedAt == 0 {
+		createdAt = time.Now()
+	}
+	if IsDryRun(ctx) {
+		return v.count(ctx, key, reportTask.TaskID, createdAt, window)
+	}
+
+	pipe := v.Redis.TxPipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{
+		Score:  float64(createdAt.UnixMicro()),
+		Member: reportTask.TaskID,
+	})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(createdAt.Add(-window).UnixMicro(), 10))
+	count := pipe.ZCount(ctx, key, strconv.FormatInt(createdAt.Add(-window).UnixMicro(), 10), strconv.FormatInt(createdAt.UnixMicro(), 10))
+	pipe.Expire(ctx, key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, errors.Wrap(err, "failed to record submission velocity")
+	}
+	return count.Val(), nil
+}
+
+// count returns the number of report tasks within the window stored at key, including the one of taskId created
+// at createdAt, as if it has been recorded.
+func (v *VelocityVerifier) count(ctx context.Context, key string, taskId string, createdAt time.Time, window time.Duration) (int64, error) {
+	pipe := v.Redis.Pipeline()
+	count := pipe.ZCount(ctx, key, strconv.FormatInt(createdAt.Add(-window).UnixMicro(), 10), strconv.FormatInt(createdAt.UnixMicro(), 10))
+	recorded := pipe.ZScore(ctx, key, taskId)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return 0, errors.Wrap(err, "failed to count submission velocity")
+	}
+	if errors.Is(recorded.Err(), redis.Nil) {
+		return count.Val() + 1, nil
+	}
+	return count.Val(), nil
+}
+
+// getThreshold returns the threshold of source, falling back to the one under key "default".
+func (v *VelocityVerifier) getThreshold(ctx context.Context, source string) (*VelocityThreshold, error) {
+	thresholds := DefaultVelocityThresholds
+
+	property, err := v.PropertyRepo.GetPropertyByKey(ctx, constant.ReportVelocityPropertyKey)
+	if err != nil && !errors.Is(err, pgerr.ErrNotFound) {
+		return nil, err
+	} else if err == nil {
+		thresholds = map[string]*VelocityThreshold{}
+		if err := json.Unmarshal([]byte(property.Value), &thresholds); err != nil {
+			return nil, errors.Wrap(err, "invalid report velocity property")
+		}
+	}
+
+	if threshold, ok := thresholds[source]; ok {
+		return threshold, nil
+	}
+	return thresholds["default"], nil
+}
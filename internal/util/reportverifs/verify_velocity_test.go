@@ -0,0 +1,21 @@
+package reportverifs
+
+import "testing"
+
+func TestVelocityWeight(t *testing.T) {
+	tests := []struct {
+		trustScore float64
+		expected   float64
+	}{
+		{0, 1},
+		{0.5, 1.5},
+		{0.94, 1.94},
+		{0.95, velocityTrustedWeight},
+		{1, velocityTrustedWeight},
+	}
+	for _, test := range tests {
+		if got := velocityWeight(test.trustScore); got != test.expected {
+			t.Errorf("velocityWeight(%v): expected %v, got %v", test.trustScore, test.expected, got)
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package reportverifs
 
+import "github.com/penguin-statistics/backend-next/internal/constant"
+
 type Violations map[int]*Violation
 
 func (v Violations) Reliability(index int) int {
@@ -18,4 +20,26 @@ type Violation struct {
 type Rejection struct {
 	Reliability int    `json:"reliability"`
 	Message     string `json:"message"`
+
+	// Err is set when the report could not be verified for a transient reason, such as the database being
+	// unavailable, in which case the report task shall be retried rather than the report being rejected.
+	Err error `json:"-"`
+}
+
+// unverifiable returns a rejection of a report which could not be verified due to err, a transient error.
+func unverifiable(err error) *Rejection {
+	return &Rejection{Err: err}
+}
+
+// Disclosable returns the violations which could be disclosed to the submitter. Violations of shadow bans are
+// excluded, so that banned submitters could not tell they are banned.
+func (v Violations) Disclosable() Violations {
+	disclosable := make(Violations, len(v))
+	for index, violation := range v {
+		if violation.Reliability == constant.ViolationReliabilityShadowBan {
+			continue
+		}
+		disclosable[index] = violation
+	}
+	return disclosable
 }
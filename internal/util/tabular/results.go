@@ -0,0 +1,114 @@
+package tabular
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/samber/lo"
+	"gopkg.in/guregu/null.v3"
+
+	modelv2 "github.com/penguin-statistics/backend-next/internal/model/v2"
+)
+
+// SendDropMatrix streams result as a table with a row per drop matrix element.
+func SendDropMatrix(ctx *fiber.Ctx, format string, filename string, result *modelv2.DropMatrixQueryResult) error {
+	header := []string{"stageId", "itemId", "times", "quantity", "stdDev", "start", "end"}
+	return Send(ctx, format, filename, header, func(write WriteFunc) error {
+		for _, el := range result.Matrix {
+			err := write(
+				el.StageID,
+				el.ItemID,
+				strconv.Itoa(el.Times),
+				strconv.Itoa(el.Quantity),
+				formatFloat(el.StdDev),
+				strconv.FormatInt(el.StartTime, 10),
+				formatNullInt(el.EndTime),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SendPatternMatrix streams result as a table with a row per pattern matrix element. Drops of a pattern are
+// formatted as `itemId:quantity` pairs separated by spaces.
+func SendPatternMatrix(ctx *fiber.Ctx, format string, filename string, result *modelv2.PatternMatrixQueryResult) error {
+	header := []string{"stageId", "pattern", "times", "quantity", "lower", "upper", "start", "end"}
+	return Send(ctx, format, filename, header, func(write WriteFunc) error {
+		for _, el := range result.PatternMatrix {
+			drops := make([]string, 0)
+			if el.Pattern != nil {
+				for _, drop := range el.Pattern.Drops {
+					drops = append(drops, drop.ItemID+":"+strconv.Itoa(drop.Quantity))
+				}
+			}
+			err := write(
+				el.StageID,
+				strings.Join(drops, " "),
+				strconv.Itoa(el.Times),
+				strconv.Itoa(el.Quantity),
+				formatFloat(el.Lower),
+				formatFloat(el.Upper),
+				strconv.FormatInt(el.StartTime, 10),
+				formatNullInt(el.EndTime),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// SendTrend streams result as a table with a row per interval of each item of each stage, where intervals are of
+// intervalLength. Rows are ordered by stage, item and start of the interval.
+func SendTrend(ctx *fiber.Ctx, format string, filename string, result *modelv2.TrendQueryResult, intervalLength time.Duration) error {
+	header := []string{"stageId", "itemId", "start", "end", "times", "quantity"}
+	return Send(ctx, format, filename, header, func(write WriteFunc) error {
+		stageIds := lo.Keys(result.Trend)
+		sort.Strings(stageIds)
+		for _, stageId := range stageIds {
+			stageTrend := result.Trend[stageId]
+			itemIds := lo.Keys(stageTrend.Results)
+			sort.Strings(itemIds)
+			for _, itemId := range itemIds {
+				itemTrend := stageTrend.Results[itemId]
+				for i := range itemTrend.Times {
+					start := stageTrend.StartTime + intervalLength.Milliseconds()*int64(i)
+					quantity := 0
+					if i < len(itemTrend.Quantity) {
+						quantity = itemTrend.Quantity[i]
+					}
+					err := write(
+						stageId,
+						itemId,
+						strconv.FormatInt(start, 10),
+						strconv.FormatInt(start+intervalLength.Milliseconds(), 10),
+						strconv.Itoa(itemTrend.Times[i]),
+						strconv.Itoa(quantity),
+					)
+					if err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatNullInt(i null.Int) string {
+	if !i.Valid {
+		return ""
+	}
+	return strconv.FormatInt(i.Int64, 10)
+}
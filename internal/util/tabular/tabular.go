@@ -0,0 +1,82 @@
+package tabular
+
+import (
+	"bufio"
+	"encoding/csv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/penguin-statistics/backend-next/internal/pkg/pgerr"
+)
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatTSV  = "tsv"
+
+	MIMETextCSV = "text/csv"
+	MIMETextTSV = "text/tab-separated-values"
+)
+
+// WriteFunc writes a row of a table.
+type WriteFunc func(row ...string) error
+
+// Negotiate returns the format the response of ctx shall be in, which is either FormatJSON, FormatCSV or FormatTSV.
+// The `format` query takes precedence over the Accept header.
+func Negotiate(ctx *fiber.Ctx) (string, error) {
+	ctx.Vary(fiber.HeaderAccept)
+
+	if format := strings.ToLower(ctx.Query("format")); format != "" {
+		switch format {
+		case FormatJSON, FormatCSV, FormatTSV:
+			return format, nil
+		default:
+			return "", pgerr.ErrInvalidReq.Msg("invalid format `%s`: must be one of `json`, `csv` and `tsv`", format)
+		}
+	}
+
+	switch ctx.Accepts(fiber.MIMEApplicationJSON, MIMETextCSV, MIMETextTSV) {
+	case MIMETextCSV:
+		return FormatCSV, nil
+	case MIMETextTSV:
+		return FormatTSV, nil
+	default:
+		return FormatJSON, nil
+	}
+}
+
+// Send streams a table as the response of ctx in format, which is either FormatCSV or FormatTSV, as an attachment
+// named filename with the extension of format. rows is called once the response starts streaming, and shall write
+// rows one by one, so that the whole table is never held in memory.
+func Send(ctx *fiber.Ctx, format string, filename string, header []string, rows func(write WriteFunc) error) error {
+	contentType, comma := MIMETextCSV, ','
+	if format == FormatTSV {
+		contentType, comma = MIMETextTSV, '\t'
+	}
+	ctx.Set(fiber.HeaderContentType, contentType+"; charset=utf-8")
+	ctx.Attachment(filename + "." + format)
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		writer := csv.NewWriter(w)
+		writer.Comma = comma
+
+		write := func(row ...string) error {
+			return writer.Write(row)
+		}
+		err := write(header...)
+		if err == nil {
+			err = rows(write)
+		}
+		writer.Flush()
+		if err == nil {
+			err = writer.Error()
+		}
+		if err != nil {
+			// the status has been sent already, so the error could only be logged
+			log.Warn().Err(err).Str("filename", filename).Msg("failed to stream table")
+		}
+	})
+	return nil
+}
@@ -0,0 +1,44 @@
+package tabular
+
+import (
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		query    string
+		accept   string
+		expected string
+		wantErr  bool
+	}{
+		{"", "", FormatJSON, false},
+		{"", fiber.MIMEApplicationJSON, FormatJSON, false},
+		{"", MIMETextCSV, FormatCSV, false},
+		{"", MIMETextTSV, FormatTSV, false},
+		{"", "text/html", FormatJSON, false},
+		{"", fiber.MIMEApplicationJSON + ", " + MIMETextCSV, FormatJSON, false},
+		{"TSV", MIMETextCSV, FormatTSV, false},
+		{"json", MIMETextCSV, FormatJSON, false},
+		{"xml", "", "", true},
+	}
+
+	app := fiber.New()
+	for _, test := range tests {
+		ctx := app.AcquireCtx(&fasthttp.RequestCtx{})
+		ctx.Request().SetRequestURI("/result/matrix?format=" + test.query)
+		if test.accept != "" {
+			ctx.Request().Header.Set(fiber.HeaderAccept, test.accept)
+		}
+		format, err := Negotiate(ctx)
+		if (err != nil) != test.wantErr {
+			t.Errorf("Negotiate(format=%q, Accept=%q): expected error %v, got %v", test.query, test.accept, test.wantErr, err)
+		}
+		if format != test.expected {
+			t.Errorf("Negotiate(format=%q, Accept=%q): expected %q, got %q", test.query, test.accept, test.expected, format)
+		}
+		app.ReleaseCtx(ctx)
+	}
+}
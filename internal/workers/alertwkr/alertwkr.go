@@ -0,0 +1,49 @@
+package alertwkr
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+type WorkerDeps struct {
+	fx.In
+	Notifier  *service.Notifier
+	Scheduler *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules checks of ops conditions alerts are pushed for, every config.Config AlertCheckInterval by
+// default, when config.Config WorkerEnabled is true. The report backlog is checked by the report worker instead, as
+// it polls the state of the report consumer already.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobAlert,
+		Spec:       func(conf *config.Config) string { return "@every " + conf.AlertCheckInterval.String() },
+		LeaderOnly: true,
+		Run:        w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	if err := w.Notifier.CheckRejectionSpikes(ctx); err != nil {
+		log.Error().Err(err).Str("service", "worker:alert").Msg("failed to check spikes of rejected reports")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package anomalywkr
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// detectTimeout is the timeout for a single run of anomaly detection of a server
+const detectTimeout = time.Minute * 5
+
+type WorkerDeps struct {
+	fx.In
+	AnomalyService *service.Anomaly
+	Scheduler      *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules drop rate anomaly detection, every config.Config AnomalyWorkerInterval by default, when
+// config.Config WorkerEnabled is true.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name: constant.JobAnomaly,
+		Spec: func(conf *config.Config) string { return "@every " + conf.AnomalyWorkerInterval.String() },
+		Run:  w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	logger := log.With().Str("service", "worker:anomaly").Logger()
+
+	var lastErr error
+	for _, server := range constant.Servers {
+		func() {
+			runCtx, cancel := context.WithTimeout(ctx, detectTimeout)
+			defer cancel()
+
+			anomalies, err := w.AnomalyService.DetectAnomalies(runCtx, server)
+			if err != nil {
+				logger.Error().Err(err).Str("server", server).Msg("failed to detect drop rate anomalies")
+				lastErr = err
+				return
+			}
+			if len(anomalies) > 0 {
+				logger.Warn().Str("server", server).Int("anomalies", len(anomalies)).Msg("drop rate anomalies flagged")
+			}
+		}()
+	}
+	return lastErr
+}
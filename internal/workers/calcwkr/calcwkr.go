@@ -13,15 +13,22 @@ import (
 
 	"github.com/penguin-statistics/backend-next/internal/config"
 	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbguard"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbrouter"
 	"github.com/penguin-statistics/backend-next/internal/service"
 )
 
 type WorkerDeps struct {
 	fx.In
+	Scheduler            *service.Scheduler
+	AggregateViewService *service.AggregateView
 	DropMatrixService    *service.DropMatrix
 	PatternMatrixService *service.PatternMatrix
 	TrendService         *service.Trend
 	SiteStatsService     *service.SiteStats
+	AccountTrustService  *service.AccountTrust
+	WebhookService       *service.Webhook
 }
 
 type Worker struct {
@@ -31,139 +38,167 @@ type Worker struct {
 	// sep describes the separation time in-between different jobs
 	sep time.Duration
 
-	// interval describes the interval in-between different batches of job running
-	interval time.Duration
-
-	// timeout describes the timeout for the worker
-	timeout time.Duration
-
 	// heartbeatURL allows the worker to ping a specified URL on succeed, to ensure worker is alive
 	heartbeatURL string
 
+	// incremental describes whether the drop matrix is refreshed incrementally
+	incremental bool
+
+	// fullRefreshHour describes the hour of day (UTC) to fully refresh the drop matrix in incremental mode
+	fullRefreshHour int
+
+	// trendGranularities describes the granularities to calculate saved trends in
+	trendGranularities []string
+
+	// sourceCategories describes the source categories to calculate matrices for
+	sourceCategories []string
+
 	WorkerDeps
 }
 
-func Start(conf *config.Config, deps WorkerDeps) {
-	if conf.WorkerEnabled {
-		if conf.WorkerHeartbeatURL == "" {
-			log.Info().
-				Msg("No heartbeat URL found. The worker will NOT send a heartbeat when it is finished.")
-		}
-		(&Worker{
-			sep:          conf.WorkerSeparation,
-			interval:     conf.WorkerInterval,
-			timeout:      conf.WorkerTimeout,
-			heartbeatURL: conf.WorkerHeartbeatURL,
-			WorkerDeps:   deps,
-		}).do(conf.MatrixWorkerSourceCategories)
-	} else {
+// Start schedules batches of calculations, every config.Config WorkerInterval by default and as soon as the
+// application starts, when config.Config WorkerEnabled is true. Batches are only run on schedule by the leader.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled {
 		log.Info().Msg("worker is disabled due to configuration")
+		return nil
 	}
+	if conf.WorkerHeartbeatURL == "" {
+		log.Info().
+			Msg("No heartbeat URL found. The worker will NOT send a heartbeat when it is finished.")
+	}
+
+	w := &Worker{
+		sep:                conf.WorkerSeparation,
+		heartbeatURL:       conf.WorkerHeartbeatURL,
+		incremental:        conf.MatrixWorkerIncremental,
+		fullRefreshHour:    conf.MatrixWorkerFullRefreshHour,
+		trendGranularities: conf.TrendWorkerGranularities,
+		sourceCategories:   conf.MatrixWorkerSourceCategories,
+		WorkerDeps:         deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobCalc,
+		Spec:       func(conf *config.Config) string { return "@every " + conf.WorkerInterval.String() },
+		Timeout:    conf.WorkerTimeout,
+		LeaderOnly: true,
+		RunOnStart: true,
+		Run:        w.do,
+	})
 }
 
-func (w *Worker) do(sourceCategories []string) {
-	logger := log.With().Str("service", "worker:calculator").Logger()
-	parentCtx := logger.WithContext(context.Background())
+func (w *Worker) do(ctx context.Context) error {
+	logger := log.With().Str("service", "worker:calculator").Int("count", w.count).Logger()
+	// matrix recalculations are bound by the timeout of the batch instead of the timeout of a single query
+	// refreshes read from the primary, as those following the watermark would otherwise miss reports the replica
+	// lags behind on for good
+	ctx = dbguard.WithQueryTimeout(dbrouter.WithPrimary(logger.WithContext(ctx)), 0)
+	defer func() {
+		w.count++
+	}()
 
-	go func() {
-		time.Sleep(time.Second * 3)
-
-		for {
-			ctx, cancel := context.WithTimeout(parentCtx, w.timeout)
-			log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
-				return c.Int("count", w.count)
-			})
-			log.Ctx(ctx).Info().Msg("worker batch started")
-
-			func() {
-				defer func() {
-					w.count++
-					time.Sleep(w.interval)
-					cancel()
-				}()
-
-				errChan := make(chan error)
-				go func() {
-					for _, server := range constant.Servers {
-						// DropMatrixService
-						log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
-							return c.Str("server", server).Str("service", "worker:calculator:dropMatrix")
-						})
-
-						log.Ctx(ctx).Info().Msg("worker microtask started calculating")
-						if err := w.DropMatrixService.RefreshAllDropMatrixElements(ctx, server, sourceCategories); err != nil {
-							log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
-							errChan <- err
-							return
-						}
-						log.Ctx(ctx).Info().Msg("worker microtask finished")
-						time.Sleep(w.sep)
-
-						// PatternMatrixService
-						log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
-							return c.Str("service", "worker:calculator:patternMatrix")
-						})
-						log.Ctx(ctx).Info().Msg("worker microtask started calculating")
-						if err := w.PatternMatrixService.RefreshAllPatternMatrixElements(ctx, server, sourceCategories); err != nil {
-							log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
-							errChan <- err
-							return
-						}
-						log.Ctx(ctx).Info().Msg("worker microtask finished")
-						time.Sleep(w.sep)
-
-						// TrendService
-						log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
-							return c.Str("service", "worker:calculator:trend")
-						})
-						log.Ctx(ctx).Info().Msg("worker microtask started calculating")
-						if err := w.TrendService.RefreshTrendElements(ctx, server, sourceCategories); err != nil {
-							log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
-							errChan <- err
-							return
-						}
-						log.Ctx(ctx).Info().Msg("worker microtask finished")
-						time.Sleep(w.sep)
-
-						// SiteStatsService
-						log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
-							return c.Str("service", "worker:calculator:siteStats")
-						})
-						log.Ctx(ctx).Info().Msg("worker microtask started calculating")
-						if _, err := w.SiteStatsService.RefreshShimSiteStats(ctx, server); err != nil {
-							log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
-							errChan <- err
-							return
-						}
-						log.Ctx(ctx).Info().Msg("worker microtask finished")
-						time.Sleep(w.sep)
-					}
-					errChan <- nil
-				}()
-
-				select {
-				case <-ctx.Done():
-					log.Ctx(ctx).Error().Err(ctx.Err()).Msg("worker timeout reached")
-					return
-				case err := <-errChan:
-					if err != nil {
-						log.Ctx(ctx).Error().Err(err).Msg("worker unexpected error occurred while running batch")
-						return
-					}
-				}
-
-				log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
-					return c.Str("service", "worker:calculator")
-				})
-
-				log.Ctx(ctx).Info().Msg("worker batch finished")
-
-				go func() {
-					w.heartbeat()
-				}()
-			}()
+	log.Ctx(ctx).Info().Msg("worker batch started")
+
+	// AggregateViewService
+	if w.AggregateViewService.Enabled() {
+		log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("service", "worker:calculator:aggregateViews")
+		})
+		log.Ctx(ctx).Info().Msg("worker microtask started calculating")
+		if err := w.AggregateViewService.RefreshViews(ctx); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
+			return err
+		}
+		log.Ctx(ctx).Info().Msg("worker microtask finished")
+		time.Sleep(w.sep)
+	}
+
+	for _, server := range constant.Servers {
+		// DropMatrixService
+		log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("server", server).Str("service", "worker:calculator:dropMatrix")
+		})
+
+		log.Ctx(ctx).Info().Msg("worker microtask started calculating")
+		var err error
+		// the drop matrix is calculated from the aggregate views, which are cheap to read in full
+		refreshed := true
+		if w.incremental && !w.AggregateViewService.Enabled() {
+			refreshed, err = w.DropMatrixService.RefreshDropMatrixElements(ctx, server, w.sourceCategories, w.fullRefreshHour)
+		} else {
+			err = w.DropMatrixService.RefreshAllDropMatrixElements(ctx, server, w.sourceCategories)
 		}
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
+			return err
+		}
+		log.Ctx(ctx).Info().Bool("refreshed", refreshed).Msg("worker microtask finished")
+		// subscribers are only notified of servers whose matrix has been touched by the batch
+		if refreshed {
+			w.WebhookService.Publish(constant.WebhookEventMatrixRefreshed, &model.WebhookMatrixRefreshed{Server: server})
+		}
+		time.Sleep(w.sep)
+
+		// PatternMatrixService
+		log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("service", "worker:calculator:patternMatrix")
+		})
+		log.Ctx(ctx).Info().Msg("worker microtask started calculating")
+		if err := w.PatternMatrixService.RefreshAllPatternMatrixElements(ctx, server, w.sourceCategories); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
+			return err
+		}
+		log.Ctx(ctx).Info().Msg("worker microtask finished")
+		time.Sleep(w.sep)
+
+		// TrendService
+		log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("service", "worker:calculator:trend")
+		})
+		for _, granularity := range w.trendGranularities {
+			log.Ctx(ctx).Info().Str("granularity", granularity).Msg("worker microtask started calculating")
+			if err := w.TrendService.RefreshTrendElements(ctx, server, granularity, w.sourceCategories); err != nil {
+				log.Ctx(ctx).Error().Err(err).Str("granularity", granularity).Msg("worker microtask failed")
+				return err
+			}
+			log.Ctx(ctx).Info().Str("granularity", granularity).Msg("worker microtask finished")
+			time.Sleep(w.sep)
+		}
+
+		// SiteStatsService
+		log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+			return c.Str("service", "worker:calculator:siteStats")
+		})
+		log.Ctx(ctx).Info().Msg("worker microtask started calculating")
+		if _, err := w.SiteStatsService.RefreshShimSiteStats(ctx, server); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
+			return err
+		}
+		log.Ctx(ctx).Info().Msg("worker microtask finished")
+		time.Sleep(w.sep)
+	}
+
+	// AccountTrustService
+	log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Str("service", "worker:calculator:accountTrust")
+	})
+	log.Ctx(ctx).Info().Msg("worker microtask started calculating")
+	refreshed, err := w.AccountTrustService.RefreshDirtyAccountTrustScores(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("worker microtask failed")
+		return err
+	}
+	log.Ctx(ctx).Info().Int("refreshed", refreshed).Msg("worker microtask finished")
+
+	log.Ctx(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Str("service", "worker:calculator")
+	})
+	log.Ctx(ctx).Info().Msg("worker batch finished")
+
+	go func() {
+		w.heartbeat()
 	}()
+	return nil
 }
 
 func (w *Worker) heartbeat() {
@@ -0,0 +1,63 @@
+package gamedatawkr
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// syncTimeout is the timeout of a single sync of game data
+const syncTimeout = time.Minute * 5
+
+type WorkerDeps struct {
+	fx.In
+	GameDataSyncService *service.GameDataSync
+	Scheduler           *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules syncs of game data from the external gamedata repository, every config.Config
+// GameDataSyncInterval by default, when config.Config WorkerEnabled is true and config.Config GameDataSyncInterval
+// is positive. Changes found are staged for approval, rather than applied.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled || conf.GameDataSyncInterval <= 0 {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:    constant.JobGameDataSync,
+		Spec:    func(conf *config.Config) string { return "@every " + conf.GameDataSyncInterval.String() },
+		Timeout: syncTimeout,
+		Run:     w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	logger := log.With().Str("service", "worker:gamedata").Logger()
+
+	staged, err := w.GameDataSyncService.Sync(ctx)
+	if errors.Is(err, service.ErrGameDataSyncInProgress) {
+		logger.Debug().Msg("game data is being synced by another instance")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if staged > 0 {
+		logger.Info().Int("staged", staged).Msg("staged game data changes for approval")
+	}
+	return nil
+}
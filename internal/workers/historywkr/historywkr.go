@@ -0,0 +1,63 @@
+package historywkr
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// historyTimeout is the timeout for snapshotting the drop matrices of all servers and pruning expired snapshots
+const historyTimeout = time.Minute * 10
+
+type WorkerDeps struct {
+	fx.In
+	DropMatrixHistoryService *service.DropMatrixHistory
+	Scheduler                *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules daily snapshots of drop matrices at 00:00 UTC, when config.Config WorkerEnabled is true. Expired
+// snapshots are pruned along the way.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobMatrixHistory,
+		Spec:       func(_ *config.Config) string { return "@daily" },
+		Timeout:    historyTimeout,
+		LeaderOnly: true,
+		Run:        w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	logger := log.With().Str("service", "worker:history").Logger()
+
+	var lastErr error
+	for _, server := range constant.Servers {
+		if err := w.DropMatrixHistoryService.TakeSnapshot(ctx, server); err != nil {
+			logger.Error().Err(err).Str("server", server).Msg("failed to snapshot drop matrix")
+			lastErr = err
+		}
+	}
+
+	if err := w.DropMatrixHistoryService.PruneSnapshots(ctx); err != nil {
+		logger.Error().Err(err).Msg("failed to prune drop matrix snapshots")
+		lastErr = err
+	}
+	return lastErr
+}
@@ -0,0 +1,49 @@
+package partitionwkr
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// partitionTimeout is the timeout for a single run of partition creation
+const partitionTimeout = time.Minute
+
+type WorkerDeps struct {
+	fx.In
+	DropReportPartitionService *service.DropReportPartition
+	Scheduler                  *service.Scheduler
+}
+
+type Worker struct {
+	// ahead is the number of monthly partitions created ahead of the current month
+	ahead int
+
+	WorkerDeps
+}
+
+// Start schedules partition creation, every config.Config DropReportPartitionInterval by default and as soon as the
+// application starts. Partition creation is idempotent, so it runs on every instance regardless of config.Config
+// WorkerEnabled, as reports would otherwise land in the default partition.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	w := &Worker{
+		ahead:      conf.DropReportPartitionsAhead,
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobPartition,
+		Spec:       func(conf *config.Config) string { return "@every " + conf.DropReportPartitionInterval.String() },
+		Timeout:    partitionTimeout,
+		RunOnStart: true,
+		Run:        w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	return w.DropReportPartitionService.EnsurePartitions(ctx, w.ahead)
+}
@@ -0,0 +1,50 @@
+package patternwkr
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// patternDedupTimeout is the timeout for a single run of pattern deduplication, including the recalculation of
+// pattern matrices of all servers
+const patternDedupTimeout = time.Hour
+
+type WorkerDeps struct {
+	fx.In
+	PatternDedupService *service.PatternDedup
+	Scheduler           *service.Scheduler
+}
+
+type Worker struct {
+	// sourceCategories are the source categories pattern matrices are recalculated for
+	sourceCategories []string
+
+	WorkerDeps
+}
+
+// Start registers pattern deduplication, which is a one-off migration and is never run on schedule unless a spec is
+// configured for it by config.Config SchedulerJobSpecs. It is run once triggered from the admin API instead.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	w := &Worker{
+		sourceCategories: conf.MatrixWorkerSourceCategories,
+		WorkerDeps:       deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobPatternDedup,
+		Spec:       func(_ *config.Config) string { return "" },
+		Timeout:    patternDedupTimeout,
+		LeaderOnly: true,
+		Run:        w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	_, err := w.PatternDedupService.Dedup(ctx, w.sourceCategories)
+	return err
+}
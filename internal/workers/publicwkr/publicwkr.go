@@ -0,0 +1,59 @@
+package publicwkr
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// publicTimeout is the timeout for rendering the public aggregates of all servers
+const publicTimeout = time.Minute * 5
+
+type WorkerDeps struct {
+	fx.In
+	PublicAggregateService *service.PublicAggregate
+	Scheduler              *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules renders of public aggregates, every config.Config PublicAggregateInterval by default and as soon
+// as the application starts, when config.Config WorkerEnabled is true.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobPublicAggregate,
+		Spec:       func(conf *config.Config) string { return "@every " + conf.PublicAggregateInterval.String() },
+		Timeout:    publicTimeout,
+		LeaderOnly: true,
+		RunOnStart: true,
+		Run:        w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	logger := log.With().Str("service", "worker:public").Logger()
+
+	var lastErr error
+	for _, server := range constant.Servers {
+		if _, err := w.PublicAggregateService.Render(ctx, server); err != nil {
+			logger.Error().Err(err).Str("server", server).Msg("failed to render public aggregate")
+			lastErr = err
+		}
+	}
+	return lastErr
+}
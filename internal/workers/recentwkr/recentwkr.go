@@ -0,0 +1,67 @@
+package recentwkr
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/pkg/dbguard"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// refreshTimeout is the timeout for a single recalculation of the recent drop matrix of a server
+const refreshTimeout = time.Minute * 5
+
+type WorkerDeps struct {
+	fx.In
+	DropMatrixService *service.DropMatrix
+	Scheduler         *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules recalculations of the recent drop matrix, every config.Config RecentMatrixWorkerInterval by
+// default, when config.Config WorkerEnabled is true, on its own schedule apart from the calculator worker, as the
+// recent drop matrix is meant to follow changes of drop rates closely.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled || conf.RecentMatrixWorkerInterval <= 0 {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobRecentMatrix,
+		Spec:       func(conf *config.Config) string { return "@every " + conf.RecentMatrixWorkerInterval.String() },
+		LeaderOnly: true,
+		Run:        w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	logger := log.With().Str("service", "worker:recentMatrix").Logger()
+
+	var lastErr error
+	for _, server := range constant.Servers {
+		func() {
+			// the recalculation is bound by refreshTimeout instead of the timeout of a single query
+			runCtx, cancel := context.WithTimeout(dbguard.WithQueryTimeout(ctx, 0), refreshTimeout)
+			defer cancel()
+
+			if err := w.DropMatrixService.RefreshRecentDropMatrix(runCtx, server); err != nil {
+				logger.Error().Err(err).Str("server", server).Msg("failed to refresh recent drop matrix")
+				lastErr = err
+				return
+			}
+			logger.Info().Str("server", server).Msg("recent drop matrix refreshed")
+		}()
+	}
+	return lastErr
+}
@@ -5,35 +5,59 @@ import (
 	"encoding/json"
 	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/nats-io/nats.go"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"go.uber.org/fx"
-	"gopkg.in/guregu/null.v3"
 
 	"github.com/penguin-statistics/backend-next/internal/config"
-	"github.com/penguin-statistics/backend-next/internal/model"
+	"github.com/penguin-statistics/backend-next/internal/constant"
 	"github.com/penguin-statistics/backend-next/internal/model/types"
+	"github.com/penguin-statistics/backend-next/internal/pkg/async"
 	"github.com/penguin-statistics/backend-next/internal/pkg/observability"
 	"github.com/penguin-statistics/backend-next/internal/service"
-	"github.com/penguin-statistics/backend-next/internal/util/reportutil"
+)
+
+const (
+	reportStream   = "penguin-reports"
+	reportConsumer = "penguin-reports"
+	reportSubjects = "REPORT.*"
+
+	reportAckWait = time.Second * 10
+	// reportDrainTimeout is the timeout of waiting for in-flight report tasks to settle on shutdown. A task is
+	// processed within reportAckWait, leaving the rest for settling it
+	reportDrainTimeout = reportAckWait * 2
+	// reportFlushTimeout is the timeout of flushing acks of settled report tasks to NATS on shutdown
+	reportFlushTimeout = time.Second * 5
+	// reportLagPollInterval is the interval in-between polls of the consumer state for lag metrics
+	reportLagPollInterval = time.Second * 15
 )
 
 type WorkerDeps struct {
 	fx.In
+	NatsConn       *nats.Conn
 	ReportServices *service.Report
+	Notifier       *service.Notifier
 }
 
 type Worker struct {
 	// count is the number of workers
 	count int
 
+	// maxInFlight is the maximum number of delivered but un-acked report tasks, shared among all instances
+	maxInFlight int
+
+	// inflight tracks report tasks being processed, so they could be settled before shutting down
+	inflight sync.WaitGroup
+
 	WorkerDeps
 }
 
-func Start(conf *config.Config, deps WorkerDeps) {
+func Start(conf *config.Config, deps WorkerDeps, lc fx.Lifecycle) {
 	ch := make(chan error)
 	// handle & dump errors from workers
 	go func() {
@@ -46,183 +70,322 @@ func Start(conf *config.Config, deps WorkerDeps) {
 	}()
 	// works like a consumer factory
 	reportWorkers := &Worker{
-		count:      0,
-		WorkerDeps: deps,
-	}
-	// spawn workers
-	// maybe we should specify the number of worker in config.Config ?
-	for i := 0; i < runtime.NumCPU(); i++ {
-		go func() {
-			err := reportWorkers.Consumer(context.Background(), ch)
-			if err != nil {
-				ch <- err
-			}
-		}()
-		// update current worker count
-		reportWorkers.count += 1
+		count:       conf.ReportWorkerConcurrency,
+		maxInFlight: conf.ReportWorkerMaxInFlight,
+		WorkerDeps:  deps,
 	}
-}
-
-func (w *Worker) Consumer(ctx context.Context, ch chan error) error {
-	msgChan := make(chan *nats.Msg, 16)
-
-	_, err := w.ReportServices.NatsJS.ChanQueueSubscribe("REPORT.*", "penguin-reports", msgChan, nats.AckWait(time.Second*10), nats.MaxAckPending(128))
-	if err != nil {
-		log.Err(err).Msg("failed to subscribe to REPORT.*")
-		return err
+	if reportWorkers.count <= 0 {
+		reportWorkers.count = runtime.NumCPU()
 	}
 
-	for {
-		select {
-		case msg := <-msgChan:
-			func() {
-				taskCtx, cancelTask := context.WithTimeout(ctx, time.Second*10)
-				inprogressInformer := time.AfterFunc(time.Second*5, func() {
-					err = msg.InProgress()
-					if err != nil {
-						log.Error().Err(err).Msg("failed to set msg InProgress")
-					}
-				})
-				defer func() {
-					inprogressInformer.Stop()
-					cancelTask()
-					if err := msg.Ack(); err != nil {
-						log.Error().Err(err).Msg("failed to ack")
-					}
-				}()
-
-				reportTask := &types.ReportTask{}
-				if err := json.Unmarshal(msg.Data, reportTask); err != nil {
-					ch <- err
-					return
-				}
+	ctx, cancel := context.WithCancel(context.Background())
+	var (
+		mu  sync.Mutex
+		sub *nats.Subscription
+	)
 
-				start := time.Now()
-				defer func() {
-					observability.ReportConsumeDuration.
-						WithLabelValues().
-						Observe(time.Since(start).Seconds())
-				}()
+	lc.Append(fx.Hook{
+		OnStart: func(_ context.Context) error {
+			// subscribe in background so an unavailable NATS server won't block the application from starting
+			go func() {
+				// reports of patterns not preloaded are still persisted, only with their patterns looked up
+				if err := reportWorkers.ReportServices.DropPatternService.PreloadDropPatterns(ctx); err != nil {
+					log.Warn().Err(err).Msg("failed to preload drop patterns")
+				}
 
-				err = w.consumeReport(taskCtx, reportTask)
+				s, err := reportWorkers.subscribe()
 				if err != nil {
-					log.Error().
-						Err(err).
-						Str("taskId", reportTask.TaskID).
-						Interface("reportTask", reportTask).
-						Msg("failed to consume report task")
 					ch <- err
 					return
 				}
+				mu.Lock()
+				sub = s
+				mu.Unlock()
+
+				go reportWorkers.pollLag(ctx, ch)
 
-				log.Info().
-					Str("taskId", reportTask.TaskID).
-					Dur("duration", time.Since(start)).
-					Msg("report task processed successfully")
+				// spawn workers
+				for i := 0; i < reportWorkers.count; i++ {
+					go func() {
+						err := reportWorkers.Consumer(ctx, s, ch)
+						if err != nil && !errors.Is(err, context.Canceled) {
+							ch <- err
+						}
+					}()
+				}
 			}()
-		case <-ctx.Done():
-			return ctx.Err()
-		}
-	}
-}
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			// stop fetching new report tasks, then wait for the ones in-flight to be settled
+			cancel()
+
+			err := <-async.Deadline(stopCtx, reportDrainTimeout, func() error {
+				reportWorkers.inflight.Wait()
+				return nil
+			})
+			if err != nil {
+				// tasks not settled in time are redelivered to other instances after AckWait
+				log.Warn().Err(err).Msg("timed out waiting for in-flight report tasks to settle")
+			}
 
-func (w *Worker) consumeReport(ctx context.Context, reportTask *types.ReportTask) error {
-	L := log.With().
-		Interface("task", reportTask).
-		Logger()
+			mu.Lock()
+			defer mu.Unlock()
+			if sub != nil {
+				// the durable consumer is not created by the subscription, so unsubscribing won't delete it
+				// and other instances keep consuming
+				if err := sub.Unsubscribe(); err != nil {
+					log.Warn().Err(err).Msg("failed to unsubscribe from report tasks")
+				}
+			}
 
-	L.Info().Msg("now processing new report task")
+			// naks and acks of dead letters are sent asynchronously, and would be lost if still buffered
+			// once the connection is closed
+			return reportWorkers.NatsConn.FlushTimeout(reportFlushTimeout)
+		},
+	})
+}
 
-	violations := w.ReportServices.ReportVerifier.Verify(ctx, reportTask)
-	if len(violations) > 0 {
-		L.Warn().
-			Interface("violations", violations).
-			Msg("report task verification failed on some or all reports")
+// subscribe ensures the durable pull consumer shared by all instances exists with the configuration of this
+// instance, and binds a subscription to it.
+func (w *Worker) subscribe() (*nats.Subscription, error) {
+	js := w.ReportServices.NatsJS
+	consumerConfig := &nats.ConsumerConfig{
+		Durable:       reportConsumer,
+		FilterSubject: reportSubjects,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       reportAckWait,
+		MaxAckPending: w.maxInFlight,
 	}
 
-	// reportTask.CreatedAt is in microseconds
-	var taskCreatedAt time.Time
-	if reportTask.CreatedAt != 0 {
-		taskCreatedAt = time.UnixMicro(reportTask.CreatedAt)
-	} else {
-		taskCreatedAt = time.Now()
+	info, err := js.ConsumerInfo(reportStream, reportConsumer)
+	if err == nil && info.Config.DeliverSubject != "" {
+		// the consumer used to be a push-based queue subscription. A work queue stream does not allow
+		// overlapping consumers, so it has to be replaced rather than created alongside
+		log.Info().Str("consumer", reportConsumer).Msg("replacing push-based report consumer with pull-based one")
+		if err := js.DeleteConsumer(reportStream, reportConsumer); err != nil {
+			return nil, errors.Wrap(err, "failed to delete push-based report consumer")
+		}
+		err = nats.ErrConsumerNotFound
+	}
+	if errors.Is(err, nats.ErrConsumerNotFound) {
+		_, err = js.AddConsumer(reportStream, consumerConfig)
+		if err != nil && !strings.Contains(err.Error(), "already in use") {
+			// another instance may have created the consumer concurrently, which is fine
+			return nil, errors.Wrap(err, "failed to create report consumer")
+		}
+	} else if err != nil {
+		return nil, errors.Wrap(err, "failed to get report consumer info")
+	} else if info.Config.MaxAckPending != consumerConfig.MaxAckPending || info.Config.AckWait != consumerConfig.AckWait {
+		// the consumer outlives instances, so configuration changed since it has been created is applied on start.
+		// This version of the client has no UpdateConsumer, while adding a consumer which already exists updates it
+		log.Info().
+			Int("maxAckPending", consumerConfig.MaxAckPending).
+			Dur("ackWait", consumerConfig.AckWait).
+			Msg("updating report consumer configuration")
+		if _, err := js.AddConsumer(reportStream, consumerConfig); err != nil {
+			// the consumer still works with the previous configuration
+			log.Warn().Err(err).Msg("failed to update report consumer configuration")
+		}
 	}
 
-	tx, err := w.ReportServices.DB.BeginTx(ctx, nil)
+	// binding to an existing consumer, instead of letting the library create one, prevents the consumer
+	// from being deleted when this instance unsubscribes
+	sub, err := js.PullSubscribe(reportSubjects, reportConsumer, nats.Bind(reportStream, reportConsumer))
 	if err != nil {
-		return err
+		log.Err(err).Msg("failed to subscribe to " + reportSubjects)
+		return nil, err
 	}
-	intendedCommit := false
-	defer func() {
-		if !intendedCommit {
-			L.Warn().Msg("rolling back transaction due to error")
-			if err := tx.Rollback(); err != nil {
-				L.Error().Err(err).Msg("failed to rollback transaction")
+	return sub, nil
+}
+
+// Consumer fetches report tasks one at a time from sub and processes them, until ctx is canceled.
+func (w *Worker) Consumer(ctx context.Context, sub *nats.Subscription, ch chan error) error {
+	for {
+		msgs, err := sub.Fetch(1, nats.Context(ctx))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+				// no report task available within the fetch window
+				continue
+			}
+			ch <- errors.Wrap(err, "failed to fetch report task")
+			// back off from an unhealthy server
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return ctx.Err()
 			}
+			continue
 		}
-	}()
 
-	// calculate drop pattern hash for each report
-	for idx, report := range reportTask.Reports {
-		report.Drops = reportutil.MergeDropsByItemID(report.Drops)
+		for _, msg := range msgs {
+			w.inflight.Add(1)
+			observeDelivery(msg)
+			inflight := observability.ReportInFlight.WithLabelValues(msg.Subject)
+			inflight.Inc()
+			// processing is not bound to ctx, so that in-flight tasks are finished on shutdown
+			w.process(context.Background(), msg, ch)
+			inflight.Dec()
+			w.inflight.Done()
+		}
+	}
+}
+
+// observeDelivery records the delivery lag and redelivery of msg, per subject.
+func observeDelivery(msg *nats.Msg) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		return
+	}
+	observability.ReportDeliveryLag.
+		WithLabelValues(msg.Subject).
+		Observe(time.Since(meta.Timestamp).Seconds())
+	observability.ReportDeliveries.
+		WithLabelValues(msg.Subject, strconv.FormatBool(meta.NumDelivered > 1)).
+		Inc()
+}
 
-		dropPattern, created, err := w.ReportServices.DropPatternRepo.GetOrCreateDropPatternFromDrops(ctx, tx, report.Drops)
+// pollLag periodically exports the state of the shared consumer, and alerts if the backlog builds up, until ctx is
+// canceled. Every instance reports the same consumer-wide values, so they should be aggregated with max() rather
+// than sum(), and backlog alerts are deduplicated across instances by the cooldown of alerts.
+func (w *Worker) pollLag(ctx context.Context, ch chan error) {
+	ticker := time.NewTicker(reportLagPollInterval)
+	defer ticker.Stop()
+
+	for {
+		info, err := w.ReportServices.NatsJS.ConsumerInfo(reportStream, reportConsumer, nats.Context(ctx))
 		if err != nil {
-			return errors.Wrap(err, "failed to calculate drop pattern hash")
-		}
-		if created {
-			_, err := w.ReportServices.DropPatternElementRepo.CreateDropPatternElements(ctx, tx, dropPattern.PatternID, report.Drops)
-			if err != nil {
-				return errors.Wrap(err, "failed to create drop pattern elements")
+			if ctx.Err() != nil {
+				return
 			}
+			ch <- errors.Wrap(err, "failed to get report consumer info")
+		} else {
+			observability.ReportConsumerPending.WithLabelValues(info.Name).Set(float64(info.NumPending))
+			observability.ReportConsumerAckPending.WithLabelValues(info.Name).Set(float64(info.NumAckPending))
+			observability.ReportConsumerRedelivered.WithLabelValues(info.Name).Set(float64(info.NumRedelivered))
+			observability.ReportConsumerWaiting.WithLabelValues(info.Name).Set(float64(info.NumWaiting))
+			observability.ReportConsumerAckWait.WithLabelValues(info.Name).Set(info.Config.AckWait.Seconds())
+			w.Notifier.CheckReportBacklog(ctx, int(info.NumPending))
 		}
 
-		stage, err := w.ReportServices.StageRepo.GetStageByArkId(ctx, report.StageID)
-		if err != nil {
-			return errors.Wrap(err, "failed to get stage")
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
 		}
+	}
+}
 
-		reliability := violations.Reliability(idx)
+func (w *Worker) process(ctx context.Context, msg *nats.Msg, ch chan error) {
+	if msg.Subject == constant.ReportSubjectDeadLetter {
+		w.handleDeadLetter(ctx, msg, ch)
+		return
+	}
 
-		dropReport := &model.DropReport{
-			StageID:     stage.StageID,
-			PatternID:   dropPattern.PatternID,
-			Times:       report.Times,
-			CreatedAt:   &taskCreatedAt,
-			Reliability: reliability,
-			Server:      reportTask.Server,
-			AccountID:   reportTask.AccountID,
-		}
-		if err = w.ReportServices.DropReportRepo.CreateDropReport(ctx, tx, dropReport); err != nil {
-			return errors.Wrap(err, "failed to create drop report")
+	taskCtx, cancelTask := context.WithTimeout(ctx, reportAckWait)
+	inprogressInformer := time.AfterFunc(reportAckWait/2, func() {
+		if err := msg.InProgress(); err != nil {
+			log.Error().Err(err).Msg("failed to set msg InProgress")
 		}
+	})
+	// consumeErr decides whether the message is acked, redelivered or dead-lettered
+	var consumeErr error
+	defer func() {
+		inprogressInformer.Stop()
+		cancelTask()
+		w.settle(ctx, msg, consumeErr)
+	}()
+
+	start := time.Now()
+	defer func() {
+		observability.ReportConsumeDuration.
+			WithLabelValues().
+			Observe(time.Since(start).Seconds())
+	}()
+
+	process := &service.ReportProcess{Subject: msg.Subject, Data: msg.Data}
+	consumeErr = w.ReportServices.Pipeline.Process(taskCtx, process)
+	var taskId string
+	if process.Task != nil {
+		taskId = process.Task.TaskID
+	}
+	if consumeErr != nil {
+		log.Error().
+			Err(consumeErr).
+			Str("taskId", taskId).
+			Interface("reportTask", process.Task).
+			Msg("failed to consume report task")
+		ch <- consumeErr
+		return
+	}
 
-		observability.ReportReliability.WithLabelValues(strconv.Itoa(reliability), reportTask.Source).Inc()
+	log.Info().
+		Str("taskId", taskId).
+		Dur("duration", time.Since(start)).
+		Msg("report task processed successfully")
+}
+
+// settle acks msg if it has been consumed successfully. Otherwise, msg is redelivered until it has been delivered
+// for constant.ReportTaskMaxDeliveries times, after which it is dead-lettered.
+func (w *Worker) settle(ctx context.Context, msg *nats.Msg, consumeErr error) {
+	if consumeErr != nil {
+		attempts := 1
+		var sequence uint64
+		if meta, err := msg.Metadata(); err == nil {
+			attempts = int(meta.NumDelivered)
+			sequence = meta.Sequence.Stream
+		}
 
-		md5 := ""
-		if report.Metadata != nil && report.Metadata.MD5 != "" {
-			md5 = report.Metadata.MD5
+		if attempts < constant.ReportTaskMaxDeliveries {
+			if err := msg.Nak(); err != nil {
+				log.Error().Err(err).Msg("failed to nak")
+			}
+			return
 		}
-		if reportTask.IP == "" {
-			// FIXME: temporary hack; find why ip is empty
-			reportTask.IP = "127.0.0.1"
+
+		if err := w.ReportServices.DeadLetterReportTask(ctx, msg.Subject, sequence, msg.Data, consumeErr, attempts); err != nil {
+			// leave the message un-acked so it would be redelivered instead of being lost
+			log.Error().Err(err).Msg("failed to dead-letter report task")
+			return
 		}
-		if err = w.ReportServices.DropReportExtraRepo.CreateDropReportExtra(ctx, tx, &model.DropReportExtra{
-			ReportID: dropReport.ReportID,
-			IP:       reportTask.IP,
-			Source:   reportTask.Source,
-			Version:  reportTask.Version,
-			Metadata: report.Metadata,
-			MD5:      null.NewString(md5, md5 != ""),
-		}); err != nil {
-			return errors.Wrap(err, "failed to create drop report extra")
+	}
+
+	// ack synchronously, so the task is confirmed to be removed from the work queue and won't be processed
+	// again by another instance
+	if err := msg.AckSync(); err != nil {
+		log.Error().Err(err).Msg("failed to ack")
+	}
+}
+
+func (w *Worker) handleDeadLetter(ctx context.Context, msg *nats.Msg, ch chan error) {
+	deadLetter := &types.DeadLetterReportTask{}
+	if err := json.Unmarshal(msg.Data, deadLetter); err != nil {
+		ch <- errors.Wrap(err, "failed to unmarshal dead-lettered report task")
+		// a malformed dead letter could never be persisted, so there's no point of redelivering it
+		if err := msg.Term(); err != nil {
+			log.Error().Err(err).Msg("failed to term")
 		}
+		return
+	}
 
-		if err := w.ReportServices.Redis.Set(ctx, reportTask.TaskID, dropReport.ReportID, time.Hour*24).Err(); err != nil {
-			return errors.Wrap(err, "failed to set report id in redis")
+	if err := w.ReportServices.PersistDeadLetterReportTask(ctx, deadLetter); err != nil {
+		ch <- errors.Wrap(err, "failed to persist dead-lettered report task")
+		if err := msg.Nak(); err != nil {
+			log.Error().Err(err).Msg("failed to nak")
 		}
+		return
 	}
 
-	intendedCommit = true
-	return tx.Commit()
+	log.Warn().
+		Str("subject", deadLetter.Subject).
+		Str("error", deadLetter.Error).
+		Int("attempts", deadLetter.Attempts).
+		Msg("report task dead-lettered")
+
+	if err := msg.Ack(); err != nil {
+		log.Error().Err(err).Msg("failed to ack")
+	}
 }
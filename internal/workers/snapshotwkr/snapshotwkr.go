@@ -0,0 +1,68 @@
+package snapshotwkr
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+// snapshotTimeout is the timeout for generating and uploading the snapshot of a single server
+const snapshotTimeout = time.Minute * 30
+
+type WorkerDeps struct {
+	fx.In
+	DatasetSnapshotService *service.DatasetSnapshot
+	Scheduler              *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules generation of dataset snapshots, every config.Config SnapshotWorkerInterval by default, when
+// config.Config WorkerEnabled is true and object storage for snapshots has been configured. Snapshots are only
+// generated by the leader, and expired snapshots are pruned after each generation.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled || !deps.DatasetSnapshotService.Enabled() {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:       constant.JobSnapshot,
+		Spec:       func(conf *config.Config) string { return "@every " + conf.SnapshotWorkerInterval.String() },
+		Run:        w.do,
+		LeaderOnly: true,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	logger := log.With().Str("service", "worker:snapshot").Logger()
+
+	var lastErr error
+	for _, server := range constant.Servers {
+		func() {
+			runCtx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+			defer cancel()
+
+			if err := w.DatasetSnapshotService.GenerateSnapshot(runCtx, server); err != nil {
+				logger.Error().Err(err).Str("server", server).Msg("failed to generate dataset snapshot")
+				lastErr = err
+				return
+			}
+			if err := w.DatasetSnapshotService.PruneSnapshots(runCtx, server); err != nil {
+				logger.Error().Err(err).Str("server", server).Msg("failed to prune dataset snapshots")
+				lastErr = err
+			}
+		}()
+	}
+	return lastErr
+}
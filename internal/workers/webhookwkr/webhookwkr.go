@@ -0,0 +1,53 @@
+package webhookwkr
+
+import (
+	"context"
+
+	"github.com/rs/zerolog/log"
+	"go.uber.org/fx"
+
+	"github.com/penguin-statistics/backend-next/internal/config"
+	"github.com/penguin-statistics/backend-next/internal/constant"
+	"github.com/penguin-statistics/backend-next/internal/service"
+)
+
+type WorkerDeps struct {
+	fx.In
+	WebhookService *service.Webhook
+	Scheduler      *service.Scheduler
+}
+
+type Worker struct {
+	WorkerDeps
+}
+
+// Start schedules attempts of pending webhook deliveries, every config.Config WebhookDeliveryInterval by default,
+// when config.Config WorkerEnabled is true. Deliveries are claimed before attempted, so the job runs on every
+// instance instead of the leader only.
+func Start(conf *config.Config, deps WorkerDeps) error {
+	if !conf.WorkerEnabled {
+		return nil
+	}
+
+	w := &Worker{
+		WorkerDeps: deps,
+	}
+	return deps.Scheduler.Register(&service.Job{
+		Name:    constant.JobWebhook,
+		Spec:    func(conf *config.Config) string { return "@every " + conf.WebhookDeliveryInterval.String() },
+		Timeout: constant.WebhookDeliveryLease,
+		Run:     w.do,
+	})
+}
+
+func (w *Worker) do(ctx context.Context) error {
+	attempted, err := w.WebhookService.DeliverDue(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("service", "worker:webhook").Msg("failed to deliver webhook events")
+		return err
+	}
+	if attempted > 0 {
+		log.Debug().Str("service", "worker:webhook").Int("deliveries", attempted).Msg("webhook deliveries attempted")
+	}
+	return nil
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"os"
+
+	"github.com/penguin-statistics/backend-next/cmd/importer"
+	"github.com/penguin-statistics/backend-next/cmd/replay"
+	"github.com/penguin-statistics/backend-next/cmd/sdk"
 	"github.com/penguin-statistics/backend-next/cmd/service"
 )
 
+//go:generate swag init --parseDependency --parseInternal -g main.go -o docs
+
 // @title        Penguin Statistics API
 // @version      3.0.0
 // @description  This is the Penguin Statistics v3 Backend, implemented for best performance, scalability, and reliability.
@@ -24,5 +31,18 @@ import (
 // @name                        Authorization
 
 func main() {
-	service.Bootstrap()
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		importer.Bootstrap(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		replay.Bootstrap(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "sdk" {
+		sdk.Bootstrap(os.Args[2:])
+		return
+	}
+
+	service.Bootstrap(os.Args[1:])
 }